@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/livefire2015/ez-ledger/src/migrate"
 	"github.com/livefire2015/ez-ledger/src/models"
 	"github.com/livefire2015/ez-ledger/src/services"
 	"github.com/shopspring/decimal"
@@ -31,6 +32,11 @@ func main() {
 
 	ctx := context.Background()
 
+	// Refuse to run against a schema that is missing migrations
+	if err := migrate.RequireCurrent(ctx, db); err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize services
 	pointsRule := models.PointsEarningRule{
 		PointsPerDollar: decimal.NewFromFloat(0.01), // 1 point per dollar
@@ -46,7 +52,8 @@ func main() {
 		log.Fatal(err)
 	}
 
-	fmt.Println("=== EZ Ledger - Complete Flow Example ===\n")
+	fmt.Println("=== EZ Ledger - Complete Flow Example ===")
+	fmt.Println()
 
 	// Step 1: Record multiple transactions
 	fmt.Println("Step 1: Recording Transactions")
@@ -93,7 +100,7 @@ func main() {
 	// Show balances after transactions
 	report, _ := reconciliationService.GenerateReconciliationReport(ctx, tenantID)
 	fmt.Printf("\n  Balances after transactions:\n")
-	fmt.Printf("    Statement Balance: $%.2f\n", report.StatementBalance)
+	fmt.Printf("    Statement Balance: $%.2f\n", report.StatementBalance.InexactFloat64())
 	fmt.Printf("    Points Balance: %d points\n\n", report.PointsBalance)
 
 	// Step 2: Record a payment
@@ -124,7 +131,7 @@ func main() {
 	// Show balances after payment
 	report, _ = reconciliationService.GenerateReconciliationReport(ctx, tenantID)
 	fmt.Printf("  Balances after payment:\n")
-	fmt.Printf("    Statement Balance: $%.2f (reduced by payment)\n", report.StatementBalance)
+	fmt.Printf("    Statement Balance: $%.2f (reduced by payment)\n", report.StatementBalance.InexactFloat64())
 	fmt.Printf("    Points Balance: %d points (UNCHANGED - payments don't affect points!)\n\n", report.PointsBalance)
 
 	// Step 3: Record a refund
@@ -162,7 +169,7 @@ func main() {
 	// Show balances after refund
 	report, _ = reconciliationService.GenerateReconciliationReport(ctx, tenantID)
 	fmt.Printf("  Balances after refund:\n")
-	fmt.Printf("    Statement Balance: $%.2f\n", report.StatementBalance)
+	fmt.Printf("    Statement Balance: $%.2f\n", report.StatementBalance.InexactFloat64())
 	fmt.Printf("    Points Balance: %d points\n\n", report.PointsBalance)
 
 	// Step 4: Redeem points for statement credit
@@ -207,8 +214,18 @@ func main() {
 	fmt.Printf("\nTenant: %s\n", tenantID)
 	fmt.Printf("Report Generated: %s\n\n", report.ReportGeneratedAt.Format(time.RFC1123))
 
+	if report.IsReconciled() {
+		fmt.Printf("RECONCILIATION: no exceptions\n\n")
+	} else {
+		fmt.Printf("RECONCILIATION: %d exceptions\n", len(report.Exceptions))
+		for _, exception := range report.Exceptions {
+			fmt.Printf("  • %s: %s\n", exception.Type, exception.Detail)
+		}
+		fmt.Println()
+	}
+
 	fmt.Printf("STATEMENT LEDGER:\n")
-	fmt.Printf("  Current Balance: $%.2f\n", report.StatementBalance)
+	fmt.Printf("  Current Balance: $%.2f\n", report.StatementBalance.InexactFloat64())
 	fmt.Printf("  Last Activity: %s\n\n", report.LastStatementActivity.Format(time.RFC1123))
 
 	fmt.Printf("POINTS LEDGER:\n")
@@ -220,7 +237,7 @@ func main() {
 	fmt.Println("  • Payments: $200.00 → No points impact")
 	fmt.Println("  • Refunds: $75.50 → Deducted ~75 points")
 	fmt.Println("  • Redemptions: 300 points → $3.00 credit")
-	fmt.Printf("\n  Final Statement Balance: $%.2f\n", report.StatementBalance)
+	fmt.Printf("\n  Final Statement Balance: $%.2f\n", report.StatementBalance.InexactFloat64())
 	fmt.Printf("  Final Points Balance: %d points\n\n", report.PointsBalance)
 
 	fmt.Println("=== Example Complete ===")
@@ -0,0 +1,543 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/livefire2015/ez-ledger/src/migrate"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/services"
+	"github.com/shopspring/decimal"
+)
+
+const usage = `Usage: ezledger [-database URL] <command>
+
+Commands:
+  migrate up          Apply every pending migration
+  migrate down [N]    Roll back the newest N migrations (default 1)
+  migrate status      List migrations and whether they are applied
+  migrate baseline N  Record migrations up to N as applied without running
+                      them, for a schema created by hand with psql
+  rebuild [-card ID] [-repair]
+                      Replay the ledgers and report projections that differ
+  verify -tenant ID   Check a tenant's ledger hash chains for deleted or
+                      rewritten entries
+  expire-holds [-as-of DATE]
+                      Release authorization holds past their hold period
+  review-penalties [-as-of DATE]
+                      Apply the penalty APR after payments 60 days late
+                      and restore prior APRs after six on-time payments
+  age-delinquencies [-as-of DATE]
+                      Move cards through the delinquency aging buckets and
+                      charge off balances 180 days past due
+  recovery-report -from DATE -to DATE [-period month|quarter|year]
+                      [-tenant ID]
+                      Print charge-offs and recoveries by period and
+                      collections agency as CSV
+  import-clearing [-format fixed|csv] [-post-unmatched] FILE
+                      Settle a processor clearing file and print its
+                      exceptions as CSV
+  export-transactions -card ID (-cycle ID | -from DATE -to DATE)
+                      [-format ofx|qfx|csv|json] [-pending]
+                      Print a card's activity for download
+  payoff -card ID (-payment AMOUNT | -minimum | -by DATE)
+                      [-balance AMOUNT] [-as-of DATE] [-method adb|daily]
+                      Print a month-by-month payoff schedule
+
+The database URL defaults to $DATABASE_URL.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	databaseURL := flag.String("database", os.Getenv("DATABASE_URL"), "Postgres connection URL")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 || (args[0] == "migrate" && len(args) < 2) {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *databaseURL == "" {
+		fmt.Fprintln(os.Stderr, "ezledger: no database URL; set -database or DATABASE_URL")
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("postgres", *databaseURL)
+	if err != nil {
+		fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	switch args[0] {
+	case "migrate":
+		var migrator *migrate.Migrator
+		if migrator, err = migrate.New(db); err == nil {
+			err = runMigrate(ctx, migrator, args[1:])
+		}
+	case "rebuild":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runRebuild(ctx, services.NewProjectionService(db), args[1:])
+		}
+	case "verify":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runVerify(ctx, services.NewLedgerChainService(db), args[1:])
+		}
+	case "expire-holds":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runExpireHolds(ctx, services.NewAuthorizationService(db), args[1:])
+		}
+	case "review-penalties":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runReviewPenalties(ctx, services.NewPenaltyPricingService(db), args[1:])
+		}
+	case "age-delinquencies":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runAgeDelinquencies(ctx, services.NewDelinquencyService(db), args[1:])
+		}
+	case "recovery-report":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runRecoveryReport(ctx, services.NewRecoveryService(db), args[1:])
+		}
+	case "import-clearing":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runImportClearing(ctx, services.NewClearingImportService(db), args[1:])
+		}
+	case "export-transactions":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runExportTransactions(ctx, services.NewTransactionExportService(db), args[1:])
+		}
+	case "payoff":
+		if err = migrate.RequireCurrent(ctx, db); err == nil {
+			err = runPayoff(ctx, services.NewInterestService(db), args[1:])
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func runMigrate(ctx context.Context, migrator *migrate.Migrator, args []string) error {
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			fmt.Printf("applied  %03d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return err
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations to roll back: %s", args[1])
+			}
+			steps = n
+		}
+
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("rolled back  %03d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+
+	case "baseline":
+		if len(args) < 2 {
+			return fmt.Errorf("migrate baseline needs the last version already in the database")
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 1 {
+			return fmt.Errorf("invalid migration version: %s", args[1])
+		}
+
+		recorded, err := migrator.Baseline(ctx, version)
+		for _, migration := range recorded {
+			fmt.Printf("baselined  %03d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(recorded) == 0 {
+			fmt.Println("nothing to baseline")
+		}
+		return err
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+		for _, status := range statuses {
+			state, appliedAt := "pending", ""
+			if status.AppliedAt != nil {
+				state, appliedAt = "applied", status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			switch {
+			case status.ChecksumMismatch:
+				state = "changed since applied"
+			case status.Unknown:
+				state = "applied, not in this build"
+			}
+			fmt.Fprintf(w, "%03d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown migrate command: %s", args[0])
+	}
+}
+
+func runRebuild(ctx context.Context, service *services.ProjectionService, args []string) error {
+	flags := flag.NewFlagSet("rebuild", flag.ExitOnError)
+	cardID := flags.String("card", "", "Credit card ID; every card when empty")
+	repair := flags.Bool("repair", false, "Write rebuilt values over those that differ")
+	flags.Parse(args)
+
+	req := services.RebuildRequest{Repair: *repair}
+	if *cardID != "" {
+		id, err := uuid.Parse(*cardID)
+		if err != nil {
+			return fmt.Errorf("invalid card ID: %w", err)
+		}
+		req.CreditCardID = &id
+	}
+
+	report, err := service.Rebuild(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	for _, card := range report.Cards {
+		for _, diff := range card.Differences {
+			fmt.Println(diff)
+		}
+	}
+	fmt.Printf("%d cards replayed, %d differences, %d cards repaired\n",
+		len(report.Cards), report.Differences, report.Repaired)
+	return nil
+}
+
+func runVerify(ctx context.Context, service *services.LedgerChainService, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	tenant := flags.String("tenant", "", "Tenant ID")
+	flags.Parse(args)
+
+	tenantID, err := uuid.Parse(*tenant)
+	if err != nil {
+		return fmt.Errorf("invalid tenant ID: %w", err)
+	}
+
+	results, err := service.Verify(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	broken := 0
+	for _, result := range results {
+		fmt.Println(result)
+		if result.Unsealed > 0 {
+			fmt.Printf("  %d entries predate the chain and are not hashed\n", result.Unsealed)
+		}
+		if !result.IsIntact() {
+			broken++
+		}
+	}
+	if broken > 0 {
+		return fmt.Errorf("%d of %d chains are broken", broken, len(results))
+	}
+	return nil
+}
+
+func runExpireHolds(ctx context.Context, service *services.AuthorizationService, args []string) error {
+	flags := flag.NewFlagSet("expire-holds", flag.ExitOnError)
+	asOfFlag := flags.String("as-of", "", "Expire holds as of this date (YYYY-MM-DD); now when empty")
+	flags.Parse(args)
+
+	asOf := time.Now()
+	if *asOfFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *asOfFlag)
+		if err != nil {
+			return fmt.Errorf("invalid date: %w", err)
+		}
+		asOf = parsed
+	}
+
+	expired, err := service.ExpireAuthorizations(ctx, asOf)
+	if err != nil {
+		return err
+	}
+
+	for _, authorization := range expired {
+		fmt.Printf("expired %s (%s) on card %s\n",
+			authorization.AuthorizationCode, authorization.MerchantName, authorization.CreditCardID)
+	}
+	fmt.Printf("%d holds expired\n", len(expired))
+	return nil
+}
+
+func runReviewPenalties(ctx context.Context, service *services.PenaltyPricingService, args []string) error {
+	flags := flag.NewFlagSet("review-penalties", flag.ExitOnError)
+	asOfFlag := flags.String("as-of", "", "Review cards as of this date (YYYY-MM-DD); now when empty")
+	flags.Parse(args)
+
+	asOf := time.Now()
+	if *asOfFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *asOfFlag)
+		if err != nil {
+			return fmt.Errorf("invalid date: %w", err)
+		}
+		asOf = parsed
+	}
+
+	// Events recorded before a failure are still reported
+	events, err := service.ReviewPenaltyPricing(ctx, asOf, services.DefaultPenaltyPricingConfig())
+	for _, event := range events {
+		if event.IsApplied() {
+			fmt.Printf("penalty APR %s%% from %s on card %s (%s)\n", event.PenaltyAPR.StringFixed(2),
+				event.EffectiveDate.Format("2006-01-02"), event.CreditCardID, event.Trigger)
+		} else {
+			fmt.Printf("penalty APR cured on card %s after %d on-time payments\n",
+				event.CreditCardID, event.OnTimePayments)
+		}
+	}
+	fmt.Printf("%d penalty events recorded\n", len(events))
+	return err
+}
+
+func runAgeDelinquencies(ctx context.Context, service *services.DelinquencyService, args []string) error {
+	flags := flag.NewFlagSet("age-delinquencies", flag.ExitOnError)
+	asOfFlag := flags.String("as-of", "", "Age cards as of this date (YYYY-MM-DD); now when empty")
+	flags.Parse(args)
+
+	asOf := time.Now()
+	if *asOfFlag != "" {
+		parsed, err := time.Parse("2006-01-02", *asOfFlag)
+		if err != nil {
+			return fmt.Errorf("invalid date: %w", err)
+		}
+		asOf = parsed
+	}
+
+	// Cards that aged before a failure are still reported
+	results, err := service.AgeDelinquencies(ctx, asOf)
+	for _, result := range results {
+		if result.Record.ChargeOffAmount.IsPositive() {
+			fmt.Printf("card %s charged off at %d days past due: %s\n", result.CreditCardID,
+				result.DaysPastDue, result.Record.ChargeOffAmount.StringFixed(2))
+		} else {
+			fmt.Printf("card %s moved from %s to %s (%d days past due)\n", result.CreditCardID,
+				result.Record.PreviousBucket, result.Bucket, result.DaysPastDue)
+		}
+	}
+	fmt.Printf("%d cards changed bucket\n", len(results))
+	return err
+}
+
+func runRecoveryReport(ctx context.Context, service *services.RecoveryService, args []string) error {
+	flags := flag.NewFlagSet("recovery-report", flag.ExitOnError)
+	fromFlag := flags.String("from", "", "First date (YYYY-MM-DD)")
+	toFlag := flags.String("to", "", "Last date (YYYY-MM-DD)")
+	period := flags.String("period", string(services.RecoveryPeriodMonth), "Group by month, quarter or year")
+	tenantID := flags.String("tenant", "", "Tenant ID; every tenant when empty")
+	flags.Parse(args)
+
+	req := services.RecoveryReportRequest{Period: services.RecoveryPeriod(*period)}
+	var err error
+	if req.From, err = time.Parse("2006-01-02", *fromFlag); err != nil {
+		return fmt.Errorf("invalid from date: %w", err)
+	}
+	if req.To, err = time.Parse("2006-01-02", *toFlag); err != nil {
+		return fmt.Errorf("invalid to date: %w", err)
+	}
+	if *tenantID != "" {
+		id, err := uuid.Parse(*tenantID)
+		if err != nil {
+			return fmt.Errorf("invalid tenant ID: %w", err)
+		}
+		req.TenantID = &id
+	}
+
+	report, err := service.RecoveryReport(ctx, req)
+	if err != nil {
+		return err
+	}
+	return report.WriteCSV(os.Stdout)
+}
+
+func runImportClearing(ctx context.Context, service *services.ClearingImportService, args []string) error {
+	flags := flag.NewFlagSet("import-clearing", flag.ExitOnError)
+	format := flags.String("format", string(services.ClearingFixedWidth), "File layout: fixed or csv")
+	postUnmatched := flags.Bool("post-unmatched", false, "Post sales with no authorization or pending entry")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("import-clearing takes one clearing file")
+	}
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	report, err := service.Import(ctx, file, services.ClearingImportRequest{
+		Format:        services.ClearingFormat(*format),
+		PostUnmatched: *postUnmatched,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := report.WriteExceptionsCSV(os.Stdout); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%d records: %d captured, %d cleared, %d refunded, %d posted, %d exceptions; %s settled\n",
+		report.Records,
+		report.Applied[services.ClearingCaptured], report.Applied[services.ClearingCleared],
+		report.Applied[services.ClearingRefunded], report.Applied[services.ClearingPosted],
+		len(report.Exceptions), report.Settled.StringFixed(2))
+	return nil
+}
+
+func runExportTransactions(ctx context.Context, service *services.TransactionExportService, args []string) error {
+	flags := flag.NewFlagSet("export-transactions", flag.ExitOnError)
+	cardID := flags.String("card", "", "Credit card ID")
+	cycleID := flags.String("cycle", "", "Billing cycle ID; overrides -from and -to")
+	fromFlag := flags.String("from", "", "First posting date (YYYY-MM-DD)")
+	toFlag := flags.String("to", "", "Last posting date (YYYY-MM-DD)")
+	format := flags.String("format", string(services.TransactionExportCSV), "Output format: ofx, qfx, csv or json")
+	pending := flags.Bool("pending", false, "Include pending transactions")
+	org := flags.String("org", "", "OFX institution name (FI/ORG)")
+	fid := flags.String("fid", "", "OFX institution ID (FI/FID)")
+	bankID := flags.String("intu-bid", "", "Intuit bank ID, required for qfx")
+	flags.Parse(args)
+
+	req := services.TransactionExportRequest{IncludePending: *pending}
+	var err error
+	if req.CreditCardID, err = uuid.Parse(*cardID); err != nil {
+		return fmt.Errorf("invalid card ID: %w", err)
+	}
+	if *cycleID != "" {
+		id, err := uuid.Parse(*cycleID)
+		if err != nil {
+			return fmt.Errorf("invalid billing cycle ID: %w", err)
+		}
+		req.BillingCycleID = &id
+	} else {
+		if req.From, err = time.Parse("2006-01-02", *fromFlag); err != nil {
+			return fmt.Errorf("invalid from date: %w", err)
+		}
+		if req.To, err = time.Parse("2006-01-02", *toFlag); err != nil {
+			return fmt.Errorf("invalid to date: %w", err)
+		}
+	}
+
+	export, err := service.Export(ctx, req)
+	if err != nil {
+		return err
+	}
+	return export.Write(os.Stdout, services.TransactionExportFormat(*format), services.OFXInstitution{
+		Organization: *org,
+		ID:           *fid,
+		IntuitBankID: *bankID,
+	})
+}
+
+func runPayoff(ctx context.Context, service *services.InterestService, args []string) error {
+	flags := flag.NewFlagSet("payoff", flag.ExitOnError)
+	cardID := flags.String("card", "", "Credit card ID")
+	balanceFlag := flags.String("balance", "", "Balance to pay off; the card's cleared balance when empty")
+	asOfFlag := flags.String("as-of", "", "Start date (YYYY-MM-DD); payments fall on this day each month; today when empty")
+	paymentFlag := flags.String("payment", "", "Pay this amount every month")
+	minimum := flags.Bool("minimum", false, "Pay only the minimum payment")
+	byFlag := flags.String("by", "", "Pay off by this date (YYYY-MM-DD)")
+	method := flags.String("method", "adb", "Interest method: adb or daily")
+	flags.Parse(args)
+
+	req := services.PayoffRequest{AsOf: time.Now()}
+	var err error
+	if req.CreditCardID, err = uuid.Parse(*cardID); err != nil {
+		return fmt.Errorf("invalid card ID: %w", err)
+	}
+	if *balanceFlag != "" {
+		if req.Balance, err = decimal.NewFromString(*balanceFlag); err != nil {
+			return fmt.Errorf("invalid balance: %w", err)
+		}
+	}
+	if *asOfFlag != "" {
+		if req.AsOf, err = time.Parse("2006-01-02", *asOfFlag); err != nil {
+			return fmt.Errorf("invalid as-of date: %w", err)
+		}
+	}
+	switch *method {
+	case "adb":
+		req.Method = services.AverageDailyBalanceMethod
+	case "daily":
+		req.Method = services.DailyBalanceMethod
+	default:
+		return fmt.Errorf("unknown interest method: %s", *method)
+	}
+
+	switch {
+	case *paymentFlag != "":
+		req.Strategy = models.PayoffStrategyFixedPayment
+		if req.MonthlyPayment, err = decimal.NewFromString(*paymentFlag); err != nil {
+			return fmt.Errorf("invalid payment: %w", err)
+		}
+	case *minimum:
+		req.Strategy = models.PayoffStrategyMinimumOnly
+	case *byFlag != "":
+		req.Strategy = models.PayoffStrategyTargetDate
+		if req.TargetDate, err = time.Parse("2006-01-02", *byFlag); err != nil {
+			return fmt.Errorf("invalid target date: %w", err)
+		}
+	default:
+		return fmt.Errorf("payoff needs -payment, -minimum or -by")
+	}
+
+	schedule, err := service.CalculateCardPayoff(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Month\tDate\tAPR\tOpening\tInterest\tPayment\tPrincipal\tClosing\t")
+	for _, month := range schedule.Schedule {
+		fmt.Fprintf(w, "%d\t%s\t%s%%\t%s\t%s\t%s\t%s\t%s\t\n",
+			month.Month, month.PaymentDate.Format("2006-01-02"), month.APR.StringFixed(2),
+			month.OpeningBalance.StringFixed(2), month.Interest.StringFixed(2), month.Payment.StringFixed(2),
+			month.Principal.StringFixed(2), month.ClosingBalance.StringFixed(2))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if schedule.NeverPaysOff {
+		fmt.Printf("%s a month never pays off %s: the balance grows\n",
+			schedule.MonthlyPayment.StringFixed(2), schedule.Balance.StringFixed(2))
+		return nil
+	}
+	fmt.Printf("%s paid off in %d months on %s: %s interest, %s paid in total\n",
+		schedule.Balance.StringFixed(2), schedule.Months, schedule.PayoffDate.Format("2006-01-02"),
+		schedule.TotalInterest.StringFixed(2), schedule.TotalPaid.StringFixed(2))
+	return nil
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "ezledger:", err)
+	os.Exit(1)
+}
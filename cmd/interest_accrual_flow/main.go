@@ -9,6 +9,7 @@ import (
 
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
+	"github.com/livefire2015/ez-ledger/src/migrate"
 	"github.com/livefire2015/ez-ledger/src/models"
 	"github.com/livefire2015/ez-ledger/src/services"
 	"github.com/shopspring/decimal"
@@ -24,6 +25,11 @@ func main() {
 
 	ctx := context.Background()
 
+	// Refuse to run against a schema that is missing migrations
+	if err := migrate.RequireCurrent(ctx, db); err != nil {
+		log.Fatal(err)
+	}
+
 	// Initialize services
 	billingService := services.NewBillingService(db)
 	reconciliationService := services.NewLedgerReconciliationService(db, models.PointsEarningRule{
@@ -58,7 +64,8 @@ func main() {
 		log.Fatal(err)
 	}
 
-	fmt.Println("=== EZ Ledger - Interest Accrual Flow Example ===\n")
+	fmt.Println("=== EZ Ledger - Interest Accrual Flow Example ===")
+	fmt.Println()
 
 	// --- Cycle 1: Open -> Closed -> Paid Full ---
 	fmt.Println("--- Cycle 1: Paid In Full ---")
@@ -98,7 +105,7 @@ func main() {
 	if err != nil {
 		log.Fatal(fmt.Errorf("generate statement 1: %w", err))
 	}
-	fmt.Printf("Cycle 1 Closed. New Balance: $%.2f. Due Date: %s\n", stmt1.BillingCycle.NewBalance, stmt1.BillingCycle.DueDate.Format("2006-01-02"))
+	fmt.Printf("Cycle 1 Closed. New Balance: $%.2f. Due Date: %s\n", stmt1.BillingCycle.NewBalance.InexactFloat64(), stmt1.BillingCycle.DueDate.Format("2006-01-02"))
 
 	// Pay Full in Cycle 1 (before due date)
 	paymentDate1 := stmt1.BillingCycle.DueDate.AddDate(0, 0, -5)
@@ -147,8 +154,8 @@ func main() {
 	}
 
 	// Check Interest for Cycle 2 (Should be 0 because Cycle 1 was paid in full)
-	fmt.Printf("Cycle 2 Interest: $%.2f (Expected: 0.00)\n", stmt2.BillingCycle.InterestAmount)
-	fmt.Printf("Cycle 2 Closed. New Balance: $%.2f. Min Payment: $%.2f\n", stmt2.BillingCycle.NewBalance, stmt2.BillingCycle.MinimumPayment)
+	fmt.Printf("Cycle 2 Interest: $%.2f (Expected: 0.00)\n", stmt2.BillingCycle.InterestAmount.InexactFloat64())
+	fmt.Printf("Cycle 2 Closed. New Balance: $%.2f. Min Payment: $%.2f\n", stmt2.BillingCycle.NewBalance.InexactFloat64(), stmt2.BillingCycle.MinimumPayment.InexactFloat64())
 
 	// Pay Minimum in Cycle 2
 	paymentDate2 := stmt2.BillingCycle.DueDate.AddDate(0, 0, -2)
@@ -196,8 +203,8 @@ func main() {
 	}
 
 	// Check Interest for Cycle 3 (Should be > 0 because Cycle 2 was NOT paid in full)
-	fmt.Printf("Cycle 3 Interest: $%.2f (Expected: > 0.00)\n", stmt3.BillingCycle.InterestAmount)
-	fmt.Printf("Cycle 3 Closed. New Balance: $%.2f. Due Date: %s\n", stmt3.BillingCycle.NewBalance, stmt3.BillingCycle.DueDate.Format("2006-01-02"))
+	fmt.Printf("Cycle 3 Interest: $%.2f (Expected: > 0.00)\n", stmt3.BillingCycle.InterestAmount.InexactFloat64())
+	fmt.Printf("Cycle 3 Closed. New Balance: $%.2f. Due Date: %s\n", stmt3.BillingCycle.NewBalance.InexactFloat64(), stmt3.BillingCycle.DueDate.Format("2006-01-02"))
 
 	// Simulate passing of due date without payment
 	// We need to manually update the due date in DB to be in the past to test "CheckAndAssessLatePaymentFees"
@@ -218,7 +225,7 @@ func main() {
 
 	if len(results) > 0 {
 		fmt.Printf("Late Fees Assessed: %d\n", len(results))
-		fmt.Printf("Fee Amount: $%.2f\n", results[0].FeeAmount)
+		fmt.Printf("Fee Amount: $%.2f\n", results[0].FeeAmount.InexactFloat64())
 	} else {
 		fmt.Println("No late fees assessed (Unexpected if logic is correct)")
 	}
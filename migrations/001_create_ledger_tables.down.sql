@@ -0,0 +1,16 @@
+-- Migration: 001_create_ledger_tables.down.sql
+-- Description: Drop the statement and points ledgers and tenants
+
+DROP VIEW IF EXISTS points_balances;
+DROP VIEW IF EXISTS statement_balances;
+
+DROP TABLE IF EXISTS points_ledger_entries;
+DROP TABLE IF EXISTS statement_ledger_entries;
+DROP TABLE IF EXISTS statements;
+DROP TABLE IF EXISTS tenants;
+
+DROP TYPE IF EXISTS points_entry_type;
+DROP TYPE IF EXISTS statement_entry_type;
+
+DROP FUNCTION IF EXISTS update_updated_at_column();
+DROP FUNCTION IF EXISTS prevent_ledger_entry_update();
@@ -0,0 +1,14 @@
+-- Migration: 002_create_credit_card_tables.down.sql
+-- Description: Drop credit card, billing cycle and cashback tables
+-- Postgres cannot remove enum values, so the statement entry types added by
+-- 002 stay until 001 is rolled back.
+
+DROP VIEW IF EXISTS credit_card_summaries;
+DROP VIEW IF EXISTS cashback_balances;
+
+DROP TABLE IF EXISTS cashback_categories;
+DROP TABLE IF EXISTS cashback_ledger_entries;
+DROP TABLE IF EXISTS billing_cycles;
+DROP TABLE IF EXISTS credit_cards;
+
+DROP TYPE IF EXISTS cashback_entry_type;
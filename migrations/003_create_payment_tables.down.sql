@@ -0,0 +1,16 @@
+-- Migration: 003_create_payment_tables.down.sql
+-- Description: Drop payment tables
+
+DROP VIEW IF EXISTS payment_summaries;
+
+DROP TRIGGER IF EXISTS log_payment_status_change ON payments;
+DROP FUNCTION IF EXISTS log_payment_status_transition();
+
+DROP TABLE IF EXISTS scheduled_payments;
+DROP TABLE IF EXISTS ach_return_codes;
+DROP TABLE IF EXISTS payment_status_transitions;
+DROP TABLE IF EXISTS payments;
+
+DROP TYPE IF EXISTS payment_type;
+DROP TYPE IF EXISTS payment_method;
+DROP TYPE IF EXISTS payment_status;
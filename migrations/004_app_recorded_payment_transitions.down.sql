@@ -0,0 +1,29 @@
+-- Migration: 004_app_recorded_payment_transitions.down.sql
+-- Description: Restore the payment status transition trigger from 003
+
+CREATE OR REPLACE FUNCTION log_payment_status_transition()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF OLD.status IS DISTINCT FROM NEW.status THEN
+        INSERT INTO payment_status_transitions (
+            payment_id,
+            from_status,
+            to_status,
+            transition_at,
+            triggered_by
+        ) VALUES (
+            NEW.id,
+            OLD.status,
+            NEW.status,
+            NOW(),
+            COALESCE(NEW.updated_by, 'system')
+        );
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER log_payment_status_change
+    AFTER UPDATE ON payments
+    FOR EACH ROW
+    EXECUTE FUNCTION log_payment_status_transition();
@@ -0,0 +1,11 @@
+-- Migration: 004_app_recorded_payment_transitions.sql
+-- Description: Stop logging payment status transitions from a trigger
+-- PaymentService now records each transition in the same transaction as the
+-- payment update, with its reason, actor and metadata. Keeping the trigger
+-- would write every transition twice.
+
+-- ============================================
+-- TRIGGERS
+-- ============================================
+DROP TRIGGER IF EXISTS log_payment_status_change ON payments;
+DROP FUNCTION IF EXISTS log_payment_status_transition();
@@ -0,0 +1,4 @@
+-- Migration: 005_create_idempotency_keys.down.sql
+-- Description: Drop stored idempotency keys
+
+DROP TABLE IF EXISTS idempotency_keys;
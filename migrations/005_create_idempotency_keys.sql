@@ -0,0 +1,27 @@
+-- Migration: 005_create_idempotency_keys.sql
+-- Description: Store idempotency keys for money-moving service calls
+-- A retried request with a key the tenant already used replays the stored
+-- response instead of posting a second set of ledger entries.
+
+-- ============================================
+-- IDEMPOTENCY KEYS TABLE
+-- ============================================
+CREATE TABLE idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+
+    idempotency_key VARCHAR(255) NOT NULL,
+    operation VARCHAR(50) NOT NULL,  -- 'record_transaction', 'record_payment', etc.
+    response JSONB NOT NULL,         -- Result returned to the original caller
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    UNIQUE(tenant_id, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_keys_created ON idempotency_keys(created_at);
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE idempotency_keys IS 'Results of idempotent calls, replayed when a request is retried with the same key';
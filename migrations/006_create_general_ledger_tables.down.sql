@@ -0,0 +1,11 @@
+-- Migration: 006_create_general_ledger_tables.down.sql
+-- Description: Drop the general ledger
+
+DROP VIEW IF EXISTS trial_balance;
+
+DROP TABLE IF EXISTS journal_lines;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS gl_accounts;
+
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+DROP TYPE IF EXISTS gl_account_type;
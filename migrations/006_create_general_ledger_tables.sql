@@ -0,0 +1,125 @@
+-- Migration: 006_create_general_ledger_tables.sql
+-- Description: Double-entry general ledger behind the statement and cashback ledgers
+-- Supports: Chart of accounts, balanced journal postings, trial balance
+
+-- ============================================
+-- CHART OF ACCOUNTS
+-- ============================================
+CREATE TYPE gl_account_type AS ENUM (
+    'asset',
+    'liability',
+    'income',
+    'expense'
+);
+
+CREATE TABLE gl_accounts (
+    code VARCHAR(10) PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    account_type gl_account_type NOT NULL
+);
+
+INSERT INTO gl_accounts (code, name, account_type) VALUES
+    ('1000', 'Cash Clearing', 'asset'),            -- Funds in transit with the card network and banks
+    ('1100', 'Card Receivable', 'asset'),          -- Balances owed by cardholders
+    ('2100', 'Cashback Liability', 'liability'),   -- Cashback earned but not yet redeemed
+    ('4000', 'Interest Income', 'income'),
+    ('4100', 'Fee Income', 'income'),
+    ('5000', 'Charge-off Expense', 'expense'),
+    ('5100', 'Rewards Expense', 'expense'),        -- Cost of cashback earned, net of refunds and expiry
+    ('5200', 'Balance Adjustments', 'expense');    -- Manual credits and debits to cardholder balances
+
+-- ============================================
+-- JOURNAL ENTRIES TABLE
+-- ============================================
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+
+    -- Source ledger entry ('statement' or 'cashback')
+    source_type VARCHAR(20) NOT NULL,
+    source_id UUID NOT NULL,
+
+    entry_date DATE NOT NULL,
+    description TEXT NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    -- One journal entry per source ledger entry
+    UNIQUE(source_type, source_id)
+);
+
+CREATE INDEX idx_journal_entries_tenant ON journal_entries(tenant_id);
+CREATE INDEX idx_journal_entries_date ON journal_entries(entry_date);
+
+-- ============================================
+-- JOURNAL LINES TABLE
+-- ============================================
+CREATE TABLE journal_lines (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    journal_entry_id UUID NOT NULL REFERENCES journal_entries(id),
+    line_number INTEGER NOT NULL,
+    account_code VARCHAR(10) NOT NULL REFERENCES gl_accounts(code),
+
+    debit DECIMAL(15,2) NOT NULL DEFAULT 0,
+    credit DECIMAL(15,2) NOT NULL DEFAULT 0,
+
+    UNIQUE(journal_entry_id, line_number),
+
+    -- Each line is either a debit or a credit
+    CONSTRAINT one_sided_line CHECK (
+        debit >= 0 AND credit >= 0 AND (debit = 0) <> (credit = 0)
+    )
+);
+
+CREATE INDEX idx_journal_lines_entry ON journal_lines(journal_entry_id);
+CREATE INDEX idx_journal_lines_account ON journal_lines(account_code);
+
+-- ============================================
+-- TRIGGERS
+-- ============================================
+
+-- Reject unbalanced journal entries at commit time
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (
+        SELECT COALESCE(SUM(debit), 0) <> COALESCE(SUM(credit), 0)
+        FROM journal_lines
+        WHERE journal_entry_id = NEW.journal_entry_id
+    ) THEN
+        RAISE EXCEPTION 'journal entry % is not balanced', NEW.journal_entry_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER journal_entry_balanced
+    AFTER INSERT OR UPDATE ON journal_lines
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+    EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- ============================================
+-- VIEWS
+-- ============================================
+
+-- Trial balance across all tenants
+CREATE VIEW trial_balance AS
+SELECT
+    a.code,
+    a.name,
+    a.account_type,
+    COALESCE(SUM(l.debit), 0) as total_debits,
+    COALESCE(SUM(l.credit), 0) as total_credits
+FROM gl_accounts a
+LEFT JOIN journal_lines l ON l.account_code = a.code
+GROUP BY a.code, a.name, a.account_type
+ORDER BY a.code;
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE gl_accounts IS 'Chart of accounts for the double-entry general ledger';
+COMMENT ON TABLE journal_entries IS 'Balanced postings generated from statement and cashback ledger entries';
+COMMENT ON TABLE journal_lines IS 'Debit and credit lines of each journal entry';
+COMMENT ON VIEW trial_balance IS 'Debit and credit totals per account; total debits equal total credits';
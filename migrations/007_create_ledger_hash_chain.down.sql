@@ -0,0 +1,19 @@
+-- Migration: 007_create_ledger_hash_chain.down.sql
+-- Description: Drop the ledger hash chain
+
+DROP TABLE IF EXISTS ledger_chain_heads;
+
+ALTER TABLE points_ledger_entries
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS sequence;
+
+ALTER TABLE cashback_ledger_entries
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS sequence;
+
+ALTER TABLE statement_ledger_entries
+    DROP COLUMN IF EXISTS hash,
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS sequence;
@@ -0,0 +1,93 @@
+-- Migration: 007_create_ledger_hash_chain.sql
+-- Description: Tamper-evident hash chain over the statement, cashback and points ledgers
+-- Each entry gets a per-tenant sequence number and a SHA-256 hash covering its
+-- immutable fields and the previous entry's hash. Deleting or rewriting a row
+-- breaks the chain, which LedgerChainService.Verify reports.
+--
+-- Entries that already exist are numbered in creation order but not hashed:
+-- their canonical encoding is computed by the application. The chain starts
+-- at the first entry written after this migration.
+
+-- ============================================
+-- CHAIN COLUMNS
+-- ============================================
+ALTER TABLE statement_ledger_entries
+    ADD COLUMN sequence BIGINT,
+    ADD COLUMN prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN hash VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE cashback_ledger_entries
+    ADD COLUMN sequence BIGINT,
+    ADD COLUMN prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN hash VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE points_ledger_entries
+    ADD COLUMN sequence BIGINT,
+    ADD COLUMN prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN hash VARCHAR(64) NOT NULL DEFAULT '';
+
+-- Number existing entries; the immutability triggers are lifted for the backfill only
+ALTER TABLE statement_ledger_entries DISABLE TRIGGER prevent_statement_entry_update;
+UPDATE statement_ledger_entries e SET sequence = n.sequence
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY tenant_id ORDER BY created_at, id) AS sequence
+    FROM statement_ledger_entries
+) n
+WHERE e.id = n.id;
+ALTER TABLE statement_ledger_entries ENABLE TRIGGER prevent_statement_entry_update;
+
+ALTER TABLE cashback_ledger_entries DISABLE TRIGGER prevent_cashback_entry_update;
+UPDATE cashback_ledger_entries e SET sequence = n.sequence
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY tenant_id ORDER BY created_at, id) AS sequence
+    FROM cashback_ledger_entries
+) n
+WHERE e.id = n.id;
+ALTER TABLE cashback_ledger_entries ENABLE TRIGGER prevent_cashback_entry_update;
+
+ALTER TABLE points_ledger_entries DISABLE TRIGGER prevent_points_entry_update;
+UPDATE points_ledger_entries e SET sequence = n.sequence
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY tenant_id ORDER BY created_at, id) AS sequence
+    FROM points_ledger_entries
+) n
+WHERE e.id = n.id;
+ALTER TABLE points_ledger_entries ENABLE TRIGGER prevent_points_entry_update;
+
+ALTER TABLE statement_ledger_entries
+    ALTER COLUMN sequence SET NOT NULL,
+    ADD CONSTRAINT statement_entries_tenant_sequence UNIQUE (tenant_id, sequence);
+
+ALTER TABLE cashback_ledger_entries
+    ALTER COLUMN sequence SET NOT NULL,
+    ADD CONSTRAINT cashback_entries_tenant_sequence UNIQUE (tenant_id, sequence);
+
+ALTER TABLE points_ledger_entries
+    ALTER COLUMN sequence SET NOT NULL,
+    ADD CONSTRAINT points_entries_tenant_sequence UNIQUE (tenant_id, sequence);
+
+-- ============================================
+-- CHAIN HEADS TABLE
+-- ============================================
+-- The newest link of each chain. Appends lock the head row, and the verifier
+-- compares it with the newest entry to detect deleted entries at the end.
+CREATE TABLE ledger_chain_heads (
+    ledger VARCHAR(20) NOT NULL, -- 'statement', 'cashback' or 'points'
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    sequence BIGINT NOT NULL,
+    hash VARCHAR(64) NOT NULL,
+
+    PRIMARY KEY (ledger, tenant_id)
+);
+
+INSERT INTO ledger_chain_heads (ledger, tenant_id, sequence, hash)
+SELECT 'statement', tenant_id, MAX(sequence), '' FROM statement_ledger_entries GROUP BY tenant_id
+UNION ALL
+SELECT 'cashback', tenant_id, MAX(sequence), '' FROM cashback_ledger_entries GROUP BY tenant_id
+UNION ALL
+SELECT 'points', tenant_id, MAX(sequence), '' FROM points_ledger_entries GROUP BY tenant_id;
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE ledger_chain_heads IS 'Newest sequence number and hash of each tenant''s ledger hash chain';
@@ -0,0 +1,5 @@
+-- Migration: 008_add_billing_cycle_segments.down.sql
+-- Description: Drop the per-segment interest breakdown
+
+ALTER TABLE billing_cycles
+    DROP COLUMN IF EXISTS segments;
@@ -0,0 +1,8 @@
+-- Migration: 008_add_billing_cycle_segments.sql
+-- Description: Per-segment interest breakdown on billing cycles
+-- Supports: Purchase, cash advance, balance transfer and promotional APRs
+
+-- One element per balance segment: segment, apr, average_daily_balance,
+-- closing_balance, interest_charge, grace_period_applied, interest_entry_id
+ALTER TABLE billing_cycles
+    ADD COLUMN segments JSONB;
@@ -0,0 +1,13 @@
+-- Migration: 009_add_balance_transfers.down.sql
+-- Description: Drop balance transfer columns
+-- Postgres cannot drop enum values, so the balance_transfer and
+-- fee_balance_transfer entry types remain
+
+ALTER TABLE billing_cycles
+    DROP COLUMN IF EXISTS balance_transfers_amount;
+
+ALTER TABLE credit_cards
+    DROP COLUMN IF EXISTS balance_transfer_fee_rate,
+    DROP COLUMN IF EXISTS balance_transfer_fee,
+    DROP COLUMN IF EXISTS balance_transfer_end_date,
+    DROP COLUMN IF EXISTS balance_transfer_apr;
@@ -0,0 +1,15 @@
+-- Migration: 009_add_balance_transfers.sql
+-- Description: Balance transfers with a promotional APR and a transfer fee
+-- Supports: Moving debt onto a card, promo expiry rolling into purchases
+
+ALTER TYPE statement_entry_type ADD VALUE IF NOT EXISTS 'balance_transfer';
+ALTER TYPE statement_entry_type ADD VALUE IF NOT EXISTS 'fee_balance_transfer';
+
+ALTER TABLE credit_cards
+    ADD COLUMN balance_transfer_apr DECIMAL(5,2) NOT NULL DEFAULT 0.00,      -- Promotional APR on transferred balances
+    ADD COLUMN balance_transfer_end_date DATE,                               -- When the promo expires; NULL if none is active
+    ADD COLUMN balance_transfer_fee DECIMAL(10,2) NOT NULL DEFAULT 5.00,     -- Flat fee minimum
+    ADD COLUMN balance_transfer_fee_rate DECIMAL(5,2) NOT NULL DEFAULT 3.00; -- Percentage rate
+
+ALTER TABLE billing_cycles
+    ADD COLUMN balance_transfers_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00;
@@ -0,0 +1,6 @@
+-- Migration: 010_create_authorizations.down.sql
+-- Description: Drop card authorizations
+
+DROP TABLE IF EXISTS authorizations;
+
+DROP TYPE IF EXISTS authorization_status;
@@ -0,0 +1,66 @@
+-- Migration: 010_create_authorizations.sql
+-- Description: Card authorization holds placed before settlement
+-- Supports: Incremental authorization, partial and complete capture, reversal, expiry
+
+-- ============================================
+-- AUTHORIZATION STATUS TYPE
+-- ============================================
+CREATE TYPE authorization_status AS ENUM (
+    'active',       -- Hold in place; may be partially captured
+    'captured',     -- Settled into transaction entries
+    'reversed',     -- Released by the merchant
+    'expired'       -- Released after the hold period
+);
+
+-- ============================================
+-- AUTHORIZATIONS TABLE
+-- ============================================
+CREATE TABLE authorizations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id),
+
+    -- Merchant details, copied onto the captured transaction
+    authorization_code VARCHAR(20) NOT NULL,
+    merchant_name VARCHAR(255),
+    merchant_category VARCHAR(4),           -- MCC code
+    reference_id VARCHAR(255),
+
+    -- Amounts
+    authorized_amount DECIMAL(15,2) NOT NULL, -- Original plus incremental authorizations
+    held_amount DECIMAL(15,2) NOT NULL,       -- Still reserved against available credit
+    captured_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+
+    -- Status and timestamps
+    status authorization_status NOT NULL DEFAULT 'active',
+    authorized_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    captured_at TIMESTAMP WITH TIME ZONE,   -- Latest capture
+    reversed_at TIMESTAMP WITH TIME ZONE,
+    expired_at TIMESTAMP WITH TIME ZONE,
+
+    -- Metadata and audit
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    -- Constraints
+    CONSTRAINT positive_authorized_amount CHECK (authorized_amount > 0),
+    CONSTRAINT valid_held_amount CHECK (held_amount >= 0 AND held_amount <= authorized_amount),
+    CONSTRAINT valid_captured_amount CHECK (captured_amount >= 0)
+);
+
+CREATE INDEX idx_authorizations_tenant ON authorizations(tenant_id);
+CREATE INDEX idx_authorizations_credit_card ON authorizations(credit_card_id);
+CREATE INDEX idx_authorizations_expiry ON authorizations(expires_at) WHERE status = 'active';
+
+-- Update timestamps for authorizations
+CREATE TRIGGER update_authorizations_updated_at
+    BEFORE UPDATE ON authorizations
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE authorizations IS 'Holds on available credit awaiting capture into statement ledger transactions';
@@ -0,0 +1,8 @@
+-- Migration: 011_create_disputes.down.sql
+-- Description: Drop cardholder disputes
+
+DROP TABLE IF EXISTS dispute_status_transitions;
+DROP TABLE IF EXISTS disputes;
+
+DROP TYPE IF EXISTS dispute_reason;
+DROP TYPE IF EXISTS dispute_status;
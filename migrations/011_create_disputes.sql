@@ -0,0 +1,102 @@
+-- Migration: 011_create_disputes.sql
+-- Description: Cardholder disputes of statement ledger entries
+-- Supports: Provisional credit, chargebacks, Regulation Z billing error timelines
+
+-- ============================================
+-- DISPUTE TYPES
+-- ============================================
+CREATE TYPE dispute_status AS ENUM (
+    'open',                 -- Billing error notice received
+    'provisional_credit',   -- Disputed amount credited while investigated
+    'chargeback',           -- Chargeback filed with the merchant's acquirer
+    'resolved_cardholder',  -- Credit made final
+    'resolved_merchant'     -- Charge upheld; any provisional credit reversed
+);
+
+CREATE TYPE dispute_reason AS ENUM (
+    'unauthorized',
+    'not_received',
+    'not_as_described',
+    'duplicate',
+    'incorrect_amount',
+    'credit_not_issued'
+);
+
+-- ============================================
+-- DISPUTES TABLE
+-- ============================================
+CREATE TABLE disputes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id),
+    statement_entry_id UUID NOT NULL REFERENCES statement_ledger_entries(id),
+
+    -- Claim details
+    reason dispute_reason NOT NULL,
+    description TEXT,
+    amount DECIMAL(15,2) NOT NULL,          -- May be part of the charge
+
+    -- Status and linked entries
+    status dispute_status NOT NULL DEFAULT 'open',
+    provisional_credit_entry_id UUID REFERENCES statement_ledger_entries(id),
+    final_credit_entry_id UUID REFERENCES statement_ledger_entries(id),
+    reversal_entry_id UUID REFERENCES statement_ledger_entries(id),
+    chargeback_reference VARCHAR(100),
+    resolution TEXT,
+
+    -- Regulation Z timeline
+    statement_date DATE,                    -- Statement that first showed the charge
+    notice_received_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reg_z_covered BOOLEAN NOT NULL DEFAULT TRUE,
+    acknowledge_by DATE NOT NULL,
+    resolve_by DATE NOT NULL,
+    acknowledged_at TIMESTAMP WITH TIME ZONE,
+    provisional_credit_at TIMESTAMP WITH TIME ZONE,
+    chargeback_filed_at TIMESTAMP WITH TIME ZONE,
+    resolved_at TIMESTAMP WITH TIME ZONE,
+
+    -- Metadata and audit
+    metadata JSONB,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_by VARCHAR(255),
+
+    -- Constraints
+    CONSTRAINT positive_dispute_amount CHECK (amount > 0)
+);
+
+CREATE INDEX idx_disputes_tenant ON disputes(tenant_id);
+CREATE INDEX idx_disputes_credit_card ON disputes(credit_card_id);
+CREATE INDEX idx_disputes_statement_entry ON disputes(statement_entry_id);
+CREATE INDEX idx_disputes_resolve_by ON disputes(resolve_by)
+    WHERE status NOT IN ('resolved_cardholder', 'resolved_merchant');
+
+-- Update timestamps for disputes
+CREATE TRIGGER update_disputes_updated_at
+    BEFORE UPDATE ON disputes
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+-- ============================================
+-- DISPUTE STATUS TRANSITIONS TABLE
+-- ============================================
+CREATE TABLE dispute_status_transitions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    dispute_id UUID NOT NULL REFERENCES disputes(id),
+
+    from_status dispute_status,             -- NULL for the opening transition
+    to_status dispute_status NOT NULL,
+    reason TEXT,
+
+    transition_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    triggered_by VARCHAR(255),
+    metadata JSONB
+);
+
+CREATE INDEX idx_dispute_transitions_dispute ON dispute_status_transitions(dispute_id, transition_at);
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE disputes IS 'Cardholder billing error claims against statement ledger entries';
+COMMENT ON TABLE dispute_status_transitions IS 'Audit trail of dispute status changes';
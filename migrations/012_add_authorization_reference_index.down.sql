@@ -0,0 +1,4 @@
+-- Migration: 012_add_authorization_reference_index.down.sql
+-- Description: Drop the authorization reference index
+
+DROP INDEX IF EXISTS idx_authorizations_reference;
@@ -0,0 +1,6 @@
+-- Migration: 012_add_authorization_reference_index.sql
+-- Description: Look up authorizations by acquirer reference
+-- Supports: Matching clearing file records to open authorizations
+
+CREATE INDEX idx_authorizations_reference ON authorizations(credit_card_id, reference_id)
+    WHERE status IN ('active', 'expired');
@@ -0,0 +1,5 @@
+-- Migration: 013_add_billing_cycle_payoff.down.sql
+-- Description: Drop the minimum payment warning projection
+
+ALTER TABLE billing_cycles
+    DROP COLUMN IF EXISTS payoff;
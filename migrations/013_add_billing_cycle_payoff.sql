@@ -0,0 +1,9 @@
+-- Migration: 013_add_billing_cycle_payoff.sql
+-- Description: Regulation Z minimum payment warning on billing cycles
+-- Supports: Months and total cost to repay paying only the minimum, and the
+-- 36-month payment that repays the balance sooner
+
+-- balance, apr, minimum_only and thirty_six_month; each scenario holds
+-- monthly_payment, months, total_interest, total_cost and never_pays_off
+ALTER TABLE billing_cycles
+    ADD COLUMN payoff JSONB;
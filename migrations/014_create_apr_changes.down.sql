@@ -0,0 +1,8 @@
+-- Migration: 014_create_apr_changes.down.sql
+-- Description: Drop APR history and change-in-terms notices
+
+ALTER TABLE apr_changes DROP CONSTRAINT IF EXISTS fk_apr_changes_notice;
+DROP TABLE IF EXISTS change_in_terms_notices;
+DROP TABLE IF EXISTS apr_changes;
+
+DROP TYPE IF EXISTS apr_type;
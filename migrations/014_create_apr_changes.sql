@@ -0,0 +1,63 @@
+-- Migration: 014_create_apr_changes.sql
+-- Description: Effective-dated APR history and change-in-terms notices
+-- Supports: Recomputing past cycles at the rates then in force, splitting a
+-- cycle across rate changes, Regulation Z 45-day advance notice of increases
+
+-- ============================================
+-- APR CHANGES TABLE
+-- ============================================
+CREATE TYPE apr_type AS ENUM (
+    'purchase',
+    'cash_advance',
+    'penalty'
+);
+
+CREATE TABLE apr_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id),
+
+    apr_type apr_type NOT NULL,
+    previous_apr DECIMAL(5,2) NOT NULL,     -- Rate that applied on the effective date before this change
+    apr DECIMAL(5,2) NOT NULL,
+    effective_date DATE NOT NULL,           -- Applies until the next change of the same type
+    reason TEXT,
+    notice_id UUID,                         -- Change-in-terms notice, for changes scheduled ahead
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_change_apr CHECK (apr >= 0 AND apr <= 100)
+);
+
+CREATE INDEX idx_apr_changes_card ON apr_changes(credit_card_id, effective_date);
+
+-- ============================================
+-- CHANGE IN TERMS NOTICES TABLE
+-- ============================================
+CREATE TABLE change_in_terms_notices (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id),
+    apr_change_id UUID NOT NULL REFERENCES apr_changes(id),
+
+    apr_type apr_type NOT NULL,
+    current_apr DECIMAL(5,2) NOT NULL,
+    new_apr DECIMAL(5,2) NOT NULL,
+    notice_date DATE NOT NULL,
+    effective_date DATE NOT NULL,           -- At least 45 days after notice_date for increases
+    summary TEXT NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_change_in_terms_notices_card ON change_in_terms_notices(credit_card_id, notice_date);
+
+ALTER TABLE apr_changes
+    ADD CONSTRAINT fk_apr_changes_notice FOREIGN KEY (notice_id) REFERENCES change_in_terms_notices(id)
+    DEFERRABLE INITIALLY DEFERRED;
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE apr_changes IS 'Effective-dated history of each card APR';
+COMMENT ON TABLE change_in_terms_notices IS 'Advance notices of scheduled APR changes';
@@ -0,0 +1,7 @@
+-- Migration: 015_create_penalty_events.down.sql
+-- Description: Drop penalty APR events
+
+DROP TABLE IF EXISTS penalty_events;
+
+DROP TYPE IF EXISTS penalty_trigger;
+DROP TYPE IF EXISTS penalty_event_type;
@@ -0,0 +1,51 @@
+-- Migration: 015_create_penalty_events.sql
+-- Description: Penalty APR triggers and cures
+-- Supports: Applying the penalty APR after a late or returned payment, and
+-- restoring the prior APRs after six on-time payments (Regulation Z 1026.55(b)(4))
+
+-- ============================================
+-- PENALTY EVENTS TABLE
+-- ============================================
+CREATE TYPE penalty_event_type AS ENUM (
+    'applied',
+    'cured'
+);
+
+CREATE TYPE penalty_trigger AS ENUM (
+    'late_payment',
+    'returned_payment'
+);
+
+CREATE TABLE penalty_events (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id),
+    event_type penalty_event_type NOT NULL,
+
+    -- Trigger details, for applied events
+    trigger penalty_trigger,
+    billing_cycle_id UUID REFERENCES billing_cycles(id),   -- Cycle whose minimum payment was late
+    payment_id UUID REFERENCES payments(id),               -- Returned payment
+    days_late INTEGER NOT NULL DEFAULT 0,
+
+    -- Consecutive on-time minimum payments, for cured events
+    on_time_payments INTEGER NOT NULL DEFAULT 0,
+
+    penalty_apr DECIMAL(5,2) NOT NULL,
+    prior_purchase_apr DECIMAL(5,2) NOT NULL,    -- Restored on cure
+    prior_cash_advance_apr DECIMAL(5,2) NOT NULL,
+
+    occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    effective_date DATE NOT NULL,                -- When the rates change
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT applied_has_trigger CHECK (event_type = 'cured' OR trigger IS NOT NULL)
+);
+
+CREATE INDEX idx_penalty_events_card ON penalty_events(credit_card_id, occurred_at);
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE penalty_events IS 'Penalty APR applied to and cured on each card';
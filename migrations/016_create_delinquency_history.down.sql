@@ -0,0 +1,11 @@
+-- Migration: 016_create_delinquency_history.down.sql
+-- Description: Drop delinquency aging and charge-off columns
+-- Postgres cannot drop enum values, so the charge_off entry type remains
+
+DROP INDEX IF EXISTS idx_credit_cards_delinquency;
+DROP TABLE IF EXISTS delinquency_history;
+
+ALTER TABLE credit_cards
+    DROP COLUMN IF EXISTS charged_off_at,
+    DROP COLUMN IF EXISTS delinquency_bucket,
+    DROP COLUMN IF EXISTS days_past_due;
@@ -0,0 +1,40 @@
+-- Migration: 016_create_delinquency_history.sql
+-- Description: Delinquency aging buckets and charge-off
+-- Supports: Daily aging of cards into 30/60/90/120/180-day buckets, charging
+-- off balances 180 days past due
+
+ALTER TYPE statement_entry_type ADD VALUE IF NOT EXISTS 'charge_off';
+
+ALTER TABLE credit_cards
+    ADD COLUMN days_past_due INTEGER NOT NULL DEFAULT 0,                 -- Oldest unpaid minimum payment
+    ADD COLUMN delinquency_bucket VARCHAR(20) NOT NULL DEFAULT 'current',
+    ADD COLUMN charged_off_at TIMESTAMP WITH TIME ZONE;                  -- NULL until the balance is written off
+
+-- ============================================
+-- DELINQUENCY HISTORY TABLE
+-- ============================================
+CREATE TABLE delinquency_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id),
+
+    bucket VARCHAR(20) NOT NULL,
+    previous_bucket VARCHAR(20) NOT NULL,
+    days_past_due INTEGER NOT NULL,
+    billing_cycle_id UUID REFERENCES billing_cycles(id),       -- Oldest cycle with an unpaid minimum
+    past_due_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    charge_off_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00,     -- Balance written off, when charged off
+    as_of DATE NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT valid_bucket CHECK (bucket IN ('current', '1_29', '30_59', '60_89', '90_119', '120_179', '180_plus'))
+);
+
+CREATE INDEX idx_delinquency_history_card ON delinquency_history(credit_card_id, as_of);
+CREATE INDEX idx_credit_cards_delinquency ON credit_cards(delinquency_bucket) WHERE delinquency_bucket <> 'current';
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE delinquency_history IS 'Each card moving between delinquency aging buckets';
@@ -0,0 +1,10 @@
+-- Migration: 017_create_recoveries.down.sql
+-- Description: Drop the collections ledger
+
+DELETE FROM journal_lines
+WHERE journal_entry_id IN (SELECT id FROM journal_entries WHERE source_type = 'recovery');
+DELETE FROM journal_entries WHERE source_type = 'recovery';
+DELETE FROM gl_accounts WHERE code IN ('4200', '5300');
+
+DROP TABLE IF EXISTS recoveries;
+DROP TABLE IF EXISTS charge_offs;
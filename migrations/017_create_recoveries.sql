@@ -0,0 +1,86 @@
+-- Migration: 017_create_recoveries.sql
+-- Description: Collections ledger for charged-off balances
+-- Supports: Recoveries collected in house or by collections agencies,
+-- agency commission splits, remaining charged-off principal, interest and fees
+
+-- ============================================
+-- CHARGE-OFFS TABLE
+-- ============================================
+CREATE TABLE charge_offs (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id),
+
+    -- Balance written off
+    principal DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    interest DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    fees DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+
+    -- Still owed after recoveries
+    principal_remaining DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    interest_remaining DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    fees_remaining DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    recovered DECIMAL(15,2) NOT NULL DEFAULT 0.00,           -- Gross amount collected to date
+
+    charged_off_on DATE NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    UNIQUE(credit_card_id),
+    CONSTRAINT valid_remaining CHECK (
+        principal_remaining BETWEEN 0 AND principal AND
+        interest_remaining BETWEEN 0 AND interest AND
+        fees_remaining BETWEEN 0 AND fees
+    )
+);
+
+CREATE INDEX idx_charge_offs_tenant ON charge_offs(tenant_id, charged_off_on);
+
+-- ============================================
+-- RECOVERIES TABLE
+-- ============================================
+CREATE TABLE recoveries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id UUID NOT NULL REFERENCES tenants(id),
+    credit_card_id UUID NOT NULL REFERENCES credit_cards(id),
+    charge_off_id UUID NOT NULL REFERENCES charge_offs(id),
+
+    agency VARCHAR(100) NOT NULL DEFAULT '',                  -- Empty when collected in house
+    reference_id VARCHAR(255),                                -- Agency remittance reference
+
+    amount DECIMAL(15,2) NOT NULL,                            -- Gross amount collected
+    commission_rate DECIMAL(5,2) NOT NULL DEFAULT 0.00,       -- Agency commission percentage
+    commission DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    net_amount DECIMAL(15,2) NOT NULL,                        -- Amount remitted to us
+
+    -- Charged-off balance paid down
+    principal_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    interest_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    fees_amount DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+
+    recovered_on DATE NOT NULL,
+
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT positive_recovery CHECK (amount > 0),
+    CONSTRAINT valid_commission_rate CHECK (commission_rate >= 0 AND commission_rate <= 100),
+    CONSTRAINT commission_split CHECK (commission + net_amount = amount),
+    CONSTRAINT component_split CHECK (principal_amount + interest_amount + fees_amount = amount)
+);
+
+CREATE INDEX idx_recoveries_card ON recoveries(credit_card_id, recovered_on);
+CREATE INDEX idx_recoveries_tenant ON recoveries(tenant_id, recovered_on);
+
+-- ============================================
+-- GENERAL LEDGER ACCOUNTS
+-- ============================================
+INSERT INTO gl_accounts (code, name, account_type) VALUES
+    ('4200', 'Charge-off Recoveries', 'income'),   -- Gross amounts collected on charged-off balances
+    ('5300', 'Collection Commissions', 'expense'); -- Agency share of recoveries
+
+-- ============================================
+-- COMMENTS
+-- ============================================
+COMMENT ON TABLE charge_offs IS 'Charged-off balances held for collection';
+COMMENT ON TABLE recoveries IS 'Money collected on charged-off balances';
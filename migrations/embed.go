@@ -0,0 +1,9 @@
+// Package migrations embeds the schema migrations so they ship with the binary
+package migrations
+
+import "embed"
+
+// FS holds every NNN_name.sql migration and its NNN_name.down.sql rollback
+//
+//go:embed *.sql
+var FS embed.FS
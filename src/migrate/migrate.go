@@ -0,0 +1,475 @@
+// Package migrate applies the embedded schema migrations and records them in
+// the schema_migrations table
+package migrate
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/migrations"
+)
+
+var (
+	// ErrSchemaOutdated is returned when migrations are waiting to be applied
+	ErrSchemaOutdated = errors.New("database schema is out of date")
+	// ErrChecksumMismatch is returned when an applied migration file has changed
+	ErrChecksumMismatch = errors.New("applied migration has changed")
+	// ErrNoDownMigration is returned when rolling back a migration without a down file
+	ErrNoDownMigration = errors.New("migration has no down file")
+)
+
+// advisoryLockKey serializes concurrent migrators on the same database
+const advisoryLockKey = 7318472601
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)(\.down)?\.sql$`)
+
+// Migration is one versioned schema change
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of Up
+}
+
+// AppliedMigration is a row of schema_migrations
+type AppliedMigration struct {
+	Version   int
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// MigrationStatus compares a migration with what the database has applied
+type MigrationStatus struct {
+	Version          int
+	Name             string
+	AppliedAt        *time.Time
+	ChecksumMismatch bool
+	Unknown          bool // Applied but not in this build
+}
+
+// IsPending returns true if the migration has not been applied
+func (s MigrationStatus) IsPending() bool {
+	return s.AppliedAt == nil
+}
+
+// Load reads NNN_name.sql and NNN_name.down.sql files from fsys
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		match := fileName.FindStringSubmatch(file)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", file)
+		}
+		version, _ := strconv.Atoi(match[1])
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] != "" {
+			migration.Down = string(content)
+		} else {
+			migration.Up = string(content)
+			migration.Checksum = checksum(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has a down file but no up file", migration.Version, migration.Name)
+		}
+		result = append(result, *migration)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Migrator applies and rolls back migrations against a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New creates a migrator for the migrations embedded in the binary
+func New(db *sql.DB) (*Migrator, error) {
+	return NewWithFS(db, migrations.FS)
+}
+
+// NewWithFS creates a migrator for the migrations in fsys
+func NewWithFS(db *sql.DB, fsys fs.FS) (*Migrator, error) {
+	loaded, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: loaded}, nil
+}
+
+// RequireCurrent returns an error unless every embedded migration has been
+// applied unchanged. Call it at startup before constructing services.
+func RequireCurrent(ctx context.Context, db *sql.DB) error {
+	m, err := New(db)
+	if err != nil {
+		return err
+	}
+	return m.Verify(ctx)
+}
+
+// Migrations returns the known migrations, oldest first
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status reports every known and applied migration, oldest first
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx, m.db)
+	if err != nil {
+		return nil, err
+	}
+	return statusOf(m.migrations, applied), nil
+}
+
+// Verify returns ErrChecksumMismatch if an applied migration has changed and
+// ErrSchemaOutdated if any migration is pending
+func (m *Migrator) Verify(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return verify(statuses)
+}
+
+// Up applies every pending migration in order, each in its own transaction.
+// Returns the migrations that were applied.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	for _, migration := range m.migrations {
+		applied, err := m.apply(ctx, migration)
+		if err != nil {
+			return done, err
+		}
+		if applied {
+			done = append(done, migration)
+		}
+	}
+
+	return done, nil
+}
+
+// Baseline records every migration up to and including version as applied
+// without running it, for a database whose schema was created by running the
+// SQL files by hand. Migrations already recorded are left alone. Returns the
+// migrations that were recorded.
+func (m *Migrator) Baseline(ctx context.Context, version int) ([]Migration, error) {
+	tx, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	applied, err := m.applied(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := baselineOf(m.migrations, applied, version)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, migration := range pending {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO schema_migrations (version, name, checksum, applied_at)
+			VALUES ($1, $2, $3, $4)
+		`, migration.Version, migration.Name, migration.Checksum, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit baseline: %w", err)
+	}
+	return pending, nil
+}
+
+// Down rolls back the newest steps applied migrations, newest first.
+// Returns the migrations that were rolled back.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+	for i := 0; i < steps; i++ {
+		migration, err := m.rollbackLatest(ctx)
+		if err != nil {
+			return done, err
+		}
+		if migration == nil {
+			break
+		}
+		done = append(done, *migration)
+	}
+
+	return done, nil
+}
+
+// apply runs one migration unless it is already applied
+func (m *Migrator) apply(ctx context.Context, migration Migration) (bool, error) {
+	tx, err := m.begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	applied, err := m.applied(ctx, tx)
+	if err != nil {
+		return false, err
+	}
+	// Earlier files must not have changed underneath the database
+	if err := verifyChecksums(statusOf(m.migrations, applied)); err != nil {
+		return false, err
+	}
+	if _, ok := applied[migration.Version]; ok {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+		return false, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO schema_migrations (version, name, checksum, applied_at)
+		VALUES ($1, $2, $3, $4)
+	`, migration.Version, migration.Name, migration.Checksum, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to record migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return true, nil
+}
+
+// rollbackLatest runs the down file of the newest applied migration.
+// Returns nil when nothing is applied.
+func (m *Migrator) rollbackLatest(ctx context.Context) (*Migration, error) {
+	tx, err := m.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	applied, err := m.applied(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) == 0 {
+		return nil, nil
+	}
+
+	latest := -1
+	for version := range applied {
+		if version > latest {
+			latest = version
+		}
+	}
+
+	var migration *Migration
+	for i := range m.migrations {
+		if m.migrations[i].Version == latest {
+			migration = &m.migrations[i]
+		}
+	}
+	if migration == nil {
+		return nil, fmt.Errorf("applied migration %d_%s is not in this build", latest, applied[latest].Name)
+	}
+	if applied[latest].Checksum != migration.Checksum {
+		return nil, fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+	}
+	if migration.Down == "" {
+		return nil, fmt.Errorf("%w: %d_%s", ErrNoDownMigration, migration.Version, migration.Name)
+	}
+
+	if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+		return nil, fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version); err != nil {
+		return nil, fmt.Errorf("failed to unrecord migration %d_%s: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit rollback of %d_%s: %w", migration.Version, migration.Name, err)
+	}
+	return migration, nil
+}
+
+// begin starts a transaction holding the migration lock until it ends and
+// creates schema_migrations on first use
+func (m *Migrator) begin(ctx context.Context) (*sql.Tx, error) {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, advisoryLockKey); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return tx, nil
+}
+
+type querier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// applied reads schema_migrations. A database without the table has applied nothing.
+func (m *Migrator) applied(ctx context.Context, db querier) (map[int]AppliedMigration, error) {
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check for schema_migrations: %w", err)
+	}
+	applied := make(map[int]AppliedMigration)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT version, name, checksum, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row AppliedMigration
+		if err := rows.Scan(&row.Version, &row.Name, &row.Checksum, &row.AppliedAt); err != nil {
+			return nil, err
+		}
+		applied[row.Version] = row
+	}
+
+	return applied, rows.Err()
+}
+
+// statusOf merges known migrations with applied ones, oldest first
+func statusOf(known []Migration, applied map[int]AppliedMigration) []MigrationStatus {
+	var statuses []MigrationStatus
+	seen := make(map[int]bool)
+
+	for _, migration := range known {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.ChecksumMismatch = row.Checksum != migration.Checksum
+		}
+		statuses = append(statuses, status)
+		seen[migration.Version] = true
+	}
+
+	for version, row := range applied {
+		if seen[version] {
+			continue
+		}
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   version,
+			Name:      row.Name,
+			AppliedAt: &appliedAt,
+			Unknown:   true,
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses
+}
+
+// baselineOf returns the known migrations up to and including version that
+// are not yet applied
+func baselineOf(known []Migration, applied map[int]AppliedMigration, version int) ([]Migration, error) {
+	if err := verifyChecksums(statusOf(known, applied)); err != nil {
+		return nil, err
+	}
+
+	found := false
+	var pending []Migration
+	for _, migration := range known {
+		if migration.Version > version {
+			break
+		}
+		if migration.Version == version {
+			found = true
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no migration with version %d", version)
+	}
+	return pending, nil
+}
+
+func verify(statuses []MigrationStatus) error {
+	if err := verifyChecksums(statuses); err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		if status.IsPending() {
+			return fmt.Errorf("%w: migration %d_%s is pending", ErrSchemaOutdated, status.Version, status.Name)
+		}
+	}
+	return nil
+}
+
+func verifyChecksums(statuses []MigrationStatus) error {
+	for _, status := range statuses {
+		if status.ChecksumMismatch {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, status.Version, status.Name)
+		}
+	}
+	return nil
+}
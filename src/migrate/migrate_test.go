@@ -0,0 +1,218 @@
+package migrate
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/migrations"
+)
+
+func TestLoad_Embedded(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(loaded) < 6 {
+		t.Fatalf("loaded %d migrations, want at least 6", len(loaded))
+	}
+	for i, migration := range loaded {
+		if migration.Version != i+1 {
+			t.Errorf("migration %d has version %d; versions must be contiguous", i, migration.Version)
+		}
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+	if loaded[0].Name != "create_ledger_tables" {
+		t.Errorf("first migration = %s, want create_ledger_tables", loaded[0].Name)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name:  "Unversioned file",
+			files: fstest.MapFS{"create_tables.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "Down without up",
+			files: fstest.MapFS{
+				"001_create_tables.down.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+		{
+			name: "Two names for one version",
+			files: fstest.MapFS{
+				"001_create_tables.sql": {Data: []byte("SELECT 1;")},
+				"001_create_views.sql":  {Data: []byte("SELECT 1;")},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(tt.files); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	known, err := Load(fstest.MapFS{
+		"001_create_tables.sql":      {Data: []byte("CREATE TABLE a (id INT);")},
+		"001_create_tables.down.sql": {Data: []byte("DROP TABLE a;")},
+		"002_create_views.sql":       {Data: []byte("CREATE VIEW v AS SELECT * FROM a;")},
+	})
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	now := time.Now()
+	applied := func(migration Migration) AppliedMigration {
+		return AppliedMigration{Version: migration.Version, Name: migration.Name, Checksum: migration.Checksum, AppliedAt: now}
+	}
+
+	tests := []struct {
+		name    string
+		applied []AppliedMigration
+		wantErr error
+		pending int
+	}{
+		{
+			name:    "Fresh database is outdated",
+			wantErr: ErrSchemaOutdated,
+			pending: 2,
+		},
+		{
+			name:    "Pending migration is outdated",
+			applied: []AppliedMigration{applied(known[0])},
+			wantErr: ErrSchemaOutdated,
+			pending: 1,
+		},
+		{
+			name:    "All applied is current",
+			applied: []AppliedMigration{applied(known[0]), applied(known[1])},
+		},
+		{
+			name: "Edited file is rejected",
+			applied: []AppliedMigration{
+				{Version: 1, Name: "create_tables", Checksum: "0000", AppliedAt: now},
+				applied(known[1]),
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "Migration from a newer build is allowed",
+			applied: []AppliedMigration{
+				applied(known[0]), applied(known[1]),
+				{Version: 3, Name: "create_indexes", Checksum: "ffff", AppliedAt: now},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := make(map[int]AppliedMigration)
+			for _, row := range tt.applied {
+				rows[row.Version] = row
+			}
+
+			statuses := statusOf(known, rows)
+			pending := 0
+			for _, status := range statuses {
+				if status.IsPending() {
+					pending++
+				}
+			}
+			if pending != tt.pending {
+				t.Errorf("pending = %d, want %d", pending, tt.pending)
+			}
+
+			err := verify(statuses)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("verify() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBaselineOf(t *testing.T) {
+	known, err := Load(fstest.MapFS{
+		"001_create_tables.sql":  {Data: []byte("CREATE TABLE a (id INT);")},
+		"002_create_views.sql":   {Data: []byte("CREATE VIEW v AS SELECT * FROM a;")},
+		"003_create_indexes.sql": {Data: []byte("CREATE INDEX i ON a (id);")},
+	})
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		applied  []AppliedMigration
+		version  int
+		wantErr  bool
+		wantIs   error
+		recorded []int
+	}{
+		{
+			name:     "Schema created by hand",
+			version:  2,
+			recorded: []int{1, 2},
+		},
+		{
+			name:     "Already recorded versions are skipped",
+			applied:  []AppliedMigration{{Version: 1, Name: "create_tables", Checksum: known[0].Checksum, AppliedAt: now}},
+			version:  3,
+			recorded: []int{2, 3},
+		},
+		{
+			name:    "Unknown version",
+			version: 4,
+			wantErr: true,
+		},
+		{
+			name:    "Edited file is rejected",
+			applied: []AppliedMigration{{Version: 1, Name: "create_tables", Checksum: "0000", AppliedAt: now}},
+			version: 2,
+			wantErr: true,
+			wantIs:  ErrChecksumMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows := make(map[int]AppliedMigration)
+			for _, row := range tt.applied {
+				rows[row.Version] = row
+			}
+
+			pending, err := baselineOf(known, rows, tt.version)
+			if tt.wantErr {
+				if err == nil || (tt.wantIs != nil && !errors.Is(err, tt.wantIs)) {
+					t.Fatalf("error = %v, want %v", err, tt.wantIs)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(pending) != len(tt.recorded) {
+				t.Fatalf("recorded %d migrations, want %d", len(pending), len(tt.recorded))
+			}
+			for i, migration := range pending {
+				if migration.Version != tt.recorded[i] || migration.Checksum != known[tt.recorded[i]-1].Checksum {
+					t.Errorf("recorded %d_%s, want version %d with its checksum",
+						migration.Version, migration.Name, tt.recorded[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// APRType identifies which of a card's rates an APR change applies to
+type APRType string
+
+const (
+	APRTypePurchase    APRType = "purchase"
+	APRTypeCashAdvance APRType = "cash_advance"
+	APRTypePenalty     APRType = "penalty"
+)
+
+// Label returns the rate name used in notices
+func (t APRType) Label() string {
+	switch t {
+	case APRTypeCashAdvance:
+		return "cash advances"
+	case APRTypePenalty:
+		return "the Penalty APR"
+	default:
+		return "purchases"
+	}
+}
+
+// ChangeInTermsNoticeDays is how far ahead of an APR increase the
+// cardholder must be told (Regulation Z, 12 CFR 1026.9(c))
+const ChangeInTermsNoticeDays = 45
+
+// ErrInsufficientAPRNotice is returned when an APR increase takes effect
+// less than ChangeInTermsNoticeDays after its notice
+var ErrInsufficientAPRNotice = errors.New("APR increases need 45 days' notice")
+
+// APRChange is one effective-dated rate in a card's APR history. The rate
+// applies from its effective date until the next change of the same type.
+type APRChange struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	TenantID      uuid.UUID       `json:"tenant_id" db:"tenant_id"`
+	CreditCardID  uuid.UUID       `json:"credit_card_id" db:"credit_card_id"`
+	APRType       APRType         `json:"apr_type" db:"apr_type"`
+	PreviousAPR   decimal.Decimal `json:"previous_apr" db:"previous_apr"` // Rate that applied on the effective date before this change
+	APR           decimal.Decimal `json:"apr" db:"apr"`
+	EffectiveDate time.Time       `json:"effective_date" db:"effective_date"`
+	Reason        string          `json:"reason,omitempty" db:"reason"`
+	NoticeID      *uuid.UUID      `json:"notice_id,omitempty" db:"notice_id"` // Change-in-terms notice, for changes scheduled ahead
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// IsIncrease returns true if the change raises the rate
+func (c *APRChange) IsIncrease() bool {
+	return c.APR.GreaterThan(c.PreviousAPR)
+}
+
+// ChangeInTermsNotice is the advance notice sent to the cardholder for an
+// APR change scheduled to take effect later
+type ChangeInTermsNotice struct {
+	ID            uuid.UUID       `json:"id" db:"id"`
+	TenantID      uuid.UUID       `json:"tenant_id" db:"tenant_id"`
+	CreditCardID  uuid.UUID       `json:"credit_card_id" db:"credit_card_id"`
+	APRChangeID   uuid.UUID       `json:"apr_change_id" db:"apr_change_id"`
+	APRType       APRType         `json:"apr_type" db:"apr_type"`
+	CurrentAPR    decimal.Decimal `json:"current_apr" db:"current_apr"`
+	NewAPR        decimal.Decimal `json:"new_apr" db:"new_apr"`
+	NoticeDate    time.Time       `json:"notice_date" db:"notice_date"`
+	EffectiveDate time.Time       `json:"effective_date" db:"effective_date"`
+	Summary       string          `json:"summary" db:"summary"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
+
+// NewChangeInTermsNotice drafts the notice for a scheduled APR change
+func NewChangeInTermsNotice(change *APRChange, noticeDate time.Time) *ChangeInTermsNotice {
+	direction := "decrease"
+	if change.IsIncrease() {
+		direction = "increase"
+	}
+	return &ChangeInTermsNotice{
+		ID:            uuid.New(),
+		TenantID:      change.TenantID,
+		CreditCardID:  change.CreditCardID,
+		APRChangeID:   change.ID,
+		APRType:       change.APRType,
+		CurrentAPR:    change.PreviousAPR,
+		NewAPR:        change.APR,
+		NoticeDate:    noticeDate,
+		EffectiveDate: change.EffectiveDate,
+		Summary: fmt.Sprintf("Beginning %s, the APR for %s will %s from %s%% to %s%%.",
+			change.EffectiveDate.Format("Jan 2, 2006"), change.APRType.Label(), direction,
+			change.PreviousAPR.StringFixed(2), change.APR.StringFixed(2)),
+	}
+}
+
+// APROn returns the rate of the given type in effect on a date. Without any
+// history for the type, it is the rate held on the card; before the first
+// change, it is the rate that change replaced.
+func (c *CreditCard) APROn(aprType APRType, on time.Time) decimal.Decimal {
+	var changes []APRChange
+	for _, change := range c.APRSchedule {
+		if change.APRType == aprType {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		switch aprType {
+		case APRTypeCashAdvance:
+			return c.CashAdvanceAPR
+		case APRTypePenalty:
+			return c.PenaltyAPR
+		default:
+			return c.PurchaseAPR
+		}
+	}
+
+	// Later changes with the same effective date replace earlier ones
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].EffectiveDate.Before(changes[j].EffectiveDate)
+	})
+	day := DateOf(on)
+	rate := changes[0].PreviousAPR
+	for _, change := range changes {
+		if change.EffectiveDate.After(day) {
+			break
+		}
+		rate = change.APR
+	}
+	return rate
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// AuthorizationStatus represents the status of a card authorization
+type AuthorizationStatus string
+
+const (
+	AuthorizationStatusActive   AuthorizationStatus = "active"   // Hold in place; may be partially captured
+	AuthorizationStatusCaptured AuthorizationStatus = "captured" // Settled into transaction entries
+	AuthorizationStatusReversed AuthorizationStatus = "reversed" // Released by the merchant
+	AuthorizationStatusExpired  AuthorizationStatus = "expired"  // Released after the hold period
+)
+
+// DefaultAuthorizationHoldDays is how long a hold lasts when the request
+// does not say
+const DefaultAuthorizationHoldDays = 7
+
+// AuthorizationMetadataKey is the statement entry metadata key that links a
+// captured transaction to its authorization
+const AuthorizationMetadataKey = "authorization_id"
+
+// Authorization errors
+var (
+	ErrAuthorizationClosed  = errors.New("authorization is no longer active")
+	ErrAuthorizationExpired = errors.New("authorization has expired")
+)
+
+// Authorization is a hold placed on available credit when a merchant
+// approves a purchase. It writes nothing to the statement ledger; a capture
+// posts the transaction entry and releases the hold.
+type Authorization struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	TenantID     uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	CreditCardID uuid.UUID `json:"credit_card_id" db:"credit_card_id"`
+
+	// Merchant details, copied onto the captured transaction
+	AuthorizationCode string `json:"authorization_code" db:"authorization_code"`
+	MerchantName      string `json:"merchant_name" db:"merchant_name"`
+	MerchantCategory  string `json:"merchant_category" db:"merchant_category"` // MCC code
+	ReferenceID       string `json:"reference_id" db:"reference_id"`
+
+	// Amounts
+	AuthorizedAmount decimal.Decimal `json:"authorized_amount" db:"authorized_amount"` // Original plus incremental authorizations
+	HeldAmount       decimal.Decimal `json:"held_amount" db:"held_amount"`             // Still reserved against available credit
+	CapturedAmount   decimal.Decimal `json:"captured_amount" db:"captured_amount"`     // Posted to the statement ledger
+
+	// Status and timestamps
+	Status       AuthorizationStatus `json:"status" db:"status"`
+	AuthorizedAt time.Time           `json:"authorized_at" db:"authorized_at"`
+	ExpiresAt    time.Time           `json:"expires_at" db:"expires_at"`
+	CapturedAt   *time.Time          `json:"captured_at,omitempty" db:"captured_at"` // Latest capture
+	ReversedAt   *time.Time          `json:"reversed_at,omitempty" db:"reversed_at"`
+	ExpiredAt    *time.Time          `json:"expired_at,omitempty" db:"expired_at"`
+
+	// Metadata and audit
+	Metadata  map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at" db:"updated_at"`
+}
+
+// IsActive returns true if the authorization still holds credit or can be captured
+func (a *Authorization) IsActive() bool {
+	return a.Status == AuthorizationStatusActive
+}
+
+// IsStale returns true if the hold period has run out by asOf
+func (a *Authorization) IsStale(asOf time.Time) bool {
+	return !asOf.Before(a.ExpiresAt)
+}
+
+// CanCapture checks if the merchant can still settle against the authorization.
+// An expired authorization can be captured late; its hold is already gone,
+// so the whole capture needs available credit.
+func (a *Authorization) CanCapture() error {
+	switch a.Status {
+	case AuthorizationStatusActive, AuthorizationStatusExpired:
+		return nil
+	}
+	return ErrAuthorizationClosed
+}
+
+// Release removes up to amount from the hold and returns how much was released
+func (a *Authorization) Release(amount decimal.Decimal) decimal.Decimal {
+	released := decimal.Min(amount, a.HeldAmount)
+	if released.IsNegative() {
+		released = decimal.Zero
+	}
+	a.HeldAmount = a.HeldAmount.Sub(released)
+	return released
+}
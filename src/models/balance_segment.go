@@ -0,0 +1,255 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// BalanceSegment is a portion of the card balance that accrues interest at
+// its own APR
+type BalanceSegment string
+
+const (
+	SegmentPurchase        BalanceSegment = "purchase"
+	SegmentCashAdvance     BalanceSegment = "cash_advance"
+	SegmentBalanceTransfer BalanceSegment = "balance_transfer"
+	SegmentPromo           BalanceSegment = "promo" // Purchases made during the introductory period
+)
+
+// BalanceSegments lists every segment. Credits that are not allocated to a
+// segment pay them down in this order.
+var BalanceSegments = []BalanceSegment{
+	SegmentPurchase,
+	SegmentPromo,
+	SegmentBalanceTransfer,
+	SegmentCashAdvance,
+}
+
+// SegmentMetadataKey is the entry metadata key that places an entry in a
+// segment explicitly
+const SegmentMetadataKey = "segment"
+
+// Label returns the segment name shown on statements
+func (s BalanceSegment) Label() string {
+	switch s {
+	case SegmentCashAdvance:
+		return "Cash advances"
+	case SegmentBalanceTransfer:
+		return "Balance transfers"
+	case SegmentPromo:
+		return "Promotional purchases"
+	default:
+		return "Purchases"
+	}
+}
+
+// HasGracePeriod returns true if the segment is interest free when the
+// previous statement was paid in full. Cash advances and balance transfers
+// accrue interest from the day they post.
+func (s BalanceSegment) HasGracePeriod() bool {
+	return s == SegmentPurchase || s == SegmentPromo
+}
+
+// SegmentOf returns the segment a debit entry adds to
+func (c *CreditCard) SegmentOf(entry *StatementLedgerEntry) BalanceSegment {
+	if segment, ok := entry.Metadata[SegmentMetadataKey].(string); ok && segment != "" {
+		return BalanceSegment(segment)
+	}
+
+	switch entry.EntryType {
+	case EntryTypeCashAdvance, EntryTypeFeeCashAdvance:
+		return SegmentCashAdvance
+	case EntryTypeBalanceTransfer:
+		return SegmentBalanceTransfer
+	case EntryTypeTransaction:
+		if c.IntroductoryEndDate != nil && entry.PostingDate.Before(*c.IntroductoryEndDate) {
+			return SegmentPromo
+		}
+	}
+	return SegmentPurchase
+}
+
+// SegmentAPR returns the APR a segment accrues at on a date
+func (c *CreditCard) SegmentAPR(segment BalanceSegment, on time.Time) decimal.Decimal {
+	switch segment {
+	case SegmentCashAdvance:
+		return c.APROn(APRTypeCashAdvance, on)
+	case SegmentBalanceTransfer:
+		if c.HasBalanceTransferPromo(on) {
+			return c.BalanceTransferAPR
+		}
+	case SegmentPromo:
+		if c.IntroductoryEndDate != nil && on.Before(*c.IntroductoryEndDate) {
+			return c.IntroductoryAPR
+		}
+	}
+	return c.APROn(APRTypePurchase, on)
+}
+
+// SegmentBalances holds the balance owed in each segment
+type SegmentBalances map[BalanceSegment]decimal.Decimal
+
+// Charge adds a debit to a segment
+func (b SegmentBalances) Charge(segment BalanceSegment, amount decimal.Decimal) {
+	b[segment] = b[segment].Add(amount)
+}
+
+// PayDown applies a credit to the segments in order, reducing each to zero
+// before moving to the next. Whatever is left becomes a credit balance in
+// the first segment. It returns the amount applied to each segment.
+func (b SegmentBalances) PayDown(amount decimal.Decimal, order []BalanceSegment) map[BalanceSegment]decimal.Decimal {
+	applied := make(map[BalanceSegment]decimal.Decimal)
+	remaining := b.payOwed(amount, order, applied)
+	if remaining.IsPositive() && len(order) > 0 {
+		b[order[0]] = b[order[0]].Sub(remaining)
+		applied[order[0]] = applied[order[0]].Add(remaining)
+	}
+	return applied
+}
+
+// payOwed pays segments down to zero in order, adding what it pays to
+// applied, and returns the part of amount nothing was owed for
+func (b SegmentBalances) payOwed(amount decimal.Decimal, order []BalanceSegment, applied map[BalanceSegment]decimal.Decimal) decimal.Decimal {
+	remaining := amount
+	for _, segment := range order {
+		if !remaining.IsPositive() {
+			break
+		}
+		owed := b[segment]
+		if !owed.IsPositive() {
+			continue
+		}
+		paid := decimal.Min(owed, remaining)
+		b[segment] = owed.Sub(paid)
+		applied[segment] = applied[segment].Add(paid)
+		remaining = remaining.Sub(paid)
+	}
+	return remaining
+}
+
+// Total returns the balance across every segment
+func (b SegmentBalances) Total() decimal.Decimal {
+	total := decimal.Zero
+	for _, balance := range b {
+		total = total.Add(balance)
+	}
+	return total
+}
+
+// AllocationMetadataKey is the payment entry metadata key that records how
+// the payment was split across segments
+const AllocationMetadataKey = "allocation"
+
+// SegmentAllocation is the amount of a payment applied to each segment
+type SegmentAllocation map[BalanceSegment]decimal.Decimal
+
+// AllocatePayment splits a payment across the segment balances.
+// The part that covers minimumDue is applied lowest APR first, as the issuer
+// prefers; the excess is applied highest APR first, as the CARD Act
+// requires. Segments with the same APR are paid in BalanceSegments order.
+// Anything beyond the balance owed becomes a credit in the purchase segment.
+func AllocatePayment(
+	balances SegmentBalances,
+	aprs map[BalanceSegment]decimal.Decimal,
+	amount, minimumDue decimal.Decimal,
+) SegmentAllocation {
+	lowest := make([]BalanceSegment, len(BalanceSegments))
+	copy(lowest, BalanceSegments)
+	sort.SliceStable(lowest, func(i, j int) bool {
+		return aprs[lowest[i]].LessThan(aprs[lowest[j]])
+	})
+	highest := make([]BalanceSegment, len(BalanceSegments))
+	copy(highest, BalanceSegments)
+	sort.SliceStable(highest, func(i, j int) bool {
+		return aprs[highest[i]].GreaterThan(aprs[highest[j]])
+	})
+
+	owed := make(SegmentBalances, len(balances))
+	for segment, balance := range balances {
+		owed[segment] = balance
+	}
+
+	applied := make(map[BalanceSegment]decimal.Decimal)
+	minimum := decimal.Max(decimal.Min(amount, minimumDue), decimal.Zero)
+	remaining := owed.payOwed(minimum, lowest, applied)
+	remaining = owed.payOwed(remaining.Add(amount.Sub(minimum)), highest, applied)
+	if remaining.IsPositive() {
+		applied[SegmentPurchase] = applied[SegmentPurchase].Add(remaining)
+	}
+	return SegmentAllocation(applied)
+}
+
+// Total returns the amount allocated across every segment
+func (a SegmentAllocation) Total() decimal.Decimal {
+	total := decimal.Zero
+	for _, amount := range a {
+		total = total.Add(amount)
+	}
+	return total
+}
+
+// Metadata encodes the allocation for entry metadata
+func (a SegmentAllocation) Metadata() map[string]interface{} {
+	metadata := make(map[string]interface{}, len(a))
+	for segment, amount := range a {
+		metadata[string(segment)] = amount.StringFixed(2)
+	}
+	return metadata
+}
+
+// SegmentAllocationFromMetadata decodes the allocation recorded on an entry,
+// returning false if there is none
+func SegmentAllocationFromMetadata(metadata map[string]interface{}) (SegmentAllocation, bool) {
+	raw, ok := metadata[AllocationMetadataKey].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	allocation := make(SegmentAllocation, len(raw))
+	for segment, value := range raw {
+		var amount decimal.Decimal
+		switch v := value.(type) {
+		case string:
+			parsed, err := decimal.NewFromString(v)
+			if err != nil {
+				return nil, false
+			}
+			amount = parsed
+		case float64:
+			amount = decimal.NewFromFloat(v)
+		default:
+			return nil, false
+		}
+		allocation[BalanceSegment(segment)] = amount
+	}
+	return allocation, true
+}
+
+// BillingCycleSegment is one segment's line in a billing cycle's interest
+// breakdown
+type BillingCycleSegment struct {
+	Segment             BalanceSegment  `json:"segment"`
+	APR                 decimal.Decimal `json:"apr"`
+	AverageDailyBalance decimal.Decimal `json:"average_daily_balance"`
+	ClosingBalance      decimal.Decimal `json:"closing_balance"` // Before this cycle's interest
+	InterestCharge      decimal.Decimal `json:"interest_charge"`
+	GracePeriodApplied  bool            `json:"grace_period_applied"`
+	InterestEntryID     *uuid.UUID      `json:"interest_entry_id,omitempty"`
+
+	// The days at each APR, when the segment's APR changed during the cycle.
+	// APR is then the rate on the last day.
+	Rates []BillingCycleRate `json:"rates,omitempty"`
+}
+
+// BillingCycleRate is the run of days in a billing cycle that a segment
+// accrued at one APR
+type BillingCycleRate struct {
+	From                time.Time       `json:"from"`
+	To                  time.Time       `json:"to"` // Inclusive
+	APR                 decimal.Decimal `json:"apr"`
+	AverageDailyBalance decimal.Decimal `json:"average_daily_balance"` // Over these days
+	InterestCharge      decimal.Decimal `json:"interest_charge"`
+}
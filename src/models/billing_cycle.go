@@ -42,6 +42,7 @@ type BillingCycle struct {
 	PaymentsReceived     decimal.Decimal `json:"payments_received" db:"payments_received"`         // Total payments this cycle
 	PurchasesAmount      decimal.Decimal `json:"purchases_amount" db:"purchases_amount"`           // New purchases
 	CashAdvancesAmount   decimal.Decimal `json:"cash_advances_amount" db:"cash_advances_amount"`   // Cash advances
+	BalanceTransfersAmount decimal.Decimal `json:"balance_transfers_amount" db:"balance_transfers_amount"` // Balances moved onto the card
 	RefundsAmount        decimal.Decimal `json:"refunds_amount" db:"refunds_amount"`               // Credits/refunds
 	FeesAmount           decimal.Decimal `json:"fees_amount" db:"fees_amount"`                     // All fees charged
 	InterestAmount       decimal.Decimal `json:"interest_amount" db:"interest_amount"`             // Interest charges
@@ -58,6 +59,12 @@ type BillingCycle struct {
 	DaysInCycle         int             `json:"days_in_cycle" db:"days_in_cycle"`
 	APRApplied          decimal.Decimal `json:"apr_applied" db:"apr_applied"` // The APR used for this cycle
 
+	// Interest breakdown by balance segment
+	Segments []BillingCycleSegment `json:"segments,omitempty" db:"segments"`
+
+	// Regulation Z minimum payment warning for the new balance
+	Payoff *PayoffProjection `json:"payoff,omitempty" db:"payoff"`
+
 	// Payment tracking
 	PaymentsMade       decimal.Decimal `json:"payments_made" db:"payments_made"`             // Payments toward this statement
 	LastPaymentDate    *time.Time      `json:"last_payment_date" db:"last_payment_date"`
@@ -74,12 +81,13 @@ type BillingCycle struct {
 }
 
 // CalculateNewBalance computes the statement balance per GAAP
-// Formula: Previous Balance - Payments + Purchases + Cash Advances - Refunds + Fees + Interest + Adjustments - Cashback Redeemed
+// Formula: Previous Balance - Payments + Purchases + Cash Advances + Balance Transfers - Refunds + Fees + Interest + Adjustments - Cashback Redeemed
 func (bc *BillingCycle) CalculateNewBalance() decimal.Decimal {
 	balance := bc.PreviousBalance.
 		Sub(bc.PaymentsReceived).
 		Add(bc.PurchasesAmount).
 		Add(bc.CashAdvancesAmount).
+		Add(bc.BalanceTransfersAmount).
 		Sub(bc.RefundsAmount).
 		Add(bc.FeesAmount).
 		Add(bc.InterestAmount).
@@ -123,6 +131,22 @@ func (bc *BillingCycle) IsPaidInFull() bool {
 	return bc.PaymentsMade.GreaterThanOrEqual(bc.NewBalance)
 }
 
+// MinimumPaidOnTime checks if the minimum payment was met by the due date,
+// going by the date of the last payment toward the statement
+func (bc *BillingCycle) MinimumPaidOnTime() bool {
+	if !bc.MinimumPaymentMet || bc.Status == BillingCycleStatusPastDue || bc.Status == BillingCycleStatusDelinquent {
+		return false
+	}
+	return bc.LastPaymentDate == nil || !DateOf(*bc.LastPaymentDate).After(DateOf(bc.DueDate))
+}
+
+// DateOf returns midnight UTC on t's calendar date, as a Postgres DATE
+// column stores it
+func DateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
 // DailyBalanceRecord represents a daily balance snapshot for interest calculation
 type DailyBalanceRecord struct {
 	Date    time.Time       `json:"date" db:"date"`
@@ -49,6 +49,9 @@ type CashbackLedgerEntry struct {
 	// Audit
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	CreatedBy *string   `json:"created_by,omitempty" db:"created_by"`
+
+	// Tamper-evident hash chain
+	ChainLink
 }
 
 // IsEarning returns true if the entry represents earning cashback
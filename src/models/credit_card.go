@@ -46,6 +46,11 @@ type CreditCard struct {
 	PenaltyAPR        decimal.Decimal `json:"penalty_apr" db:"penalty_apr"`                 // Penalty APR for late payments
 	IntroductoryAPR   decimal.Decimal `json:"introductory_apr" db:"introductory_apr"`       // Promotional APR
 	IntroductoryEndDate *time.Time    `json:"introductory_end_date" db:"introductory_end_date"`
+	BalanceTransferAPR     decimal.Decimal `json:"balance_transfer_apr" db:"balance_transfer_apr"`           // Promotional APR on transferred balances
+	BalanceTransferEndDate *time.Time      `json:"balance_transfer_end_date" db:"balance_transfer_end_date"` // When the transfer promo expires; nil if none is active
+
+	// Effective-dated APR history, loaded with the card; see APROn
+	APRSchedule []APRChange `json:"apr_schedule,omitempty" db:"-"`
 
 	// Fee configuration
 	AnnualFee              decimal.Decimal `json:"annual_fee" db:"annual_fee"`
@@ -54,6 +59,8 @@ type CreditCard struct {
 	InternationalFeeRate   decimal.Decimal `json:"international_fee_rate" db:"international_fee_rate"` // Percentage of transaction
 	CashAdvanceFee         decimal.Decimal `json:"cash_advance_fee" db:"cash_advance_fee"`             // Flat fee or percentage
 	CashAdvanceFeeRate     decimal.Decimal `json:"cash_advance_fee_rate" db:"cash_advance_fee_rate"`   // Percentage rate
+	BalanceTransferFee     decimal.Decimal `json:"balance_transfer_fee" db:"balance_transfer_fee"`           // Flat fee minimum
+	BalanceTransferFeeRate decimal.Decimal `json:"balance_transfer_fee_rate" db:"balance_transfer_fee_rate"` // Percentage rate
 	OverLimitFee           decimal.Decimal `json:"over_limit_fee" db:"over_limit_fee"`
 
 	// Billing configuration
@@ -77,6 +84,11 @@ type CreditCard struct {
 	LastPaymentAmount  decimal.Decimal  `json:"last_payment_amount" db:"last_payment_amount"`
 	ConsecutiveLateCount int            `json:"consecutive_late_count" db:"consecutive_late_count"`
 
+	// Delinquency aging
+	DaysPastDue       int               `json:"days_past_due" db:"days_past_due"`
+	DelinquencyBucket DelinquencyBucket `json:"delinquency_bucket" db:"delinquency_bucket"`
+	ChargedOffAt      *time.Time        `json:"charged_off_at,omitempty" db:"charged_off_at"`
+
 	// Audit fields
 	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
@@ -136,19 +148,16 @@ func (c *CreditCard) HasAvailableCredit(amount decimal.Decimal) error {
 	return nil
 }
 
-// GetEffectiveAPR returns the current effective APR based on account status
+// GetEffectiveAPR returns the effective APR on a date from the card's APR
+// history. A penalty APR applies only once the penalty pricing review has
+// scheduled it; being delinquent does not reprice the card by itself.
 func (c *CreditCard) GetEffectiveAPR(now time.Time) decimal.Decimal {
-	// Check for penalty APR due to delinquency
-	if c.Status == CreditCardStatusDelinquent {
-		return c.PenaltyAPR
-	}
-
 	// Check for introductory APR
 	if c.IntroductoryEndDate != nil && now.Before(*c.IntroductoryEndDate) {
 		return c.IntroductoryAPR
 	}
 
-	return c.PurchaseAPR
+	return c.APROn(APRTypePurchase, now)
 }
 
 // GetDailyPeriodicRate converts APR to daily rate for interest calculations
@@ -194,6 +203,22 @@ func (c *CreditCard) CalculateCashAdvanceFee(amount decimal.Decimal) decimal.Dec
 	return percentFee
 }
 
+// CalculateBalanceTransferFee calculates the balance transfer fee
+// Returns the greater of: flat fee OR percentage of amount
+func (c *CreditCard) CalculateBalanceTransferFee(amount decimal.Decimal) decimal.Decimal {
+	percentFee := amount.Mul(c.BalanceTransferFeeRate).Div(decimal.NewFromInt(100))
+
+	if percentFee.LessThan(c.BalanceTransferFee) {
+		return c.BalanceTransferFee
+	}
+	return percentFee
+}
+
+// HasBalanceTransferPromo checks if a balance transfer promotion is active on a date
+func (c *CreditCard) HasBalanceTransferPromo(on time.Time) bool {
+	return c.BalanceTransferEndDate != nil && on.Before(*c.BalanceTransferEndDate)
+}
+
 // GetNextBillingPeriod calculates the next billing period dates
 func (c *CreditCard) GetNextBillingPeriod(fromDate time.Time) (startDate, endDate, dueDate time.Time) {
 	year, month, _ := fromDate.Date()
@@ -238,6 +263,9 @@ func CreditCardDefaults() CreditCard {
 		InternationalFeeRate:  decimal.NewFromFloat(3.0),
 		CashAdvanceFee:        decimal.NewFromInt(10),
 		CashAdvanceFeeRate:    decimal.NewFromFloat(5.0),
+		BalanceTransferAPR:    decimal.Zero,
+		BalanceTransferFee:    decimal.NewFromInt(5),
+		BalanceTransferFeeRate: decimal.NewFromFloat(3.0),
 		OverLimitFee:          decimal.NewFromInt(35),
 		BillingCycleType:      BillingCycleMonthly,
 		BillingCycleDay:       1,
@@ -249,5 +277,6 @@ func CreditCardDefaults() CreditCard {
 		CashbackRate:          decimal.NewFromFloat(1.5),
 		CashbackRedemptionMin: decimal.NewFromInt(25),
 		Status:                CreditCardStatusActive,
+		DelinquencyBucket:     DelinquencyBucketCurrent,
 	}
 }
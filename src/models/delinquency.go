@@ -0,0 +1,84 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// DelinquencyBucket groups cards by how far past due their oldest unpaid
+// minimum payment is
+type DelinquencyBucket string
+
+const (
+	DelinquencyBucketCurrent  DelinquencyBucket = "current"
+	DelinquencyBucket1To29    DelinquencyBucket = "1_29"
+	DelinquencyBucket30To59   DelinquencyBucket = "30_59"
+	DelinquencyBucket60To89   DelinquencyBucket = "60_89"
+	DelinquencyBucket90To119  DelinquencyBucket = "90_119"
+	DelinquencyBucket120To179 DelinquencyBucket = "120_179"
+	DelinquencyBucket180Plus  DelinquencyBucket = "180_plus"
+)
+
+const (
+	// DelinquentDays is how far past due a card is before it is delinquent
+	DelinquentDays = 30
+	// ChargeOffDays is how far past due a card is before it is charged off
+	ChargeOffDays = 180
+)
+
+// ErrCardChargedOff is returned when reopening a charged-off card or posting
+// a payment, refund, adjustment, payment return or dispute credit to it
+var ErrCardChargedOff = errors.New("card is charged off")
+
+// DelinquencyBucketFor returns the aging bucket for a number of days past due
+func DelinquencyBucketFor(daysPastDue int) DelinquencyBucket {
+	switch {
+	case daysPastDue <= 0:
+		return DelinquencyBucketCurrent
+	case daysPastDue < 30:
+		return DelinquencyBucket1To29
+	case daysPastDue < 60:
+		return DelinquencyBucket30To59
+	case daysPastDue < 90:
+		return DelinquencyBucket60To89
+	case daysPastDue < 120:
+		return DelinquencyBucket90To119
+	case daysPastDue < ChargeOffDays:
+		return DelinquencyBucket120To179
+	default:
+		return DelinquencyBucket180Plus
+	}
+}
+
+// DelinquencyRecord is one entry in a card's aging history, written each
+// time the card moves to another bucket
+type DelinquencyRecord struct {
+	ID              uuid.UUID         `json:"id" db:"id"`
+	TenantID        uuid.UUID         `json:"tenant_id" db:"tenant_id"`
+	CreditCardID    uuid.UUID         `json:"credit_card_id" db:"credit_card_id"`
+	Bucket          DelinquencyBucket `json:"bucket" db:"bucket"`
+	PreviousBucket  DelinquencyBucket `json:"previous_bucket" db:"previous_bucket"`
+	DaysPastDue     int               `json:"days_past_due" db:"days_past_due"`
+	BillingCycleID  *uuid.UUID        `json:"billing_cycle_id,omitempty" db:"billing_cycle_id"` // Oldest cycle with an unpaid minimum
+	PastDueAmount   decimal.Decimal   `json:"past_due_amount" db:"past_due_amount"`             // Unpaid minimum payments
+	ChargeOffAmount decimal.Decimal   `json:"charge_off_amount" db:"charge_off_amount"`         // Balance written off, when charged off
+	AsOf            time.Time         `json:"as_of" db:"as_of"`
+	CreatedAt       time.Time         `json:"created_at" db:"created_at"`
+}
+
+// IsChargedOff returns true if the card's balance has been charged off
+func (c *CreditCard) IsChargedOff() bool {
+	return c.ChargedOffAt != nil
+}
+
+// CanPost checks that the card's balance is still open. Once it has been
+// written off at charge-off, nothing more posts to it.
+func (c *CreditCard) CanPost() error {
+	if c.IsChargedOff() {
+		return ErrCardChargedOff
+	}
+	return nil
+}
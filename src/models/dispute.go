@@ -0,0 +1,185 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// DisputeStatus represents the status of a disputed transaction
+type DisputeStatus string
+
+const (
+	DisputeStatusOpen               DisputeStatus = "open"                // Billing error notice received
+	DisputeStatusProvisionalCredit  DisputeStatus = "provisional_credit"  // Disputed amount credited while investigated
+	DisputeStatusChargeback         DisputeStatus = "chargeback"          // Chargeback filed with the merchant's acquirer
+	DisputeStatusResolvedCardholder DisputeStatus = "resolved_cardholder" // Credit made final
+	DisputeStatusResolvedMerchant   DisputeStatus = "resolved_merchant"   // Charge upheld; any provisional credit reversed
+)
+
+// DisputeReason categorizes why the cardholder contests a charge
+type DisputeReason string
+
+const (
+	DisputeReasonUnauthorized    DisputeReason = "unauthorized"      // Cardholder did not make the charge
+	DisputeReasonNotReceived     DisputeReason = "not_received"      // Goods or services not delivered
+	DisputeReasonNotAsDescribed  DisputeReason = "not_as_described"  // Defective or not as agreed
+	DisputeReasonDuplicate       DisputeReason = "duplicate"         // Charged more than once
+	DisputeReasonIncorrectAmount DisputeReason = "incorrect_amount"  // Amount differs from the receipt
+	DisputeReasonCreditNotIssued DisputeReason = "credit_not_issued" // Promised refund never posted
+)
+
+// DisputeMetadataKey is the statement entry metadata key that links a
+// provisional credit or its reversal to the dispute
+const DisputeMetadataKey = "dispute_id"
+
+// Regulation Z (12 CFR 1026.13) billing error timelines
+const (
+	RegZNoticeDays          = 60 // Cardholder notice window after the statement is sent
+	RegZAcknowledgementDays = 30 // Written acknowledgement after the notice
+	RegZResolutionDays      = 90 // Outer limit on resolution; two complete billing cycles if sooner
+)
+
+// Dispute is a cardholder's challenge to a statement ledger entry
+type Dispute struct {
+	ID               uuid.UUID `json:"id" db:"id"`
+	TenantID         uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	CreditCardID     uuid.UUID `json:"credit_card_id" db:"credit_card_id"`
+	StatementEntryID uuid.UUID `json:"statement_entry_id" db:"statement_entry_id"` // The disputed charge
+
+	// Claim details
+	Reason      DisputeReason   `json:"reason" db:"reason"`
+	Description string          `json:"description" db:"description"`
+	Amount      decimal.Decimal `json:"amount" db:"amount"` // May be part of the charge
+
+	// Status and linked entries
+	Status                   DisputeStatus `json:"status" db:"status"`
+	ProvisionalCreditEntryID *uuid.UUID    `json:"provisional_credit_entry_id,omitempty" db:"provisional_credit_entry_id"`
+	FinalCreditEntryID       *uuid.UUID    `json:"final_credit_entry_id,omitempty" db:"final_credit_entry_id"` // Credit posted at resolution when none was provisional
+	ReversalEntryID          *uuid.UUID    `json:"reversal_entry_id,omitempty" db:"reversal_entry_id"`
+	ChargebackReference      *string       `json:"chargeback_reference,omitempty" db:"chargeback_reference"`
+	Resolution               *string       `json:"resolution,omitempty" db:"resolution"`
+
+	// Regulation Z timeline
+	StatementDate       *time.Time `json:"statement_date,omitempty" db:"statement_date"` // Statement that first showed the charge; nil if not yet billed
+	NoticeReceivedAt    time.Time  `json:"notice_received_at" db:"notice_received_at"`
+	RegZCovered         bool       `json:"reg_z_covered" db:"reg_z_covered"` // Notice arrived within RegZNoticeDays of the statement
+	AcknowledgeBy       time.Time  `json:"acknowledge_by" db:"acknowledge_by"`
+	ResolveBy           time.Time  `json:"resolve_by" db:"resolve_by"`
+	AcknowledgedAt      *time.Time `json:"acknowledged_at,omitempty" db:"acknowledged_at"`
+	ProvisionalCreditAt *time.Time `json:"provisional_credit_at,omitempty" db:"provisional_credit_at"`
+	ChargebackFiledAt   *time.Time `json:"chargeback_filed_at,omitempty" db:"chargeback_filed_at"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+
+	// Metadata and audit
+	Metadata  map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at" db:"updated_at"`
+	CreatedBy *string                `json:"created_by,omitempty" db:"created_by"`
+}
+
+// DisputeStatusTransition represents a status change in dispute history
+type DisputeStatusTransition struct {
+	ID           uuid.UUID              `json:"id" db:"id"`
+	DisputeID    uuid.UUID              `json:"dispute_id" db:"dispute_id"`
+	FromStatus   DisputeStatus          `json:"from_status" db:"from_status"` // Empty for the opening transition
+	ToStatus     DisputeStatus          `json:"to_status" db:"to_status"`
+	Reason       *string                `json:"reason,omitempty" db:"reason"`
+	TransitionAt time.Time              `json:"transition_at" db:"transition_at"`
+	TriggeredBy  *string                `json:"triggered_by,omitempty" db:"triggered_by"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty" db:"metadata"`
+}
+
+// CanTransitionTo checks if the dispute can move to a new status
+func (d *Dispute) CanTransitionTo(newStatus DisputeStatus) bool {
+	validTransitions := map[DisputeStatus][]DisputeStatus{
+		DisputeStatusOpen: {
+			DisputeStatusProvisionalCredit,
+			DisputeStatusChargeback,
+			DisputeStatusResolvedCardholder,
+			DisputeStatusResolvedMerchant,
+		},
+		DisputeStatusProvisionalCredit: {
+			DisputeStatusChargeback,
+			DisputeStatusResolvedCardholder,
+			DisputeStatusResolvedMerchant,
+		},
+		DisputeStatusChargeback: {
+			DisputeStatusResolvedCardholder,
+			DisputeStatusResolvedMerchant,
+		},
+		DisputeStatusResolvedCardholder: {}, // Terminal state
+		DisputeStatusResolvedMerchant:   {}, // Terminal state
+	}
+
+	for _, s := range validTransitions[d.Status] {
+		if s == newStatus {
+			return true
+		}
+	}
+	return false
+}
+
+// IsResolved returns true if the dispute is in a terminal state
+func (d *Dispute) IsResolved() bool {
+	return d.Status == DisputeStatusResolvedCardholder || d.Status == DisputeStatusResolvedMerchant
+}
+
+// HasProvisionalCredit returns true if the disputed amount is currently credited
+func (d *Dispute) HasProvisionalCredit() bool {
+	return d.ProvisionalCreditEntryID != nil && d.ReversalEntryID == nil
+}
+
+// InterestSuspendedOn reports whether interest on the disputed amount is
+// suspended on a day. Suspension starts with the notice and ends at
+// resolution, or earlier once a credit takes the amount off the balance.
+func (d *Dispute) InterestSuspendedOn(day time.Time) bool {
+	if day.Before(DateOf(d.NoticeReceivedAt)) {
+		return false
+	}
+	if d.ProvisionalCreditAt != nil && !day.Before(DateOf(*d.ProvisionalCreditAt)) {
+		return false
+	}
+	if d.ResolvedAt != nil && !day.Before(DateOf(*d.ResolvedAt)) {
+		return false
+	}
+	return true
+}
+
+// IsAcknowledgementOverdue returns true if a covered dispute still needs its
+// written acknowledgement after the deadline. A dispute resolved before the
+// deadline needs none.
+func (d *Dispute) IsAcknowledgementOverdue(asOf time.Time) bool {
+	if !d.RegZCovered || d.AcknowledgedAt != nil {
+		return false
+	}
+	if d.ResolvedAt != nil && !d.ResolvedAt.After(d.AcknowledgeBy) {
+		return false
+	}
+	return asOf.After(d.AcknowledgeBy)
+}
+
+// IsResolutionOverdue returns true if a covered dispute is still open after its deadline
+func (d *Dispute) IsResolutionOverdue(asOf time.Time) bool {
+	return d.RegZCovered && !d.IsResolved() && asOf.After(d.ResolveBy)
+}
+
+// SetRegZTimeline records whether the notice is covered by Regulation Z and
+// its deadlines. Resolution is due within two complete billing cycles of the
+// notice, and never more than RegZResolutionDays after it.
+func (d *Dispute) SetRegZTimeline(card *CreditCard) {
+	notice := DateOf(d.NoticeReceivedAt)
+
+	d.RegZCovered = d.StatementDate == nil ||
+		!notice.After(DateOf(*d.StatementDate).AddDate(0, 0, RegZNoticeDays))
+	d.AcknowledgeBy = notice.AddDate(0, 0, RegZAcknowledgementDays)
+
+	// The cycle in progress at the notice is not complete; count the next two
+	_, firstEnd, _ := card.GetNextBillingPeriod(notice)
+	_, secondEnd, _ := card.GetNextBillingPeriod(firstEnd.AddDate(0, 0, 1))
+	d.ResolveBy = notice.AddDate(0, 0, RegZResolutionDays)
+	if secondEnd.Before(d.ResolveBy) {
+		d.ResolveBy = secondEnd
+	}
+}
@@ -0,0 +1,260 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// GLAccountType classifies a general ledger account
+type GLAccountType string
+
+const (
+	GLAccountTypeAsset     GLAccountType = "asset"
+	GLAccountTypeLiability GLAccountType = "liability"
+	GLAccountTypeIncome    GLAccountType = "income"
+	GLAccountTypeExpense   GLAccountType = "expense"
+)
+
+// GLAccountCode identifies an account in the chart of accounts
+type GLAccountCode string
+
+const (
+	GLAccountCashClearing       GLAccountCode = "1000" // Funds in transit with the card network and banks
+	GLAccountCardReceivable     GLAccountCode = "1100" // Balances owed by cardholders
+	GLAccountCashbackLiability  GLAccountCode = "2100" // Cashback earned but not yet redeemed
+	GLAccountInterestIncome     GLAccountCode = "4000" // Interest charged to cardholders
+	GLAccountFeeIncome          GLAccountCode = "4100" // Late, failed payment, annual and other fees
+	GLAccountRecoveries         GLAccountCode = "4200" // Collected on balances already charged off
+	GLAccountChargeOffExpense   GLAccountCode = "5000" // Receivables written off as uncollectable
+	GLAccountRewardsExpense     GLAccountCode = "5100" // Cost of cashback earned, net of refunds and expiry
+	GLAccountBalanceAdjustments GLAccountCode = "5200" // Manual credits and debits to cardholder balances
+	GLAccountCollectionExpense  GLAccountCode = "5300" // Commissions kept by collections agencies
+)
+
+// GLAccount is an account in the chart of accounts
+type GLAccount struct {
+	Code GLAccountCode `json:"code" db:"code"`
+	Name string        `json:"name" db:"name"`
+	Type GLAccountType `json:"account_type" db:"account_type"`
+}
+
+// IsDebitNormal returns true if the account's balance normally sits on the debit side
+func (a GLAccount) IsDebitNormal() bool {
+	return a.Type == GLAccountTypeAsset || a.Type == GLAccountTypeExpense
+}
+
+// ChartOfAccounts lists every general ledger account, in code order
+var ChartOfAccounts = []GLAccount{
+	{Code: GLAccountCashClearing, Name: "Cash Clearing", Type: GLAccountTypeAsset},
+	{Code: GLAccountCardReceivable, Name: "Card Receivable", Type: GLAccountTypeAsset},
+	{Code: GLAccountCashbackLiability, Name: "Cashback Liability", Type: GLAccountTypeLiability},
+	{Code: GLAccountInterestIncome, Name: "Interest Income", Type: GLAccountTypeIncome},
+	{Code: GLAccountFeeIncome, Name: "Fee Income", Type: GLAccountTypeIncome},
+	{Code: GLAccountRecoveries, Name: "Charge-off Recoveries", Type: GLAccountTypeIncome},
+	{Code: GLAccountChargeOffExpense, Name: "Charge-off Expense", Type: GLAccountTypeExpense},
+	{Code: GLAccountRewardsExpense, Name: "Rewards Expense", Type: GLAccountTypeExpense},
+	{Code: GLAccountBalanceAdjustments, Name: "Balance Adjustments", Type: GLAccountTypeExpense},
+	{Code: GLAccountCollectionExpense, Name: "Collection Commissions", Type: GLAccountTypeExpense},
+}
+
+// GetGLAccount looks up an account in the chart of accounts
+func GetGLAccount(code GLAccountCode) (GLAccount, bool) {
+	for _, account := range ChartOfAccounts {
+		if account.Code == code {
+			return account, true
+		}
+	}
+	return GLAccount{}, false
+}
+
+// JournalSourceType identifies the ledger a journal entry was generated from
+type JournalSourceType string
+
+const (
+	JournalSourceStatement JournalSourceType = "statement"
+	JournalSourceCashback  JournalSourceType = "cashback"
+	JournalSourceRecovery  JournalSourceType = "recovery"
+)
+
+// JournalEntry is a balanced double-entry posting in the general ledger.
+// Each statement and cashback ledger entry, and each recovery, generates
+// exactly one.
+type JournalEntry struct {
+	ID          uuid.UUID         `json:"id" db:"id"`
+	TenantID    uuid.UUID         `json:"tenant_id" db:"tenant_id"`
+	SourceType  JournalSourceType `json:"source_type" db:"source_type"`
+	SourceID    uuid.UUID         `json:"source_id" db:"source_id"`
+	EntryDate   time.Time         `json:"entry_date" db:"entry_date"`
+	Description string            `json:"description" db:"description"`
+	Lines       []JournalLine     `json:"lines"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+}
+
+// JournalLine debits or credits a single account.
+// Exactly one of Debit and Credit is non-zero.
+type JournalLine struct {
+	AccountCode GLAccountCode   `json:"account_code" db:"account_code"`
+	Debit       decimal.Decimal `json:"debit" db:"debit"`
+	Credit      decimal.Decimal `json:"credit" db:"credit"`
+}
+
+// TotalDebits returns the sum of the entry's debit lines
+func (j *JournalEntry) TotalDebits() decimal.Decimal {
+	total := decimal.Zero
+	for _, line := range j.Lines {
+		total = total.Add(line.Debit)
+	}
+	return total
+}
+
+// TotalCredits returns the sum of the entry's credit lines
+func (j *JournalEntry) TotalCredits() decimal.Decimal {
+	total := decimal.Zero
+	for _, line := range j.Lines {
+		total = total.Add(line.Credit)
+	}
+	return total
+}
+
+// IsBalanced returns true if debits equal credits
+func (j *JournalEntry) IsBalanced() bool {
+	return j.TotalDebits().Equal(j.TotalCredits())
+}
+
+// TrialBalanceLine holds the totals posted to one account
+type TrialBalanceLine struct {
+	Account GLAccount       `json:"account"`
+	Debits  decimal.Decimal `json:"debits"`
+	Credits decimal.Decimal `json:"credits"`
+}
+
+// Balance returns the account balance on its normal side
+func (l TrialBalanceLine) Balance() decimal.Decimal {
+	if l.Account.IsDebitNormal() {
+		return l.Debits.Sub(l.Credits)
+	}
+	return l.Credits.Sub(l.Debits)
+}
+
+// TrialBalance summarizes the general ledger as of a date
+type TrialBalance struct {
+	TenantID     *uuid.UUID         `json:"tenant_id,omitempty"`
+	AsOf         time.Time          `json:"as_of"`
+	Lines        []TrialBalanceLine `json:"lines"`
+	TotalDebits  decimal.Decimal    `json:"total_debits"`
+	TotalCredits decimal.Decimal    `json:"total_credits"`
+}
+
+// Net returns total debits less total credits, which is zero for a sound ledger
+func (t *TrialBalance) Net() decimal.Decimal {
+	return t.TotalDebits.Sub(t.TotalCredits)
+}
+
+// IsBalanced returns true if the trial balance nets to zero
+func (t *TrialBalance) IsBalanced() bool {
+	return t.Net().IsZero()
+}
+
+// GLPostingRule names the accounts an entry debits and credits when its
+// signed amount is positive. Negative amounts post the other way round.
+type GLPostingRule struct {
+	Debit  GLAccountCode `json:"debit"`
+	Credit GLAccountCode `json:"credit"`
+}
+
+// GLAccountMapping maps ledger entry types to general ledger accounts
+type GLAccountMapping struct {
+	Statement map[StatementEntryType]GLPostingRule `json:"statement"`
+	Cashback  map[CashbackEntryType]GLPostingRule  `json:"cashback"`
+
+	// ControlAccount carries the cardholder statement balance; its net change
+	// must match the statement ledger
+	ControlAccount GLAccountCode `json:"control_account"`
+
+	// AccountNames overrides chart of account names in exports
+	AccountNames map[GLAccountCode]string `json:"account_names,omitempty"`
+}
+
+// DefaultGLAccountMapping returns the mapping used when posting to the general ledger
+func DefaultGLAccountMapping() *GLAccountMapping {
+	receivable := func(offset GLAccountCode) GLPostingRule {
+		return GLPostingRule{Debit: GLAccountCardReceivable, Credit: offset}
+	}
+	liability := func(offset GLAccountCode) GLPostingRule {
+		return GLPostingRule{Debit: offset, Credit: GLAccountCashbackLiability}
+	}
+
+	return &GLAccountMapping{
+		Statement: map[StatementEntryType]GLPostingRule{
+			EntryTypeTransaction:        receivable(GLAccountCashClearing),
+			EntryTypeCashAdvance:        receivable(GLAccountCashClearing),
+			EntryTypeBalanceTransfer:    receivable(GLAccountCashClearing),
+			EntryTypePayment:            receivable(GLAccountCashClearing),
+			EntryTypeRefund:             receivable(GLAccountCashClearing),
+			EntryTypeFeeInterest:        receivable(GLAccountInterestIncome),
+			EntryTypeFeeLate:            receivable(GLAccountFeeIncome),
+			EntryTypeFeeFailed:          receivable(GLAccountFeeIncome),
+			EntryTypeFeeInternational:   receivable(GLAccountFeeIncome),
+			EntryTypeFeeOverLimit:       receivable(GLAccountFeeIncome),
+			EntryTypeFeeAnnual:          receivable(GLAccountFeeIncome),
+			EntryTypeFeeCashAdvance:     receivable(GLAccountFeeIncome),
+			EntryTypeFeeBalanceTransfer: receivable(GLAccountFeeIncome),
+			// Redemptions settle through clearing; the cashback ledger moves the liability
+			EntryTypeReward:           receivable(GLAccountCashClearing),
+			EntryTypeReturnedReward:   receivable(GLAccountCashClearing),
+			EntryTypeCashbackEarned:   receivable(GLAccountCashClearing),
+			EntryTypeCashbackRedeemed: receivable(GLAccountCashClearing),
+			EntryTypeAdjustment:       receivable(GLAccountBalanceAdjustments),
+			EntryTypeCredit:           receivable(GLAccountBalanceAdjustments),
+			EntryTypeChargeOff:        receivable(GLAccountChargeOffExpense),
+		},
+		Cashback: map[CashbackEntryType]GLPostingRule{
+			CashbackEarned:            liability(GLAccountRewardsExpense),
+			CashbackEarnedRefund:      liability(GLAccountRewardsExpense),
+			CashbackExpired:           liability(GLAccountRewardsExpense),
+			CashbackAdjustment:        liability(GLAccountRewardsExpense),
+			CashbackRedeemed:          liability(GLAccountCashClearing),
+			CashbackRedeemedCancelled: liability(GLAccountCashClearing),
+		},
+		ControlAccount: GLAccountCardReceivable,
+	}
+}
+
+// StatementRule returns the posting rule for a statement entry.
+// Adjustments and credits that correct another entry post against that
+// entry's accounts: a fee waiver reverses the fee's income account and a
+// returned payment reverses the payment's cash.
+func (m *GLAccountMapping) StatementRule(entry *StatementLedgerEntry) (GLPostingRule, bool) {
+	entryType := entry.EntryType
+	if entryType == EntryTypeAdjustment || entryType == EntryTypeCredit {
+		if feeType, ok := entry.Metadata["original_fee_type"].(string); ok {
+			entryType = StatementEntryType(feeType)
+		} else if _, ok := entry.Metadata["payment_id"]; ok {
+			entryType = EntryTypePayment
+		} else if _, ok := entry.Metadata["original_payment_id"]; ok {
+			entryType = EntryTypePayment
+		}
+	}
+
+	rule, ok := m.Statement[entryType]
+	return rule, ok
+}
+
+// CashbackRule returns the posting rule for a cashback entry
+func (m *GLAccountMapping) CashbackRule(entry *CashbackLedgerEntry) (GLPostingRule, bool) {
+	rule, ok := m.Cashback[entry.EntryType]
+	return rule, ok
+}
+
+// AccountName returns the export name of an account
+func (m *GLAccountMapping) AccountName(code GLAccountCode) string {
+	if name, ok := m.AccountNames[code]; ok {
+		return name
+	}
+	if account, ok := GetGLAccount(code); ok {
+		return account.Name
+	}
+	return string(code)
+}
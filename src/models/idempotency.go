@@ -0,0 +1,19 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyRecord remembers the result of a money-moving call so that a
+// retried request with the same key replays it instead of posting again
+type IdempotencyRecord struct {
+	ID        uuid.UUID       `json:"id" db:"id"`
+	TenantID  uuid.UUID       `json:"tenant_id" db:"tenant_id"`
+	Key       string          `json:"idempotency_key" db:"idempotency_key"`
+	Operation string          `json:"operation" db:"operation"` // record_transaction, record_payment, etc.
+	Response  json.RawMessage `json:"response" db:"response"`
+	CreatedAt time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// LedgerName identifies one of the hash-chained ledgers
+type LedgerName string
+
+const (
+	LedgerStatement LedgerName = "statement"
+	LedgerCashback  LedgerName = "cashback"
+	LedgerPoints    LedgerName = "points"
+)
+
+// ChainLink places a ledger entry in its tenant's hash chain.
+// Each ledger (statement, cashback, points) keeps one chain per tenant:
+// sequence numbers start at 1 with no gaps, and each entry's hash covers its
+// immutable fields and the hash of the entry before it. Status and clearing
+// time change after posting, so they are not hashed.
+type ChainLink struct {
+	Sequence int64  `json:"sequence" db:"sequence"`
+	PrevHash string `json:"prev_hash,omitempty" db:"prev_hash"`
+	Hash     string `json:"hash,omitempty" db:"hash"`
+}
+
+// IsSealed returns true if the entry was hashed when it was written.
+// Entries posted before the chain existed have a sequence but no hash.
+func (l ChainLink) IsSealed() bool {
+	return l.Hash != ""
+}
+
+// chainHash hashes the link's position and the entry's canonical fields
+func chainHash(sequence int64, prevHash string, fields interface{}) string {
+	payload, err := json.Marshal(struct {
+		Sequence int64       `json:"sequence"`
+		PrevHash string      `json:"prev_hash"`
+		Fields   interface{} `json:"fields"`
+	}{sequence, prevHash, fields})
+	if err != nil {
+		// Every field is a string or a JSON-decoded metadata value
+		panic("ledger chain: " + err.Error())
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// Canonical encodings match what survives a round trip through Postgres:
+// timestamps to the microsecond, dates without a time, and amounts at the
+// column's scale
+
+func chainTime(t time.Time) string {
+	return t.UTC().Round(time.Microsecond).Format(time.RFC3339Nano)
+}
+
+func chainDate(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func chainUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func chainString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func chainDecimal(d *decimal.Decimal, scale int32) string {
+	if d == nil {
+		return ""
+	}
+	return d.StringFixed(scale)
+}
+
+// ComputeHash returns the chain hash of the entry as it is now
+func (e *StatementLedgerEntry) ComputeHash() string {
+	return chainHash(e.Sequence, e.PrevHash, map[string]interface{}{
+		"id":           e.ID.String(),
+		"tenant_id":    e.TenantID.String(),
+		"statement_id": chainUUID(e.StatementID),
+		"entry_type":   string(e.EntryType),
+		"entry_date":   chainTime(e.EntryDate),
+		"posting_date": chainDate(e.PostingDate),
+		"amount":       e.Amount.StringFixed(2),
+		"description":  e.Description,
+		"reference_id": chainString(e.ReferenceID),
+		"metadata":     e.Metadata,
+		"created_by":   chainString(e.CreatedBy),
+	})
+}
+
+// Seal appends the entry to its chain after the entry with prevHash
+func (e *StatementLedgerEntry) Seal(sequence int64, prevHash string) {
+	e.Sequence = sequence
+	e.PrevHash = prevHash
+	e.Hash = e.ComputeHash()
+}
+
+// ComputeHash returns the chain hash of the entry as it is now
+func (c *CashbackLedgerEntry) ComputeHash() string {
+	return chainHash(c.Sequence, c.PrevHash, map[string]interface{}{
+		"id":                 c.ID.String(),
+		"tenant_id":          c.TenantID.String(),
+		"credit_card_id":     c.CreditCardID.String(),
+		"statement_entry_id": chainUUID(c.StatementEntryID),
+		"entry_type":         string(c.EntryType),
+		"entry_date":         chainTime(c.EntryDate),
+		"amount":             c.Amount.StringFixed(2),
+		"description":        c.Description,
+		"reference_id":       chainString(c.ReferenceID),
+		"transaction_amount": chainDecimal(c.TransactionAmount, 2),
+		"cashback_rate":      chainDecimal(c.CashbackRate, 2),
+		"category_bonus":     chainDecimal(c.CategoryBonus, 2),
+		"metadata":           c.Metadata,
+		"created_by":         chainString(c.CreatedBy),
+	})
+}
+
+// Seal appends the entry to its chain after the entry with prevHash
+func (c *CashbackLedgerEntry) Seal(sequence int64, prevHash string) {
+	c.Sequence = sequence
+	c.PrevHash = prevHash
+	c.Hash = c.ComputeHash()
+}
+
+// ComputeHash returns the chain hash of the entry as it is now
+func (p *PointsLedgerEntry) ComputeHash() string {
+	return chainHash(p.Sequence, p.PrevHash, map[string]interface{}{
+		"id":                    p.ID.String(),
+		"tenant_id":             p.TenantID.String(),
+		"statement_entry_id":    chainUUID(p.StatementEntryID),
+		"entry_type":            string(p.EntryType),
+		"entry_date":            chainTime(p.EntryDate),
+		"points":                p.Points,
+		"description":           p.Description,
+		"external_platform":     chainString(p.ExternalPlatform),
+		"external_reference_id": chainString(p.ExternalReferenceID),
+		"transaction_amount":    chainDecimal(p.TransactionAmount, 2),
+		"points_rate":           chainDecimal(p.PointsRate, 4),
+		"metadata":              p.Metadata,
+		"created_by":            chainString(p.CreatedBy),
+	})
+}
+
+// Seal appends the entry to its chain after the entry with prevHash
+func (p *PointsLedgerEntry) Seal(sequence int64, prevHash string) {
+	p.Sequence = sequence
+	p.PrevHash = prevHash
+	p.Hash = p.ComputeHash()
+}
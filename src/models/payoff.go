@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PayoffMaxMonths bounds payoff projections. A plan that has not repaid the
+// balance by then is treated as never repaying it.
+const PayoffMaxMonths = 1200
+
+// PayoffThirtySixMonths is the repayment period of the Regulation Z
+// comparison payment
+const PayoffThirtySixMonths = 36
+
+// PayoffScenario is the cost of repaying a balance on one payment plan,
+// assuming no further charges
+type PayoffScenario struct {
+	MonthlyPayment decimal.Decimal `json:"monthly_payment"` // First payment; minimum payments shrink with the balance
+	Months         int             `json:"months"`
+	TotalInterest  decimal.Decimal `json:"total_interest"`
+	TotalCost      decimal.Decimal `json:"total_cost"`     // Balance plus interest
+	NeverPaysOff   bool            `json:"never_pays_off"` // Payments do not cover the interest
+}
+
+// PayoffYears returns the repayment period as printed on statements: whole
+// years, rounded to the nearest year, or "less than 1 year"
+func (s PayoffScenario) PayoffYears() string {
+	if s.NeverPaysOff {
+		return "never"
+	}
+	years := (s.Months + 6) / 12
+	switch years {
+	case 0:
+		return "less than 1 year"
+	case 1:
+		return "1 year"
+	default:
+		return fmt.Sprintf("%d years", years)
+	}
+}
+
+// PayoffProjection is the Regulation Z minimum payment repayment estimate
+// for a statement balance
+type PayoffProjection struct {
+	Balance     decimal.Decimal `json:"balance"`
+	APR         decimal.Decimal `json:"apr"` // APR at the statement date
+	MinimumOnly PayoffScenario  `json:"minimum_only"`
+
+	// Nil when minimum payments repay the balance within 36 months, or
+	// never repay it
+	ThirtySixMonth *PayoffScenario `json:"thirty_six_month,omitempty"`
+}
+
+// Savings returns how much less the 36-month plan costs than paying only
+// the minimum
+func (p *PayoffProjection) Savings() decimal.Decimal {
+	if p.ThirtySixMonth == nil {
+		return decimal.Zero
+	}
+	return p.MinimumOnly.TotalCost.Sub(p.ThirtySixMonth.TotalCost)
+}
+
+// PayoffStrategy is how a payoff calculation sets the monthly payment
+type PayoffStrategy string
+
+const (
+	// PayoffStrategyFixedPayment pays the same amount every month
+	PayoffStrategyFixedPayment PayoffStrategy = "fixed_payment"
+	// PayoffStrategyMinimumOnly pays each month's minimum payment
+	PayoffStrategyMinimumOnly PayoffStrategy = "minimum_only"
+	// PayoffStrategyTargetDate pays the smallest level amount that repays the
+	// balance by a target date
+	PayoffStrategyTargetDate PayoffStrategy = "target_date"
+)
+
+// PayoffScheduleMonth is one month of an amortization schedule. Interest
+// accrues on the opening balance until the payment date, then the payment
+// is applied.
+type PayoffScheduleMonth struct {
+	Month          int             `json:"month"`
+	PaymentDate    time.Time       `json:"payment_date"`
+	APR            decimal.Decimal `json:"apr"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+	Interest       decimal.Decimal `json:"interest"`
+	Payment        decimal.Decimal `json:"payment"`
+	Principal      decimal.Decimal `json:"principal"` // Payment less interest; negative when the balance grows
+	ClosingBalance decimal.Decimal `json:"closing_balance"`
+}
+
+// PayoffSchedule is the month-by-month repayment of a balance on one payment
+// plan, assuming no further charges
+type PayoffSchedule struct {
+	Strategy       PayoffStrategy        `json:"strategy"`
+	Balance        decimal.Decimal       `json:"balance"`
+	AsOf           time.Time             `json:"as_of"`
+	MonthlyPayment decimal.Decimal       `json:"monthly_payment"` // First payment; minimum payments shrink with the balance
+	Months         int                   `json:"months"`          // Zero when the plan never repays the balance
+	TotalInterest  decimal.Decimal       `json:"total_interest"`
+	TotalPaid      decimal.Decimal       `json:"total_paid"`
+	PayoffDate     *time.Time            `json:"payoff_date,omitempty"`
+	NeverPaysOff   bool                  `json:"never_pays_off"`
+	Schedule       []PayoffScheduleMonth `json:"schedule"` // Stops at the first month the balance grows when NeverPaysOff
+}
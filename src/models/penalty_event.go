@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// PenaltyTrigger is what put a card on its penalty APR
+type PenaltyTrigger string
+
+const (
+	PenaltyTriggerLatePayment     PenaltyTrigger = "late_payment"     // Minimum payment not received in time
+	PenaltyTriggerReturnedPayment PenaltyTrigger = "returned_payment" // Payment returned unpaid
+)
+
+// PenaltyEventType records whether the penalty APR was applied or cured
+type PenaltyEventType string
+
+const (
+	PenaltyEventApplied PenaltyEventType = "applied"
+	PenaltyEventCured   PenaltyEventType = "cured"
+)
+
+// PenaltyEvent records a card going onto or coming off its penalty APR.
+// The rates themselves change through the card's APR history.
+type PenaltyEvent struct {
+	ID           uuid.UUID        `json:"id" db:"id"`
+	TenantID     uuid.UUID        `json:"tenant_id" db:"tenant_id"`
+	CreditCardID uuid.UUID        `json:"credit_card_id" db:"credit_card_id"`
+	EventType    PenaltyEventType `json:"event_type" db:"event_type"`
+
+	// Trigger details, for applied events
+	Trigger        PenaltyTrigger `json:"trigger,omitempty" db:"trigger"`
+	BillingCycleID *uuid.UUID     `json:"billing_cycle_id,omitempty" db:"billing_cycle_id"` // Cycle whose minimum payment was late
+	PaymentID      *uuid.UUID     `json:"payment_id,omitempty" db:"payment_id"`             // Returned payment
+	DaysLate       int            `json:"days_late,omitempty" db:"days_late"`
+
+	// Consecutive on-time minimum payments, for cured events
+	OnTimePayments int `json:"on_time_payments,omitempty" db:"on_time_payments"`
+
+	PenaltyAPR          decimal.Decimal `json:"penalty_apr" db:"penalty_apr"`
+	PriorPurchaseAPR    decimal.Decimal `json:"prior_purchase_apr" db:"prior_purchase_apr"`         // Restored on cure
+	PriorCashAdvanceAPR decimal.Decimal `json:"prior_cash_advance_apr" db:"prior_cash_advance_apr"` // Restored on cure
+
+	OccurredAt    time.Time `json:"occurred_at" db:"occurred_at"`       // When the trigger or cure happened
+	EffectiveDate time.Time `json:"effective_date" db:"effective_date"` // When the rates change
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsApplied returns true if the event put the card on its penalty APR
+func (e *PenaltyEvent) IsApplied() bool {
+	return e.EventType == PenaltyEventApplied
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+var (
+	// ErrNotChargedOff is returned when recording a recovery on a card that
+	// has not been charged off
+	ErrNotChargedOff = errors.New("card is not charged off")
+	// ErrRecoveryExceedsBalance is returned when a recovery is more than the
+	// charged-off balance still owed
+	ErrRecoveryExceedsBalance = errors.New("recovery exceeds the remaining charged-off balance")
+)
+
+// ChargeOff is a charged-off balance held for collection. What was written
+// off is split into principal, interest and fees, and each part is paid down
+// as recoveries come in.
+type ChargeOff struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	TenantID     uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	CreditCardID uuid.UUID `json:"credit_card_id" db:"credit_card_id"`
+
+	// Written off
+	Principal decimal.Decimal `json:"principal" db:"principal"`
+	Interest  decimal.Decimal `json:"interest" db:"interest"`
+	Fees      decimal.Decimal `json:"fees" db:"fees"`
+
+	// Still owed after recoveries
+	PrincipalRemaining decimal.Decimal `json:"principal_remaining" db:"principal_remaining"`
+	InterestRemaining  decimal.Decimal `json:"interest_remaining" db:"interest_remaining"`
+	FeesRemaining      decimal.Decimal `json:"fees_remaining" db:"fees_remaining"`
+	Recovered          decimal.Decimal `json:"recovered" db:"recovered"` // Gross amount collected to date
+
+	ChargedOffOn time.Time `json:"charged_off_on" db:"charged_off_on"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Amount returns the total balance written off
+func (c *ChargeOff) Amount() decimal.Decimal {
+	return c.Principal.Add(c.Interest).Add(c.Fees)
+}
+
+// Remaining returns the charged-off balance still owed
+func (c *ChargeOff) Remaining() decimal.Decimal {
+	return c.PrincipalRemaining.Add(c.InterestRemaining).Add(c.FeesRemaining)
+}
+
+// ApplyRecovery pays down the remaining balance with the recovery's gross
+// amount, principal first, then interest, then fees, and records the split
+// on the recovery
+func (c *ChargeOff) ApplyRecovery(recovery *Recovery) error {
+	if recovery.Amount.GreaterThan(c.Remaining()) {
+		return ErrRecoveryExceedsBalance
+	}
+
+	left := recovery.Amount
+	take := func(remaining *decimal.Decimal) decimal.Decimal {
+		paid := decimal.Min(left, *remaining)
+		*remaining = remaining.Sub(paid)
+		left = left.Sub(paid)
+		return paid
+	}
+	recovery.PrincipalAmount = take(&c.PrincipalRemaining)
+	recovery.InterestAmount = take(&c.InterestRemaining)
+	recovery.FeesAmount = take(&c.FeesRemaining)
+	c.Recovered = c.Recovered.Add(recovery.Amount)
+	return nil
+}
+
+// Recovery is money collected on a charged-off balance, either in house or
+// by a collections agency that keeps a commission
+type Recovery struct {
+	ID           uuid.UUID `json:"id" db:"id"`
+	TenantID     uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	CreditCardID uuid.UUID `json:"credit_card_id" db:"credit_card_id"`
+	ChargeOffID  uuid.UUID `json:"charge_off_id" db:"charge_off_id"`
+
+	Agency      string  `json:"agency,omitempty" db:"agency"` // Empty when collected in house
+	ReferenceID *string `json:"reference_id,omitempty" db:"reference_id"`
+
+	// Amount is the gross amount collected from the cardholder. The agency
+	// keeps the commission and remits the net amount.
+	Amount         decimal.Decimal `json:"amount" db:"amount"`
+	CommissionRate decimal.Decimal `json:"commission_rate" db:"commission_rate"` // Percentage, e.g. 25.00 = 25%
+	Commission     decimal.Decimal `json:"commission" db:"commission"`
+	NetAmount      decimal.Decimal `json:"net_amount" db:"net_amount"`
+
+	// Charged-off balance the amount paid down
+	PrincipalAmount decimal.Decimal `json:"principal_amount" db:"principal_amount"`
+	InterestAmount  decimal.Decimal `json:"interest_amount" db:"interest_amount"`
+	FeesAmount      decimal.Decimal `json:"fees_amount" db:"fees_amount"`
+
+	RecoveredOn time.Time `json:"recovered_on" db:"recovered_on"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// SplitCommission works out the agency's commission and the net amount
+// remitted from the gross amount and commission rate
+func (r *Recovery) SplitCommission() {
+	r.Commission = r.Amount.Mul(r.CommissionRate).Div(decimal.NewFromInt(100)).Round(2)
+	r.NetAmount = r.Amount.Sub(r.Commission)
+}
@@ -24,10 +24,13 @@ const (
 	EntryTypeFeeAnnual         StatementEntryType = "fee_annual"
 	EntryTypeFeeCashAdvance    StatementEntryType = "fee_cash_advance"
 	EntryTypeCashAdvance       StatementEntryType = "cash_advance"
+	EntryTypeBalanceTransfer   StatementEntryType = "balance_transfer"
+	EntryTypeFeeBalanceTransfer StatementEntryType = "fee_balance_transfer"
 	EntryTypeCashbackEarned    StatementEntryType = "cashback_earned"
 	EntryTypeCashbackRedeemed  StatementEntryType = "cashback_redeemed"
 	EntryTypeAdjustment        StatementEntryType = "adjustment"
 	EntryTypeCredit            StatementEntryType = "credit"
+	EntryTypeChargeOff         StatementEntryType = "charge_off"
 )
 
 // StatementLedgerEntry represents a single entry in the statement ledger
@@ -57,6 +60,9 @@ type StatementLedgerEntry struct {
 	// Audit
 	CreatedAt   time.Time          `json:"created_at" db:"created_at"`
 	CreatedBy   *string            `json:"created_by,omitempty" db:"created_by"`
+
+	// Tamper-evident hash chain
+	ChainLink
 }
 
 // EntryStatus represents the status of a ledger entry
@@ -74,10 +80,11 @@ func (e *StatementLedgerEntry) IsDebit() bool {
 	case EntryTypeTransaction, EntryTypeCashAdvance,
 		EntryTypeFeeLate, EntryTypeFeeFailed, EntryTypeFeeInternational,
 		EntryTypeFeeInterest, EntryTypeFeeOverLimit, EntryTypeFeeAnnual,
-		EntryTypeFeeCashAdvance, EntryTypeReturnedReward:
+		EntryTypeFeeCashAdvance, EntryTypeReturnedReward,
+		EntryTypeBalanceTransfer, EntryTypeFeeBalanceTransfer:
 		return true
 	case EntryTypePayment, EntryTypeRefund, EntryTypeReward, EntryTypeCredit,
-		EntryTypeCashbackRedeemed:
+		EntryTypeCashbackRedeemed, EntryTypeChargeOff:
 		return false
 	case EntryTypeAdjustment, EntryTypeCashbackEarned:
 		// Adjustments and cashback earned can be either debit or credit based on amount sign
@@ -0,0 +1,358 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type creditCardRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the credit card
+func (r *creditCardRepo) Create(ctx context.Context, card *models.CreditCard) error {
+	if card.ID == uuid.Nil {
+		card.ID = uuid.New()
+	}
+	if card.CreatedAt.IsZero() {
+		card.CreatedAt = time.Now()
+	}
+
+	return r.s.write(func(d *data) error {
+		if _, ok := d.cards[card.ID]; ok {
+			return fmt.Errorf("credit card %s already exists", card.ID)
+		}
+		stored := *card
+		stored.APRSchedule = nil // Kept in the APR change table
+		d.cards[card.ID] = stored
+		return nil
+	})
+}
+
+// GetByID retrieves a credit card by ID
+func (r *creditCardRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.CreditCard, error) {
+	var card *models.CreditCard
+	err := r.s.read(func(d *data) error {
+		c, ok := d.cards[id]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		card = withAPRSchedule(d, c)
+		return nil
+	})
+	return card, err
+}
+
+// GetForUpdate retrieves a credit card. Transactions on the memory store are
+// already serialized, so no further locking is needed.
+func (r *creditCardRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*models.CreditCard, error) {
+	return r.GetByID(ctx, id)
+}
+
+// List retrieves credit cards matching the filter
+func (r *creditCardRepo) List(
+	ctx context.Context,
+	filter repository.CreditCardFilter,
+) ([]*models.CreditCard, error) {
+	var cards []*models.CreditCard
+	err := r.s.read(func(d *data) error {
+		for _, c := range d.cards {
+			if filter.TenantID != nil && c.TenantID != *filter.TenantID {
+				continue
+			}
+			if !contains(filter.Statuses, c.Status) {
+				continue
+			}
+			cards = append(cards, withAPRSchedule(d, c))
+		}
+		return nil
+	})
+
+	sort.Slice(cards, func(i, j int) bool {
+		if !cards[i].CreatedAt.Equal(cards[j].CreatedAt) {
+			return cards[i].CreatedAt.Before(cards[j].CreatedAt)
+		}
+		return cards[i].ID.String() < cards[j].ID.String()
+	})
+
+	return cards, err
+}
+
+// Update replaces the stored card
+func (r *creditCardRepo) Update(ctx context.Context, card *models.CreditCard) error {
+	return r.s.write(func(d *data) error {
+		existing, ok := d.cards[card.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		updated := *card
+		updated.TenantID = existing.TenantID
+		updated.CardNumber = existing.CardNumber
+		updated.CreatedAt = existing.CreatedAt
+		updated.APRSchedule = nil
+		d.cards[card.ID] = updated
+		return nil
+	})
+}
+
+type billingCycleRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the billing cycle
+func (r *billingCycleRepo) Create(ctx context.Context, cycle *models.BillingCycle) error {
+	if cycle.ID == uuid.Nil {
+		cycle.ID = uuid.New()
+	}
+
+	return r.s.write(func(d *data) error {
+		for _, c := range d.cycles {
+			if c.CreditCardID == cycle.CreditCardID && c.CycleNumber == cycle.CycleNumber {
+				return fmt.Errorf("billing cycle %d already exists for card %s", cycle.CycleNumber, cycle.CreditCardID)
+			}
+		}
+		d.cycles[cycle.ID] = *cycle
+		return nil
+	})
+}
+
+// GetByID retrieves a billing cycle by ID
+func (r *billingCycleRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.BillingCycle, error) {
+	var cycle *models.BillingCycle
+	err := r.s.read(func(d *data) error {
+		c, ok := d.cycles[id]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		cycle = &c
+		return nil
+	})
+	return cycle, err
+}
+
+// List retrieves billing cycles matching the filter
+func (r *billingCycleRepo) List(
+	ctx context.Context,
+	filter repository.BillingCycleFilter,
+) ([]*models.BillingCycle, error) {
+	var cycles []*models.BillingCycle
+	err := r.s.read(func(d *data) error {
+		for _, c := range d.cycles {
+			if filter.CreditCardID != nil && c.CreditCardID != *filter.CreditCardID {
+				continue
+			}
+			if filter.CycleNumber != nil && c.CycleNumber != *filter.CycleNumber {
+				continue
+			}
+			if filter.MinimumPaymentMet != nil && c.MinimumPaymentMet != *filter.MinimumPaymentMet {
+				continue
+			}
+			if filter.DueBefore != nil && !c.DueDate.Before(*filter.DueBefore) {
+				continue
+			}
+			if !contains(filter.Statuses, c.Status) {
+				continue
+			}
+			cycle := c
+			cycles = append(cycles, &cycle)
+		}
+		return nil
+	})
+
+	sort.Slice(cycles, func(i, j int) bool {
+		if cycles[i].CycleNumber != cycles[j].CycleNumber {
+			return cycles[i].CycleNumber < cycles[j].CycleNumber
+		}
+		return cycles[i].CreditCardID.String() < cycles[j].CreditCardID.String()
+	})
+
+	return newestFirst(cycles, filter.Limit), err
+}
+
+// Update replaces the stored billing cycle
+func (r *billingCycleRepo) Update(ctx context.Context, cycle *models.BillingCycle) error {
+	return r.s.write(func(d *data) error {
+		existing, ok := d.cycles[cycle.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		updated := *cycle
+		updated.CreditCardID = existing.CreditCardID
+		updated.TenantID = existing.TenantID
+		updated.CycleNumber = existing.CycleNumber
+		updated.CycleType = existing.CycleType
+		updated.CycleStartDate = existing.CycleStartDate
+		updated.CycleEndDate = existing.CycleEndDate
+		updated.CreatedAt = existing.CreatedAt
+		d.cycles[cycle.ID] = updated
+		return nil
+	})
+}
+
+type paymentRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the payment
+func (r *paymentRepo) Create(ctx context.Context, payment *models.Payment) error {
+	if payment.ID == uuid.Nil {
+		payment.ID = uuid.New()
+	}
+
+	stored := *payment
+	stored.Metadata = copyMap(payment.Metadata)
+
+	return r.s.write(func(d *data) error {
+		for _, p := range d.payments {
+			if p.PaymentNumber == payment.PaymentNumber {
+				return fmt.Errorf("payment number %s already exists", payment.PaymentNumber)
+			}
+		}
+		d.payments[payment.ID] = stored
+		return nil
+	})
+}
+
+// GetByID retrieves a payment by ID
+func (r *paymentRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	var payment *models.Payment
+	err := r.s.read(func(d *data) error {
+		p, ok := d.payments[id]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		p.Metadata = copyMap(p.Metadata)
+		payment = &p
+		return nil
+	})
+	return payment, err
+}
+
+// GetByNumber retrieves a payment by its payment number
+func (r *paymentRepo) GetByNumber(ctx context.Context, paymentNumber string) (*models.Payment, error) {
+	var payment *models.Payment
+	err := r.s.read(func(d *data) error {
+		for _, p := range d.payments {
+			if p.PaymentNumber == paymentNumber {
+				p.Metadata = copyMap(p.Metadata)
+				payment = &p
+				return nil
+			}
+		}
+		return repository.ErrNotFound
+	})
+	return payment, err
+}
+
+// List retrieves payments matching the filter
+func (r *paymentRepo) List(
+	ctx context.Context,
+	filter repository.PaymentFilter,
+) ([]*models.Payment, error) {
+	var payments []*models.Payment
+	err := r.s.read(func(d *data) error {
+		for _, p := range d.payments {
+			if filter.TenantID != nil && p.TenantID != *filter.TenantID {
+				continue
+			}
+			if filter.CreditCardID != nil && p.CreditCardID != *filter.CreditCardID {
+				continue
+			}
+			if !contains(filter.Statuses, p.Status) {
+				continue
+			}
+			payment := p
+			payment.Metadata = copyMap(p.Metadata)
+			payments = append(payments, &payment)
+		}
+		return nil
+	})
+
+	sort.Slice(payments, func(i, j int) bool {
+		a, b := payments[i], payments[j]
+		if !a.InitiatedAt.Equal(b.InitiatedAt) {
+			return a.InitiatedAt.Before(b.InitiatedAt)
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.PaymentNumber < b.PaymentNumber
+	})
+
+	return payments, err
+}
+
+// Update replaces the stored payment
+func (r *paymentRepo) Update(ctx context.Context, payment *models.Payment) error {
+	return r.s.write(func(d *data) error {
+		existing, ok := d.payments[payment.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		updated := *payment
+		updated.TenantID = existing.TenantID
+		updated.CreditCardID = existing.CreditCardID
+		updated.PaymentNumber = existing.PaymentNumber
+		updated.Amount = existing.Amount
+		updated.Currency = existing.Currency
+		updated.PaymentType = existing.PaymentType
+		updated.PaymentMethod = existing.PaymentMethod
+		updated.SourceAccountLast4 = existing.SourceAccountLast4
+		updated.SourceRoutingLast4 = existing.SourceRoutingLast4
+		updated.SourceBankName = existing.SourceBankName
+		updated.ScheduledDate = existing.ScheduledDate
+		updated.InitiatedAt = existing.InitiatedAt
+		updated.MaxRetries = existing.MaxRetries
+		updated.CreatedAt = existing.CreatedAt
+		updated.CreatedBy = existing.CreatedBy
+		updated.Metadata = copyMap(payment.Metadata)
+		d.payments[payment.ID] = updated
+		return nil
+	})
+}
+
+// CreateTransition stores a copy of the status transition
+func (r *paymentRepo) CreateTransition(ctx context.Context, transition *models.PaymentStatusTransition) error {
+	if transition.ID == uuid.Nil {
+		transition.ID = uuid.New()
+	}
+
+	stored := *transition
+	stored.Metadata = copyMap(transition.Metadata)
+
+	return r.s.write(func(d *data) error {
+		d.transitions = append(d.transitions, stored)
+		return nil
+	})
+}
+
+// ListTransitions retrieves a payment's status history, oldest first
+func (r *paymentRepo) ListTransitions(
+	ctx context.Context,
+	paymentID uuid.UUID,
+) ([]*models.PaymentStatusTransition, error) {
+	var transitions []*models.PaymentStatusTransition
+	err := r.s.read(func(d *data) error {
+		for _, t := range d.transitions {
+			if t.PaymentID == paymentID {
+				transition := t
+				transition.Metadata = copyMap(t.Metadata)
+				transitions = append(transitions, &transition)
+			}
+		}
+		return nil
+	})
+
+	// Insertion order breaks ties between transitions recorded at the same instant
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].TransitionAt.Before(transitions[j].TransitionAt)
+	})
+
+	return transitions, err
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+type aprChangeRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the APR change
+func (r *aprChangeRepo) Create(ctx context.Context, change *models.APRChange) error {
+	if change.ID == uuid.Nil {
+		change.ID = uuid.New()
+	}
+	if change.CreatedAt.IsZero() {
+		change.CreatedAt = time.Now()
+	}
+
+	stored := *change
+	stored.EffectiveDate = models.DateOf(change.EffectiveDate)
+
+	return r.s.write(func(d *data) error {
+		d.aprChanges = append(d.aprChanges, stored)
+		return nil
+	})
+}
+
+// List retrieves the cards' APR changes ordered by effective date
+func (r *aprChangeRepo) List(ctx context.Context, creditCardIDs ...uuid.UUID) ([]*models.APRChange, error) {
+	var changes []*models.APRChange
+	err := r.s.read(func(d *data) error {
+		changes = aprChangesOf(d, creditCardIDs...)
+		return nil
+	})
+	return changes, err
+}
+
+// CreateNotice stores a copy of the change-in-terms notice
+func (r *aprChangeRepo) CreateNotice(ctx context.Context, notice *models.ChangeInTermsNotice) error {
+	if notice.ID == uuid.Nil {
+		notice.ID = uuid.New()
+	}
+	if notice.CreatedAt.IsZero() {
+		notice.CreatedAt = time.Now()
+	}
+
+	stored := *notice
+	stored.NoticeDate = models.DateOf(notice.NoticeDate)
+	stored.EffectiveDate = models.DateOf(notice.EffectiveDate)
+
+	return r.s.write(func(d *data) error {
+		d.notices = append(d.notices, stored)
+		return nil
+	})
+}
+
+// ListNotices retrieves a card's change-in-terms notices, oldest first
+func (r *aprChangeRepo) ListNotices(ctx context.Context, creditCardID uuid.UUID) ([]*models.ChangeInTermsNotice, error) {
+	var notices []*models.ChangeInTermsNotice
+	err := r.s.read(func(d *data) error {
+		for _, n := range d.notices {
+			if n.CreditCardID == creditCardID {
+				notice := n
+				notices = append(notices, &notice)
+			}
+		}
+		return nil
+	})
+
+	// Insertion order breaks ties between notices sent on the same day
+	sort.SliceStable(notices, func(i, j int) bool {
+		return notices[i].NoticeDate.Before(notices[j].NoticeDate)
+	})
+
+	return notices, err
+}
+
+// aprChangesOf returns copies of the cards' APR changes ordered by effective
+// date, with insertion order breaking ties
+func aprChangesOf(d *data, creditCardIDs ...uuid.UUID) []*models.APRChange {
+	var changes []*models.APRChange
+	for _, c := range d.aprChanges {
+		if len(creditCardIDs) > 0 && contains(creditCardIDs, c.CreditCardID) {
+			change := c
+			changes = append(changes, &change)
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].EffectiveDate.Before(changes[j].EffectiveDate)
+	})
+	return changes
+}
+
+// withAPRSchedule returns a copy of the card carrying its APR history
+func withAPRSchedule(d *data, card models.CreditCard) *models.CreditCard {
+	card.APRSchedule = nil
+	for _, change := range aprChangesOf(d, card.ID) {
+		card.APRSchedule = append(card.APRSchedule, *change)
+	}
+	return &card
+}
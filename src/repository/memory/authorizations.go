@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type authorizationRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the authorization
+func (r *authorizationRepo) Create(ctx context.Context, authorization *models.Authorization) error {
+	if authorization.ID == uuid.Nil {
+		authorization.ID = uuid.New()
+	}
+	if authorization.CreatedAt.IsZero() {
+		authorization.CreatedAt = time.Now()
+	}
+
+	stored := *authorization
+	stored.Metadata = copyMap(authorization.Metadata)
+
+	return r.s.write(func(d *data) error {
+		if _, ok := d.authorizations[authorization.ID]; ok {
+			return fmt.Errorf("authorization %s already exists", authorization.ID)
+		}
+		d.authorizations[authorization.ID] = stored
+		return nil
+	})
+}
+
+// GetByID retrieves an authorization by ID
+func (r *authorizationRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Authorization, error) {
+	var authorization *models.Authorization
+	err := r.s.read(func(d *data) error {
+		a, ok := d.authorizations[id]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		a.Metadata = copyMap(a.Metadata)
+		authorization = &a
+		return nil
+	})
+	return authorization, err
+}
+
+// List retrieves authorizations matching the filter
+func (r *authorizationRepo) List(
+	ctx context.Context,
+	filter repository.AuthorizationFilter,
+) ([]*models.Authorization, error) {
+	var authorizations []*models.Authorization
+	err := r.s.read(func(d *data) error {
+		for _, a := range d.authorizations {
+			if filter.TenantID != nil && a.TenantID != *filter.TenantID {
+				continue
+			}
+			if filter.CreditCardID != nil && a.CreditCardID != *filter.CreditCardID {
+				continue
+			}
+			if filter.ReferenceID != nil && a.ReferenceID != *filter.ReferenceID {
+				continue
+			}
+			if filter.ExpiringBy != nil && a.ExpiresAt.After(*filter.ExpiringBy) {
+				continue
+			}
+			if !contains(filter.Statuses, a.Status) {
+				continue
+			}
+			authorization := a
+			authorization.Metadata = copyMap(a.Metadata)
+			authorizations = append(authorizations, &authorization)
+		}
+		return nil
+	})
+
+	sort.Slice(authorizations, func(i, j int) bool {
+		a, b := authorizations[i], authorizations[j]
+		if !a.AuthorizedAt.Equal(b.AuthorizedAt) {
+			return a.AuthorizedAt.Before(b.AuthorizedAt)
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+
+	return authorizations, err
+}
+
+// Update replaces the stored authorization
+func (r *authorizationRepo) Update(ctx context.Context, authorization *models.Authorization) error {
+	return r.s.write(func(d *data) error {
+		existing, ok := d.authorizations[authorization.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		updated := *authorization
+		updated.TenantID = existing.TenantID
+		updated.CreditCardID = existing.CreditCardID
+		updated.AuthorizationCode = existing.AuthorizationCode
+		updated.MerchantName = existing.MerchantName
+		updated.MerchantCategory = existing.MerchantCategory
+		updated.ReferenceID = existing.ReferenceID
+		updated.AuthorizedAt = existing.AuthorizedAt
+		updated.CreatedAt = existing.CreatedAt
+		updated.Metadata = copyMap(authorization.Metadata)
+		d.authorizations[authorization.ID] = updated
+		return nil
+	})
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+type delinquencyRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the aging record
+func (r *delinquencyRepo) Create(ctx context.Context, record *models.DelinquencyRecord) error {
+	if record.ID == uuid.Nil {
+		record.ID = uuid.New()
+	}
+	if record.CreatedAt.IsZero() {
+		record.CreatedAt = time.Now()
+	}
+
+	stored := *record
+	stored.AsOf = models.DateOf(record.AsOf)
+
+	return r.s.write(func(d *data) error {
+		d.delinquencies = append(d.delinquencies, stored)
+		return nil
+	})
+}
+
+// List retrieves a card's aging history ordered by date
+func (r *delinquencyRepo) List(ctx context.Context, creditCardID uuid.UUID) ([]*models.DelinquencyRecord, error) {
+	var records []*models.DelinquencyRecord
+	err := r.s.read(func(d *data) error {
+		for _, rec := range d.delinquencies {
+			if rec.CreditCardID == creditCardID {
+				record := rec
+				records = append(records, &record)
+			}
+		}
+		return nil
+	})
+
+	// Insertion order breaks ties
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].AsOf.Before(records[j].AsOf)
+	})
+
+	return records, err
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type disputeRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the dispute
+func (r *disputeRepo) Create(ctx context.Context, dispute *models.Dispute) error {
+	if dispute.ID == uuid.Nil {
+		dispute.ID = uuid.New()
+	}
+	if dispute.CreatedAt.IsZero() {
+		dispute.CreatedAt = time.Now()
+	}
+
+	stored := *dispute
+	stored.Metadata = copyMap(dispute.Metadata)
+
+	return r.s.write(func(d *data) error {
+		if _, ok := d.disputes[dispute.ID]; ok {
+			return fmt.Errorf("dispute %s already exists", dispute.ID)
+		}
+		d.disputes[dispute.ID] = stored
+		return nil
+	})
+}
+
+// GetByID retrieves a dispute by ID
+func (r *disputeRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	var dispute *models.Dispute
+	err := r.s.read(func(d *data) error {
+		found, ok := d.disputes[id]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		found.Metadata = copyMap(found.Metadata)
+		dispute = &found
+		return nil
+	})
+	return dispute, err
+}
+
+// List retrieves disputes matching the filter
+func (r *disputeRepo) List(
+	ctx context.Context,
+	filter repository.DisputeFilter,
+) ([]*models.Dispute, error) {
+	var disputes []*models.Dispute
+	err := r.s.read(func(d *data) error {
+		for _, found := range d.disputes {
+			if filter.TenantID != nil && found.TenantID != *filter.TenantID {
+				continue
+			}
+			if filter.CreditCardID != nil && found.CreditCardID != *filter.CreditCardID {
+				continue
+			}
+			if filter.StatementEntryID != nil && found.StatementEntryID != *filter.StatementEntryID {
+				continue
+			}
+			if !contains(filter.Statuses, found.Status) {
+				continue
+			}
+			dispute := found
+			dispute.Metadata = copyMap(found.Metadata)
+			disputes = append(disputes, &dispute)
+		}
+		return nil
+	})
+
+	sort.Slice(disputes, func(i, j int) bool {
+		a, b := disputes[i], disputes[j]
+		if !a.NoticeReceivedAt.Equal(b.NoticeReceivedAt) {
+			return a.NoticeReceivedAt.Before(b.NoticeReceivedAt)
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+
+	return disputes, err
+}
+
+// Update replaces the stored dispute
+func (r *disputeRepo) Update(ctx context.Context, dispute *models.Dispute) error {
+	return r.s.write(func(d *data) error {
+		existing, ok := d.disputes[dispute.ID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		updated := *dispute
+		updated.TenantID = existing.TenantID
+		updated.CreditCardID = existing.CreditCardID
+		updated.StatementEntryID = existing.StatementEntryID
+		updated.Reason = existing.Reason
+		updated.Amount = existing.Amount
+		updated.StatementDate = existing.StatementDate
+		updated.NoticeReceivedAt = existing.NoticeReceivedAt
+		updated.RegZCovered = existing.RegZCovered
+		updated.AcknowledgeBy = existing.AcknowledgeBy
+		updated.ResolveBy = existing.ResolveBy
+		updated.CreatedAt = existing.CreatedAt
+		updated.CreatedBy = existing.CreatedBy
+		updated.Metadata = copyMap(dispute.Metadata)
+		d.disputes[dispute.ID] = updated
+		return nil
+	})
+}
+
+// CreateTransition stores a copy of the status transition
+func (r *disputeRepo) CreateTransition(ctx context.Context, transition *models.DisputeStatusTransition) error {
+	if transition.ID == uuid.Nil {
+		transition.ID = uuid.New()
+	}
+
+	stored := *transition
+	stored.Metadata = copyMap(transition.Metadata)
+
+	return r.s.write(func(d *data) error {
+		d.disputeHistory = append(d.disputeHistory, stored)
+		return nil
+	})
+}
+
+// ListTransitions retrieves a dispute's status history, oldest first
+func (r *disputeRepo) ListTransitions(
+	ctx context.Context,
+	disputeID uuid.UUID,
+) ([]*models.DisputeStatusTransition, error) {
+	var transitions []*models.DisputeStatusTransition
+	err := r.s.read(func(d *data) error {
+		for _, t := range d.disputeHistory {
+			if t.DisputeID == disputeID {
+				transition := t
+				transition.Metadata = copyMap(t.Metadata)
+				transitions = append(transitions, &transition)
+			}
+		}
+		return nil
+	})
+
+	// Insertion order breaks ties between transitions recorded at the same instant
+	sort.SliceStable(transitions, func(i, j int) bool {
+		return transitions[i].TransitionAt.Before(transitions[j].TransitionAt)
+	})
+
+	return transitions, err
+}
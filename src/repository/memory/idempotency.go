@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type idempotencyRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the record, enforcing one record per tenant and key
+func (r *idempotencyRepo) Create(ctx context.Context, record *models.IdempotencyRecord) error {
+	if record.ID == uuid.Nil {
+		record.ID = uuid.New()
+	}
+
+	stored := *record
+	stored.Response = append([]byte(nil), record.Response...)
+
+	return r.s.write(func(d *data) error {
+		for _, existing := range d.idempotencyKeys {
+			if existing.TenantID == record.TenantID && existing.Key == record.Key {
+				return repository.ErrDuplicate
+			}
+		}
+		d.idempotencyKeys = append(d.idempotencyKeys, stored)
+		return nil
+	})
+}
+
+// Get retrieves the record for a tenant's idempotency key
+func (r *idempotencyRepo) Get(ctx context.Context, tenantID uuid.UUID, key string) (*models.IdempotencyRecord, error) {
+	var record *models.IdempotencyRecord
+	err := r.s.read(func(d *data) error {
+		for _, existing := range d.idempotencyKeys {
+			if existing.TenantID == tenantID && existing.Key == key {
+				existing.Response = append([]byte(nil), existing.Response...)
+				record = &existing
+				return nil
+			}
+		}
+		return repository.ErrNotFound
+	})
+	return record, err
+}
@@ -0,0 +1,68 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type journalRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the journal entry and its lines
+func (r *journalRepo) Create(ctx context.Context, entry *models.JournalEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	stored := *entry
+	stored.EntryDate = models.DateOf(entry.EntryDate)
+	stored.Lines = append([]models.JournalLine(nil), entry.Lines...)
+
+	return r.s.write(func(d *data) error {
+		d.journalEntries = append(d.journalEntries, stored)
+		return nil
+	})
+}
+
+// List retrieves journal entries matching the filter
+func (r *journalRepo) List(
+	ctx context.Context,
+	filter repository.JournalFilter,
+) ([]*models.JournalEntry, error) {
+	var entries []*models.JournalEntry
+	err := r.s.read(func(d *data) error {
+		for _, e := range d.journalEntries {
+			if filter.TenantID != nil && e.TenantID != *filter.TenantID {
+				continue
+			}
+			if filter.SourceID != nil && e.SourceID != *filter.SourceID {
+				continue
+			}
+			if !within(e.EntryDate, filter.From, filter.To) {
+				continue
+			}
+			entry := e
+			entry.Lines = append([]models.JournalLine(nil), e.Lines...)
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].EntryDate.Equal(entries[j].EntryDate) {
+			return entries[i].EntryDate.Before(entries[j].EntryDate)
+		}
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+
+	return entries, err
+}
@@ -0,0 +1,373 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type statementEntryRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the statement ledger entry
+func (r *statementEntryRepo) Create(ctx context.Context, entry *models.StatementLedgerEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.Status == "" {
+		entry.Status = models.EntryStatusPending
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	return r.s.write(func(d *data) error {
+		var tail chainTail
+		for _, e := range d.statementEntries {
+			if e.TenantID == entry.TenantID {
+				tail.follow(e.ChainLink)
+			}
+		}
+		entry.Seal(tail.Sequence+1, tail.Hash)
+
+		stored := *entry
+		stored.PostingDate = models.DateOf(entry.PostingDate)
+		stored.Metadata = copyMap(entry.Metadata)
+		d.statementEntries = append(d.statementEntries, stored)
+		return nil
+	})
+}
+
+// GetByID retrieves a statement ledger entry by ID
+func (r *statementEntryRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.StatementLedgerEntry, error) {
+	var found *models.StatementLedgerEntry
+	err := r.s.read(func(d *data) error {
+		for i := range d.statementEntries {
+			if d.statementEntries[i].ID == id {
+				entry := d.statementEntries[i]
+				entry.Metadata = copyMap(entry.Metadata)
+				found = &entry
+				return nil
+			}
+		}
+		return repository.ErrNotFound
+	})
+	return found, err
+}
+
+// ChainHead returns the newest link of the tenant's statement chain
+func (r *statementEntryRepo) ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error) {
+	var tail chainTail
+	err := r.s.read(func(d *data) error {
+		for _, e := range d.statementEntries {
+			if e.TenantID == tenantID {
+				tail.follow(e.ChainLink)
+			}
+		}
+		return nil
+	})
+	return tail.ChainLink, err
+}
+
+// List retrieves statement ledger entries matching the filter
+func (r *statementEntryRepo) List(
+	ctx context.Context,
+	filter repository.StatementEntryFilter,
+) ([]*models.StatementLedgerEntry, error) {
+	var entries []*models.StatementLedgerEntry
+	err := r.s.read(func(d *data) error {
+		for _, e := range d.statementEntries {
+			if filter.TenantID != nil && e.TenantID != *filter.TenantID {
+				continue
+			}
+			if filter.StatementID != nil && (e.StatementID == nil || *e.StatementID != *filter.StatementID) {
+				continue
+			}
+			if filter.ReferenceID != nil && (e.ReferenceID == nil || *e.ReferenceID != *filter.ReferenceID) {
+				continue
+			}
+			if !contains(filter.EntryTypes, e.EntryType) || !contains(filter.Statuses, e.Status) {
+				continue
+			}
+			if !within(e.PostingDate, filter.PostedFrom, filter.PostedTo) {
+				continue
+			}
+			entry := e
+			entry.Metadata = copyMap(e.Metadata)
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.PostingDate.Equal(b.PostingDate) {
+			return a.PostingDate.Before(b.PostingDate)
+		}
+		if !a.EntryDate.Equal(b.EntryDate) {
+			return a.EntryDate.Before(b.EntryDate)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	return entries, err
+}
+
+// UpdateStatus transitions an entry between statuses
+func (r *statementEntryRepo) UpdateStatus(
+	ctx context.Context,
+	id uuid.UUID,
+	from, to models.EntryStatus,
+	at time.Time,
+) error {
+	return r.s.write(func(d *data) error {
+		for i := range d.statementEntries {
+			entry := &d.statementEntries[i]
+			if entry.ID != id || entry.Status != from {
+				continue
+			}
+			entry.Status = to
+			if to == models.EntryStatusCleared {
+				clearedAt := at
+				entry.ClearedAt = &clearedAt
+			}
+			return nil
+		}
+		return repository.ErrNotFound
+	})
+}
+
+type cashbackEntryRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the cashback ledger entry
+func (r *cashbackEntryRepo) Create(ctx context.Context, entry *models.CashbackLedgerEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	return r.s.write(func(d *data) error {
+		var tail chainTail
+		for _, e := range d.cashbackEntries {
+			if e.TenantID == entry.TenantID {
+				tail.follow(e.ChainLink)
+			}
+		}
+		entry.Seal(tail.Sequence+1, tail.Hash)
+
+		stored := *entry
+		stored.Metadata = copyMap(entry.Metadata)
+		d.cashbackEntries = append(d.cashbackEntries, stored)
+		return nil
+	})
+}
+
+// ChainHead returns the newest link of the tenant's cashback chain
+func (r *cashbackEntryRepo) ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error) {
+	var tail chainTail
+	err := r.s.read(func(d *data) error {
+		for _, e := range d.cashbackEntries {
+			if e.TenantID == tenantID {
+				tail.follow(e.ChainLink)
+			}
+		}
+		return nil
+	})
+	return tail.ChainLink, err
+}
+
+// List retrieves cashback ledger entries matching the filter
+func (r *cashbackEntryRepo) List(
+	ctx context.Context,
+	filter repository.CashbackEntryFilter,
+) ([]*models.CashbackLedgerEntry, error) {
+	var entries []*models.CashbackLedgerEntry
+	err := r.s.read(func(d *data) error {
+		for _, e := range d.cashbackEntries {
+			if filter.TenantID != nil && e.TenantID != *filter.TenantID {
+				continue
+			}
+			if filter.CreditCardID != nil && e.CreditCardID != *filter.CreditCardID {
+				continue
+			}
+			if filter.StatementEntryID != nil &&
+				(e.StatementEntryID == nil || *e.StatementEntryID != *filter.StatementEntryID) {
+				continue
+			}
+			if !contains(filter.EntryTypes, e.EntryType) || !within(e.EntryDate, filter.From, filter.To) {
+				continue
+			}
+			entry := e
+			entry.Metadata = copyMap(e.Metadata)
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.EntryDate.Equal(b.EntryDate) {
+			return a.EntryDate.Before(b.EntryDate)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	return newestFirst(entries, filter.Limit), err
+}
+
+// SaveCategory inserts a category or replaces the one with the same card and code
+func (r *cashbackEntryRepo) SaveCategory(ctx context.Context, category *models.CashbackCategory) error {
+	if category.ID == uuid.Nil {
+		category.ID = uuid.New()
+	}
+
+	return r.s.write(func(d *data) error {
+		for i := range d.categories {
+			existing := &d.categories[i]
+			if existing.CreditCardID == category.CreditCardID && existing.CategoryCode == category.CategoryCode {
+				id := existing.ID
+				*existing = *category
+				existing.ID = id
+				return nil
+			}
+		}
+		d.categories = append(d.categories, *category)
+		return nil
+	})
+}
+
+// ListCategories retrieves all bonus categories configured for a card
+func (r *cashbackEntryRepo) ListCategories(
+	ctx context.Context,
+	creditCardID uuid.UUID,
+) ([]*models.CashbackCategory, error) {
+	var categories []*models.CashbackCategory
+	err := r.s.read(func(d *data) error {
+		for _, c := range d.categories {
+			if c.CreditCardID == creditCardID {
+				category := c
+				categories = append(categories, &category)
+			}
+		}
+		return nil
+	})
+
+	sort.SliceStable(categories, func(i, j int) bool {
+		return categories[i].CategoryCode < categories[j].CategoryCode
+	})
+
+	return categories, err
+}
+
+type pointsEntryRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the points ledger entry
+func (r *pointsEntryRepo) Create(ctx context.Context, entry *models.PointsLedgerEntry) error {
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	return r.s.write(func(d *data) error {
+		var tail chainTail
+		for _, e := range d.pointsEntries {
+			if e.TenantID == entry.TenantID {
+				tail.follow(e.ChainLink)
+			}
+		}
+		entry.Seal(tail.Sequence+1, tail.Hash)
+
+		stored := *entry
+		stored.Metadata = copyMap(entry.Metadata)
+		d.pointsEntries = append(d.pointsEntries, stored)
+		return nil
+	})
+}
+
+// ChainHead returns the newest link of the tenant's points chain
+func (r *pointsEntryRepo) ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error) {
+	var tail chainTail
+	err := r.s.read(func(d *data) error {
+		for _, e := range d.pointsEntries {
+			if e.TenantID == tenantID {
+				tail.follow(e.ChainLink)
+			}
+		}
+		return nil
+	})
+	return tail.ChainLink, err
+}
+
+// List retrieves points ledger entries matching the filter
+func (r *pointsEntryRepo) List(
+	ctx context.Context,
+	filter repository.PointsEntryFilter,
+) ([]*models.PointsLedgerEntry, error) {
+	var entries []*models.PointsLedgerEntry
+	err := r.s.read(func(d *data) error {
+		for _, e := range d.pointsEntries {
+			if filter.TenantID != nil && e.TenantID != *filter.TenantID {
+				continue
+			}
+			if filter.StatementEntryID != nil &&
+				(e.StatementEntryID == nil || *e.StatementEntryID != *filter.StatementEntryID) {
+				continue
+			}
+			if !contains(filter.EntryTypes, e.EntryType) {
+				continue
+			}
+			entry := e
+			entry.Metadata = copyMap(e.Metadata)
+			entries = append(entries, &entry)
+		}
+		return nil
+	})
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.EntryDate.Equal(b.EntryDate) {
+			return a.EntryDate.Before(b.EntryDate)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+
+	return newestFirst(entries, filter.Limit), err
+}
+
+// chainTail tracks the newest link of a tenant's chain
+type chainTail struct {
+	models.ChainLink
+}
+
+// follow moves the tail to link if it comes later in the chain
+func (t *chainTail) follow(link models.ChainLink) {
+	if link.Sequence > t.Sequence {
+		t.ChainLink = link
+	}
+}
+
+// newestFirst applies a listing limit to an ascending slice.
+// With a limit the newest entries are returned, newest first.
+func newestFirst[T any](items []T, limit int) []T {
+	if limit <= 0 {
+		return items
+	}
+	reversed := make([]T, 0, len(items))
+	for i := len(items) - 1; i >= 0 && len(reversed) < limit; i-- {
+		reversed = append(reversed, items[i])
+	}
+	return reversed
+}
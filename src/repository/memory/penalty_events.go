@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+type penaltyEventRepo struct {
+	s *Store
+}
+
+// Create stores a copy of the penalty event
+func (r *penaltyEventRepo) Create(ctx context.Context, event *models.PenaltyEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	stored := *event
+	stored.EffectiveDate = models.DateOf(event.EffectiveDate)
+
+	return r.s.write(func(d *data) error {
+		d.penaltyEvents = append(d.penaltyEvents, stored)
+		return nil
+	})
+}
+
+// List retrieves a card's penalty events ordered by occurrence
+func (r *penaltyEventRepo) List(ctx context.Context, creditCardID uuid.UUID) ([]*models.PenaltyEvent, error) {
+	var events []*models.PenaltyEvent
+	err := r.s.read(func(d *data) error {
+		for _, e := range d.penaltyEvents {
+			if e.CreditCardID == creditCardID {
+				event := e
+				events = append(events, &event)
+			}
+		}
+		return nil
+	})
+
+	// Insertion order breaks ties
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+
+	return events, err
+}
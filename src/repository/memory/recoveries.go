@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type recoveryRepo struct {
+	s *Store
+}
+
+// CreateChargeOff stores a copy of the charge-off
+func (r *recoveryRepo) CreateChargeOff(ctx context.Context, chargeOff *models.ChargeOff) error {
+	if chargeOff.ID == uuid.Nil {
+		chargeOff.ID = uuid.New()
+	}
+	if chargeOff.CreatedAt.IsZero() {
+		chargeOff.CreatedAt = time.Now()
+	}
+
+	stored := *chargeOff
+	stored.ChargedOffOn = models.DateOf(chargeOff.ChargedOffOn)
+
+	return r.s.write(func(d *data) error {
+		if _, ok := d.chargeOffs[chargeOff.CreditCardID]; ok {
+			return fmt.Errorf("card %s is already charged off", chargeOff.CreditCardID)
+		}
+		d.chargeOffs[chargeOff.CreditCardID] = stored
+		return nil
+	})
+}
+
+// GetChargeOff retrieves a card's charge-off
+func (r *recoveryRepo) GetChargeOff(ctx context.Context, creditCardID uuid.UUID) (*models.ChargeOff, error) {
+	var chargeOff *models.ChargeOff
+	err := r.s.read(func(d *data) error {
+		found, ok := d.chargeOffs[creditCardID]
+		if !ok {
+			return repository.ErrNotFound
+		}
+		chargeOff = &found
+		return nil
+	})
+	return chargeOff, err
+}
+
+// ListChargeOffs retrieves charge-offs matching the filter
+func (r *recoveryRepo) ListChargeOffs(
+	ctx context.Context,
+	filter repository.ChargeOffFilter,
+) ([]*models.ChargeOff, error) {
+	var chargeOffs []*models.ChargeOff
+	err := r.s.read(func(d *data) error {
+		for _, found := range d.chargeOffs {
+			if filter.TenantID != nil && found.TenantID != *filter.TenantID {
+				continue
+			}
+			if !within(found.ChargedOffOn, filter.From, filter.To) {
+				continue
+			}
+			chargeOff := found
+			chargeOffs = append(chargeOffs, &chargeOff)
+		}
+		return nil
+	})
+
+	sort.Slice(chargeOffs, func(i, j int) bool {
+		a, b := chargeOffs[i], chargeOffs[j]
+		if !a.ChargedOffOn.Equal(b.ChargedOffOn) {
+			return a.ChargedOffOn.Before(b.ChargedOffOn)
+		}
+		return a.ID.String() < b.ID.String()
+	})
+
+	return chargeOffs, err
+}
+
+// UpdateChargeOff replaces the remaining balances and amount recovered
+func (r *recoveryRepo) UpdateChargeOff(ctx context.Context, chargeOff *models.ChargeOff) error {
+	return r.s.write(func(d *data) error {
+		existing, ok := d.chargeOffs[chargeOff.CreditCardID]
+		if !ok || existing.ID != chargeOff.ID {
+			return repository.ErrNotFound
+		}
+		existing.PrincipalRemaining = chargeOff.PrincipalRemaining
+		existing.InterestRemaining = chargeOff.InterestRemaining
+		existing.FeesRemaining = chargeOff.FeesRemaining
+		existing.Recovered = chargeOff.Recovered
+		existing.UpdatedAt = chargeOff.UpdatedAt
+		d.chargeOffs[chargeOff.CreditCardID] = existing
+		return nil
+	})
+}
+
+// Create stores a copy of the recovery
+func (r *recoveryRepo) Create(ctx context.Context, recovery *models.Recovery) error {
+	if recovery.ID == uuid.Nil {
+		recovery.ID = uuid.New()
+	}
+	if recovery.CreatedAt.IsZero() {
+		recovery.CreatedAt = time.Now()
+	}
+
+	stored := *recovery
+	stored.RecoveredOn = models.DateOf(recovery.RecoveredOn)
+
+	return r.s.write(func(d *data) error {
+		d.recoveries = append(d.recoveries, stored)
+		return nil
+	})
+}
+
+// List retrieves recoveries matching the filter
+func (r *recoveryRepo) List(
+	ctx context.Context,
+	filter repository.RecoveryFilter,
+) ([]*models.Recovery, error) {
+	var recoveries []*models.Recovery
+	err := r.s.read(func(d *data) error {
+		for _, found := range d.recoveries {
+			if filter.TenantID != nil && found.TenantID != *filter.TenantID {
+				continue
+			}
+			if filter.CreditCardID != nil && found.CreditCardID != *filter.CreditCardID {
+				continue
+			}
+			if filter.Agency != nil && found.Agency != *filter.Agency {
+				continue
+			}
+			if !within(found.RecoveredOn, filter.From, filter.To) {
+				continue
+			}
+			recovery := found
+			recoveries = append(recoveries, &recovery)
+		}
+		return nil
+	})
+
+	// Insertion order breaks ties
+	sort.SliceStable(recoveries, func(i, j int) bool {
+		return recoveries[i].RecoveredOn.Before(recoveries[j].RecoveredOn)
+	})
+
+	return recoveries, err
+}
@@ -0,0 +1,279 @@
+// Package memory implements the repository interfaces in process memory.
+// It is intended for tests and local tooling; nothing is persisted.
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+// data holds every table of the store
+type data struct {
+	statementEntries []models.StatementLedgerEntry
+	cashbackEntries  []models.CashbackLedgerEntry
+	categories       []models.CashbackCategory
+	pointsEntries    []models.PointsLedgerEntry
+	cards            map[uuid.UUID]models.CreditCard
+	cycles           map[uuid.UUID]models.BillingCycle
+	payments         map[uuid.UUID]models.Payment
+	transitions      []models.PaymentStatusTransition
+	authorizations   map[uuid.UUID]models.Authorization
+	disputes         map[uuid.UUID]models.Dispute
+	disputeHistory   []models.DisputeStatusTransition
+	aprChanges       []models.APRChange
+	notices          []models.ChangeInTermsNotice
+	penaltyEvents    []models.PenaltyEvent
+	delinquencies    []models.DelinquencyRecord
+	chargeOffs       map[uuid.UUID]models.ChargeOff // By credit card
+	recoveries       []models.Recovery
+	idempotencyKeys  []models.IdempotencyRecord
+	journalEntries   []models.JournalEntry
+}
+
+func newData() *data {
+	return &data{
+		cards:          make(map[uuid.UUID]models.CreditCard),
+		cycles:         make(map[uuid.UUID]models.BillingCycle),
+		payments:       make(map[uuid.UUID]models.Payment),
+		authorizations: make(map[uuid.UUID]models.Authorization),
+		disputes:       make(map[uuid.UUID]models.Dispute),
+		chargeOffs:     make(map[uuid.UUID]models.ChargeOff),
+	}
+}
+
+// clone copies every table so a transaction can be discarded
+func (d *data) clone() *data {
+	c := &data{
+		statementEntries: append([]models.StatementLedgerEntry(nil), d.statementEntries...),
+		cashbackEntries:  append([]models.CashbackLedgerEntry(nil), d.cashbackEntries...),
+		categories:       append([]models.CashbackCategory(nil), d.categories...),
+		pointsEntries:    append([]models.PointsLedgerEntry(nil), d.pointsEntries...),
+		cards:            make(map[uuid.UUID]models.CreditCard, len(d.cards)),
+		cycles:           make(map[uuid.UUID]models.BillingCycle, len(d.cycles)),
+		payments:         make(map[uuid.UUID]models.Payment, len(d.payments)),
+		transitions:      append([]models.PaymentStatusTransition(nil), d.transitions...),
+		authorizations:   make(map[uuid.UUID]models.Authorization, len(d.authorizations)),
+		disputes:         make(map[uuid.UUID]models.Dispute, len(d.disputes)),
+		disputeHistory:   append([]models.DisputeStatusTransition(nil), d.disputeHistory...),
+		aprChanges:       append([]models.APRChange(nil), d.aprChanges...),
+		notices:          append([]models.ChangeInTermsNotice(nil), d.notices...),
+		penaltyEvents:    append([]models.PenaltyEvent(nil), d.penaltyEvents...),
+		delinquencies:    append([]models.DelinquencyRecord(nil), d.delinquencies...),
+		chargeOffs:       make(map[uuid.UUID]models.ChargeOff, len(d.chargeOffs)),
+		recoveries:       append([]models.Recovery(nil), d.recoveries...),
+		idempotencyKeys:  append([]models.IdempotencyRecord(nil), d.idempotencyKeys...),
+		journalEntries:   append([]models.JournalEntry(nil), d.journalEntries...),
+	}
+	for k, v := range d.cards {
+		c.cards[k] = v
+	}
+	for k, v := range d.cycles {
+		c.cycles[k] = v
+	}
+	for k, v := range d.payments {
+		c.payments[k] = v
+	}
+	for k, v := range d.authorizations {
+		c.authorizations[k] = v
+	}
+	for k, v := range d.disputes {
+		c.disputes[k] = v
+	}
+	for k, v := range d.chargeOffs {
+		c.chargeOffs[k] = v
+	}
+	return c
+}
+
+// Store is an in-memory repository.Store.
+// Transactions are serialized: WithinTx works on a private copy of the data
+// and publishes it only when fn succeeds.
+type Store struct {
+	txMu *sync.Mutex // Serializes writers on the root store
+	mu   *sync.RWMutex
+	data *data
+	inTx bool
+}
+
+// NewStore creates an empty in-memory store
+func NewStore() *Store {
+	return &Store{
+		txMu: &sync.Mutex{},
+		mu:   &sync.RWMutex{},
+		data: newData(),
+	}
+}
+
+// StatementEntries returns the statement ledger entry repository
+func (s *Store) StatementEntries() repository.StatementEntryRepository {
+	return &statementEntryRepo{s}
+}
+
+// CashbackEntries returns the cashback ledger entry repository
+func (s *Store) CashbackEntries() repository.CashbackEntryRepository {
+	return &cashbackEntryRepo{s}
+}
+
+// PointsEntries returns the points ledger entry repository
+func (s *Store) PointsEntries() repository.PointsEntryRepository {
+	return &pointsEntryRepo{s}
+}
+
+// CreditCards returns the credit card repository
+func (s *Store) CreditCards() repository.CreditCardRepository {
+	return &creditCardRepo{s}
+}
+
+// BillingCycles returns the billing cycle repository
+func (s *Store) BillingCycles() repository.BillingCycleRepository {
+	return &billingCycleRepo{s}
+}
+
+// Payments returns the payment repository
+func (s *Store) Payments() repository.PaymentRepository {
+	return &paymentRepo{s}
+}
+
+// Authorizations returns the card authorization repository
+func (s *Store) Authorizations() repository.AuthorizationRepository {
+	return &authorizationRepo{s}
+}
+
+// Disputes returns the dispute repository
+func (s *Store) Disputes() repository.DisputeRepository {
+	return &disputeRepo{s}
+}
+
+// APRChanges returns the APR history repository
+func (s *Store) APRChanges() repository.APRChangeRepository {
+	return &aprChangeRepo{s}
+}
+
+// PenaltyEvents returns the penalty APR event repository
+func (s *Store) PenaltyEvents() repository.PenaltyEventRepository {
+	return &penaltyEventRepo{s}
+}
+
+// Delinquencies returns the delinquency aging history repository
+func (s *Store) Delinquencies() repository.DelinquencyRepository {
+	return &delinquencyRepo{s}
+}
+
+// Recoveries returns the charge-off and recovery repository
+func (s *Store) Recoveries() repository.RecoveryRepository {
+	return &recoveryRepo{s}
+}
+
+// IdempotencyKeys returns the idempotency key repository
+func (s *Store) IdempotencyKeys() repository.IdempotencyRepository {
+	return &idempotencyRepo{s}
+}
+
+// JournalEntries returns the general ledger journal repository
+func (s *Store) JournalEntries() repository.JournalRepository {
+	return &journalRepo{s}
+}
+
+// WithinTx runs fn against a copy of the data and publishes the copy when fn
+// returns nil. Calling WithinTx on a transactional store joins it, and a
+// failing fn leaves the data as it was before the call.
+func (s *Store) WithinTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	if s.inTx {
+		s.mu.RLock()
+		savepoint := s.data.clone()
+		s.mu.RUnlock()
+
+		if err := fn(s); err != nil {
+			s.mu.Lock()
+			s.data = savepoint
+			s.mu.Unlock()
+			return err
+		}
+		return nil
+	}
+
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	s.mu.RLock()
+	tx := &Store{
+		txMu: &sync.Mutex{},
+		mu:   &sync.RWMutex{},
+		data: s.data.clone(),
+		inTx: true,
+	}
+	s.mu.RUnlock()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.data = tx.data
+	s.mu.Unlock()
+	return nil
+}
+
+// read runs fn with shared access to the data
+func (s *Store) read(fn func(d *data) error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fn(s.data)
+}
+
+// write runs fn with exclusive access to the data.
+// Outside a transaction the write also waits for running transactions, so a
+// committing transaction never overwrites it.
+func (s *Store) write(fn func(d *data) error) error {
+	if !s.inTx {
+		s.txMu.Lock()
+		defer s.txMu.Unlock()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.data)
+}
+
+// copyMap makes a shallow copy of a metadata map
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// within reports whether t lies in the inclusive range [from, to]
+func within(t time.Time, from, to *time.Time) bool {
+	if from != nil && t.Before(*from) {
+		return false
+	}
+	if to != nil && t.After(*to) {
+		return false
+	}
+	return true
+}
+
+// contains reports whether values is empty or includes v
+func contains[T comparable](values []T, v T) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
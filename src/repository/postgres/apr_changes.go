@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+type aprChangeRepo struct {
+	db Querier
+}
+
+const aprChangeColumns = `
+	id, tenant_id, credit_card_id, apr_type, previous_apr, apr,
+	effective_date, reason, notice_id, created_at`
+
+const changeInTermsNoticeColumns = `
+	id, tenant_id, credit_card_id, apr_change_id, apr_type,
+	current_apr, new_apr, notice_date, effective_date, summary, created_at`
+
+// Create inserts an APR change
+func (r *aprChangeRepo) Create(ctx context.Context, change *models.APRChange) error {
+	query := `
+		INSERT INTO apr_changes (` + aprChangeColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	if change.ID == uuid.Nil {
+		change.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		change.ID, change.TenantID, change.CreditCardID, change.APRType, change.PreviousAPR, change.APR,
+		change.EffectiveDate, change.Reason, change.NoticeID, change.CreatedAt,
+	)
+
+	return err
+}
+
+// List retrieves the cards' APR changes ordered by effective date
+func (r *aprChangeRepo) List(ctx context.Context, creditCardIDs ...uuid.UUID) ([]*models.APRChange, error) {
+	if len(creditCardIDs) == 0 {
+		return nil, nil
+	}
+
+	var c conditions
+	ids := make([]string, len(creditCardIDs))
+	for i, id := range creditCardIDs {
+		ids[i] = id.String()
+	}
+	c.addIn("credit_card_id", ids)
+
+	query := `SELECT ` + aprChangeColumns + ` FROM apr_changes ` +
+		c.where() + ` ORDER BY effective_date, created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*models.APRChange
+	for rows.Next() {
+		change := &models.APRChange{}
+		err := rows.Scan(
+			&change.ID, &change.TenantID, &change.CreditCardID, &change.APRType, &change.PreviousAPR, &change.APR,
+			&change.EffectiveDate, &change.Reason, &change.NoticeID, &change.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, rows.Err()
+}
+
+// CreateNotice inserts a change-in-terms notice
+func (r *aprChangeRepo) CreateNotice(ctx context.Context, notice *models.ChangeInTermsNotice) error {
+	query := `
+		INSERT INTO change_in_terms_notices (` + changeInTermsNoticeColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	if notice.ID == uuid.Nil {
+		notice.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		notice.ID, notice.TenantID, notice.CreditCardID, notice.APRChangeID, notice.APRType,
+		notice.CurrentAPR, notice.NewAPR, notice.NoticeDate, notice.EffectiveDate, notice.Summary, notice.CreatedAt,
+	)
+
+	return err
+}
+
+// ListNotices retrieves a card's change-in-terms notices, oldest first
+func (r *aprChangeRepo) ListNotices(ctx context.Context, creditCardID uuid.UUID) ([]*models.ChangeInTermsNotice, error) {
+	query := `SELECT ` + changeInTermsNoticeColumns + ` FROM change_in_terms_notices
+		WHERE credit_card_id = $1 ORDER BY notice_date, created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, creditCardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notices []*models.ChangeInTermsNotice
+	for rows.Next() {
+		notice := &models.ChangeInTermsNotice{}
+		err := rows.Scan(
+			&notice.ID, &notice.TenantID, &notice.CreditCardID, &notice.APRChangeID, &notice.APRType,
+			&notice.CurrentAPR, &notice.NewAPR, &notice.NoticeDate, &notice.EffectiveDate, &notice.Summary, &notice.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		notices = append(notices, notice)
+	}
+
+	return notices, rows.Err()
+}
+
+// attachAPRSchedules loads each card's APR history onto it
+func attachAPRSchedules(ctx context.Context, db Querier, cards ...*models.CreditCard) error {
+	ids := make([]uuid.UUID, len(cards))
+	byID := make(map[uuid.UUID]*models.CreditCard, len(cards))
+	for i, card := range cards {
+		ids[i] = card.ID
+		byID[card.ID] = card
+	}
+
+	changes, err := (&aprChangeRepo{db: db}).List(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	for _, change := range changes {
+		if card, ok := byID[change.CreditCardID]; ok {
+			card.APRSchedule = append(card.APRSchedule, *change)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type authorizationRepo struct {
+	db Querier
+}
+
+const authorizationColumns = `
+	id, tenant_id, credit_card_id,
+	authorization_code, merchant_name, merchant_category, reference_id,
+	authorized_amount, held_amount, captured_amount,
+	status, authorized_at, expires_at, captured_at, reversed_at, expired_at,
+	metadata, created_at, updated_at`
+
+// Create inserts a new authorization
+func (r *authorizationRepo) Create(ctx context.Context, authorization *models.Authorization) error {
+	query := `
+		INSERT INTO authorizations (` + authorizationColumns + `
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			$11, $12, $13, $14, $15, $16, $17, $18, $19
+		)
+	`
+
+	if authorization.ID == uuid.Nil {
+		authorization.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		authorization.ID, authorization.TenantID, authorization.CreditCardID,
+		authorization.AuthorizationCode, authorization.MerchantName,
+		authorization.MerchantCategory, authorization.ReferenceID,
+		authorization.AuthorizedAmount, authorization.HeldAmount, authorization.CapturedAmount,
+		authorization.Status, authorization.AuthorizedAt, authorization.ExpiresAt,
+		authorization.CapturedAt, authorization.ReversedAt, authorization.ExpiredAt,
+		jsonMap(authorization.Metadata), authorization.CreatedAt, authorization.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetByID retrieves an authorization by ID
+func (r *authorizationRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Authorization, error) {
+	query := `SELECT ` + authorizationColumns + ` FROM authorizations WHERE id = $1`
+
+	authorization, err := scanAuthorization(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return authorization, err
+}
+
+// List retrieves authorizations matching the filter
+func (r *authorizationRepo) List(
+	ctx context.Context,
+	filter repository.AuthorizationFilter,
+) ([]*models.Authorization, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.CreditCardID != nil {
+		c.add("credit_card_id = ?", *filter.CreditCardID)
+	}
+	if filter.ReferenceID != nil {
+		c.add("reference_id = ?", *filter.ReferenceID)
+	}
+	if filter.ExpiringBy != nil {
+		c.add("expires_at <= ?", *filter.ExpiringBy)
+	}
+	c.addIn("status", stringsOf(filter.Statuses))
+
+	query := `SELECT ` + authorizationColumns + ` FROM authorizations ` +
+		c.where() + ` ORDER BY authorized_at, created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var authorizations []*models.Authorization
+	for rows.Next() {
+		authorization, err := scanAuthorization(rows)
+		if err != nil {
+			return nil, err
+		}
+		authorizations = append(authorizations, authorization)
+	}
+
+	return authorizations, rows.Err()
+}
+
+// Update writes every mutable column of the authorization
+func (r *authorizationRepo) Update(ctx context.Context, authorization *models.Authorization) error {
+	query := `
+		UPDATE authorizations
+		SET authorized_amount = $1, held_amount = $2, captured_amount = $3,
+		    status = $4, expires_at = $5,
+		    captured_at = $6, reversed_at = $7, expired_at = $8,
+		    metadata = $9, updated_at = $10
+		WHERE id = $11
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		authorization.AuthorizedAmount, authorization.HeldAmount, authorization.CapturedAmount,
+		authorization.Status, authorization.ExpiresAt,
+		authorization.CapturedAt, authorization.ReversedAt, authorization.ExpiredAt,
+		jsonMap(authorization.Metadata), authorization.UpdatedAt,
+		authorization.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+func scanAuthorization(row rowScanner) (*models.Authorization, error) {
+	authorization := &models.Authorization{}
+	var metadata jsonMap
+	err := row.Scan(
+		&authorization.ID, &authorization.TenantID, &authorization.CreditCardID,
+		&authorization.AuthorizationCode, &authorization.MerchantName,
+		&authorization.MerchantCategory, &authorization.ReferenceID,
+		&authorization.AuthorizedAmount, &authorization.HeldAmount, &authorization.CapturedAmount,
+		&authorization.Status, &authorization.AuthorizedAt, &authorization.ExpiresAt,
+		&authorization.CapturedAt, &authorization.ReversedAt, &authorization.ExpiredAt,
+		&metadata, &authorization.CreatedAt, &authorization.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	authorization.Metadata = metadata
+	return authorization, nil
+}
@@ -0,0 +1,172 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type billingCycleRepo struct {
+	db Querier
+}
+
+const billingCycleColumns = `
+	id, credit_card_id, tenant_id, cycle_number, cycle_type,
+	cycle_start_date, cycle_end_date, statement_date, due_date, grace_period_end,
+	previous_balance, payments_received, purchases_amount, cash_advances_amount,
+	balance_transfers_amount, refunds_amount, fees_amount, interest_amount, adjustments_amount,
+	cashback_earned, cashback_redeemed, new_balance, minimum_payment,
+	average_daily_balance, days_in_cycle, apr_applied, segments, payoff,
+	payments_made, last_payment_date, last_payment_amount, minimum_payment_met,
+	status, created_at, updated_at, closed_at`
+
+// Create inserts a new billing cycle
+func (r *billingCycleRepo) Create(ctx context.Context, cycle *models.BillingCycle) error {
+	query := `
+		INSERT INTO billing_cycles (
+			id, credit_card_id, tenant_id, cycle_number, cycle_type,
+			cycle_start_date, cycle_end_date, statement_date, due_date, grace_period_end,
+			previous_balance, payments_received, purchases_amount, cash_advances_amount,
+			balance_transfers_amount, refunds_amount, fees_amount, interest_amount, adjustments_amount,
+			cashback_earned, cashback_redeemed, new_balance, minimum_payment,
+			average_daily_balance, days_in_cycle, apr_applied, segments, payoff,
+			payments_made, minimum_payment_met, status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30,
+			$31, $32, $33
+		)
+	`
+
+	if cycle.ID == uuid.Nil {
+		cycle.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		cycle.ID, cycle.CreditCardID, cycle.TenantID, cycle.CycleNumber, cycle.CycleType,
+		cycle.CycleStartDate, cycle.CycleEndDate, cycle.StatementDate, cycle.DueDate, cycle.GracePeriodEnd,
+		cycle.PreviousBalance, cycle.PaymentsReceived, cycle.PurchasesAmount, cycle.CashAdvancesAmount,
+		cycle.BalanceTransfersAmount, cycle.RefundsAmount, cycle.FeesAmount, cycle.InterestAmount, cycle.AdjustmentsAmount,
+		cycle.CashbackEarned, cycle.CashbackRedeemed, cycle.NewBalance, cycle.MinimumPayment,
+		cycle.AverageDailyBalance, cycle.DaysInCycle, cycle.APRApplied, jsonValue(cycle.Segments), jsonValue(cycle.Payoff),
+		cycle.PaymentsMade, cycle.MinimumPaymentMet, cycle.Status, cycle.CreatedAt, cycle.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetByID retrieves a billing cycle by ID
+func (r *billingCycleRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.BillingCycle, error) {
+	query := `SELECT ` + billingCycleColumns + ` FROM billing_cycles WHERE id = $1`
+
+	cycle, err := scanBillingCycle(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return cycle, err
+}
+
+// List retrieves billing cycles matching the filter
+func (r *billingCycleRepo) List(
+	ctx context.Context,
+	filter repository.BillingCycleFilter,
+) ([]*models.BillingCycle, error) {
+	var c conditions
+	if filter.CreditCardID != nil {
+		c.add("credit_card_id = ?", *filter.CreditCardID)
+	}
+	if filter.CycleNumber != nil {
+		c.add("cycle_number = ?", *filter.CycleNumber)
+	}
+	if filter.MinimumPaymentMet != nil {
+		c.add("minimum_payment_met = ?", *filter.MinimumPaymentMet)
+	}
+	if filter.DueBefore != nil {
+		c.add("due_date < ?", *filter.DueBefore)
+	}
+	c.addIn("status", stringsOf(filter.Statuses))
+
+	order := ` ORDER BY cycle_number `
+	if filter.Limit > 0 {
+		order = ` ORDER BY cycle_number DESC `
+	}
+	query := `SELECT ` + billingCycleColumns + ` FROM billing_cycles ` +
+		c.where() + order + c.limit(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cycles []*models.BillingCycle
+	for rows.Next() {
+		cycle, err := scanBillingCycle(rows)
+		if err != nil {
+			return nil, err
+		}
+		cycles = append(cycles, cycle)
+	}
+
+	return cycles, rows.Err()
+}
+
+// Update writes every mutable column of the cycle
+func (r *billingCycleRepo) Update(ctx context.Context, cycle *models.BillingCycle) error {
+	query := `
+		UPDATE billing_cycles
+		SET statement_date = $1, due_date = $2, grace_period_end = $3,
+		    previous_balance = $4, payments_received = $5, purchases_amount = $6,
+		    cash_advances_amount = $7, balance_transfers_amount = $8,
+		    refunds_amount = $9, fees_amount = $10,
+		    interest_amount = $11, adjustments_amount = $12,
+		    cashback_earned = $13, cashback_redeemed = $14,
+		    new_balance = $15, minimum_payment = $16,
+		    average_daily_balance = $17, days_in_cycle = $18, apr_applied = $19,
+		    segments = $20, payoff = $21,
+		    payments_made = $22, last_payment_date = $23, last_payment_amount = $24,
+		    minimum_payment_met = $25, status = $26, updated_at = $27, closed_at = $28
+		WHERE id = $29
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		cycle.StatementDate, cycle.DueDate, cycle.GracePeriodEnd,
+		cycle.PreviousBalance, cycle.PaymentsReceived, cycle.PurchasesAmount,
+		cycle.CashAdvancesAmount, cycle.BalanceTransfersAmount,
+		cycle.RefundsAmount, cycle.FeesAmount,
+		cycle.InterestAmount, cycle.AdjustmentsAmount,
+		cycle.CashbackEarned, cycle.CashbackRedeemed,
+		cycle.NewBalance, cycle.MinimumPayment,
+		cycle.AverageDailyBalance, cycle.DaysInCycle, cycle.APRApplied,
+		jsonValue(cycle.Segments), jsonValue(cycle.Payoff),
+		cycle.PaymentsMade, cycle.LastPaymentDate, cycle.LastPaymentAmount,
+		cycle.MinimumPaymentMet, cycle.Status, cycle.UpdatedAt, cycle.ClosedAt,
+		cycle.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+func scanBillingCycle(row rowScanner) (*models.BillingCycle, error) {
+	cycle := &models.BillingCycle{}
+	err := row.Scan(
+		&cycle.ID, &cycle.CreditCardID, &cycle.TenantID, &cycle.CycleNumber, &cycle.CycleType,
+		&cycle.CycleStartDate, &cycle.CycleEndDate, &cycle.StatementDate, &cycle.DueDate, &cycle.GracePeriodEnd,
+		&cycle.PreviousBalance, &cycle.PaymentsReceived, &cycle.PurchasesAmount, &cycle.CashAdvancesAmount,
+		&cycle.BalanceTransfersAmount, &cycle.RefundsAmount, &cycle.FeesAmount, &cycle.InterestAmount, &cycle.AdjustmentsAmount,
+		&cycle.CashbackEarned, &cycle.CashbackRedeemed, &cycle.NewBalance, &cycle.MinimumPayment,
+		&cycle.AverageDailyBalance, &cycle.DaysInCycle, &cycle.APRApplied, jsonValue(&cycle.Segments), jsonValue(&cycle.Payoff),
+		&cycle.PaymentsMade, &cycle.LastPaymentDate, &cycle.LastPaymentAmount, &cycle.MinimumPaymentMet,
+		&cycle.Status, &cycle.CreatedAt, &cycle.UpdatedAt, &cycle.ClosedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return cycle, nil
+}
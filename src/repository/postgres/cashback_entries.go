@@ -0,0 +1,217 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type cashbackEntryRepo struct {
+	db Querier
+}
+
+const cashbackEntryColumns = `
+	id, tenant_id, credit_card_id, statement_entry_id, entry_type,
+	entry_date, amount, description, reference_id, transaction_amount,
+	cashback_rate, category_bonus, metadata, created_at, created_by,
+	sequence, prev_hash, hash`
+
+// Create inserts a new cashback ledger entry
+func (r *cashbackEntryRepo) Create(ctx context.Context, entry *models.CashbackLedgerEntry) error {
+	query := `
+		INSERT INTO cashback_ledger_entries (` + cashbackEntryColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`
+
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	head, err := lockChainHead(ctx, r.db, models.LedgerCashback, entry.TenantID)
+	if err != nil {
+		return err
+	}
+	entry.EntryDate = microseconds(entry.EntryDate)
+	entry.Seal(head.Sequence+1, head.Hash)
+
+	_, err = r.db.ExecContext(ctx, query,
+		entry.ID,
+		entry.TenantID,
+		entry.CreditCardID,
+		entry.StatementEntryID,
+		entry.EntryType,
+		entry.EntryDate,
+		entry.Amount,
+		entry.Description,
+		entry.ReferenceID,
+		entry.TransactionAmount,
+		entry.CashbackRate,
+		entry.CategoryBonus,
+		jsonMap(entry.Metadata),
+		entry.CreatedAt,
+		entry.CreatedBy,
+		entry.Sequence,
+		entry.PrevHash,
+		entry.Hash,
+	)
+	if err != nil {
+		return err
+	}
+
+	return advanceChainHead(ctx, r.db, models.LedgerCashback, entry.TenantID, entry.ChainLink)
+}
+
+// ChainHead returns the newest link of the tenant's cashback chain
+func (r *cashbackEntryRepo) ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error) {
+	return chainHead(ctx, r.db, models.LedgerCashback, tenantID, "")
+}
+
+// List retrieves cashback ledger entries matching the filter
+func (r *cashbackEntryRepo) List(
+	ctx context.Context,
+	filter repository.CashbackEntryFilter,
+) ([]*models.CashbackLedgerEntry, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.CreditCardID != nil {
+		c.add("credit_card_id = ?", *filter.CreditCardID)
+	}
+	if filter.StatementEntryID != nil {
+		c.add("statement_entry_id = ?", *filter.StatementEntryID)
+	}
+	if filter.From != nil {
+		c.add("entry_date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		c.add("entry_date <= ?", *filter.To)
+	}
+	c.addIn("entry_type", stringsOf(filter.EntryTypes))
+
+	order := ` ORDER BY entry_date, created_at `
+	if filter.Limit > 0 {
+		order = ` ORDER BY entry_date DESC, created_at DESC `
+	}
+	query := `SELECT ` + cashbackEntryColumns + ` FROM cashback_ledger_entries ` +
+		c.where() + order + c.limit(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.CashbackLedgerEntry
+	for rows.Next() {
+		entry := &models.CashbackLedgerEntry{}
+		var metadata jsonMap
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TenantID,
+			&entry.CreditCardID,
+			&entry.StatementEntryID,
+			&entry.EntryType,
+			&entry.EntryDate,
+			&entry.Amount,
+			&entry.Description,
+			&entry.ReferenceID,
+			&entry.TransactionAmount,
+			&entry.CashbackRate,
+			&entry.CategoryBonus,
+			&metadata,
+			&entry.CreatedAt,
+			&entry.CreatedBy,
+			&entry.Sequence,
+			&entry.PrevHash,
+			&entry.Hash,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.Metadata = metadata
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// SaveCategory upserts a cashback bonus category
+func (r *cashbackEntryRepo) SaveCategory(ctx context.Context, category *models.CashbackCategory) error {
+	query := `
+		INSERT INTO cashback_categories (
+			id, credit_card_id, category_code, category_name, bonus_rate,
+			max_bonus, is_active, start_date, end_date
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (credit_card_id, category_code)
+		DO UPDATE SET
+			category_name = EXCLUDED.category_name,
+			bonus_rate = EXCLUDED.bonus_rate,
+			max_bonus = EXCLUDED.max_bonus,
+			is_active = EXCLUDED.is_active,
+			start_date = EXCLUDED.start_date,
+			end_date = EXCLUDED.end_date
+	`
+
+	if category.ID == uuid.Nil {
+		category.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		category.ID,
+		category.CreditCardID,
+		category.CategoryCode,
+		category.CategoryName,
+		category.BonusRate,
+		category.MaxBonus,
+		category.IsActive,
+		category.StartDate,
+		category.EndDate,
+	)
+
+	return err
+}
+
+// ListCategories retrieves all bonus categories configured for a card
+func (r *cashbackEntryRepo) ListCategories(
+	ctx context.Context,
+	creditCardID uuid.UUID,
+) ([]*models.CashbackCategory, error) {
+	query := `
+		SELECT id, credit_card_id, category_code, category_name, bonus_rate,
+		       max_bonus, is_active, start_date, end_date
+		FROM cashback_categories
+		WHERE credit_card_id = $1
+		ORDER BY category_code
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, creditCardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.CashbackCategory
+	for rows.Next() {
+		cat := &models.CashbackCategory{}
+		err := rows.Scan(
+			&cat.ID,
+			&cat.CreditCardID,
+			&cat.CategoryCode,
+			&cat.CategoryName,
+			&cat.BonusRate,
+			&cat.MaxBonus,
+			&cat.IsActive,
+			&cat.StartDate,
+			&cat.EndDate,
+		)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, cat)
+	}
+
+	return categories, rows.Err()
+}
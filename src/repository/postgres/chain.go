@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+// lockChainHead returns the newest link of a tenant's chain and locks it
+// until the transaction ends, so appends to one chain are serialized.
+// Outside a transaction the unique (tenant_id, sequence) constraint rejects
+// a racing append instead.
+func lockChainHead(
+	ctx context.Context,
+	db Querier,
+	ledger models.LedgerName,
+	tenantID uuid.UUID,
+) (models.ChainLink, error) {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO ledger_chain_heads (ledger, tenant_id, sequence, hash)
+		VALUES ($1, $2, 0, '')
+		ON CONFLICT (ledger, tenant_id) DO NOTHING
+	`, ledger, tenantID)
+	if err != nil {
+		return models.ChainLink{}, err
+	}
+
+	return chainHead(ctx, db, ledger, tenantID, " FOR UPDATE")
+}
+
+// chainHead returns the newest link of a tenant's chain.
+// A tenant with no entries has the zero link.
+func chainHead(
+	ctx context.Context,
+	db Querier,
+	ledger models.LedgerName,
+	tenantID uuid.UUID,
+	lock string,
+) (models.ChainLink, error) {
+	query := `
+		SELECT sequence, hash FROM ledger_chain_heads
+		WHERE ledger = $1 AND tenant_id = $2` + lock
+
+	var head models.ChainLink
+	err := db.QueryRowContext(ctx, query, ledger, tenantID).Scan(&head.Sequence, &head.Hash)
+	if err == sql.ErrNoRows {
+		return models.ChainLink{}, nil
+	}
+	return head, err
+}
+
+// advanceChainHead records link as the newest of the tenant's chain
+func advanceChainHead(
+	ctx context.Context,
+	db Querier,
+	ledger models.LedgerName,
+	tenantID uuid.UUID,
+	link models.ChainLink,
+) error {
+	_, err := db.ExecContext(ctx, `
+		UPDATE ledger_chain_heads SET sequence = $1, hash = $2
+		WHERE ledger = $3 AND tenant_id = $4
+	`, link.Sequence, link.Hash, ledger, tenantID)
+	return err
+}
+
+// microseconds rounds t to the precision of a TIMESTAMPTZ column, so the
+// hash computed before an insert matches the value read back
+func microseconds(t time.Time) time.Time {
+	return t.Round(time.Microsecond)
+}
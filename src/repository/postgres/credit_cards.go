@@ -0,0 +1,207 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type creditCardRepo struct {
+	db Querier
+}
+
+const creditCardColumns = `
+	id, tenant_id, cardholder_name, credit_limit, available_credit,
+	purchase_apr, cash_advance_apr, penalty_apr, introductory_apr,
+	introductory_end_date, annual_fee, late_payment_fee, failed_payment_fee,
+	international_fee_rate, cash_advance_fee, cash_advance_fee_rate, over_limit_fee,
+	balance_transfer_apr, balance_transfer_end_date,
+	balance_transfer_fee, balance_transfer_fee_rate,
+	billing_cycle_type, billing_cycle_day, payment_due_days, grace_period_days,
+	minimum_payment_percent, minimum_payment_amount,
+	cashback_enabled, cashback_rate, cashback_redemption_min,
+	status, last_statement_date, next_statement_date,
+	last_payment_date, last_payment_amount, consecutive_late_count,
+	days_past_due, delinquency_bucket, charged_off_at,
+	created_at, updated_at, closed_at`
+
+// Create inserts a new credit card account
+func (r *creditCardRepo) Create(ctx context.Context, card *models.CreditCard) error {
+	query := `
+		INSERT INTO credit_cards (
+			id, tenant_id, cardholder_name, credit_limit, available_credit,
+			purchase_apr, cash_advance_apr, penalty_apr, introductory_apr,
+			annual_fee, late_payment_fee, failed_payment_fee, international_fee_rate,
+			cash_advance_fee, cash_advance_fee_rate, over_limit_fee,
+			balance_transfer_apr, balance_transfer_end_date,
+			balance_transfer_fee, balance_transfer_fee_rate,
+			billing_cycle_type, billing_cycle_day, payment_due_days, grace_period_days,
+			minimum_payment_percent, minimum_payment_amount,
+			cashback_enabled, cashback_rate, cashback_redemption_min,
+			status, next_statement_date, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
+			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29,
+			$30, $31, $32, $33
+		)
+	`
+
+	if card.ID == uuid.Nil {
+		card.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		card.ID, card.TenantID, card.CardholderName, card.CreditLimit, card.AvailableCredit,
+		card.PurchaseAPR, card.CashAdvanceAPR, card.PenaltyAPR, card.IntroductoryAPR,
+		card.AnnualFee, card.LatePaymentFee, card.FailedPaymentFee, card.InternationalFeeRate,
+		card.CashAdvanceFee, card.CashAdvanceFeeRate, card.OverLimitFee,
+		card.BalanceTransferAPR, card.BalanceTransferEndDate,
+		card.BalanceTransferFee, card.BalanceTransferFeeRate,
+		card.BillingCycleType, card.BillingCycleDay, card.PaymentDueDays, card.GracePeriodDays,
+		card.MinimumPaymentPercent, card.MinimumPaymentAmount,
+		card.CashbackEnabled, card.CashbackRate, card.CashbackRedemptionMin,
+		card.Status, card.NextStatementDate, card.CreatedAt, card.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetByID retrieves a credit card by ID
+func (r *creditCardRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.CreditCard, error) {
+	query := `SELECT ` + creditCardColumns + ` FROM credit_cards WHERE id = $1`
+
+	card, err := scanCreditCard(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return card, attachAPRSchedules(ctx, r.db, card)
+}
+
+// GetForUpdate retrieves a credit card and locks its row with SELECT ... FOR UPDATE
+func (r *creditCardRepo) GetForUpdate(ctx context.Context, id uuid.UUID) (*models.CreditCard, error) {
+	query := `SELECT ` + creditCardColumns + ` FROM credit_cards WHERE id = $1 FOR UPDATE`
+
+	card, err := scanCreditCard(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return card, attachAPRSchedules(ctx, r.db, card)
+}
+
+// List retrieves credit cards matching the filter
+func (r *creditCardRepo) List(
+	ctx context.Context,
+	filter repository.CreditCardFilter,
+) ([]*models.CreditCard, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	c.addIn("status", stringsOf(filter.Statuses))
+
+	query := `SELECT ` + creditCardColumns + ` FROM credit_cards ` +
+		c.where() + ` ORDER BY created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cards []*models.CreditCard
+	for rows.Next() {
+		card, err := scanCreditCard(rows)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return cards, attachAPRSchedules(ctx, r.db, cards...)
+}
+
+// Update writes every mutable column of the card
+func (r *creditCardRepo) Update(ctx context.Context, card *models.CreditCard) error {
+	query := `
+		UPDATE credit_cards
+		SET cardholder_name = $1, credit_limit = $2, available_credit = $3,
+		    purchase_apr = $4, cash_advance_apr = $5, penalty_apr = $6,
+		    introductory_apr = $7, introductory_end_date = $8,
+		    annual_fee = $9, late_payment_fee = $10, failed_payment_fee = $11,
+		    international_fee_rate = $12, cash_advance_fee = $13,
+		    cash_advance_fee_rate = $14, over_limit_fee = $15,
+		    balance_transfer_apr = $16, balance_transfer_end_date = $17,
+		    balance_transfer_fee = $18, balance_transfer_fee_rate = $19,
+		    billing_cycle_type = $20, billing_cycle_day = $21,
+		    payment_due_days = $22, grace_period_days = $23,
+		    minimum_payment_percent = $24, minimum_payment_amount = $25,
+		    cashback_enabled = $26, cashback_rate = $27, cashback_redemption_min = $28,
+		    status = $29, last_statement_date = $30, next_statement_date = $31,
+		    last_payment_date = $32, last_payment_amount = $33,
+		    consecutive_late_count = $34, days_past_due = $35,
+		    delinquency_bucket = $36, charged_off_at = $37,
+		    updated_at = $38, closed_at = $39
+		WHERE id = $40
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		card.CardholderName, card.CreditLimit, card.AvailableCredit,
+		card.PurchaseAPR, card.CashAdvanceAPR, card.PenaltyAPR,
+		card.IntroductoryAPR, card.IntroductoryEndDate,
+		card.AnnualFee, card.LatePaymentFee, card.FailedPaymentFee,
+		card.InternationalFeeRate, card.CashAdvanceFee,
+		card.CashAdvanceFeeRate, card.OverLimitFee,
+		card.BalanceTransferAPR, card.BalanceTransferEndDate,
+		card.BalanceTransferFee, card.BalanceTransferFeeRate,
+		card.BillingCycleType, card.BillingCycleDay,
+		card.PaymentDueDays, card.GracePeriodDays,
+		card.MinimumPaymentPercent, card.MinimumPaymentAmount,
+		card.CashbackEnabled, card.CashbackRate, card.CashbackRedemptionMin,
+		card.Status, card.LastStatementDate, card.NextStatementDate,
+		card.LastPaymentDate, card.LastPaymentAmount,
+		card.ConsecutiveLateCount, card.DaysPastDue,
+		card.DelinquencyBucket, card.ChargedOffAt,
+		card.UpdatedAt, card.ClosedAt,
+		card.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+func scanCreditCard(row rowScanner) (*models.CreditCard, error) {
+	card := &models.CreditCard{}
+	err := row.Scan(
+		&card.ID, &card.TenantID, &card.CardholderName, &card.CreditLimit, &card.AvailableCredit,
+		&card.PurchaseAPR, &card.CashAdvanceAPR, &card.PenaltyAPR, &card.IntroductoryAPR,
+		&card.IntroductoryEndDate, &card.AnnualFee, &card.LatePaymentFee, &card.FailedPaymentFee,
+		&card.InternationalFeeRate, &card.CashAdvanceFee, &card.CashAdvanceFeeRate, &card.OverLimitFee,
+		&card.BalanceTransferAPR, &card.BalanceTransferEndDate,
+		&card.BalanceTransferFee, &card.BalanceTransferFeeRate,
+		&card.BillingCycleType, &card.BillingCycleDay, &card.PaymentDueDays, &card.GracePeriodDays,
+		&card.MinimumPaymentPercent, &card.MinimumPaymentAmount,
+		&card.CashbackEnabled, &card.CashbackRate, &card.CashbackRedemptionMin,
+		&card.Status, &card.LastStatementDate, &card.NextStatementDate,
+		&card.LastPaymentDate, &card.LastPaymentAmount, &card.ConsecutiveLateCount,
+		&card.DaysPastDue, &card.DelinquencyBucket, &card.ChargedOffAt,
+		&card.CreatedAt, &card.UpdatedAt, &card.ClosedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return card, nil
+}
@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+type delinquencyRepo struct {
+	db Querier
+}
+
+const delinquencyColumns = `
+	id, tenant_id, credit_card_id, bucket, previous_bucket, days_past_due,
+	billing_cycle_id, past_due_amount, charge_off_amount, as_of, created_at`
+
+// Create inserts an aging record
+func (r *delinquencyRepo) Create(ctx context.Context, record *models.DelinquencyRecord) error {
+	query := `
+		INSERT INTO delinquency_history (` + delinquencyColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	if record.ID == uuid.Nil {
+		record.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		record.ID, record.TenantID, record.CreditCardID, record.Bucket, record.PreviousBucket, record.DaysPastDue,
+		record.BillingCycleID, record.PastDueAmount, record.ChargeOffAmount, record.AsOf, record.CreatedAt,
+	)
+
+	return err
+}
+
+// List retrieves a card's aging history ordered by date
+func (r *delinquencyRepo) List(ctx context.Context, creditCardID uuid.UUID) ([]*models.DelinquencyRecord, error) {
+	query := `SELECT ` + delinquencyColumns + ` FROM delinquency_history
+		WHERE credit_card_id = $1 ORDER BY as_of, created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, creditCardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*models.DelinquencyRecord
+	for rows.Next() {
+		record := &models.DelinquencyRecord{}
+		err := rows.Scan(
+			&record.ID, &record.TenantID, &record.CreditCardID, &record.Bucket, &record.PreviousBucket, &record.DaysPastDue,
+			&record.BillingCycleID, &record.PastDueAmount, &record.ChargeOffAmount, &record.AsOf, &record.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type disputeRepo struct {
+	db Querier
+}
+
+const disputeColumns = `
+	id, tenant_id, credit_card_id, statement_entry_id,
+	reason, description, amount,
+	status, provisional_credit_entry_id, final_credit_entry_id, reversal_entry_id,
+	chargeback_reference, resolution,
+	statement_date, notice_received_at, reg_z_covered, acknowledge_by, resolve_by,
+	acknowledged_at, provisional_credit_at, chargeback_filed_at, resolved_at,
+	metadata, created_at, updated_at, created_by`
+
+// Create inserts a new dispute
+func (r *disputeRepo) Create(ctx context.Context, dispute *models.Dispute) error {
+	query := `
+		INSERT INTO disputes (` + disputeColumns + `
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10,
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+			$21, $22, $23, $24, $25, $26
+		)
+	`
+
+	if dispute.ID == uuid.Nil {
+		dispute.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		dispute.ID, dispute.TenantID, dispute.CreditCardID, dispute.StatementEntryID,
+		dispute.Reason, dispute.Description, dispute.Amount,
+		dispute.Status, dispute.ProvisionalCreditEntryID, dispute.FinalCreditEntryID, dispute.ReversalEntryID,
+		dispute.ChargebackReference, dispute.Resolution,
+		dispute.StatementDate, dispute.NoticeReceivedAt, dispute.RegZCovered,
+		dispute.AcknowledgeBy, dispute.ResolveBy,
+		dispute.AcknowledgedAt, dispute.ProvisionalCreditAt, dispute.ChargebackFiledAt, dispute.ResolvedAt,
+		jsonMap(dispute.Metadata), dispute.CreatedAt, dispute.UpdatedAt, dispute.CreatedBy,
+	)
+
+	return err
+}
+
+// GetByID retrieves a dispute by ID
+func (r *disputeRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes WHERE id = $1`
+
+	dispute, err := scanDispute(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return dispute, err
+}
+
+// List retrieves disputes matching the filter
+func (r *disputeRepo) List(
+	ctx context.Context,
+	filter repository.DisputeFilter,
+) ([]*models.Dispute, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.CreditCardID != nil {
+		c.add("credit_card_id = ?", *filter.CreditCardID)
+	}
+	if filter.StatementEntryID != nil {
+		c.add("statement_entry_id = ?", *filter.StatementEntryID)
+	}
+	c.addIn("status", stringsOf(filter.Statuses))
+
+	query := `SELECT ` + disputeColumns + ` FROM disputes ` +
+		c.where() + ` ORDER BY notice_received_at, created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disputes []*models.Dispute
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, dispute)
+	}
+
+	return disputes, rows.Err()
+}
+
+// Update writes every mutable column of the dispute
+func (r *disputeRepo) Update(ctx context.Context, dispute *models.Dispute) error {
+	query := `
+		UPDATE disputes
+		SET description = $1, status = $2,
+		    provisional_credit_entry_id = $3, final_credit_entry_id = $4, reversal_entry_id = $5,
+		    chargeback_reference = $6, resolution = $7,
+		    acknowledged_at = $8, provisional_credit_at = $9,
+		    chargeback_filed_at = $10, resolved_at = $11,
+		    metadata = $12, updated_at = $13
+		WHERE id = $14
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		dispute.Description, dispute.Status,
+		dispute.ProvisionalCreditEntryID, dispute.FinalCreditEntryID, dispute.ReversalEntryID,
+		dispute.ChargebackReference, dispute.Resolution,
+		dispute.AcknowledgedAt, dispute.ProvisionalCreditAt,
+		dispute.ChargebackFiledAt, dispute.ResolvedAt,
+		jsonMap(dispute.Metadata), dispute.UpdatedAt,
+		dispute.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+// CreateTransition records a dispute status change
+func (r *disputeRepo) CreateTransition(ctx context.Context, transition *models.DisputeStatusTransition) error {
+	query := `
+		INSERT INTO dispute_status_transitions (
+			id, dispute_id, from_status, to_status, reason,
+			transition_at, triggered_by, metadata
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	if transition.ID == uuid.Nil {
+		transition.ID = uuid.New()
+	}
+
+	// The opening transition has no from status
+	var fromStatus *string
+	if transition.FromStatus != "" {
+		from := string(transition.FromStatus)
+		fromStatus = &from
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		transition.ID, transition.DisputeID, fromStatus, transition.ToStatus, transition.Reason,
+		transition.TransitionAt, transition.TriggeredBy, jsonMap(transition.Metadata),
+	)
+
+	return err
+}
+
+// ListTransitions retrieves a dispute's status history, oldest first
+func (r *disputeRepo) ListTransitions(
+	ctx context.Context,
+	disputeID uuid.UUID,
+) ([]*models.DisputeStatusTransition, error) {
+	query := `
+		SELECT id, dispute_id, from_status, to_status, reason,
+		       transition_at, triggered_by, metadata
+		FROM dispute_status_transitions
+		WHERE dispute_id = $1
+		ORDER BY transition_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, disputeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transitions []*models.DisputeStatusTransition
+	for rows.Next() {
+		transition := &models.DisputeStatusTransition{}
+		var fromStatus sql.NullString
+		var metadata jsonMap
+		err := rows.Scan(
+			&transition.ID, &transition.DisputeID, &fromStatus, &transition.ToStatus, &transition.Reason,
+			&transition.TransitionAt, &transition.TriggeredBy, &metadata,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transition.FromStatus = models.DisputeStatus(fromStatus.String)
+		transition.Metadata = metadata
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, rows.Err()
+}
+
+func scanDispute(row rowScanner) (*models.Dispute, error) {
+	dispute := &models.Dispute{}
+	var description sql.NullString
+	var metadata jsonMap
+	err := row.Scan(
+		&dispute.ID, &dispute.TenantID, &dispute.CreditCardID, &dispute.StatementEntryID,
+		&dispute.Reason, &description, &dispute.Amount,
+		&dispute.Status, &dispute.ProvisionalCreditEntryID, &dispute.FinalCreditEntryID, &dispute.ReversalEntryID,
+		&dispute.ChargebackReference, &dispute.Resolution,
+		&dispute.StatementDate, &dispute.NoticeReceivedAt, &dispute.RegZCovered,
+		&dispute.AcknowledgeBy, &dispute.ResolveBy,
+		&dispute.AcknowledgedAt, &dispute.ProvisionalCreditAt, &dispute.ChargebackFiledAt, &dispute.ResolvedAt,
+		&metadata, &dispute.CreatedAt, &dispute.UpdatedAt, &dispute.CreatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	dispute.Description = description.String
+	dispute.Metadata = metadata
+	return dispute, nil
+}
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+// uniqueViolation is the Postgres error code for a unique constraint violation
+const uniqueViolation = "23505"
+
+type idempotencyRepo struct {
+	db Querier
+}
+
+// Create inserts a new idempotency record
+func (r *idempotencyRepo) Create(ctx context.Context, record *models.IdempotencyRecord) error {
+	query := `
+		INSERT INTO idempotency_keys (
+			id, tenant_id, idempotency_key, operation, response, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if record.ID == uuid.Nil {
+		record.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		record.ID, record.TenantID, record.Key, record.Operation,
+		string(record.Response), record.CreatedAt,
+	)
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+// Get retrieves the record for a tenant's idempotency key
+func (r *idempotencyRepo) Get(ctx context.Context, tenantID uuid.UUID, key string) (*models.IdempotencyRecord, error) {
+	query := `
+		SELECT id, tenant_id, idempotency_key, operation, response, created_at
+		FROM idempotency_keys
+		WHERE tenant_id = $1 AND idempotency_key = $2
+	`
+
+	record := &models.IdempotencyRecord{}
+	var response []byte
+	err := r.db.QueryRowContext(ctx, query, tenantID, key).Scan(
+		&record.ID, &record.TenantID, &record.Key, &record.Operation,
+		&response, &record.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	record.Response = response
+	return record, nil
+}
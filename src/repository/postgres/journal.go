@@ -0,0 +1,114 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type journalRepo struct {
+	db Querier
+}
+
+// Create inserts a journal entry and its lines.
+// Run it inside a transaction: the balance check on journal_lines is deferred
+// to commit, and a partially written entry would be rejected.
+func (r *journalRepo) Create(ctx context.Context, entry *models.JournalEntry) error {
+	entryQuery := `
+		INSERT INTO journal_entries (
+			id, tenant_id, source_type, source_id, entry_date, description, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	lineQuery := `
+		INSERT INTO journal_lines (
+			id, journal_entry_id, line_number, account_code, debit, credit
+		) VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, entryQuery,
+		entry.ID, entry.TenantID, entry.SourceType, entry.SourceID,
+		entry.EntryDate, entry.Description, entry.CreatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	for i, line := range entry.Lines {
+		_, err := r.db.ExecContext(ctx, lineQuery,
+			uuid.New(), entry.ID, i+1, line.AccountCode, line.Debit, line.Credit,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert journal line %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
+
+// List retrieves journal entries and their lines matching the filter
+func (r *journalRepo) List(
+	ctx context.Context,
+	filter repository.JournalFilter,
+) ([]*models.JournalEntry, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("e.tenant_id = ?", *filter.TenantID)
+	}
+	if filter.SourceID != nil {
+		c.add("e.source_id = ?", *filter.SourceID)
+	}
+	if filter.From != nil {
+		c.add("e.entry_date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		c.add("e.entry_date <= ?", *filter.To)
+	}
+
+	query := `
+		SELECT e.id, e.tenant_id, e.source_type, e.source_id, e.entry_date,
+		       e.description, e.created_at,
+		       l.account_code, l.debit, l.credit
+		FROM journal_entries e
+		JOIN journal_lines l ON l.journal_entry_id = e.id
+		` + c.where() + `
+		ORDER BY e.entry_date, e.created_at, e.id, l.line_number
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.JournalEntry
+	var current *models.JournalEntry
+	for rows.Next() {
+		entry := &models.JournalEntry{}
+		var line models.JournalLine
+		err := rows.Scan(
+			&entry.ID, &entry.TenantID, &entry.SourceType, &entry.SourceID, &entry.EntryDate,
+			&entry.Description, &entry.CreatedAt,
+			&line.AccountCode, &line.Debit, &line.Credit,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Rows arrive grouped by entry, one per line
+		if current == nil || current.ID != entry.ID {
+			current = entry
+			entries = append(entries, current)
+		}
+		current.Lines = append(current.Lines, line)
+	}
+
+	return entries, rows.Err()
+}
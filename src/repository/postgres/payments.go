@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type paymentRepo struct {
+	db Querier
+}
+
+const paymentColumns = `
+	id, tenant_id, credit_card_id, payment_number, confirmation_num,
+	amount, currency, applied_amount, processing_fee,
+	payment_type, payment_method,
+	source_account_last4, source_routing_last4, source_bank_name,
+	billing_cycle_id, statement_entry_id,
+	status, previous_status, status_reason,
+	scheduled_date, initiated_at, processing_at, cleared_at, failed_at,
+	returned_at, cancelled_at, reversed_at, effective_date,
+	processor_ref, processor_response, return_reason_code, return_reason_desc,
+	attempt_count, last_attempt_at, next_retry_at, max_retries,
+	metadata, notes, created_at, updated_at, created_by, updated_by`
+
+// Create inserts a new payment
+func (r *paymentRepo) Create(ctx context.Context, payment *models.Payment) error {
+	query := `
+		INSERT INTO payments (` + paymentColumns + `
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+			$15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28,
+			$29, $30, $31, $32, $33, $34, $35, $36, $37, $38, $39, $40, $41, $42
+		)
+	`
+
+	if payment.ID == uuid.Nil {
+		payment.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		payment.ID, payment.TenantID, payment.CreditCardID, payment.PaymentNumber, payment.ConfirmationNum,
+		payment.Amount, payment.Currency, payment.AppliedAmount, payment.ProcessingFee,
+		payment.PaymentType, payment.PaymentMethod,
+		payment.SourceAccountLast4, payment.SourceRoutingLast4, payment.SourceBankName,
+		payment.BillingCycleID, payment.StatementEntryID,
+		payment.Status, payment.PreviousStatus, payment.StatusReason,
+		payment.ScheduledDate, payment.InitiatedAt, payment.ProcessingAt, payment.ClearedAt, payment.FailedAt,
+		payment.ReturnedAt, payment.CancelledAt, payment.ReversedAt, payment.EffectiveDate,
+		payment.ProcessorRef, payment.ProcessorResponse, payment.ReturnReasonCode, payment.ReturnReasonDesc,
+		payment.AttemptCount, payment.LastAttemptAt, payment.NextRetryAt, payment.MaxRetries,
+		jsonMap(payment.Metadata), payment.Notes, payment.CreatedAt, payment.UpdatedAt,
+		payment.CreatedBy, payment.UpdatedBy,
+	)
+
+	return err
+}
+
+// GetByID retrieves a payment by ID
+func (r *paymentRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = $1`
+
+	payment, err := scanPayment(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return payment, err
+}
+
+// GetByNumber retrieves a payment by its payment number
+func (r *paymentRepo) GetByNumber(ctx context.Context, paymentNumber string) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE payment_number = $1`
+
+	payment, err := scanPayment(r.db.QueryRowContext(ctx, query, paymentNumber))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return payment, err
+}
+
+// List retrieves payments matching the filter
+func (r *paymentRepo) List(
+	ctx context.Context,
+	filter repository.PaymentFilter,
+) ([]*models.Payment, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.CreditCardID != nil {
+		c.add("credit_card_id = ?", *filter.CreditCardID)
+	}
+	c.addIn("status", stringsOf(filter.Statuses))
+
+	query := `SELECT ` + paymentColumns + ` FROM payments ` +
+		c.where() + ` ORDER BY initiated_at, created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*models.Payment
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+// Update writes every mutable column of the payment
+func (r *paymentRepo) Update(ctx context.Context, payment *models.Payment) error {
+	query := `
+		UPDATE payments
+		SET confirmation_num = $1, applied_amount = $2, processing_fee = $3,
+		    billing_cycle_id = $4, statement_entry_id = $5,
+		    status = $6, previous_status = $7, status_reason = $8,
+		    processing_at = $9, cleared_at = $10, failed_at = $11,
+		    returned_at = $12, cancelled_at = $13, reversed_at = $14, effective_date = $15,
+		    processor_ref = $16, processor_response = $17,
+		    return_reason_code = $18, return_reason_desc = $19,
+		    attempt_count = $20, last_attempt_at = $21, next_retry_at = $22,
+		    metadata = $23, notes = $24, updated_at = $25, updated_by = $26
+		WHERE id = $27
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		payment.ConfirmationNum, payment.AppliedAmount, payment.ProcessingFee,
+		payment.BillingCycleID, payment.StatementEntryID,
+		payment.Status, payment.PreviousStatus, payment.StatusReason,
+		payment.ProcessingAt, payment.ClearedAt, payment.FailedAt,
+		payment.ReturnedAt, payment.CancelledAt, payment.ReversedAt, payment.EffectiveDate,
+		payment.ProcessorRef, payment.ProcessorResponse,
+		payment.ReturnReasonCode, payment.ReturnReasonDesc,
+		payment.AttemptCount, payment.LastAttemptAt, payment.NextRetryAt,
+		jsonMap(payment.Metadata), payment.Notes, payment.UpdatedAt, payment.UpdatedBy,
+		payment.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+// CreateTransition records a payment status change
+func (r *paymentRepo) CreateTransition(ctx context.Context, transition *models.PaymentStatusTransition) error {
+	query := `
+		INSERT INTO payment_status_transitions (
+			id, payment_id, from_status, to_status, reason,
+			transition_at, triggered_by, metadata
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	if transition.ID == uuid.Nil {
+		transition.ID = uuid.New()
+	}
+
+	// The initial transition has no from status
+	var fromStatus *string
+	if transition.FromStatus != "" {
+		from := string(transition.FromStatus)
+		fromStatus = &from
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		transition.ID, transition.PaymentID, fromStatus, transition.ToStatus, transition.Reason,
+		transition.TransitionAt, transition.TriggeredBy, jsonMap(transition.Metadata),
+	)
+
+	return err
+}
+
+// ListTransitions retrieves a payment's status history, oldest first
+func (r *paymentRepo) ListTransitions(
+	ctx context.Context,
+	paymentID uuid.UUID,
+) ([]*models.PaymentStatusTransition, error) {
+	query := `
+		SELECT id, payment_id, from_status, to_status, reason,
+		       transition_at, triggered_by, metadata
+		FROM payment_status_transitions
+		WHERE payment_id = $1
+		ORDER BY transition_at, id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transitions []*models.PaymentStatusTransition
+	for rows.Next() {
+		transition := &models.PaymentStatusTransition{}
+		var fromStatus sql.NullString
+		var metadata jsonMap
+		err := rows.Scan(
+			&transition.ID, &transition.PaymentID, &fromStatus, &transition.ToStatus, &transition.Reason,
+			&transition.TransitionAt, &transition.TriggeredBy, &metadata,
+		)
+		if err != nil {
+			return nil, err
+		}
+		transition.FromStatus = models.PaymentStatus(fromStatus.String)
+		transition.Metadata = metadata
+		transitions = append(transitions, transition)
+	}
+
+	return transitions, rows.Err()
+}
+
+func scanPayment(row rowScanner) (*models.Payment, error) {
+	payment := &models.Payment{}
+	var metadata jsonMap
+	err := row.Scan(
+		&payment.ID, &payment.TenantID, &payment.CreditCardID, &payment.PaymentNumber, &payment.ConfirmationNum,
+		&payment.Amount, &payment.Currency, &payment.AppliedAmount, &payment.ProcessingFee,
+		&payment.PaymentType, &payment.PaymentMethod,
+		&payment.SourceAccountLast4, &payment.SourceRoutingLast4, &payment.SourceBankName,
+		&payment.BillingCycleID, &payment.StatementEntryID,
+		&payment.Status, &payment.PreviousStatus, &payment.StatusReason,
+		&payment.ScheduledDate, &payment.InitiatedAt, &payment.ProcessingAt, &payment.ClearedAt, &payment.FailedAt,
+		&payment.ReturnedAt, &payment.CancelledAt, &payment.ReversedAt, &payment.EffectiveDate,
+		&payment.ProcessorRef, &payment.ProcessorResponse, &payment.ReturnReasonCode, &payment.ReturnReasonDesc,
+		&payment.AttemptCount, &payment.LastAttemptAt, &payment.NextRetryAt, &payment.MaxRetries,
+		&metadata, &payment.Notes, &payment.CreatedAt, &payment.UpdatedAt,
+		&payment.CreatedBy, &payment.UpdatedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	payment.Metadata = metadata
+	return payment, nil
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+type penaltyEventRepo struct {
+	db Querier
+}
+
+const penaltyEventColumns = `
+	id, tenant_id, credit_card_id, event_type, trigger, billing_cycle_id, payment_id,
+	days_late, on_time_payments, penalty_apr, prior_purchase_apr, prior_cash_advance_apr,
+	occurred_at, effective_date, created_at`
+
+// Create inserts a penalty event
+func (r *penaltyEventRepo) Create(ctx context.Context, event *models.PenaltyEvent) error {
+	query := `
+		INSERT INTO penalty_events (` + penaltyEventColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+
+	// Cured events have no trigger
+	var trigger *string
+	if event.Trigger != "" {
+		t := string(event.Trigger)
+		trigger = &t
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		event.ID, event.TenantID, event.CreditCardID, event.EventType, trigger, event.BillingCycleID, event.PaymentID,
+		event.DaysLate, event.OnTimePayments, event.PenaltyAPR, event.PriorPurchaseAPR, event.PriorCashAdvanceAPR,
+		event.OccurredAt, event.EffectiveDate, event.CreatedAt,
+	)
+
+	return err
+}
+
+// List retrieves a card's penalty events ordered by occurrence
+func (r *penaltyEventRepo) List(ctx context.Context, creditCardID uuid.UUID) ([]*models.PenaltyEvent, error) {
+	query := `SELECT ` + penaltyEventColumns + ` FROM penalty_events
+		WHERE credit_card_id = $1 ORDER BY occurred_at, created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, creditCardID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.PenaltyEvent
+	for rows.Next() {
+		event := &models.PenaltyEvent{}
+		var trigger sql.NullString
+		err := rows.Scan(
+			&event.ID, &event.TenantID, &event.CreditCardID, &event.EventType, &trigger, &event.BillingCycleID, &event.PaymentID,
+			&event.DaysLate, &event.OnTimePayments, &event.PenaltyAPR, &event.PriorPurchaseAPR, &event.PriorCashAdvanceAPR,
+			&event.OccurredAt, &event.EffectiveDate, &event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		event.Trigger = models.PenaltyTrigger(trigger.String)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type pointsEntryRepo struct {
+	db Querier
+}
+
+// Create inserts a new points ledger entry
+func (r *pointsEntryRepo) Create(ctx context.Context, entry *models.PointsLedgerEntry) error {
+	query := `
+		INSERT INTO points_ledger_entries (
+			id, tenant_id, statement_entry_id, entry_type, entry_date,
+			points, description, external_platform, external_reference_id,
+			transaction_amount, points_rate, metadata, created_by,
+			sequence, prev_hash, hash
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	head, err := lockChainHead(ctx, r.db, models.LedgerPoints, entry.TenantID)
+	if err != nil {
+		return err
+	}
+	entry.EntryDate = microseconds(entry.EntryDate)
+	entry.Seal(head.Sequence+1, head.Hash)
+
+	_, err = r.db.ExecContext(ctx, query,
+		entry.ID,
+		entry.TenantID,
+		entry.StatementEntryID,
+		entry.EntryType,
+		entry.EntryDate,
+		entry.Points,
+		entry.Description,
+		entry.ExternalPlatform,
+		entry.ExternalReferenceID,
+		entry.TransactionAmount,
+		entry.PointsRate,
+		jsonMap(entry.Metadata),
+		entry.CreatedBy,
+		entry.Sequence,
+		entry.PrevHash,
+		entry.Hash,
+	)
+	if err != nil {
+		return err
+	}
+
+	return advanceChainHead(ctx, r.db, models.LedgerPoints, entry.TenantID, entry.ChainLink)
+}
+
+// ChainHead returns the newest link of the tenant's points chain
+func (r *pointsEntryRepo) ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error) {
+	return chainHead(ctx, r.db, models.LedgerPoints, tenantID, "")
+}
+
+// List retrieves points ledger entries matching the filter
+func (r *pointsEntryRepo) List(
+	ctx context.Context,
+	filter repository.PointsEntryFilter,
+) ([]*models.PointsLedgerEntry, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.StatementEntryID != nil {
+		c.add("statement_entry_id = ?", *filter.StatementEntryID)
+	}
+	c.addIn("entry_type", stringsOf(filter.EntryTypes))
+
+	order := ` ORDER BY entry_date, created_at `
+	if filter.Limit > 0 {
+		order = ` ORDER BY entry_date DESC, created_at DESC `
+	}
+	query := `
+		SELECT id, tenant_id, statement_entry_id, entry_type, entry_date,
+		       points, description, external_platform, external_reference_id,
+		       transaction_amount, points_rate, metadata, created_at, created_by,
+		       sequence, prev_hash, hash
+		FROM points_ledger_entries ` + c.where() + order + c.limit(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.PointsLedgerEntry
+	for rows.Next() {
+		entry := &models.PointsLedgerEntry{}
+		var metadata jsonMap
+		err := rows.Scan(
+			&entry.ID,
+			&entry.TenantID,
+			&entry.StatementEntryID,
+			&entry.EntryType,
+			&entry.EntryDate,
+			&entry.Points,
+			&entry.Description,
+			&entry.ExternalPlatform,
+			&entry.ExternalReferenceID,
+			&entry.TransactionAmount,
+			&entry.PointsRate,
+			&metadata,
+			&entry.CreatedAt,
+			&entry.CreatedBy,
+			&entry.Sequence,
+			&entry.PrevHash,
+			&entry.Hash,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.Metadata = metadata
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
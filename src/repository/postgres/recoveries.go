@@ -0,0 +1,200 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type recoveryRepo struct {
+	db Querier
+}
+
+const chargeOffColumns = `
+	id, tenant_id, credit_card_id, principal, interest, fees,
+	principal_remaining, interest_remaining, fees_remaining, recovered,
+	charged_off_on, created_at, updated_at`
+
+const recoveryColumns = `
+	id, tenant_id, credit_card_id, charge_off_id, agency, reference_id,
+	amount, commission_rate, commission, net_amount,
+	principal_amount, interest_amount, fees_amount, recovered_on, created_at`
+
+// CreateChargeOff inserts a card's charge-off
+func (r *recoveryRepo) CreateChargeOff(ctx context.Context, chargeOff *models.ChargeOff) error {
+	query := `
+		INSERT INTO charge_offs (` + chargeOffColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	`
+
+	if chargeOff.ID == uuid.Nil {
+		chargeOff.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		chargeOff.ID, chargeOff.TenantID, chargeOff.CreditCardID,
+		chargeOff.Principal, chargeOff.Interest, chargeOff.Fees,
+		chargeOff.PrincipalRemaining, chargeOff.InterestRemaining, chargeOff.FeesRemaining, chargeOff.Recovered,
+		chargeOff.ChargedOffOn, chargeOff.CreatedAt, chargeOff.UpdatedAt,
+	)
+
+	return err
+}
+
+// GetChargeOff retrieves a card's charge-off
+func (r *recoveryRepo) GetChargeOff(ctx context.Context, creditCardID uuid.UUID) (*models.ChargeOff, error) {
+	query := `SELECT ` + chargeOffColumns + ` FROM charge_offs WHERE credit_card_id = $1`
+
+	chargeOff, err := scanChargeOff(r.db.QueryRowContext(ctx, query, creditCardID))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return chargeOff, err
+}
+
+// ListChargeOffs retrieves charge-offs matching the filter
+func (r *recoveryRepo) ListChargeOffs(
+	ctx context.Context,
+	filter repository.ChargeOffFilter,
+) ([]*models.ChargeOff, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.From != nil {
+		c.add("charged_off_on >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		c.add("charged_off_on <= ?", *filter.To)
+	}
+
+	query := `SELECT ` + chargeOffColumns + ` FROM charge_offs ` +
+		c.where() + ` ORDER BY charged_off_on, id`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chargeOffs []*models.ChargeOff
+	for rows.Next() {
+		chargeOff, err := scanChargeOff(rows)
+		if err != nil {
+			return nil, err
+		}
+		chargeOffs = append(chargeOffs, chargeOff)
+	}
+
+	return chargeOffs, rows.Err()
+}
+
+// UpdateChargeOff writes the remaining balances and amount recovered
+func (r *recoveryRepo) UpdateChargeOff(ctx context.Context, chargeOff *models.ChargeOff) error {
+	query := `
+		UPDATE charge_offs
+		SET principal_remaining = $1, interest_remaining = $2, fees_remaining = $3,
+		    recovered = $4, updated_at = $5
+		WHERE id = $6
+	`
+
+	result, err := r.db.ExecContext(ctx, query,
+		chargeOff.PrincipalRemaining, chargeOff.InterestRemaining, chargeOff.FeesRemaining,
+		chargeOff.Recovered, chargeOff.UpdatedAt,
+		chargeOff.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+// Create inserts a recovery
+func (r *recoveryRepo) Create(ctx context.Context, recovery *models.Recovery) error {
+	query := `
+		INSERT INTO recoveries (` + recoveryColumns + `
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+	`
+
+	if recovery.ID == uuid.Nil {
+		recovery.ID = uuid.New()
+	}
+
+	_, err := r.db.ExecContext(ctx, query,
+		recovery.ID, recovery.TenantID, recovery.CreditCardID, recovery.ChargeOffID,
+		recovery.Agency, recovery.ReferenceID,
+		recovery.Amount, recovery.CommissionRate, recovery.Commission, recovery.NetAmount,
+		recovery.PrincipalAmount, recovery.InterestAmount, recovery.FeesAmount,
+		recovery.RecoveredOn, recovery.CreatedAt,
+	)
+
+	return err
+}
+
+// List retrieves recoveries matching the filter
+func (r *recoveryRepo) List(
+	ctx context.Context,
+	filter repository.RecoveryFilter,
+) ([]*models.Recovery, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.CreditCardID != nil {
+		c.add("credit_card_id = ?", *filter.CreditCardID)
+	}
+	if filter.Agency != nil {
+		c.add("agency = ?", *filter.Agency)
+	}
+	if filter.From != nil {
+		c.add("recovered_on >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		c.add("recovered_on <= ?", *filter.To)
+	}
+
+	query := `SELECT ` + recoveryColumns + ` FROM recoveries ` +
+		c.where() + ` ORDER BY recovered_on, created_at, id`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recoveries []*models.Recovery
+	for rows.Next() {
+		recovery := &models.Recovery{}
+		err := rows.Scan(
+			&recovery.ID, &recovery.TenantID, &recovery.CreditCardID, &recovery.ChargeOffID,
+			&recovery.Agency, &recovery.ReferenceID,
+			&recovery.Amount, &recovery.CommissionRate, &recovery.Commission, &recovery.NetAmount,
+			&recovery.PrincipalAmount, &recovery.InterestAmount, &recovery.FeesAmount,
+			&recovery.RecoveredOn, &recovery.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		recoveries = append(recoveries, recovery)
+	}
+
+	return recoveries, rows.Err()
+}
+
+func scanChargeOff(row rowScanner) (*models.ChargeOff, error) {
+	chargeOff := &models.ChargeOff{}
+	err := row.Scan(
+		&chargeOff.ID, &chargeOff.TenantID, &chargeOff.CreditCardID,
+		&chargeOff.Principal, &chargeOff.Interest, &chargeOff.Fees,
+		&chargeOff.PrincipalRemaining, &chargeOff.InterestRemaining, &chargeOff.FeesRemaining, &chargeOff.Recovered,
+		&chargeOff.ChargedOffOn, &chargeOff.CreatedAt, &chargeOff.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return chargeOff, nil
+}
@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+type statementEntryRepo struct {
+	db Querier
+}
+
+const statementEntryColumns = `
+	id, tenant_id, statement_id, entry_type, entry_date, posting_date,
+	amount, description, reference_id, metadata, status, cleared_at,
+	created_at, created_by, sequence, prev_hash, hash`
+
+// Create inserts a new statement ledger entry
+func (r *statementEntryRepo) Create(ctx context.Context, entry *models.StatementLedgerEntry) error {
+	query := `
+		INSERT INTO statement_ledger_entries (
+			id, tenant_id, statement_id, entry_type, entry_date, posting_date,
+			amount, description, reference_id, metadata, status, cleared_at, created_by,
+			sequence, prev_hash, hash
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+
+	head, err := lockChainHead(ctx, r.db, models.LedgerStatement, entry.TenantID)
+	if err != nil {
+		return err
+	}
+	entry.EntryDate = microseconds(entry.EntryDate)
+	entry.Seal(head.Sequence+1, head.Hash)
+
+	_, err = r.db.ExecContext(ctx, query,
+		entry.ID,
+		entry.TenantID,
+		entry.StatementID,
+		entry.EntryType,
+		entry.EntryDate,
+		entry.PostingDate,
+		entry.Amount,
+		entry.Description,
+		entry.ReferenceID,
+		jsonMap(entry.Metadata),
+		entry.Status,
+		entry.ClearedAt,
+		entry.CreatedBy,
+		entry.Sequence,
+		entry.PrevHash,
+		entry.Hash,
+	)
+	if err != nil {
+		return err
+	}
+
+	return advanceChainHead(ctx, r.db, models.LedgerStatement, entry.TenantID, entry.ChainLink)
+}
+
+// GetByID retrieves a statement ledger entry by ID
+func (r *statementEntryRepo) GetByID(ctx context.Context, id uuid.UUID) (*models.StatementLedgerEntry, error) {
+	query := `SELECT ` + statementEntryColumns + ` FROM statement_ledger_entries WHERE id = $1`
+
+	entry, err := scanStatementEntry(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, repository.ErrNotFound
+	}
+	return entry, err
+}
+
+// List retrieves statement ledger entries matching the filter
+func (r *statementEntryRepo) List(
+	ctx context.Context,
+	filter repository.StatementEntryFilter,
+) ([]*models.StatementLedgerEntry, error) {
+	var c conditions
+	if filter.TenantID != nil {
+		c.add("tenant_id = ?", *filter.TenantID)
+	}
+	if filter.StatementID != nil {
+		c.add("statement_id = ?", *filter.StatementID)
+	}
+	if filter.ReferenceID != nil {
+		c.add("reference_id = ?", *filter.ReferenceID)
+	}
+	if filter.PostedFrom != nil {
+		c.add("posting_date >= ?", *filter.PostedFrom)
+	}
+	if filter.PostedTo != nil {
+		c.add("posting_date <= ?", *filter.PostedTo)
+	}
+	c.addIn("entry_type", stringsOf(filter.EntryTypes))
+	c.addIn("status", stringsOf(filter.Statuses))
+
+	query := `SELECT ` + statementEntryColumns + ` FROM statement_ledger_entries ` +
+		c.where() + ` ORDER BY posting_date, entry_date, created_at`
+
+	rows, err := r.db.QueryContext(ctx, query, c.args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.StatementLedgerEntry
+	for rows.Next() {
+		entry, err := scanStatementEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// ChainHead returns the newest link of the tenant's statement chain
+func (r *statementEntryRepo) ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error) {
+	return chainHead(ctx, r.db, models.LedgerStatement, tenantID, "")
+}
+
+// UpdateStatus transitions an entry between statuses
+func (r *statementEntryRepo) UpdateStatus(
+	ctx context.Context,
+	id uuid.UUID,
+	from, to models.EntryStatus,
+	at time.Time,
+) error {
+	query := `
+		UPDATE statement_ledger_entries
+		SET status = $1, cleared_at = COALESCE($2, cleared_at)
+		WHERE id = $3 AND status = $4
+	`
+
+	var clearedAt *time.Time
+	if to == models.EntryStatusCleared {
+		clearedAt = &at
+	}
+
+	result, err := r.db.ExecContext(ctx, query, to, clearedAt, id, from)
+	if err != nil {
+		return err
+	}
+
+	return requireRow(result)
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStatementEntry(row rowScanner) (*models.StatementLedgerEntry, error) {
+	entry := &models.StatementLedgerEntry{}
+	var metadata jsonMap
+	err := row.Scan(
+		&entry.ID,
+		&entry.TenantID,
+		&entry.StatementID,
+		&entry.EntryType,
+		&entry.EntryDate,
+		&entry.PostingDate,
+		&entry.Amount,
+		&entry.Description,
+		&entry.ReferenceID,
+		&metadata,
+		&entry.Status,
+		&entry.ClearedAt,
+		&entry.CreatedAt,
+		&entry.CreatedBy,
+		&entry.Sequence,
+		&entry.PrevHash,
+		&entry.Hash,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entry.Metadata = metadata
+	return entry, nil
+}
@@ -0,0 +1,298 @@
+// Package postgres implements the repository interfaces on top of
+// database/sql and the lib/pq driver.
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+// Querier is the statement surface shared by *sql.DB and *sql.Tx
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txBeginner is implemented by *sql.DB
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Store is the Postgres-backed repository.Store
+type Store struct {
+	db Querier
+}
+
+// NewStore creates a store that issues its SQL through db.
+// When db is a *sql.Tx the store joins that transaction and never commits it.
+func NewStore(db Querier) *Store {
+	return &Store{db: db}
+}
+
+// StatementEntries returns the statement ledger entry repository
+func (s *Store) StatementEntries() repository.StatementEntryRepository {
+	return &statementEntryRepo{db: s.db}
+}
+
+// CashbackEntries returns the cashback ledger entry repository
+func (s *Store) CashbackEntries() repository.CashbackEntryRepository {
+	return &cashbackEntryRepo{db: s.db}
+}
+
+// PointsEntries returns the points ledger entry repository
+func (s *Store) PointsEntries() repository.PointsEntryRepository {
+	return &pointsEntryRepo{db: s.db}
+}
+
+// CreditCards returns the credit card repository
+func (s *Store) CreditCards() repository.CreditCardRepository {
+	return &creditCardRepo{db: s.db}
+}
+
+// BillingCycles returns the billing cycle repository
+func (s *Store) BillingCycles() repository.BillingCycleRepository {
+	return &billingCycleRepo{db: s.db}
+}
+
+// Payments returns the payment repository
+func (s *Store) Payments() repository.PaymentRepository {
+	return &paymentRepo{db: s.db}
+}
+
+// Authorizations returns the card authorization repository
+func (s *Store) Authorizations() repository.AuthorizationRepository {
+	return &authorizationRepo{db: s.db}
+}
+
+// Disputes returns the dispute repository
+func (s *Store) Disputes() repository.DisputeRepository {
+	return &disputeRepo{db: s.db}
+}
+
+// APRChanges returns the APR history repository
+func (s *Store) APRChanges() repository.APRChangeRepository {
+	return &aprChangeRepo{db: s.db}
+}
+
+// PenaltyEvents returns the penalty APR event repository
+func (s *Store) PenaltyEvents() repository.PenaltyEventRepository {
+	return &penaltyEventRepo{db: s.db}
+}
+
+// Delinquencies returns the delinquency aging history repository
+func (s *Store) Delinquencies() repository.DelinquencyRepository {
+	return &delinquencyRepo{db: s.db}
+}
+
+// Recoveries returns the charge-off and recovery repository
+func (s *Store) Recoveries() repository.RecoveryRepository {
+	return &recoveryRepo{db: s.db}
+}
+
+// IdempotencyKeys returns the idempotency key repository
+func (s *Store) IdempotencyKeys() repository.IdempotencyRepository {
+	return &idempotencyRepo{db: s.db}
+}
+
+// JournalEntries returns the general ledger journal repository
+func (s *Store) JournalEntries() repository.JournalRepository {
+	return &journalRepo{db: s.db}
+}
+
+// WithinTx runs fn in a database transaction.
+// If the store is already bound to a *sql.Tx, fn joins it inside a savepoint
+// and the owner of that transaction decides whether to commit. Otherwise a new
+// transaction is started, committed when fn succeeds and rolled back when it
+// returns an error.
+func (s *Store) WithinTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	if tx, ok := s.db.(*sql.Tx); ok {
+		return withinSavepoint(ctx, tx, func() error { return fn(s) })
+	}
+
+	db, ok := s.db.(txBeginner)
+	if !ok {
+		return fmt.Errorf("querier %T cannot begin a transaction", s.db)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{db: tx}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// nestedSavepoint names the savepoint a joined WithinTx starts. Postgres
+// resolves a reused name to the most recent savepoint, so nested calls stack.
+const nestedSavepoint = "within_tx"
+
+// withinSavepoint runs fn inside a savepoint of tx. When fn fails, tx is rolled
+// back to the savepoint, which also clears the aborted state a failed statement
+// leaves behind, so the owner of tx can keep using it.
+func withinSavepoint(ctx context.Context, tx *sql.Tx, fn func() error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+nestedSavepoint); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+nestedSavepoint); rollbackErr != nil {
+			return fmt.Errorf("%w (failed to roll back to savepoint: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+nestedSavepoint); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
+
+// jsonMap stores metadata maps in JSONB columns
+type jsonMap map[string]interface{}
+
+// Value implements driver.Valuer
+func (m jsonMap) Value() (driver.Value, error) {
+	if m == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(map[string]interface{}(m))
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (m *jsonMap) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into metadata", src)
+	}
+	if len(b) == 0 {
+		*m = nil
+		return nil
+	}
+	return json.Unmarshal(b, (*map[string]interface{})(m))
+}
+
+// jsonColumn stores any JSON-encodable value in a JSONB column
+type jsonColumn struct {
+	v interface{}
+}
+
+// jsonValue wraps v, or a pointer to it when scanning
+func jsonValue(v interface{}) jsonColumn {
+	return jsonColumn{v: v}
+}
+
+// Value implements driver.Valuer
+func (c jsonColumn) Value() (driver.Value, error) {
+	b, err := json.Marshal(c.v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal JSON column: %w", err)
+	}
+	if string(b) == "null" {
+		return nil, nil
+	}
+	return string(b), nil
+}
+
+// Scan implements sql.Scanner
+func (c jsonColumn) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, c.v)
+	case string:
+		return json.Unmarshal([]byte(v), c.v)
+	default:
+		return fmt.Errorf("cannot scan %T into JSON column", src)
+	}
+}
+
+// conditions accumulates a WHERE clause with positional parameters
+type conditions struct {
+	clauses []string
+	args    []interface{}
+}
+
+// add appends a clause whose single "?" is replaced by the next parameter
+func (c *conditions) add(clause string, arg interface{}) {
+	c.args = append(c.args, arg)
+	c.clauses = append(c.clauses, strings.Replace(clause, "?", fmt.Sprintf("$%d", len(c.args)), 1))
+}
+
+// addIn appends "column IN (...)" for the given values
+func (c *conditions) addIn(column string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		c.args = append(c.args, v)
+		placeholders[i] = fmt.Sprintf("$%d", len(c.args))
+	}
+	c.clauses = append(c.clauses, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+}
+
+// where renders the WHERE clause, or an empty string when unconstrained
+func (c *conditions) where() string {
+	if len(c.clauses) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(c.clauses, " AND ")
+}
+
+// limit renders a LIMIT clause for n > 0
+func (c *conditions) limit(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	c.args = append(c.args, n)
+	return fmt.Sprintf("LIMIT $%d", len(c.args))
+}
+
+// requireRow reports ErrNotFound when a statement touched no rows
+func requireRow(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// stringsOf converts a slice of string-kinded enums to []string
+func stringsOf[T ~string](values []T) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = string(v)
+	}
+	return out
+}
@@ -0,0 +1,299 @@
+// Package repository defines the persistence boundary for the ledger.
+// Services depend only on these interfaces; the postgres package provides the
+// lib/pq implementation and the memory package an in-process one for tests.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// ErrDuplicate is returned when a write collides with a unique constraint
+var ErrDuplicate = errors.New("duplicate record")
+
+// Store groups the repositories that make up a unit of work
+type Store interface {
+	StatementEntries() StatementEntryRepository
+	CashbackEntries() CashbackEntryRepository
+	PointsEntries() PointsEntryRepository
+	CreditCards() CreditCardRepository
+	BillingCycles() BillingCycleRepository
+	Payments() PaymentRepository
+	Authorizations() AuthorizationRepository
+	Disputes() DisputeRepository
+	APRChanges() APRChangeRepository
+	PenaltyEvents() PenaltyEventRepository
+	Delinquencies() DelinquencyRepository
+	Recoveries() RecoveryRepository
+	IdempotencyKeys() IdempotencyRepository
+	JournalEntries() JournalRepository
+
+	// WithinTx runs fn against a transactional view of the store.
+	// Every write made through tx is committed when fn returns nil and
+	// discarded when it returns an error. Calling WithinTx on a store that is
+	// already transactional joins the existing transaction; a failing fn then
+	// discards only its own writes and the enclosing transaction carries on.
+	WithinTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// StatementEntryFilter narrows a statement entry listing
+// Nil and empty fields match every entry
+type StatementEntryFilter struct {
+	TenantID    *uuid.UUID
+	StatementID *uuid.UUID
+	ReferenceID *string
+	EntryTypes  []models.StatementEntryType
+	Statuses    []models.EntryStatus
+	PostedFrom  *time.Time // Inclusive
+	PostedTo    *time.Time // Inclusive
+}
+
+// StatementEntryRepository persists statement ledger entries.
+// Create appends each entry to its tenant's hash chain (see models.ChainLink).
+type StatementEntryRepository interface {
+	Create(ctx context.Context, entry *models.StatementLedgerEntry) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.StatementLedgerEntry, error)
+	// List returns matching entries ordered by posting date, entry date and creation time
+	List(ctx context.Context, filter StatementEntryFilter) ([]*models.StatementLedgerEntry, error)
+	// UpdateStatus moves an entry from one status to another.
+	// Returns ErrNotFound when no entry with that ID is in the from status.
+	UpdateStatus(ctx context.Context, id uuid.UUID, from, to models.EntryStatus, at time.Time) error
+	// ChainHead returns the newest link of the tenant's hash chain, or the
+	// zero link when the tenant has no entries
+	ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error)
+}
+
+// CashbackEntryFilter narrows a cashback entry listing
+type CashbackEntryFilter struct {
+	TenantID         *uuid.UUID
+	CreditCardID     *uuid.UUID
+	StatementEntryID *uuid.UUID
+	EntryTypes       []models.CashbackEntryType
+	From             *time.Time // Inclusive, on entry date
+	To               *time.Time // Inclusive, on entry date
+	Limit            int        // When set, only the newest Limit entries, newest first
+}
+
+// CashbackEntryRepository persists cashback ledger entries and category bonuses
+type CashbackEntryRepository interface {
+	Create(ctx context.Context, entry *models.CashbackLedgerEntry) error
+	// List returns matching entries ordered by entry date and creation time
+	List(ctx context.Context, filter CashbackEntryFilter) ([]*models.CashbackLedgerEntry, error)
+	// SaveCategory inserts a category or replaces the one with the same card and code
+	SaveCategory(ctx context.Context, category *models.CashbackCategory) error
+	ListCategories(ctx context.Context, creditCardID uuid.UUID) ([]*models.CashbackCategory, error)
+	// ChainHead returns the newest link of the tenant's hash chain
+	ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error)
+}
+
+// PointsEntryFilter narrows a points entry listing
+type PointsEntryFilter struct {
+	TenantID         *uuid.UUID
+	StatementEntryID *uuid.UUID
+	EntryTypes       []models.PointsEntryType
+	Limit            int // When set, only the newest Limit entries, newest first
+}
+
+// PointsEntryRepository persists points ledger entries
+type PointsEntryRepository interface {
+	Create(ctx context.Context, entry *models.PointsLedgerEntry) error
+	// List returns matching entries ordered by entry date and creation time
+	List(ctx context.Context, filter PointsEntryFilter) ([]*models.PointsLedgerEntry, error)
+	// ChainHead returns the newest link of the tenant's hash chain
+	ChainHead(ctx context.Context, tenantID uuid.UUID) (models.ChainLink, error)
+}
+
+// CreditCardFilter narrows a credit card listing
+type CreditCardFilter struct {
+	TenantID *uuid.UUID
+	Statuses []models.CreditCardStatus
+}
+
+// CreditCardRepository persists credit card accounts.
+// Cards are returned with their APR schedule.
+type CreditCardRepository interface {
+	Create(ctx context.Context, card *models.CreditCard) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.CreditCard, error)
+	// GetForUpdate loads a card and locks it against concurrent writers until
+	// the surrounding transaction ends
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*models.CreditCard, error)
+	// List returns matching cards ordered by creation time
+	List(ctx context.Context, filter CreditCardFilter) ([]*models.CreditCard, error)
+	// Update writes every mutable column of the card
+	Update(ctx context.Context, card *models.CreditCard) error
+}
+
+// BillingCycleFilter narrows a billing cycle listing
+type BillingCycleFilter struct {
+	CreditCardID      *uuid.UUID
+	CycleNumber       *int
+	Statuses          []models.BillingCycleStatus
+	MinimumPaymentMet *bool
+	DueBefore         *time.Time // Exclusive
+	Limit             int        // When set, only the latest Limit cycles, latest first
+}
+
+// BillingCycleRepository persists billing cycles
+type BillingCycleRepository interface {
+	Create(ctx context.Context, cycle *models.BillingCycle) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.BillingCycle, error)
+	// List returns matching cycles ordered by cycle number
+	List(ctx context.Context, filter BillingCycleFilter) ([]*models.BillingCycle, error)
+	// Update writes every mutable column of the cycle
+	Update(ctx context.Context, cycle *models.BillingCycle) error
+}
+
+// PaymentFilter narrows a payment listing
+type PaymentFilter struct {
+	TenantID     *uuid.UUID
+	CreditCardID *uuid.UUID
+	Statuses     []models.PaymentStatus
+}
+
+// PaymentRepository persists payments and their status history
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Payment, error)
+	GetByNumber(ctx context.Context, paymentNumber string) (*models.Payment, error)
+	// List returns matching payments ordered by initiation time
+	List(ctx context.Context, filter PaymentFilter) ([]*models.Payment, error)
+	// Update writes every mutable column of the payment
+	Update(ctx context.Context, payment *models.Payment) error
+
+	CreateTransition(ctx context.Context, transition *models.PaymentStatusTransition) error
+	// ListTransitions returns a payment's status history, oldest first
+	ListTransitions(ctx context.Context, paymentID uuid.UUID) ([]*models.PaymentStatusTransition, error)
+}
+
+// AuthorizationFilter narrows an authorization listing
+type AuthorizationFilter struct {
+	TenantID     *uuid.UUID
+	CreditCardID *uuid.UUID
+	ReferenceID  *string
+	Statuses     []models.AuthorizationStatus
+	ExpiringBy   *time.Time // Inclusive, on expiry time
+}
+
+// AuthorizationRepository persists card authorizations
+type AuthorizationRepository interface {
+	Create(ctx context.Context, authorization *models.Authorization) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Authorization, error)
+	// List returns matching authorizations ordered by authorization time
+	List(ctx context.Context, filter AuthorizationFilter) ([]*models.Authorization, error)
+	// Update writes every mutable column of the authorization
+	Update(ctx context.Context, authorization *models.Authorization) error
+}
+
+// DisputeFilter narrows a dispute listing
+type DisputeFilter struct {
+	TenantID         *uuid.UUID
+	CreditCardID     *uuid.UUID
+	StatementEntryID *uuid.UUID
+	Statuses         []models.DisputeStatus
+}
+
+// DisputeRepository persists disputes and their status history
+type DisputeRepository interface {
+	Create(ctx context.Context, dispute *models.Dispute) error
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Dispute, error)
+	// List returns matching disputes ordered by notice date
+	List(ctx context.Context, filter DisputeFilter) ([]*models.Dispute, error)
+	// Update writes every mutable column of the dispute
+	Update(ctx context.Context, dispute *models.Dispute) error
+
+	CreateTransition(ctx context.Context, transition *models.DisputeStatusTransition) error
+	// ListTransitions returns a dispute's status history, oldest first
+	ListTransitions(ctx context.Context, disputeID uuid.UUID) ([]*models.DisputeStatusTransition, error)
+}
+
+// APRChangeRepository persists card APR history and the change-in-terms
+// notices sent for scheduled changes
+type APRChangeRepository interface {
+	Create(ctx context.Context, change *models.APRChange) error
+	// List returns the cards' APR changes ordered by effective date and creation time
+	List(ctx context.Context, creditCardIDs ...uuid.UUID) ([]*models.APRChange, error)
+
+	CreateNotice(ctx context.Context, notice *models.ChangeInTermsNotice) error
+	// ListNotices returns a card's notices ordered by notice date and creation time
+	ListNotices(ctx context.Context, creditCardID uuid.UUID) ([]*models.ChangeInTermsNotice, error)
+}
+
+// PenaltyEventRepository persists the penalty APR being applied to and cured on cards
+type PenaltyEventRepository interface {
+	Create(ctx context.Context, event *models.PenaltyEvent) error
+	// List returns a card's penalty events ordered by occurrence and creation time
+	List(ctx context.Context, creditCardID uuid.UUID) ([]*models.PenaltyEvent, error)
+}
+
+// DelinquencyRepository persists each card's delinquency aging history
+type DelinquencyRepository interface {
+	Create(ctx context.Context, record *models.DelinquencyRecord) error
+	// List returns a card's aging history ordered by date and creation time
+	List(ctx context.Context, creditCardID uuid.UUID) ([]*models.DelinquencyRecord, error)
+}
+
+// ChargeOffFilter narrows a charge-off listing
+// Nil fields match every charge-off
+type ChargeOffFilter struct {
+	TenantID *uuid.UUID
+	From     *time.Time // Inclusive, on charge-off date
+	To       *time.Time // Inclusive, on charge-off date
+}
+
+// RecoveryFilter narrows a recovery listing
+// Nil fields match every recovery
+type RecoveryFilter struct {
+	TenantID     *uuid.UUID
+	CreditCardID *uuid.UUID
+	Agency       *string    // Empty string matches in-house recoveries
+	From         *time.Time // Inclusive, on recovery date
+	To           *time.Time // Inclusive, on recovery date
+}
+
+// RecoveryRepository persists charged-off balances and the recoveries
+// collected on them
+type RecoveryRepository interface {
+	CreateChargeOff(ctx context.Context, chargeOff *models.ChargeOff) error
+	// GetChargeOff returns ErrNotFound when the card has not been charged off
+	GetChargeOff(ctx context.Context, creditCardID uuid.UUID) (*models.ChargeOff, error)
+	// ListChargeOffs returns matching charge-offs ordered by charge-off date
+	ListChargeOffs(ctx context.Context, filter ChargeOffFilter) ([]*models.ChargeOff, error)
+	// UpdateChargeOff writes the remaining balances and amount recovered
+	UpdateChargeOff(ctx context.Context, chargeOff *models.ChargeOff) error
+
+	Create(ctx context.Context, recovery *models.Recovery) error
+	// List returns matching recoveries ordered by recovery date and creation time
+	List(ctx context.Context, filter RecoveryFilter) ([]*models.Recovery, error)
+}
+
+// IdempotencyRepository persists the results of idempotent service calls
+type IdempotencyRepository interface {
+	// Create stores the record.
+	// Returns ErrDuplicate when the tenant has already used the key.
+	Create(ctx context.Context, record *models.IdempotencyRecord) error
+	// Get returns ErrNotFound when the tenant has not used the key
+	Get(ctx context.Context, tenantID uuid.UUID, key string) (*models.IdempotencyRecord, error)
+}
+
+// JournalFilter narrows a journal entry listing
+type JournalFilter struct {
+	TenantID *uuid.UUID
+	SourceID *uuid.UUID
+	From     *time.Time // Inclusive, on entry date
+	To       *time.Time // Inclusive, on entry date
+}
+
+// JournalRepository persists general ledger journal entries
+type JournalRepository interface {
+	// Create stores the entry together with its lines
+	Create(ctx context.Context, entry *models.JournalEntry) error
+	// List returns matching entries with their lines, ordered by entry date and creation time
+	List(ctx context.Context, filter JournalFilter) ([]*models.JournalEntry, error)
+}
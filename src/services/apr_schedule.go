@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/shopspring/decimal"
+)
+
+// APRChangeRequest sets a new rate for one of a card's APRs from a date
+type APRChangeRequest struct {
+	CreditCardID  uuid.UUID
+	APRType       models.APRType
+	APR           decimal.Decimal
+	EffectiveDate time.Time // On or after the notice date
+	NoticeDate    time.Time // Defaults to today
+	Reason        string
+}
+
+// APRChangeResult is the recorded change and the notice sent for it
+type APRChangeResult struct {
+	Change *models.APRChange
+	Notice *models.ChangeInTermsNotice // Nil when the change takes effect on the notice date
+}
+
+// ScheduleAPRChange adds a rate to the card's APR history. Past cycles keep
+// the rates they were charged at, and interest for a cycle the change falls
+// in is split at the effective date. A change that takes effect later gets
+// a change-in-terms notice; increases must be scheduled at least
+// ChangeInTermsNoticeDays after it. A change effective on the notice date
+// also updates the rate held on the card.
+func (s *CreditCardService) ScheduleAPRChange(ctx context.Context, req APRChangeRequest) (*APRChangeResult, error) {
+	switch req.APRType {
+	case models.APRTypePurchase, models.APRTypeCashAdvance, models.APRTypePenalty:
+	default:
+		return nil, fmt.Errorf("invalid APR type: %s", req.APRType)
+	}
+	if req.APR.IsNegative() || req.APR.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, models.ErrInvalidAPR
+	}
+	if req.NoticeDate.IsZero() {
+		req.NoticeDate = time.Now()
+	}
+	noticeDate := models.DateOf(req.NoticeDate)
+	effectiveDate := models.DateOf(req.EffectiveDate)
+	if effectiveDate.Before(noticeDate) {
+		return nil, errors.New("APR changes cannot take effect before their notice date")
+	}
+
+	result := &APRChangeResult{}
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		card, err := tx.CreditCards().GetForUpdate(ctx, req.CreditCardID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit card: %w", err)
+		}
+
+		change := &models.APRChange{
+			ID:            uuid.New(),
+			TenantID:      card.TenantID,
+			CreditCardID:  card.ID,
+			APRType:       req.APRType,
+			PreviousAPR:   card.APROn(req.APRType, effectiveDate),
+			APR:           req.APR,
+			EffectiveDate: effectiveDate,
+			Reason:        req.Reason,
+			CreatedAt:     time.Now(),
+		}
+		if change.APR.Equal(change.PreviousAPR) {
+			return fmt.Errorf("%s APR is already %s%% on %s",
+				req.APRType, req.APR.StringFixed(2), effectiveDate.Format("2006-01-02"))
+		}
+		if change.IsIncrease() && effectiveDate.Before(noticeDate.AddDate(0, 0, models.ChangeInTermsNoticeDays)) {
+			return models.ErrInsufficientAPRNotice
+		}
+
+		if effectiveDate.After(noticeDate) {
+			result.Notice = models.NewChangeInTermsNotice(change, noticeDate)
+			result.Notice.CreatedAt = change.CreatedAt
+			change.NoticeID = &result.Notice.ID
+		}
+		if err := tx.APRChanges().Create(ctx, change); err != nil {
+			return fmt.Errorf("failed to create APR change: %w", err)
+		}
+		result.Change = change
+		if result.Notice != nil {
+			if err := tx.APRChanges().CreateNotice(ctx, result.Notice); err != nil {
+				return fmt.Errorf("failed to create change-in-terms notice: %w", err)
+			}
+			return nil
+		}
+
+		switch req.APRType {
+		case models.APRTypePurchase:
+			card.PurchaseAPR = req.APR
+		case models.APRTypeCashAdvance:
+			card.CashAdvanceAPR = req.APR
+		case models.APRTypePenalty:
+			card.PenaltyAPR = req.APR
+		}
+		card.UpdatedAt = time.Now()
+		return tx.CreditCards().Update(ctx, card)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ListChangeInTermsNotices returns the notices sent for a card's scheduled
+// APR changes, oldest first
+func (s *CreditCardService) ListChangeInTermsNotices(ctx context.Context, cardID uuid.UUID) ([]*models.ChangeInTermsNotice, error) {
+	notices, err := s.store.APRChanges().ListNotices(ctx, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list change-in-terms notices: %w", err)
+	}
+	return notices, nil
+}
+
+// loadAPRSchedule returns a copy of the card carrying its stored APR history,
+// for callers that were handed a card without it
+func loadAPRSchedule(ctx context.Context, store repository.Store, card *models.CreditCard) (*models.CreditCard, error) {
+	changes, err := store.APRChanges().List(ctx, card.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list APR changes: %w", err)
+	}
+
+	loaded := *card
+	loaded.APRSchedule = make([]models.APRChange, len(changes))
+	for i, change := range changes {
+		loaded.APRSchedule[i] = *change
+	}
+	return &loaded, nil
+}
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestCreditCardService_ScheduleAPRChange(t *testing.T) {
+	noticeDate := time.Date(2026, time.January, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		aprType       models.APRType
+		apr           decimal.Decimal
+		effectiveDate time.Time
+		wantErr       error
+		wantNotice    bool
+		wantCardAPR   decimal.Decimal // Purchase APR held on the card afterwards
+	}{
+		{
+			name:          "Increase with 45 days' notice",
+			aprType:       models.APRTypePurchase,
+			apr:           decimal.NewFromFloat(24.99),
+			effectiveDate: noticeDate.AddDate(0, 0, 45),
+			wantNotice:    true,
+			wantCardAPR:   decimal.NewFromFloat(19.99),
+		},
+		{
+			name:          "Increase with too little notice",
+			aprType:       models.APRTypePurchase,
+			apr:           decimal.NewFromFloat(24.99),
+			effectiveDate: noticeDate.AddDate(0, 0, 44),
+			wantErr:       models.ErrInsufficientAPRNotice,
+		},
+		{
+			name:          "Decrease from today",
+			aprType:       models.APRTypePurchase,
+			apr:           decimal.NewFromFloat(15.99),
+			effectiveDate: noticeDate,
+			wantCardAPR:   decimal.NewFromFloat(15.99),
+		},
+		{
+			name:          "Rate above 100%",
+			aprType:       models.APRTypeCashAdvance,
+			apr:           decimal.NewFromInt(101),
+			effectiveDate: noticeDate.AddDate(0, 2, 0),
+			wantErr:       models.ErrInvalidAPR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+			service := NewCreditCardServiceWithStore(store)
+
+			result, err := service.ScheduleAPRChange(ctx, APRChangeRequest{
+				CreditCardID:  card.ID,
+				APRType:       tt.aprType,
+				APR:           tt.apr,
+				EffectiveDate: tt.effectiveDate,
+				NoticeDate:    noticeDate,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !result.Change.PreviousAPR.Equal(decimal.NewFromFloat(19.99)) {
+				t.Errorf("previous APR = %s, want 19.99", result.Change.PreviousAPR)
+			}
+			notices, err := service.ListChangeInTermsNotices(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to list notices: %v", err)
+			}
+			if tt.wantNotice {
+				if result.Notice == nil || len(notices) != 1 || notices[0].APRChangeID != result.Change.ID {
+					t.Fatalf("notices = %+v, want one for the change", notices)
+				}
+				if result.Change.NoticeID == nil || *result.Change.NoticeID != result.Notice.ID {
+					t.Errorf("change notice ID = %v, want %s", result.Change.NoticeID, result.Notice.ID)
+				}
+			} else if result.Notice != nil || len(notices) != 0 {
+				t.Errorf("unexpected notices: %+v", notices)
+			}
+
+			// The stored card resolves the rate from its history
+			stored, err := store.CreditCards().GetByID(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to load card: %v", err)
+			}
+			if !stored.PurchaseAPR.Equal(tt.wantCardAPR) {
+				t.Errorf("card purchase APR = %s, want %s", stored.PurchaseAPR, tt.wantCardAPR)
+			}
+			if got := stored.GetEffectiveAPR(tt.effectiveDate.AddDate(0, 0, -1)); !got.Equal(decimal.NewFromFloat(19.99)) {
+				t.Errorf("APR the day before = %s, want 19.99", got)
+			}
+			if got := stored.GetEffectiveAPR(tt.effectiveDate); !got.Equal(tt.apr) {
+				t.Errorf("APR on the effective date = %s, want %s", got, tt.apr)
+			}
+		})
+	}
+}
+
+func TestCreditCardService_UpdateCreditCardAPRNeedsNoticeToIncrease(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+	service := NewCreditCardServiceWithStore(store)
+
+	err := service.UpdateCreditCardAPR(ctx, card.ID, decimal.NewFromFloat(29.99), "purchase")
+	if !errors.Is(err, models.ErrInsufficientAPRNotice) {
+		t.Errorf("error = %v, want %v", err, models.ErrInsufficientAPRNotice)
+	}
+	if err := service.UpdateCreditCardAPR(ctx, card.ID, decimal.NewFromFloat(9.99), "purchase"); err != nil {
+		t.Fatalf("unexpected error lowering the APR: %v", err)
+	}
+	if err := service.UpdateCreditCardAPR(ctx, card.ID, decimal.NewFromFloat(9.99), "variable"); err == nil {
+		t.Error("expected an error for an unknown APR type")
+	}
+}
+
+func TestInterestService_CalculateInterestAcrossRateChange(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	card.PurchaseAPR = decimal.NewFromFloat(18.25) // DPR 0.0005
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	// A balance carried through February and March
+	carried := &models.StatementLedgerEntry{
+		TenantID:    card.TenantID,
+		EntryType:   models.EntryTypeTransaction,
+		Amount:      decimal.NewFromInt(3000),
+		PostingDate: time.Date(2026, time.January, 20, 0, 0, 0, 0, time.UTC),
+		Status:      models.EntryStatusCleared,
+	}
+	carried.EntryDate = carried.PostingDate
+	if err := store.StatementEntries().Create(ctx, carried); err != nil {
+		t.Fatalf("failed to create entry: %v", err)
+	}
+	if err := store.BillingCycles().Create(ctx, &models.BillingCycle{
+		CreditCardID: card.ID,
+		TenantID:     card.TenantID,
+		CycleNumber:  1,
+		NewBalance:   decimal.NewFromInt(3000),
+		Status:       models.BillingCycleStatusClosed,
+	}); err != nil {
+		t.Fatalf("failed to create previous cycle: %v", err)
+	}
+
+	// The purchase APR doubles halfway through March
+	_, err := NewCreditCardServiceWithStore(store).ScheduleAPRChange(ctx, APRChangeRequest{
+		CreditCardID:  card.ID,
+		APRType:       models.APRTypePurchase,
+		APR:           decimal.NewFromFloat(36.5), // DPR 0.001
+		EffectiveDate: time.Date(2026, time.March, 16, 0, 0, 0, 0, time.UTC),
+		NoticeDate:    time.Date(2026, time.January, 30, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("failed to schedule APR change: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		wantRates  int
+		wantAPR    decimal.Decimal
+		wantCharge decimal.Decimal
+	}{
+		{
+			name:      "Earlier cycle keeps the old rate",
+			start:     time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC),
+			wantRates: 0,
+			wantAPR:   decimal.NewFromFloat(18.25),
+			// 3000 × 0.0005 × 28
+			wantCharge: decimal.NewFromInt(42),
+		},
+		{
+			name:      "Cycle split at the change",
+			start:     time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, time.March, 30, 0, 0, 0, 0, time.UTC),
+			wantRates: 2,
+			wantAPR:   decimal.NewFromFloat(36.5),
+			// 3000 × 0.0005 × 15 + 3000 × 0.001 × 15
+			wantCharge: decimal.NewFromFloat(67.5),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle := &models.BillingCycle{
+				ID:             uuid.New(),
+				CreditCardID:   card.ID,
+				CycleNumber:    2,
+				CycleStartDate: tt.start,
+				CycleEndDate:   tt.end,
+			}
+
+			// The card passed in has no history; it is read from the store
+			result, err := NewInterestServiceWithStore(store).CalculateInterest(ctx, card, cycle, DefaultInterestConfig())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.InterestCharge.Equal(tt.wantCharge) {
+				t.Errorf("interest = %s, want %s", result.InterestCharge, tt.wantCharge)
+			}
+			if len(result.Segments) != 1 {
+				t.Fatalf("segments = %+v, want purchases only", result.Segments)
+			}
+
+			segment := result.Segments[0]
+			if !segment.APR.Equal(tt.wantAPR) {
+				t.Errorf("segment APR = %s, want %s", segment.APR, tt.wantAPR)
+			}
+			if len(segment.Rates) != tt.wantRates {
+				t.Fatalf("rates = %+v, want %d", segment.Rates, tt.wantRates)
+			}
+			if tt.wantRates == 2 {
+				first, second := segment.Rates[0], segment.Rates[1]
+				if !first.To.Equal(time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)) || !second.From.Equal(first.To.AddDate(0, 0, 1)) {
+					t.Errorf("rates split at %s and %s, want March 15 and 16", first.To, second.From)
+				}
+				if !first.InterestCharge.Equal(decimal.NewFromFloat(22.5)) || !second.InterestCharge.Equal(decimal.NewFromInt(45)) {
+					t.Errorf("rate charges = %s and %s, want 22.50 and 45.00", first.InterestCharge, second.InterestCharge)
+				}
+			}
+		})
+	}
+}
+
+func TestInterestService_GetAccrualSchedulesUsesRateInEffect(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	card.PurchaseAPR = decimal.NewFromFloat(19.99)
+	nextStatement := time.Now().AddDate(0, 0, 20)
+	card.NextStatementDate = &nextStatement
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	// Noticed 60 days ago, in effect since 10 days ago
+	_, err := NewCreditCardServiceWithStore(store).ScheduleAPRChange(ctx, APRChangeRequest{
+		CreditCardID:  card.ID,
+		APRType:       models.APRTypePurchase,
+		APR:           decimal.NewFromFloat(24.99),
+		EffectiveDate: time.Now().AddDate(0, 0, -10),
+		NoticeDate:    time.Now().AddDate(0, 0, -60),
+	})
+	if err != nil {
+		t.Fatalf("failed to schedule APR change: %v", err)
+	}
+
+	schedules, err := NewInterestServiceWithStore(store).GetAccrualSchedules(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(schedules) != 1 {
+		t.Fatalf("got %d schedules, want 1", len(schedules))
+	}
+	if !schedules[0].CurrentAPR.Equal(decimal.NewFromFloat(24.99)) {
+		t.Errorf("current APR = %s, want 24.99", schedules[0].CurrentAPR)
+	}
+}
@@ -0,0 +1,439 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// AuthorizationService places and settles holds on available credit.
+// Every operation locks the card before it reads the authorization, so
+// operations on one card's authorizations never interleave.
+type AuthorizationService struct {
+	store             repository.Store
+	creditCardService *CreditCardService
+}
+
+// NewAuthorizationService creates a new authorization service
+func NewAuthorizationService(db Querier) *AuthorizationService {
+	return NewAuthorizationServiceWithStore(postgres.NewStore(db))
+}
+
+// NewAuthorizationServiceWithStore creates an authorization service backed by store
+func NewAuthorizationServiceWithStore(store repository.Store) *AuthorizationService {
+	return &AuthorizationService{
+		store:             store,
+		creditCardService: NewCreditCardServiceWithStore(store),
+	}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *AuthorizationService) withStore(store repository.Store) *AuthorizationService {
+	return NewAuthorizationServiceWithStore(store)
+}
+
+// AuthorizeRequest contains parameters for placing a hold
+type AuthorizeRequest struct {
+	CreditCard       *models.CreditCard
+	Amount           decimal.Decimal
+	MerchantName     string
+	MerchantCategory string // MCC code
+	ReferenceID      string
+	AuthorizedAt     time.Time
+	HoldDays         int    // Zero uses models.DefaultAuthorizationHoldDays
+	IdempotencyKey   string // Retries with the same key replay the original result
+}
+
+// AuthorizationResult contains the result of an authorization operation
+type AuthorizationResult struct {
+	Authorization   *models.Authorization
+	Transaction     *TransactionResult // Set by a capture
+	Released        decimal.Decimal    // Hold given back to available credit
+	AvailableCredit decimal.Decimal
+}
+
+// Authorize places a hold on available credit. Nothing is posted to the
+// statement ledger until the authorization is captured.
+func (s *AuthorizationService) Authorize(ctx context.Context, req AuthorizeRequest) (*AuthorizationResult, error) {
+	if !req.Amount.IsPositive() {
+		return nil, errors.New("authorization amount must be positive")
+	}
+	holdDays := req.HoldDays
+	if holdDays <= 0 {
+		holdDays = models.DefaultAuthorizationHoldDays
+	}
+
+	result := &AuthorizationResult{}
+
+	err := idempotently(ctx, s.store, req.CreditCard.TenantID, req.IdempotencyKey, operationAuthorize, result, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		card, err := txs.creditCardService.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
+		if err := card.CanTransact(); err != nil {
+			return err
+		}
+		if err := card.HasAvailableCredit(req.Amount); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		authorization := &models.Authorization{
+			ID:                uuid.New(),
+			TenantID:          card.TenantID,
+			CreditCardID:      card.ID,
+			AuthorizationCode: generateAuthorizationCode(),
+			MerchantName:      req.MerchantName,
+			MerchantCategory:  req.MerchantCategory,
+			ReferenceID:       req.ReferenceID,
+			AuthorizedAmount:  req.Amount,
+			HeldAmount:        req.Amount,
+			CapturedAmount:    decimal.Zero,
+			Status:            models.AuthorizationStatusActive,
+			AuthorizedAt:      req.AuthorizedAt,
+			ExpiresAt:         req.AuthorizedAt.AddDate(0, 0, holdDays),
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		if err := tx.Authorizations().Create(ctx, authorization); err != nil {
+			return fmt.Errorf("failed to create authorization: %w", err)
+		}
+
+		newAvailableCredit := card.AvailableCredit.Sub(req.Amount)
+		if err := txs.creditCardService.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+			return fmt.Errorf("failed to update available credit: %w", err)
+		}
+
+		result.Authorization = authorization
+		result.AvailableCredit = newAvailableCredit
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// IncrementAuthorizationRequest contains parameters for raising a hold
+type IncrementAuthorizationRequest struct {
+	AuthorizationID uuid.UUID
+	Amount          decimal.Decimal // Added to the hold
+	At              time.Time
+	IdempotencyKey  string // Retries with the same key replay the original result
+}
+
+// IncrementAuthorization raises an active hold, as a hotel or car rental
+// merchant does when the final amount grows. The expiry date is unchanged.
+func (s *AuthorizationService) IncrementAuthorization(
+	ctx context.Context,
+	req IncrementAuthorizationRequest,
+) (*AuthorizationResult, error) {
+	if !req.Amount.IsPositive() {
+		return nil, errors.New("incremental authorization amount must be positive")
+	}
+
+	tenantID, err := s.tenantOf(ctx, req.AuthorizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuthorizationResult{}
+
+	err = idempotently(ctx, s.store, tenantID, req.IdempotencyKey, operationIncrementAuthorization, result, func(tx repository.Store) error {
+		return s.withStore(tx).updateAuthorization(ctx, req.AuthorizationID, result,
+			func(txs *AuthorizationService, card *models.CreditCard, authorization *models.Authorization) error {
+				if !authorization.IsActive() {
+					return models.ErrAuthorizationClosed
+				}
+				if authorization.IsStale(req.At) {
+					return models.ErrAuthorizationExpired
+				}
+				if err := card.CanTransact(); err != nil {
+					return err
+				}
+				if err := card.HasAvailableCredit(req.Amount); err != nil {
+					return err
+				}
+
+				authorization.AuthorizedAmount = authorization.AuthorizedAmount.Add(req.Amount)
+				authorization.HeldAmount = authorization.HeldAmount.Add(req.Amount)
+
+				newAvailableCredit := card.AvailableCredit.Sub(req.Amount)
+				if err := txs.creditCardService.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+					return fmt.Errorf("failed to update available credit: %w", err)
+				}
+				result.AvailableCredit = newAvailableCredit
+				return nil
+			})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CaptureRequest contains parameters for settling an authorization
+type CaptureRequest struct {
+	AuthorizationID uuid.UUID
+	Amount          decimal.Decimal // May differ from the hold, e.g. when a tip is added
+	Description     string
+	PostingDate     time.Time
+	Final           bool   // Release whatever is left of the hold after this capture
+	IdempotencyKey  string // Retries with the same key replay the original result
+}
+
+// CaptureAuthorization posts a transaction entry for a captured amount and
+// releases the hold it settles. The capture may be larger than the hold;
+// the difference needs available credit. A capture that is not final leaves
+// the rest of the hold in place for later captures.
+func (s *AuthorizationService) CaptureAuthorization(ctx context.Context, req CaptureRequest) (*AuthorizationResult, error) {
+	if !req.Amount.IsPositive() {
+		return nil, errors.New("capture amount must be positive")
+	}
+
+	tenantID, err := s.tenantOf(ctx, req.AuthorizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &AuthorizationResult{}
+
+	err = idempotently(ctx, s.store, tenantID, req.IdempotencyKey, operationCaptureAuthorization, result, func(tx repository.Store) error {
+		return s.withStore(tx).updateAuthorization(ctx, req.AuthorizationID, result,
+			func(txs *AuthorizationService, card *models.CreditCard, authorization *models.Authorization) error {
+				if err := authorization.CanCapture(); err != nil {
+					return err
+				}
+
+				// Give the settled hold back first, so the transaction is
+				// checked against the credit it actually uses
+				released := authorization.Release(req.Amount)
+				if req.Final {
+					released = released.Add(authorization.Release(authorization.HeldAmount))
+				}
+				if err := txs.creditCardService.updateAvailableCredit(ctx, card.ID, card.AvailableCredit.Add(released)); err != nil {
+					return fmt.Errorf("failed to update available credit: %w", err)
+				}
+
+				authorizationID := authorization.ID
+				transaction, err := txs.creditCardService.RecordTransaction(ctx, CCTransactionRequest{
+					CreditCard:       card,
+					Amount:           req.Amount,
+					Description:      req.Description,
+					MerchantName:     authorization.MerchantName,
+					MerchantCategory: authorization.MerchantCategory,
+					TransactionDate:  authorization.AuthorizedAt,
+					PostingDate:      req.PostingDate,
+					ReferenceID:      authorization.ReferenceID,
+					AuthorizationID:  &authorizationID,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to record captured transaction: %w", err)
+				}
+
+				capturedAt := req.PostingDate
+				authorization.CapturedAmount = authorization.CapturedAmount.Add(req.Amount)
+				authorization.CapturedAt = &capturedAt
+				if authorization.HeldAmount.IsZero() {
+					authorization.Status = models.AuthorizationStatusCaptured
+				}
+
+				result.Transaction = transaction
+				result.Released = released
+				result.AvailableCredit = transaction.AvailableCredit
+				return nil
+			})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ReverseAuthorization releases amount of an active hold, or all of it when
+// amount is zero. An authorization with nothing captured is reversed once its
+// hold is gone; one that was partly captured is then complete.
+func (s *AuthorizationService) ReverseAuthorization(
+	ctx context.Context,
+	authorizationID uuid.UUID,
+	amount decimal.Decimal,
+	at time.Time,
+) (*AuthorizationResult, error) {
+	if amount.IsNegative() {
+		return nil, errors.New("reversal amount cannot be negative")
+	}
+
+	result := &AuthorizationResult{}
+
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		return s.withStore(tx).updateAuthorization(ctx, authorizationID, result,
+			func(txs *AuthorizationService, card *models.CreditCard, authorization *models.Authorization) error {
+				if !authorization.IsActive() {
+					return models.ErrAuthorizationClosed
+				}
+				if amount.GreaterThan(authorization.HeldAmount) {
+					return fmt.Errorf("reversal of %s exceeds the held amount of %s", amount, authorization.HeldAmount)
+				}
+
+				toRelease := amount
+				if toRelease.IsZero() {
+					toRelease = authorization.HeldAmount
+				}
+				released := authorization.Release(toRelease)
+				if authorization.HeldAmount.IsZero() {
+					reversedAt := at
+					authorization.ReversedAt = &reversedAt
+					authorization.Status = models.AuthorizationStatusReversed
+					if authorization.CapturedAmount.IsPositive() {
+						authorization.Status = models.AuthorizationStatusCaptured
+					}
+				}
+
+				return txs.release(ctx, card, released, result)
+			})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// ExpireAuthorizations releases every active hold whose hold period has run
+// out by asOf and returns the expired authorizations. Each authorization is
+// expired in its own transaction.
+func (s *AuthorizationService) ExpireAuthorizations(ctx context.Context, asOf time.Time) ([]*models.Authorization, error) {
+	stale, err := s.store.Authorizations().List(ctx, repository.AuthorizationFilter{
+		Statuses:   []models.AuthorizationStatus{models.AuthorizationStatusActive},
+		ExpiringBy: &asOf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stale authorizations: %w", err)
+	}
+
+	var expired []*models.Authorization
+	for _, candidate := range stale {
+		result := &AuthorizationResult{}
+		err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+			return s.withStore(tx).updateAuthorization(ctx, candidate.ID, result,
+				func(txs *AuthorizationService, card *models.CreditCard, authorization *models.Authorization) error {
+					// Captured or reversed since it was listed
+					if !authorization.IsActive() || !authorization.IsStale(asOf) {
+						return nil
+					}
+
+					released := authorization.Release(authorization.HeldAmount)
+					expiredAt := asOf
+					authorization.ExpiredAt = &expiredAt
+					authorization.Status = models.AuthorizationStatusExpired
+
+					return txs.release(ctx, card, released, result)
+				})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to expire authorization %s: %w", candidate.ID, err)
+		}
+		if result.Authorization.Status == models.AuthorizationStatusExpired {
+			expired = append(expired, result.Authorization)
+		}
+	}
+
+	return expired, nil
+}
+
+// GetAuthorization retrieves an authorization by ID
+func (s *AuthorizationService) GetAuthorization(ctx context.Context, authorizationID uuid.UUID) (*models.Authorization, error) {
+	authorization, err := s.store.Authorizations().GetByID(ctx, authorizationID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("authorization not found: %s", authorizationID)
+	}
+	return authorization, err
+}
+
+// GetActiveAuthorizations returns the card's authorizations that still hold credit
+func (s *AuthorizationService) GetActiveAuthorizations(ctx context.Context, creditCardID uuid.UUID) ([]*models.Authorization, error) {
+	return s.store.Authorizations().List(ctx, repository.AuthorizationFilter{
+		CreditCardID: &creditCardID,
+		Statuses:     []models.AuthorizationStatus{models.AuthorizationStatusActive},
+	})
+}
+
+// updateAuthorization locks the authorization's card, applies apply to the
+// card and the freshly read authorization, and writes the authorization back
+func (s *AuthorizationService) updateAuthorization(
+	ctx context.Context,
+	authorizationID uuid.UUID,
+	result *AuthorizationResult,
+	apply func(txs *AuthorizationService, card *models.CreditCard, authorization *models.Authorization) error,
+) error {
+	authorization, err := s.GetAuthorization(ctx, authorizationID)
+	if err != nil {
+		return err
+	}
+
+	card, err := s.creditCardService.lockCard(ctx, authorization.CreditCardID)
+	if err != nil {
+		return err
+	}
+
+	// Read again under the card lock
+	authorization, err = s.GetAuthorization(ctx, authorizationID)
+	if err != nil {
+		return err
+	}
+
+	if err := apply(s, card, authorization); err != nil {
+		return err
+	}
+
+	authorization.UpdatedAt = time.Now()
+	if err := s.store.Authorizations().Update(ctx, authorization); err != nil {
+		return fmt.Errorf("failed to update authorization: %w", err)
+	}
+
+	result.Authorization = authorization
+	return nil
+}
+
+// release gives a released hold back to available credit
+func (s *AuthorizationService) release(
+	ctx context.Context,
+	card *models.CreditCard,
+	released decimal.Decimal,
+	result *AuthorizationResult,
+) error {
+	newAvailableCredit := card.AvailableCredit.Add(released)
+	if err := s.creditCardService.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+		return fmt.Errorf("failed to update available credit: %w", err)
+	}
+
+	result.Released = released
+	result.AvailableCredit = newAvailableCredit
+	return nil
+}
+
+// tenantOf returns the tenant an authorization belongs to, for scoping its idempotency key
+func (s *AuthorizationService) tenantOf(ctx context.Context, authorizationID uuid.UUID) (uuid.UUID, error) {
+	authorization, err := s.GetAuthorization(ctx, authorizationID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return authorization.TenantID, nil
+}
+
+// generateAuthorizationCode generates the approval code returned to the merchant
+func generateAuthorizationCode() string {
+	return fmt.Sprintf("AUTH-%s", uuid.New().String()[:8])
+}
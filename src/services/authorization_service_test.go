@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestAuthorizationService_Lifecycle(t *testing.T) {
+	day := time.Date(2026, time.January, 10, 19, 0, 0, 0, time.UTC)
+
+	// Each step runs against a card with a 5000 limit and a fresh authorization of 200
+	type step func(ctx context.Context, s *AuthorizationService, auth *models.Authorization) error
+	capture := func(amount int64, final bool) step {
+		return func(ctx context.Context, s *AuthorizationService, auth *models.Authorization) error {
+			_, err := s.CaptureAuthorization(ctx, CaptureRequest{
+				AuthorizationID: auth.ID,
+				Amount:          decimal.NewFromInt(amount),
+				Description:     "Dinner",
+				PostingDate:     day.AddDate(0, 0, 1),
+				Final:           final,
+			})
+			return err
+		}
+	}
+	increment := func(amount int64, at time.Time) step {
+		return func(ctx context.Context, s *AuthorizationService, auth *models.Authorization) error {
+			_, err := s.IncrementAuthorization(ctx, IncrementAuthorizationRequest{
+				AuthorizationID: auth.ID,
+				Amount:          decimal.NewFromInt(amount),
+				At:              at,
+			})
+			return err
+		}
+	}
+	reverse := func(amount int64) step {
+		return func(ctx context.Context, s *AuthorizationService, auth *models.Authorization) error {
+			_, err := s.ReverseAuthorization(ctx, auth.ID, decimal.NewFromInt(amount), day)
+			return err
+		}
+	}
+	expire := func(asOf time.Time) step {
+		return func(ctx context.Context, s *AuthorizationService, auth *models.Authorization) error {
+			_, err := s.ExpireAuthorizations(ctx, asOf)
+			return err
+		}
+	}
+
+	tests := []struct {
+		name          string
+		steps         []step
+		wantErr       error // Returned by the last step
+		wantStatus    models.AuthorizationStatus
+		wantHeld      int64
+		wantCaptured  int64
+		wantAvailable int64
+	}{
+		{
+			name:          "Hold reduces available credit",
+			wantStatus:    models.AuthorizationStatusActive,
+			wantHeld:      200,
+			wantAvailable: 4800,
+		},
+		{
+			name:          "Capture with a tip",
+			steps:         []step{capture(240, true)},
+			wantStatus:    models.AuthorizationStatusCaptured,
+			wantCaptured:  240,
+			wantAvailable: 4760,
+		},
+		{
+			name:          "Partial capture keeps the rest of the hold",
+			steps:         []step{capture(50, false)},
+			wantStatus:    models.AuthorizationStatusActive,
+			wantHeld:      150,
+			wantCaptured:  50,
+			wantAvailable: 4800,
+		},
+		{
+			name:          "Final capture releases the rest of the hold",
+			steps:         []step{capture(50, false), capture(100, true)},
+			wantStatus:    models.AuthorizationStatusCaptured,
+			wantCaptured:  150,
+			wantAvailable: 4850,
+		},
+		{
+			name:          "Incremental authorization",
+			steps:         []step{increment(150, day.AddDate(0, 0, 2)), capture(330, true)},
+			wantStatus:    models.AuthorizationStatusCaptured,
+			wantCaptured:  330,
+			wantAvailable: 4670,
+		},
+		{
+			name:          "Increment over the limit",
+			steps:         []step{increment(4900, day)},
+			wantErr:       models.ErrInsufficientCredit,
+			wantStatus:    models.AuthorizationStatusActive,
+			wantHeld:      200,
+			wantAvailable: 4800,
+		},
+		{
+			name:          "Partial reversal",
+			steps:         []step{reverse(80)},
+			wantStatus:    models.AuthorizationStatusActive,
+			wantHeld:      120,
+			wantAvailable: 4880,
+		},
+		{
+			name:          "Full reversal",
+			steps:         []step{reverse(80), reverse(0)},
+			wantStatus:    models.AuthorizationStatusReversed,
+			wantAvailable: 5000,
+		},
+		{
+			name:          "Capture after reversal",
+			steps:         []step{reverse(0), capture(200, true)},
+			wantErr:       models.ErrAuthorizationClosed,
+			wantStatus:    models.AuthorizationStatusReversed,
+			wantAvailable: 5000,
+		},
+		{
+			name:          "Hold survives until its expiry",
+			steps:         []step{expire(day.AddDate(0, 0, 6))},
+			wantStatus:    models.AuthorizationStatusActive,
+			wantHeld:      200,
+			wantAvailable: 4800,
+		},
+		{
+			name:          "Stale hold expires",
+			steps:         []step{expire(day.AddDate(0, 0, 7))},
+			wantStatus:    models.AuthorizationStatusExpired,
+			wantAvailable: 5000,
+		},
+		{
+			name:          "Increment after expiry",
+			steps:         []step{increment(50, day.AddDate(0, 0, 8))},
+			wantErr:       models.ErrAuthorizationExpired,
+			wantStatus:    models.AuthorizationStatusActive,
+			wantHeld:      200,
+			wantAvailable: 4800,
+		},
+		{
+			name:          "Late capture after expiry",
+			steps:         []step{expire(day.AddDate(0, 0, 7)), capture(200, true)},
+			wantStatus:    models.AuthorizationStatusCaptured,
+			wantCaptured:  200,
+			wantAvailable: 4800,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			card.CashbackEnabled = false
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+			service := NewAuthorizationServiceWithStore(store)
+
+			authorized, err := service.Authorize(ctx, AuthorizeRequest{
+				CreditCard:       card,
+				Amount:           decimal.NewFromInt(200),
+				MerchantName:     "Test Restaurant",
+				MerchantCategory: "5812",
+				AuthorizedAt:     day,
+			})
+			if err != nil {
+				t.Fatalf("failed to authorize: %v", err)
+			}
+			entries, _ := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+			if len(entries) != 0 {
+				t.Fatalf("authorizing posted %d statement entries", len(entries))
+			}
+
+			for i, run := range tt.steps {
+				err := run(ctx, service, authorized.Authorization)
+				if i < len(tt.steps)-1 || tt.wantErr == nil {
+					if err != nil {
+						t.Fatalf("step %d: unexpected error: %v", i+1, err)
+					}
+					continue
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			}
+
+			auth, err := service.GetAuthorization(ctx, authorized.Authorization.ID)
+			if err != nil {
+				t.Fatalf("failed to load authorization: %v", err)
+			}
+			if auth.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", auth.Status, tt.wantStatus)
+			}
+			if !auth.HeldAmount.Equal(decimal.NewFromInt(tt.wantHeld)) {
+				t.Errorf("held = %s, want %d", auth.HeldAmount, tt.wantHeld)
+			}
+			if !auth.CapturedAmount.Equal(decimal.NewFromInt(tt.wantCaptured)) {
+				t.Errorf("captured = %s, want %d", auth.CapturedAmount, tt.wantCaptured)
+			}
+
+			updated, _ := store.CreditCards().GetByID(ctx, card.ID)
+			if !updated.AvailableCredit.Equal(decimal.NewFromInt(tt.wantAvailable)) {
+				t.Errorf("available credit = %s, want %d", updated.AvailableCredit, tt.wantAvailable)
+			}
+
+			// Captured transactions are linked back to the authorization
+			entries, _ = store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+			captured := decimal.Zero
+			for _, entry := range entries {
+				if entry.Metadata[models.AuthorizationMetadataKey] != auth.ID.String() {
+					t.Errorf("entry %s is not linked to the authorization", entry.ID)
+				}
+				captured = captured.Add(entry.Amount)
+			}
+			if !captured.Equal(auth.CapturedAmount) {
+				t.Errorf("ledger holds %s of captures, authorization %s", captured, auth.CapturedAmount)
+			}
+
+			// Open holds are part of the rebuilt available credit
+			report, err := NewProjectionServiceWithStore(store).Rebuild(ctx, RebuildRequest{CreditCardID: &card.ID})
+			if err != nil {
+				t.Fatalf("failed to rebuild: %v", err)
+			}
+			if report.Differences != 0 {
+				t.Errorf("projection differences: %v", report.Cards[0].Differences)
+			}
+		})
+	}
+}
+
+func TestAuthorizationService_AuthorizeDeclined(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	_, err := NewAuthorizationServiceWithStore(store).Authorize(ctx, AuthorizeRequest{
+		CreditCard:   card,
+		Amount:       decimal.NewFromInt(5001),
+		MerchantName: "Test Merchant",
+		AuthorizedAt: time.Now(),
+	})
+	if !errors.Is(err, models.ErrInsufficientCredit) {
+		t.Fatalf("error = %v, want %v", err, models.ErrInsufficientCredit)
+	}
+
+	authorizations, _ := store.Authorizations().List(ctx, repository.AuthorizationFilter{CreditCardID: &card.ID})
+	if len(authorizations) != 0 {
+		t.Errorf("declined authorization was stored")
+	}
+}
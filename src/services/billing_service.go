@@ -2,37 +2,52 @@ package services
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 	"github.com/shopspring/decimal"
 )
 
 // BillingService handles billing cycle management and statement generation
 type BillingService struct {
-	db                     *sql.DB
+	store                  repository.Store
 	creditCardService      *CreditCardService
 	statementLedgerService *StatementLedgerService
 	interestService        *InterestService
 	feeService             *FeeService
 	cashbackService        *CashbackService
+	statementService       *StatementService
 }
 
 // NewBillingService creates a new billing service
-func NewBillingService(db *sql.DB) *BillingService {
+func NewBillingService(db Querier) *BillingService {
+	return NewBillingServiceWithStore(postgres.NewStore(db))
+}
+
+// NewBillingServiceWithStore creates a billing service backed by store
+func NewBillingServiceWithStore(store repository.Store) *BillingService {
 	return &BillingService{
-		db:                     db,
-		creditCardService:      NewCreditCardService(db),
-		statementLedgerService: NewStatementLedgerService(db),
-		interestService:        NewInterestService(db),
-		feeService:             NewFeeService(db),
-		cashbackService:        NewCashbackService(db),
+		store:                  store,
+		creditCardService:      NewCreditCardServiceWithStore(store),
+		statementLedgerService: NewStatementLedgerServiceWithStore(store),
+		interestService:        NewInterestServiceWithStore(store),
+		feeService:             NewFeeServiceWithStore(store),
+		cashbackService:        NewCashbackServiceWithStore(store),
+		statementService:       NewStatementServiceWithStore(store),
 	}
 }
 
+// withStore returns a copy of the service bound to store
+func (s *BillingService) withStore(store repository.Store) *BillingService {
+	return NewBillingServiceWithStore(store)
+}
+
 // GenerateStatementRequest contains parameters for generating a billing statement
 type GenerateStatementRequest struct {
 	CreditCard *models.CreditCard
@@ -45,7 +60,8 @@ type StatementGenerationResult struct {
 	InterestResult   *InterestCalculationResult
 	FeeSummary       *FeeSummary
 	CashbackStatement *models.CashbackStatement
-	StatementPDF     []byte // Optional PDF representation
+	StatementPDF     []byte // Cardholder statement as a PDF
+	StatementHTML    []byte // The same statement as an HTML page
 }
 
 // GenerateStatement generates a billing statement for a credit card
@@ -55,112 +71,155 @@ func (s *BillingService) GenerateStatement(
 ) (*StatementGenerationResult, error) {
 	result := &StatementGenerationResult{}
 
-	// Get the previous billing cycle to determine previous balance
-	previousCycle, err := s.getPreviousCycle(ctx, req.CreditCard.ID)
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get previous cycle: %w", err)
-	}
+	// Cycle, interest entry and card dates are written as one unit of work
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
 
-	var previousBalance decimal.Decimal
-	if previousCycle != nil {
-		previousBalance = previousCycle.NewBalance.Sub(previousCycle.PaymentsMade)
-	}
-
-	// Determine billing period dates
-	startDate := req.CreditCard.LastStatementDate
-	if startDate == nil {
-		// First statement - use card creation date
-		startDate = &req.CreditCard.CreatedAt
-	}
-
-	cycleNumber := 1
-	if previousCycle != nil {
-		cycleNumber = previousCycle.CycleNumber + 1
-	}
+		// Rates come from the card's stored APR history
+		creditCard, err := loadAPRSchedule(ctx, tx, req.CreditCard)
+		if err != nil {
+			return err
+		}
 
-	// Calculate due date and grace period
-	dueDate := req.CycleEnd.AddDate(0, 0, req.CreditCard.PaymentDueDays)
-	gracePeriodEnd := req.CycleEnd.AddDate(0, 0, req.CreditCard.GracePeriodDays)
+		// Get the previous billing cycle to determine previous balance
+		previousCycle, err := txs.getPreviousCycle(ctx, req.CreditCard.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get previous cycle: %w", err)
+		}
 
-	// Get the effective APR
-	apr := req.CreditCard.GetEffectiveAPR(req.CycleEnd)
+		var previousBalance decimal.Decimal
+		if previousCycle != nil {
+			previousBalance = previousCycle.NewBalance.Sub(previousCycle.PaymentsMade)
+		}
 
-	// Build the billing cycle
-	cycle := models.NewBillingCycleBuilder().
-		WithCreditCard(req.CreditCard).
-		WithCycleNumber(cycleNumber).
-		WithDateRange(*startDate, req.CycleEnd, dueDate, gracePeriodEnd).
-		WithPreviousBalance(previousBalance).
-		WithAPR(apr).
-		Build()
+		// Determine billing period dates
+		startDate := req.CreditCard.LastStatementDate
+		if startDate == nil {
+			// First statement - use card creation date
+			startDate = &req.CreditCard.CreatedAt
+		}
 
-	// Get all transactions for this billing period
-	if err := s.populateCycleAmounts(ctx, cycle, req.CreditCard.TenantID); err != nil {
-		return nil, fmt.Errorf("failed to populate cycle amounts: %w", err)
-	}
+		cycleNumber := 1
+		if previousCycle != nil {
+			cycleNumber = previousCycle.CycleNumber + 1
+		}
 
-	// Calculate interest if applicable
-	interestConfig := DefaultInterestConfig()
-	interestResult, err := s.interestService.CalculateInterest(ctx, req.CreditCard, cycle, interestConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate interest: %w", err)
-	}
-	result.InterestResult = interestResult
+		// Calculate due date and grace period
+		dueDate := req.CycleEnd.AddDate(0, 0, req.CreditCard.PaymentDueDays)
+		gracePeriodEnd := req.CycleEnd.AddDate(0, 0, req.CreditCard.GracePeriodDays)
+
+		// Get the effective APR
+		apr := creditCard.GetEffectiveAPR(req.CycleEnd)
+
+		// Build the billing cycle
+		cycle := models.NewBillingCycleBuilder().
+			WithCreditCard(req.CreditCard).
+			WithCycleNumber(cycleNumber).
+			WithDateRange(*startDate, req.CycleEnd, dueDate, gracePeriodEnd).
+			WithPreviousBalance(previousBalance).
+			WithAPR(apr).
+			Build()
+
+		// A balance transfer promotion ending this cycle rolls into purchases.
+		// Interest still uses creditCard, whose promotion covers the
+		// transferred balance up to the end date.
+		if _, err := txs.creditCardService.RollExpiredBalanceTransferPromo(ctx, req.CreditCard.ID, req.CycleEnd); err != nil {
+			return fmt.Errorf("failed to roll balance transfer promotion: %w", err)
+		}
 
-	// Add interest to cycle if applicable
-	if interestResult != nil && !interestResult.WaivedDueToGracePeriod {
-		cycle.InterestAmount = interestResult.InterestCharge
+		// Get all transactions for this billing period
+		if err := txs.populateCycleAmounts(ctx, cycle, req.CreditCard.TenantID); err != nil {
+			return fmt.Errorf("failed to populate cycle amounts: %w", err)
+		}
 
-		// Create interest entry
-		_, err := s.interestService.AccrueInterest(ctx, req.CreditCard.TenantID, cycle, interestResult)
+		// Calculate interest if applicable
+		interestConfig := DefaultInterestConfig()
+		interestResult, err := txs.interestService.CalculateInterest(ctx, creditCard, cycle, interestConfig)
 		if err != nil {
-			return nil, fmt.Errorf("failed to accrue interest: %w", err)
+			return fmt.Errorf("failed to calculate interest: %w", err)
 		}
-	}
+		result.InterestResult = interestResult
+
+		// Add interest to cycle if applicable. The grace period may waive
+		// purchases while cash advances still accrue.
+		if interestResult.InterestCharge.IsPositive() {
+			cycle.InterestAmount = interestResult.InterestCharge
+
+			// Create interest entries, one per segment
+			_, err := txs.interestService.AccrueInterest(ctx, req.CreditCard.TenantID, cycle, interestResult)
+			if err != nil {
+				return fmt.Errorf("failed to accrue interest: %w", err)
+			}
+		}
+		cycle.Segments = interestResult.Segments
 
-	// Calculate new balance
-	cycle.NewBalance = cycle.CalculateNewBalance()
+		// Calculate new balance
+		cycle.NewBalance = cycle.CalculateNewBalance()
 
-	// Calculate minimum payment
-	cycle.MinimumPayment = req.CreditCard.CalculateMinimumPayment(cycle.NewBalance)
+		// Calculate minimum payment
+		cycle.MinimumPayment = req.CreditCard.CalculateMinimumPayment(cycle.NewBalance)
 
-	// Calculate average daily balance
-	cycle.AverageDailyBalance = interestResult.AverageDailyBalance
+		// Project the payoff for the minimum payment warning
+		cycle.Payoff = txs.interestService.ProjectPayoff(creditCard, cycle.NewBalance, req.CycleEnd)
 
-	// Set statement date
-	cycle.StatementDate = time.Now()
+		// Calculate average daily balance
+		cycle.AverageDailyBalance = interestResult.AverageDailyBalance
 
-	// Save the billing cycle
-	if err := s.saveBillingCycle(ctx, cycle); err != nil {
-		return nil, fmt.Errorf("failed to save billing cycle: %w", err)
-	}
-	result.BillingCycle = cycle
+		// Set statement date
+		cycle.StatementDate = time.Now()
 
-	// Get fee summary
-	feeSummary, err := s.feeService.GetFeeSummary(ctx, req.CreditCard.TenantID, *startDate, req.CycleEnd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get fee summary: %w", err)
-	}
-	result.FeeSummary = feeSummary
+		// Save the billing cycle
+		if err := txs.saveBillingCycle(ctx, cycle); err != nil {
+			return fmt.Errorf("failed to save billing cycle: %w", err)
+		}
+		result.BillingCycle = cycle
 
-	// Get cashback statement
-	cashbackStatement, err := s.cashbackService.GetCashbackStatement(ctx, req.CreditCard.ID, *startDate, req.CycleEnd)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get cashback statement: %w", err)
-	}
-	result.CashbackStatement = cashbackStatement
+		// Get fee summary
+		feeSummary, err := txs.feeService.GetFeeSummary(ctx, req.CreditCard.TenantID, *startDate, req.CycleEnd)
+		if err != nil {
+			return fmt.Errorf("failed to get fee summary: %w", err)
+		}
+		result.FeeSummary = feeSummary
 
-	// Update credit card with new statement date
-	if err := s.updateCardStatementDates(ctx, req.CreditCard.ID, req.CycleEnd); err != nil {
-		return nil, fmt.Errorf("failed to update card statement dates: %w", err)
-	}
+		// Get cashback statement
+		cashbackStatement, err := txs.cashbackService.GetCashbackStatement(ctx, req.CreditCard.ID, *startDate, req.CycleEnd)
+		if err != nil {
+			return fmt.Errorf("failed to get cashback statement: %w", err)
+		}
+		result.CashbackStatement = cashbackStatement
+
+		// Update credit card with new statement date
+		if err := txs.updateCardStatementDates(ctx, req.CreditCard.ID, req.CycleEnd); err != nil {
+			return fmt.Errorf("failed to update card statement dates: %w", err)
+		}
+
+		// Close the billing cycle
+		now := time.Now()
+		cycle.Status = models.BillingCycleStatusClosed
+		cycle.ClosedAt = &now
+		if err := txs.updateBillingCycle(ctx, cycle); err != nil {
+			return fmt.Errorf("failed to close billing cycle: %w", err)
+		}
+
+		// Render the cardholder statement with the card's current available credit
+		card, err := tx.CreditCards().GetByID(ctx, req.CreditCard.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit card: %w", err)
+		}
+		doc, err := txs.statementService.BuildDocument(ctx, card, cycle)
+		if err != nil {
+			return fmt.Errorf("failed to build statement: %w", err)
+		}
+		result.StatementHTML, err = RenderStatementHTML(doc)
+		if err != nil {
+			return err
+		}
+		result.StatementPDF = RenderStatementPDF(doc)
 
-	// Close the billing cycle
-	now := time.Now()
-	cycle.Status = models.BillingCycleStatusClosed
-	cycle.ClosedAt = &now
-	if err := s.updateBillingCycleStatus(ctx, cycle.ID, models.BillingCycleStatusClosed); err != nil {
-		return nil, fmt.Errorf("failed to close billing cycle: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -173,103 +232,89 @@ func (s *BillingService) ProcessPaymentTowardsBillingCycle(
 	paymentAmount decimal.Decimal,
 	paymentDate time.Time,
 ) error {
-	// Get the billing cycle
-	cycle, err := s.getBillingCycle(ctx, cycleID)
-	if err != nil {
-		return fmt.Errorf("failed to get billing cycle: %w", err)
-	}
+	// Read-modify-write of the cycle runs in one transaction
+	return s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		// Get the billing cycle
+		cycle, err := txs.getBillingCycle(ctx, cycleID)
+		if err != nil {
+			return fmt.Errorf("failed to get billing cycle: %w", err)
+		}
 
-	// Add payment to cycle
-	newPaymentsMade := cycle.PaymentsMade.Add(paymentAmount)
+		// Add payment to cycle
+		cycle.PaymentsMade = cycle.PaymentsMade.Add(paymentAmount)
+		cycle.LastPaymentDate = &paymentDate
+		cycle.LastPaymentAmount = paymentAmount
 
-	// Check if minimum payment is now met
-	minimumPaymentMet := newPaymentsMade.GreaterThanOrEqual(cycle.MinimumPayment)
+		// Check if minimum payment is now met
+		cycle.MinimumPaymentMet = cycle.PaymentsMade.GreaterThanOrEqual(cycle.MinimumPayment)
 
-	// Determine new status
-	var newStatus models.BillingCycleStatus
-	if newPaymentsMade.GreaterThanOrEqual(cycle.NewBalance) {
-		newStatus = models.BillingCycleStatusPaidFull
-	} else if minimumPaymentMet {
-		newStatus = models.BillingCycleStatusPaid
-	} else {
-		newStatus = cycle.Status // Keep current status
-	}
+		// Determine new status, keeping the current one otherwise
+		if cycle.PaymentsMade.GreaterThanOrEqual(cycle.NewBalance) {
+			cycle.Status = models.BillingCycleStatusPaidFull
+		} else if cycle.MinimumPaymentMet {
+			cycle.Status = models.BillingCycleStatusPaid
+		}
 
-	// Update the cycle
-	query := `
-		UPDATE billing_cycles
-		SET payments_made = $1,
-		    last_payment_date = $2,
-		    last_payment_amount = $3,
-		    minimum_payment_met = $4,
-		    status = $5,
-		    updated_at = $6
-		WHERE id = $7
-	`
-
-	_, err = s.db.ExecContext(ctx, query,
-		newPaymentsMade,
-		paymentDate,
-		paymentAmount,
-		minimumPaymentMet,
-		newStatus,
-		time.Now(),
-		cycleID,
-	)
-
-	return err
+		// Update the cycle
+		return txs.updateBillingCycle(ctx, cycle)
+	})
 }
 
 // CheckAndAssessLatePaymentFees checks all overdue cycles and assesses late fees
 func (s *BillingService) CheckAndAssessLatePaymentFees(ctx context.Context) ([]*FeeAssessmentResult, error) {
 	currentDate := time.Now()
 
-	// Find all cycles that are past due and haven't had minimum payment
-	query := `
-		SELECT bc.id, bc.credit_card_id
-		FROM billing_cycles bc
-		JOIN credit_cards cc ON cc.id = bc.credit_card_id
-		WHERE bc.status = 'closed'
-		  AND bc.minimum_payment_met = false
-		  AND bc.due_date < $1
-		  AND NOT EXISTS (
-		      SELECT 1 FROM statement_ledger_entries sle
-		      WHERE sle.statement_id = bc.id
-		        AND sle.entry_type = 'fee_late'
-		        AND sle.status != 'reversed'
-		  )
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, currentDate)
+	// Find all cycles that are past due and haven't had minimum payment.
+	// Cycles already charged a late fee are skipped by AssessLatePaymentFee.
+	minimumPaymentMet := false
+	overdue, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{
+		Statuses:          []models.BillingCycleStatus{models.BillingCycleStatusClosed},
+		MinimumPaymentMet: &minimumPaymentMet,
+		DueBefore:         &currentDate,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to find overdue cycles: %w", err)
 	}
-	defer rows.Close()
 
 	var results []*FeeAssessmentResult
-	for rows.Next() {
-		var cycleID, cardID uuid.UUID
-		if err := rows.Scan(&cycleID, &cardID); err != nil {
-			continue
-		}
-
-		// Get the credit card and cycle
-		card, err := s.creditCardService.GetCreditCard(ctx, cardID)
-		if err != nil {
-			continue
-		}
+	for _, overdueCycle := range overdue {
+		// Fee, cycle status and late count are committed together per cycle
+		var feeResult *FeeAssessmentResult
+		err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+			txs := s.withStore(tx)
+
+			// Get the credit card and cycle
+			card, err := txs.creditCardService.GetCreditCard(ctx, overdueCycle.CreditCardID)
+			if err != nil {
+				return err
+			}
+
+			cycle, err := txs.getBillingCycle(ctx, overdueCycle.ID)
+			if err != nil {
+				return err
+			}
+
+			// Assess late fee
+			feeResult, err = txs.feeService.AssessLatePaymentFee(ctx, LatePaymentFeeRequest{
+				CreditCard:   card,
+				BillingCycle: cycle,
+				CurrentDate:  currentDate,
+				DaysOverdue:  cycle.DaysOverdue(currentDate),
+			})
+			if err != nil || feeResult == nil {
+				return err
+			}
 
-		cycle, err := s.getBillingCycle(ctx, cycleID)
-		if err != nil {
-			continue
-		}
+			// Update cycle status to past due
+			cycle.Status = models.BillingCycleStatusPastDue
+			if err := txs.updateBillingCycle(ctx, cycle); err != nil {
+				return err
+			}
 
-		// Assess late fee
-		feeResult, err := s.feeService.AssessLatePaymentFee(ctx, LatePaymentFeeRequest{
-			CreditCard:   card,
-			BillingCycle: cycle,
-			CurrentDate:  currentDate,
-			DaysOverdue:  cycle.DaysOverdue(currentDate),
+			// Increment consecutive late count
+			return txs.incrementLateCounts(ctx, overdueCycle.CreditCardID)
 		})
 		if err != nil {
 			continue
@@ -277,16 +322,10 @@ func (s *BillingService) CheckAndAssessLatePaymentFees(ctx context.Context) ([]*
 
 		if feeResult != nil {
 			results = append(results, feeResult)
-
-			// Update cycle status to past due
-			s.updateBillingCycleStatus(ctx, cycleID, models.BillingCycleStatusPastDue)
-
-			// Increment consecutive late count
-			s.incrementLateCounts(ctx, cardID)
 		}
 	}
 
-	return results, rows.Err()
+	return results, nil
 }
 
 // GetCurrentBillingCycle returns the current open billing cycle for a card
@@ -294,37 +333,16 @@ func (s *BillingService) GetCurrentBillingCycle(
 	ctx context.Context,
 	creditCardID uuid.UUID,
 ) (*models.BillingCycle, error) {
-	query := `
-		SELECT id, credit_card_id, tenant_id, cycle_number, cycle_type,
-		       cycle_start_date, cycle_end_date, statement_date, due_date, grace_period_end,
-		       previous_balance, payments_received, purchases_amount, cash_advances_amount,
-		       refunds_amount, fees_amount, interest_amount, adjustments_amount,
-		       cashback_earned, cashback_redeemed, new_balance, minimum_payment,
-		       average_daily_balance, days_in_cycle, apr_applied,
-		       payments_made, last_payment_date, last_payment_amount, minimum_payment_met,
-		       status, created_at, updated_at, closed_at
-		FROM billing_cycles
-		WHERE credit_card_id = $1 AND status = 'open'
-		ORDER BY cycle_number DESC
-		LIMIT 1
-	`
-
-	cycle := &models.BillingCycle{}
-	err := s.db.QueryRowContext(ctx, query, creditCardID).Scan(
-		&cycle.ID, &cycle.CreditCardID, &cycle.TenantID, &cycle.CycleNumber, &cycle.CycleType,
-		&cycle.CycleStartDate, &cycle.CycleEndDate, &cycle.StatementDate, &cycle.DueDate, &cycle.GracePeriodEnd,
-		&cycle.PreviousBalance, &cycle.PaymentsReceived, &cycle.PurchasesAmount, &cycle.CashAdvancesAmount,
-		&cycle.RefundsAmount, &cycle.FeesAmount, &cycle.InterestAmount, &cycle.AdjustmentsAmount,
-		&cycle.CashbackEarned, &cycle.CashbackRedeemed, &cycle.NewBalance, &cycle.MinimumPayment,
-		&cycle.AverageDailyBalance, &cycle.DaysInCycle, &cycle.APRApplied,
-		&cycle.PaymentsMade, &cycle.LastPaymentDate, &cycle.LastPaymentAmount, &cycle.MinimumPaymentMet,
-		&cycle.Status, &cycle.CreatedAt, &cycle.UpdatedAt, &cycle.ClosedAt,
-	)
-
-	if err == sql.ErrNoRows {
-		return nil, nil // No current cycle
+	cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{
+		CreditCardID: &creditCardID,
+		Statuses:     []models.BillingCycleStatus{models.BillingCycleStatusOpen},
+		Limit:        1,
+	})
+	if err != nil || len(cycles) == 0 {
+		return nil, err // No current cycle
 	}
-	return cycle, err
+
+	return cycles[0], nil
 }
 
 // GetBillingHistory returns billing cycle history for a card
@@ -333,39 +351,23 @@ func (s *BillingService) GetBillingHistory(
 	creditCardID uuid.UUID,
 	limit int,
 ) ([]*models.BillingCycleSummary, error) {
-	query := `
-		SELECT id, cycle_number, cycle_type, cycle_start_date, cycle_end_date,
-		       due_date, new_balance, minimum_payment, payments_made, status
-		FROM billing_cycles
-		WHERE credit_card_id = $1
-		ORDER BY cycle_number DESC
-		LIMIT $2
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, creditCardID, limit)
+	cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{
+		CreditCardID: &creditCardID,
+		Limit:        limit,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
 	currentDate := time.Now()
 	var summaries []*models.BillingCycleSummary
 
-	for rows.Next() {
-		cycle := &models.BillingCycle{}
-		err := rows.Scan(
-			&cycle.ID, &cycle.CycleNumber, &cycle.CycleType, &cycle.CycleStartDate, &cycle.CycleEndDate,
-			&cycle.DueDate, &cycle.NewBalance, &cycle.MinimumPayment, &cycle.PaymentsMade, &cycle.Status,
-		)
-		if err != nil {
-			return nil, err
-		}
-
+	for _, cycle := range cycles {
 		summary := cycle.ToSummary(currentDate)
 		summaries = append(summaries, &summary)
 	}
 
-	return summaries, rows.Err()
+	return summaries, nil
 }
 
 // StartNewBillingCycle creates a new billing cycle for a card
@@ -375,7 +377,7 @@ func (s *BillingService) StartNewBillingCycle(
 ) (*models.BillingCycle, error) {
 	// Get the previous cycle
 	previousCycle, err := s.getPreviousCycle(ctx, creditCard.ID)
-	if err != nil && err != sql.ErrNoRows {
+	if err != nil {
 		return nil, fmt.Errorf("failed to get previous cycle: %w", err)
 	}
 
@@ -424,126 +426,72 @@ func (s *BillingService) populateCycleAmounts(
 	cycle *models.BillingCycle,
 	tenantID uuid.UUID,
 ) error {
-	query := `
-		SELECT
-			COALESCE(SUM(CASE WHEN entry_type = 'transaction' THEN amount ELSE 0 END), 0) as purchases,
-			COALESCE(SUM(CASE WHEN entry_type = 'cash_advance' THEN amount ELSE 0 END), 0) as cash_advances,
-			COALESCE(SUM(CASE WHEN entry_type = 'refund' THEN amount ELSE 0 END), 0) as refunds,
-			COALESCE(SUM(CASE WHEN entry_type = 'payment' THEN amount ELSE 0 END), 0) as payments,
-			COALESCE(SUM(CASE WHEN entry_type LIKE 'fee_%' THEN amount ELSE 0 END), 0) as fees,
-			COALESCE(SUM(CASE WHEN entry_type IN ('adjustment', 'credit') THEN
-				CASE WHEN entry_type = 'credit' THEN -amount ELSE amount END
-			ELSE 0 END), 0) as adjustments,
-			COALESCE(SUM(CASE WHEN entry_type = 'cashback_earned' THEN amount ELSE 0 END), 0) as cashback_earned,
-			COALESCE(SUM(CASE WHEN entry_type = 'cashback_redeemed' THEN amount ELSE 0 END), 0) as cashback_redeemed
-		FROM statement_ledger_entries
-		WHERE tenant_id = $1
-		  AND posting_date >= $2
-		  AND posting_date <= $3
-		  AND status IN ('pending', 'cleared')
-	`
-
-	err := s.db.QueryRowContext(ctx, query, tenantID, cycle.CycleStartDate, cycle.CycleEndDate).Scan(
-		&cycle.PurchasesAmount,
-		&cycle.CashAdvancesAmount,
-		&cycle.RefundsAmount,
-		&cycle.PaymentsReceived,
-		&cycle.FeesAmount,
-		&cycle.AdjustmentsAmount,
-		&cycle.CashbackEarned,
-		&cycle.CashbackRedeemed,
-	)
-
-	return err
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:   &tenantID,
+		Statuses:   []models.EntryStatus{models.EntryStatusPending, models.EntryStatusCleared},
+		PostedFrom: &cycle.CycleStartDate,
+		PostedTo:   &cycle.CycleEndDate,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.EntryType == models.EntryTypeTransaction:
+			cycle.PurchasesAmount = cycle.PurchasesAmount.Add(entry.Amount)
+		case entry.EntryType == models.EntryTypeCashAdvance:
+			cycle.CashAdvancesAmount = cycle.CashAdvancesAmount.Add(entry.Amount)
+		case entry.EntryType == models.EntryTypeBalanceTransfer:
+			cycle.BalanceTransfersAmount = cycle.BalanceTransfersAmount.Add(entry.Amount)
+		case entry.EntryType == models.EntryTypeRefund:
+			cycle.RefundsAmount = cycle.RefundsAmount.Add(entry.Amount)
+		case entry.EntryType == models.EntryTypePayment:
+			cycle.PaymentsReceived = cycle.PaymentsReceived.Add(entry.Amount)
+		case strings.HasPrefix(string(entry.EntryType), "fee_"):
+			cycle.FeesAmount = cycle.FeesAmount.Add(entry.Amount)
+		case entry.EntryType == models.EntryTypeAdjustment:
+			cycle.AdjustmentsAmount = cycle.AdjustmentsAmount.Add(entry.Amount)
+		case entry.EntryType == models.EntryTypeCredit, entry.EntryType == models.EntryTypeChargeOff:
+			cycle.AdjustmentsAmount = cycle.AdjustmentsAmount.Sub(entry.Amount)
+		case entry.EntryType == models.EntryTypeCashbackEarned:
+			cycle.CashbackEarned = cycle.CashbackEarned.Add(entry.Amount)
+		case entry.EntryType == models.EntryTypeCashbackRedeemed:
+			cycle.CashbackRedeemed = cycle.CashbackRedeemed.Add(entry.Amount)
+		}
+	}
+
+	return nil
 }
 
 // saveBillingCycle saves a billing cycle to the database
 func (s *BillingService) saveBillingCycle(ctx context.Context, cycle *models.BillingCycle) error {
-	query := `
-		INSERT INTO billing_cycles (
-			id, credit_card_id, tenant_id, cycle_number, cycle_type,
-			cycle_start_date, cycle_end_date, statement_date, due_date, grace_period_end,
-			previous_balance, payments_received, purchases_amount, cash_advances_amount,
-			refunds_amount, fees_amount, interest_amount, adjustments_amount,
-			cashback_earned, cashback_redeemed, new_balance, minimum_payment,
-			average_daily_balance, days_in_cycle, apr_applied,
-			payments_made, minimum_payment_met, status, created_at, updated_at
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
-			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30
-		)
-	`
-
-	_, err := s.db.ExecContext(ctx, query,
-		cycle.ID, cycle.CreditCardID, cycle.TenantID, cycle.CycleNumber, cycle.CycleType,
-		cycle.CycleStartDate, cycle.CycleEndDate, cycle.StatementDate, cycle.DueDate, cycle.GracePeriodEnd,
-		cycle.PreviousBalance, cycle.PaymentsReceived, cycle.PurchasesAmount, cycle.CashAdvancesAmount,
-		cycle.RefundsAmount, cycle.FeesAmount, cycle.InterestAmount, cycle.AdjustmentsAmount,
-		cycle.CashbackEarned, cycle.CashbackRedeemed, cycle.NewBalance, cycle.MinimumPayment,
-		cycle.AverageDailyBalance, cycle.DaysInCycle, cycle.APRApplied,
-		cycle.PaymentsMade, cycle.MinimumPaymentMet, cycle.Status, cycle.CreatedAt, cycle.UpdatedAt,
-	)
-
-	return err
+	return s.store.BillingCycles().Create(ctx, cycle)
 }
 
 // getBillingCycle retrieves a billing cycle by ID
 func (s *BillingService) getBillingCycle(ctx context.Context, cycleID uuid.UUID) (*models.BillingCycle, error) {
-	query := `
-		SELECT id, credit_card_id, tenant_id, cycle_number, cycle_type,
-		       cycle_start_date, cycle_end_date, statement_date, due_date, grace_period_end,
-		       previous_balance, payments_received, purchases_amount, cash_advances_amount,
-		       refunds_amount, fees_amount, interest_amount, adjustments_amount,
-		       cashback_earned, cashback_redeemed, new_balance, minimum_payment,
-		       average_daily_balance, days_in_cycle, apr_applied,
-		       payments_made, last_payment_date, last_payment_amount, minimum_payment_met,
-		       status, created_at, updated_at, closed_at
-		FROM billing_cycles
-		WHERE id = $1
-	`
-
-	cycle := &models.BillingCycle{}
-	err := s.db.QueryRowContext(ctx, query, cycleID).Scan(
-		&cycle.ID, &cycle.CreditCardID, &cycle.TenantID, &cycle.CycleNumber, &cycle.CycleType,
-		&cycle.CycleStartDate, &cycle.CycleEndDate, &cycle.StatementDate, &cycle.DueDate, &cycle.GracePeriodEnd,
-		&cycle.PreviousBalance, &cycle.PaymentsReceived, &cycle.PurchasesAmount, &cycle.CashAdvancesAmount,
-		&cycle.RefundsAmount, &cycle.FeesAmount, &cycle.InterestAmount, &cycle.AdjustmentsAmount,
-		&cycle.CashbackEarned, &cycle.CashbackRedeemed, &cycle.NewBalance, &cycle.MinimumPayment,
-		&cycle.AverageDailyBalance, &cycle.DaysInCycle, &cycle.APRApplied,
-		&cycle.PaymentsMade, &cycle.LastPaymentDate, &cycle.LastPaymentAmount, &cycle.MinimumPaymentMet,
-		&cycle.Status, &cycle.CreatedAt, &cycle.UpdatedAt, &cycle.ClosedAt,
-	)
-
-	return cycle, err
+	return s.store.BillingCycles().GetByID(ctx, cycleID)
 }
 
 // getPreviousCycle retrieves the most recent billing cycle for a card
+// Returns nil when the card has no billing cycles yet
 func (s *BillingService) getPreviousCycle(ctx context.Context, creditCardID uuid.UUID) (*models.BillingCycle, error) {
-	query := `
-		SELECT id FROM billing_cycles
-		WHERE credit_card_id = $1
-		ORDER BY cycle_number DESC
-		LIMIT 1
-	`
-
-	var cycleID uuid.UUID
-	err := s.db.QueryRowContext(ctx, query, creditCardID).Scan(&cycleID)
-	if err != nil {
+	cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{
+		CreditCardID: &creditCardID,
+		Limit:        1,
+	})
+	if err != nil || len(cycles) == 0 {
 		return nil, err
 	}
 
-	return s.getBillingCycle(ctx, cycleID)
+	return cycles[0], nil
 }
 
-// updateBillingCycleStatus updates the status of a billing cycle
-func (s *BillingService) updateBillingCycleStatus(
-	ctx context.Context,
-	cycleID uuid.UUID,
-	status models.BillingCycleStatus,
-) error {
-	query := `UPDATE billing_cycles SET status = $1, updated_at = $2 WHERE id = $3`
-	_, err := s.db.ExecContext(ctx, query, status, time.Now(), cycleID)
-	return err
+// updateBillingCycle writes the mutable fields of a billing cycle
+func (s *BillingService) updateBillingCycle(ctx context.Context, cycle *models.BillingCycle) error {
+	cycle.UpdatedAt = time.Now()
+	return s.store.BillingCycles().Update(ctx, cycle)
 }
 
 // updateCardStatementDates updates the statement dates on the credit card
@@ -555,25 +503,17 @@ func (s *BillingService) updateCardStatementDates(
 	// Calculate next statement date
 	nextStatement := lastStatementDate.AddDate(0, 1, 0) // Monthly default
 
-	query := `
-		UPDATE credit_cards
-		SET last_statement_date = $1, next_statement_date = $2, updated_at = $3
-		WHERE id = $4
-	`
-
-	_, err := s.db.ExecContext(ctx, query, lastStatementDate, nextStatement, time.Now(), cardID)
-	return err
+	return s.creditCardService.updateCard(ctx, cardID, func(card *models.CreditCard) {
+		card.LastStatementDate = &lastStatementDate
+		card.NextStatementDate = &nextStatement
+	})
 }
 
 // incrementLateCounts increments the consecutive late payment count
 func (s *BillingService) incrementLateCounts(ctx context.Context, cardID uuid.UUID) error {
-	query := `
-		UPDATE credit_cards
-		SET consecutive_late_count = consecutive_late_count + 1, updated_at = $1
-		WHERE id = $2
-	`
-	_, err := s.db.ExecContext(ctx, query, time.Now(), cardID)
-	return err
+	return s.creditCardService.updateCard(ctx, cardID, func(card *models.CreditCard) {
+		card.ConsecutiveLateCount++
+	})
 }
 
 // GetUpcomingStatementDates returns cards that have statements due soon
@@ -581,32 +521,35 @@ func (s *BillingService) GetUpcomingStatementDates(
 	ctx context.Context,
 	withinDays int,
 ) ([]UpcomingStatement, error) {
-	query := `
-		SELECT cc.id, cc.tenant_id, cc.cardholder_name, cc.next_statement_date
-		FROM credit_cards cc
-		WHERE cc.status = 'active'
-		  AND cc.next_statement_date <= $1
-		  AND cc.next_statement_date >= CURRENT_DATE
-		ORDER BY cc.next_statement_date
-	`
-
-	futureDate := time.Now().AddDate(0, 0, withinDays)
-	rows, err := s.db.QueryContext(ctx, query, futureDate)
+	cards, err := s.store.CreditCards().List(ctx, repository.CreditCardFilter{
+		Statuses: []models.CreditCardStatus{models.CreditCardStatusActive},
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+
+	today := models.DateOf(time.Now())
+	futureDate := time.Now().AddDate(0, 0, withinDays)
 
 	var upcoming []UpcomingStatement
-	for rows.Next() {
-		var stmt UpcomingStatement
-		if err := rows.Scan(&stmt.CreditCardID, &stmt.TenantID, &stmt.CardholderName, &stmt.StatementDate); err != nil {
-			return nil, err
+	for _, card := range cards {
+		if card.NextStatementDate == nil ||
+			card.NextStatementDate.Before(today) || card.NextStatementDate.After(futureDate) {
+			continue
 		}
-		upcoming = append(upcoming, stmt)
+		upcoming = append(upcoming, UpcomingStatement{
+			CreditCardID:   card.ID,
+			TenantID:       card.TenantID,
+			CardholderName: card.CardholderName,
+			StatementDate:  *card.NextStatementDate,
+		})
 	}
 
-	return upcoming, rows.Err()
+	sort.SliceStable(upcoming, func(i, j int) bool {
+		return upcoming[i].StatementDate.Before(upcoming[j].StatementDate)
+	})
+
+	return upcoming, nil
 }
 
 // UpcomingStatement represents an upcoming billing statement
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestBillingService_GenerateStatement(t *testing.T) {
+	cycleStart := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	purchaseDate := time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		previousCycle   *models.BillingCycle
+		wantCycleNumber int
+		wantWaived      bool
+		wantInterest    decimal.Decimal
+		wantNewBalance  decimal.Decimal
+	}{
+		{
+			name:            "First cycle waives interest",
+			wantCycleNumber: 1,
+			wantWaived:      true,
+			wantInterest:    decimal.Zero,
+			wantNewBalance:  decimal.NewFromInt(1000),
+		},
+		{
+			name: "Previous cycle paid in full waives interest",
+			previousCycle: &models.BillingCycle{
+				CycleNumber:  1,
+				NewBalance:   decimal.NewFromInt(500),
+				PaymentsMade: decimal.NewFromInt(500),
+				Status:       models.BillingCycleStatusPaidFull,
+			},
+			wantCycleNumber: 2,
+			wantWaived:      true,
+			wantInterest:    decimal.Zero,
+			wantNewBalance:  decimal.NewFromInt(1000),
+		},
+		{
+			name: "Previous cycle not paid in full charges interest",
+			previousCycle: &models.BillingCycle{
+				CycleNumber:  1,
+				NewBalance:   decimal.NewFromInt(500),
+				PaymentsMade: decimal.NewFromInt(100),
+				Status:       models.BillingCycleStatusPaid,
+			},
+			wantCycleNumber: 2,
+			wantWaived:      false,
+			// ADB 709.68 (1000 owed for 22 of 31 days) × 19.99% / 365 × 31
+			wantInterest:   decimal.NewFromFloat(12.05),
+			wantNewBalance: decimal.NewFromFloat(1412.05),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+
+			card := testCard()
+			card.CreatedAt = cycleStart
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+
+			if tt.previousCycle != nil {
+				tt.previousCycle.CreditCardID = card.ID
+				tt.previousCycle.TenantID = card.TenantID
+				if err := store.BillingCycles().Create(ctx, tt.previousCycle); err != nil {
+					t.Fatalf("failed to create previous cycle: %v", err)
+				}
+			}
+
+			// A cleared purchase part way through the cycle
+			purchase, err := NewCreditCardServiceWithStore(store).RecordTransaction(ctx, CCTransactionRequest{
+				CreditCard:       card,
+				Amount:           decimal.NewFromInt(1000),
+				Description:      "Dinner",
+				MerchantName:     "Test Merchant",
+				MerchantCategory: "5812",
+				TransactionDate:  purchaseDate,
+				PostingDate:      purchaseDate,
+				ReferenceID:      "TXN-1",
+			})
+			if err != nil {
+				t.Fatalf("failed to record purchase: %v", err)
+			}
+			if err := NewStatementLedgerServiceWithStore(store).ClearEntry(ctx, purchase.TransactionEntry.ID); err != nil {
+				t.Fatalf("failed to clear purchase: %v", err)
+			}
+
+			result, err := NewBillingServiceWithStore(store).GenerateStatement(ctx, GenerateStatementRequest{
+				CreditCard: card,
+				CycleEnd:   cycleEnd,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			cycle := result.BillingCycle
+			if cycle.CycleNumber != tt.wantCycleNumber {
+				t.Errorf("cycle number = %d, want %d", cycle.CycleNumber, tt.wantCycleNumber)
+			}
+			if !cycle.PurchasesAmount.Equal(decimal.NewFromInt(1000)) {
+				t.Errorf("purchases = %s, want 1000", cycle.PurchasesAmount)
+			}
+			if result.InterestResult.WaivedDueToGracePeriod != tt.wantWaived {
+				t.Errorf("waived = %v, want %v", result.InterestResult.WaivedDueToGracePeriod, tt.wantWaived)
+			}
+			if !cycle.InterestAmount.Equal(tt.wantInterest) {
+				t.Errorf("interest = %s, want %s", cycle.InterestAmount, tt.wantInterest)
+			}
+			if !cycle.NewBalance.Equal(tt.wantNewBalance) {
+				t.Errorf("new balance = %s, want %s", cycle.NewBalance, tt.wantNewBalance)
+			}
+			if !result.FeeSummary.TotalInterestCharges.Equal(tt.wantInterest) {
+				t.Errorf("fee summary interest = %s, want %s", result.FeeSummary.TotalInterestCharges, tt.wantInterest)
+			}
+			if cycle.Payoff == nil || !cycle.Payoff.Balance.Equal(tt.wantNewBalance) {
+				t.Errorf("payoff projection = %+v, want one for %s", cycle.Payoff, tt.wantNewBalance)
+			}
+
+			// 1.5% cashback on the purchase, attributed to its merchant category
+			cashback := result.CashbackStatement
+			if !cashback.CashbackEarned.Equal(decimal.NewFromInt(15)) {
+				t.Errorf("cashback earned = %s, want 15", cashback.CashbackEarned)
+			}
+			if len(cashback.CategoryBreakdown) != 1 || cashback.CategoryBreakdown[0].CategoryCode != "5812" {
+				t.Errorf("unexpected category breakdown: %+v", cashback.CategoryBreakdown)
+			}
+
+			// The cycle and card updates are persisted
+			stored, err := store.BillingCycles().GetByID(ctx, cycle.ID)
+			if err != nil {
+				t.Fatalf("failed to load cycle: %v", err)
+			}
+			if stored.Status != models.BillingCycleStatusClosed {
+				t.Errorf("cycle status = %s, want closed", stored.Status)
+			}
+
+			updated, err := store.CreditCards().GetByID(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to load card: %v", err)
+			}
+			if updated.LastStatementDate == nil || !updated.LastStatementDate.Equal(cycleEnd) {
+				t.Errorf("last statement date = %v, want %v", updated.LastStatementDate, cycleEnd)
+			}
+		})
+	}
+}
+
+func TestInterestService_CalculateInterest(t *testing.T) {
+	cycleStart := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, time.March, 30, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		entries    []*models.StatementLedgerEntry
+		wantADB    decimal.Decimal
+		wantCharge decimal.Decimal
+	}{
+		{
+			name: "Balance carried all cycle",
+			entries: []*models.StatementLedgerEntry{
+				{EntryType: models.EntryTypeTransaction, PostingDate: cycleStart.AddDate(0, 0, -5), Amount: decimal.NewFromInt(3000)},
+			},
+			wantADB: decimal.NewFromInt(3000),
+			// 3000 × 19.99% / 365 × 30 = 49.29
+			wantCharge: decimal.NewFromFloat(49.29),
+		},
+		{
+			name: "Payment halfway reduces the average",
+			entries: []*models.StatementLedgerEntry{
+				{EntryType: models.EntryTypeTransaction, PostingDate: cycleStart.AddDate(0, 0, -5), Amount: decimal.NewFromInt(3000)},
+				{EntryType: models.EntryTypePayment, PostingDate: cycleStart.AddDate(0, 0, 15), Amount: decimal.NewFromInt(3000)},
+			},
+			wantADB: decimal.NewFromInt(1500),
+			// 1500 × 19.99% / 365 × 30 = 24.65
+			wantCharge: decimal.NewFromFloat(24.65),
+		},
+		{
+			name: "Pending entries are ignored",
+			entries: []*models.StatementLedgerEntry{
+				{EntryType: models.EntryTypeTransaction, PostingDate: cycleStart, Amount: decimal.NewFromInt(3000), Status: models.EntryStatusPending},
+			},
+			wantADB:    decimal.Zero,
+			wantCharge: decimal.Zero,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+
+			for _, entry := range tt.entries {
+				entry.TenantID = card.TenantID
+				entry.EntryDate = entry.PostingDate
+				if entry.Status == "" {
+					entry.Status = models.EntryStatusCleared
+				}
+				if err := store.StatementEntries().Create(ctx, entry); err != nil {
+					t.Fatalf("failed to create entry: %v", err)
+				}
+			}
+
+			// A previous cycle that was not paid off, so grace does not apply
+			if err := store.BillingCycles().Create(ctx, &models.BillingCycle{
+				CreditCardID: card.ID,
+				TenantID:     card.TenantID,
+				CycleNumber:  1,
+				NewBalance:   decimal.NewFromInt(3000),
+				Status:       models.BillingCycleStatusClosed,
+			}); err != nil {
+				t.Fatalf("failed to create previous cycle: %v", err)
+			}
+
+			cycle := &models.BillingCycle{
+				ID:             uuid.New(),
+				CreditCardID:   card.ID,
+				CycleNumber:    2,
+				CycleStartDate: cycleStart,
+				CycleEndDate:   cycleEnd,
+			}
+
+			result, err := NewInterestServiceWithStore(store).CalculateInterest(ctx, card, cycle, DefaultInterestConfig())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.WaivedDueToGracePeriod {
+				t.Fatal("expected grace period not to apply")
+			}
+			if result.DaysInCycle != 30 {
+				t.Errorf("days in cycle = %d, want 30", result.DaysInCycle)
+			}
+			if !result.AverageDailyBalance.Equal(tt.wantADB) {
+				t.Errorf("ADB = %s, want %s", result.AverageDailyBalance, tt.wantADB)
+			}
+			if !result.InterestCharge.Equal(tt.wantCharge) {
+				t.Errorf("interest = %s, want %s", result.InterestCharge, tt.wantCharge)
+			}
+		})
+	}
+}
+
+func TestInterestService_CalculateInterestBySegment(t *testing.T) {
+	cycleStart := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, time.March, 30, 0, 0, 0, 0, time.UTC)
+	carried := cycleStart.AddDate(0, 0, -5)
+	halfway := cycleStart.AddDate(0, 0, 15)
+
+	tests := []struct {
+		name         string
+		paidInFull   bool // Previous cycle paid off, so purchases get the grace period
+		paymentMeta  map[string]interface{}
+		wantWaived   bool
+		wantSegments map[models.BalanceSegment]decimal.Decimal
+		wantCharge   decimal.Decimal
+	}{
+		{
+			name: "Payment pays purchases first",
+			wantSegments: map[models.BalanceSegment]decimal.Decimal{
+				// ADB 2500 × 19.99% / 365 × 30
+				models.SegmentPurchase: decimal.NewFromFloat(41.08),
+				// ADB 1000 × 24.99% / 365 × 30
+				models.SegmentCashAdvance: decimal.NewFromFloat(20.54),
+			},
+			wantCharge: decimal.NewFromFloat(61.62),
+		},
+		{
+			name:        "Payment placed in the cash advance segment",
+			paymentMeta: map[string]interface{}{models.SegmentMetadataKey: "cash_advance"},
+			wantSegments: map[models.BalanceSegment]decimal.Decimal{
+				models.SegmentPurchase:    decimal.NewFromFloat(49.29),
+				models.SegmentCashAdvance: decimal.NewFromFloat(10.27),
+			},
+			wantCharge: decimal.NewFromFloat(59.56),
+		},
+		{
+			name:       "Grace period does not cover cash advances",
+			paidInFull: true,
+			wantWaived: true,
+			wantSegments: map[models.BalanceSegment]decimal.Decimal{
+				models.SegmentPurchase:    decimal.Zero,
+				models.SegmentCashAdvance: decimal.NewFromFloat(20.54),
+			},
+			wantCharge: decimal.NewFromFloat(20.54),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+
+			entries := []*models.StatementLedgerEntry{
+				{EntryType: models.EntryTypeTransaction, PostingDate: carried, Amount: decimal.NewFromInt(3000)},
+				{EntryType: models.EntryTypeCashAdvance, PostingDate: carried, Amount: decimal.NewFromInt(1000)},
+				{EntryType: models.EntryTypePayment, PostingDate: halfway, Amount: decimal.NewFromInt(1000), Metadata: tt.paymentMeta},
+			}
+			for _, entry := range entries {
+				entry.TenantID = card.TenantID
+				entry.EntryDate = entry.PostingDate
+				entry.Status = models.EntryStatusCleared
+				if err := store.StatementEntries().Create(ctx, entry); err != nil {
+					t.Fatalf("failed to create entry: %v", err)
+				}
+			}
+
+			previous := &models.BillingCycle{
+				CreditCardID: card.ID,
+				TenantID:     card.TenantID,
+				CycleNumber:  1,
+				NewBalance:   decimal.NewFromInt(4000),
+				Status:       models.BillingCycleStatusClosed,
+			}
+			if tt.paidInFull {
+				previous.PaymentsMade = previous.NewBalance
+				previous.Status = models.BillingCycleStatusPaidFull
+			}
+			if err := store.BillingCycles().Create(ctx, previous); err != nil {
+				t.Fatalf("failed to create previous cycle: %v", err)
+			}
+
+			cycle := &models.BillingCycle{
+				ID:             uuid.New(),
+				CreditCardID:   card.ID,
+				CycleNumber:    2,
+				CycleStartDate: cycleStart,
+				CycleEndDate:   cycleEnd,
+			}
+
+			result, err := NewInterestServiceWithStore(store).CalculateInterest(ctx, card, cycle, DefaultInterestConfig())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if result.WaivedDueToGracePeriod != tt.wantWaived {
+				t.Errorf("waived = %v, want %v", result.WaivedDueToGracePeriod, tt.wantWaived)
+			}
+			if !result.InterestCharge.Equal(tt.wantCharge) {
+				t.Errorf("interest = %s, want %s", result.InterestCharge, tt.wantCharge)
+			}
+			if len(result.Segments) != len(tt.wantSegments) {
+				t.Fatalf("segments = %+v, want %d", result.Segments, len(tt.wantSegments))
+			}
+			for _, segment := range result.Segments {
+				if want := tt.wantSegments[segment.Segment]; !segment.InterestCharge.Equal(want) {
+					t.Errorf("%s interest = %s, want %s", segment.Segment, segment.InterestCharge, want)
+				}
+				if segment.GracePeriodApplied != (tt.wantWaived && segment.Segment.HasGracePeriod()) {
+					t.Errorf("%s grace period applied = %v", segment.Segment, segment.GracePeriodApplied)
+				}
+			}
+
+			accrued, err := NewInterestServiceWithStore(store).AccrueInterest(ctx, card.TenantID, cycle, result)
+			if err != nil {
+				t.Fatalf("failed to accrue interest: %v", err)
+			}
+			total := decimal.Zero
+			for _, entry := range accrued {
+				total = total.Add(entry.Amount)
+			}
+			if !total.Equal(tt.wantCharge) {
+				t.Errorf("accrued %s across %d entries, want %s", total, len(accrued), tt.wantCharge)
+			}
+		})
+	}
+}
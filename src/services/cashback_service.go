@@ -2,29 +2,41 @@ package services
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 	"github.com/shopspring/decimal"
 )
 
 // CashbackService handles cashback calculation, earning, and redemption
 type CashbackService struct {
-	db                     *sql.DB
+	store                  repository.Store
 	statementLedgerService *StatementLedgerService
 }
 
 // NewCashbackService creates a new cashback service
-func NewCashbackService(db *sql.DB) *CashbackService {
+func NewCashbackService(db Querier) *CashbackService {
+	return NewCashbackServiceWithStore(postgres.NewStore(db))
+}
+
+// NewCashbackServiceWithStore creates a cashback service backed by store
+func NewCashbackServiceWithStore(store repository.Store) *CashbackService {
 	return &CashbackService{
-		db:                     db,
-		statementLedgerService: NewStatementLedgerService(db),
+		store:                  store,
+		statementLedgerService: NewStatementLedgerServiceWithStore(store),
 	}
 }
 
+// withStore returns a copy of the service bound to store
+func (s *CashbackService) withStore(store repository.Store) *CashbackService {
+	return NewCashbackServiceWithStore(store)
+}
+
 // EarnCashbackRequest contains parameters for earning cashback on a transaction
 type EarnCashbackRequest struct {
 	TenantID          uuid.UUID
@@ -117,12 +129,12 @@ func (s *CashbackService) AdjustCashbackForRefund(
 ) (*models.CashbackLedgerEntry, error) {
 	// Find original cashback entry
 	originalEntry, err := s.getEntryByStatementEntryID(ctx, req.OriginalTransactionEntryID)
-	if err == sql.ErrNoRows {
-		return nil, nil // No cashback was earned on original transaction
-	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to find original cashback: %w", err)
 	}
+	if originalEntry == nil {
+		return nil, nil // No cashback was earned on original transaction
+	}
 
 	// Calculate proportional cashback to deduct
 	var deductAmount decimal.Decimal
@@ -180,31 +192,12 @@ func (s *CashbackService) RedeemCashback(
 	ctx context.Context,
 	req RedeemCashbackRequest,
 ) (*models.CashbackLedgerEntry, *models.StatementLedgerEntry, error) {
-	// Get current balance
-	balance, err := s.GetBalance(ctx, req.CreditCard.ID)
-	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get cashback balance: %w", err)
-	}
-
-	// Validate redemption
-	if req.Amount.GreaterThan(balance.AvailableBalance) {
-		return nil, nil, fmt.Errorf("insufficient cashback balance: available $%.2f, requested $%.2f",
-			balance.AvailableBalance.InexactFloat64(), req.Amount.InexactFloat64())
-	}
-
 	// Check minimum redemption amount
 	if req.Amount.LessThan(req.CreditCard.CashbackRedemptionMin) {
 		return nil, nil, fmt.Errorf("minimum redemption amount is $%.2f",
 			req.CreditCard.CashbackRedemptionMin.InexactFloat64())
 	}
 
-	// Start transaction for atomicity
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer tx.Rollback()
-
 	// Create cashback redemption entry (negative)
 	cashbackEntry := &models.CashbackLedgerEntry{
 		ID:           uuid.New(),
@@ -220,39 +213,57 @@ func (s *CashbackService) RedeemCashback(
 		CreatedAt: time.Now(),
 	}
 
-	if err := s.createEntry(ctx, cashbackEntry); err != nil {
-		return nil, nil, fmt.Errorf("failed to create cashback redemption entry: %w", err)
-	}
-
 	var statementEntry *models.StatementLedgerEntry
 
-	// If redeeming as statement credit, create corresponding statement entry
-	if req.RedeemAs == "statement_credit" {
-		statementEntry = &models.StatementLedgerEntry{
-			ID:          uuid.New(),
-			TenantID:    req.TenantID,
-			EntryType:   models.EntryTypeCashbackRedeemed,
-			EntryDate:   req.RedemptionDate,
-			PostingDate: req.RedemptionDate,
-			Amount:      req.Amount, // Credit amount (will be treated as credit)
-			Description: fmt.Sprintf("Cashback redemption - $%.2f applied", req.Amount.InexactFloat64()),
-			Status:      models.EntryStatusPending,
-			Metadata: map[string]interface{}{
-				"cashback_entry_id": cashbackEntry.ID.String(),
-			},
-			CreatedAt: time.Now(),
+	// Balance check and both entries run in one transaction for atomicity
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		// Get current balance
+		balance, err := txs.GetBalance(ctx, req.CreditCard.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get cashback balance: %w", err)
 		}
 
-		if err := s.statementLedgerService.CreateEntry(ctx, statementEntry); err != nil {
-			return nil, nil, fmt.Errorf("failed to create statement credit entry: %w", err)
+		// Validate redemption
+		if req.Amount.GreaterThan(balance.AvailableBalance) {
+			return fmt.Errorf("insufficient cashback balance: available $%.2f, requested $%.2f",
+				balance.AvailableBalance.InexactFloat64(), req.Amount.InexactFloat64())
 		}
 
-		// Link the two entries
-		cashbackEntry.StatementEntryID = &statementEntry.ID
-	}
+		// If redeeming as statement credit, create corresponding statement entry
+		if req.RedeemAs == "statement_credit" {
+			statementEntry = &models.StatementLedgerEntry{
+				ID:          uuid.New(),
+				TenantID:    req.TenantID,
+				EntryType:   models.EntryTypeCashbackRedeemed,
+				EntryDate:   req.RedemptionDate,
+				PostingDate: req.RedemptionDate,
+				Amount:      req.Amount, // Credit amount (will be treated as credit)
+				Description: fmt.Sprintf("Cashback redemption - $%.2f applied", req.Amount.InexactFloat64()),
+				Status:      models.EntryStatusPending,
+				Metadata: map[string]interface{}{
+					"cashback_entry_id": cashbackEntry.ID.String(),
+				},
+				CreatedAt: time.Now(),
+			}
+
+			if err := txs.statementLedgerService.CreateEntry(ctx, statementEntry); err != nil {
+				return fmt.Errorf("failed to create statement credit entry: %w", err)
+			}
+
+			// Link the two entries before the cashback entry is written
+			cashbackEntry.StatementEntryID = &statementEntry.ID
+		}
 
-	if err := tx.Commit(); err != nil {
-		return nil, nil, fmt.Errorf("failed to commit redemption: %w", err)
+		if err := txs.createEntry(ctx, cashbackEntry); err != nil {
+			return fmt.Errorf("failed to create cashback redemption entry: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return cashbackEntry, statementEntry, nil
@@ -263,40 +274,31 @@ func (s *CashbackService) GetBalance(
 	ctx context.Context,
 	creditCardID uuid.UUID,
 ) (*models.CashbackBalance, error) {
-	query := `
-		SELECT
-			tenant_id,
-			credit_card_id,
-			COALESCE(SUM(CASE WHEN entry_type = 'earned' THEN amount ELSE 0 END), 0) as earned_total,
-			COALESCE(SUM(CASE WHEN entry_type = 'redeemed' THEN ABS(amount) ELSE 0 END), 0) as redeemed_total,
-			COALESCE(SUM(CASE WHEN entry_type = 'expired' THEN ABS(amount) ELSE 0 END), 0) as expired_total,
-			COALESCE(SUM(amount), 0) as available_balance,
-			COUNT(*) as total_entries,
-			MAX(entry_date) as last_activity_date
-		FROM cashback_ledger_entries
-		WHERE credit_card_id = $1
-		GROUP BY tenant_id, credit_card_id
-	`
-
-	balance := &models.CashbackBalance{CreditCardID: creditCardID}
-	err := s.db.QueryRowContext(ctx, query, creditCardID).Scan(
-		&balance.TenantID,
-		&balance.CreditCardID,
-		&balance.EarnedTotal,
-		&balance.RedeemedTotal,
-		&balance.ExpiredTotal,
-		&balance.AvailableBalance,
-		&balance.TotalEntries,
-		&balance.LastActivityDate,
-	)
-
-	if err == sql.ErrNoRows {
-		return &models.CashbackBalance{CreditCardID: creditCardID}, nil
-	}
+	entries, err := s.store.CashbackEntries().List(ctx, repository.CashbackEntryFilter{
+		CreditCardID: &creditCardID,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get cashback balance: %w", err)
 	}
 
+	balance := &models.CashbackBalance{CreditCardID: creditCardID}
+	for _, entry := range entries {
+		balance.TenantID = entry.TenantID
+		switch entry.EntryType {
+		case models.CashbackEarned:
+			balance.EarnedTotal = balance.EarnedTotal.Add(entry.Amount)
+		case models.CashbackRedeemed:
+			balance.RedeemedTotal = balance.RedeemedTotal.Add(entry.Amount.Abs())
+		case models.CashbackExpired:
+			balance.ExpiredTotal = balance.ExpiredTotal.Add(entry.Amount.Abs())
+		}
+		balance.AvailableBalance = balance.AvailableBalance.Add(entry.GetSignedAmount())
+		balance.TotalEntries++
+		if entry.EntryDate.After(balance.LastActivityDate) {
+			balance.LastActivityDate = entry.EntryDate
+		}
+	}
+
 	return balance, nil
 }
 
@@ -312,27 +314,45 @@ func (s *CashbackService) GetCashbackStatement(
 		CycleEndDate:   endDate,
 	}
 
-	// Get summary totals
-	summaryQuery := `
-		SELECT
-			COALESCE(SUM(CASE WHEN entry_type = 'earned' THEN amount ELSE 0 END), 0) as earned,
-			COALESCE(SUM(CASE WHEN entry_type = 'redeemed' THEN ABS(amount) ELSE 0 END), 0) as redeemed,
-			COALESCE(SUM(CASE WHEN entry_type = 'earned' THEN transaction_amount ELSE 0 END), 0) as total_purchases
-		FROM cashback_ledger_entries
-		WHERE credit_card_id = $1
-		  AND entry_date >= $2
-		  AND entry_date <= $3
-	`
-
-	err := s.db.QueryRowContext(ctx, summaryQuery, creditCardID, startDate, endDate).Scan(
-		&statement.CashbackEarned,
-		&statement.CashbackRedeemed,
-		&statement.TotalPurchases,
-	)
-	if err != nil && err != sql.ErrNoRows {
+	entries, err := s.store.CashbackEntries().List(ctx, repository.CashbackEntryFilter{
+		CreditCardID: &creditCardID,
+		From:         &startDate,
+		To:           &endDate,
+	})
+	if err != nil {
 		return nil, fmt.Errorf("failed to get cashback summary: %w", err)
 	}
 
+	// Get summary totals and the per-category breakdown of earnings
+	categories := make(map[string]*models.CategoryCashbackSummary)
+	for _, entry := range entries {
+		switch entry.EntryType {
+		case models.CashbackEarned:
+			statement.CashbackEarned = statement.CashbackEarned.Add(entry.Amount)
+		case models.CashbackRedeemed:
+			statement.CashbackRedeemed = statement.CashbackRedeemed.Add(entry.Amount.Abs())
+			continue
+		default:
+			continue
+		}
+
+		code := "unknown"
+		if category, ok := entry.Metadata["merchant_category"].(string); ok {
+			code = category
+		}
+		summary, ok := categories[code]
+		if !ok {
+			summary = &models.CategoryCashbackSummary{CategoryCode: code}
+			categories[code] = summary
+		}
+		summary.TransactionCount++
+		summary.CashbackEarned = summary.CashbackEarned.Add(entry.Amount)
+		if entry.TransactionAmount != nil {
+			summary.TotalSpent = summary.TotalSpent.Add(*entry.TransactionAmount)
+			statement.TotalPurchases = statement.TotalPurchases.Add(*entry.TransactionAmount)
+		}
+	}
+
 	// Calculate effective rate
 	if statement.TotalPurchases.GreaterThan(decimal.Zero) {
 		statement.EffectiveRate = statement.CashbackEarned.
@@ -348,40 +368,7 @@ func (s *CashbackService) GetCashbackStatement(
 	}
 	statement.EndingBalance = balance.AvailableBalance
 
-	// Get category breakdown
-	categoryQuery := `
-		SELECT
-			COALESCE(metadata->>'merchant_category', 'unknown') as category_code,
-			COUNT(*) as tx_count,
-			COALESCE(SUM(transaction_amount), 0) as total_spent,
-			COALESCE(SUM(amount), 0) as cashback_earned
-		FROM cashback_ledger_entries
-		WHERE credit_card_id = $1
-		  AND entry_date >= $2
-		  AND entry_date <= $3
-		  AND entry_type = 'earned'
-		GROUP BY metadata->>'merchant_category'
-		ORDER BY cashback_earned DESC
-	`
-
-	rows, err := s.db.QueryContext(ctx, categoryQuery, creditCardID, startDate, endDate)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get category breakdown: %w", err)
-	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var summary models.CategoryCashbackSummary
-		err := rows.Scan(
-			&summary.CategoryCode,
-			&summary.TransactionCount,
-			&summary.TotalSpent,
-			&summary.CashbackEarned,
-		)
-		if err != nil {
-			return nil, err
-		}
-
+	for _, summary := range categories {
 		// Calculate effective rate for category
 		if summary.TotalSpent.GreaterThan(decimal.Zero) {
 			summary.EffectiveRate = summary.CashbackEarned.
@@ -390,10 +377,19 @@ func (s *CashbackService) GetCashbackStatement(
 				Round(2)
 		}
 
-		statement.CategoryBreakdown = append(statement.CategoryBreakdown, summary)
+		statement.CategoryBreakdown = append(statement.CategoryBreakdown, *summary)
 	}
 
-	return statement, rows.Err()
+	// Largest earning categories first
+	sort.Slice(statement.CategoryBreakdown, func(i, j int) bool {
+		a, b := statement.CategoryBreakdown[i], statement.CategoryBreakdown[j]
+		if !a.CashbackEarned.Equal(b.CashbackEarned) {
+			return a.CashbackEarned.GreaterThan(b.CashbackEarned)
+		}
+		return a.CategoryCode < b.CategoryCode
+	})
+
+	return statement, nil
 }
 
 // GetRecentEarnings returns recent cashback earnings for a card
@@ -402,48 +398,10 @@ func (s *CashbackService) GetRecentEarnings(
 	creditCardID uuid.UUID,
 	limit int,
 ) ([]*models.CashbackLedgerEntry, error) {
-	query := `
-		SELECT id, tenant_id, credit_card_id, statement_entry_id, entry_type,
-		       entry_date, amount, description, transaction_amount, cashback_rate,
-		       category_bonus, metadata, created_at, created_by
-		FROM cashback_ledger_entries
-		WHERE credit_card_id = $1
-		ORDER BY entry_date DESC, created_at DESC
-		LIMIT $2
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, creditCardID, limit)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var entries []*models.CashbackLedgerEntry
-	for rows.Next() {
-		entry := &models.CashbackLedgerEntry{}
-		err := rows.Scan(
-			&entry.ID,
-			&entry.TenantID,
-			&entry.CreditCardID,
-			&entry.StatementEntryID,
-			&entry.EntryType,
-			&entry.EntryDate,
-			&entry.Amount,
-			&entry.Description,
-			&entry.TransactionAmount,
-			&entry.CashbackRate,
-			&entry.CategoryBonus,
-			&entry.Metadata,
-			&entry.CreatedAt,
-			&entry.CreatedBy,
-		)
-		if err != nil {
-			return nil, err
-		}
-		entries = append(entries, entry)
-	}
-
-	return entries, rows.Err()
+	return s.store.CashbackEntries().List(ctx, repository.CashbackEntryFilter{
+		CreditCardID: &creditCardID,
+		Limit:        limit,
+	})
 }
 
 // SetCategoryBonusRate sets a bonus cashback rate for a merchant category
@@ -468,33 +426,7 @@ func (s *CashbackService) SetCategoryBonusRate(
 		EndDate:      endDate,
 	}
 
-	query := `
-		INSERT INTO cashback_categories (
-			id, credit_card_id, category_code, category_name, bonus_rate,
-			max_bonus, is_active, start_date, end_date
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
-		ON CONFLICT (credit_card_id, category_code)
-		DO UPDATE SET
-			category_name = EXCLUDED.category_name,
-			bonus_rate = EXCLUDED.bonus_rate,
-			max_bonus = EXCLUDED.max_bonus,
-			is_active = EXCLUDED.is_active,
-			start_date = EXCLUDED.start_date,
-			end_date = EXCLUDED.end_date
-	`
-
-	_, err := s.db.ExecContext(ctx, query,
-		category.ID,
-		category.CreditCardID,
-		category.CategoryCode,
-		category.CategoryName,
-		category.BonusRate,
-		category.MaxBonus,
-		category.IsActive,
-		category.StartDate,
-		category.EndDate,
-	)
-
+	err := s.store.CashbackEntries().SaveCategory(ctx, category)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set category bonus: %w", err)
 	}
@@ -508,117 +440,56 @@ func (s *CashbackService) getEarningRule(
 	creditCardID uuid.UUID,
 ) (*models.CashbackEarningRule, error) {
 	// Get card's base cashback rate
-	cardQuery := `SELECT cashback_rate FROM credit_cards WHERE id = $1`
-	var baseRate decimal.Decimal
-	err := s.db.QueryRowContext(ctx, cardQuery, creditCardID).Scan(&baseRate)
+	card, err := s.store.CreditCards().GetByID(ctx, creditCardID)
 	if err != nil {
 		return nil, err
 	}
 
 	rule := &models.CashbackEarningRule{
-		BaseRate:       baseRate,
+		BaseRate:       card.CashbackRate,
 		MinTransaction: decimal.NewFromFloat(0.01),
 		CategoryRules:  make(map[string]models.CashbackCategory),
 	}
 
 	// Get category bonuses
-	categoryQuery := `
-		SELECT id, credit_card_id, category_code, category_name, bonus_rate,
-		       max_bonus, is_active, start_date, end_date
-		FROM cashback_categories
-		WHERE credit_card_id = $1 AND is_active = true
-	`
-
-	rows, err := s.db.QueryContext(ctx, categoryQuery, creditCardID)
+	categories, err := s.store.CashbackEntries().ListCategories(ctx, creditCardID)
 	if err != nil {
 		return rule, nil // Return base rule if categories fail
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var cat models.CashbackCategory
-		err := rows.Scan(
-			&cat.ID,
-			&cat.CreditCardID,
-			&cat.CategoryCode,
-			&cat.CategoryName,
-			&cat.BonusRate,
-			&cat.MaxBonus,
-			&cat.IsActive,
-			&cat.StartDate,
-			&cat.EndDate,
-		)
-		if err != nil {
-			continue
+
+	for _, cat := range categories {
+		if cat.IsActive {
+			rule.CategoryRules[cat.CategoryCode] = *cat
 		}
-		rule.CategoryRules[cat.CategoryCode] = cat
 	}
 
 	return rule, nil
 }
 
 // getEntryByStatementEntryID finds a cashback entry by its linked statement entry
+// Returns nil when no cashback entry is linked to it
 func (s *CashbackService) getEntryByStatementEntryID(
 	ctx context.Context,
 	statementEntryID uuid.UUID,
 ) (*models.CashbackLedgerEntry, error) {
-	query := `
-		SELECT id, tenant_id, credit_card_id, statement_entry_id, entry_type,
-		       entry_date, amount, description, transaction_amount, cashback_rate,
-		       category_bonus, metadata, created_at, created_by
-		FROM cashback_ledger_entries
-		WHERE statement_entry_id = $1
-		LIMIT 1
-	`
-
-	entry := &models.CashbackLedgerEntry{}
-	err := s.db.QueryRowContext(ctx, query, statementEntryID).Scan(
-		&entry.ID,
-		&entry.TenantID,
-		&entry.CreditCardID,
-		&entry.StatementEntryID,
-		&entry.EntryType,
-		&entry.EntryDate,
-		&entry.Amount,
-		&entry.Description,
-		&entry.TransactionAmount,
-		&entry.CashbackRate,
-		&entry.CategoryBonus,
-		&entry.Metadata,
-		&entry.CreatedAt,
-		&entry.CreatedBy,
-	)
+	entries, err := s.store.CashbackEntries().List(ctx, repository.CashbackEntryFilter{
+		StatementEntryID: &statementEntryID,
+	})
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
 
-	return entry, err
+	return entries[0], nil
 }
 
-// createEntry inserts a new cashback ledger entry
+// createEntry inserts a new cashback ledger entry and its general ledger posting
 func (s *CashbackService) createEntry(ctx context.Context, entry *models.CashbackLedgerEntry) error {
-	query := `
-		INSERT INTO cashback_ledger_entries (
-			id, tenant_id, credit_card_id, statement_entry_id, entry_type,
-			entry_date, amount, description, reference_id, transaction_amount,
-			cashback_rate, category_bonus, metadata, created_at, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-	`
-
-	_, err := s.db.ExecContext(ctx, query,
-		entry.ID,
-		entry.TenantID,
-		entry.CreditCardID,
-		entry.StatementEntryID,
-		entry.EntryType,
-		entry.EntryDate,
-		entry.Amount,
-		entry.Description,
-		entry.ReferenceID,
-		entry.TransactionAmount,
-		entry.CashbackRate,
-		entry.CategoryBonus,
-		entry.Metadata,
-		entry.CreatedAt,
-		entry.CreatedBy,
-	)
+	return s.store.WithinTx(ctx, func(tx repository.Store) error {
+		if err := tx.CashbackEntries().Create(ctx, entry); err != nil {
+			return err
+		}
 
-	return err
+		_, err := NewGeneralLedgerServiceWithStore(tx).PostCashbackEntry(ctx, entry)
+		return err
+	})
 }
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ClearingFormat names a clearing file layout
+type ClearingFormat string
+
+const (
+	ClearingFixedWidth ClearingFormat = "fixed"
+	ClearingCSV        ClearingFormat = "csv"
+)
+
+// ClearingRecordType is the kind of settled activity a record carries
+type ClearingRecordType string
+
+const (
+	ClearingSale   ClearingRecordType = "sale"
+	ClearingRefund ClearingRecordType = "refund"
+)
+
+// ClearingRecord is one settled sale or refund from a processor's clearing file
+type ClearingRecord struct {
+	Line                int                `json:"line"` // 1-based line in the file
+	Type                ClearingRecordType `json:"type"`
+	CreditCardID        uuid.UUID          `json:"credit_card_id"`
+	ReferenceID         string             `json:"reference_id"` // Acquirer reference, shared with the authorization
+	Amount              decimal.Decimal    `json:"amount"`
+	TransactionDate     time.Time          `json:"transaction_date"`
+	SettlementDate      time.Time          `json:"settlement_date"`
+	MerchantCategory    string             `json:"merchant_category,omitempty"`
+	MerchantName        string             `json:"merchant_name"`
+	OriginalReferenceID string             `json:"original_reference_id,omitempty"` // The sale a refund returns
+}
+
+// Fixed-width layout, one record per line. Positions are 1-based and
+// inclusive; amounts are in cents and dates are YYYYMMDD.
+//
+//	1-2      record type: 05 sale, 06 refund
+//	3-38     credit card ID
+//	39-61    reference ID
+//	62-73    amount
+//	74-81    transaction date
+//	82-89    settlement date
+//	90-93    merchant category code
+//	94-118   merchant name
+//	119-141  original reference ID (refunds)
+const clearingFixedWidthLength = 141
+
+var clearingFixedWidthTypes = map[string]ClearingRecordType{
+	"05": ClearingSale,
+	"06": ClearingRefund,
+}
+
+// clearingCSVColumns are the columns a CSV clearing file must name in its header row
+var clearingCSVColumns = []string{
+	"record_type", "card_id", "reference_id", "amount",
+	"transaction_date", "settlement_date",
+	"merchant_category", "merchant_name", "original_reference_id",
+}
+
+// ParseClearingFile reads every record in a clearing file. A record that
+// cannot be parsed is returned as an invalid exception rather than failing
+// the file; the error is only for a file that cannot be read at all.
+func ParseClearingFile(r io.Reader, format ClearingFormat) ([]ClearingRecord, []ClearingException, error) {
+	switch format {
+	case ClearingFixedWidth:
+		return parseClearingFixedWidth(r)
+	case ClearingCSV:
+		return parseClearingCSV(r)
+	default:
+		return nil, nil, fmt.Errorf("unsupported clearing format: %s", format)
+	}
+}
+
+func parseClearingFixedWidth(r io.Reader) ([]ClearingRecord, []ClearingException, error) {
+	var records []ClearingRecord
+	var exceptions []ClearingException
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		record, err := parseClearingFixedWidthLine(line, text)
+		if err != nil {
+			exceptions = append(exceptions, invalidClearingRecord(line, err))
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("failed to read clearing file: %w", err)
+	}
+
+	return records, exceptions, nil
+}
+
+func parseClearingFixedWidthLine(line int, text string) (ClearingRecord, error) {
+	if len(text) > clearingFixedWidthLength {
+		return ClearingRecord{}, fmt.Errorf("record is %d characters, want at most %d", len(text), clearingFixedWidthLength)
+	}
+	// Trailing blank fields may be trimmed
+	text += strings.Repeat(" ", clearingFixedWidthLength-len(text))
+	field := func(start, end int) string {
+		return strings.TrimSpace(text[start-1 : end])
+	}
+
+	recordType, ok := clearingFixedWidthTypes[field(1, 2)]
+	if !ok {
+		return ClearingRecord{}, fmt.Errorf("unknown record type %q", field(1, 2))
+	}
+
+	cents, err := decimal.NewFromString(field(62, 73))
+	if err != nil {
+		return ClearingRecord{}, fmt.Errorf("invalid amount %q", field(62, 73))
+	}
+
+	return newClearingRecord(line, recordType, clearingFields{
+		cardID:              field(3, 38),
+		referenceID:         field(39, 61),
+		amount:              cents.Shift(-2),
+		transactionDate:     field(74, 81),
+		settlementDate:      field(82, 89),
+		merchantCategory:    field(90, 93),
+		merchantName:        field(94, 118),
+		originalReferenceID: field(119, 141),
+		dateLayout:          "20060102",
+	})
+}
+
+func parseClearingCSV(r io.Reader) ([]ClearingRecord, []ClearingException, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read clearing file header: %w", err)
+	}
+	column := make(map[string]int, len(header))
+	for i, name := range header {
+		column[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range clearingCSVColumns {
+		if _, ok := column[name]; !ok {
+			return nil, nil, fmt.Errorf("clearing file header has no %s column", name)
+		}
+	}
+
+	var records []ClearingRecord
+	var exceptions []ClearingException
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, fmt.Errorf("failed to read clearing file: %w", err)
+			}
+			exceptions = append(exceptions, invalidClearingRecord(line, err))
+			continue
+		}
+
+		field := func(name string) string {
+			if i := column[name]; i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		recordType := ClearingRecordType(strings.ToLower(field("record_type")))
+		if recordType != ClearingSale && recordType != ClearingRefund {
+			exceptions = append(exceptions, invalidClearingRecord(line, fmt.Errorf("unknown record type %q", field("record_type"))))
+			continue
+		}
+		amount, err := decimal.NewFromString(field("amount"))
+		if err != nil {
+			exceptions = append(exceptions, invalidClearingRecord(line, fmt.Errorf("invalid amount %q", field("amount"))))
+			continue
+		}
+
+		record, err := newClearingRecord(line, recordType, clearingFields{
+			cardID:              field("card_id"),
+			referenceID:         field("reference_id"),
+			amount:              amount,
+			transactionDate:     field("transaction_date"),
+			settlementDate:      field("settlement_date"),
+			merchantCategory:    field("merchant_category"),
+			merchantName:        field("merchant_name"),
+			originalReferenceID: field("original_reference_id"),
+			dateLayout:          "2006-01-02",
+		})
+		if err != nil {
+			exceptions = append(exceptions, invalidClearingRecord(line, err))
+			continue
+		}
+		records = append(records, record)
+	}
+
+	return records, exceptions, nil
+}
+
+// clearingFields are a record's raw fields, shared by both layouts
+type clearingFields struct {
+	cardID              string
+	referenceID         string
+	amount              decimal.Decimal
+	transactionDate     string
+	settlementDate      string
+	merchantCategory    string
+	merchantName        string
+	originalReferenceID string
+	dateLayout          string
+}
+
+// newClearingRecord validates raw fields into a record
+func newClearingRecord(line int, recordType ClearingRecordType, f clearingFields) (ClearingRecord, error) {
+	cardID, err := uuid.Parse(f.cardID)
+	if err != nil {
+		return ClearingRecord{}, fmt.Errorf("invalid card ID %q", f.cardID)
+	}
+	if f.referenceID == "" {
+		return ClearingRecord{}, errors.New("missing reference ID")
+	}
+	if !f.amount.IsPositive() {
+		return ClearingRecord{}, fmt.Errorf("amount must be positive, got %s", f.amount)
+	}
+	transactionDate, err := time.Parse(f.dateLayout, f.transactionDate)
+	if err != nil {
+		return ClearingRecord{}, fmt.Errorf("invalid transaction date %q", f.transactionDate)
+	}
+	settlementDate, err := time.Parse(f.dateLayout, f.settlementDate)
+	if err != nil {
+		return ClearingRecord{}, fmt.Errorf("invalid settlement date %q", f.settlementDate)
+	}
+
+	return ClearingRecord{
+		Line:                line,
+		Type:                recordType,
+		CreditCardID:        cardID,
+		ReferenceID:         f.referenceID,
+		Amount:              f.amount,
+		TransactionDate:     transactionDate,
+		SettlementDate:      settlementDate,
+		MerchantCategory:    f.merchantCategory,
+		MerchantName:        f.merchantName,
+		OriginalReferenceID: f.originalReferenceID,
+	}, nil
+}
+
+func invalidClearingRecord(line int, err error) ClearingException {
+	return ClearingException{Line: line, Kind: ClearingExceptionInvalid, Reason: err.Error()}
+}
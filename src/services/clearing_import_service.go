@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// ClearingExceptionKind says why a clearing record was not applied
+type ClearingExceptionKind string
+
+const (
+	ClearingExceptionInvalid        ClearingExceptionKind = "invalid"         // Record could not be parsed
+	ClearingExceptionDuplicate      ClearingExceptionKind = "duplicate"       // Already in the file or already cleared
+	ClearingExceptionUnmatched      ClearingExceptionKind = "unmatched"       // No authorization, pending entry or original sale
+	ClearingExceptionAmountMismatch ClearingExceptionKind = "amount_mismatch" // Pending entry is for a different amount
+	ClearingExceptionRejected       ClearingExceptionKind = "rejected"        // Posting failed, e.g. the card is closed
+	ClearingExceptionAmbiguous      ClearingExceptionKind = "ambiguous"       // Reference could match another card's entry
+)
+
+// ClearingOutcome says how a clearing record was applied
+type ClearingOutcome string
+
+const (
+	ClearingCaptured ClearingOutcome = "captured" // Captured an open authorization
+	ClearingCleared  ClearingOutcome = "cleared"  // Cleared a pending entry
+	ClearingRefunded ClearingOutcome = "refunded" // Refund posted against the original sale
+	ClearingPosted   ClearingOutcome = "posted"   // Sale posted without an authorization
+)
+
+// ClearingException is a clearing record left for manual review
+type ClearingException struct {
+	Line        int                   `json:"line"`
+	ReferenceID string                `json:"reference_id,omitempty"`
+	Kind        ClearingExceptionKind `json:"kind"`
+	Reason      string                `json:"reason"`
+	Record      *ClearingRecord       `json:"record,omitempty"` // Nil when the record could not be parsed
+}
+
+// ClearingImportRequest contains parameters for importing a clearing file
+type ClearingImportRequest struct {
+	Format ClearingFormat
+
+	// PostUnmatched posts sales with no authorization or pending entry as
+	// new transactions instead of reporting them as unmatched
+	PostUnmatched bool
+}
+
+// ClearingImportReport summarizes an imported clearing file
+type ClearingImportReport struct {
+	Records    int                     `json:"records"`
+	Applied    map[ClearingOutcome]int `json:"applied"`
+	Settled    decimal.Decimal         `json:"settled"` // Sales less refunds applied
+	Exceptions []ClearingException     `json:"exceptions"`
+	ImportedAt time.Time               `json:"imported_at"`
+}
+
+// ClearingImportService applies processor clearing files to the statement ledger
+type ClearingImportService struct {
+	store                  repository.Store
+	statementLedgerService *StatementLedgerService
+	creditCardService      *CreditCardService
+	authorizationService   *AuthorizationService
+}
+
+// NewClearingImportService creates a new clearing import service
+func NewClearingImportService(db Querier) *ClearingImportService {
+	return NewClearingImportServiceWithStore(postgres.NewStore(db))
+}
+
+// NewClearingImportServiceWithStore creates a clearing import service backed by store
+func NewClearingImportServiceWithStore(store repository.Store) *ClearingImportService {
+	return &ClearingImportService{
+		store:                  store,
+		statementLedgerService: NewStatementLedgerServiceWithStore(store),
+		creditCardService:      NewCreditCardServiceWithStore(store),
+		authorizationService:   NewAuthorizationServiceWithStore(store),
+	}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *ClearingImportService) withStore(store repository.Store) *ClearingImportService {
+	return NewClearingImportServiceWithStore(store)
+}
+
+// Import applies every record in a clearing file and reports the ones it
+// could not apply. Records are matched by reference ID:
+//
+//   - A sale captures the open authorization with its reference, or clears
+//     the pending transaction with it.
+//   - A refund clears the pending refund with its reference, or is posted
+//     against the sale named by its original reference.
+//
+// Each record is applied in its own transaction, so one bad record does not
+// hold up the rest of the file. Importing a file again reports every record
+// already cleared as a duplicate.
+func (s *ClearingImportService) Import(
+	ctx context.Context,
+	r io.Reader,
+	req ClearingImportRequest,
+) (*ClearingImportReport, error) {
+	records, exceptions, err := ParseClearingFile(r, req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ClearingImportReport{
+		Records:    len(records) + len(exceptions),
+		Applied:    make(map[ClearingOutcome]int),
+		Settled:    decimal.Zero,
+		Exceptions: exceptions,
+		ImportedAt: time.Now(),
+	}
+
+	type recordKey struct {
+		recordType  ClearingRecordType
+		cardID      uuid.UUID
+		referenceID string
+	}
+	seen := make(map[recordKey]int)
+
+	for i := range records {
+		record := &records[i]
+		except := func(kind ClearingExceptionKind, reason string) {
+			report.Exceptions = append(report.Exceptions, ClearingException{
+				Line:        record.Line,
+				ReferenceID: record.ReferenceID,
+				Kind:        kind,
+				Reason:      reason,
+				Record:      record,
+			})
+		}
+
+		key := recordKey{record.Type, record.CreditCardID, record.ReferenceID}
+		if first, ok := seen[key]; ok {
+			except(ClearingExceptionDuplicate, fmt.Sprintf("repeats line %d", first))
+			continue
+		}
+		seen[key] = record.Line
+
+		var outcome ClearingOutcome
+		var exception *ClearingException
+		err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+			var err error
+			outcome, exception, err = s.withStore(tx).applyRecord(ctx, record, req)
+			if exception != nil {
+				// Nothing to keep; roll back any partial work
+				return errClearingException
+			}
+			return err
+		})
+		switch {
+		case exception != nil:
+			except(exception.Kind, exception.Reason)
+		case err != nil:
+			except(ClearingExceptionRejected, err.Error())
+		default:
+			report.Applied[outcome]++
+			if record.Type == ClearingRefund {
+				report.Settled = report.Settled.Sub(record.Amount)
+			} else {
+				report.Settled = report.Settled.Add(record.Amount)
+			}
+		}
+	}
+
+	// Parse failures were collected first; report everything in file order
+	sort.SliceStable(report.Exceptions, func(i, j int) bool {
+		return report.Exceptions[i].Line < report.Exceptions[j].Line
+	})
+
+	return report, nil
+}
+
+// errClearingException rolls back a record that became an exception
+var errClearingException = errors.New("clearing record not applied")
+
+// applyRecord applies one record, or returns the exception it raises
+func (s *ClearingImportService) applyRecord(
+	ctx context.Context,
+	record *ClearingRecord,
+	req ClearingImportRequest,
+) (ClearingOutcome, *ClearingException, error) {
+	card, err := s.store.CreditCards().GetForUpdate(ctx, record.CreditCardID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return "", &ClearingException{Kind: ClearingExceptionUnmatched, Reason: fmt.Sprintf("unknown card %s", record.CreditCardID)}, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to lock credit card: %w", err)
+	}
+
+	entryTypes := []models.StatementEntryType{models.EntryTypeTransaction}
+	if record.Type == ClearingRefund {
+		entryTypes = []models.StatementEntryType{models.EntryTypeRefund}
+	}
+
+	// Anything already cleared with this reference was settled by an earlier file
+	cleared, exception, err := s.entriesByReference(ctx, card, record.ReferenceID, entryTypes, models.EntryStatusCleared)
+	if exception != nil || err != nil {
+		return "", exception, err
+	}
+	if len(cleared) > 0 {
+		return "", &ClearingException{Kind: ClearingExceptionDuplicate, Reason: fmt.Sprintf("entry %s is already cleared", cleared[0].ID)}, nil
+	}
+
+	if record.Type == ClearingSale {
+		captured, err := s.captureAuthorization(ctx, card, record)
+		if err != nil {
+			return "", nil, err
+		}
+		if captured {
+			return ClearingCaptured, nil, nil
+		}
+	}
+
+	pending, exception, err := s.entriesByReference(ctx, card, record.ReferenceID, entryTypes, models.EntryStatusPending)
+	if exception != nil || err != nil {
+		return "", exception, err
+	}
+	if len(pending) > 0 {
+		entry := pending[0]
+		if !entry.Amount.Abs().Equal(record.Amount) {
+			return "", &ClearingException{
+				Kind:   ClearingExceptionAmountMismatch,
+				Reason: fmt.Sprintf("pending entry %s is for %s, record is for %s", entry.ID, entry.Amount.Abs(), record.Amount),
+			}, nil
+		}
+		if err := s.statementLedgerService.ClearEntry(ctx, entry.ID); err != nil {
+			return "", nil, err
+		}
+		return ClearingCleared, nil, nil
+	}
+
+	if record.Type == ClearingRefund {
+		return s.postRefund(ctx, card, record)
+	}
+
+	if !req.PostUnmatched {
+		return "", &ClearingException{Kind: ClearingExceptionUnmatched, Reason: "no open authorization or pending transaction"}, nil
+	}
+	transaction, err := s.creditCardService.RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:       card,
+		Amount:           record.Amount,
+		Description:      record.MerchantName,
+		MerchantName:     record.MerchantName,
+		MerchantCategory: record.MerchantCategory,
+		TransactionDate:  record.TransactionDate,
+		PostingDate:      record.SettlementDate,
+		ReferenceID:      record.ReferenceID,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.statementLedgerService.ClearEntry(ctx, transaction.TransactionEntry.ID); err != nil {
+		return "", nil, err
+	}
+	return ClearingPosted, nil, nil
+}
+
+// captureAuthorization captures and clears the card's open authorization
+// with the record's reference. The capture is final; whatever is left of
+// the hold is released. Returns false if there is no such authorization.
+func (s *ClearingImportService) captureAuthorization(
+	ctx context.Context,
+	card *models.CreditCard,
+	record *ClearingRecord,
+) (bool, error) {
+	authorizations, err := s.store.Authorizations().List(ctx, repository.AuthorizationFilter{
+		CreditCardID: &card.ID,
+		ReferenceID:  &record.ReferenceID,
+		// An expired hold can still be captured late
+		Statuses: []models.AuthorizationStatus{models.AuthorizationStatusActive, models.AuthorizationStatusExpired},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to list authorizations: %w", err)
+	}
+	if len(authorizations) == 0 {
+		return false, nil
+	}
+
+	result, err := s.authorizationService.CaptureAuthorization(ctx, CaptureRequest{
+		AuthorizationID: authorizations[0].ID,
+		Amount:          record.Amount,
+		Description:     record.MerchantName,
+		PostingDate:     record.SettlementDate,
+		Final:           true,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := s.statementLedgerService.ClearEntry(ctx, result.Transaction.TransactionEntry.ID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// postRefund posts and clears a refund of the sale named by the record's
+// original reference
+func (s *ClearingImportService) postRefund(
+	ctx context.Context,
+	card *models.CreditCard,
+	record *ClearingRecord,
+) (ClearingOutcome, *ClearingException, error) {
+	if record.OriginalReferenceID == "" {
+		return "", &ClearingException{Kind: ClearingExceptionUnmatched, Reason: "refund names no original reference"}, nil
+	}
+	originals, exception, err := s.entriesByReference(ctx, card, record.OriginalReferenceID,
+		[]models.StatementEntryType{models.EntryTypeTransaction},
+		models.EntryStatusPending, models.EntryStatusCleared)
+	if exception != nil || err != nil {
+		return "", exception, err
+	}
+	if len(originals) == 0 {
+		return "", &ClearingException{
+			Kind:   ClearingExceptionUnmatched,
+			Reason: fmt.Sprintf("no sale with reference %s", record.OriginalReferenceID),
+		}, nil
+	}
+
+	refund, err := s.creditCardService.RecordRefund(ctx, CCRefundRequest{
+		CreditCard:            card,
+		OriginalTransactionID: originals[0].ID,
+		RefundAmount:          record.Amount,
+		RefundDate:            record.TransactionDate,
+		PostingDate:           record.SettlementDate,
+		MerchantName:          record.MerchantName,
+		ReferenceID:           record.ReferenceID,
+		Description:           "clearing " + record.ReferenceID,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if err := s.statementLedgerService.ClearEntry(ctx, refund.RefundEntry.ID); err != nil {
+		return "", nil, err
+	}
+	return ClearingRefunded, nil, nil
+}
+
+// entriesByReference lists the card's entries with a reference ID.
+//
+// Statement entries belong to a tenant rather than a card, so they can be
+// attributed to the card only when it is its tenant's one card. Otherwise a
+// reused processor reference could match another card's entry, and the record
+// is left for review.
+func (s *ClearingImportService) entriesByReference(
+	ctx context.Context,
+	card *models.CreditCard,
+	referenceID string,
+	entryTypes []models.StatementEntryType,
+	statuses ...models.EntryStatus,
+) ([]*models.StatementLedgerEntry, *ClearingException, error) {
+	tenantCards, err := s.store.CreditCards().List(ctx, repository.CreditCardFilter{TenantID: &card.TenantID})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list tenant cards: %w", err)
+	}
+	if len(tenantCards) > 1 {
+		return nil, &ClearingException{
+			Kind: ClearingExceptionAmbiguous,
+			Reason: fmt.Sprintf("tenant %s has %d cards; its statement entries cannot be attributed to card %s",
+				card.TenantID, len(tenantCards), card.ID),
+		}, nil
+	}
+
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:    &card.TenantID,
+		ReferenceID: &referenceID,
+		EntryTypes:  entryTypes,
+		Statuses:    statuses,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list statement entries: %w", err)
+	}
+	return entries, nil, nil
+}
+
+// WriteExceptionsCSV writes the report's exceptions, one row per record
+func (r *ClearingImportReport) WriteExceptionsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"line", "kind", "reference_id", "record_type", "card_id", "amount", "reason"}); err != nil {
+		return err
+	}
+
+	for _, exception := range r.Exceptions {
+		row := []string{strconv.Itoa(exception.Line), string(exception.Kind), exception.ReferenceID, "", "", "", exception.Reason}
+		if record := exception.Record; record != nil {
+			row[3] = string(record.Type)
+			row[4] = record.CreditCardID.String()
+			row[5] = record.Amount.StringFixed(2)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+// clearingLine is a clearing record before it is laid out in a file
+type clearingLine struct {
+	recordType string // sale or refund
+	cardID     uuid.UUID
+	reference  string
+	cents      int64
+	original   string
+}
+
+func (l clearingLine) fixedWidth() string {
+	code := map[string]string{"sale": "05", "refund": "06"}[l.recordType]
+	return fmt.Sprintf("%-2s%-36s%-23s%012d%-8s%-8s%-4s%-25s%-23s",
+		code, l.cardID, l.reference, l.cents, "20260310", "20260311", "5812", "Test Merchant", l.original)
+}
+
+func (l clearingLine) csv() string {
+	return fmt.Sprintf("%s,%s,%s,%s,2026-03-10,2026-03-11,5812,Test Merchant,%s",
+		l.recordType, l.cardID, l.reference, decimal.New(l.cents, -2).StringFixed(2), l.original)
+}
+
+func TestClearingImportService_Import(t *testing.T) {
+	day := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	for _, format := range []ClearingFormat{ClearingFixedWidth, ClearingCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+
+			// An open authorization and two pending transactions to match against
+			authorized, err := NewAuthorizationServiceWithStore(store).Authorize(ctx, AuthorizeRequest{
+				CreditCard:   card,
+				Amount:       decimal.NewFromInt(200),
+				MerchantName: "Test Restaurant",
+				ReferenceID:  "REF-AUTH",
+				AuthorizedAt: day,
+			})
+			if err != nil {
+				t.Fatalf("failed to authorize: %v", err)
+			}
+			cardService := NewCreditCardServiceWithStore(store)
+			for _, pending := range []struct {
+				reference string
+				amount    int64
+			}{{"REF-PENDING", 50}, {"REF-MISMATCH", 75}} {
+				_, err := cardService.RecordTransaction(ctx, CCTransactionRequest{
+					CreditCard:      card,
+					Amount:          decimal.NewFromInt(pending.amount),
+					MerchantName:    "Test Merchant",
+					TransactionDate: day,
+					PostingDate:     day,
+					ReferenceID:     pending.reference,
+				})
+				if err != nil {
+					t.Fatalf("failed to record transaction: %v", err)
+				}
+			}
+
+			lines := []clearingLine{
+				{"sale", card.ID, "REF-AUTH", 24000, ""},    // Captured with a tip
+				{"sale", card.ID, "REF-PENDING", 5000, ""},  // Clears the pending entry
+				{"sale", card.ID, "REF-MISMATCH", 8000, ""}, // Pending entry is for 75
+				{"sale", card.ID, "REF-UNKNOWN", 1000, ""},  // Nothing to match
+				{"refund", card.ID, "REF-REFUND", 2000, "REF-PENDING"},
+				{"sale", card.ID, "REF-PENDING", 5000, ""},          // Repeats line 2
+				{"sale", uuid.New(), "REF-OTHER", 1000, ""},         // Unknown card
+				{"refund", card.ID, "REF-ORPHAN", 1000, "REF-NONE"}, // No such sale
+			}
+			var file strings.Builder
+			if format == ClearingCSV {
+				file.WriteString(strings.Join(clearingCSVColumns, ",") + "\n")
+			}
+			for _, line := range lines {
+				if format == ClearingCSV {
+					file.WriteString(line.csv() + "\n")
+				} else {
+					file.WriteString(line.fixedWidth() + "\n")
+				}
+			}
+			file.WriteString("99 not a clearing record\n")
+			offset := 0 // Line of the first record
+			if format == ClearingCSV {
+				offset = 1
+			}
+
+			service := NewClearingImportServiceWithStore(store)
+			report, err := service.Import(ctx, strings.NewReader(file.String()), ClearingImportRequest{Format: format})
+			if err != nil {
+				t.Fatalf("import failed: %v", err)
+			}
+
+			if report.Records != 9 {
+				t.Errorf("records = %d, want 9", report.Records)
+			}
+			wantApplied := map[ClearingOutcome]int{ClearingCaptured: 1, ClearingCleared: 1, ClearingRefunded: 1}
+			for outcome, want := range wantApplied {
+				if report.Applied[outcome] != want {
+					t.Errorf("%s = %d, want %d", outcome, report.Applied[outcome], want)
+				}
+			}
+			if !report.Settled.Equal(decimal.NewFromInt(270)) {
+				t.Errorf("settled = %s, want 270", report.Settled)
+			}
+
+			wantExceptions := []struct {
+				line int
+				kind ClearingExceptionKind
+			}{
+				{3, ClearingExceptionAmountMismatch},
+				{4, ClearingExceptionUnmatched},
+				{6, ClearingExceptionDuplicate},
+				{7, ClearingExceptionUnmatched},
+				{8, ClearingExceptionUnmatched},
+				{9, ClearingExceptionInvalid},
+			}
+			if len(report.Exceptions) != len(wantExceptions) {
+				t.Fatalf("exceptions = %+v, want %d", report.Exceptions, len(wantExceptions))
+			}
+			for i, want := range wantExceptions {
+				got := report.Exceptions[i]
+				if got.Line != want.line+offset || got.Kind != want.kind {
+					t.Errorf("exception %d = line %d %s, want line %d %s", i, got.Line, got.Kind, want.line+offset, want.kind)
+				}
+			}
+
+			auth, _ := store.Authorizations().GetByID(ctx, authorized.Authorization.ID)
+			if auth.Status != models.AuthorizationStatusCaptured {
+				t.Errorf("authorization status = %s, want captured", auth.Status)
+			}
+
+			// 5000 less the 240 capture, 50 and 75 purchases, plus the 20 refund
+			updated, _ := store.CreditCards().GetByID(ctx, card.ID)
+			if !updated.AvailableCredit.Equal(decimal.NewFromInt(4655)) {
+				t.Errorf("available credit = %s, want 4655", updated.AvailableCredit)
+			}
+
+			pending, _ := store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+				TenantID: &card.TenantID,
+				Statuses: []models.EntryStatus{models.EntryStatusPending},
+			})
+			if len(pending) != 1 || *pending[0].ReferenceID != "REF-MISMATCH" {
+				t.Errorf("pending entries = %d, want only the mismatched one", len(pending))
+			}
+
+			// A second import of the same file settles nothing
+			again, err := service.Import(ctx, strings.NewReader(file.String()), ClearingImportRequest{Format: format})
+			if err != nil {
+				t.Fatalf("second import failed: %v", err)
+			}
+			if len(again.Applied) != 0 || !again.Settled.IsZero() {
+				t.Errorf("second import applied %v", again.Applied)
+			}
+			duplicates := 0
+			for _, exception := range again.Exceptions {
+				if exception.Kind == ClearingExceptionDuplicate {
+					duplicates++
+				}
+			}
+			if duplicates != 4 {
+				t.Errorf("second import found %d duplicates, want 4", duplicates)
+			}
+
+			var out bytes.Buffer
+			if err := again.WriteExceptionsCSV(&out); err != nil {
+				t.Fatalf("failed to write exceptions: %v", err)
+			}
+			if rows := strings.Count(out.String(), "\n"); rows != len(again.Exceptions)+1 {
+				t.Errorf("exceptions CSV has %d rows, want %d", rows, len(again.Exceptions)+1)
+			}
+		})
+	}
+}
+
+func TestClearingImportService_PostUnmatched(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	file := clearingLine{"sale", card.ID, "REF-FORCE", 12345, ""}.fixedWidth()
+	report, err := NewClearingImportServiceWithStore(store).Import(ctx, strings.NewReader(file), ClearingImportRequest{
+		Format:        ClearingFixedWidth,
+		PostUnmatched: true,
+	})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if report.Applied[ClearingPosted] != 1 || len(report.Exceptions) != 0 {
+		t.Fatalf("applied = %v, exceptions = %+v", report.Applied, report.Exceptions)
+	}
+
+	entries, _ := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+	if len(entries) != 1 || entries[0].Status != models.EntryStatusCleared || !entries[0].Amount.Equal(decimal.NewFromFloat(123.45)) {
+		t.Errorf("posted entries = %+v, want one cleared entry of 123.45", entries)
+	}
+}
+
+func TestClearingImportService_SharedTenantReference(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	day := time.Date(2026, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+	card := testCard()
+	other := testCard()
+	other.TenantID = card.TenantID
+	for _, c := range []*models.CreditCard{card, other} {
+		if err := store.CreditCards().Create(ctx, c); err != nil {
+			t.Fatalf("failed to create card: %v", err)
+		}
+	}
+	sale, err := NewCreditCardServiceWithStore(store).RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:      card,
+		Amount:          decimal.NewFromInt(50),
+		MerchantName:    "Test Merchant",
+		TransactionDate: day,
+		PostingDate:     day,
+		ReferenceID:     "REF-REUSED",
+	})
+	if err != nil {
+		t.Fatalf("failed to record transaction: %v", err)
+	}
+
+	// The other card's records carry the same processor reference
+	file := strings.Join([]string{
+		strings.Join(clearingCSVColumns, ","),
+		clearingLine{"sale", other.ID, "REF-REUSED", 5000, ""}.csv(),
+		clearingLine{"refund", other.ID, "REF-REFUND", 2000, "REF-REUSED"}.csv(),
+	}, "\n")
+	report, err := NewClearingImportServiceWithStore(store).Import(ctx, strings.NewReader(file), ClearingImportRequest{
+		Format: ClearingCSV,
+	})
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	if len(report.Exceptions) != 2 {
+		t.Fatalf("exceptions = %+v, want both records", report.Exceptions)
+	}
+	for _, exception := range report.Exceptions {
+		if exception.Kind != ClearingExceptionAmbiguous {
+			t.Errorf("line %d: kind = %s, want %s", exception.Line, exception.Kind, ClearingExceptionAmbiguous)
+		}
+	}
+
+	entries, _ := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+	if len(entries) != 1 || entries[0].ID != sale.TransactionEntry.ID || entries[0].Status != models.EntryStatusPending {
+		t.Errorf("entries = %+v, want only the first card's sale, still pending", entries)
+	}
+}
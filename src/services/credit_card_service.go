@@ -2,34 +2,47 @@ package services
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 	"github.com/shopspring/decimal"
 )
 
 // CreditCardService handles all credit card operations including
 // transactions, payments, refunds, and account management
 type CreditCardService struct {
-	db                     *sql.DB
+	store                  repository.Store
 	statementLedgerService *StatementLedgerService
 	feeService             *FeeService
 	cashbackService        *CashbackService
 }
 
 // NewCreditCardService creates a new credit card service
-func NewCreditCardService(db *sql.DB) *CreditCardService {
+func NewCreditCardService(db Querier) *CreditCardService {
+	return NewCreditCardServiceWithStore(postgres.NewStore(db))
+}
+
+// NewCreditCardServiceWithStore creates a credit card service backed by store
+func NewCreditCardServiceWithStore(store repository.Store) *CreditCardService {
 	return &CreditCardService{
-		db:                     db,
-		statementLedgerService: NewStatementLedgerService(db),
-		feeService:             NewFeeService(db),
-		cashbackService:        NewCashbackService(db),
+		store:                  store,
+		statementLedgerService: NewStatementLedgerServiceWithStore(store),
+		feeService:             NewFeeServiceWithStore(store),
+		cashbackService:        NewCashbackServiceWithStore(store),
 	}
 }
 
+// withStore returns a copy of the service bound to store
+func (s *CreditCardService) withStore(store repository.Store) *CreditCardService {
+	return NewCreditCardServiceWithStore(store)
+}
+
 // CreateCreditCardRequest contains parameters for creating a new credit card
 type CreateCreditCardRequest struct {
 	TenantID         uuid.UUID
@@ -68,33 +81,7 @@ func (s *CreditCardService) CreateCreditCard(
 		return nil, fmt.Errorf("invalid credit card configuration: %w", err)
 	}
 
-	query := `
-		INSERT INTO credit_cards (
-			id, tenant_id, cardholder_name, credit_limit, available_credit,
-			purchase_apr, cash_advance_apr, penalty_apr, introductory_apr,
-			annual_fee, late_payment_fee, failed_payment_fee, international_fee_rate,
-			cash_advance_fee, cash_advance_fee_rate, over_limit_fee,
-			billing_cycle_type, billing_cycle_day, payment_due_days, grace_period_days,
-			minimum_payment_percent, minimum_payment_amount,
-			cashback_enabled, cashback_rate, cashback_redemption_min,
-			status, next_statement_date, created_at, updated_at
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16,
-			$17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29
-		)
-	`
-
-	_, err := s.db.ExecContext(ctx, query,
-		card.ID, card.TenantID, card.CardholderName, card.CreditLimit, card.AvailableCredit,
-		card.PurchaseAPR, card.CashAdvanceAPR, card.PenaltyAPR, card.IntroductoryAPR,
-		card.AnnualFee, card.LatePaymentFee, card.FailedPaymentFee, card.InternationalFeeRate,
-		card.CashAdvanceFee, card.CashAdvanceFeeRate, card.OverLimitFee,
-		card.BillingCycleType, card.BillingCycleDay, card.PaymentDueDays, card.GracePeriodDays,
-		card.MinimumPaymentPercent, card.MinimumPaymentAmount,
-		card.CashbackEnabled, card.CashbackRate, card.CashbackRedemptionMin,
-		card.Status, card.NextStatementDate, card.CreatedAt, card.UpdatedAt,
-	)
-
+	err := s.store.CreditCards().Create(ctx, &card)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create credit card: %w", err)
 	}
@@ -116,6 +103,8 @@ type CCTransactionRequest struct {
 	CountryCode      string
 	CurrencyCode     string
 	ExchangeRate     decimal.Decimal
+	AuthorizationID  *uuid.UUID // Set when the transaction captures an authorization
+	IdempotencyKey   string     // Retries with the same key replay the original result
 }
 
 // TransactionResult contains the results of processing a transaction
@@ -132,99 +121,108 @@ func (s *CreditCardService) RecordTransaction(
 	ctx context.Context,
 	req CCTransactionRequest,
 ) (*TransactionResult, error) {
-	// Validate card can transact
-	if err := req.CreditCard.CanTransact(); err != nil {
-		return nil, err
-	}
-
-	// Check available credit
-	if err := req.CreditCard.HasAvailableCredit(req.Amount); err != nil {
-		return nil, err
-	}
+	result := &TransactionResult{}
 
 	// Start transaction for atomicity
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
+	err := idempotently(ctx, s.store, req.CreditCard.TenantID, req.IdempotencyKey, operationRecordTransaction, result, func(tx repository.Store) error {
+		txs := s.withStore(tx)
 
-	result := &TransactionResult{}
+		// Lock the card so concurrent postings see each other's credit updates
+		card, err := txs.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
 
-	// Create main transaction entry
-	transactionEntry := &models.StatementLedgerEntry{
-		ID:          uuid.New(),
-		TenantID:    req.CreditCard.TenantID,
-		EntryType:   models.EntryTypeTransaction,
-		EntryDate:   req.TransactionDate,
-		PostingDate: req.PostingDate,
-		Amount:      req.Amount,
-		Description: fmt.Sprintf("%s - %s", req.MerchantName, req.Description),
-		ReferenceID: &req.ReferenceID,
-		Status:      models.EntryStatusPending,
-		Metadata: map[string]interface{}{
-			"merchant_name":     req.MerchantName,
-			"merchant_category": req.MerchantCategory,
-			"is_international":  req.IsInternational,
-		},
-		CreatedAt: time.Now(),
-	}
+		// Validate card can transact. A replayed request skips these checks,
+		// since the original already used up the credit it is checked against.
+		if err := card.CanTransact(); err != nil {
+			return err
+		}
 
-	if err := s.statementLedgerService.CreateEntry(ctx, transactionEntry); err != nil {
-		return nil, fmt.Errorf("failed to create transaction entry: %w", err)
-	}
-	result.TransactionEntry = transactionEntry
-
-	// Assess international fee if applicable
-	if req.IsInternational && req.CreditCard.InternationalFeeRate.GreaterThan(decimal.Zero) {
-		feeResult, err := s.feeService.AssessInternationalFee(ctx, InternationalFeeRequest{
-			CreditCard:          req.CreditCard,
-			TransactionAmount:   req.Amount,
-			TransactionCurrency: req.CurrencyCode,
-			ExchangeRate:        req.ExchangeRate,
-			MerchantCountry:     req.CountryCode,
-			TransactionDate:     req.TransactionDate,
-			ReferenceID:         req.ReferenceID,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to assess international fee: %w", err)
+		// Check available credit against the locked row
+		if err := card.HasAvailableCredit(req.Amount); err != nil {
+			return err
 		}
-		result.InternationalFee = feeResult
-	}
 
-	// Earn cashback if enabled
-	if req.CreditCard.CashbackEnabled {
-		cashbackEntry, err := s.cashbackService.EarnCashback(ctx, EarnCashbackRequest{
-			TenantID:          req.CreditCard.TenantID,
-			CreditCard:        req.CreditCard,
-			TransactionAmount: req.Amount,
-			TransactionDate:   req.TransactionDate,
-			StatementEntryID:  transactionEntry.ID,
-			MerchantCategory:  req.MerchantCategory,
-			Description:       req.Description,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("failed to earn cashback: %w", err)
+		// Create main transaction entry
+		transactionEntry := &models.StatementLedgerEntry{
+			ID:          uuid.New(),
+			TenantID:    card.TenantID,
+			EntryType:   models.EntryTypeTransaction,
+			EntryDate:   req.TransactionDate,
+			PostingDate: req.PostingDate,
+			Amount:      req.Amount,
+			Description: fmt.Sprintf("%s - %s", req.MerchantName, req.Description),
+			ReferenceID: &req.ReferenceID,
+			Status:      models.EntryStatusPending,
+			Metadata: map[string]interface{}{
+				"merchant_name":     req.MerchantName,
+				"merchant_category": req.MerchantCategory,
+				"is_international":  req.IsInternational,
+			},
+			CreatedAt: time.Now(),
+		}
+		if req.AuthorizationID != nil {
+			transactionEntry.Metadata[models.AuthorizationMetadataKey] = req.AuthorizationID.String()
 		}
-		result.CashbackEntry = cashbackEntry
-	}
 
-	// Update available credit
-	totalCharged := req.Amount
-	if result.InternationalFee != nil {
-		totalCharged = totalCharged.Add(result.InternationalFee.FeeAmount)
-	}
+		if err := txs.statementLedgerService.CreateEntry(ctx, transactionEntry); err != nil {
+			return fmt.Errorf("failed to create transaction entry: %w", err)
+		}
+		result.TransactionEntry = transactionEntry
+
+		// Assess international fee if applicable
+		if req.IsInternational && card.InternationalFeeRate.GreaterThan(decimal.Zero) {
+			feeResult, err := txs.feeService.AssessInternationalFee(ctx, InternationalFeeRequest{
+				CreditCard:          card,
+				TransactionAmount:   req.Amount,
+				TransactionCurrency: req.CurrencyCode,
+				ExchangeRate:        req.ExchangeRate,
+				MerchantCountry:     req.CountryCode,
+				TransactionDate:     req.TransactionDate,
+				ReferenceID:         req.ReferenceID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to assess international fee: %w", err)
+			}
+			result.InternationalFee = feeResult
+		}
 
-	newAvailableCredit := req.CreditCard.AvailableCredit.Sub(totalCharged)
-	if err := s.updateAvailableCredit(ctx, req.CreditCard.ID, newAvailableCredit); err != nil {
-		return nil, fmt.Errorf("failed to update available credit: %w", err)
-	}
+		// Earn cashback if enabled
+		if card.CashbackEnabled {
+			cashbackEntry, err := txs.cashbackService.EarnCashback(ctx, EarnCashbackRequest{
+				TenantID:          card.TenantID,
+				CreditCard:        card,
+				TransactionAmount: req.Amount,
+				TransactionDate:   req.TransactionDate,
+				StatementEntryID:  transactionEntry.ID,
+				MerchantCategory:  req.MerchantCategory,
+				Description:       req.Description,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to earn cashback: %w", err)
+			}
+			result.CashbackEntry = cashbackEntry
+		}
+
+		// Update available credit
+		totalCharged := req.Amount
+		if result.InternationalFee != nil {
+			totalCharged = totalCharged.Add(result.InternationalFee.FeeAmount)
+		}
+
+		newAvailableCredit := card.AvailableCredit.Sub(totalCharged)
+		if err := txs.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+			return fmt.Errorf("failed to update available credit: %w", err)
+		}
 
-	result.AvailableCredit = newAvailableCredit
-	result.NewBalance = req.CreditCard.CreditLimit.Sub(newAvailableCredit)
+		result.AvailableCredit = newAvailableCredit
+		result.NewBalance = card.CreditLimit.Sub(newAvailableCredit)
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -253,94 +251,332 @@ func (s *CreditCardService) RecordCashAdvance(
 	ctx context.Context,
 	req CashAdvanceRequest,
 ) (*CashAdvanceResult, error) {
-	if err := req.CreditCard.CanTransact(); err != nil {
-		return nil, err
-	}
+	result := &CashAdvanceResult{}
 
-	if err := req.CreditCard.HasAvailableCredit(req.Amount); err != nil {
-		return nil, err
-	}
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		card, err := txs.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := card.CanTransact(); err != nil {
+			return err
+		}
+
+		if err := card.HasAvailableCredit(req.Amount); err != nil {
+			return err
+		}
+
+		// Create cash advance entry
+		advanceEntry := &models.StatementLedgerEntry{
+			ID:          uuid.New(),
+			TenantID:    card.TenantID,
+			EntryType:   models.EntryTypeCashAdvance,
+			EntryDate:   req.TransactionDate,
+			PostingDate: req.TransactionDate,
+			Amount:      req.Amount,
+			Description: fmt.Sprintf("Cash advance - %s", req.ATMLocation),
+			ReferenceID: &req.ReferenceID,
+			Status:      models.EntryStatusPending,
+			Metadata: map[string]interface{}{
+				"atm_location":     req.ATMLocation,
+				"cash_advance_apr": card.CashAdvanceAPR.String(),
+			},
+			CreatedAt: time.Now(),
+		}
+
+		if err := txs.statementLedgerService.CreateEntry(ctx, advanceEntry); err != nil {
+			return fmt.Errorf("failed to create cash advance entry: %w", err)
+		}
+		result.CashAdvanceEntry = advanceEntry
+
+		// Assess cash advance fee
+		feeResult, err := txs.feeService.AssessCashAdvanceFee(ctx, CashAdvanceFeeRequest{
+			CreditCard:        card,
+			CashAdvanceAmount: req.Amount,
+			TransactionDate:   req.TransactionDate,
+			ATMLocation:       req.ATMLocation,
+			ReferenceID:       req.ReferenceID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to assess cash advance fee: %w", err)
+		}
+		result.FeeEntry = feeResult
 
-	tx, err := s.db.BeginTx(ctx, nil)
+		// Calculate total charged
+		totalCharged := req.Amount
+		if feeResult != nil {
+			totalCharged = totalCharged.Add(feeResult.FeeAmount)
+		}
+
+		// Update available credit
+		newAvailableCredit := card.AvailableCredit.Sub(totalCharged)
+		if err := txs.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+			return fmt.Errorf("failed to update available credit: %w", err)
+		}
+
+		result.AvailableCredit = newAvailableCredit
+		result.NewBalance = card.CreditLimit.Sub(newAvailableCredit)
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
 
-	result := &CashAdvanceResult{}
+	return result, nil
+}
 
-	// Create cash advance entry
-	advanceEntry := &models.StatementLedgerEntry{
-		ID:          uuid.New(),
-		TenantID:    req.CreditCard.TenantID,
-		EntryType:   models.EntryTypeCashAdvance,
-		EntryDate:   req.TransactionDate,
-		PostingDate: req.TransactionDate,
-		Amount:      req.Amount,
-		Description: fmt.Sprintf("Cash advance - %s", req.ATMLocation),
-		ReferenceID: &req.ReferenceID,
-		Status:      models.EntryStatusPending,
-		Metadata: map[string]interface{}{
-			"atm_location":     req.ATMLocation,
-			"cash_advance_apr": req.CreditCard.CashAdvanceAPR.String(),
-		},
-		CreatedAt: time.Now(),
-	}
+// BalanceTransferRequest contains parameters for moving a balance onto the card
+type BalanceTransferRequest struct {
+	CreditCard      *models.CreditCard
+	Amount          decimal.Decimal
+	Creditor        string // Lender the balance is transferred from
+	TransactionDate time.Time
+	ReferenceID     string
+	// Promotion offered with the transfer. Ignored if it matches the active
+	// promotion; leave both nil to transfer at the card's current terms.
+	PromoAPR       *decimal.Decimal
+	PromoEndDate   *time.Time
+	IdempotencyKey string // Retries with the same key replay the original result
+}
 
-	if err := s.statementLedgerService.CreateEntry(ctx, advanceEntry); err != nil {
-		return nil, fmt.Errorf("failed to create cash advance entry: %w", err)
+// BalanceTransferResult contains the result of processing a balance transfer
+type BalanceTransferResult struct {
+	TransferEntry   *models.StatementLedgerEntry
+	FeeEntry        *FeeAssessmentResult
+	RolledEntries   []*models.StatementLedgerEntry // An expired promotion rolled to purchases first
+	PromoAPR        decimal.Decimal                // APR the transfer accrues at
+	PromoEndDate    *time.Time                     // Nil when the transfer accrues at the purchase APR
+	NewBalance      decimal.Decimal
+	AvailableCredit decimal.Decimal
+}
+
+// RecordBalanceTransfer moves a balance from another lender onto the card.
+// The amount goes into the balance transfer segment, which accrues at the
+// promotional APR until the promotion ends and is then rolled into purchases
+// (see RollExpiredBalanceTransferPromo). Transfers do not earn cashback.
+func (s *CreditCardService) RecordBalanceTransfer(
+	ctx context.Context,
+	req BalanceTransferRequest,
+) (*BalanceTransferResult, error) {
+	if req.Amount.LessThanOrEqual(decimal.Zero) {
+		return nil, errors.New("balance transfer amount must be positive")
+	}
+	if (req.PromoAPR == nil) != (req.PromoEndDate == nil) {
+		return nil, errors.New("balance transfer promotion needs both an APR and an end date")
 	}
-	result.CashAdvanceEntry = advanceEntry
-
-	// Assess cash advance fee
-	feeResult, err := s.feeService.AssessCashAdvanceFee(ctx, CashAdvanceFeeRequest{
-		CreditCard:        req.CreditCard,
-		CashAdvanceAmount: req.Amount,
-		TransactionDate:   req.TransactionDate,
-		ATMLocation:       req.ATMLocation,
-		ReferenceID:       req.ReferenceID,
+	if req.PromoEndDate != nil && !req.PromoEndDate.After(req.TransactionDate) {
+		return nil, fmt.Errorf("balance transfer promotion ends %s, before the transfer",
+			req.PromoEndDate.Format("2006-01-02"))
+	}
+
+	result := &BalanceTransferResult{}
+
+	err := idempotently(ctx, s.store, req.CreditCard.TenantID, req.IdempotencyKey, operationRecordTransfer, result, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		// A promotion that has ended is rolled before a new one can start
+		rolled, err := txs.RollExpiredBalanceTransferPromo(ctx, req.CreditCard.ID, req.TransactionDate)
+		if err != nil {
+			return err
+		}
+		result.RolledEntries = rolled
+
+		card, err := txs.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := card.CanTransact(); err != nil {
+			return err
+		}
+
+		feeAmount := card.CalculateBalanceTransferFee(req.Amount)
+		if err := card.HasAvailableCredit(req.Amount.Add(feeAmount)); err != nil {
+			return err
+		}
+
+		if req.PromoEndDate != nil {
+			if card.HasBalanceTransferPromo(req.TransactionDate) &&
+				(!card.BalanceTransferEndDate.Equal(*req.PromoEndDate) || !card.BalanceTransferAPR.Equal(*req.PromoAPR)) {
+				return fmt.Errorf("a balance transfer promotion at %s%% is active until %s",
+					card.BalanceTransferAPR, card.BalanceTransferEndDate.Format("2006-01-02"))
+			}
+			card.BalanceTransferAPR = *req.PromoAPR
+			card.BalanceTransferEndDate = req.PromoEndDate
+		}
+
+		result.PromoAPR = card.SegmentAPR(models.SegmentBalanceTransfer, req.TransactionDate)
+		if card.HasBalanceTransferPromo(req.TransactionDate) {
+			result.PromoEndDate = card.BalanceTransferEndDate
+		}
+
+		metadata := map[string]interface{}{
+			"creditor": req.Creditor,
+			"apr":      result.PromoAPR.String(),
+		}
+		if result.PromoEndDate != nil {
+			metadata["promo_end_date"] = result.PromoEndDate.Format("2006-01-02")
+		}
+
+		transferEntry := &models.StatementLedgerEntry{
+			ID:          uuid.New(),
+			TenantID:    card.TenantID,
+			EntryType:   models.EntryTypeBalanceTransfer,
+			EntryDate:   req.TransactionDate,
+			PostingDate: req.TransactionDate,
+			Amount:      req.Amount,
+			Description: fmt.Sprintf("Balance transfer - %s", req.Creditor),
+			ReferenceID: &req.ReferenceID,
+			Status:      models.EntryStatusPending,
+			Metadata:    metadata,
+			CreatedAt:   time.Now(),
+		}
+
+		if err := txs.statementLedgerService.CreateEntry(ctx, transferEntry); err != nil {
+			return fmt.Errorf("failed to create balance transfer entry: %w", err)
+		}
+		result.TransferEntry = transferEntry
+
+		totalCharged := req.Amount
+		if feeAmount.IsPositive() {
+			feeResult, err := txs.feeService.AssessBalanceTransferFee(ctx, BalanceTransferFeeRequest{
+				CreditCard:      card,
+				TransferAmount:  req.Amount,
+				TransactionDate: req.TransactionDate,
+				Creditor:        req.Creditor,
+				ReferenceID:     req.ReferenceID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to assess balance transfer fee: %w", err)
+			}
+			result.FeeEntry = feeResult
+			totalCharged = totalCharged.Add(feeResult.FeeAmount)
+		}
+
+		newAvailableCredit := card.AvailableCredit.Sub(totalCharged)
+		err = txs.updateCard(ctx, card.ID, func(locked *models.CreditCard) {
+			locked.AvailableCredit = newAvailableCredit
+			locked.BalanceTransferAPR = card.BalanceTransferAPR
+			locked.BalanceTransferEndDate = card.BalanceTransferEndDate
+		})
+		if err != nil {
+			return fmt.Errorf("failed to update card: %w", err)
+		}
+
+		result.AvailableCredit = newAvailableCredit
+		result.NewBalance = card.CreditLimit.Sub(newAvailableCredit)
+
+		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to assess cash advance fee: %w", err)
+		return nil, err
 	}
-	result.FeeEntry = feeResult
 
-	// Calculate total charged
-	totalCharged := req.Amount
-	if feeResult != nil {
-		totalCharged = totalCharged.Add(feeResult.FeeAmount)
-	}
+	return result, nil
+}
 
-	// Update available credit
-	newAvailableCredit := req.CreditCard.AvailableCredit.Sub(totalCharged)
-	if err := s.updateAvailableCredit(ctx, req.CreditCard.ID, newAvailableCredit); err != nil {
-		return nil, fmt.Errorf("failed to update available credit: %w", err)
-	}
+// RollExpiredBalanceTransferPromo moves what is left of the balance transfer
+// segment into purchases once the card's promotion has ended on or before
+// asOf, so it accrues at the purchase APR from the promotion's end date. The
+// move is posted as a pair of cleared adjustments that net to zero, and the
+// card's promotion is cleared. It returns nil if there was nothing to roll.
+func (s *CreditCardService) RollExpiredBalanceTransferPromo(
+	ctx context.Context,
+	cardID uuid.UUID,
+	asOf time.Time,
+) ([]*models.StatementLedgerEntry, error) {
+	var entries []*models.StatementLedgerEntry
 
-	result.AvailableCredit = newAvailableCredit
-	result.NewBalance = req.CreditCard.CreditLimit.Sub(newAvailableCredit)
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit cash advance: %w", err)
+		card, err := txs.lockCard(ctx, cardID)
+		if err != nil {
+			return err
+		}
+		if card.BalanceTransferEndDate == nil || asOf.Before(*card.BalanceTransferEndDate) {
+			return nil
+		}
+		endDate := *card.BalanceTransferEndDate
+
+		posted, err := tx.StatementEntries().List(ctx, repository.StatementEntryFilter{
+			TenantID: &card.TenantID,
+			Statuses: []models.EntryStatus{models.EntryStatusPending, models.EntryStatusCleared},
+			PostedTo: &endDate,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list statement entries: %w", err)
+		}
+		ledger := newSegmentLedger(card)
+		for _, entry := range posted {
+			ledger.post(entry)
+		}
+
+		if remaining := ledger.balances[models.SegmentBalanceTransfer]; remaining.IsPositive() {
+			now := time.Now()
+			moves := []struct {
+				segment models.BalanceSegment
+				amount  decimal.Decimal
+			}{
+				{models.SegmentBalanceTransfer, remaining.Neg()},
+				{models.SegmentPurchase, remaining},
+			}
+			for _, move := range moves {
+				entry := &models.StatementLedgerEntry{
+					ID:          uuid.New(),
+					TenantID:    card.TenantID,
+					EntryType:   models.EntryTypeAdjustment,
+					EntryDate:   now,
+					PostingDate: endDate,
+					Amount:      move.amount,
+					Description: fmt.Sprintf("Balance transfer promotion ended - %s", strings.ToLower(move.segment.Label())),
+					Status:      models.EntryStatusCleared,
+					ClearedAt:   &now,
+					Metadata: map[string]interface{}{
+						models.SegmentMetadataKey: string(move.segment),
+						"promo_apr":               card.BalanceTransferAPR.String(),
+						"promo_end_date":          endDate.Format("2006-01-02"),
+					},
+					CreatedAt: now,
+				}
+				if err := txs.statementLedgerService.CreateEntry(ctx, entry); err != nil {
+					return fmt.Errorf("failed to create promotion roll entry: %w", err)
+				}
+				entries = append(entries, entry)
+			}
+		}
+
+		return txs.updateCard(ctx, card.ID, func(locked *models.CreditCard) {
+			locked.BalanceTransferEndDate = nil
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return result, nil
+	return entries, nil
 }
 
 // CCPaymentRequest contains parameters for recording a credit card payment
 type CCPaymentRequest struct {
-	CreditCard    *models.CreditCard
-	Amount        decimal.Decimal
-	PaymentDate   time.Time
-	PostingDate   time.Time
-	PaymentMethod string
-	ReferenceID   string
-	Description   string
+	CreditCard     *models.CreditCard
+	Amount         decimal.Decimal
+	PaymentDate    time.Time
+	PostingDate    time.Time
+	PaymentMethod  string
+	ReferenceID    string
+	Description    string
+	IdempotencyKey string // Retries with the same key replay the original result
 }
 
 // CCPaymentResult contains the result of processing a payment
 type CCPaymentResult struct {
 	PaymentEntry    *models.StatementLedgerEntry
+	Allocation      models.SegmentAllocation // Amount applied to each balance segment
 	NewBalance      decimal.Decimal
 	AvailableCredit decimal.Decimal
 }
@@ -351,60 +587,134 @@ func (s *CreditCardService) RecordPayment(
 	ctx context.Context,
 	req CCPaymentRequest,
 ) (*CCPaymentResult, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
+	result := &CCPaymentResult{}
+
+	err := idempotently(ctx, s.store, req.CreditCard.TenantID, req.IdempotencyKey, operationRecordPayment, result, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		card, err := txs.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
+		// Money collected after charge-off is a recovery, not a payment
+		if card.IsChargedOff() {
+			return models.ErrCardChargedOff
+		}
+
+		allocation, err := txs.allocatePayment(ctx, card, req.Amount, req.PostingDate)
+		if err != nil {
+			return err
+		}
+		result.Allocation = allocation
+
+		// Create payment entry
+		paymentEntry := &models.StatementLedgerEntry{
+			ID:          uuid.New(),
+			TenantID:    card.TenantID,
+			EntryType:   models.EntryTypePayment,
+			EntryDate:   req.PaymentDate,
+			PostingDate: req.PostingDate,
+			Amount:      req.Amount,
+			Description: fmt.Sprintf("Payment received - %s", req.Description),
+			ReferenceID: &req.ReferenceID,
+			Status:      models.EntryStatusPending, // Will be cleared when ACH settles
+			Metadata: map[string]interface{}{
+				"payment_method":             req.PaymentMethod,
+				models.AllocationMetadataKey: allocation.Metadata(),
+			},
+			CreatedAt: time.Now(),
+		}
+
+		if err := txs.statementLedgerService.CreateEntry(ctx, paymentEntry); err != nil {
+			return fmt.Errorf("failed to create payment entry: %w", err)
+		}
+		result.PaymentEntry = paymentEntry
+
+		// Update available credit (increase by payment amount)
+		newAvailableCredit := card.AvailableCredit.Add(req.Amount)
+		// Cap at credit limit
+		if newAvailableCredit.GreaterThan(card.CreditLimit) {
+			newAvailableCredit = card.CreditLimit
+		}
+
+		if err := txs.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+			return fmt.Errorf("failed to update available credit: %w", err)
+		}
+
+		result.AvailableCredit = newAvailableCredit
+		result.NewBalance = card.CreditLimit.Sub(newAvailableCredit)
+
+		// Update last payment info
+		if err := txs.updateLastPayment(ctx, card.ID, req.PaymentDate, req.Amount); err != nil {
+			return fmt.Errorf("failed to update last payment: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer tx.Rollback()
 
-	result := &CCPaymentResult{}
+	return result, nil
+}
 
-	// Create payment entry
-	paymentEntry := &models.StatementLedgerEntry{
-		ID:          uuid.New(),
-		TenantID:    req.CreditCard.TenantID,
-		EntryType:   models.EntryTypePayment,
-		EntryDate:   req.PaymentDate,
-		PostingDate: req.PostingDate,
-		Amount:      req.Amount,
-		Description: fmt.Sprintf("Payment received - %s", req.Description),
-		ReferenceID: &req.ReferenceID,
-		Status:      models.EntryStatusPending, // Will be cleared when ACH settles
-		Metadata: map[string]interface{}{
-			"payment_method": req.PaymentMethod,
-		},
-		CreatedAt: time.Now(),
+// allocatePayment splits a payment posted on postingDate across the card's
+// balance segments (see models.AllocatePayment). The minimum due is the last
+// statement's minimum payment less the payments posted since it closed.
+func (s *CreditCardService) allocatePayment(
+	ctx context.Context,
+	card *models.CreditCard,
+	amount decimal.Decimal,
+	postingDate time.Time,
+) (models.SegmentAllocation, error) {
+	cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{
+		CreditCardID: &card.ID,
+		Limit:        1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last billing cycle: %w", err)
 	}
 
-	if err := s.statementLedgerService.CreateEntry(ctx, paymentEntry); err != nil {
-		return nil, fmt.Errorf("failed to create payment entry: %w", err)
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID: &card.TenantID,
+		Statuses: []models.EntryStatus{models.EntryStatusPending, models.EntryStatusCleared},
+		PostedTo: &postingDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement entries: %w", err)
 	}
-	result.PaymentEntry = paymentEntry
 
-	// Update available credit (increase by payment amount)
-	newAvailableCredit := req.CreditCard.AvailableCredit.Add(req.Amount)
-	// Cap at credit limit
-	if newAvailableCredit.GreaterThan(req.CreditCard.CreditLimit) {
-		newAvailableCredit = req.CreditCard.CreditLimit
-	}
+	ledger := newSegmentLedger(card)
+	paidSinceStatement := decimal.Zero
+	for _, entry := range entries {
+		ledger.post(entry)
 
-	if err := s.updateAvailableCredit(ctx, req.CreditCard.ID, newAvailableCredit); err != nil {
-		return nil, fmt.Errorf("failed to update available credit: %w", err)
+		if len(cycles) == 0 || !entry.PostingDate.After(cycles[0].CycleEndDate) {
+			continue
+		}
+		// Returned payments carry the allocation of the payment they undo
+		if allocation, ok := models.SegmentAllocationFromMetadata(entry.Metadata); ok {
+			if entry.EntryType == models.EntryTypePayment {
+				paidSinceStatement = paidSinceStatement.Add(allocation.Total())
+			} else {
+				paidSinceStatement = paidSinceStatement.Sub(allocation.Total())
+			}
+		} else if entry.EntryType == models.EntryTypePayment {
+			paidSinceStatement = paidSinceStatement.Add(entry.Amount)
+		}
 	}
 
-	result.AvailableCredit = newAvailableCredit
-	result.NewBalance = req.CreditCard.CreditLimit.Sub(newAvailableCredit)
-
-	// Update last payment info
-	if err := s.updateLastPayment(ctx, req.CreditCard.ID, req.PaymentDate, req.Amount); err != nil {
-		return nil, fmt.Errorf("failed to update last payment: %w", err)
+	minimumDue := decimal.Zero
+	if len(cycles) > 0 && cycles[0].Status != models.BillingCycleStatusOpen {
+		minimumDue = decimal.Max(cycles[0].MinimumPayment.Sub(paidSinceStatement), decimal.Zero)
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit payment: %w", err)
+	aprs := make(map[models.BalanceSegment]decimal.Decimal, len(models.BalanceSegments))
+	for _, segment := range models.BalanceSegments {
+		aprs[segment] = card.SegmentAPR(segment, postingDate)
 	}
 
-	return result, nil
+	return models.AllocatePayment(ledger.balances, aprs, amount, minimumDue), nil
 }
 
 // FailedPaymentRequest contains parameters for recording a failed payment
@@ -427,66 +737,76 @@ func (s *CreditCardService) RecordFailedPayment(
 	ctx context.Context,
 	req FailedPaymentRequest,
 ) (*FailedPaymentResult, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-
 	result := &FailedPaymentResult{}
 
-	// Create reversal entry (adds back the payment amount as a charge)
-	reversalEntry := &models.StatementLedgerEntry{
-		ID:          uuid.New(),
-		TenantID:    req.CreditCard.TenantID,
-		EntryType:   models.EntryTypeAdjustment,
-		EntryDate:   req.FailureDate,
-		PostingDate: req.FailureDate,
-		Amount:      req.OriginalPayment.Amount, // Positive to add back
-		Description: fmt.Sprintf("Payment returned - %s", req.FailureReason),
-		Status:      models.EntryStatusPending,
-		Metadata: map[string]interface{}{
-			"original_payment_id": req.OriginalPayment.ID.String(),
-			"failure_reason":      req.FailureReason,
-		},
-		CreatedAt: time.Now(),
-	}
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
 
-	if err := s.statementLedgerService.CreateEntry(ctx, reversalEntry); err != nil {
-		return nil, fmt.Errorf("failed to create reversal entry: %w", err)
-	}
-	result.ReversalEntry = reversalEntry
-
-	// Assess failed payment fee
-	refID := req.OriginalPayment.ID.String()
-	feeResult, err := s.feeService.AssessFailedPaymentFee(ctx, FailedPaymentFeeRequest{
-		CreditCard:    req.CreditCard,
-		PaymentAmount: req.OriginalPayment.Amount,
-		PaymentDate:   req.FailureDate,
-		FailureReason: req.FailureReason,
-		PaymentMethod: "ACH",
-		ReferenceID:   refID,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to assess failed payment fee: %w", err)
-	}
-	result.FeeEntry = feeResult
+		card, err := txs.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
+		if err := card.CanPost(); err != nil {
+			return err
+		}
 
-	// Update available credit (reduce by reversed amount + fee)
-	totalReduction := req.OriginalPayment.Amount
-	if feeResult != nil {
-		totalReduction = totalReduction.Add(feeResult.FeeAmount)
-	}
+		// Create reversal entry (adds back the payment amount as a charge)
+		reversalEntry := &models.StatementLedgerEntry{
+			ID:          uuid.New(),
+			TenantID:    card.TenantID,
+			EntryType:   models.EntryTypeAdjustment,
+			EntryDate:   req.FailureDate,
+			PostingDate: req.FailureDate,
+			Amount:      req.OriginalPayment.Amount, // Positive to add back
+			Description: fmt.Sprintf("Payment returned - %s", req.FailureReason),
+			Status:      models.EntryStatusPending,
+			Metadata: map[string]interface{}{
+				"original_payment_id": req.OriginalPayment.ID.String(),
+				"failure_reason":      req.FailureReason,
+			},
+			CreatedAt: time.Now(),
+		}
+		if allocation, ok := req.OriginalPayment.Metadata[models.AllocationMetadataKey]; ok {
+			reversalEntry.Metadata[models.AllocationMetadataKey] = allocation
+		}
 
-	newAvailableCredit := req.CreditCard.AvailableCredit.Sub(totalReduction)
-	if err := s.updateAvailableCredit(ctx, req.CreditCard.ID, newAvailableCredit); err != nil {
-		return nil, fmt.Errorf("failed to update available credit: %w", err)
-	}
+		if err := txs.statementLedgerService.CreateEntry(ctx, reversalEntry); err != nil {
+			return fmt.Errorf("failed to create reversal entry: %w", err)
+		}
+		result.ReversalEntry = reversalEntry
+
+		// Assess failed payment fee
+		refID := req.OriginalPayment.ID.String()
+		feeResult, err := txs.feeService.AssessFailedPaymentFee(ctx, FailedPaymentFeeRequest{
+			CreditCard:    card,
+			PaymentAmount: req.OriginalPayment.Amount,
+			PaymentDate:   req.FailureDate,
+			FailureReason: req.FailureReason,
+			PaymentMethod: "ACH",
+			ReferenceID:   refID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to assess failed payment fee: %w", err)
+		}
+		result.FeeEntry = feeResult
 
-	result.NewBalance = req.CreditCard.CreditLimit.Sub(newAvailableCredit)
+		// Update available credit (reduce by reversed amount + fee)
+		totalReduction := req.OriginalPayment.Amount
+		if feeResult != nil {
+			totalReduction = totalReduction.Add(feeResult.FeeAmount)
+		}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit failed payment: %w", err)
+		newAvailableCredit := card.AvailableCredit.Sub(totalReduction)
+		if err := txs.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+			return fmt.Errorf("failed to update available credit: %w", err)
+		}
+
+		result.NewBalance = card.CreditLimit.Sub(newAvailableCredit)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -502,6 +822,7 @@ type CCRefundRequest struct {
 	MerchantName          string
 	ReferenceID           string
 	Description           string
+	IdempotencyKey        string // Retries with the same key replay the original result
 }
 
 // RefundResult contains the result of processing a refund
@@ -517,69 +838,76 @@ func (s *CreditCardService) RecordRefund(
 	ctx context.Context,
 	req CCRefundRequest,
 ) (*RefundResult, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, err
-	}
-	defer tx.Rollback()
-
 	result := &RefundResult{}
 
-	// Create refund entry
-	refundEntry := &models.StatementLedgerEntry{
-		ID:          uuid.New(),
-		TenantID:    req.CreditCard.TenantID,
-		EntryType:   models.EntryTypeRefund,
-		EntryDate:   req.RefundDate,
-		PostingDate: req.PostingDate,
-		Amount:      req.RefundAmount,
-		Description: fmt.Sprintf("Refund from %s - %s", req.MerchantName, req.Description),
-		ReferenceID: &req.ReferenceID,
-		Status:      models.EntryStatusPending,
-		Metadata: map[string]interface{}{
-			"original_transaction_id": req.OriginalTransactionID.String(),
-			"merchant_name":           req.MerchantName,
-		},
-		CreatedAt: time.Now(),
-	}
+	err := idempotently(ctx, s.store, req.CreditCard.TenantID, req.IdempotencyKey, operationRecordRefund, result, func(tx repository.Store) error {
+		txs := s.withStore(tx)
 
-	if err := s.statementLedgerService.CreateEntry(ctx, refundEntry); err != nil {
-		return nil, fmt.Errorf("failed to create refund entry: %w", err)
-	}
-	result.RefundEntry = refundEntry
-
-	// Adjust cashback if applicable
-	if req.CreditCard.CashbackEnabled {
-		cashbackAdj, err := s.cashbackService.AdjustCashbackForRefund(ctx, AdjustCashbackForRefundRequest{
-			TenantID:                   req.CreditCard.TenantID,
-			CreditCard:                 req.CreditCard,
-			RefundAmount:               req.RefundAmount,
-			RefundDate:                 req.RefundDate,
-			OriginalTransactionEntryID: req.OriginalTransactionID,
-			RefundEntryID:              refundEntry.ID,
-		})
+		card, err := txs.lockCard(ctx, req.CreditCard.ID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to adjust cashback: %w", err)
+			return err
+		}
+		if err := card.CanPost(); err != nil {
+			return err
 		}
-		result.CashbackAdjust = cashbackAdj
-	}
 
-	// Update available credit (increase by refund amount)
-	newAvailableCredit := req.CreditCard.AvailableCredit.Add(req.RefundAmount)
-	// Cap at credit limit
-	if newAvailableCredit.GreaterThan(req.CreditCard.CreditLimit) {
-		newAvailableCredit = req.CreditCard.CreditLimit
-	}
+		// Create refund entry
+		refundEntry := &models.StatementLedgerEntry{
+			ID:          uuid.New(),
+			TenantID:    card.TenantID,
+			EntryType:   models.EntryTypeRefund,
+			EntryDate:   req.RefundDate,
+			PostingDate: req.PostingDate,
+			Amount:      req.RefundAmount,
+			Description: fmt.Sprintf("Refund from %s - %s", req.MerchantName, req.Description),
+			ReferenceID: &req.ReferenceID,
+			Status:      models.EntryStatusPending,
+			Metadata: map[string]interface{}{
+				"original_transaction_id": req.OriginalTransactionID.String(),
+				"merchant_name":           req.MerchantName,
+			},
+			CreatedAt: time.Now(),
+		}
 
-	if err := s.updateAvailableCredit(ctx, req.CreditCard.ID, newAvailableCredit); err != nil {
-		return nil, fmt.Errorf("failed to update available credit: %w", err)
-	}
+		if err := txs.statementLedgerService.CreateEntry(ctx, refundEntry); err != nil {
+			return fmt.Errorf("failed to create refund entry: %w", err)
+		}
+		result.RefundEntry = refundEntry
+
+		// Adjust cashback if applicable
+		if card.CashbackEnabled {
+			cashbackAdj, err := txs.cashbackService.AdjustCashbackForRefund(ctx, AdjustCashbackForRefundRequest{
+				TenantID:                   card.TenantID,
+				CreditCard:                 card,
+				RefundAmount:               req.RefundAmount,
+				RefundDate:                 req.RefundDate,
+				OriginalTransactionEntryID: req.OriginalTransactionID,
+				RefundEntryID:              refundEntry.ID,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to adjust cashback: %w", err)
+			}
+			result.CashbackAdjust = cashbackAdj
+		}
+
+		// Update available credit (increase by refund amount)
+		newAvailableCredit := card.AvailableCredit.Add(req.RefundAmount)
+		// Cap at credit limit
+		if newAvailableCredit.GreaterThan(card.CreditLimit) {
+			newAvailableCredit = card.CreditLimit
+		}
 
-	result.AvailableCredit = newAvailableCredit
-	result.NewBalance = req.CreditCard.CreditLimit.Sub(newAvailableCredit)
+		if err := txs.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+			return fmt.Errorf("failed to update available credit: %w", err)
+		}
+
+		result.AvailableCredit = newAvailableCredit
+		result.NewBalance = card.CreditLimit.Sub(newAvailableCredit)
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit refund: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
@@ -593,6 +921,7 @@ type AdjustmentRequest struct {
 	Reason         string
 	ApprovedBy     string
 	ReferenceID    string
+	IdempotencyKey string // Retries with the same key replay the original result
 }
 
 // RecordAdjustment records a manual adjustment (credit or debit)
@@ -623,22 +952,39 @@ func (s *CreditCardService) RecordAdjustment(
 		CreatedAt: time.Now(),
 	}
 
-	if err := s.statementLedgerService.CreateEntry(ctx, entry); err != nil {
-		return nil, fmt.Errorf("failed to create adjustment entry: %w", err)
-	}
+	err := idempotently(ctx, s.store, req.CreditCard.TenantID, req.IdempotencyKey, operationRecordAdjustment, entry, func(tx repository.Store) error {
+		txs := s.withStore(tx)
 
-	// Update available credit
-	var newAvailableCredit decimal.Decimal
-	if req.Amount.LessThan(decimal.Zero) {
-		// Credit adjustment - increase available
-		newAvailableCredit = req.CreditCard.AvailableCredit.Add(req.Amount.Abs())
-	} else {
-		// Debit adjustment - decrease available
-		newAvailableCredit = req.CreditCard.AvailableCredit.Sub(req.Amount)
-	}
+		card, err := txs.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
+		if err := card.CanPost(); err != nil {
+			return err
+		}
+
+		entry.TenantID = card.TenantID
+		if err := txs.statementLedgerService.CreateEntry(ctx, entry); err != nil {
+			return fmt.Errorf("failed to create adjustment entry: %w", err)
+		}
+
+		// Update available credit
+		var newAvailableCredit decimal.Decimal
+		if req.Amount.LessThan(decimal.Zero) {
+			// Credit adjustment - increase available
+			newAvailableCredit = card.AvailableCredit.Add(req.Amount.Abs())
+		} else {
+			// Debit adjustment - decrease available
+			newAvailableCredit = card.AvailableCredit.Sub(req.Amount)
+		}
 
-	if err := s.updateAvailableCredit(ctx, req.CreditCard.ID, newAvailableCredit); err != nil {
-		return nil, fmt.Errorf("failed to update available credit: %w", err)
+		if err := txs.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+			return fmt.Errorf("failed to update available credit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return entry, nil
@@ -646,36 +992,8 @@ func (s *CreditCardService) RecordAdjustment(
 
 // GetCreditCard retrieves a credit card by ID
 func (s *CreditCardService) GetCreditCard(ctx context.Context, cardID uuid.UUID) (*models.CreditCard, error) {
-	query := `
-		SELECT id, tenant_id, cardholder_name, credit_limit, available_credit,
-		       purchase_apr, cash_advance_apr, penalty_apr, introductory_apr,
-		       introductory_end_date, annual_fee, late_payment_fee, failed_payment_fee,
-		       international_fee_rate, cash_advance_fee, cash_advance_fee_rate, over_limit_fee,
-		       billing_cycle_type, billing_cycle_day, payment_due_days, grace_period_days,
-		       minimum_payment_percent, minimum_payment_amount,
-		       cashback_enabled, cashback_rate, cashback_redemption_min,
-		       status, last_statement_date, next_statement_date,
-		       last_payment_date, last_payment_amount, consecutive_late_count,
-		       created_at, updated_at, closed_at
-		FROM credit_cards
-		WHERE id = $1
-	`
-
-	card := &models.CreditCard{}
-	err := s.db.QueryRowContext(ctx, query, cardID).Scan(
-		&card.ID, &card.TenantID, &card.CardholderName, &card.CreditLimit, &card.AvailableCredit,
-		&card.PurchaseAPR, &card.CashAdvanceAPR, &card.PenaltyAPR, &card.IntroductoryAPR,
-		&card.IntroductoryEndDate, &card.AnnualFee, &card.LatePaymentFee, &card.FailedPaymentFee,
-		&card.InternationalFeeRate, &card.CashAdvanceFee, &card.CashAdvanceFeeRate, &card.OverLimitFee,
-		&card.BillingCycleType, &card.BillingCycleDay, &card.PaymentDueDays, &card.GracePeriodDays,
-		&card.MinimumPaymentPercent, &card.MinimumPaymentAmount,
-		&card.CashbackEnabled, &card.CashbackRate, &card.CashbackRedemptionMin,
-		&card.Status, &card.LastStatementDate, &card.NextStatementDate,
-		&card.LastPaymentDate, &card.LastPaymentAmount, &card.ConsecutiveLateCount,
-		&card.CreatedAt, &card.UpdatedAt, &card.ClosedAt,
-	)
-
-	if err == sql.ErrNoRows {
+	card, err := s.store.CreditCards().GetByID(ctx, cardID)
+	if errors.Is(err, repository.ErrNotFound) {
 		return nil, fmt.Errorf("credit card not found: %s", cardID)
 	}
 	if err != nil {
@@ -687,66 +1005,73 @@ func (s *CreditCardService) GetCreditCard(ctx context.Context, cardID uuid.UUID)
 
 // GetCreditCardByTenant retrieves a credit card by tenant ID
 func (s *CreditCardService) GetCreditCardByTenant(ctx context.Context, tenantID uuid.UUID) (*models.CreditCard, error) {
-	query := `
-		SELECT id FROM credit_cards WHERE tenant_id = $1 AND status != 'closed' LIMIT 1
-	`
-
-	var cardID uuid.UUID
-	err := s.db.QueryRowContext(ctx, query, tenantID).Scan(&cardID)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("no active credit card found for tenant: %s", tenantID)
-	}
+	cards, err := s.store.CreditCards().List(ctx, repository.CreditCardFilter{
+		TenantID: &tenantID,
+		Statuses: []models.CreditCardStatus{
+			models.CreditCardStatusActive,
+			models.CreditCardStatusFrozen,
+			models.CreditCardStatusDelinquent,
+		},
+	})
 	if err != nil {
 		return nil, err
 	}
+	if len(cards) == 0 {
+		return nil, fmt.Errorf("no active credit card found for tenant: %s", tenantID)
+	}
 
-	return s.GetCreditCard(ctx, cardID)
+	return cards[0], nil
 }
 
-// UpdateCreditCardAPR updates the APR for a credit card
+// UpdateCreditCardAPR changes an APR for a credit card from today.
+// Increases need advance notice, so schedule them with ScheduleAPRChange.
 func (s *CreditCardService) UpdateCreditCardAPR(
 	ctx context.Context,
 	cardID uuid.UUID,
 	newAPR decimal.Decimal,
 	aprType string, // "purchase", "cash_advance", "penalty"
 ) error {
-	var column string
-	switch aprType {
-	case "purchase":
-		column = "purchase_apr"
-	case "cash_advance":
-		column = "cash_advance_apr"
-	case "penalty":
-		column = "penalty_apr"
-	default:
-		return fmt.Errorf("invalid APR type: %s", aprType)
-	}
-
-	query := fmt.Sprintf(`UPDATE credit_cards SET %s = $1, updated_at = $2 WHERE id = $3`, column)
-	_, err := s.db.ExecContext(ctx, query, newAPR, time.Now(), cardID)
+	_, err := s.ScheduleAPRChange(ctx, APRChangeRequest{
+		CreditCardID:  cardID,
+		APRType:       models.APRType(aprType),
+		APR:           newAPR,
+		EffectiveDate: time.Now(),
+	})
 	return err
 }
 
 // FreezeCard freezes a credit card account
 func (s *CreditCardService) FreezeCard(ctx context.Context, cardID uuid.UUID) error {
-	query := `UPDATE credit_cards SET status = $1, updated_at = $2 WHERE id = $3`
-	_, err := s.db.ExecContext(ctx, query, models.CreditCardStatusFrozen, time.Now(), cardID)
-	return err
+	return s.updateCard(ctx, cardID, func(card *models.CreditCard) {
+		card.Status = models.CreditCardStatusFrozen
+	})
 }
 
-// UnfreezeCard unfreezes a credit card account
+// UnfreezeCard unfreezes a credit card account. A charged-off card stays
+// frozen.
 func (s *CreditCardService) UnfreezeCard(ctx context.Context, cardID uuid.UUID) error {
-	query := `UPDATE credit_cards SET status = $1, updated_at = $2 WHERE id = $3`
-	_, err := s.db.ExecContext(ctx, query, models.CreditCardStatusActive, time.Now(), cardID)
-	return err
+	return s.store.WithinTx(ctx, func(tx repository.Store) error {
+		card, err := tx.CreditCards().GetForUpdate(ctx, cardID)
+		if err != nil {
+			return err
+		}
+		if card.IsChargedOff() {
+			return models.ErrCardChargedOff
+		}
+
+		card.Status = models.CreditCardStatusActive
+		card.UpdatedAt = time.Now()
+		return tx.CreditCards().Update(ctx, card)
+	})
 }
 
 // CloseCard closes a credit card account
 func (s *CreditCardService) CloseCard(ctx context.Context, cardID uuid.UUID) error {
 	now := time.Now()
-	query := `UPDATE credit_cards SET status = $1, closed_at = $2, updated_at = $2 WHERE id = $3`
-	_, err := s.db.ExecContext(ctx, query, models.CreditCardStatusClosed, now, cardID)
-	return err
+	return s.updateCard(ctx, cardID, func(card *models.CreditCard) {
+		card.Status = models.CreditCardStatusClosed
+		card.ClosedAt = &now
+	})
 }
 
 // updateAvailableCredit updates the available credit for a card
@@ -755,9 +1080,9 @@ func (s *CreditCardService) updateAvailableCredit(
 	cardID uuid.UUID,
 	newCredit decimal.Decimal,
 ) error {
-	query := `UPDATE credit_cards SET available_credit = $1, updated_at = $2 WHERE id = $3`
-	_, err := s.db.ExecContext(ctx, query, newCredit, time.Now(), cardID)
-	return err
+	return s.updateCard(ctx, cardID, func(card *models.CreditCard) {
+		card.AvailableCredit = newCredit
+	})
 }
 
 // updateLastPayment updates the last payment information
@@ -767,11 +1092,36 @@ func (s *CreditCardService) updateLastPayment(
 	paymentDate time.Time,
 	amount decimal.Decimal,
 ) error {
-	query := `
-		UPDATE credit_cards
-		SET last_payment_date = $1, last_payment_amount = $2, updated_at = $3
-		WHERE id = $4
-	`
-	_, err := s.db.ExecContext(ctx, query, paymentDate, amount, time.Now(), cardID)
-	return err
+	return s.updateCard(ctx, cardID, func(card *models.CreditCard) {
+		card.LastPaymentDate = &paymentDate
+		card.LastPaymentAmount = amount
+	})
+}
+
+// lockCard loads a card and locks its row until the transaction ends
+func (s *CreditCardService) lockCard(ctx context.Context, cardID uuid.UUID) (*models.CreditCard, error) {
+	card, err := s.store.CreditCards().GetForUpdate(ctx, cardID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("credit card not found: %s", cardID)
+	}
+	return card, err
+}
+
+// updateCard locks a card, applies mutate and writes it back in one transaction
+func (s *CreditCardService) updateCard(
+	ctx context.Context,
+	cardID uuid.UUID,
+	mutate func(card *models.CreditCard),
+) error {
+	return s.store.WithinTx(ctx, func(tx repository.Store) error {
+		card, err := tx.CreditCards().GetForUpdate(ctx, cardID)
+		if err != nil {
+			return err
+		}
+
+		mutate(card)
+		card.UpdatedAt = time.Now()
+
+		return tx.CreditCards().Update(ctx, card)
+	})
 }
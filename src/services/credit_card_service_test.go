@@ -0,0 +1,417 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// The memory store runs every transaction behind one mutex, so this only
+// checks that purchases against a stale snapshot are booked against the
+// stored card. TestCreditCardService_ConcurrentPurchasesPostgres exercises
+// the SELECT ... FOR UPDATE row lock.
+func TestCreditCardService_ConcurrentPurchases(t *testing.T) {
+	testConcurrentPurchases(t, memory.NewStore())
+}
+
+func TestCreditCardService_ConcurrentPurchasesPostgres(t *testing.T) {
+	testConcurrentPurchases(t, postgres.NewStore(openPostgres(t)))
+}
+
+func testConcurrentPurchases(t *testing.T, store repository.Store) {
+	const purchases = 300
+
+	ctx := context.Background()
+	service := NewCreditCardServiceWithStore(store)
+
+	// 300 purchases of 20 against a 5000 limit: exactly 250 fit
+	card := testCard()
+	card.CashbackEnabled = false
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		approved int
+		declined int
+	)
+	for i := 0; i < purchases; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Every caller holds the same stale snapshot of the card
+			_, err := service.RecordTransaction(ctx, CCTransactionRequest{
+				CreditCard:      card,
+				Amount:          decimal.NewFromInt(20),
+				Description:     "Coffee",
+				MerchantName:    "Test Merchant",
+				TransactionDate: time.Now(),
+				PostingDate:     time.Now(),
+			})
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				approved++
+			case errors.Is(err, models.ErrInsufficientCredit):
+				declined++
+			default:
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if approved != 250 || declined != 50 {
+		t.Errorf("approved %d and declined %d, want 250 and 50", approved, declined)
+	}
+
+	entries, err := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+	if err != nil {
+		t.Fatalf("failed to list entries: %v", err)
+	}
+
+	updated, err := store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to load card: %v", err)
+	}
+
+	// Available credit must agree with what the ledger says was charged
+	want := updated.CreditLimit.Sub(sumSignedAmounts(entries))
+	if !updated.AvailableCredit.Equal(want) {
+		t.Errorf("available credit = %s, ledger implies %s", updated.AvailableCredit, want)
+	}
+	if !updated.AvailableCredit.IsZero() {
+		t.Errorf("available credit = %s, want 0", updated.AvailableCredit)
+	}
+}
+
+func TestCreditCardService_PostingsUseStoredTerms(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	service := NewCreditCardServiceWithStore(store)
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+	day := time.Now()
+
+	// The caller's snapshot has another tenant, no fees and no cashback; the
+	// stored card has the default terms
+	stale := *card
+	stale.TenantID = uuid.New()
+	stale.InternationalFeeRate = decimal.Zero
+	stale.CashAdvanceAPR = decimal.Zero
+	stale.CashAdvanceFee = decimal.Zero
+	stale.CashAdvanceFeeRate = decimal.Zero
+	stale.BalanceTransferFee = decimal.Zero
+	stale.BalanceTransferFeeRate = decimal.Zero
+	stale.FailedPaymentFee = decimal.Zero
+	stale.CashbackEnabled = false
+
+	purchase, err := service.RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:      &stale,
+		Amount:          decimal.NewFromInt(100),
+		Description:     "Hotel",
+		MerchantName:    "Test Merchant",
+		IsInternational: true,
+		CurrencyCode:    "EUR",
+		ExchangeRate:    decimal.NewFromInt(1),
+		CountryCode:     "FR",
+		TransactionDate: day,
+		PostingDate:     day,
+	})
+	if err != nil {
+		t.Fatalf("failed to record transaction: %v", err)
+	}
+	if purchase.InternationalFee == nil || !purchase.InternationalFee.FeeAmount.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("international fee = %+v, want 3", purchase.InternationalFee)
+	}
+	if purchase.CashbackEntry == nil {
+		t.Error("no cashback earned on a card with cashback enabled")
+	}
+
+	advance, err := service.RecordCashAdvance(ctx, CashAdvanceRequest{
+		CreditCard:      &stale,
+		Amount:          decimal.NewFromInt(100),
+		ATMLocation:     "Main St",
+		TransactionDate: day,
+	})
+	if err != nil {
+		t.Fatalf("failed to record cash advance: %v", err)
+	}
+	if advance.FeeEntry == nil || !advance.FeeEntry.FeeAmount.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("cash advance fee = %+v, want 10", advance.FeeEntry)
+	}
+	if apr := advance.CashAdvanceEntry.Metadata["cash_advance_apr"]; apr != card.CashAdvanceAPR.String() {
+		t.Errorf("cash advance APR = %v, want %s", apr, card.CashAdvanceAPR)
+	}
+
+	transfer, err := service.RecordBalanceTransfer(ctx, BalanceTransferRequest{
+		CreditCard:      &stale,
+		Amount:          decimal.NewFromInt(1000),
+		Creditor:        "Other Bank",
+		TransactionDate: day,
+	})
+	if err != nil {
+		t.Fatalf("failed to record balance transfer: %v", err)
+	}
+	if transfer.FeeEntry == nil || !transfer.FeeEntry.FeeAmount.Equal(decimal.NewFromInt(30)) {
+		t.Errorf("balance transfer fee = %+v, want 30", transfer.FeeEntry)
+	}
+
+	refund, err := service.RecordRefund(ctx, CCRefundRequest{
+		CreditCard:            &stale,
+		OriginalTransactionID: purchase.TransactionEntry.ID,
+		RefundAmount:          decimal.NewFromInt(50),
+		RefundDate:            day,
+		PostingDate:           day,
+	})
+	if err != nil {
+		t.Fatalf("failed to record refund: %v", err)
+	}
+	if refund.CashbackAdjust == nil {
+		t.Error("refund did not take back its cashback")
+	}
+
+	payment, err := service.RecordPayment(ctx, CCPaymentRequest{
+		CreditCard:  &stale,
+		Amount:      decimal.NewFromInt(100),
+		PaymentDate: day,
+		PostingDate: day,
+	})
+	if err != nil {
+		t.Fatalf("failed to record payment: %v", err)
+	}
+	returned, err := service.RecordFailedPayment(ctx, FailedPaymentRequest{
+		CreditCard:      &stale,
+		OriginalPayment: payment.PaymentEntry,
+		FailureReason:   "NSF",
+		FailureDate:     day,
+	})
+	if err != nil {
+		t.Fatalf("failed to record failed payment: %v", err)
+	}
+	if returned.FeeEntry == nil || !returned.FeeEntry.FeeAmount.Equal(card.FailedPaymentFee) {
+		t.Errorf("returned payment fee = %+v, want %s", returned.FeeEntry, card.FailedPaymentFee)
+	}
+
+	if _, err := service.RecordAdjustment(ctx, AdjustmentRequest{
+		CreditCard:     &stale,
+		Amount:         decimal.NewFromInt(25),
+		AdjustmentDate: day,
+		Reason:         "Goodwill reversal",
+		ApprovedBy:     "ops",
+	}); err != nil {
+		t.Fatalf("failed to record adjustment: %v", err)
+	}
+
+	// Every entry posts to the stored card's tenant
+	misposted, err := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &stale.TenantID})
+	if err != nil {
+		t.Fatalf("failed to list entries: %v", err)
+	}
+	if len(misposted) != 0 {
+		t.Errorf("%d entries posted to the snapshot's tenant", len(misposted))
+	}
+}
+
+func TestCreditCardService_RecordBalanceTransfer(t *testing.T) {
+	transferDate := time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC)
+	promoEnd := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+	zero := decimal.Zero
+
+	tests := []struct {
+		name          string
+		activePromo   *time.Time // Promotion already on the card, at 0%
+		amount        decimal.Decimal
+		wantErr       bool
+		wantFee       decimal.Decimal
+		wantAvailable decimal.Decimal
+	}{
+		{
+			name:          "Percentage fee",
+			amount:        decimal.NewFromInt(2000),
+			wantFee:       decimal.NewFromInt(60), // 3% of 2000
+			wantAvailable: decimal.NewFromInt(2940),
+		},
+		{
+			name:          "Minimum fee",
+			amount:        decimal.NewFromInt(100),
+			wantFee:       decimal.NewFromInt(5),
+			wantAvailable: decimal.NewFromInt(4895),
+		},
+		{
+			name:          "Joins the matching promotion",
+			activePromo:   &promoEnd,
+			amount:        decimal.NewFromInt(100),
+			wantFee:       decimal.NewFromInt(5),
+			wantAvailable: decimal.NewFromInt(4895),
+		},
+		{
+			name:        "Conflicting promotion",
+			activePromo: timePtr(promoEnd.AddDate(0, 1, 0)),
+			amount:      decimal.NewFromInt(100),
+			wantErr:     true,
+		},
+		{
+			name:    "Fee over the credit limit",
+			amount:  decimal.NewFromInt(4900),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			card.BalanceTransferEndDate = tt.activePromo
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+
+			result, err := NewCreditCardServiceWithStore(store).RecordBalanceTransfer(ctx, BalanceTransferRequest{
+				CreditCard:      card,
+				Amount:          tt.amount,
+				Creditor:        "Other Bank",
+				TransactionDate: transferDate,
+				ReferenceID:     "BT-1",
+				PromoAPR:        &zero,
+				PromoEndDate:    &promoEnd,
+			})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if segment := card.SegmentOf(result.TransferEntry); segment != models.SegmentBalanceTransfer {
+				t.Errorf("transfer segment = %s, want balance_transfer", segment)
+			}
+			if result.FeeEntry == nil || !result.FeeEntry.FeeAmount.Equal(tt.wantFee) {
+				t.Errorf("fee = %+v, want %s", result.FeeEntry, tt.wantFee)
+			}
+			if !result.PromoAPR.IsZero() || result.PromoEndDate == nil || !result.PromoEndDate.Equal(promoEnd) {
+				t.Errorf("promotion = %s until %v, want 0 until %s", result.PromoAPR, result.PromoEndDate, promoEnd)
+			}
+
+			updated, err := store.CreditCards().GetByID(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to load card: %v", err)
+			}
+			if !updated.AvailableCredit.Equal(tt.wantAvailable) {
+				t.Errorf("available credit = %s, want %s", updated.AvailableCredit, tt.wantAvailable)
+			}
+			if !updated.HasBalanceTransferPromo(transferDate) {
+				t.Error("card has no balance transfer promotion")
+			}
+		})
+	}
+}
+
+func TestBillingService_BalanceTransferPromoEnds(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	cycleStart := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	promoEnd := time.Date(2026, time.January, 21, 0, 0, 0, 0, time.UTC)
+	zero := decimal.Zero
+
+	card := testCard()
+	card.CreatedAt = cycleStart
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+	// A previous statement that was not paid off, so there is no grace period
+	if err := store.BillingCycles().Create(ctx, &models.BillingCycle{
+		CreditCardID: card.ID,
+		TenantID:     card.TenantID,
+		CycleNumber:  1,
+		NewBalance:   decimal.NewFromInt(500),
+		PaymentsMade: decimal.NewFromInt(100),
+		Status:       models.BillingCycleStatusPaid,
+	}); err != nil {
+		t.Fatalf("failed to create previous cycle: %v", err)
+	}
+
+	transfer, err := NewCreditCardServiceWithStore(store).RecordBalanceTransfer(ctx, BalanceTransferRequest{
+		CreditCard:      card,
+		Amount:          decimal.NewFromInt(1000),
+		Creditor:        "Other Bank",
+		TransactionDate: cycleStart,
+		PromoAPR:        &zero,
+		PromoEndDate:    &promoEnd,
+	})
+	if err != nil {
+		t.Fatalf("failed to record transfer: %v", err)
+	}
+	ledger := NewStatementLedgerServiceWithStore(store)
+	for _, id := range []uuid.UUID{transfer.TransferEntry.ID, transfer.FeeEntry.EntryID} {
+		if err := ledger.ClearEntry(ctx, id); err != nil {
+			t.Fatalf("failed to clear entry: %v", err)
+		}
+	}
+
+	card, err = store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to load card: %v", err)
+	}
+	result, err := NewBillingServiceWithStore(store).GenerateStatement(ctx, GenerateStatementRequest{
+		CreditCard: card,
+		CycleEnd:   cycleEnd,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The transfer is free for the 20 days of the promotion. The 30 fee is a
+	// purchase all cycle and the transfer joins it for the last 11 days:
+	// ADB (30 × 31 + 1000 × 11) / 31 × 19.99% / 365 × 31
+	wantInterest := decimal.NewFromFloat(6.53)
+	cycle := result.BillingCycle
+	if !cycle.InterestAmount.Equal(wantInterest) {
+		t.Errorf("interest = %s, want %s (segments %+v)", cycle.InterestAmount, wantInterest, cycle.Segments)
+	}
+	if !cycle.BalanceTransfersAmount.Equal(decimal.NewFromInt(1000)) {
+		t.Errorf("balance transfers = %s, want 1000", cycle.BalanceTransfersAmount)
+	}
+	if want := decimal.NewFromFloat(1436.53); !cycle.NewBalance.Equal(want) {
+		t.Errorf("new balance = %s, want %s", cycle.NewBalance, want)
+	}
+
+	updated, err := store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to load card: %v", err)
+	}
+	if updated.BalanceTransferEndDate != nil {
+		t.Errorf("promotion still ends %s after rolling", updated.BalanceTransferEndDate)
+	}
+
+	// Nothing is left to roll the next time
+	rolled, err := NewCreditCardServiceWithStore(store).RollExpiredBalanceTransferPromo(ctx, card.ID, cycleEnd)
+	if err != nil || rolled != nil {
+		t.Errorf("second roll = %v, %v; want nothing", rolled, err)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
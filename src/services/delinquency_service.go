@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// DelinquencyService ages cards through the delinquency buckets and charges
+// off balances that reach ChargeOffDays past due
+type DelinquencyService struct {
+	store         repository.Store
+	ledgerService *StatementLedgerService
+}
+
+// NewDelinquencyService creates a new delinquency service
+func NewDelinquencyService(db Querier) *DelinquencyService {
+	return NewDelinquencyServiceWithStore(postgres.NewStore(db))
+}
+
+// NewDelinquencyServiceWithStore creates a delinquency service backed by store
+func NewDelinquencyServiceWithStore(store repository.Store) *DelinquencyService {
+	return &DelinquencyService{
+		store:         store,
+		ledgerService: NewStatementLedgerServiceWithStore(store),
+	}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *DelinquencyService) withStore(store repository.Store) *DelinquencyService {
+	return NewDelinquencyServiceWithStore(store)
+}
+
+// DelinquencyResult is a card's delinquency as of a date
+type DelinquencyResult struct {
+	CreditCardID     uuid.UUID
+	DaysPastDue      int
+	Bucket           models.DelinquencyBucket
+	Record           *models.DelinquencyRecord      // Nil when the card stayed in its bucket
+	ChargeOffEntries []*models.StatementLedgerEntry // Posted when the card was charged off
+	ChargeOff        *models.ChargeOff              // Balance held for collection once charged off
+}
+
+// AgeDelinquencies ages every open card as of asOf and returns the cards that
+// moved to another bucket. Each card is aged in its own transaction; run it
+// daily. A card that fails to age does not stop the others: the cards that
+// moved are returned along with an error naming each card that failed.
+func (s *DelinquencyService) AgeDelinquencies(ctx context.Context, asOf time.Time) ([]*DelinquencyResult, error) {
+	cards, err := s.store.CreditCards().List(ctx, repository.CreditCardFilter{
+		Statuses: []models.CreditCardStatus{
+			models.CreditCardStatusActive,
+			models.CreditCardStatusFrozen,
+			models.CreditCardStatusDelinquent,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credit cards: %w", err)
+	}
+
+	var (
+		moved    []*DelinquencyResult
+		failures []error
+	)
+	for _, card := range cards {
+		if card.IsChargedOff() {
+			continue
+		}
+		result, err := s.AgeCard(ctx, card.ID, asOf)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to age card %s: %w", card.ID, err))
+			continue
+		}
+		if result.Record != nil {
+			moved = append(moved, result)
+		}
+	}
+
+	return moved, errors.Join(failures...)
+}
+
+// AgeCard works out how many days past due a card's oldest unpaid minimum
+// payment is on asOf and moves the card to that bucket, recording the move
+// in its aging history. Cycles DelinquentDays past due, and the card itself,
+// become delinquent; the card goes back to active once it is under
+// DelinquentDays again. At ChargeOffDays the balance is charged off: each
+// balance segment is written off with a charge_off credit and the card is
+// frozen, so no further interest or fees accrue on it. The written-off
+// balance is held for collection, split into principal, interest and fees.
+func (s *DelinquencyService) AgeCard(ctx context.Context, cardID uuid.UUID, asOf time.Time) (*DelinquencyResult, error) {
+	result := &DelinquencyResult{CreditCardID: cardID}
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		card, err := tx.CreditCards().GetForUpdate(ctx, cardID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit card: %w", err)
+		}
+		if card.IsChargedOff() {
+			result.DaysPastDue = card.DaysPastDue
+			result.Bucket = card.DelinquencyBucket
+			return nil
+		}
+
+		unpaid := false
+		cycles, err := tx.BillingCycles().List(ctx, repository.BillingCycleFilter{
+			CreditCardID: &card.ID,
+			Statuses: []models.BillingCycleStatus{
+				models.BillingCycleStatusClosed,
+				models.BillingCycleStatusPastDue,
+				models.BillingCycleStatusDelinquent,
+			},
+			MinimumPaymentMet: &unpaid,
+			DueBefore:         &asOf,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list overdue billing cycles: %w", err)
+		}
+
+		// The oldest unpaid minimum sets the days past due
+		var oldest *models.BillingCycle
+		pastDue := decimal.Zero
+		for _, cycle := range cycles {
+			if !cycle.MinimumPayment.IsPositive() {
+				continue
+			}
+			if oldest == nil || cycle.DueDate.Before(oldest.DueDate) {
+				oldest = cycle
+			}
+			pastDue = pastDue.Add(cycle.GetRemainingMinimum())
+
+			if cycle.DaysOverdue(asOf) >= models.DelinquentDays && cycle.Status != models.BillingCycleStatusDelinquent {
+				cycle.Status = models.BillingCycleStatusDelinquent
+				cycle.UpdatedAt = time.Now()
+				if err := tx.BillingCycles().Update(ctx, cycle); err != nil {
+					return fmt.Errorf("failed to update billing cycle: %w", err)
+				}
+			}
+		}
+
+		result.DaysPastDue = 0
+		if oldest != nil {
+			result.DaysPastDue = oldest.DaysOverdue(asOf)
+		}
+		result.Bucket = models.DelinquencyBucketFor(result.DaysPastDue)
+
+		previous := card.DelinquencyBucket
+		if previous == "" {
+			previous = models.DelinquencyBucketCurrent
+		}
+		card.DaysPastDue = result.DaysPastDue
+		card.DelinquencyBucket = result.Bucket
+
+		// A frozen card stays frozen
+		switch {
+		case result.DaysPastDue >= models.DelinquentDays && card.Status == models.CreditCardStatusActive:
+			card.Status = models.CreditCardStatusDelinquent
+		case result.DaysPastDue < models.DelinquentDays && card.Status == models.CreditCardStatusDelinquent:
+			card.Status = models.CreditCardStatusActive
+		}
+
+		chargeOffAmount := decimal.Zero
+		if result.DaysPastDue >= models.ChargeOffDays {
+			result.ChargeOffEntries, result.ChargeOff, err = txs.chargeOff(ctx, card, result.DaysPastDue, asOf)
+			if err != nil {
+				return err
+			}
+			chargeOffAmount = result.ChargeOff.Amount()
+			chargedOffAt := asOf
+			card.ChargedOffAt = &chargedOffAt
+			card.Status = models.CreditCardStatusFrozen
+			card.AvailableCredit = decimal.Zero
+		}
+
+		if result.Bucket != previous || card.IsChargedOff() {
+			result.Record = &models.DelinquencyRecord{
+				ID:              uuid.New(),
+				TenantID:        card.TenantID,
+				CreditCardID:    card.ID,
+				Bucket:          result.Bucket,
+				PreviousBucket:  previous,
+				DaysPastDue:     result.DaysPastDue,
+				PastDueAmount:   pastDue,
+				ChargeOffAmount: chargeOffAmount,
+				AsOf:            asOf,
+				CreatedAt:       time.Now(),
+			}
+			if oldest != nil {
+				result.Record.BillingCycleID = &oldest.ID
+			}
+			if err := tx.Delinquencies().Create(ctx, result.Record); err != nil {
+				return fmt.Errorf("failed to record delinquency: %w", err)
+			}
+		}
+
+		card.UpdatedAt = time.Now()
+		return tx.CreditCards().Update(ctx, card)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetDelinquencyHistory returns a card's aging history, oldest first
+func (s *DelinquencyService) GetDelinquencyHistory(ctx context.Context, cardID uuid.UUID) ([]*models.DelinquencyRecord, error) {
+	records, err := s.store.Delinquencies().List(ctx, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list delinquency history: %w", err)
+	}
+	return records, nil
+}
+
+// chargeOff writes off the card's balance as of asOf with one cleared
+// charge_off credit per balance segment, records the balance written off for
+// collection and returns the entries and the charge-off
+func (s *DelinquencyService) chargeOff(
+	ctx context.Context,
+	card *models.CreditCard,
+	daysPastDue int,
+	asOf time.Time,
+) ([]*models.StatementLedgerEntry, *models.ChargeOff, error) {
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID: &card.TenantID,
+		Statuses: []models.EntryStatus{models.EntryStatusPending, models.EntryStatusCleared},
+		PostedTo: &asOf,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list statement entries: %w", err)
+	}
+
+	ledger := newSegmentLedger(card)
+	for _, entry := range entries {
+		ledger.post(entry)
+	}
+
+	now := time.Now()
+	var posted []*models.StatementLedgerEntry
+	total := decimal.Zero
+	for _, segment := range models.BalanceSegments {
+		balance := ledger.balances[segment].Round(2)
+		if !balance.IsPositive() {
+			continue
+		}
+
+		entry := &models.StatementLedgerEntry{
+			ID:          uuid.New(),
+			TenantID:    card.TenantID,
+			EntryType:   models.EntryTypeChargeOff,
+			EntryDate:   asOf,
+			PostingDate: asOf,
+			Amount:      balance,
+			Description: fmt.Sprintf("Charge-off - %s balance %d days past due",
+				strings.ToLower(segment.Label()), daysPastDue),
+			Status:    models.EntryStatusCleared,
+			ClearedAt: &now,
+			Metadata: map[string]interface{}{
+				models.SegmentMetadataKey: string(segment),
+				"days_past_due":           daysPastDue,
+			},
+			CreatedAt: now,
+		}
+		if err := s.ledgerService.CreateEntry(ctx, entry); err != nil {
+			return nil, nil, fmt.Errorf("failed to create charge-off entry: %w", err)
+		}
+		posted = append(posted, entry)
+		total = total.Add(balance)
+	}
+
+	principal, interest, fees := splitChargeOff(entries, total)
+	chargeOff := &models.ChargeOff{
+		ID:                 uuid.New(),
+		TenantID:           card.TenantID,
+		CreditCardID:       card.ID,
+		Principal:          principal,
+		Interest:           interest,
+		Fees:               fees,
+		PrincipalRemaining: principal,
+		InterestRemaining:  interest,
+		FeesRemaining:      fees,
+		Recovered:          decimal.Zero,
+		ChargedOffOn:       asOf,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	if err := s.store.Recoveries().CreateChargeOff(ctx, chargeOff); err != nil {
+		return nil, nil, fmt.Errorf("failed to create charge-off: %w", err)
+	}
+
+	return posted, chargeOff, nil
+}
@@ -0,0 +1,574 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+// failingCardStore wraps a store so that locking one card fails
+type failingCardStore struct {
+	repository.Store
+	cardID uuid.UUID
+}
+
+type failingCards struct {
+	repository.CreditCardRepository
+	cardID uuid.UUID
+}
+
+func (s failingCardStore) CreditCards() repository.CreditCardRepository {
+	return failingCards{s.Store.CreditCards(), s.cardID}
+}
+
+func (s failingCardStore) WithinTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	return s.Store.WithinTx(ctx, func(tx repository.Store) error {
+		return fn(failingCardStore{tx, s.cardID})
+	})
+}
+
+func (r failingCards) GetForUpdate(ctx context.Context, id uuid.UUID) (*models.CreditCard, error) {
+	if id == r.cardID {
+		return nil, errors.New("forced failure")
+	}
+	return r.CreditCardRepository.GetForUpdate(ctx, id)
+}
+
+func TestDelinquencyService_AgeCard(t *testing.T) {
+	dueDate := penaltyDate(time.January, 25)
+
+	tests := []struct {
+		name       string
+		paidOn     *time.Time
+		asOf       time.Time
+		wantDays   int
+		wantBucket models.DelinquencyBucket
+		wantStatus models.CreditCardStatus
+		wantRecord bool
+	}{
+		{
+			name:       "Paid on time",
+			paidOn:     timePtr(penaltyDate(time.January, 20)),
+			asOf:       penaltyDate(time.March, 1),
+			wantBucket: models.DelinquencyBucketCurrent,
+			wantStatus: models.CreditCardStatusActive,
+		},
+		{
+			name:       "Not yet due",
+			asOf:       dueDate,
+			wantBucket: models.DelinquencyBucketCurrent,
+			wantStatus: models.CreditCardStatusActive,
+		},
+		{
+			name:       "One day past due",
+			asOf:       penaltyDate(time.January, 26),
+			wantDays:   1,
+			wantBucket: models.DelinquencyBucket1To29,
+			wantStatus: models.CreditCardStatusActive,
+			wantRecord: true,
+		},
+		{
+			name:       "29 days past due",
+			asOf:       penaltyDate(time.February, 23),
+			wantDays:   29,
+			wantBucket: models.DelinquencyBucket1To29,
+			wantStatus: models.CreditCardStatusActive,
+			wantRecord: true,
+		},
+		{
+			name:       "30 days past due",
+			asOf:       penaltyDate(time.February, 24),
+			wantDays:   30,
+			wantBucket: models.DelinquencyBucket30To59,
+			wantStatus: models.CreditCardStatusDelinquent,
+			wantRecord: true,
+		},
+		{
+			name:       "90 days past due",
+			asOf:       penaltyDate(time.April, 25),
+			wantDays:   90,
+			wantBucket: models.DelinquencyBucket90To119,
+			wantStatus: models.CreditCardStatusDelinquent,
+			wantRecord: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+			createDueCycle(t, store, card, 1, dueDate, tt.paidOn)
+			service := NewDelinquencyServiceWithStore(store)
+
+			result, err := service.AgeCard(ctx, card.ID, tt.asOf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.DaysPastDue != tt.wantDays {
+				t.Errorf("days past due = %d, want %d", result.DaysPastDue, tt.wantDays)
+			}
+			if result.Bucket != tt.wantBucket {
+				t.Errorf("bucket = %s, want %s", result.Bucket, tt.wantBucket)
+			}
+			if (result.Record != nil) != tt.wantRecord {
+				t.Errorf("record = %+v, want record %v", result.Record, tt.wantRecord)
+			}
+			if len(result.ChargeOffEntries) != 0 {
+				t.Errorf("unexpected charge-off entries: %d", len(result.ChargeOffEntries))
+			}
+
+			stored, err := store.CreditCards().GetByID(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to get card: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("card status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			if stored.DaysPastDue != tt.wantDays || stored.DelinquencyBucket != tt.wantBucket {
+				t.Errorf("card aged %d days in %s, want %d in %s",
+					stored.DaysPastDue, stored.DelinquencyBucket, tt.wantDays, tt.wantBucket)
+			}
+		})
+	}
+}
+
+func TestDelinquencyService_AgeDelinquenciesReportsFailedCards(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	dueDate := penaltyDate(time.January, 25)
+
+	cards := []*models.CreditCard{testCard(), testCard(), testCard()}
+	for _, card := range cards {
+		if err := store.CreditCards().Create(ctx, card); err != nil {
+			t.Fatalf("failed to create card: %v", err)
+		}
+		createDueCycle(t, store, card, 1, dueDate, nil)
+	}
+	failing := cards[1]
+
+	service := NewDelinquencyServiceWithStore(failingCardStore{store, failing.ID})
+	moved, err := service.AgeDelinquencies(ctx, dueDate.AddDate(0, 0, 30))
+	if err == nil || !strings.Contains(err.Error(), failing.ID.String()) {
+		t.Errorf("error = %v, want one naming card %s", err, failing.ID)
+	}
+
+	// Every other card was still aged and committed
+	if len(moved) != len(cards)-1 {
+		t.Fatalf("moved %d cards, want %d", len(moved), len(cards)-1)
+	}
+	for _, card := range cards {
+		stored, err := store.CreditCards().GetByID(ctx, card.ID)
+		if err != nil {
+			t.Fatalf("failed to get card: %v", err)
+		}
+		want := models.DelinquencyBucket30To59
+		if card.ID == failing.ID {
+			want = models.DelinquencyBucketCurrent
+		}
+		if stored.DelinquencyBucket != want {
+			t.Errorf("card %s bucket = %s, want %s", card.ID, stored.DelinquencyBucket, want)
+		}
+	}
+}
+
+func TestDelinquencyService_DelinquentCardKeepsContractAPR(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+	purchase := &models.StatementLedgerEntry{
+		TenantID:    card.TenantID,
+		EntryType:   models.EntryTypeTransaction,
+		EntryDate:   penaltyDate(time.January, 5),
+		PostingDate: penaltyDate(time.January, 5),
+		Amount:      decimal.NewFromInt(1000),
+		Status:      models.EntryStatusCleared,
+	}
+	if err := store.StatementEntries().Create(ctx, purchase); err != nil {
+		t.Fatalf("failed to create entry: %v", err)
+	}
+	createDueCycle(t, store, card, 1, penaltyDate(time.January, 25), nil)
+
+	// 45 days past due: delinquent, but short of the penalty trigger
+	result, err := NewDelinquencyServiceWithStore(store).AgeCard(ctx, card.ID, penaltyDate(time.March, 11))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Bucket != models.DelinquencyBucket30To59 {
+		t.Fatalf("bucket = %s, want %s", result.Bucket, models.DelinquencyBucket30To59)
+	}
+	stored, err := store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if stored.Status != models.CreditCardStatusDelinquent {
+		t.Fatalf("card status = %s, want %s", stored.Status, models.CreditCardStatusDelinquent)
+	}
+
+	cycle := &models.BillingCycle{
+		ID:             uuid.New(),
+		CreditCardID:   card.ID,
+		TenantID:       card.TenantID,
+		CycleNumber:    2, // Follows the unpaid cycle, so no grace period
+		CycleStartDate: penaltyDate(time.March, 1),
+		CycleEndDate:   penaltyDate(time.March, 31),
+		Status:         models.BillingCycleStatusOpen,
+	}
+	interest, err := NewInterestServiceWithStore(store).CalculateInterest(ctx, stored, cycle, DefaultInterestConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !interest.APRUsed.Equal(card.PurchaseAPR) {
+		t.Errorf("APR used = %s, want purchase APR %s", interest.APRUsed, card.PurchaseAPR)
+	}
+	if len(interest.Segments) == 0 || !interest.InterestCharge.IsPositive() {
+		t.Fatalf("interest = %s over %d segments, want a charge", interest.InterestCharge, len(interest.Segments))
+	}
+	for _, segment := range interest.Segments {
+		if !segment.APR.Equal(card.PurchaseAPR) {
+			t.Errorf("%s segment APR = %s, want purchase APR %s", segment.Segment, segment.APR, card.PurchaseAPR)
+		}
+	}
+}
+
+func TestDelinquencyService_ChargedOffCardRejectsLateActivity(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+	day := penaltyDate(time.January, 5)
+
+	// A payment that cleared and two disputed charges, one with provisional credit
+	purchases := make([]*models.StatementLedgerEntry, 2)
+	for i := range purchases {
+		purchase, err := NewCreditCardServiceWithStore(store).RecordTransaction(ctx, CCTransactionRequest{
+			CreditCard:      card,
+			Amount:          decimal.NewFromInt(300),
+			Description:     "Headphones",
+			MerchantName:    "Test Electronics",
+			TransactionDate: day,
+			PostingDate:     day,
+		})
+		if err != nil {
+			t.Fatalf("failed to record transaction: %v", err)
+		}
+		purchases[i] = purchase.TransactionEntry
+	}
+
+	payments := NewPaymentServiceWithStore(store)
+	initiated, err := payments.InitiatePayment(ctx, InitiatePaymentRequest{
+		TenantID:      card.TenantID,
+		CreditCardID:  card.ID,
+		Amount:        decimal.NewFromInt(100),
+		PaymentType:   models.PaymentTypeRegular,
+		PaymentMethod: models.PaymentMethodACH,
+		CreatedBy:     "user",
+	})
+	if err != nil {
+		t.Fatalf("failed to initiate payment: %v", err)
+	}
+	payment := initiated.Payment
+	if _, err := payments.ProcessPayment(ctx, payment.ID, "REF-1"); err != nil {
+		t.Fatalf("failed to process payment: %v", err)
+	}
+	if _, err := payments.ClearPayment(ctx, payment.ID, "CONF-1"); err != nil {
+		t.Fatalf("failed to clear payment: %v", err)
+	}
+
+	disputes := NewDisputeServiceWithStore(store)
+	opened := make([]*models.Dispute, len(purchases))
+	for i, purchase := range purchases {
+		result, err := disputes.OpenDispute(ctx, OpenDisputeRequest{
+			CreditCard:       card,
+			StatementEntryID: purchase.ID,
+			Reason:           models.DisputeReasonNotReceived,
+			NoticeReceivedAt: day,
+		})
+		if err != nil {
+			t.Fatalf("failed to open dispute: %v", err)
+		}
+		opened[i] = result.Dispute
+	}
+	if _, err := disputes.PostProvisionalCredit(ctx, opened[0].ID, day); err != nil {
+		t.Fatalf("failed to post provisional credit: %v", err)
+	}
+
+	stored, err := store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	chargedOffAt := day.AddDate(0, 6, 0)
+	stored.ChargedOffAt = &chargedOffAt
+	stored.Status = models.CreditCardStatusFrozen
+	stored.AvailableCredit = decimal.Zero
+	if err := store.CreditCards().Update(ctx, stored); err != nil {
+		t.Fatalf("failed to update card: %v", err)
+	}
+	before, err := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+	if err != nil {
+		t.Fatalf("failed to list entries: %v", err)
+	}
+
+	later := chargedOffAt.AddDate(0, 0, 1)
+	if _, err := payments.ReturnPayment(ctx, payment.ID, models.ACHReturnR01); !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("ReturnPayment error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+	if _, err := payments.ReversePayment(ctx, payment.ID, "Duplicate", "ops"); !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("ReversePayment error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+	if _, err := disputes.ResolveForMerchant(ctx, opened[0].ID, "Signed delivery receipt", later); !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("ResolveForMerchant error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+	if _, err := disputes.PostProvisionalCredit(ctx, opened[1].ID, later); !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("PostProvisionalCredit error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+
+	after, err := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+	if err != nil {
+		t.Fatalf("failed to list entries: %v", err)
+	}
+	if len(after) != len(before) {
+		t.Errorf("%d entries posted after charge-off", len(after)-len(before))
+	}
+	charged, err := store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if !charged.AvailableCredit.IsZero() {
+		t.Errorf("available credit = %s, want 0", charged.AvailableCredit)
+	}
+}
+
+func TestDelinquencyService_AgingHistory(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+	createDueCycle(t, store, card, 1, penaltyDate(time.January, 25), nil)
+	service := NewDelinquencyServiceWithStore(store)
+
+	// Aged daily; only the days that move the card to a new bucket are kept
+	for day := penaltyDate(time.January, 20); day.Before(penaltyDate(time.March, 31)); day = day.AddDate(0, 0, 1) {
+		if _, err := service.AgeDelinquencies(ctx, day); err != nil {
+			t.Fatalf("unexpected error on %s: %v", day.Format("2006-01-02"), err)
+		}
+	}
+
+	history, err := service.GetDelinquencyHistory(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct {
+		previous, bucket models.DelinquencyBucket
+		asOf             time.Time
+	}{
+		{models.DelinquencyBucketCurrent, models.DelinquencyBucket1To29, penaltyDate(time.January, 26)},
+		{models.DelinquencyBucket1To29, models.DelinquencyBucket30To59, penaltyDate(time.February, 24)},
+		{models.DelinquencyBucket30To59, models.DelinquencyBucket60To89, penaltyDate(time.March, 26)},
+	}
+	if len(history) != len(want) {
+		t.Fatalf("history has %d records, want %d: %+v", len(history), len(want), history)
+	}
+	for i, record := range history {
+		if record.PreviousBucket != want[i].previous || record.Bucket != want[i].bucket || !record.AsOf.Equal(want[i].asOf) {
+			t.Errorf("record %d = %s -> %s on %s, want %s -> %s on %s", i,
+				record.PreviousBucket, record.Bucket, record.AsOf.Format("2006-01-02"),
+				want[i].previous, want[i].bucket, want[i].asOf.Format("2006-01-02"))
+		}
+		if !record.PastDueAmount.Equal(decimal.NewFromInt(35)) {
+			t.Errorf("record %d past due = %s, want 35", i, record.PastDueAmount)
+		}
+	}
+
+	cycles, err := store.BillingCycles().List(ctx, repository.BillingCycleFilter{CreditCardID: &card.ID})
+	if err != nil {
+		t.Fatalf("failed to get billing cycles: %v", err)
+	}
+	if cycles[0].Status != models.BillingCycleStatusDelinquent {
+		t.Errorf("cycle status = %s, want %s", cycles[0].Status, models.BillingCycleStatusDelinquent)
+	}
+
+	// Bringing the minimum up to date returns the card to active
+	cycles[0].PaymentsMade = cycles[0].MinimumPayment
+	cycles[0].MinimumPaymentMet = true
+	cycles[0].Status = models.BillingCycleStatusPaid
+	if err := store.BillingCycles().Update(ctx, cycles[0]); err != nil {
+		t.Fatalf("failed to update cycle: %v", err)
+	}
+	result, err := service.AgeCard(ctx, card.ID, penaltyDate(time.April, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Bucket != models.DelinquencyBucketCurrent || result.Record == nil {
+		t.Fatalf("cured card aged into %s with record %+v", result.Bucket, result.Record)
+	}
+	stored, err := store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if stored.Status != models.CreditCardStatusActive {
+		t.Errorf("card status = %s, want %s", stored.Status, models.CreditCardStatusActive)
+	}
+}
+
+func TestDelinquencyService_ChargeOff(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	posted := penaltyDate(time.January, 5)
+	entries := []*models.StatementLedgerEntry{
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(800)},
+		{EntryType: models.EntryTypeCashAdvance, Amount: decimal.NewFromInt(200)},
+		{EntryType: models.EntryTypeFeeLate, Amount: decimal.NewFromInt(40)},
+	}
+	for _, entry := range entries {
+		entry.TenantID = card.TenantID
+		entry.EntryDate = posted
+		entry.PostingDate = posted
+		entry.Status = models.EntryStatusCleared
+		if err := store.StatementEntries().Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create entry: %v", err)
+		}
+	}
+	dueDate := penaltyDate(time.January, 25)
+	createDueCycle(t, store, card, 1, dueDate, nil)
+	service := NewDelinquencyServiceWithStore(store)
+
+	result, err := service.AgeCard(ctx, card.ID, dueDate.AddDate(0, 0, 179))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.ChargeOffEntries) != 0 {
+		t.Fatalf("charged off at %d days past due", result.DaysPastDue)
+	}
+
+	chargeOffDate := dueDate.AddDate(0, 0, models.ChargeOffDays)
+	result, err = service.AgeCard(ctx, card.ID, chargeOffDate)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Bucket != models.DelinquencyBucket180Plus {
+		t.Errorf("bucket = %s, want %s", result.Bucket, models.DelinquencyBucket180Plus)
+	}
+
+	// One write-off per segment; fees sit in the purchase segment
+	want := map[models.BalanceSegment]decimal.Decimal{
+		models.SegmentPurchase:    decimal.NewFromInt(840),
+		models.SegmentCashAdvance: decimal.NewFromInt(200),
+	}
+	if len(result.ChargeOffEntries) != len(want) {
+		t.Fatalf("got %d charge-off entries, want %d", len(result.ChargeOffEntries), len(want))
+	}
+	for _, entry := range result.ChargeOffEntries {
+		segment := models.BalanceSegment(entry.Metadata[models.SegmentMetadataKey].(string))
+		if entry.EntryType != models.EntryTypeChargeOff || !entry.Amount.Equal(want[segment]) {
+			t.Errorf("%s charge-off = %s %s, want %s", segment, entry.EntryType, entry.Amount, want[segment])
+		}
+	}
+	if result.Record == nil || !result.Record.ChargeOffAmount.Equal(decimal.NewFromInt(1040)) {
+		t.Errorf("charge-off record = %+v, want 1040 charged off", result.Record)
+	}
+
+	balance, err := NewStatementLedgerServiceWithStore(store).GetBalance(ctx, card.TenantID)
+	if err != nil {
+		t.Fatalf("failed to get balance: %v", err)
+	}
+	if !balance.CurrentBalance.IsZero() {
+		t.Errorf("balance after charge-off = %s, want 0", balance.CurrentBalance)
+	}
+
+	charged, err := store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if !charged.IsChargedOff() || charged.Status != models.CreditCardStatusFrozen {
+		t.Fatalf("card status = %s, charged off at %v; want frozen and charged off", charged.Status, charged.ChargedOffAt)
+	}
+	cards := NewCreditCardServiceWithStore(store)
+	if err := cards.UnfreezeCard(ctx, card.ID); !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("UnfreezeCard error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+
+	// No new receivable activity posts to the written-off balance
+	_, err = cards.RecordRefund(ctx, CCRefundRequest{
+		CreditCard:   card,
+		RefundAmount: decimal.NewFromInt(50),
+		RefundDate:   chargeOffDate,
+		PostingDate:  chargeOffDate,
+	})
+	if !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("RecordRefund error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+	_, err = cards.RecordAdjustment(ctx, AdjustmentRequest{
+		CreditCard:     card,
+		Amount:         decimal.NewFromInt(25),
+		AdjustmentDate: chargeOffDate,
+	})
+	if !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("RecordAdjustment error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+	_, err = cards.RecordFailedPayment(ctx, FailedPaymentRequest{
+		CreditCard: card,
+		OriginalPayment: &models.StatementLedgerEntry{
+			EntryType: models.EntryTypePayment,
+			Amount:    decimal.NewFromInt(100),
+		},
+		FailureDate: chargeOffDate,
+	})
+	if !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("RecordFailedPayment error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+
+	// Nothing more accrues on the card, and it is no longer aged
+	cycles, err := store.BillingCycles().List(ctx, repository.BillingCycleFilter{CreditCardID: &card.ID})
+	if err != nil {
+		t.Fatalf("failed to get billing cycles: %v", err)
+	}
+	// The caller's snapshot predates the charge-off; the fee check reads the stored card
+	fee, err := NewFeeServiceWithStore(store).AssessLatePaymentFee(ctx, LatePaymentFeeRequest{
+		CreditCard:   card,
+		BillingCycle: cycles[0],
+		CurrentDate:  chargeOffDate,
+		DaysOverdue:  models.ChargeOffDays,
+	})
+	if err != nil || fee != nil {
+		t.Errorf("late fee after charge-off = %+v, %v; want none", fee, err)
+	}
+	interest, err := NewInterestServiceWithStore(store).CalculateInterest(ctx, charged, cycles[0], DefaultInterestConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !interest.InterestCharge.IsZero() {
+		t.Errorf("interest after charge-off = %s, want 0", interest.InterestCharge)
+	}
+
+	moved, err := service.AgeDelinquencies(ctx, chargeOffDate.AddDate(0, 0, 30))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(moved) != 0 {
+		t.Errorf("charged-off card aged again: %+v", moved)
+	}
+}
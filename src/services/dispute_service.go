@@ -0,0 +1,528 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// DisputeService handles cardholder disputes of statement ledger entries,
+// from the billing error notice through provisional credit and chargeback
+// to resolution. Every status change is recorded as a transition.
+type DisputeService struct {
+	store                  repository.Store
+	statementLedgerService *StatementLedgerService
+	creditCardService      *CreditCardService
+	cashbackService        *CashbackService
+}
+
+// NewDisputeService creates a new dispute service
+func NewDisputeService(db Querier) *DisputeService {
+	return NewDisputeServiceWithStore(postgres.NewStore(db))
+}
+
+// NewDisputeServiceWithStore creates a dispute service backed by store
+func NewDisputeServiceWithStore(store repository.Store) *DisputeService {
+	return &DisputeService{
+		store:                  store,
+		statementLedgerService: NewStatementLedgerServiceWithStore(store),
+		creditCardService:      NewCreditCardServiceWithStore(store),
+		cashbackService:        NewCashbackServiceWithStore(store),
+	}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *DisputeService) withStore(store repository.Store) *DisputeService {
+	return NewDisputeServiceWithStore(store)
+}
+
+// OpenDisputeRequest contains parameters for opening a dispute
+type OpenDisputeRequest struct {
+	CreditCard       *models.CreditCard
+	StatementEntryID uuid.UUID
+	Reason           models.DisputeReason
+	Description      string
+	Amount           decimal.Decimal // Zero disputes the whole entry
+	NoticeReceivedAt time.Time
+	CreatedBy        string
+	IdempotencyKey   string // Retries with the same key replay the original result
+}
+
+// DisputeResult contains the result of a dispute operation
+type DisputeResult struct {
+	Dispute         *models.Dispute
+	Transition      *models.DisputeStatusTransition
+	Entry           *models.StatementLedgerEntry // Credit or reversal posted by the operation
+	CashbackAdjust  *models.CashbackLedgerEntry
+	AvailableCredit decimal.Decimal
+}
+
+// OpenDispute records a billing error notice against a charge and sets its
+// Regulation Z deadlines. The statement date comes from the first closed
+// billing cycle that covers the charge's posting date.
+func (s *DisputeService) OpenDispute(ctx context.Context, req OpenDisputeRequest) (*DisputeResult, error) {
+	if req.Amount.IsNegative() {
+		return nil, errors.New("dispute amount cannot be negative")
+	}
+
+	result := &DisputeResult{}
+
+	err := idempotently(ctx, s.store, req.CreditCard.TenantID, req.IdempotencyKey, operationOpenDispute, result, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		card, err := txs.creditCardService.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
+
+		entry, err := tx.StatementEntries().GetByID(ctx, req.StatementEntryID)
+		if errors.Is(err, repository.ErrNotFound) || (err == nil && entry.TenantID != card.TenantID) {
+			return fmt.Errorf("statement entry not found: %s", req.StatementEntryID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get statement entry: %w", err)
+		}
+		if !entry.IsDebit() || entry.Status == models.EntryStatusReversed {
+			return fmt.Errorf("only posted charges can be disputed, entry %s is a %s", entry.ID, entry.EntryType)
+		}
+
+		// Part of the charge may already be under dispute
+		disputable, err := txs.disputableAmount(ctx, entry)
+		if err != nil {
+			return err
+		}
+		amount := req.Amount
+		if amount.IsZero() {
+			amount = disputable
+		}
+		if !amount.IsPositive() || amount.GreaterThan(disputable) {
+			return fmt.Errorf("dispute of %s exceeds the undisputed %s of entry %s", amount, disputable, entry.ID)
+		}
+
+		statementDate, err := txs.statementDateOf(ctx, card, entry)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		dispute := &models.Dispute{
+			ID:               uuid.New(),
+			TenantID:         card.TenantID,
+			CreditCardID:     card.ID,
+			StatementEntryID: entry.ID,
+			Reason:           req.Reason,
+			Description:      req.Description,
+			Amount:           amount,
+			Status:           models.DisputeStatusOpen,
+			StatementDate:    statementDate,
+			NoticeReceivedAt: req.NoticeReceivedAt,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		if req.CreatedBy != "" {
+			dispute.CreatedBy = &req.CreatedBy
+		}
+		dispute.SetRegZTimeline(card)
+
+		if err := tx.Disputes().Create(ctx, dispute); err != nil {
+			return fmt.Errorf("failed to create dispute: %w", err)
+		}
+
+		transition := &models.DisputeStatusTransition{
+			ID:           uuid.New(),
+			DisputeID:    dispute.ID,
+			ToStatus:     models.DisputeStatusOpen,
+			TransitionAt: req.NoticeReceivedAt,
+			TriggeredBy:  dispute.CreatedBy,
+		}
+		if err := tx.Disputes().CreateTransition(ctx, transition); err != nil {
+			return fmt.Errorf("failed to record dispute transition: %w", err)
+		}
+
+		result.Dispute = dispute
+		result.Transition = transition
+		result.AvailableCredit = card.AvailableCredit
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AcknowledgeDispute records the written acknowledgement of the notice.
+// The status is unchanged.
+func (s *DisputeService) AcknowledgeDispute(ctx context.Context, disputeID uuid.UUID, at time.Time) (*models.Dispute, error) {
+	var dispute *models.Dispute
+
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		var err error
+		dispute, err = s.withStore(tx).GetDispute(ctx, disputeID)
+		if err != nil {
+			return err
+		}
+		if dispute.AcknowledgedAt != nil {
+			return nil
+		}
+
+		acknowledgedAt := at
+		dispute.AcknowledgedAt = &acknowledgedAt
+		dispute.UpdatedAt = time.Now()
+		if err := tx.Disputes().Update(ctx, dispute); err != nil {
+			return fmt.Errorf("failed to update dispute: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// PostProvisionalCredit credits the disputed amount while the dispute is
+// investigated. The credit pays down the disputed charge's segment first.
+func (s *DisputeService) PostProvisionalCredit(ctx context.Context, disputeID uuid.UUID, at time.Time) (*DisputeResult, error) {
+	return s.transitionDispute(ctx, disputeID, models.DisputeStatusProvisionalCredit, "post provisional credit for", at, nil,
+		func(txs *DisputeService, card *models.CreditCard, dispute *models.Dispute, result *DisputeResult) error {
+			entry, err := txs.postCredit(ctx, card, dispute, "Provisional credit", at, result)
+			if err != nil {
+				return err
+			}
+
+			creditedAt := at
+			dispute.ProvisionalCreditEntryID = &entry.ID
+			dispute.ProvisionalCreditAt = &creditedAt
+			return nil
+		})
+}
+
+// FileChargeback records the chargeback filed with the merchant's acquirer
+func (s *DisputeService) FileChargeback(
+	ctx context.Context,
+	disputeID uuid.UUID,
+	reference string,
+	at time.Time,
+) (*DisputeResult, error) {
+	return s.transitionDispute(ctx, disputeID, models.DisputeStatusChargeback, "file chargeback for", at, nil,
+		func(txs *DisputeService, card *models.CreditCard, dispute *models.Dispute, result *DisputeResult) error {
+			filedAt := at
+			dispute.ChargebackReference = &reference
+			dispute.ChargebackFiledAt = &filedAt
+			result.AvailableCredit = card.AvailableCredit
+			return nil
+		})
+}
+
+// ResolveForCardholder closes the dispute in the cardholder's favour. A
+// provisional credit becomes final; otherwise the disputed amount is
+// credited now. Cashback earned on the disputed amount is taken back as it
+// is for a refund.
+func (s *DisputeService) ResolveForCardholder(
+	ctx context.Context,
+	disputeID uuid.UUID,
+	resolution string,
+	at time.Time,
+) (*DisputeResult, error) {
+	return s.transitionDispute(ctx, disputeID, models.DisputeStatusResolvedCardholder, "resolve", at, &resolution,
+		func(txs *DisputeService, card *models.CreditCard, dispute *models.Dispute, result *DisputeResult) error {
+			creditEntryID := dispute.ProvisionalCreditEntryID
+			if creditEntryID == nil {
+				entry, err := txs.postCredit(ctx, card, dispute, "Dispute credit", at, result)
+				if err != nil {
+					return err
+				}
+				dispute.FinalCreditEntryID = &entry.ID
+				creditEntryID = &entry.ID
+			} else {
+				result.AvailableCredit = card.AvailableCredit
+			}
+
+			if card.CashbackEnabled {
+				cashbackAdj, err := txs.cashbackService.AdjustCashbackForRefund(ctx, AdjustCashbackForRefundRequest{
+					TenantID:                   card.TenantID,
+					CreditCard:                 card,
+					RefundAmount:               dispute.Amount,
+					RefundDate:                 at,
+					OriginalTransactionEntryID: dispute.StatementEntryID,
+					RefundEntryID:              *creditEntryID,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to adjust cashback: %w", err)
+				}
+				result.CashbackAdjust = cashbackAdj
+			}
+
+			resolvedAt := at
+			dispute.Resolution = &resolution
+			dispute.ResolvedAt = &resolvedAt
+			return nil
+		})
+}
+
+// ResolveForMerchant closes the dispute in the merchant's favour. Any
+// provisional credit is reversed back onto the disputed charge's segment.
+func (s *DisputeService) ResolveForMerchant(
+	ctx context.Context,
+	disputeID uuid.UUID,
+	resolution string,
+	at time.Time,
+) (*DisputeResult, error) {
+	return s.transitionDispute(ctx, disputeID, models.DisputeStatusResolvedMerchant, "resolve", at, &resolution,
+		func(txs *DisputeService, card *models.CreditCard, dispute *models.Dispute, result *DisputeResult) error {
+			result.AvailableCredit = card.AvailableCredit
+
+			if dispute.HasProvisionalCredit() {
+				if err := card.CanPost(); err != nil {
+					return err
+				}
+
+				disputed, err := txs.store.StatementEntries().GetByID(ctx, dispute.StatementEntryID)
+				if err != nil {
+					return fmt.Errorf("failed to get disputed entry: %w", err)
+				}
+
+				now := time.Now()
+				reversal := &models.StatementLedgerEntry{
+					ID:          uuid.New(),
+					TenantID:    card.TenantID,
+					EntryType:   models.EntryTypeAdjustment,
+					EntryDate:   at,
+					PostingDate: at,
+					Amount:      dispute.Amount,
+					Description: fmt.Sprintf("Provisional credit reversed - %s", disputed.Description),
+					Status:      models.EntryStatusCleared,
+					ClearedAt:   &now,
+					Metadata: map[string]interface{}{
+						models.DisputeMetadataKey: dispute.ID.String(),
+						models.SegmentMetadataKey: string(card.SegmentOf(disputed)),
+						"original_transaction_id": dispute.StatementEntryID.String(),
+					},
+					CreatedAt: now,
+				}
+				if err := txs.statementLedgerService.CreateEntry(ctx, reversal); err != nil {
+					return fmt.Errorf("failed to create provisional credit reversal: %w", err)
+				}
+
+				newAvailableCredit := card.AvailableCredit.Sub(dispute.Amount)
+				if err := txs.creditCardService.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+					return fmt.Errorf("failed to update available credit: %w", err)
+				}
+
+				dispute.ReversalEntryID = &reversal.ID
+				result.Entry = reversal
+				result.AvailableCredit = newAvailableCredit
+			}
+
+			resolvedAt := at
+			dispute.Resolution = &resolution
+			dispute.ResolvedAt = &resolvedAt
+			return nil
+		})
+}
+
+// GetDispute retrieves a dispute by ID
+func (s *DisputeService) GetDispute(ctx context.Context, disputeID uuid.UUID) (*models.Dispute, error) {
+	dispute, err := s.store.Disputes().GetByID(ctx, disputeID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("dispute not found: %s", disputeID)
+	}
+	return dispute, err
+}
+
+// GetDisputeHistory returns a dispute's status transitions, oldest first
+func (s *DisputeService) GetDisputeHistory(ctx context.Context, disputeID uuid.UUID) ([]*models.DisputeStatusTransition, error) {
+	return s.store.Disputes().ListTransitions(ctx, disputeID)
+}
+
+// GetOverdueDisputes returns the unresolved disputes that have missed a
+// Regulation Z acknowledgement or resolution deadline by asOf
+func (s *DisputeService) GetOverdueDisputes(ctx context.Context, asOf time.Time) ([]*models.Dispute, error) {
+	unresolved, err := s.store.Disputes().List(ctx, repository.DisputeFilter{
+		Statuses: []models.DisputeStatus{
+			models.DisputeStatusOpen,
+			models.DisputeStatusProvisionalCredit,
+			models.DisputeStatusChargeback,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list disputes: %w", err)
+	}
+
+	var overdue []*models.Dispute
+	for _, dispute := range unresolved {
+		if dispute.IsAcknowledgementOverdue(asOf) || dispute.IsResolutionOverdue(asOf) {
+			overdue = append(overdue, dispute)
+		}
+	}
+	return overdue, nil
+}
+
+// transitionDispute moves a dispute to status in a transaction. The card is
+// locked before the dispute is read, so changes to one card's disputes and
+// balances never interleave.
+func (s *DisputeService) transitionDispute(
+	ctx context.Context,
+	disputeID uuid.UUID,
+	status models.DisputeStatus,
+	verb string,
+	at time.Time,
+	reason *string,
+	apply func(txs *DisputeService, card *models.CreditCard, dispute *models.Dispute, result *DisputeResult) error,
+) (*DisputeResult, error) {
+	result := &DisputeResult{}
+
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		dispute, err := txs.GetDispute(ctx, disputeID)
+		if err != nil {
+			return err
+		}
+
+		card, err := txs.creditCardService.lockCard(ctx, dispute.CreditCardID)
+		if err != nil {
+			return err
+		}
+
+		// Read again under the card lock
+		dispute, err = txs.GetDispute(ctx, disputeID)
+		if err != nil {
+			return err
+		}
+
+		if !dispute.CanTransitionTo(status) {
+			return fmt.Errorf("cannot %s dispute in status %s", verb, dispute.Status)
+		}
+
+		transition := &models.DisputeStatusTransition{
+			ID:           uuid.New(),
+			DisputeID:    dispute.ID,
+			FromStatus:   dispute.Status,
+			ToStatus:     status,
+			Reason:       reason,
+			TransitionAt: at,
+		}
+
+		if err := apply(txs, card, dispute, result); err != nil {
+			return err
+		}
+
+		dispute.Status = status
+		dispute.UpdatedAt = time.Now()
+		if err := tx.Disputes().Update(ctx, dispute); err != nil {
+			return fmt.Errorf("failed to update dispute: %w", err)
+		}
+
+		if err := tx.Disputes().CreateTransition(ctx, transition); err != nil {
+			return fmt.Errorf("failed to record dispute transition: %w", err)
+		}
+
+		result.Dispute = dispute
+		result.Transition = transition
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// postCredit posts a cleared credit of the disputed amount and gives it back
+// to available credit, capped at the credit limit
+func (s *DisputeService) postCredit(
+	ctx context.Context,
+	card *models.CreditCard,
+	dispute *models.Dispute,
+	label string,
+	at time.Time,
+	result *DisputeResult,
+) (*models.StatementLedgerEntry, error) {
+	if err := card.CanPost(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entry := &models.StatementLedgerEntry{
+		ID:          uuid.New(),
+		TenantID:    card.TenantID,
+		EntryType:   models.EntryTypeCredit,
+		EntryDate:   at,
+		PostingDate: at,
+		Amount:      dispute.Amount,
+		Description: fmt.Sprintf("%s - disputed %s", label, dispute.Reason),
+		Status:      models.EntryStatusCleared,
+		ClearedAt:   &now,
+		Metadata: map[string]interface{}{
+			models.DisputeMetadataKey: dispute.ID.String(),
+			"original_transaction_id": dispute.StatementEntryID.String(),
+		},
+		CreatedAt: now,
+	}
+	if err := s.statementLedgerService.CreateEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to create dispute credit: %w", err)
+	}
+
+	newAvailableCredit := card.AvailableCredit.Add(dispute.Amount)
+	if newAvailableCredit.GreaterThan(card.CreditLimit) {
+		newAvailableCredit = card.CreditLimit
+	}
+	if err := s.creditCardService.updateAvailableCredit(ctx, card.ID, newAvailableCredit); err != nil {
+		return nil, fmt.Errorf("failed to update available credit: %w", err)
+	}
+
+	result.Entry = entry
+	result.AvailableCredit = newAvailableCredit
+	return entry, nil
+}
+
+// disputableAmount returns the part of a charge not claimed by an earlier
+// dispute. A dispute the merchant won releases its amount.
+func (s *DisputeService) disputableAmount(ctx context.Context, entry *models.StatementLedgerEntry) (decimal.Decimal, error) {
+	existing, err := s.store.Disputes().List(ctx, repository.DisputeFilter{StatementEntryID: &entry.ID})
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to list disputes: %w", err)
+	}
+
+	disputable := entry.Amount.Abs()
+	for _, dispute := range existing {
+		if dispute.Status != models.DisputeStatusResolvedMerchant {
+			disputable = disputable.Sub(dispute.Amount)
+		}
+	}
+	return disputable, nil
+}
+
+// statementDateOf returns the statement date of the first closed billing
+// cycle covering the entry's posting date, or nil if it has not been billed
+func (s *DisputeService) statementDateOf(
+	ctx context.Context,
+	card *models.CreditCard,
+	entry *models.StatementLedgerEntry,
+) (*time.Time, error) {
+	cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{CreditCardID: &card.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billing cycles: %w", err)
+	}
+
+	posted := models.DateOf(entry.PostingDate)
+	for _, cycle := range cycles {
+		if cycle.Status == models.BillingCycleStatusOpen {
+			continue
+		}
+		if !posted.After(models.DateOf(cycle.CycleEndDate)) {
+			statementDate := cycle.StatementDate
+			return &statementDate, nil
+		}
+	}
+	return nil, nil
+}
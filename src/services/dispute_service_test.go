@@ -0,0 +1,377 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestDisputeService_Workflow(t *testing.T) {
+	day := time.Date(2026, time.March, 10, 15, 0, 0, 0, time.UTC)
+
+	// Each step runs against a card with a 5000 limit and an open dispute of a 300 charge
+	type step func(ctx context.Context, s *DisputeService, dispute *models.Dispute) error
+	provisional := func(ctx context.Context, s *DisputeService, dispute *models.Dispute) error {
+		_, err := s.PostProvisionalCredit(ctx, dispute.ID, day.AddDate(0, 0, 2))
+		return err
+	}
+	chargeback := func(ctx context.Context, s *DisputeService, dispute *models.Dispute) error {
+		_, err := s.FileChargeback(ctx, dispute.ID, "CB-1001", day.AddDate(0, 0, 3))
+		return err
+	}
+	cardholder := func(ctx context.Context, s *DisputeService, dispute *models.Dispute) error {
+		_, err := s.ResolveForCardholder(ctx, dispute.ID, "Merchant accepted the chargeback", day.AddDate(0, 0, 20))
+		return err
+	}
+	merchant := func(ctx context.Context, s *DisputeService, dispute *models.Dispute) error {
+		_, err := s.ResolveForMerchant(ctx, dispute.ID, "Signed delivery receipt", day.AddDate(0, 0, 20))
+		return err
+	}
+
+	tests := []struct {
+		name            string
+		steps           []step
+		wantErr         bool // Returned by the last step
+		wantHistory     []models.DisputeStatus
+		wantAvailable   int64
+		wantCredits     int // Dispute credits and reversals posted
+		wantCashbackCut bool
+	}{
+		{
+			name:          "Open dispute",
+			wantHistory:   []models.DisputeStatus{models.DisputeStatusOpen},
+			wantAvailable: 4700,
+		},
+		{
+			name:          "Provisional credit",
+			steps:         []step{provisional},
+			wantHistory:   []models.DisputeStatus{models.DisputeStatusOpen, models.DisputeStatusProvisionalCredit},
+			wantAvailable: 5000,
+			wantCredits:   1,
+		},
+		{
+			name:  "Cardholder wins after provisional credit",
+			steps: []step{provisional, chargeback, cardholder},
+			wantHistory: []models.DisputeStatus{
+				models.DisputeStatusOpen, models.DisputeStatusProvisionalCredit,
+				models.DisputeStatusChargeback, models.DisputeStatusResolvedCardholder,
+			},
+			wantAvailable:   5000,
+			wantCredits:     1,
+			wantCashbackCut: true,
+		},
+		{
+			name:            "Cardholder wins without provisional credit",
+			steps:           []step{cardholder},
+			wantHistory:     []models.DisputeStatus{models.DisputeStatusOpen, models.DisputeStatusResolvedCardholder},
+			wantAvailable:   5000,
+			wantCredits:     1,
+			wantCashbackCut: true,
+		},
+		{
+			name:  "Merchant wins and the provisional credit is reversed",
+			steps: []step{provisional, chargeback, merchant},
+			wantHistory: []models.DisputeStatus{
+				models.DisputeStatusOpen, models.DisputeStatusProvisionalCredit,
+				models.DisputeStatusChargeback, models.DisputeStatusResolvedMerchant,
+			},
+			wantAvailable: 4700,
+			wantCredits:   2,
+		},
+		{
+			name:          "Merchant wins without provisional credit",
+			steps:         []step{merchant},
+			wantHistory:   []models.DisputeStatus{models.DisputeStatusOpen, models.DisputeStatusResolvedMerchant},
+			wantAvailable: 4700,
+		},
+		{
+			name:          "Resolved dispute cannot be credited",
+			steps:         []step{merchant, provisional},
+			wantErr:       true,
+			wantHistory:   []models.DisputeStatus{models.DisputeStatusOpen, models.DisputeStatusResolvedMerchant},
+			wantAvailable: 4700,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+
+			purchase, err := NewCreditCardServiceWithStore(store).RecordTransaction(ctx, CCTransactionRequest{
+				CreditCard:      card,
+				Amount:          decimal.NewFromInt(300),
+				Description:     "Headphones",
+				MerchantName:    "Test Electronics",
+				TransactionDate: day.AddDate(0, 0, -5),
+				PostingDate:     day.AddDate(0, 0, -4),
+			})
+			if err != nil {
+				t.Fatalf("failed to record transaction: %v", err)
+			}
+
+			service := NewDisputeServiceWithStore(store)
+			opened, err := service.OpenDispute(ctx, OpenDisputeRequest{
+				CreditCard:       card,
+				StatementEntryID: purchase.TransactionEntry.ID,
+				Reason:           models.DisputeReasonNotReceived,
+				NoticeReceivedAt: day,
+			})
+			if err != nil {
+				t.Fatalf("failed to open dispute: %v", err)
+			}
+			if !opened.Dispute.Amount.Equal(decimal.NewFromInt(300)) {
+				t.Fatalf("disputed amount = %s, want the whole charge", opened.Dispute.Amount)
+			}
+
+			for i, run := range tt.steps {
+				err := run(ctx, service, opened.Dispute)
+				if i < len(tt.steps)-1 || !tt.wantErr {
+					if err != nil {
+						t.Fatalf("step %d: unexpected error: %v", i+1, err)
+					}
+					continue
+				}
+				if err == nil {
+					t.Fatalf("expected an error from the last step")
+				}
+			}
+
+			history, err := service.GetDisputeHistory(ctx, opened.Dispute.ID)
+			if err != nil {
+				t.Fatalf("failed to load history: %v", err)
+			}
+			if len(history) != len(tt.wantHistory) {
+				t.Fatalf("history has %d transitions, want %d", len(history), len(tt.wantHistory))
+			}
+			for i, transition := range history {
+				if transition.ToStatus != tt.wantHistory[i] {
+					t.Errorf("transition %d to %s, want %s", i, transition.ToStatus, tt.wantHistory[i])
+				}
+				if i > 0 && transition.FromStatus != tt.wantHistory[i-1] {
+					t.Errorf("transition %d from %s, want %s", i, transition.FromStatus, tt.wantHistory[i-1])
+				}
+			}
+
+			dispute, _ := service.GetDispute(ctx, opened.Dispute.ID)
+			if dispute.Status != tt.wantHistory[len(tt.wantHistory)-1] {
+				t.Errorf("status = %s, want %s", dispute.Status, tt.wantHistory[len(tt.wantHistory)-1])
+			}
+
+			updated, _ := store.CreditCards().GetByID(ctx, card.ID)
+			if !updated.AvailableCredit.Equal(decimal.NewFromInt(tt.wantAvailable)) {
+				t.Errorf("available credit = %s, want %d", updated.AvailableCredit, tt.wantAvailable)
+			}
+
+			// Credits and reversals are linked back to the dispute, and net to
+			// zero when the merchant wins
+			entries, _ := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+			credits := 0
+			net := decimal.Zero
+			for _, entry := range entries {
+				if entry.Metadata[models.DisputeMetadataKey] == dispute.ID.String() {
+					credits++
+					net = net.Add(entry.GetSignedAmount())
+				}
+			}
+			if credits != tt.wantCredits {
+				t.Errorf("dispute entries = %d, want %d", credits, tt.wantCredits)
+			}
+			if dispute.Status == models.DisputeStatusResolvedMerchant && !net.IsZero() {
+				t.Errorf("dispute entries net to %s after the merchant won", net)
+			}
+
+			adjustments, _ := store.CashbackEntries().List(ctx, repository.CashbackEntryFilter{
+				CreditCardID: &card.ID,
+				EntryTypes:   []models.CashbackEntryType{models.CashbackEarnedRefund},
+			})
+			if (len(adjustments) > 0) != tt.wantCashbackCut {
+				t.Errorf("cashback adjustments = %d, want cut %v", len(adjustments), tt.wantCashbackCut)
+			}
+		})
+	}
+}
+
+func TestDisputeService_OpenDisputeLimits(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	now := time.Now()
+	purchase := &models.StatementLedgerEntry{
+		TenantID:    card.TenantID,
+		EntryType:   models.EntryTypeTransaction,
+		EntryDate:   now,
+		PostingDate: now,
+		Amount:      decimal.NewFromInt(300),
+		Status:      models.EntryStatusCleared,
+	}
+	payment := &models.StatementLedgerEntry{
+		TenantID:    card.TenantID,
+		EntryType:   models.EntryTypePayment,
+		EntryDate:   now,
+		PostingDate: now,
+		Amount:      decimal.NewFromInt(100),
+		Status:      models.EntryStatusCleared,
+	}
+	for _, entry := range []*models.StatementLedgerEntry{purchase, payment} {
+		if err := store.StatementEntries().Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create entry: %v", err)
+		}
+	}
+
+	service := NewDisputeServiceWithStore(store)
+	open := func(entryID uuid.UUID, amount int64) error {
+		_, err := service.OpenDispute(ctx, OpenDisputeRequest{
+			CreditCard:       card,
+			StatementEntryID: entryID,
+			Reason:           models.DisputeReasonIncorrectAmount,
+			Amount:           decimal.NewFromInt(amount),
+			NoticeReceivedAt: now,
+		})
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		entryID uuid.UUID
+		amount  int64
+		wantErr bool
+	}{
+		{"Part of the charge", purchase.ID, 120, false},
+		{"More than the undisputed rest", purchase.ID, 200, true},
+		{"The undisputed rest", purchase.ID, 0, false},
+		{"A payment", payment.ID, 0, true},
+		{"An unknown entry", uuid.New(), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := open(tt.entryID, tt.amount)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestInterestService_DisputeSuspendsInterest(t *testing.T) {
+	cycleStart := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, time.March, 30, 0, 0, 0, 0, time.UTC)
+	carried := cycleStart.AddDate(0, 0, -5)
+	notice := cycleStart.AddDate(0, 0, 10)
+	credited := cycleStart.AddDate(0, 0, 20)
+
+	tests := []struct {
+		name        string
+		dispute     bool
+		provisional bool
+		want        decimal.Decimal
+	}{
+		{
+			name: "No dispute",
+			// ADB 2000 × 19.99% / 365 × 30
+			want: decimal.NewFromFloat(32.86),
+		},
+		{
+			name:    "Disputed amount left out from the notice",
+			dispute: true,
+			// ADB (2000 × 10 + 1500 × 20) / 30
+			want: decimal.NewFromFloat(27.38),
+		},
+		{
+			name:        "Provisional credit takes over the suspension",
+			dispute:     true,
+			provisional: true,
+			// 500 is suspended for days 11-20, then credited
+			want: decimal.NewFromFloat(27.38),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			card.CashbackEnabled = false
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+
+			purchases := []*models.StatementLedgerEntry{
+				{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(1500)},
+				{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(500)},
+			}
+			for _, entry := range purchases {
+				entry.TenantID = card.TenantID
+				entry.EntryDate = carried
+				entry.PostingDate = carried
+				entry.Status = models.EntryStatusCleared
+				if err := store.StatementEntries().Create(ctx, entry); err != nil {
+					t.Fatalf("failed to create entry: %v", err)
+				}
+			}
+
+			// The previous statement was not paid in full, so no grace period
+			previous := &models.BillingCycle{
+				CreditCardID:   card.ID,
+				TenantID:       card.TenantID,
+				CycleNumber:    1,
+				CycleStartDate: cycleStart.AddDate(0, -1, 0),
+				CycleEndDate:   cycleStart.AddDate(0, 0, -1),
+				StatementDate:  cycleStart.AddDate(0, 0, -1),
+				NewBalance:     decimal.NewFromInt(2000),
+				Status:         models.BillingCycleStatusClosed,
+			}
+			if err := store.BillingCycles().Create(ctx, previous); err != nil {
+				t.Fatalf("failed to create previous cycle: %v", err)
+			}
+
+			if tt.dispute {
+				service := NewDisputeServiceWithStore(store)
+				opened, err := service.OpenDispute(ctx, OpenDisputeRequest{
+					CreditCard:       card,
+					StatementEntryID: purchases[1].ID,
+					Reason:           models.DisputeReasonNotAsDescribed,
+					NoticeReceivedAt: notice,
+				})
+				if err != nil {
+					t.Fatalf("failed to open dispute: %v", err)
+				}
+				if tt.provisional {
+					if _, err := service.PostProvisionalCredit(ctx, opened.Dispute.ID, credited); err != nil {
+						t.Fatalf("failed to post provisional credit: %v", err)
+					}
+				}
+			}
+
+			cycle := &models.BillingCycle{
+				ID:             uuid.New(),
+				CreditCardID:   card.ID,
+				CycleNumber:    2,
+				CycleStartDate: cycleStart,
+				CycleEndDate:   cycleEnd,
+			}
+			result, err := NewInterestServiceWithStore(store).CalculateInterest(ctx, card, cycle, DefaultInterestConfig())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !result.InterestCharge.Equal(tt.want) {
+				t.Errorf("interest = %s, want %s", result.InterestCharge, tt.want)
+			}
+		})
+	}
+}
@@ -2,29 +2,41 @@ package services
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 	"github.com/shopspring/decimal"
 )
 
 // FeeService handles fee calculation and assessment for credit card accounts
 type FeeService struct {
-	db                     *sql.DB
+	store                  repository.Store
 	statementLedgerService *StatementLedgerService
 }
 
 // NewFeeService creates a new fee service
-func NewFeeService(db *sql.DB) *FeeService {
+func NewFeeService(db Querier) *FeeService {
+	return NewFeeServiceWithStore(postgres.NewStore(db))
+}
+
+// NewFeeServiceWithStore creates a fee service backed by store
+func NewFeeServiceWithStore(store repository.Store) *FeeService {
 	return &FeeService{
-		db:                     db,
-		statementLedgerService: NewStatementLedgerService(db),
+		store:                  store,
+		statementLedgerService: NewStatementLedgerServiceWithStore(store),
 	}
 }
 
+// withStore returns a copy of the service bound to store
+func (s *FeeService) withStore(store repository.Store) *FeeService {
+	return NewFeeServiceWithStore(store)
+}
+
 // FeeType represents the type of fee being assessed
 type FeeType string
 
@@ -35,6 +47,7 @@ const (
 	FeeTypeOverLimit       FeeType = "over_limit"
 	FeeTypeAnnual          FeeType = "annual"
 	FeeTypeCashAdvance     FeeType = "cash_advance"
+	FeeTypeBalanceTransfer FeeType = "balance_transfer"
 )
 
 // FeeAssessmentResult contains the result of assessing a fee
@@ -68,6 +81,16 @@ type LatePaymentFeeRequest struct {
 func (s *FeeService) AssessLatePaymentFee(
 	ctx context.Context,
 	req LatePaymentFeeRequest,
+) (*FeeAssessmentResult, error) {
+	return s.unlessChargedOff(ctx, req.CreditCard.ID, func(txs *FeeService, card *models.CreditCard) (*FeeAssessmentResult, error) {
+		req.CreditCard = card
+		return txs.assessLatePaymentFee(ctx, req)
+	})
+}
+
+func (s *FeeService) assessLatePaymentFee(
+	ctx context.Context,
+	req LatePaymentFeeRequest,
 ) (*FeeAssessmentResult, error) {
 	// Check if payment is actually overdue
 	if !req.BillingCycle.IsOverdue(req.CurrentDate) {
@@ -138,6 +161,16 @@ type FailedPaymentFeeRequest struct {
 func (s *FeeService) AssessFailedPaymentFee(
 	ctx context.Context,
 	req FailedPaymentFeeRequest,
+) (*FeeAssessmentResult, error) {
+	return s.unlessChargedOff(ctx, req.CreditCard.ID, func(txs *FeeService, card *models.CreditCard) (*FeeAssessmentResult, error) {
+		req.CreditCard = card
+		return txs.assessFailedPaymentFee(ctx, req)
+	})
+}
+
+func (s *FeeService) assessFailedPaymentFee(
+	ctx context.Context,
+	req FailedPaymentFeeRequest,
 ) (*FeeAssessmentResult, error) {
 	feeAmount := req.CreditCard.FailedPaymentFee
 
@@ -241,6 +274,16 @@ type OverLimitFeeRequest struct {
 func (s *FeeService) AssessOverLimitFee(
 	ctx context.Context,
 	req OverLimitFeeRequest,
+) (*FeeAssessmentResult, error) {
+	return s.unlessChargedOff(ctx, req.CreditCard.ID, func(txs *FeeService, card *models.CreditCard) (*FeeAssessmentResult, error) {
+		req.CreditCard = card
+		return txs.assessOverLimitFee(ctx, req)
+	})
+}
+
+func (s *FeeService) assessOverLimitFee(
+	ctx context.Context,
+	req OverLimitFeeRequest,
 ) (*FeeAssessmentResult, error) {
 	// Check if actually over limit
 	if req.CurrentBalance.LessThanOrEqual(req.CreditCard.CreditLimit) {
@@ -296,6 +339,16 @@ type AnnualFeeRequest struct {
 func (s *FeeService) AssessAnnualFee(
 	ctx context.Context,
 	req AnnualFeeRequest,
+) (*FeeAssessmentResult, error) {
+	return s.unlessChargedOff(ctx, req.CreditCard.ID, func(txs *FeeService, card *models.CreditCard) (*FeeAssessmentResult, error) {
+		req.CreditCard = card
+		return txs.assessAnnualFee(ctx, req)
+	})
+}
+
+func (s *FeeService) assessAnnualFee(
+	ctx context.Context,
+	req AnnualFeeRequest,
 ) (*FeeAssessmentResult, error) {
 	// Check if card has an annual fee
 	if req.CreditCard.AnnualFee.LessThanOrEqual(decimal.Zero) {
@@ -390,6 +443,55 @@ func (s *FeeService) AssessCashAdvanceFee(
 	}, nil
 }
 
+// BalanceTransferFeeRequest contains parameters for assessing a balance transfer fee
+type BalanceTransferFeeRequest struct {
+	CreditCard      *models.CreditCard
+	TransferAmount  decimal.Decimal
+	TransactionDate time.Time
+	Creditor        string
+	ReferenceID     string
+}
+
+// AssessBalanceTransferFee assesses a balance transfer fee
+func (s *FeeService) AssessBalanceTransferFee(
+	ctx context.Context,
+	req BalanceTransferFeeRequest,
+) (*FeeAssessmentResult, error) {
+	// Calculate fee - greater of flat fee or percentage
+	feeAmount := req.CreditCard.CalculateBalanceTransferFee(req.TransferAmount)
+
+	entry := &models.StatementLedgerEntry{
+		ID:          uuid.New(),
+		TenantID:    req.CreditCard.TenantID,
+		EntryType:   models.EntryTypeFeeBalanceTransfer,
+		EntryDate:   req.TransactionDate,
+		PostingDate: req.TransactionDate,
+		Amount:      feeAmount,
+		Description: fmt.Sprintf("Balance transfer fee - $%.2f transfer", req.TransferAmount.InexactFloat64()),
+		ReferenceID: &req.ReferenceID,
+		Status:      models.EntryStatusPending,
+		Metadata: map[string]interface{}{
+			"transfer_amount":  req.TransferAmount.String(),
+			"flat_fee":         req.CreditCard.BalanceTransferFee.String(),
+			"fee_rate_percent": req.CreditCard.BalanceTransferFeeRate.String(),
+			"creditor":         req.Creditor,
+		},
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.statementLedgerService.CreateEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to create balance transfer fee entry: %w", err)
+	}
+
+	return &FeeAssessmentResult{
+		FeeType:     FeeTypeBalanceTransfer,
+		FeeAmount:   feeAmount,
+		EntryID:     entry.ID,
+		Description: entry.Description,
+		AssessedAt:  time.Now(),
+	}, nil
+}
+
 // WaiveFee creates a credit entry to offset a previously assessed fee
 func (s *FeeService) WaiveFee(
 	ctx context.Context,
@@ -445,6 +547,7 @@ type FeeSummary struct {
 	TotalOverLimitFees   decimal.Decimal `json:"total_over_limit_fees"`
 	TotalAnnualFees      decimal.Decimal `json:"total_annual_fees"`
 	TotalCashAdvanceFees decimal.Decimal `json:"total_cash_advance_fees"`
+	TotalBalanceTransferFees decimal.Decimal `json:"total_balance_transfer_fees"`
 	TotalInterestCharges decimal.Decimal `json:"total_interest_charges"`
 	GrandTotal           decimal.Decimal `json:"grand_total"`
 	FeeCount             int             `json:"fee_count"`
@@ -456,42 +559,45 @@ func (s *FeeService) GetFeeSummary(
 	tenantID uuid.UUID,
 	startDate, endDate time.Time,
 ) (*FeeSummary, error) {
-	query := `
-		SELECT
-			COUNT(*) as fee_count,
-			COALESCE(SUM(CASE WHEN entry_type = 'fee_late' THEN amount ELSE 0 END), 0) as late_fees,
-			COALESCE(SUM(CASE WHEN entry_type = 'fee_failed' THEN amount ELSE 0 END), 0) as failed_fees,
-			COALESCE(SUM(CASE WHEN entry_type = 'fee_international' THEN amount ELSE 0 END), 0) as intl_fees,
-			COALESCE(SUM(CASE WHEN entry_type = 'fee_over_limit' THEN amount ELSE 0 END), 0) as over_limit_fees,
-			COALESCE(SUM(CASE WHEN entry_type = 'fee_annual' THEN amount ELSE 0 END), 0) as annual_fees,
-			COALESCE(SUM(CASE WHEN entry_type = 'fee_cash_advance' THEN amount ELSE 0 END), 0) as cash_advance_fees,
-			COALESCE(SUM(CASE WHEN entry_type = 'fee_interest' THEN amount ELSE 0 END), 0) as interest_charges
-		FROM statement_ledger_entries
-		WHERE tenant_id = $1
-		  AND posting_date >= $2
-		  AND posting_date <= $3
-		  AND entry_type LIKE 'fee_%'
-		  AND status != 'reversed'
-	`
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:   &tenantID,
+		Statuses:   activeEntryStatuses,
+		PostedFrom: &startDate,
+		PostedTo:   &endDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee summary: %w", err)
+	}
 
 	summary := &FeeSummary{
 		TenantID: tenantID,
 		Period:   fmt.Sprintf("%s to %s", startDate.Format("2006-01-02"), endDate.Format("2006-01-02")),
 	}
 
-	err := s.db.QueryRowContext(ctx, query, tenantID, startDate, endDate).Scan(
-		&summary.FeeCount,
-		&summary.TotalLatePaymentFees,
-		&summary.TotalFailedPaymentFees,
-		&summary.TotalInternationalFees,
-		&summary.TotalOverLimitFees,
-		&summary.TotalAnnualFees,
-		&summary.TotalCashAdvanceFees,
-		&summary.TotalInterestCharges,
-	)
-
-	if err != nil && err != sql.ErrNoRows {
-		return nil, fmt.Errorf("failed to get fee summary: %w", err)
+	for _, entry := range entries {
+		var total *decimal.Decimal
+		switch entry.EntryType {
+		case models.EntryTypeFeeLate:
+			total = &summary.TotalLatePaymentFees
+		case models.EntryTypeFeeFailed:
+			total = &summary.TotalFailedPaymentFees
+		case models.EntryTypeFeeInternational:
+			total = &summary.TotalInternationalFees
+		case models.EntryTypeFeeOverLimit:
+			total = &summary.TotalOverLimitFees
+		case models.EntryTypeFeeAnnual:
+			total = &summary.TotalAnnualFees
+		case models.EntryTypeFeeCashAdvance:
+			total = &summary.TotalCashAdvanceFees
+		case models.EntryTypeFeeBalanceTransfer:
+			total = &summary.TotalBalanceTransferFees
+		case models.EntryTypeFeeInterest:
+			total = &summary.TotalInterestCharges
+		default:
+			continue
+		}
+		*total = total.Add(entry.Amount)
+		summary.FeeCount++
 	}
 
 	// Calculate grand total
@@ -501,11 +607,40 @@ func (s *FeeService) GetFeeSummary(
 		Add(summary.TotalOverLimitFees).
 		Add(summary.TotalAnnualFees).
 		Add(summary.TotalCashAdvanceFees).
+		Add(summary.TotalBalanceTransferFees).
 		Add(summary.TotalInterestCharges)
 
 	return summary, nil
 }
 
+// unlessChargedOff runs assess in a transaction with the card's row locked,
+// passing it the locked card. A charged-off card accrues no further fees, so
+// nothing is assessed on one.
+func (s *FeeService) unlessChargedOff(
+	ctx context.Context,
+	cardID uuid.UUID,
+	assess func(txs *FeeService, card *models.CreditCard) (*FeeAssessmentResult, error),
+) (*FeeAssessmentResult, error) {
+	var result *FeeAssessmentResult
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		card, err := tx.CreditCards().GetForUpdate(ctx, cardID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit card: %w", err)
+		}
+		if card.IsChargedOff() {
+			return nil
+		}
+
+		result, err = assess(s.withStore(tx), card)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 // hasExistingFee checks if a fee of the given type already exists for the cycle
 func (s *FeeService) hasExistingFee(
 	ctx context.Context,
@@ -513,19 +648,13 @@ func (s *FeeService) hasExistingFee(
 	cycleID uuid.UUID,
 	feeType models.StatementEntryType,
 ) (bool, error) {
-	query := `
-		SELECT EXISTS(
-			SELECT 1 FROM statement_ledger_entries
-			WHERE tenant_id = $1
-			  AND statement_id = $2
-			  AND entry_type = $3
-			  AND status != 'reversed'
-		)
-	`
-
-	var exists bool
-	err := s.db.QueryRowContext(ctx, query, tenantID, cycleID, feeType).Scan(&exists)
-	return exists, err
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:    &tenantID,
+		StatementID: &cycleID,
+		EntryTypes:  []models.StatementEntryType{feeType},
+		Statuses:    activeEntryStatuses,
+	})
+	return len(entries) > 0, err
 }
 
 // hasRecentAnnualFee checks if an annual fee was assessed within the specified months
@@ -534,50 +663,20 @@ func (s *FeeService) hasRecentAnnualFee(
 	tenantID uuid.UUID,
 	withinMonths int,
 ) (bool, error) {
-	query := `
-		SELECT EXISTS(
-			SELECT 1 FROM statement_ledger_entries
-			WHERE tenant_id = $1
-			  AND entry_type = 'fee_annual'
-			  AND status != 'reversed'
-			  AND posting_date >= NOW() - INTERVAL '%d months'
-		)
-	`
-
-	var exists bool
-	err := s.db.QueryRowContext(ctx, fmt.Sprintf(query, withinMonths), tenantID).Scan(&exists)
-	return exists, err
+	since := time.Now().AddDate(0, -withinMonths, 0)
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:   &tenantID,
+		EntryTypes: []models.StatementEntryType{models.EntryTypeFeeAnnual},
+		Statuses:   activeEntryStatuses,
+		PostedFrom: &since,
+	})
+	return len(entries) > 0, err
 }
 
 // getFeeEntry retrieves a specific fee entry by ID
 func (s *FeeService) getFeeEntry(ctx context.Context, entryID uuid.UUID) (*models.StatementLedgerEntry, error) {
-	query := `
-		SELECT id, tenant_id, statement_id, entry_type, entry_date, posting_date,
-		       amount, description, reference_id, metadata, status, cleared_at,
-		       created_at, created_by
-		FROM statement_ledger_entries
-		WHERE id = $1
-	`
-
-	entry := &models.StatementLedgerEntry{}
-	err := s.db.QueryRowContext(ctx, query, entryID).Scan(
-		&entry.ID,
-		&entry.TenantID,
-		&entry.StatementID,
-		&entry.EntryType,
-		&entry.EntryDate,
-		&entry.PostingDate,
-		&entry.Amount,
-		&entry.Description,
-		&entry.ReferenceID,
-		&entry.Metadata,
-		&entry.Status,
-		&entry.ClearedAt,
-		&entry.CreatedAt,
-		&entry.CreatedBy,
-	)
-
-	if err == sql.ErrNoRows {
+	entry, err := s.store.StatementEntries().GetByID(ctx, entryID)
+	if errors.Is(err, repository.ErrNotFound) {
 		return nil, fmt.Errorf("fee entry not found: %s", entryID)
 	}
 	if err != nil {
@@ -586,3 +685,6 @@ func (s *FeeService) getFeeEntry(ctx context.Context, entryID uuid.UUID) (*model
 
 	return entry, nil
 }
+
+// activeEntryStatuses matches every entry that has not been reversed
+var activeEntryStatuses = []models.EntryStatus{models.EntryStatusPending, models.EntryStatusCleared}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// GeneralLedgerService posts statement and cashback activity to the
+// double-entry general ledger and reports on it
+type GeneralLedgerService struct {
+	store repository.Store
+}
+
+// NewGeneralLedgerService creates a new general ledger service
+func NewGeneralLedgerService(db Querier) *GeneralLedgerService {
+	return NewGeneralLedgerServiceWithStore(postgres.NewStore(db))
+}
+
+// NewGeneralLedgerServiceWithStore creates a general ledger service backed by store
+func NewGeneralLedgerServiceWithStore(store repository.Store) *GeneralLedgerService {
+	return &GeneralLedgerService{store: store}
+}
+
+// PostStatementEntry records the journal entry for a statement ledger entry.
+// Zero-amount entries move no money and post nothing.
+func (s *GeneralLedgerService) PostStatementEntry(
+	ctx context.Context,
+	entry *models.StatementLedgerEntry,
+) (*models.JournalEntry, error) {
+	journal, err := statementJournal(entry)
+	if err != nil || journal == nil {
+		return nil, err
+	}
+
+	if err := s.store.JournalEntries().Create(ctx, journal); err != nil {
+		return nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	return journal, nil
+}
+
+// PostCashbackEntry records the journal entry for a cashback ledger entry.
+// Zero-amount entries move no money and post nothing.
+func (s *GeneralLedgerService) PostCashbackEntry(
+	ctx context.Context,
+	entry *models.CashbackLedgerEntry,
+) (*models.JournalEntry, error) {
+	journal, err := cashbackJournal(entry)
+	if err != nil || journal == nil {
+		return nil, err
+	}
+
+	if err := s.store.JournalEntries().Create(ctx, journal); err != nil {
+		return nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	return journal, nil
+}
+
+// PostRecovery records the journal entry for a recovery on a charged-off
+// balance
+func (s *GeneralLedgerService) PostRecovery(
+	ctx context.Context,
+	recovery *models.Recovery,
+) (*models.JournalEntry, error) {
+	journal := recoveryJournal(recovery)
+	if err := s.store.JournalEntries().Create(ctx, journal); err != nil {
+		return nil, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	return journal, nil
+}
+
+// GetJournalEntries returns a tenant's journal entries posted within a date range
+func (s *GeneralLedgerService) GetJournalEntries(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	from, to time.Time,
+) ([]*models.JournalEntry, error) {
+	return s.store.JournalEntries().List(ctx, repository.JournalFilter{
+		TenantID: &tenantID,
+		From:     &from,
+		To:       &to,
+	})
+}
+
+// GetTrialBalance totals every account as of a date.
+// A nil tenantID reports on the whole ledger.
+func (s *GeneralLedgerService) GetTrialBalance(
+	ctx context.Context,
+	tenantID *uuid.UUID,
+	asOf time.Time,
+) (*models.TrialBalance, error) {
+	entries, err := s.store.JournalEntries().List(ctx, repository.JournalFilter{
+		TenantID: tenantID,
+		To:       &asOf,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal entries: %w", err)
+	}
+
+	totals := make(map[models.GLAccountCode]*models.TrialBalanceLine, len(models.ChartOfAccounts))
+	report := &models.TrialBalance{
+		TenantID:     tenantID,
+		AsOf:         asOf,
+		Lines:        make([]models.TrialBalanceLine, len(models.ChartOfAccounts)),
+		TotalDebits:  decimal.Zero,
+		TotalCredits: decimal.Zero,
+	}
+	for i, account := range models.ChartOfAccounts {
+		report.Lines[i] = models.TrialBalanceLine{Account: account, Debits: decimal.Zero, Credits: decimal.Zero}
+		totals[account.Code] = &report.Lines[i]
+	}
+
+	for _, entry := range entries {
+		for _, line := range entry.Lines {
+			total, ok := totals[line.AccountCode]
+			if !ok {
+				return nil, fmt.Errorf("journal entry %s posts to unknown account %s", entry.ID, line.AccountCode)
+			}
+			total.Debits = total.Debits.Add(line.Debit)
+			total.Credits = total.Credits.Add(line.Credit)
+			report.TotalDebits = report.TotalDebits.Add(line.Debit)
+			report.TotalCredits = report.TotalCredits.Add(line.Credit)
+		}
+	}
+
+	return report, nil
+}
+
+// statementJournal builds the journal entry for a statement ledger entry.
+// Card receivable is debited when the entry raises the cardholder's balance
+// and credited when it lowers it.
+func statementJournal(entry *models.StatementLedgerEntry) (*models.JournalEntry, error) {
+	rule, ok := models.DefaultGLAccountMapping().StatementRule(entry)
+	if !ok {
+		return nil, fmt.Errorf("no general ledger mapping for statement entry type %s", entry.EntryType)
+	}
+
+	amount := entry.GetSignedAmount()
+	if amount.IsZero() {
+		return nil, nil
+	}
+
+	return newJournal(entry.TenantID, models.JournalSourceStatement, entry.ID,
+		entry.PostingDate, entry.Description, rule, amount), nil
+}
+
+// cashbackJournal builds the journal entry for a cashback ledger entry.
+// Cashback liability grows with positive amounts and shrinks with negative ones.
+func cashbackJournal(entry *models.CashbackLedgerEntry) (*models.JournalEntry, error) {
+	rule, ok := models.DefaultGLAccountMapping().CashbackRule(entry)
+	if !ok {
+		return nil, fmt.Errorf("no general ledger mapping for cashback entry type %s", entry.EntryType)
+	}
+
+	amount := entry.GetSignedAmount()
+	if amount.IsZero() {
+		return nil, nil
+	}
+
+	return newJournal(entry.TenantID, models.JournalSourceCashback, entry.ID,
+		entry.EntryDate, entry.Description, rule, amount), nil
+}
+
+// recoveryJournal builds the journal entry for a recovery. The charged-off
+// receivable is already off the books, so the gross amount is income; the
+// agency's commission is an expense and only the net amount is cash.
+func recoveryJournal(recovery *models.Recovery) *models.JournalEntry {
+	lines := []models.JournalLine{
+		{AccountCode: models.GLAccountCashClearing, Debit: recovery.NetAmount, Credit: decimal.Zero},
+	}
+	if recovery.Commission.IsPositive() {
+		lines = append(lines, models.JournalLine{
+			AccountCode: models.GLAccountCollectionExpense, Debit: recovery.Commission, Credit: decimal.Zero,
+		})
+	}
+	lines = append(lines, models.JournalLine{
+		AccountCode: models.GLAccountRecoveries, Debit: decimal.Zero, Credit: recovery.Amount,
+	})
+
+	description := "Recovery on charged-off balance"
+	if recovery.Agency != "" {
+		description = fmt.Sprintf("Recovery on charged-off balance - %s", recovery.Agency)
+	}
+
+	return &models.JournalEntry{
+		ID:          uuid.New(),
+		TenantID:    recovery.TenantID,
+		SourceType:  models.JournalSourceRecovery,
+		SourceID:    recovery.ID,
+		EntryDate:   recovery.RecoveredOn,
+		Description: description,
+		Lines:       lines,
+		CreatedAt:   time.Now(),
+	}
+}
+
+// newJournal builds a two-line journal entry posting amount by rule
+func newJournal(
+	tenantID uuid.UUID,
+	sourceType models.JournalSourceType,
+	sourceID uuid.UUID,
+	date time.Time,
+	description string,
+	rule models.GLPostingRule,
+	amount decimal.Decimal,
+) *models.JournalEntry {
+	debit, credit := rule.Debit, rule.Credit
+	if amount.IsNegative() {
+		debit, credit = credit, debit
+	}
+	amount = amount.Abs()
+
+	return &models.JournalEntry{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		EntryDate:   date,
+		Description: description,
+		Lines: []models.JournalLine{
+			{AccountCode: debit, Debit: amount, Credit: decimal.Zero},
+			{AccountCode: credit, Debit: decimal.Zero, Credit: amount},
+		},
+		CreatedAt: time.Now(),
+	}
+}
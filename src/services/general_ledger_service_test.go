@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestStatementJournal(t *testing.T) {
+	tests := []struct {
+		name       string
+		entryType  models.StatementEntryType
+		amount     int64
+		metadata   map[string]interface{}
+		wantDebit  models.GLAccountCode
+		wantCredit models.GLAccountCode
+	}{
+		{"Purchase", models.EntryTypeTransaction, 100, nil, models.GLAccountCardReceivable, models.GLAccountCashClearing},
+		{"Cash advance", models.EntryTypeCashAdvance, 100, nil, models.GLAccountCardReceivable, models.GLAccountCashClearing},
+		{"Payment", models.EntryTypePayment, 100, nil, models.GLAccountCashClearing, models.GLAccountCardReceivable},
+		{"Refund", models.EntryTypeRefund, 100, nil, models.GLAccountCashClearing, models.GLAccountCardReceivable},
+		{"Interest", models.EntryTypeFeeInterest, 12, nil, models.GLAccountCardReceivable, models.GLAccountInterestIncome},
+		{"Late fee", models.EntryTypeFeeLate, 35, nil, models.GLAccountCardReceivable, models.GLAccountFeeIncome},
+		{"Cashback statement credit", models.EntryTypeCashbackRedeemed, 10, nil, models.GLAccountCashClearing, models.GLAccountCardReceivable},
+		{
+			"Fee waiver", models.EntryTypeCredit, 35,
+			map[string]interface{}{"original_fee_type": "fee_late"},
+			models.GLAccountFeeIncome, models.GLAccountCardReceivable,
+		},
+		{
+			"Interest waiver", models.EntryTypeCredit, 12,
+			map[string]interface{}{"original_fee_type": "fee_interest"},
+			models.GLAccountInterestIncome, models.GLAccountCardReceivable,
+		},
+		{
+			"Returned payment", models.EntryTypeAdjustment, 100,
+			map[string]interface{}{"original_payment_id": uuid.New().String()},
+			models.GLAccountCardReceivable, models.GLAccountCashClearing,
+		},
+		{"Manual credit", models.EntryTypeAdjustment, -20, nil, models.GLAccountBalanceAdjustments, models.GLAccountCardReceivable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &models.StatementLedgerEntry{
+				ID:        uuid.New(),
+				TenantID:  uuid.New(),
+				EntryType: tt.entryType,
+				Amount:    decimal.NewFromInt(tt.amount),
+				Metadata:  tt.metadata,
+			}
+
+			journal, err := statementJournal(entry)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !journal.IsBalanced() {
+				t.Errorf("journal is not balanced: %+v", journal.Lines)
+			}
+			if journal.Lines[0].AccountCode != tt.wantDebit || journal.Lines[1].AccountCode != tt.wantCredit {
+				t.Errorf("posted Dr %s / Cr %s, want Dr %s / Cr %s",
+					journal.Lines[0].AccountCode, journal.Lines[1].AccountCode, tt.wantDebit, tt.wantCredit)
+			}
+			if want := decimal.NewFromInt(tt.amount).Abs(); !journal.TotalDebits().Equal(want) {
+				t.Errorf("amount = %s, want %s", journal.TotalDebits(), want)
+			}
+		})
+	}
+}
+
+func TestGeneralLedgerService_TrialBalance(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	day := time.Date(2026, time.April, 6, 0, 0, 0, 0, time.UTC)
+
+	card := testCard()
+	card.AnnualFee = decimal.NewFromInt(95)
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	cards := NewCreditCardServiceWithStore(store)
+	purchase, err := cards.RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:       card,
+		Amount:           decimal.NewFromInt(400),
+		Description:      "Dinner",
+		MerchantName:     "Test Merchant",
+		MerchantCategory: "5812",
+		TransactionDate:  day,
+		PostingDate:      day,
+	})
+	if err != nil {
+		t.Fatalf("failed to record purchase: %v", err)
+	}
+
+	if _, err := cards.RecordRefund(ctx, CCRefundRequest{
+		CreditCard:            card,
+		OriginalTransactionID: purchase.TransactionEntry.ID,
+		RefundAmount:          decimal.NewFromInt(100),
+		RefundDate:            day,
+		PostingDate:           day,
+		MerchantName:          "Test Merchant",
+	}); err != nil {
+		t.Fatalf("failed to record refund: %v", err)
+	}
+
+	fees := NewFeeServiceWithStore(store)
+	fee, err := fees.AssessAnnualFee(ctx, AnnualFeeRequest{CreditCard: card, AnniversaryDate: day})
+	if err != nil {
+		t.Fatalf("failed to assess annual fee: %v", err)
+	}
+	if _, err := fees.WaiveFee(ctx, FeeWaiverRequest{
+		EntryID:     fee.EntryID,
+		WaiveAmount: decimal.NewFromInt(95),
+		Reason:      "Retention",
+		ApprovedBy:  "ops",
+	}); err != nil {
+		t.Fatalf("failed to waive fee: %v", err)
+	}
+
+	if _, _, err := NewCashbackServiceWithStore(store).RedeemCashback(ctx, RedeemCashbackRequest{
+		TenantID:       card.TenantID,
+		CreditCard:     card,
+		Amount:         decimal.NewFromInt(2),
+		RedemptionDate: day,
+		RedeemAs:       "statement_credit",
+	}); err != nil {
+		t.Fatalf("failed to redeem cashback: %v", err)
+	}
+
+	if _, err := cards.RecordPayment(ctx, CCPaymentRequest{
+		CreditCard:  card,
+		Amount:      decimal.NewFromInt(150),
+		PaymentDate: day,
+		PostingDate: day,
+	}); err != nil {
+		t.Fatalf("failed to record payment: %v", err)
+	}
+
+	// The fee waiver posts today, so report as of now
+	report, err := NewGeneralLedgerServiceWithStore(store).GetTrialBalance(ctx, &card.TenantID, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !report.IsBalanced() {
+		t.Errorf("trial balance nets to %s, want 0", report.Net())
+	}
+
+	balances := make(map[models.GLAccountCode]decimal.Decimal)
+	for _, line := range report.Lines {
+		balances[line.Account.Code] = line.Balance()
+	}
+
+	// 400 purchase - 100 refund + 95 fee - 95 waiver - 2 cashback - 150 payment
+	want := map[models.GLAccountCode]decimal.Decimal{
+		models.GLAccountCardReceivable:    decimal.NewFromInt(148),
+		models.GLAccountFeeIncome:         decimal.Zero,
+		models.GLAccountCashbackLiability: decimal.NewFromFloat(2.5), // 1.5% of 300, less 2 redeemed
+		models.GLAccountRewardsExpense:    decimal.NewFromFloat(4.5),
+		models.GLAccountCashClearing:      decimal.NewFromInt(-150),
+	}
+	for code, amount := range want {
+		if !balances[code].Equal(amount) {
+			t.Errorf("account %s balance = %s, want %s", code, balances[code], amount)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// GLExportFormat names a journal export file format
+type GLExportFormat string
+
+const (
+	GLExportCSV  GLExportFormat = "csv"
+	GLExportIIF  GLExportFormat = "iif"
+	GLExportJSON GLExportFormat = "json"
+)
+
+// GLExportService summarizes ledger activity into journals for accounting systems
+type GLExportService struct {
+	store         repository.Store
+	ledgerService *StatementLedgerService
+}
+
+// NewGLExportService creates a new GL export service
+func NewGLExportService(db Querier) *GLExportService {
+	return NewGLExportServiceWithStore(postgres.NewStore(db))
+}
+
+// NewGLExportServiceWithStore creates a GL export service backed by the given store
+func NewGLExportServiceWithStore(store repository.Store) *GLExportService {
+	return &GLExportService{
+		store:         store,
+		ledgerService: NewStatementLedgerServiceWithStore(store),
+	}
+}
+
+// GLExportRequest selects the activity to export
+type GLExportRequest struct {
+	TenantID uuid.UUID
+	From     time.Time // Inclusive
+	To       time.Time // Inclusive
+
+	// Mapping defaults to models.DefaultGLAccountMapping
+	Mapping *models.GLAccountMapping
+}
+
+// GLExportLine is the summarized activity of one account
+type GLExportLine struct {
+	AccountCode models.GLAccountCode `json:"account_code"`
+	AccountName string               `json:"account_name"`
+	Debit       decimal.Decimal      `json:"debit"`
+	Credit      decimal.Decimal      `json:"credit"`
+	EntryCount  int                  `json:"entry_count"`
+}
+
+// Net returns debits less credits
+func (l GLExportLine) Net() decimal.Decimal {
+	return l.Debit.Sub(l.Credit)
+}
+
+// GLControlTotal ties the control account back to the statement ledger
+type GLControlTotal struct {
+	AccountCode        models.GLAccountCode `json:"account_code"`
+	AccountNet         decimal.Decimal      `json:"account_net"`
+	StatementLedgerNet decimal.Decimal      `json:"statement_ledger_net"`
+}
+
+// Ties returns true if the control account matches the statement ledger
+func (c GLControlTotal) Ties() bool {
+	return c.AccountNet.Equal(c.StatementLedgerNet)
+}
+
+// GLExport is a summarized journal for a date range
+type GLExport struct {
+	TenantID       uuid.UUID       `json:"tenant_id"`
+	From           time.Time       `json:"from"`
+	To             time.Time       `json:"to"`
+	Lines          []GLExportLine  `json:"lines"`
+	TotalDebits    decimal.Decimal `json:"total_debits"`
+	TotalCredits   decimal.Decimal `json:"total_credits"`
+	StatementCount int             `json:"statement_entry_count"`
+	CashbackCount  int             `json:"cashback_entry_count"`
+	RecoveryCount  int             `json:"recovery_count"`
+	Control        GLControlTotal  `json:"control_total"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
+
+// Export summarizes cleared statement entries posted in the range, and
+// cashback entries and recoveries dated in the range, into one line per account.
+// Returns an error if the control account does not tie to the statement ledger.
+func (s *GLExportService) Export(ctx context.Context, req GLExportRequest) (*GLExport, error) {
+	if req.To.Before(req.From) {
+		return nil, fmt.Errorf("export range ends before it starts")
+	}
+
+	mapping := req.Mapping
+	if mapping == nil {
+		mapping = models.DefaultGLAccountMapping()
+	}
+
+	statementEntries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:   &req.TenantID,
+		Statuses:   []models.EntryStatus{models.EntryStatusCleared},
+		PostedFrom: &req.From,
+		PostedTo:   &req.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement entries: %w", err)
+	}
+
+	cashbackEntries, err := s.store.CashbackEntries().List(ctx, repository.CashbackEntryFilter{
+		TenantID: &req.TenantID,
+		From:     &req.From,
+		To:       &req.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cashback entries: %w", err)
+	}
+
+	recoveries, err := s.store.Recoveries().List(ctx, repository.RecoveryFilter{
+		TenantID: &req.TenantID,
+		From:     &req.From,
+		To:       &req.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recoveries: %w", err)
+	}
+
+	lines := make(map[models.GLAccountCode]*GLExportLine)
+	post := func(rule models.GLPostingRule, amount decimal.Decimal) {
+		debit, credit := rule.Debit, rule.Credit
+		if amount.IsNegative() {
+			debit, credit = credit, debit
+		}
+		amount = amount.Abs()
+
+		for _, code := range []models.GLAccountCode{debit, credit} {
+			if _, ok := lines[code]; !ok {
+				lines[code] = &GLExportLine{
+					AccountCode: code,
+					AccountName: mapping.AccountName(code),
+					Debit:       decimal.Zero,
+					Credit:      decimal.Zero,
+				}
+			}
+			lines[code].EntryCount++
+		}
+		lines[debit].Debit = lines[debit].Debit.Add(amount)
+		lines[credit].Credit = lines[credit].Credit.Add(amount)
+	}
+
+	for _, entry := range statementEntries {
+		rule, ok := mapping.StatementRule(entry)
+		if !ok {
+			return nil, fmt.Errorf("no GL mapping for statement entry type %s", entry.EntryType)
+		}
+		post(rule, entry.GetSignedAmount())
+	}
+
+	for _, entry := range cashbackEntries {
+		rule, ok := mapping.CashbackRule(entry)
+		if !ok {
+			return nil, fmt.Errorf("no GL mapping for cashback entry type %s", entry.EntryType)
+		}
+		post(rule, entry.GetSignedAmount())
+	}
+
+	// Recoveries are booked gross as income: the net is cash and the
+	// agency's commission an expense
+	for _, recovery := range recoveries {
+		post(models.GLPostingRule{Debit: models.GLAccountCashClearing, Credit: models.GLAccountRecoveries}, recovery.NetAmount)
+		if recovery.Commission.IsPositive() {
+			post(models.GLPostingRule{Debit: models.GLAccountCollectionExpense, Credit: models.GLAccountRecoveries}, recovery.Commission)
+		}
+	}
+
+	export := &GLExport{
+		TenantID:       req.TenantID,
+		From:           req.From,
+		To:             req.To,
+		Lines:          make([]GLExportLine, 0, len(lines)),
+		TotalDebits:    decimal.Zero,
+		TotalCredits:   decimal.Zero,
+		StatementCount: len(statementEntries),
+		CashbackCount:  len(cashbackEntries),
+		RecoveryCount:  len(recoveries),
+		GeneratedAt:    time.Now(),
+	}
+
+	for _, line := range lines {
+		export.Lines = append(export.Lines, *line)
+		export.TotalDebits = export.TotalDebits.Add(line.Debit)
+		export.TotalCredits = export.TotalCredits.Add(line.Credit)
+	}
+	sort.Slice(export.Lines, func(i, j int) bool {
+		return export.Lines[i].AccountCode < export.Lines[j].AccountCode
+	})
+
+	// The statement ledger's movement over the same range must land on the control account
+	ledgerNet, err := s.ledgerService.CalculateStatementBalance(ctx, req.TenantID, req.From, req.To, decimal.Zero)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate statement balance: %w", err)
+	}
+
+	export.Control = GLControlTotal{
+		AccountCode:        mapping.ControlAccount,
+		AccountNet:         decimal.Zero,
+		StatementLedgerNet: ledgerNet,
+	}
+	if line, ok := lines[mapping.ControlAccount]; ok {
+		export.Control.AccountNet = line.Net()
+	}
+
+	if !export.Control.Ties() {
+		return nil, fmt.Errorf("control account %s net %s does not tie to statement ledger net %s",
+			mapping.ControlAccount, export.Control.AccountNet, ledgerNet)
+	}
+
+	return export, nil
+}
+
+// Write writes the export in the given format
+func (e *GLExport) Write(w io.Writer, format GLExportFormat) error {
+	switch format {
+	case GLExportCSV:
+		return e.WriteCSV(w)
+	case GLExportIIF:
+		return e.WriteIIF(w)
+	case GLExportJSON:
+		return e.WriteJSON(w)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// WriteCSV writes one row per account followed by the totals and control rows
+func (e *GLExport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	rows := [][]string{{"account_code", "account_name", "debit", "credit", "entry_count"}}
+	for _, line := range e.Lines {
+		rows = append(rows, []string{
+			string(line.AccountCode),
+			line.AccountName,
+			line.Debit.StringFixed(2),
+			line.Credit.StringFixed(2),
+			strconv.Itoa(line.EntryCount),
+		})
+	}
+	rows = append(rows,
+		[]string{"TOTAL", "", e.TotalDebits.StringFixed(2), e.TotalCredits.StringFixed(2),
+			strconv.Itoa(e.StatementCount + e.CashbackCount + e.RecoveryCount)},
+		[]string{"CONTROL", string(e.Control.AccountCode),
+			e.Control.AccountNet.StringFixed(2), e.Control.StatementLedgerNet.StringFixed(2), ""},
+	)
+
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// WriteIIF writes a single QuickBooks general journal transaction dated at
+// the end of the range. Amounts are signed: debits positive, credits negative.
+func (e *GLExport) WriteIIF(w io.Writer) error {
+	header := "!TRNS\tTRNSTYPE\tDATE\tACCNT\tAMOUNT\tMEMO\n" +
+		"!SPL\tTRNSTYPE\tDATE\tACCNT\tAMOUNT\tMEMO\n" +
+		"!ENDTRNS\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return fmt.Errorf("failed to write IIF: %w", err)
+	}
+
+	date := e.To.Format("01/02/2006")
+	memo := fmt.Sprintf("Ledger activity %s to %s", e.From.Format("2006-01-02"), e.To.Format("2006-01-02"))
+
+	first := true
+	for _, line := range e.Lines {
+		net := line.Net()
+		if net.IsZero() {
+			continue
+		}
+
+		kind := "SPL"
+		if first {
+			kind = "TRNS"
+			first = false
+		}
+		if _, err := fmt.Fprintf(w, "%s\tGENERAL JOURNAL\t%s\t%s\t%s\t%s\n",
+			kind, date, line.AccountName, net.StringFixed(2), memo); err != nil {
+			return fmt.Errorf("failed to write IIF: %w", err)
+		}
+	}
+
+	if !first {
+		if _, err := io.WriteString(w, "ENDTRNS\n"); err != nil {
+			return fmt.Errorf("failed to write IIF: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the export as an indented JSON document
+func (e *GLExport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(e); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestGLExportService_Export(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	tenantID := uuid.New()
+	cardID := uuid.New()
+	from := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.April, 30, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return from.AddDate(0, 0, d-1) }
+
+	statementEntries := []*models.StatementLedgerEntry{
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(400), PostingDate: day(5)},
+		{EntryType: models.EntryTypeFeeAnnual, Amount: decimal.NewFromInt(95), PostingDate: day(6)},
+		{
+			EntryType:   models.EntryTypeAdjustment,
+			Amount:      decimal.NewFromInt(-95),
+			PostingDate: day(7),
+			Metadata:    map[string]interface{}{"original_fee_type": string(models.EntryTypeFeeAnnual)},
+		},
+		{EntryType: models.EntryTypePayment, Amount: decimal.NewFromInt(150), PostingDate: day(20)},
+		// Pending and out of range entries are left out
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(50), PostingDate: day(21), Status: models.EntryStatusPending},
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(70), PostingDate: to.AddDate(0, 0, 2)},
+	}
+	for _, entry := range statementEntries {
+		entry.TenantID = tenantID
+		entry.EntryDate = entry.PostingDate
+		if entry.Status == "" {
+			entry.Status = models.EntryStatusCleared
+		}
+		if err := store.StatementEntries().Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create statement entry: %v", err)
+		}
+	}
+
+	cashbackEntries := []*models.CashbackLedgerEntry{
+		{EntryType: models.CashbackEarned, Amount: decimal.NewFromInt(6), EntryDate: day(5)},
+		{EntryType: models.CashbackRedeemed, Amount: decimal.NewFromInt(-2), EntryDate: day(25)},
+	}
+	for _, entry := range cashbackEntries {
+		entry.TenantID = tenantID
+		entry.CreditCardID = cardID
+		if err := store.CashbackEntries().Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create cashback entry: %v", err)
+		}
+	}
+
+	service := NewGLExportServiceWithStore(store)
+
+	t.Run("Summarizes activity per account", func(t *testing.T) {
+		export, err := service.Export(ctx, GLExportRequest{TenantID: tenantID, From: from, To: to})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		// 400 purchase + 95 fee - 95 waiver - 150 payment
+		if !export.Control.AccountNet.Equal(decimal.NewFromInt(250)) || !export.Control.Ties() {
+			t.Errorf("control = %+v, want 250 tied", export.Control)
+		}
+		if !export.TotalDebits.Equal(export.TotalCredits) {
+			t.Errorf("debits %s != credits %s", export.TotalDebits, export.TotalCredits)
+		}
+
+		var csvOut bytes.Buffer
+		if err := export.Write(&csvOut, GLExportCSV); err != nil {
+			t.Fatalf("failed to write CSV: %v", err)
+		}
+		wantCSV := strings.Join([]string{
+			"account_code,account_name,debit,credit,entry_count",
+			"1000,Cash Clearing,150.00,402.00,3",
+			"1100,Card Receivable,495.00,245.00,4",
+			"2100,Cashback Liability,2.00,6.00,2",
+			"4100,Fee Income,95.00,95.00,2",
+			"5100,Rewards Expense,6.00,0.00,1",
+			"TOTAL,,748.00,748.00,6",
+			"CONTROL,1100,250.00,250.00,",
+			"",
+		}, "\n")
+		if csvOut.String() != wantCSV {
+			t.Errorf("CSV =\n%s\nwant\n%s", csvOut.String(), wantCSV)
+		}
+
+		var iifOut bytes.Buffer
+		if err := export.Write(&iifOut, GLExportIIF); err != nil {
+			t.Fatalf("failed to write IIF: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(iifOut.String()), "\n")
+		// Three headers, four non-zero accounts and the closing marker
+		if len(lines) != 8 || !strings.HasPrefix(lines[3], "TRNS\tGENERAL JOURNAL\t04/30/2026\tCash Clearing\t-252.00") ||
+			lines[7] != "ENDTRNS" {
+			t.Errorf("unexpected IIF:\n%s", iifOut.String())
+		}
+		net := decimal.Zero
+		for _, line := range lines[3:7] {
+			amount, err := decimal.NewFromString(strings.Split(line, "\t")[4])
+			if err != nil {
+				t.Fatalf("bad IIF amount in %q: %v", line, err)
+			}
+			net = net.Add(amount)
+		}
+		if !net.IsZero() {
+			t.Errorf("IIF transaction nets to %s, want 0", net)
+		}
+
+		var jsonOut bytes.Buffer
+		if err := export.Write(&jsonOut, GLExportJSON); err != nil {
+			t.Fatalf("failed to write JSON: %v", err)
+		}
+		var decoded GLExport
+		if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+			t.Fatalf("failed to decode JSON: %v", err)
+		}
+		if len(decoded.Lines) != 5 || !decoded.Control.StatementLedgerNet.Equal(decimal.NewFromInt(250)) {
+			t.Errorf("unexpected JSON export: %s", jsonOut.String())
+		}
+	})
+
+	t.Run("Custom mapping renames and reroutes accounts", func(t *testing.T) {
+		mapping := models.DefaultGLAccountMapping()
+		mapping.Statement[models.EntryTypeFeeAnnual] = models.GLPostingRule{
+			Debit:  models.GLAccountCardReceivable,
+			Credit: "4150",
+		}
+		mapping.AccountNames = map[models.GLAccountCode]string{"4150": "Annual Fee Income"}
+
+		export, err := service.Export(ctx, GLExportRequest{TenantID: tenantID, From: from, To: to, Mapping: mapping})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		found := false
+		for _, line := range export.Lines {
+			if line.AccountCode == "4150" {
+				found = line.AccountName == "Annual Fee Income" && line.Net().IsZero()
+			}
+		}
+		if !found {
+			t.Errorf("expected annual fee and waiver on 4150: %+v", export.Lines)
+		}
+	})
+
+	t.Run("Control account that does not tie is rejected", func(t *testing.T) {
+		mapping := models.DefaultGLAccountMapping()
+		mapping.ControlAccount = models.GLAccountCashClearing
+
+		if _, err := service.Export(ctx, GLExportRequest{TenantID: tenantID, From: from, To: to, Mapping: mapping}); err == nil {
+			t.Fatal("expected control total error")
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+)
+
+// Operations recorded against idempotency keys
+const (
+	operationRecordTransaction = "record_transaction"
+	operationRecordPayment     = "record_payment"
+	operationRecordRefund      = "record_refund"
+	operationRecordAdjustment  = "record_adjustment"
+	operationRecordTransfer    = "record_balance_transfer"
+	operationInitiatePayment   = "initiate_payment"
+
+	operationAuthorize              = "authorize"
+	operationIncrementAuthorization = "increment_authorization"
+	operationCaptureAuthorization   = "capture_authorization"
+
+	operationOpenDispute = "open_dispute"
+
+	operationRecordRecovery = "record_recovery"
+)
+
+// idempotently runs fn in a transaction at most once per tenant and key.
+// fn fills in result; its encoded form is stored with the key in the same
+// transaction. When the key has been used before, fn is skipped and result is
+// replaced with the stored one. An empty key runs fn in a plain transaction.
+func idempotently[T any](
+	ctx context.Context,
+	store repository.Store,
+	tenantID uuid.UUID,
+	key string,
+	operation string,
+	result *T,
+	fn func(tx repository.Store) error,
+) error {
+	if key == "" {
+		return store.WithinTx(ctx, fn)
+	}
+
+	err := store.WithinTx(ctx, func(tx repository.Store) error {
+		replayed, err := replayResult(ctx, tx, tenantID, key, operation, result)
+		if err != nil || replayed {
+			return err
+		}
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		response, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode result: %w", err)
+		}
+
+		return tx.IdempotencyKeys().Create(ctx, &models.IdempotencyRecord{
+			ID:        uuid.New(),
+			TenantID:  tenantID,
+			Key:       key,
+			Operation: operation,
+			Response:  response,
+			CreatedAt: time.Now(),
+		})
+	})
+
+	// A concurrent request with the same key committed first. fn's writes have
+	// been discarded, and a caller's transaction that store joins is still
+	// usable, so the stored result can be read back through store.
+	if errors.Is(err, repository.ErrDuplicate) {
+		_, err = replayResult(ctx, store, tenantID, key, operation, result)
+	}
+
+	return err
+}
+
+// replayResult decodes the stored result for key into result.
+// Reports false when the key has not been used yet.
+func replayResult[T any](
+	ctx context.Context,
+	store repository.Store,
+	tenantID uuid.UUID,
+	key string,
+	operation string,
+	result *T,
+) (bool, error) {
+	record, err := store.IdempotencyKeys().Get(ctx, tenantID, key)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to look up idempotency key: %w", err)
+	}
+
+	if record.Operation != operation {
+		return false, fmt.Errorf("idempotency key %q was already used for %s", key, record.Operation)
+	}
+
+	var replayed T
+	if err := json.Unmarshal(record.Response, &replayed); err != nil {
+		return false, fmt.Errorf("failed to decode stored result: %w", err)
+	}
+	*result = replayed
+
+	return true, nil
+}
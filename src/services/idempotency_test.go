@@ -0,0 +1,302 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+func TestCreditCardService_IdempotentReplay(t *testing.T) {
+	now := time.Date(2026, time.February, 3, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// call performs the request and returns the ID of the entry it posted
+		call          func(s *CreditCardService, ctx context.Context, card *models.CreditCard) (string, error)
+		wantAvailable decimal.Decimal
+	}{
+		{
+			name: "Transaction",
+			call: func(s *CreditCardService, ctx context.Context, card *models.CreditCard) (string, error) {
+				result, err := s.RecordTransaction(ctx, CCTransactionRequest{
+					CreditCard:       card,
+					Amount:           decimal.NewFromInt(100),
+					Description:      "Groceries",
+					MerchantName:     "Test Merchant",
+					MerchantCategory: "5411",
+					TransactionDate:  now,
+					PostingDate:      now,
+					ReferenceID:      "TXN-1",
+					IdempotencyKey:   "key-1",
+				})
+				if err != nil {
+					return "", err
+				}
+				return result.TransactionEntry.ID.String(), nil
+			},
+			wantAvailable: decimal.NewFromInt(4900),
+		},
+		{
+			name: "Payment",
+			call: func(s *CreditCardService, ctx context.Context, card *models.CreditCard) (string, error) {
+				result, err := s.RecordPayment(ctx, CCPaymentRequest{
+					CreditCard:     card,
+					Amount:         decimal.NewFromInt(100),
+					PaymentDate:    now,
+					PostingDate:    now,
+					PaymentMethod:  "ACH",
+					ReferenceID:    "PMT-1",
+					IdempotencyKey: "key-1",
+				})
+				if err != nil {
+					return "", err
+				}
+				return result.PaymentEntry.ID.String(), nil
+			},
+			wantAvailable: decimal.NewFromInt(5000),
+		},
+		{
+			name: "Adjustment",
+			call: func(s *CreditCardService, ctx context.Context, card *models.CreditCard) (string, error) {
+				entry, err := s.RecordAdjustment(ctx, AdjustmentRequest{
+					CreditCard:     card,
+					Amount:         decimal.NewFromInt(25),
+					AdjustmentDate: now,
+					Reason:         "Correction",
+					ApprovedBy:     "ops",
+					ReferenceID:    "ADJ-1",
+					IdempotencyKey: "key-1",
+				})
+				if err != nil {
+					return "", err
+				}
+				return entry.ID.String(), nil
+			},
+			wantAvailable: decimal.NewFromInt(4975),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			service := NewCreditCardServiceWithStore(store)
+
+			card := testCard()
+			card.CashbackEnabled = false
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+
+			first, err := tt.call(service, ctx, card)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			second, err := tt.call(service, ctx, card)
+			if err != nil {
+				t.Fatalf("unexpected error on replay: %v", err)
+			}
+			if first != second {
+				t.Errorf("replay returned entry %s, want %s", second, first)
+			}
+
+			entries, err := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+			if err != nil {
+				t.Fatalf("failed to list entries: %v", err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("ledger entries = %d, want 1", len(entries))
+			}
+
+			updated, err := store.CreditCards().GetByID(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to load card: %v", err)
+			}
+			if !updated.AvailableCredit.Equal(tt.wantAvailable) {
+				t.Errorf("available credit = %s, want %s", updated.AvailableCredit, tt.wantAvailable)
+			}
+		})
+	}
+}
+
+func TestIdempotently_KeyReusedForAnotherOperation(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	payments := NewPaymentServiceWithStore(store)
+	req := InitiatePaymentRequest{
+		TenantID:       card.TenantID,
+		CreditCardID:   card.ID,
+		Amount:         decimal.NewFromInt(100),
+		PaymentType:    models.PaymentTypeRegular,
+		PaymentMethod:  models.PaymentMethodACH,
+		CreatedBy:      "user",
+		IdempotencyKey: "key-1",
+	}
+
+	first, err := payments.InitiatePayment(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	second, err := payments.InitiatePayment(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error on replay: %v", err)
+	}
+	if first.Payment.ID != second.Payment.ID {
+		t.Errorf("replay returned payment %s, want %s", second.Payment.ID, first.Payment.ID)
+	}
+
+	stored, err := payments.GetPaymentsByCard(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to list payments: %v", err)
+	}
+	if len(stored) != 1 {
+		t.Errorf("payments = %d, want 1", len(stored))
+	}
+
+	_, err = NewCreditCardServiceWithStore(store).RecordPayment(ctx, CCPaymentRequest{
+		CreditCard:     card,
+		Amount:         decimal.NewFromInt(100),
+		PaymentDate:    time.Now(),
+		PostingDate:    time.Now(),
+		IdempotencyKey: "key-1",
+	})
+	if err == nil {
+		t.Error("expected an error reusing the key for a different operation")
+	}
+}
+
+func TestIdempotently_ReplaysInsideCallerTransaction(t *testing.T) {
+	ctx := context.Background()
+	card := testCard()
+
+	// Another request with the same key commits while this one is posting
+	stored := TransactionResult{TransactionEntry: &models.StatementLedgerEntry{ID: uuid.New()}}
+	response, err := json.Marshal(stored)
+	if err != nil {
+		t.Fatalf("failed to encode result: %v", err)
+	}
+	store := &fakeStore{
+		rows:        cashbackRows(card, "0"),
+		duplicateOn: "idempotency_keys",
+		concurrent: map[string]fakeRow{
+			"FROM idempotency_keys": {
+				columns: []string{"id", "tenant_id", "idempotency_key", "operation", "response", "created_at"},
+				values: []driver.Value{uuid.New().String(), card.TenantID.String(), "key-1",
+					operationRecordTransaction, response, time.Now()},
+			},
+		},
+	}
+	db := newFakeDB(t, store)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	result, err := NewCreditCardService(tx).RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:      card,
+		Amount:          decimal.NewFromInt(100),
+		Description:     "Test purchase",
+		MerchantName:    "Test Merchant",
+		TransactionDate: time.Now(),
+		PostingDate:     time.Now(),
+		IdempotencyKey:  "key-1",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.TransactionEntry.ID != stored.TransactionEntry.ID {
+		t.Errorf("replayed entry %s, want %s", result.TransactionEntry.ID, stored.TransactionEntry.ID)
+	}
+
+	// The caller's transaction is still usable and holds none of the duplicate's writes
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+	if got := store.totalCommitted(); got != 0 {
+		t.Errorf("duplicate request committed %v", store.committed)
+	}
+}
+
+func TestIdempotently_ReplaysInsideCallerTransactionPostgres(t *testing.T) {
+	ctx := context.Background()
+	db := openPostgres(t)
+	store := postgres.NewStore(db)
+
+	card := testCard()
+	card.CashbackEnabled = false
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+	req := CCTransactionRequest{
+		CreditCard:      card,
+		Amount:          decimal.NewFromInt(100),
+		Description:     "Test purchase",
+		MerchantName:    "Test Merchant",
+		TransactionDate: time.Now(),
+		PostingDate:     time.Now(),
+		IdempotencyKey:  "key-1",
+	}
+
+	first, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer first.Rollback()
+	original, err := NewCreditCardService(first).RecordTransaction(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The second request waits on the card lock until the first commits,
+	// then collides with its key
+	second, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+	defer second.Rollback()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		time.Sleep(200 * time.Millisecond)
+		if err := first.Commit(); err != nil {
+			t.Errorf("failed to commit first request: %v", err)
+		}
+	}()
+	replayed, err := NewCreditCardService(second).RecordTransaction(ctx, req)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replayed.TransactionEntry.ID != original.TransactionEntry.ID {
+		t.Errorf("replayed entry %s, want %s", replayed.TransactionEntry.ID, original.TransactionEntry.ID)
+	}
+	if err := second.Commit(); err != nil {
+		t.Fatalf("failed to commit second request: %v", err)
+	}
+
+	entries, err := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+	if err != nil {
+		t.Fatalf("failed to list entries: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("entries = %d, want 1", len(entries))
+	}
+}
@@ -2,24 +2,37 @@ package services
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 	"github.com/shopspring/decimal"
 )
 
 // InterestService handles interest calculation and accrual
 // Implements GAAP-compliant Average Daily Balance (ADB) method
 type InterestService struct {
-	db *sql.DB
+	store repository.Store
 }
 
 // NewInterestService creates a new interest service
-func NewInterestService(db *sql.DB) *InterestService {
-	return &InterestService{db: db}
+func NewInterestService(db Querier) *InterestService {
+	return NewInterestServiceWithStore(postgres.NewStore(db))
+}
+
+// NewInterestServiceWithStore creates an interest service backed by store
+func NewInterestServiceWithStore(store repository.Store) *InterestService {
+	return &InterestService{store: store}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *InterestService) withStore(store repository.Store) *InterestService {
+	return NewInterestServiceWithStore(store)
 }
 
 // InterestCalculationMethod represents the method used to calculate interest
@@ -51,6 +64,7 @@ type InterestCalculationResult struct {
 	InterestCharge       decimal.Decimal `json:"interest_charge"`
 	MinimumInterestCharge decimal.Decimal `json:"minimum_interest_charge"`
 	WaivedDueToGracePeriod bool          `json:"waived_due_to_grace_period"`
+	Segments             []models.BillingCycleSegment `json:"segments"`
 	CalculatedAt         time.Time       `json:"calculated_at"`
 }
 
@@ -73,13 +87,21 @@ func DefaultInterestConfig() InterestConfig {
 }
 
 // CalculateInterest calculates interest for a billing cycle
-// Uses the Average Daily Balance method per GAAP standards
+// Uses the Average Daily Balance method per GAAP standards.
+// Each balance segment is charged at its own APR on its own daily balances;
+// the grace period only waives segments that have one.
 func (s *InterestService) CalculateInterest(
 	ctx context.Context,
 	card *models.CreditCard,
 	cycle *models.BillingCycle,
 	config InterestConfig,
 ) (*InterestCalculationResult, error) {
+	// Rates come from the card's stored APR history
+	card, err := loadAPRSchedule(ctx, s.store, card)
+	if err != nil {
+		return nil, err
+	}
+
 	result := &InterestCalculationResult{
 		CreditCardID:      card.ID,
 		BillingCycleID:    cycle.ID,
@@ -87,6 +109,11 @@ func (s *InterestService) CalculateInterest(
 		CalculatedAt:      time.Now(),
 	}
 
+	// Interest stops accruing once the balance is charged off
+	if card.IsChargedOff() {
+		return result, nil
+	}
+
 	// Get effective APR for this cycle
 	apr := card.GetEffectiveAPR(cycle.CycleStartDate)
 	result.APRUsed = apr
@@ -100,8 +127,8 @@ func (s *InterestService) CalculateInterest(
 	daysInCycle := int(cycle.CycleEndDate.Sub(cycle.CycleStartDate).Hours() / 24) + 1
 	result.DaysInCycle = daysInCycle
 
-	// Get daily balances for the billing cycle
-	dailyBalances, err := s.getDailyBalances(ctx, card.ID, cycle.CycleStartDate, cycle.CycleEndDate)
+	// Get daily balances for the billing cycle, in total and per segment
+	dailyBalances, segmentBalances, err := s.getDailyBalances(ctx, card, cycle.CycleStartDate, cycle.CycleEndDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get daily balances: %w", err)
 	}
@@ -111,28 +138,79 @@ func (s *InterestService) CalculateInterest(
 	result.AverageDailyBalance = adb
 
 	// Check if grace period applies (paid previous balance in full)
-	if s.qualifiesForGracePeriod(ctx, card, cycle) {
-		result.WaivedDueToGracePeriod = true
-		result.InterestCharge = decimal.Zero
-		return result, nil
+	result.WaivedDueToGracePeriod = s.qualifiesForGracePeriod(ctx, card, cycle)
+
+	// The adjusted balance method works on the cycle totals, so it cannot be
+	// split by segment; the whole balance is charged as purchases
+	if config.Method == AdjustedBalanceMethod {
+		segment := models.BillingCycleSegment{
+			Segment:             models.SegmentPurchase,
+			APR:                 apr,
+			AverageDailyBalance: adb,
+			ClosingBalance:      closingBalance(dailyBalances),
+			GracePeriodApplied:  result.WaivedDueToGracePeriod,
+		}
+		if !segment.GracePeriodApplied {
+			segment.InterestCharge = s.calculateAdjustedBalanceInterest(cycle.PreviousBalance, cycle.PaymentsReceived, apr)
+		}
+		result.Segments = []models.BillingCycleSegment{segment}
+	} else {
+		for _, name := range models.BalanceSegments {
+			records, ok := segmentBalances[name]
+			if !ok {
+				continue
+			}
+
+			segment := models.BillingCycleSegment{
+				Segment:             name,
+				AverageDailyBalance: models.CalculateAverageDailyBalance(records),
+				ClosingBalance:      closingBalance(records),
+				GracePeriodApplied:  result.WaivedDueToGracePeriod && name.HasGracePeriod(),
+			}
+
+			// A rate change during the cycle splits it; each run of days is
+			// charged at its own APR
+			rates := s.splitByRate(card, name, records)
+			for i := range rates {
+				if segment.GracePeriodApplied {
+					continue
+				}
+				rate := &rates[i]
+				days := records[rate.first : rate.last+1]
+				dpr := card.GetDailyPeriodicRate(rate.APR)
+				switch config.Method {
+				case DailyBalanceMethod:
+					rate.InterestCharge = s.calculateDailyBalanceInterest(days, dpr)
+				default:
+					rate.InterestCharge = s.calculateADBInterest(rate.AverageDailyBalance, dpr, len(days))
+				}
+				segment.InterestCharge = segment.InterestCharge.Add(rate.InterestCharge)
+			}
+			segment.APR = rates[len(rates)-1].APR
+			if len(rates) > 1 {
+				for _, rate := range rates {
+					segment.Rates = append(segment.Rates, rate.BillingCycleRate)
+				}
+			}
+			result.Segments = append(result.Segments, segment)
+		}
 	}
 
-	// Calculate interest based on method
-	var interestCharge decimal.Decimal
-	switch config.Method {
-	case AverageDailyBalanceMethod:
-		interestCharge = s.calculateADBInterest(adb, dpr, daysInCycle)
-	case DailyBalanceMethod:
-		interestCharge = s.calculateDailyBalanceInterest(dailyBalances, dpr)
-	case AdjustedBalanceMethod:
-		interestCharge = s.calculateAdjustedBalanceInterest(cycle.PreviousBalance, cycle.PaymentsReceived, apr)
-	default:
-		interestCharge = s.calculateADBInterest(adb, dpr, daysInCycle)
+	interestCharge := decimal.Zero
+	largest := -1
+	for i, segment := range result.Segments {
+		interestCharge = interestCharge.Add(segment.InterestCharge)
+		if largest < 0 || segment.InterestCharge.GreaterThan(result.Segments[largest].InterestCharge) {
+			largest = i
+		}
 	}
 
-	// Apply minimum interest charge if applicable
+	// Apply minimum interest charge if applicable, topping up the segment
+	// that accrued the most
 	result.MinimumInterestCharge = config.MinimumInterestCharge
 	if interestCharge.GreaterThan(decimal.Zero) && interestCharge.LessThan(config.MinimumInterestCharge) {
+		topUp := config.MinimumInterestCharge.Sub(interestCharge)
+		result.Segments[largest].InterestCharge = result.Segments[largest].InterestCharge.Add(topUp)
 		interestCharge = config.MinimumInterestCharge
 	}
 
@@ -142,6 +220,48 @@ func (s *InterestService) CalculateInterest(
 	return result, nil
 }
 
+// segmentRate is a run of daily balance records at one APR
+type segmentRate struct {
+	models.BillingCycleRate
+	first, last int // Indexes into the records
+}
+
+// splitByRate groups a segment's days into runs at the same APR. A cycle
+// without a rate change is a single run.
+func (s *InterestService) splitByRate(
+	card *models.CreditCard,
+	segment models.BalanceSegment,
+	records []models.DailyBalanceRecord,
+) []segmentRate {
+	var rates []segmentRate
+	for i, record := range records {
+		apr := card.SegmentAPR(segment, record.Date)
+		if len(rates) == 0 || !rates[len(rates)-1].APR.Equal(apr) {
+			rates = append(rates, segmentRate{
+				BillingCycleRate: models.BillingCycleRate{From: record.Date, APR: apr},
+				first:            i,
+			})
+		}
+		rate := &rates[len(rates)-1]
+		rate.To = record.Date
+		rate.last = i
+	}
+
+	for i := range rates {
+		rate := &rates[i]
+		rate.AverageDailyBalance = models.CalculateAverageDailyBalance(records[rate.first : rate.last+1])
+	}
+	return rates
+}
+
+// closingBalance returns the last day's balance
+func closingBalance(records []models.DailyBalanceRecord) decimal.Decimal {
+	if len(records) == 0 {
+		return decimal.Zero
+	}
+	return records[len(records)-1].Balance
+}
+
 // calculateADBInterest calculates interest using Average Daily Balance method
 // Formula: ADB × DPR × Days in cycle
 func (s *InterestService) calculateADBInterest(adb, dpr decimal.Decimal, daysInCycle int) decimal.Decimal {
@@ -180,66 +300,160 @@ func (s *InterestService) calculateAdjustedBalanceInterest(previousBalance, paym
 }
 
 // getDailyBalances retrieves daily balance snapshots for interest calculation
+// Each day's balance includes every cleared entry posted on or before that day,
+// in total and split into segments by a segmentLedger. Only segments that
+// carried a balance at some point are returned. Disputed amounts are left out
+// on the days their interest is suspended.
 func (s *InterestService) getDailyBalances(
 	ctx context.Context,
-	creditCardID uuid.UUID,
+	card *models.CreditCard,
 	startDate, endDate time.Time,
-) ([]models.DailyBalanceRecord, error) {
-	query := `
-		WITH RECURSIVE dates AS (
-			SELECT $2::date as date
-			UNION ALL
-			SELECT date + interval '1 day'
-			FROM dates
-			WHERE date < $3::date
-		),
-		daily_entries AS (
-			SELECT
-				d.date,
-				COALESCE(
-					SUM(
-						CASE
-							WHEN sle.entry_type IN ('transaction', 'fee_late', 'fee_failed', 'fee_international', 'returned_reward')
-								THEN sle.amount
-							WHEN sle.entry_type IN ('payment', 'refund', 'reward', 'credit')
-								THEN -sle.amount
-							WHEN sle.entry_type = 'adjustment'
-								THEN sle.amount
-							ELSE 0
-						END
-					) OVER (ORDER BY d.date ROWS UNBOUNDED PRECEDING),
-					0
-				) as running_balance
-			FROM dates d
-			LEFT JOIN statement_ledger_entries sle ON
-				sle.posting_date <= d.date
-				AND EXISTS (
-					SELECT 1 FROM credit_cards cc
-					WHERE cc.id = $1 AND cc.tenant_id = sle.tenant_id
-				)
-				AND sle.status = 'cleared'
-		)
-		SELECT DISTINCT ON (date) date, running_balance
-		FROM daily_entries
-		ORDER BY date, running_balance DESC
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, creditCardID, startDate, endDate)
+) ([]models.DailyBalanceRecord, map[models.BalanceSegment][]models.DailyBalanceRecord, error) {
+	first := models.DateOf(startDate)
+	last := models.DateOf(endDate)
+
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID: &card.TenantID,
+		Statuses: []models.EntryStatus{models.EntryStatusCleared},
+		PostedTo: &last,
+	})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	disputes, err := s.store.Disputes().List(ctx, repository.DisputeFilter{CreditCardID: &card.ID})
+	if err != nil {
+		return nil, nil, err
 	}
-	defer rows.Close()
 
 	var records []models.DailyBalanceRecord
-	for rows.Next() {
-		var record models.DailyBalanceRecord
-		if err := rows.Scan(&record.Date, &record.Balance); err != nil {
-			return nil, err
+	segmentRecords := make(map[models.BalanceSegment][]models.DailyBalanceRecord)
+	ledger := newSegmentLedger(card)
+	next := 0
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		// Entries are ordered by posting date, so each is added exactly once
+		for next < len(entries) && !models.DateOf(entries[next].PostingDate).After(day) {
+			ledger.post(entries[next])
+			next++
+		}
+
+		balances := ledger.suspendDisputed(disputes, day)
+		records = append(records, models.DailyBalanceRecord{Date: day, Balance: balances.Total()})
+		for segment, balance := range balances {
+			segmentRecords[segment] = append(segmentRecords[segment], models.DailyBalanceRecord{Date: day, Balance: balance})
+		}
+	}
+
+	// A segment first charged mid-cycle owes nothing on the days before
+	for segment, segmentDays := range segmentRecords {
+		if missing := len(records) - len(segmentDays); missing > 0 {
+			padded := make([]models.DailyBalanceRecord, missing, len(records))
+			for i := range padded {
+				padded[i] = models.DailyBalanceRecord{Date: records[i].Date, Balance: decimal.Zero}
+			}
+			segmentRecords[segment] = append(padded, segmentDays...)
+		}
+	}
+
+	return records, segmentRecords, nil
+}
+
+// segmentLedger replays statement entries into per-segment balances.
+// Debits add to the segment they belong to. A credit that records its
+// allocation (see models.AllocatePayment) pays exactly those segments, and a
+// debit carrying an allocation, such as a returned payment, adds it back.
+// Other credits pay segments down in creditOrder.
+type segmentLedger struct {
+	card      *models.CreditCard
+	balances  models.SegmentBalances
+	segmentOf map[uuid.UUID]models.BalanceSegment
+}
+
+func newSegmentLedger(card *models.CreditCard) *segmentLedger {
+	return &segmentLedger{
+		card:      card,
+		balances:  make(models.SegmentBalances),
+		segmentOf: make(map[uuid.UUID]models.BalanceSegment),
+	}
+}
+
+// post applies one entry to the balances
+func (l *segmentLedger) post(entry *models.StatementLedgerEntry) {
+	amount := entry.GetSignedAmount()
+	if allocation, ok := models.SegmentAllocationFromMetadata(entry.Metadata); ok {
+		for segment, allocated := range allocation {
+			if amount.IsPositive() {
+				l.balances.Charge(segment, allocated)
+			} else {
+				l.balances.Charge(segment, allocated.Neg())
+			}
+		}
+		return
+	}
+
+	if amount.IsPositive() {
+		segment := l.card.SegmentOf(entry)
+		l.segmentOf[entry.ID] = segment
+		l.balances.Charge(segment, amount)
+	} else {
+		l.balances.PayDown(amount.Neg(), creditOrder(entry, l.segmentOf))
+	}
+}
+
+// suspendDisputed returns the day's balances without the disputed amounts
+// whose interest is suspended. Each amount comes off the segment of the
+// disputed charge, never taking it below zero.
+func (l *segmentLedger) suspendDisputed(disputes []*models.Dispute, day time.Time) models.SegmentBalances {
+	balances := l.balances
+	copied := false
+	for _, dispute := range disputes {
+		segment, posted := l.segmentOf[dispute.StatementEntryID]
+		if !posted || !dispute.InterestSuspendedOn(day) {
+			continue
+		}
+		suspended := decimal.Min(dispute.Amount, balances[segment])
+		if !suspended.IsPositive() {
+			continue
+		}
+
+		// The ledger's own balances carry on to the next day
+		if !copied {
+			balances = make(models.SegmentBalances, len(l.balances))
+			for name, balance := range l.balances {
+				balances[name] = balance
+			}
+			copied = true
 		}
-		records = append(records, record)
+		balances[segment] = balances[segment].Sub(suspended)
 	}
+	return balances
+}
 
-	return records, rows.Err()
+// creditOrder returns the order in which a credit pays down the segments.
+// A credit placed in a segment, or a refund of a known purchase, pays that
+// segment first; the rest follow models.BalanceSegments.
+func creditOrder(entry *models.StatementLedgerEntry, segmentOf map[uuid.UUID]models.BalanceSegment) []models.BalanceSegment {
+	first, ok := entry.Metadata[models.SegmentMetadataKey].(string)
+	if !ok {
+		if original, isString := entry.Metadata["original_transaction_id"].(string); isString {
+			if id, err := uuid.Parse(original); err == nil {
+				var segment models.BalanceSegment
+				segment, ok = segmentOf[id]
+				first = string(segment)
+			}
+		}
+	}
+	if !ok || first == "" {
+		return models.BalanceSegments
+	}
+
+	order := []models.BalanceSegment{models.BalanceSegment(first)}
+	for _, segment := range models.BalanceSegments {
+		if segment != order[0] {
+			order = append(order, segment)
+		}
+	}
+	return order
 }
 
 // qualifiesForGracePeriod checks if the cardholder qualifies for grace period
@@ -250,69 +464,79 @@ func (s *InterestService) qualifiesForGracePeriod(
 	currentCycle *models.BillingCycle,
 ) bool {
 	// Get previous billing cycle
-	query := `
-		SELECT new_balance, payments_made
-		FROM billing_cycles
-		WHERE credit_card_id = $1
-		  AND cycle_number = $2
-		  AND status IN ('paid_full')
-		LIMIT 1
-	`
-
-	var prevBalance, prevPayments decimal.Decimal
-	err := s.db.QueryRowContext(ctx, query, card.ID, currentCycle.CycleNumber-1).Scan(&prevBalance, &prevPayments)
-	if err == sql.ErrNoRows {
-		// No previous cycle, grace period applies for new accounts
-		return true
-	}
+	previousNumber := currentCycle.CycleNumber - 1
+	cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{
+		CreditCardID: &card.ID,
+		CycleNumber:  &previousNumber,
+	})
 	if err != nil {
 		return false
 	}
+	if len(cycles) == 0 {
+		// No previous cycle, grace period applies for new accounts
+		return true
+	}
 
 	// Grace period applies if previous balance was paid in full
-	return prevPayments.GreaterThanOrEqual(prevBalance)
+	previous := cycles[0]
+	return previous.PaymentsMade.GreaterThanOrEqual(previous.NewBalance)
 }
 
-// AccrueInterest creates an interest charge entry in the statement ledger
+// AccrueInterest creates one interest charge entry in the statement ledger
+// per segment that accrued interest. Each entry is placed in its segment, so
+// unpaid interest accrues at that segment's APR in later cycles.
 func (s *InterestService) AccrueInterest(
 	ctx context.Context,
 	tenantID uuid.UUID,
 	cycle *models.BillingCycle,
 	result *InterestCalculationResult,
-) (*models.StatementLedgerEntry, error) {
+) ([]*models.StatementLedgerEntry, error) {
 	if result.InterestCharge.LessThanOrEqual(decimal.Zero) {
 		return nil, nil // No interest to accrue
 	}
 
-	entry := &models.StatementLedgerEntry{
-		ID:          uuid.New(),
-		TenantID:    tenantID,
-		StatementID: &cycle.ID,
-		EntryType:   models.EntryTypeFeeInterest,
-		EntryDate:   time.Now(),
-		PostingDate: cycle.CycleEndDate,
-		Amount:      result.InterestCharge,
-		Description: fmt.Sprintf("Interest charge (APR: %.2f%%, ADB: $%.2f)",
-			result.APRUsed.InexactFloat64(),
-			result.AverageDailyBalance.InexactFloat64()),
-		Status: models.EntryStatusPending,
-		Metadata: map[string]interface{}{
-			"calculation_method":     string(result.CalculationMethod),
-			"apr_used":               result.APRUsed.String(),
-			"daily_periodic_rate":    result.DailyPeriodicRate.String(),
-			"average_daily_balance":  result.AverageDailyBalance.String(),
-			"days_in_cycle":          result.DaysInCycle,
-			"billing_cycle_id":       cycle.ID.String(),
-		},
-		CreatedAt: time.Now(),
-	}
-
-	statementService := NewStatementLedgerService(s.db)
-	if err := statementService.CreateEntry(ctx, entry); err != nil {
-		return nil, fmt.Errorf("failed to create interest entry: %w", err)
-	}
-
-	return entry, nil
+	statementService := NewStatementLedgerServiceWithStore(s.store)
+
+	var entries []*models.StatementLedgerEntry
+	for i := range result.Segments {
+		segment := &result.Segments[i]
+		charge := segment.InterestCharge.Round(2)
+		if !charge.IsPositive() {
+			continue
+		}
+
+		entry := &models.StatementLedgerEntry{
+			ID:          uuid.New(),
+			TenantID:    tenantID,
+			StatementID: &cycle.ID,
+			EntryType:   models.EntryTypeFeeInterest,
+			EntryDate:   time.Now(),
+			PostingDate: cycle.CycleEndDate,
+			Amount:      charge,
+			Description: fmt.Sprintf("Interest charge - %s (APR: %.2f%%, ADB: $%.2f)",
+				strings.ToLower(segment.Segment.Label()),
+				segment.APR.InexactFloat64(),
+				segment.AverageDailyBalance.InexactFloat64()),
+			Status: models.EntryStatusPending,
+			Metadata: map[string]interface{}{
+				models.SegmentMetadataKey: string(segment.Segment),
+				"calculation_method":      string(result.CalculationMethod),
+				"apr_used":                segment.APR.String(),
+				"average_daily_balance":   segment.AverageDailyBalance.String(),
+				"days_in_cycle":           result.DaysInCycle,
+				"billing_cycle_id":        cycle.ID.String(),
+			},
+			CreatedAt: time.Now(),
+		}
+
+		if err := statementService.CreateEntry(ctx, entry); err != nil {
+			return nil, fmt.Errorf("failed to create interest entry: %w", err)
+		}
+		segment.InterestEntryID = &entry.ID
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
 }
 
 // ProjectedInterest calculates projected interest if balance remains unchanged
@@ -366,32 +590,51 @@ type InterestAccrualSchedule struct {
 
 // GetAccrualSchedules returns interest accrual schedules for all active cards
 func (s *InterestService) GetAccrualSchedules(ctx context.Context) ([]InterestAccrualSchedule, error) {
-	query := `
-		SELECT
-			cc.id,
-			COALESCE(bc.cycle_end_date, cc.next_statement_date) as next_accrual,
-			cc.purchase_apr as current_apr
-		FROM credit_cards cc
-		LEFT JOIN billing_cycles bc ON bc.credit_card_id = cc.id AND bc.status = 'open'
-		WHERE cc.status = 'active'
-		ORDER BY next_accrual
-	`
-
-	rows, err := s.db.QueryContext(ctx, query)
+	cards, err := s.store.CreditCards().List(ctx, repository.CreditCardFilter{
+		Statuses: []models.CreditCardStatus{models.CreditCardStatusActive},
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
+	now := time.Now()
 	var schedules []InterestAccrualSchedule
-	for rows.Next() {
-		var schedule InterestAccrualSchedule
-		schedule.AccrualMethod = AverageDailyBalanceMethod
-		if err := rows.Scan(&schedule.CreditCardID, &schedule.NextAccrual, &schedule.CurrentAPR); err != nil {
+	for _, card := range cards {
+		// The rate in effect today comes from the card's stored APR history
+		card, err := loadAPRSchedule(ctx, s.store, card)
+		if err != nil {
 			return nil, err
 		}
+
+		schedule := InterestAccrualSchedule{
+			CreditCardID:  card.ID,
+			AccrualMethod: AverageDailyBalanceMethod,
+			CurrentAPR:    card.GetEffectiveAPR(now),
+		}
+
+		// Accrue at the end of the open cycle, or the next statement date without one
+		openCycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{
+			CreditCardID: &card.ID,
+			Statuses:     []models.BillingCycleStatus{models.BillingCycleStatusOpen},
+		})
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(openCycles) > 0:
+			schedule.NextAccrual = openCycles[0].CycleEndDate
+		case card.NextStatementDate != nil:
+			schedule.NextAccrual = *card.NextStatementDate
+		default:
+			continue
+		}
+
 		schedules = append(schedules, schedule)
 	}
 
-	return schedules, rows.Err()
+	sort.SliceStable(schedules, func(i, j int) bool {
+		return schedules[i].NextAccrual.Before(schedules[j].NextAccrual)
+	})
+
+	return schedules, nil
 }
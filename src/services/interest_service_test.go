@@ -2,6 +2,7 @@ package services
 
 import (
 	"testing"
+	"time"
 
 	"github.com/livefire2015/ez-ledger/src/models"
 	"github.com/shopspring/decimal"
@@ -226,3 +227,132 @@ func TestCalculateProjectedInterest(t *testing.T) {
 		})
 	}
 }
+
+func TestInterestService_ProjectPayoff(t *testing.T) {
+	service := &InterestService{}
+	asOf := time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		configure     func(card *models.CreditCard)
+		balance       decimal.Decimal
+		wantNil       bool
+		wantNever     bool
+		wantMonths    int
+		wantCost      decimal.Decimal
+		wantThirtySix *models.PayoffScenario
+	}{
+		{
+			name: "Interest free",
+			configure: func(card *models.CreditCard) {
+				card.PurchaseAPR = decimal.Zero
+			},
+			balance: decimal.NewFromInt(1000),
+			// $25 a month, the fixed minimum, for 40 months
+			wantMonths: 40,
+			wantCost:   decimal.NewFromInt(1000),
+			// 1000 / 36 = 27.78, with a smaller last payment
+			wantThirtySix: &models.PayoffScenario{
+				MonthlyPayment: decimal.NewFromFloat(27.78),
+				Months:         36,
+				TotalCost:      decimal.NewFromInt(1000),
+			},
+		},
+		{
+			name:       "Repaid within 36 months",
+			balance:    decimal.NewFromInt(20),
+			wantMonths: 1,
+			wantCost:   decimal.NewFromInt(20),
+		},
+		{
+			name: "Minimum payment below the interest",
+			configure: func(card *models.CreditCard) {
+				card.MinimumPaymentPercent = decimal.NewFromInt(1)
+				card.MinimumPaymentAmount = decimal.Zero
+				card.PurchaseAPR = decimal.NewFromFloat(29.99)
+			},
+			balance:   decimal.NewFromInt(2000),
+			wantNever: true,
+		},
+		{
+			name:    "No balance",
+			balance: decimal.Zero,
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := models.CreditCardDefaults()
+			if tt.configure != nil {
+				tt.configure(&card)
+			}
+
+			projection := service.ProjectPayoff(&card, tt.balance, asOf)
+			if tt.wantNil {
+				if projection != nil {
+					t.Fatalf("projection = %+v, want nil", projection)
+				}
+				return
+			}
+			if projection == nil {
+				t.Fatal("projection is nil")
+			}
+
+			minimum := projection.MinimumOnly
+			if minimum.NeverPaysOff != tt.wantNever {
+				t.Errorf("NeverPaysOff = %v, want %v", minimum.NeverPaysOff, tt.wantNever)
+			}
+			if minimum.Months != tt.wantMonths {
+				t.Errorf("Months = %d, want %d", minimum.Months, tt.wantMonths)
+			}
+			if !minimum.TotalCost.Equal(tt.wantCost) {
+				t.Errorf("TotalCost = %s, want %s", minimum.TotalCost, tt.wantCost)
+			}
+
+			plan := projection.ThirtySixMonth
+			if tt.wantThirtySix == nil {
+				if plan != nil {
+					t.Errorf("ThirtySixMonth = %+v, want nil", plan)
+				}
+				return
+			}
+			if plan == nil {
+				t.Fatal("ThirtySixMonth is nil")
+			}
+			if !plan.MonthlyPayment.Equal(tt.wantThirtySix.MonthlyPayment) {
+				t.Errorf("36-month payment = %s, want %s", plan.MonthlyPayment, tt.wantThirtySix.MonthlyPayment)
+			}
+			if plan.Months != tt.wantThirtySix.Months {
+				t.Errorf("36-month months = %d, want %d", plan.Months, tt.wantThirtySix.Months)
+			}
+			if !plan.TotalCost.Equal(tt.wantThirtySix.TotalCost) {
+				t.Errorf("36-month cost = %s, want %s", plan.TotalCost, tt.wantThirtySix.TotalCost)
+			}
+		})
+	}
+}
+
+func TestInterestService_ProjectPayoffSavings(t *testing.T) {
+	card := models.CreditCardDefaults()
+	asOf := time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)
+
+	projection := (&InterestService{}).ProjectPayoff(&card, decimal.NewFromInt(3000), asOf)
+	if projection == nil || projection.ThirtySixMonth == nil {
+		t.Fatalf("projection = %+v, want a 36-month plan", projection)
+	}
+
+	// Every plan repays the balance, and paying it off in 36 months costs less
+	plan := projection.ThirtySixMonth
+	if plan.Months != models.PayoffThirtySixMonths {
+		t.Errorf("36-month months = %d", plan.Months)
+	}
+	for _, scenario := range []models.PayoffScenario{projection.MinimumOnly, *plan} {
+		if !scenario.TotalCost.Equal(projection.Balance.Add(scenario.TotalInterest)) {
+			t.Errorf("cost %s != balance %s + interest %s", scenario.TotalCost, projection.Balance, scenario.TotalInterest)
+		}
+	}
+	if !projection.Savings().IsPositive() {
+		t.Errorf("savings = %s, want positive", projection.Savings())
+	}
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+)
+
+// LedgerChainService verifies the hash chains over the ledgers
+type LedgerChainService struct {
+	store repository.Store
+}
+
+// NewLedgerChainService creates a new ledger chain service
+func NewLedgerChainService(db Querier) *LedgerChainService {
+	return NewLedgerChainServiceWithStore(postgres.NewStore(db))
+}
+
+// NewLedgerChainServiceWithStore creates a ledger chain service backed by store
+func NewLedgerChainServiceWithStore(store repository.Store) *LedgerChainService {
+	return &LedgerChainService{store: store}
+}
+
+// ChainBreak is the first link of a chain that does not verify
+type ChainBreak struct {
+	Sequence int64      `json:"sequence"`
+	EntryID  *uuid.UUID `json:"entry_id,omitempty"` // Nil when the entry is missing
+	Reason   string     `json:"reason"`
+}
+
+// ChainVerification is the result of walking one of a tenant's chains
+type ChainVerification struct {
+	Ledger   models.LedgerName `json:"ledger"`
+	TenantID uuid.UUID         `json:"tenant_id"`
+	Entries  int               `json:"entries"`
+	Unsealed int               `json:"unsealed"` // Entries posted before the chain existed
+	Head     models.ChainLink  `json:"head"`
+	Break    *ChainBreak       `json:"break,omitempty"`
+}
+
+// IsIntact returns true if every link of the chain verified
+func (v *ChainVerification) IsIntact() bool {
+	return v.Break == nil
+}
+
+// String describes the result for logs and reports
+func (v *ChainVerification) String() string {
+	if v.Break != nil {
+		return fmt.Sprintf("%s chain of tenant %s broken at sequence %d: %s",
+			v.Ledger, v.TenantID, v.Break.Sequence, v.Break.Reason)
+	}
+	return fmt.Sprintf("%s chain of tenant %s intact: %d entries, head %d",
+		v.Ledger, v.TenantID, v.Entries, v.Head.Sequence)
+}
+
+// chainedEntry is a ledger entry reduced to what the verifier checks
+type chainedEntry struct {
+	id       uuid.UUID
+	link     models.ChainLink
+	computed string // Hash of the entry's current contents
+}
+
+// Verify walks the tenant's statement, cashback and points chains and
+// reports the first broken link of each. A deleted entry shows up as a gap in
+// the sequence, or as a head that is ahead of the newest entry; a rewritten
+// entry no longer matches its hash or the next entry's previous hash.
+func (s *LedgerChainService) Verify(ctx context.Context, tenantID uuid.UUID) ([]*ChainVerification, error) {
+	statementEntries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &tenantID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement entries: %w", err)
+	}
+	statementChain := make([]chainedEntry, len(statementEntries))
+	for i, entry := range statementEntries {
+		statementChain[i] = chainedEntry{id: entry.ID, link: entry.ChainLink, computed: entry.ComputeHash()}
+	}
+
+	cashbackEntries, err := s.store.CashbackEntries().List(ctx, repository.CashbackEntryFilter{TenantID: &tenantID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cashback entries: %w", err)
+	}
+	cashbackChain := make([]chainedEntry, len(cashbackEntries))
+	for i, entry := range cashbackEntries {
+		cashbackChain[i] = chainedEntry{id: entry.ID, link: entry.ChainLink, computed: entry.ComputeHash()}
+	}
+
+	pointsEntries, err := s.store.PointsEntries().List(ctx, repository.PointsEntryFilter{TenantID: &tenantID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list points entries: %w", err)
+	}
+	pointsChain := make([]chainedEntry, len(pointsEntries))
+	for i, entry := range pointsEntries {
+		pointsChain[i] = chainedEntry{id: entry.ID, link: entry.ChainLink, computed: entry.ComputeHash()}
+	}
+
+	heads := map[models.LedgerName]func(context.Context, uuid.UUID) (models.ChainLink, error){
+		models.LedgerStatement: s.store.StatementEntries().ChainHead,
+		models.LedgerCashback:  s.store.CashbackEntries().ChainHead,
+		models.LedgerPoints:    s.store.PointsEntries().ChainHead,
+	}
+	chains := []struct {
+		ledger  models.LedgerName
+		entries []chainedEntry
+	}{
+		{models.LedgerStatement, statementChain},
+		{models.LedgerCashback, cashbackChain},
+		{models.LedgerPoints, pointsChain},
+	}
+
+	results := make([]*ChainVerification, 0, len(chains))
+	for _, chain := range chains {
+		head, err := heads[chain.ledger](ctx, tenantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s chain head: %w", chain.ledger, err)
+		}
+
+		result := verifyChain(chain.entries, head)
+		result.Ledger = chain.ledger
+		result.TenantID = tenantID
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// verifyChain checks entries against each other and against the recorded head
+func verifyChain(entries []chainedEntry, head models.ChainLink) *ChainVerification {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].link.Sequence < entries[j].link.Sequence
+	})
+
+	result := &ChainVerification{Entries: len(entries), Head: head}
+	fail := func(sequence int64, id *uuid.UUID, reason string, args ...interface{}) *ChainVerification {
+		result.Break = &ChainBreak{Sequence: sequence, EntryID: id, Reason: fmt.Sprintf(reason, args...)}
+		return result
+	}
+
+	var last models.ChainLink
+	for _, entry := range entries {
+		id := entry.id
+		expected := last.Sequence + 1
+		switch {
+		case entry.link.Sequence < expected:
+			return fail(entry.link.Sequence, &id, "sequence %d is used more than once", entry.link.Sequence)
+		case entry.link.Sequence > expected:
+			return fail(expected, nil, "entries %d to %d are missing", expected, entry.link.Sequence-1)
+		}
+
+		if !entry.link.IsSealed() {
+			if last.IsSealed() {
+				return fail(entry.link.Sequence, &id, "entry has no hash but follows a hashed entry")
+			}
+			result.Unsealed++
+			last = entry.link
+			continue
+		}
+		if entry.link.PrevHash != last.Hash {
+			return fail(entry.link.Sequence, &id, "previous hash does not match entry %d", last.Sequence)
+		}
+		if entry.link.Hash != entry.computed {
+			return fail(entry.link.Sequence, &id, "entry does not match its hash")
+		}
+		last = entry.link
+	}
+
+	switch {
+	case head.Sequence > last.Sequence:
+		return fail(last.Sequence+1, nil, "entries %d to %d are missing", last.Sequence+1, head.Sequence)
+	case head.Sequence < last.Sequence:
+		return fail(head.Sequence+1, nil, "entries after %d were not appended through the chain head", head.Sequence)
+	case head.Hash != last.Hash:
+		return fail(last.Sequence, nil, "chain head hash does not match entry %d", last.Sequence)
+	}
+
+	return result
+}
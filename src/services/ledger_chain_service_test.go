@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestLedgerChainService_Verify(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	day := time.Date(2026, time.January, 10, 12, 30, 0, 123456789, time.UTC)
+
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+	cards := NewCreditCardServiceWithStore(store)
+	for i := 0; i < 3; i++ {
+		if _, err := cards.RecordTransaction(ctx, CCTransactionRequest{
+			CreditCard:      card,
+			Amount:          decimal.NewFromInt(100),
+			Description:     "Dinner",
+			MerchantName:    "Test Merchant",
+			TransactionDate: day,
+			PostingDate:     day,
+		}); err != nil {
+			t.Fatalf("failed to record purchase: %v", err)
+		}
+	}
+
+	results, err := NewLedgerChainServiceWithStore(store).Verify(ctx, card.TenantID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := map[models.LedgerName]int{
+		models.LedgerStatement: 3,
+		models.LedgerCashback:  3,
+		models.LedgerPoints:    0,
+	}
+	for _, result := range results {
+		if !result.IsIntact() {
+			t.Errorf("%s", result)
+		}
+		if result.Entries != want[result.Ledger] || result.Head.Sequence != int64(want[result.Ledger]) {
+			t.Errorf("%s: entries = %d, head = %d, want %d",
+				result.Ledger, result.Entries, result.Head.Sequence, want[result.Ledger])
+		}
+	}
+}
+
+func TestVerifyChain(t *testing.T) {
+	tenantID := uuid.New()
+	day := time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC)
+
+	// seal builds a chain of statement entries with the first unsealed
+	// entries standing in for rows written before the chain existed
+	seal := func(n, unsealed int) []*models.StatementLedgerEntry {
+		entries := make([]*models.StatementLedgerEntry, n)
+		prevHash := ""
+		for i := range entries {
+			entry := &models.StatementLedgerEntry{
+				ID:          uuid.New(),
+				TenantID:    tenantID,
+				EntryType:   models.EntryTypeTransaction,
+				EntryDate:   day,
+				PostingDate: day,
+				Amount:      decimal.NewFromInt(int64(10 * (i + 1))),
+				Description: "Purchase",
+				Metadata:    map[string]interface{}{"merchant_name": "Test Merchant"},
+			}
+			if i < unsealed {
+				entry.Sequence = int64(i + 1)
+			} else {
+				entry.Seal(int64(i+1), prevHash)
+			}
+			prevHash = entry.Hash
+			entries[i] = entry
+		}
+		return entries
+	}
+	chainOf := func(entries []*models.StatementLedgerEntry) ([]chainedEntry, models.ChainLink) {
+		chain := make([]chainedEntry, 0, len(entries))
+		var head models.ChainLink
+		for _, entry := range entries {
+			chain = append(chain, chainedEntry{id: entry.ID, link: entry.ChainLink, computed: entry.ComputeHash()})
+			head = entry.ChainLink
+		}
+		return chain, head
+	}
+
+	tests := []struct {
+		name      string
+		entries   []*models.StatementLedgerEntry
+		tamper    func(entries []*models.StatementLedgerEntry) []*models.StatementLedgerEntry
+		wantBreak int64 // Sequence of the first broken link; 0 when intact
+	}{
+		{
+			name:    "Intact chain",
+			entries: seal(4, 0),
+		},
+		{
+			name:    "Entries from before the chain",
+			entries: seal(4, 2),
+		},
+		{
+			name:    "Deleted entry",
+			entries: seal(4, 0),
+			tamper: func(entries []*models.StatementLedgerEntry) []*models.StatementLedgerEntry {
+				return append(entries[:1:1], entries[2:]...)
+			},
+			wantBreak: 2,
+		},
+		{
+			name:    "Rewritten amount",
+			entries: seal(4, 0),
+			tamper: func(entries []*models.StatementLedgerEntry) []*models.StatementLedgerEntry {
+				entries[2].Amount = decimal.NewFromInt(1)
+				return entries
+			},
+			wantBreak: 3,
+		},
+		{
+			name:    "Rewritten metadata",
+			entries: seal(4, 0),
+			tamper: func(entries []*models.StatementLedgerEntry) []*models.StatementLedgerEntry {
+				entries[0].Metadata["merchant_name"] = "Other Merchant"
+				return entries
+			},
+			wantBreak: 1,
+		},
+		{
+			name:    "Rewritten and rehashed entry",
+			entries: seal(4, 0),
+			tamper: func(entries []*models.StatementLedgerEntry) []*models.StatementLedgerEntry {
+				entries[1].Amount = decimal.NewFromInt(1)
+				entries[1].Hash = entries[1].ComputeHash()
+				return entries
+			},
+			wantBreak: 3,
+		},
+		{
+			name:    "Hash removed",
+			entries: seal(4, 0),
+			tamper: func(entries []*models.StatementLedgerEntry) []*models.StatementLedgerEntry {
+				entries[3].Hash = ""
+				return entries
+			},
+			wantBreak: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, head := chainOf(tt.entries)
+			entries := tt.entries
+			if tt.tamper != nil {
+				entries = tt.tamper(entries)
+			}
+			chain, _ := chainOf(entries)
+
+			result := verifyChain(chain, head)
+			switch {
+			case tt.wantBreak == 0 && result.Break != nil:
+				t.Errorf("unexpected break at %d: %s", result.Break.Sequence, result.Break.Reason)
+			case tt.wantBreak != 0 && result.Break == nil:
+				t.Errorf("expected break at %d, chain verified", tt.wantBreak)
+			case tt.wantBreak != 0 && result.Break.Sequence != tt.wantBreak:
+				t.Errorf("break at %d (%s), want %d", result.Break.Sequence, result.Break.Reason, tt.wantBreak)
+			}
+		})
+	}
+
+	t.Run("Deleted newest entry", func(t *testing.T) {
+		entries := seal(3, 0)
+		chain, head := chainOf(entries)
+
+		result := verifyChain(chain[:2], head)
+		if result.Break == nil || result.Break.Sequence != 3 {
+			t.Errorf("break = %+v, want missing entry 3", result.Break)
+		}
+	})
+}
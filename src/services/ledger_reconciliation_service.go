@@ -2,19 +2,20 @@ package services
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 	"github.com/shopspring/decimal"
 )
 
 // LedgerReconciliationService coordinates between Statement and Points ledgers
 // This service ensures both ledgers stay in sync and handles cross-ledger transactions
 type LedgerReconciliationService struct {
-	db                     *sql.DB
+	store                  repository.Store
 	statementLedgerService *StatementLedgerService
 	pointsLedgerService    *PointsLedgerService
 	defaultPointsRule      models.PointsEarningRule
@@ -22,17 +23,30 @@ type LedgerReconciliationService struct {
 
 // NewLedgerReconciliationService creates a new reconciliation service
 func NewLedgerReconciliationService(
-	db *sql.DB,
+	db Querier,
+	defaultPointsRule models.PointsEarningRule,
+) *LedgerReconciliationService {
+	return NewLedgerReconciliationServiceWithStore(postgres.NewStore(db), defaultPointsRule)
+}
+
+// NewLedgerReconciliationServiceWithStore creates a reconciliation service backed by store
+func NewLedgerReconciliationServiceWithStore(
+	store repository.Store,
 	defaultPointsRule models.PointsEarningRule,
 ) *LedgerReconciliationService {
 	return &LedgerReconciliationService{
-		db:                     db,
-		statementLedgerService: NewStatementLedgerService(db),
-		pointsLedgerService:    NewPointsLedgerService(db),
+		store:                  store,
+		statementLedgerService: NewStatementLedgerServiceWithStore(store),
+		pointsLedgerService:    NewPointsLedgerServiceWithStore(store),
 		defaultPointsRule:      defaultPointsRule,
 	}
 }
 
+// withStore returns a copy of the service bound to store
+func (s *LedgerReconciliationService) withStore(store repository.Store) *LedgerReconciliationService {
+	return NewLedgerReconciliationServiceWithStore(store, s.defaultPointsRule)
+}
+
 // TransactionRequest represents a request to record a transaction
 type TransactionRequest struct {
 	TenantID      uuid.UUID
@@ -51,60 +65,60 @@ func (s *LedgerReconciliationService) RecordTransaction(
 	ctx context.Context,
 	req TransactionRequest,
 ) (*models.StatementLedgerEntry, *models.PointsLedgerEntry, error) {
-	// Start a database transaction for atomicity
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer tx.Rollback()
-
-	// 1. Create statement ledger entry (the charge)
-	statementEntry := &models.StatementLedgerEntry{
-		TenantID:    req.TenantID,
-		EntryType:   models.EntryTypeTransaction,
-		EntryDate:   req.TransactionDate,
-		PostingDate: req.PostingDate,
-		Amount:      req.Amount,
-		Description: req.Description,
-		ReferenceID: &req.ReferenceID,
-		Status:      models.EntryStatusPending,
-	}
-
-	if err := s.statementLedgerService.CreateEntry(ctx, statementEntry); err != nil {
-		return nil, nil, fmt.Errorf("failed to create statement entry: %w", err)
-	}
-
+	var statementEntry *models.StatementLedgerEntry
 	var pointsEntry *models.PointsLedgerEntry
 
-	// 2. Create points ledger entry (if earning points)
-	if req.EarnPoints {
-		pointsRule := s.defaultPointsRule
-		if req.PointsRule != nil {
-			pointsRule = *req.PointsRule
+	// Both ledgers are written in a single database transaction for atomicity
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		// 1. Create statement ledger entry (the charge)
+		statementEntry = &models.StatementLedgerEntry{
+			TenantID:    req.TenantID,
+			EntryType:   models.EntryTypeTransaction,
+			EntryDate:   req.TransactionDate,
+			PostingDate: req.PostingDate,
+			Amount:      req.Amount,
+			Description: req.Description,
+			ReferenceID: &req.ReferenceID,
+			Status:      models.EntryStatusPending,
+		}
+
+		if err := txs.statementLedgerService.CreateEntry(ctx, statementEntry); err != nil {
+			return fmt.Errorf("failed to create statement entry: %w", err)
 		}
 
-		pointsEarned := pointsRule.CalculatePointsEarned(req.Amount)
-
-		if pointsEarned > 0 {
-			pointsEntry = &models.PointsLedgerEntry{
-				TenantID:          req.TenantID,
-				StatementEntryID:  &statementEntry.ID,
-				EntryType:         models.PointsEarnedTransaction,
-				EntryDate:         req.TransactionDate,
-				Points:            pointsEarned,
-				Description:       fmt.Sprintf("Points earned from transaction: %s", req.Description),
-				TransactionAmount: &req.Amount,
-				PointsRate:        &pointsRule.PointsPerDollar,
+		// 2. Create points ledger entry (if earning points)
+		if req.EarnPoints {
+			pointsRule := s.defaultPointsRule
+			if req.PointsRule != nil {
+				pointsRule = *req.PointsRule
 			}
 
-			if err := s.pointsLedgerService.CreateEntry(ctx, pointsEntry); err != nil {
-				return nil, nil, fmt.Errorf("failed to create points entry: %w", err)
+			pointsEarned := pointsRule.CalculatePointsEarned(req.Amount)
+
+			if pointsEarned > 0 {
+				pointsEntry = &models.PointsLedgerEntry{
+					TenantID:          req.TenantID,
+					StatementEntryID:  &statementEntry.ID,
+					EntryType:         models.PointsEarnedTransaction,
+					EntryDate:         req.TransactionDate,
+					Points:            pointsEarned,
+					Description:       fmt.Sprintf("Points earned from transaction: %s", req.Description),
+					TransactionAmount: &req.Amount,
+					PointsRate:        &pointsRule.PointsPerDollar,
+				}
+
+				if err := txs.pointsLedgerService.CreateEntry(ctx, pointsEntry); err != nil {
+					return fmt.Errorf("failed to create points entry: %w", err)
+				}
 			}
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return statementEntry, pointsEntry, nil
@@ -163,69 +177,80 @@ func (s *LedgerReconciliationService) RecordRefund(
 	ctx context.Context,
 	req RefundRequest,
 ) (*models.StatementLedgerEntry, *models.PointsLedgerEntry, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer tx.Rollback()
-
-	// 1. Create statement ledger entry (credit)
-	statementEntry := &models.StatementLedgerEntry{
-		TenantID:    req.TenantID,
-		EntryType:   models.EntryTypeRefund,
-		EntryDate:   req.RefundDate,
-		PostingDate: req.PostingDate,
-		Amount:      req.Amount,
-		Description: req.Description,
-		ReferenceID: &req.ReferenceID,
-		Status:      models.EntryStatusPending,
-	}
+	var statementEntry *models.StatementLedgerEntry
+	var pointsEntry *models.PointsLedgerEntry
 
-	if err := s.statementLedgerService.CreateEntry(ctx, statementEntry); err != nil {
-		return nil, nil, fmt.Errorf("failed to create refund entry: %w", err)
-	}
+	// Both ledgers are written in a single database transaction for atomicity
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		// 1. Create statement ledger entry (credit)
+		statementEntry = &models.StatementLedgerEntry{
+			TenantID:    req.TenantID,
+			EntryType:   models.EntryTypeRefund,
+			EntryDate:   req.RefundDate,
+			PostingDate: req.PostingDate,
+			Amount:      req.Amount,
+			Description: req.Description,
+			ReferenceID: &req.ReferenceID,
+			Status:      models.EntryStatusPending,
+			Metadata: map[string]interface{}{
+				"original_transaction_id": req.OriginalTransactionID.String(),
+			},
+		}
 
-	var pointsEntry *models.PointsLedgerEntry
+		if err := txs.statementLedgerService.CreateEntry(ctx, statementEntry); err != nil {
+			return fmt.Errorf("failed to create refund entry: %w", err)
+		}
 
-	// 2. Adjust points if applicable
-	if req.AdjustPoints {
-		// Find original points entry
-		var originalPoints int
-		var originalPointsRate decimal.Decimal
-
-		queryPoints := `
-			SELECT points, COALESCE(points_rate, 0)
-			FROM points_ledger_entries
-			WHERE statement_entry_id = $1 AND entry_type = 'earned_transaction'
-		`
-
-		err := s.db.QueryRowContext(ctx, queryPoints, req.OriginalTransactionID).Scan(&originalPoints, &originalPointsRate)
-		if err == nil && originalPoints > 0 {
-			// Calculate points to deduct based on refund amount
-			pointsToDeduct := int(req.Amount.Mul(originalPointsRate).IntPart())
-			if pointsToDeduct > originalPoints {
-				pointsToDeduct = originalPoints
+		// 2. Adjust points if applicable
+		if req.AdjustPoints {
+			// Find original points entry
+			originalEntries, err := txs.store.PointsEntries().List(ctx, repository.PointsEntryFilter{
+				StatementEntryID: &req.OriginalTransactionID,
+				EntryTypes:       []models.PointsEntryType{models.PointsEarnedTransaction},
+			})
+			if err != nil {
+				return fmt.Errorf("failed to find original points entry: %w", err)
 			}
 
-			pointsEntry = &models.PointsLedgerEntry{
-				TenantID:          req.TenantID,
-				StatementEntryID:  &statementEntry.ID,
-				EntryType:         models.PointsEarnedRefund,
-				EntryDate:         req.RefundDate,
-				Points:            -pointsToDeduct, // Negative to deduct points
-				Description:       fmt.Sprintf("Points adjustment for refund: %s", req.Description),
-				TransactionAmount: &req.Amount,
-				PointsRate:        &originalPointsRate,
+			var originalPoints int
+			originalPointsRate := decimal.Zero
+			if len(originalEntries) > 0 {
+				originalPoints = originalEntries[0].Points
+				if originalEntries[0].PointsRate != nil {
+					originalPointsRate = *originalEntries[0].PointsRate
+				}
 			}
 
-			if err := s.pointsLedgerService.CreateEntry(ctx, pointsEntry); err != nil {
-				return nil, nil, fmt.Errorf("failed to create points adjustment entry: %w", err)
+			if originalPoints > 0 {
+				// Calculate points to deduct based on refund amount
+				pointsToDeduct := int(req.Amount.Mul(originalPointsRate).IntPart())
+				if pointsToDeduct > originalPoints {
+					pointsToDeduct = originalPoints
+				}
+
+				pointsEntry = &models.PointsLedgerEntry{
+					TenantID:          req.TenantID,
+					StatementEntryID:  &statementEntry.ID,
+					EntryType:         models.PointsEarnedRefund,
+					EntryDate:         req.RefundDate,
+					Points:            -pointsToDeduct, // Negative to deduct points
+					Description:       fmt.Sprintf("Points adjustment for refund: %s", req.Description),
+					TransactionAmount: &req.Amount,
+					PointsRate:        &originalPointsRate,
+				}
+
+				if err := txs.pointsLedgerService.CreateEntry(ctx, pointsEntry); err != nil {
+					return fmt.Errorf("failed to create points adjustment entry: %w", err)
+				}
 			}
 		}
-	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, nil, fmt.Errorf("failed to commit refund transaction: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return statementEntry, pointsEntry, nil
@@ -249,64 +274,94 @@ func (s *LedgerReconciliationService) RecordRewardRedemption(
 	ctx context.Context,
 	req RewardRedemptionRequest,
 ) (*models.StatementLedgerEntry, *models.PointsLedgerEntry, error) {
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return nil, nil, err
-	}
-	defer tx.Rollback()
+	var statementEntry *models.StatementLedgerEntry
+	var pointsEntry *models.PointsLedgerEntry
 
-	// 1. Validate tenant has enough points
-	if err := s.pointsLedgerService.ValidateRedemption(ctx, req.TenantID, req.PointsToRedeem); err != nil {
-		return nil, nil, fmt.Errorf("redemption validation failed: %w", err)
-	}
+	// Both ledgers are written in a single database transaction for atomicity
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
 
-	// 2. Create points ledger entry (redemption/deduction)
-	pointsEntry := &models.PointsLedgerEntry{
-		TenantID:            req.TenantID,
-		EntryType:           models.PointsRedeemedSpent,
-		EntryDate:           req.RedemptionDate,
-		Points:              -req.PointsToRedeem, // Negative for redemption
-		Description:         req.Description,
-		ExternalPlatform:    &req.ExternalPlatform,
-		ExternalReferenceID: &req.ExternalReferenceID,
-	}
+		// 1. Validate tenant has enough points
+		if err := txs.pointsLedgerService.ValidateRedemption(ctx, req.TenantID, req.PointsToRedeem); err != nil {
+			return fmt.Errorf("redemption validation failed: %w", err)
+		}
 
-	if err := s.pointsLedgerService.CreateEntry(ctx, pointsEntry); err != nil {
-		return nil, nil, fmt.Errorf("failed to create points redemption entry: %w", err)
-	}
+		// 2. Build points ledger entry (redemption/deduction)
+		pointsEntry = &models.PointsLedgerEntry{
+			ID:                  uuid.New(),
+			TenantID:            req.TenantID,
+			EntryType:           models.PointsRedeemedSpent,
+			EntryDate:           req.RedemptionDate,
+			Points:              -req.PointsToRedeem, // Negative for redemption
+			Description:         req.Description,
+			ExternalPlatform:    &req.ExternalPlatform,
+			ExternalReferenceID: &req.ExternalReferenceID,
+		}
 
-	// 3. Create statement ledger entry (reward credit)
-	statementEntry := &models.StatementLedgerEntry{
-		TenantID:    req.TenantID,
-		EntryType:   models.EntryTypeReward,
-		EntryDate:   req.RedemptionDate,
-		PostingDate: req.PostingDate,
-		Amount:      req.CreditAmount,
-		Description: fmt.Sprintf("%s (redeemed %d points)", req.Description, req.PointsToRedeem),
-		ReferenceID: &req.ExternalReferenceID,
-		Status:      models.EntryStatusPending,
-		Metadata: map[string]interface{}{
-			"points_redeemed":     req.PointsToRedeem,
-			"points_entry_id":     pointsEntry.ID.String(),
-			"external_platform":   req.ExternalPlatform,
-			"external_reference":  req.ExternalReferenceID,
-		},
-	}
+		// 3. Create statement ledger entry (reward credit)
+		statementEntry = &models.StatementLedgerEntry{
+			TenantID:    req.TenantID,
+			EntryType:   models.EntryTypeReward,
+			EntryDate:   req.RedemptionDate,
+			PostingDate: req.PostingDate,
+			Amount:      req.CreditAmount,
+			Description: fmt.Sprintf("%s (redeemed %d points)", req.Description, req.PointsToRedeem),
+			ReferenceID: &req.ExternalReferenceID,
+			Status:      models.EntryStatusPending,
+			Metadata: map[string]interface{}{
+				"points_redeemed":     req.PointsToRedeem,
+				"points_entry_id":     pointsEntry.ID.String(),
+				"external_platform":   req.ExternalPlatform,
+				"external_reference":  req.ExternalReferenceID,
+			},
+		}
 
-	if err := s.statementLedgerService.CreateEntry(ctx, statementEntry); err != nil {
-		return nil, nil, fmt.Errorf("failed to create reward statement entry: %w", err)
-	}
+		if err := txs.statementLedgerService.CreateEntry(ctx, statementEntry); err != nil {
+			return fmt.Errorf("failed to create reward statement entry: %w", err)
+		}
 
-	// 4. Link the two entries
-	pointsEntry.StatementEntryID = &statementEntry.ID
+		// 4. Link the two entries and write the points entry
+		pointsEntry.StatementEntryID = &statementEntry.ID
+		if err := txs.pointsLedgerService.CreateEntry(ctx, pointsEntry); err != nil {
+			return fmt.Errorf("failed to create points redemption entry: %w", err)
+		}
 
-	if err := tx.Commit(); err != nil {
-		return nil, nil, fmt.Errorf("failed to commit reward redemption: %w", err)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
 
 	return statementEntry, pointsEntry, nil
 }
 
+// ReconciliationExceptionType classifies a cross-ledger inconsistency
+type ReconciliationExceptionType string
+
+const (
+	// A cashback or points entry whose statement entry is missing or reversed
+	ExceptionOrphanedCashback ReconciliationExceptionType = "orphaned_cashback"
+	ExceptionOrphanedPoints   ReconciliationExceptionType = "orphaned_points"
+	// A refund of a rewarded purchase with no earned_refund adjustment
+	ExceptionRefundNotAdjusted ReconciliationExceptionType = "refund_not_adjusted"
+	// A cashback statement credit with no matching cashback redemption
+	ExceptionRedemptionUnmatched ReconciliationExceptionType = "redemption_unmatched"
+	// A billing cycle total that disagrees with the ledger
+	ExceptionBillingCycleMismatch ReconciliationExceptionType = "billing_cycle_mismatch"
+)
+
+// ReconciliationException is one inconsistency found between the ledgers
+type ReconciliationException struct {
+	Type             ReconciliationExceptionType `json:"type"`
+	StatementEntryID *uuid.UUID                  `json:"statement_entry_id,omitempty"`
+	CashbackEntryID  *uuid.UUID                  `json:"cashback_entry_id,omitempty"`
+	PointsEntryID    *uuid.UUID                  `json:"points_entry_id,omitempty"`
+	BillingCycleID   *uuid.UUID                  `json:"billing_cycle_id,omitempty"`
+	Expected         *decimal.Decimal            `json:"expected,omitempty"`
+	Actual           *decimal.Decimal            `json:"actual,omitempty"`
+	Detail           string                      `json:"detail"`
+}
+
 // GetReconciliationReport generates a report showing both ledgers for a tenant
 type ReconciliationReport struct {
 	TenantID              uuid.UUID
@@ -314,10 +369,18 @@ type ReconciliationReport struct {
 	PointsBalance         int
 	LastStatementActivity time.Time
 	LastPointsActivity    time.Time
+	Exceptions            []ReconciliationException
 	ReportGeneratedAt     time.Time
 }
 
-// GenerateReconciliationReport creates a report of both ledgers
+// IsReconciled returns true if no exceptions were found
+func (r *ReconciliationReport) IsReconciled() bool {
+	return len(r.Exceptions) == 0
+}
+
+// GenerateReconciliationReport creates a report of both ledgers and checks
+// that the statement, cashback and points ledgers and the billing cycles
+// agree with each other
 func (s *LedgerReconciliationService) GenerateReconciliationReport(
 	ctx context.Context,
 	tenantID uuid.UUID,
@@ -343,5 +406,262 @@ func (s *LedgerReconciliationService) GenerateReconciliationReport(
 		ReportGeneratedAt:     time.Now(),
 	}
 
+	report.Exceptions, err = s.findExceptions(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
 	return report, nil
 }
+
+// findExceptions runs every cross-ledger check for a tenant
+func (s *LedgerReconciliationService) findExceptions(
+	ctx context.Context,
+	tenantID uuid.UUID,
+) ([]ReconciliationException, error) {
+	statementEntries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &tenantID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement entries: %w", err)
+	}
+	cashbackEntries, err := s.store.CashbackEntries().List(ctx, repository.CashbackEntryFilter{TenantID: &tenantID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cashback entries: %w", err)
+	}
+	pointsEntries, err := s.store.PointsEntries().List(ctx, repository.PointsEntryFilter{TenantID: &tenantID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list points entries: %w", err)
+	}
+
+	statementByID := make(map[uuid.UUID]*models.StatementLedgerEntry, len(statementEntries))
+	for _, entry := range statementEntries {
+		statementByID[entry.ID] = entry
+	}
+
+	var exceptions []ReconciliationException
+	exceptions = append(exceptions, orphanedRewardExceptions(statementByID, cashbackEntries, pointsEntries)...)
+	exceptions = append(exceptions, refundExceptions(statementEntries, cashbackEntries, pointsEntries)...)
+	exceptions = append(exceptions, redemptionExceptions(statementEntries, cashbackEntries)...)
+
+	cycleExceptions, err := s.billingCycleExceptions(ctx, tenantID, statementEntries)
+	if err != nil {
+		return nil, err
+	}
+	exceptions = append(exceptions, cycleExceptions...)
+
+	return exceptions, nil
+}
+
+// orphanedRewardExceptions flags cashback and points entries whose statement
+// entry is missing or reversed. Earning entries must have one.
+func orphanedRewardExceptions(
+	statementByID map[uuid.UUID]*models.StatementLedgerEntry,
+	cashbackEntries []*models.CashbackLedgerEntry,
+	pointsEntries []*models.PointsLedgerEntry,
+) []ReconciliationException {
+	// orphaned returns why a link is broken, or "" if it is intact
+	orphaned := func(statementEntryID *uuid.UUID, required bool) string {
+		if statementEntryID == nil {
+			if required {
+				return "no statement entry linked"
+			}
+			return ""
+		}
+		entry, ok := statementByID[*statementEntryID]
+		if !ok {
+			return fmt.Sprintf("statement entry %s does not exist", statementEntryID)
+		}
+		if entry.Status == models.EntryStatusReversed {
+			return fmt.Sprintf("statement entry %s is reversed", statementEntryID)
+		}
+		return ""
+	}
+
+	var exceptions []ReconciliationException
+	for _, entry := range cashbackEntries {
+		required := entry.EntryType == models.CashbackEarned || entry.EntryType == models.CashbackEarnedRefund
+		if detail := orphaned(entry.StatementEntryID, required); detail != "" {
+			cashbackID := entry.ID
+			exceptions = append(exceptions, ReconciliationException{
+				Type:             ExceptionOrphanedCashback,
+				StatementEntryID: entry.StatementEntryID,
+				CashbackEntryID:  &cashbackID,
+				Detail:           fmt.Sprintf("cashback %s entry: %s", entry.EntryType, detail),
+			})
+		}
+	}
+	for _, entry := range pointsEntries {
+		required := entry.EntryType == models.PointsEarnedTransaction || entry.EntryType == models.PointsEarnedRefund
+		if detail := orphaned(entry.StatementEntryID, required); detail != "" {
+			pointsID := entry.ID
+			exceptions = append(exceptions, ReconciliationException{
+				Type:             ExceptionOrphanedPoints,
+				StatementEntryID: entry.StatementEntryID,
+				PointsEntryID:    &pointsID,
+				Detail:           fmt.Sprintf("points %s entry: %s", entry.EntryType, detail),
+			})
+		}
+	}
+	return exceptions
+}
+
+// refundExceptions flags refunds of purchases that earned cashback or points
+// with no earned_refund entry linked to the refund
+func refundExceptions(
+	statementEntries []*models.StatementLedgerEntry,
+	cashbackEntries []*models.CashbackLedgerEntry,
+	pointsEntries []*models.PointsLedgerEntry,
+) []ReconciliationException {
+	cashbackEarned := make(map[uuid.UUID]bool)
+	cashbackAdjusted := make(map[uuid.UUID]bool)
+	for _, entry := range cashbackEntries {
+		if entry.StatementEntryID == nil {
+			continue
+		}
+		switch entry.EntryType {
+		case models.CashbackEarned:
+			cashbackEarned[*entry.StatementEntryID] = true
+		case models.CashbackEarnedRefund:
+			cashbackAdjusted[*entry.StatementEntryID] = true
+		}
+	}
+
+	pointsEarned := make(map[uuid.UUID]bool)
+	pointsAdjusted := make(map[uuid.UUID]bool)
+	for _, entry := range pointsEntries {
+		if entry.StatementEntryID == nil {
+			continue
+		}
+		switch entry.EntryType {
+		case models.PointsEarnedTransaction:
+			pointsEarned[*entry.StatementEntryID] = entry.Points > 0
+		case models.PointsEarnedRefund:
+			pointsAdjusted[*entry.StatementEntryID] = true
+		}
+	}
+
+	var exceptions []ReconciliationException
+	for _, refund := range statementEntries {
+		if refund.EntryType != models.EntryTypeRefund || refund.Status == models.EntryStatusReversed {
+			continue
+		}
+		original, ok := refund.Metadata["original_transaction_id"].(string)
+		if !ok {
+			continue
+		}
+		originalID, err := uuid.Parse(original)
+		if err != nil {
+			continue
+		}
+
+		refundID := refund.ID
+		if cashbackEarned[originalID] && !cashbackAdjusted[refundID] {
+			exceptions = append(exceptions, ReconciliationException{
+				Type:             ExceptionRefundNotAdjusted,
+				StatementEntryID: &refundID,
+				Detail:           fmt.Sprintf("refund of %s earned cashback but has no earned_refund entry", originalID),
+			})
+		}
+		if pointsEarned[originalID] && !pointsAdjusted[refundID] {
+			exceptions = append(exceptions, ReconciliationException{
+				Type:             ExceptionRefundNotAdjusted,
+				StatementEntryID: &refundID,
+				Detail:           fmt.Sprintf("refund of %s earned points but has no earned_refund entry", originalID),
+			})
+		}
+	}
+	return exceptions
+}
+
+// redemptionExceptions flags cashback statement credits without a cashback
+// redemption of the same amount linked to them
+func redemptionExceptions(
+	statementEntries []*models.StatementLedgerEntry,
+	cashbackEntries []*models.CashbackLedgerEntry,
+) []ReconciliationException {
+	redeemed := make(map[uuid.UUID]*models.CashbackLedgerEntry)
+	for _, entry := range cashbackEntries {
+		if entry.EntryType == models.CashbackRedeemed && entry.StatementEntryID != nil {
+			redeemed[*entry.StatementEntryID] = entry
+		}
+	}
+
+	var exceptions []ReconciliationException
+	for _, credit := range statementEntries {
+		if credit.EntryType != models.EntryTypeCashbackRedeemed || credit.Status == models.EntryStatusReversed {
+			continue
+		}
+
+		creditID := credit.ID
+		redemption, ok := redeemed[creditID]
+		if !ok {
+			exceptions = append(exceptions, ReconciliationException{
+				Type:             ExceptionRedemptionUnmatched,
+				StatementEntryID: &creditID,
+				Detail:           "statement credit has no cashback redemption entry",
+			})
+			continue
+		}
+
+		expected := credit.Amount.Abs()
+		actual := redemption.Amount.Abs()
+		if !expected.Equal(actual) {
+			cashbackID := redemption.ID
+			exceptions = append(exceptions, ReconciliationException{
+				Type:             ExceptionRedemptionUnmatched,
+				StatementEntryID: &creditID,
+				CashbackEntryID:  &cashbackID,
+				Expected:         &expected,
+				Actual:           &actual,
+				Detail:           "cashback redeemed differs from the statement credit",
+			})
+		}
+	}
+	return exceptions
+}
+
+// billingCycleExceptions flags billing cycle totals that disagree with the
+// statement ledger, using the same replay as ProjectionService
+func (s *LedgerReconciliationService) billingCycleExceptions(
+	ctx context.Context,
+	tenantID uuid.UUID,
+	statementEntries []*models.StatementLedgerEntry,
+) ([]ReconciliationException, error) {
+	cards, err := s.store.CreditCards().List(ctx, repository.CreditCardFilter{TenantID: &tenantID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credit cards: %w", err)
+	}
+	if len(cards) == 0 {
+		return nil, nil
+	}
+	if len(cards) > 1 {
+		return nil, fmt.Errorf("tenant %s has %d cards; its statement entries cannot be attributed to one card",
+			tenantID, len(cards))
+	}
+
+	cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{CreditCardID: &cards[0].ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billing cycles: %w", err)
+	}
+
+	var live []*models.StatementLedgerEntry
+	for _, entry := range statementEntries {
+		if entry.Status != models.EntryStatusReversed {
+			live = append(live, entry)
+		}
+	}
+
+	differences, _ := replayBillingCycles(cards[0], cycles, live)
+
+	exceptions := make([]ReconciliationException, 0, len(differences))
+	for _, diff := range differences {
+		expected, actual := diff.Rebuilt, diff.Stored
+		exceptions = append(exceptions, ReconciliationException{
+			Type:           ExceptionBillingCycleMismatch,
+			BillingCycleID: diff.BillingCycleID,
+			Expected:       &expected,
+			Actual:         &actual,
+			Detail:         fmt.Sprintf("%s does not match the ledger", diff.Field),
+		})
+	}
+	return exceptions, nil
+}
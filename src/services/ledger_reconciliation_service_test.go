@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestLedgerReconciliationService_GenerateReconciliationReport(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	cycleStart := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	day := cycleStart.AddDate(0, 0, 9)
+
+	card := testCard()
+	card.CreatedAt = cycleStart
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	cards := NewCreditCardServiceWithStore(store)
+	purchase, err := cards.RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:       card,
+		Amount:           decimal.NewFromInt(1000),
+		Description:      "Dinner",
+		MerchantName:     "Test Merchant",
+		MerchantCategory: "5812",
+		TransactionDate:  day,
+		PostingDate:      day,
+	})
+	if err != nil {
+		t.Fatalf("failed to record purchase: %v", err)
+	}
+	if _, err := cards.RecordRefund(ctx, CCRefundRequest{
+		CreditCard:            card,
+		OriginalTransactionID: purchase.TransactionEntry.ID,
+		RefundAmount:          decimal.NewFromInt(100),
+		RefundDate:            day,
+		PostingDate:           day,
+		MerchantName:          "Test Merchant",
+	}); err != nil {
+		t.Fatalf("failed to record refund: %v", err)
+	}
+	if _, _, err := NewCashbackServiceWithStore(store).RedeemCashback(ctx, RedeemCashbackRequest{
+		TenantID:       card.TenantID,
+		CreditCard:     card,
+		Amount:         decimal.NewFromInt(5),
+		RedemptionDate: day,
+		RedeemAs:       "statement_credit",
+	}); err != nil {
+		t.Fatalf("failed to redeem cashback: %v", err)
+	}
+	statement, err := NewBillingServiceWithStore(store).GenerateStatement(ctx, GenerateStatementRequest{
+		CreditCard: card,
+		CycleEnd:   cycleEnd,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate statement: %v", err)
+	}
+
+	service := NewLedgerReconciliationServiceWithStore(store, models.PointsEarningRule{PointsPerDollar: decimal.NewFromInt(1)})
+	report, err := service.GenerateReconciliationReport(ctx, card.TenantID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !report.IsReconciled() {
+		t.Fatalf("consistent ledgers reported exceptions: %+v", report.Exceptions)
+	}
+
+	// Break each invariant once
+	missing := uuid.New()
+	if err := store.CashbackEntries().Create(ctx, &models.CashbackLedgerEntry{
+		ID:               uuid.New(),
+		TenantID:         card.TenantID,
+		CreditCardID:     card.ID,
+		StatementEntryID: &missing,
+		EntryType:        models.CashbackEarned,
+		EntryDate:        day,
+		Amount:           decimal.NewFromInt(1),
+		CreatedAt:        day,
+	}); err != nil {
+		t.Fatalf("failed to create cashback entry: %v", err)
+	}
+	for _, entry := range []*models.StatementLedgerEntry{
+		{
+			EntryType: models.EntryTypeRefund,
+			Amount:    decimal.NewFromInt(50),
+			Metadata:  map[string]interface{}{"original_transaction_id": purchase.TransactionEntry.ID.String()},
+		},
+		{
+			EntryType: models.EntryTypeCashbackRedeemed,
+			Amount:    decimal.NewFromInt(2),
+		},
+	} {
+		entry.ID = uuid.New()
+		entry.TenantID = card.TenantID
+		entry.EntryDate = cycleEnd.AddDate(0, 0, 5)
+		entry.PostingDate = entry.EntryDate
+		entry.Status = models.EntryStatusPending
+		entry.CreatedAt = entry.EntryDate
+		if err := store.StatementEntries().Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create statement entry: %v", err)
+		}
+	}
+	cycle, _ := store.BillingCycles().GetByID(ctx, statement.BillingCycle.ID)
+	cycle.RefundsAmount = decimal.Zero
+	if err := store.BillingCycles().Update(ctx, cycle); err != nil {
+		t.Fatalf("failed to update cycle: %v", err)
+	}
+
+	report, err = service.GenerateReconciliationReport(ctx, card.TenantID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := make(map[ReconciliationExceptionType]int)
+	for _, exception := range report.Exceptions {
+		got[exception.Type]++
+	}
+	want := map[ReconciliationExceptionType]int{
+		ExceptionOrphanedCashback:     1,
+		ExceptionRefundNotAdjusted:    1,
+		ExceptionRedemptionUnmatched:  1,
+		ExceptionBillingCycleMismatch: 1,
+	}
+	for exceptionType, count := range want {
+		if got[exceptionType] != count {
+			t.Errorf("%s exceptions = %d, want %d", exceptionType, got[exceptionType], count)
+		}
+	}
+	if len(report.Exceptions) != 4 {
+		t.Errorf("exceptions = %+v, want 4", report.Exceptions)
+	}
+}
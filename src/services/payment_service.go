@@ -8,23 +8,39 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 	"github.com/shopspring/decimal"
 )
 
 // PaymentService handles payment processing, tracking, and status management
 type PaymentService struct {
-	ledgerService *StatementLedgerService
-	feeService    *FeeService
+	store             repository.Store
+	ledgerService     *StatementLedgerService
+	feeService        *FeeService
+	creditCardService *CreditCardService
 }
 
 // NewPaymentService creates a new payment service
-func NewPaymentService(ledgerService *StatementLedgerService, feeService *FeeService) *PaymentService {
+func NewPaymentService(db Querier) *PaymentService {
+	return NewPaymentServiceWithStore(postgres.NewStore(db))
+}
+
+// NewPaymentServiceWithStore creates a payment service backed by store
+func NewPaymentServiceWithStore(store repository.Store) *PaymentService {
 	return &PaymentService{
-		ledgerService: ledgerService,
-		feeService:    feeService,
+		store:             store,
+		ledgerService:     NewStatementLedgerServiceWithStore(store),
+		feeService:        NewFeeServiceWithStore(store),
+		creditCardService: NewCreditCardServiceWithStore(store),
 	}
 }
 
+// withStore returns a copy of the service bound to store
+func (s *PaymentService) withStore(store repository.Store) *PaymentService {
+	return NewPaymentServiceWithStore(store)
+}
+
 // InitiatePaymentRequest contains parameters for initiating a payment
 type InitiatePaymentRequest struct {
 	TenantID       uuid.UUID
@@ -36,6 +52,7 @@ type InitiatePaymentRequest struct {
 	BillingCycleID *uuid.UUID
 	SourceAccount  *PaymentSourceAccount
 	CreatedBy      string
+	IdempotencyKey string // Retries with the same key replay the original result
 }
 
 // PaymentSourceAccount holds source account information
@@ -56,7 +73,7 @@ type PaymentResult struct {
 }
 
 // InitiatePayment creates a new payment in pending status
-func (s *PaymentService) InitiatePayment(req InitiatePaymentRequest) (*PaymentResult, error) {
+func (s *PaymentService) InitiatePayment(ctx context.Context, req InitiatePaymentRequest) (*PaymentResult, error) {
 	if req.Amount.LessThanOrEqual(decimal.Zero) {
 		return nil, errors.New("payment amount must be positive")
 	}
@@ -93,313 +110,343 @@ func (s *PaymentService) InitiatePayment(req InitiatePaymentRequest) (*PaymentRe
 		PaymentID:    payment.ID,
 		FromStatus:   "",
 		ToStatus:     models.PaymentStatusPending,
-		TransitionAt: time.Now(),
+		TransitionAt: payment.InitiatedAt,
 		TriggeredBy:  &req.CreatedBy,
 	}
 
-	return &PaymentResult{
-		Payment:     payment,
-		Transitions: []models.PaymentStatusTransition{transition},
-	}, nil
-}
+	result := &PaymentResult{}
 
-// ProcessPayment moves a payment from pending to processing status
-func (s *PaymentService) ProcessPayment(payment *models.Payment, processorRef string) (*PaymentResult, error) {
-	if !payment.CanTransitionTo(models.PaymentStatusProcessing) {
-		return nil, fmt.Errorf("cannot process payment in status %s", payment.Status)
-	}
-
-	now := time.Now()
-	previousStatus := payment.Status
+	err := idempotently(ctx, s.store, req.TenantID, req.IdempotencyKey, operationInitiatePayment, result, func(tx repository.Store) error {
+		if err := tx.Payments().Create(ctx, payment); err != nil {
+			return fmt.Errorf("failed to create payment: %w", err)
+		}
 
-	payment.PreviousStatus = &previousStatus
-	payment.Status = models.PaymentStatusProcessing
-	payment.ProcessingAt = &now
-	payment.ProcessorRef = &processorRef
-	payment.AttemptCount++
-	payment.LastAttemptAt = &now
-	payment.UpdatedAt = now
+		if err := tx.Payments().CreateTransition(ctx, &transition); err != nil {
+			return fmt.Errorf("failed to record payment transition: %w", err)
+		}
 
-	transition := models.PaymentStatusTransition{
-		ID:           uuid.New(),
-		PaymentID:    payment.ID,
-		FromStatus:   previousStatus,
-		ToStatus:     models.PaymentStatusProcessing,
-		TransitionAt: now,
-		TriggeredBy:  strPtr("system"),
+		result.Payment = payment
+		result.Transitions = []models.PaymentStatusTransition{transition}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &PaymentResult{
-		Payment:     payment,
-		Transitions: []models.PaymentStatusTransition{transition},
-	}, nil
+	return result, nil
 }
 
-// ClearPayment marks a payment as successfully cleared
-func (s *PaymentService) ClearPayment(payment *models.Payment, card *models.CreditCard, confirmationNum string) (*PaymentResult, error) {
-	if !payment.CanTransitionTo(models.PaymentStatusCleared) {
-		return nil, fmt.Errorf("cannot clear payment in status %s", payment.Status)
-	}
+// ProcessPayment moves a payment from pending to processing status
+func (s *PaymentService) ProcessPayment(ctx context.Context, paymentID uuid.UUID, processorRef string) (*PaymentResult, error) {
+	return s.transitionPayment(ctx, paymentID, models.PaymentStatusProcessing, "process",
+		func(txs *PaymentService, payment *models.Payment, transition *models.PaymentStatusTransition, result *PaymentResult) error {
+			now := transition.TransitionAt
+			payment.ProcessingAt = &now
+			payment.ProcessorRef = &processorRef
+			payment.AttemptCount++
+			payment.LastAttemptAt = &now
+			transition.TriggeredBy = strPtr("system")
+			return nil
+		})
+}
 
-	now := time.Now()
-	previousStatus := payment.Status
+// ClearPayment marks a payment as successfully cleared and posts it to the statement ledger
+func (s *PaymentService) ClearPayment(ctx context.Context, paymentID uuid.UUID, confirmationNum string) (*PaymentResult, error) {
+	return s.transitionPayment(ctx, paymentID, models.PaymentStatusCleared, "clear",
+		func(txs *PaymentService, payment *models.Payment, transition *models.PaymentStatusTransition, result *PaymentResult) error {
+			now := transition.TransitionAt
+			payment.ClearedAt = &now
+			payment.ConfirmationNum = &confirmationNum
+			transition.TriggeredBy = strPtr("processor")
+
+			card, err := txs.creditCardService.lockCard(ctx, payment.CreditCardID)
+			if err != nil {
+				return err
+			}
+			// Money collected after charge-off is a recovery, not a payment
+			if card.IsChargedOff() {
+				return models.ErrCardChargedOff
+			}
+			allocation, err := txs.creditCardService.allocatePayment(ctx, card, payment.AppliedAmount, payment.EffectiveDate)
+			if err != nil {
+				return err
+			}
 
-	payment.PreviousStatus = &previousStatus
-	payment.Status = models.PaymentStatusCleared
-	payment.ClearedAt = &now
-	payment.ConfirmationNum = &confirmationNum
-	payment.UpdatedAt = now
+			// Create ledger entry for the payment
+			entry := &models.StatementLedgerEntry{
+				ID:          uuid.New(),
+				TenantID:    payment.TenantID,
+				EntryType:   models.EntryTypePayment,
+				EntryDate:   now,
+				PostingDate: payment.EffectiveDate,
+				Amount:      payment.AppliedAmount,
+				Description: fmt.Sprintf("Payment - %s", payment.PaymentNumber),
+				ReferenceID: &payment.PaymentNumber,
+				Status:      models.EntryStatusCleared,
+				ClearedAt:   &now,
+				Metadata: map[string]interface{}{
+					"payment_id":                 payment.ID.String(),
+					"payment_method":             string(payment.PaymentMethod),
+					models.AllocationMetadataKey: allocation.Metadata(),
+				},
+				CreatedAt: now,
+			}
 
-	transition := models.PaymentStatusTransition{
-		ID:           uuid.New(),
-		PaymentID:    payment.ID,
-		FromStatus:   previousStatus,
-		ToStatus:     models.PaymentStatusCleared,
-		TransitionAt: now,
-		TriggeredBy:  strPtr("processor"),
-	}
+			if err := txs.ledgerService.CreateEntry(ctx, entry); err != nil {
+				return fmt.Errorf("failed to create payment entry: %w", err)
+			}
 
-	// Create ledger entry for the payment
-	var ledgerEntry *models.StatementLedgerEntry
-	if s.ledgerService != nil {
-		entry := &models.StatementLedgerEntry{
-			ID:          uuid.New(),
-			TenantID:    payment.TenantID,
-			EntryType:   models.EntryTypePayment,
-			EntryDate:   now,
-			PostingDate: payment.EffectiveDate,
-			Amount:      payment.AppliedAmount,
-			Description: fmt.Sprintf("Payment - %s", payment.PaymentNumber),
-			ReferenceID: &payment.PaymentNumber,
-			CreatedAt:   now,
-		}
-		ledgerEntry = entry
-	}
+			payment.StatementEntryID = &entry.ID
+			result.LedgerEntry = entry
+
+			// Free up credit for the payment, capped at the credit limit
+			err = txs.creditCardService.updateCard(ctx, payment.CreditCardID, func(card *models.CreditCard) {
+				card.AvailableCredit = decimal.Min(card.AvailableCredit.Add(payment.AppliedAmount), card.CreditLimit)
+				card.LastPaymentDate = &now
+				card.LastPaymentAmount = payment.AppliedAmount
+			})
+			if err != nil {
+				return fmt.Errorf("failed to update available credit: %w", err)
+			}
 
-	return &PaymentResult{
-		Payment:     payment,
-		Transitions: []models.PaymentStatusTransition{transition},
-		LedgerEntry: ledgerEntry,
-	}, nil
+			return nil
+		})
 }
 
 // FailPayment marks a payment as failed
-func (s *PaymentService) FailPayment(payment *models.Payment, reason string, processorResponse string) (*PaymentResult, error) {
-	if !payment.CanTransitionTo(models.PaymentStatusFailed) {
-		return nil, fmt.Errorf("cannot fail payment in status %s", payment.Status)
-	}
-
-	now := time.Now()
-	previousStatus := payment.Status
-
-	payment.PreviousStatus = &previousStatus
-	payment.Status = models.PaymentStatusFailed
-	payment.FailedAt = &now
-	payment.StatusReason = &reason
-	payment.ProcessorResponse = &processorResponse
-	payment.UpdatedAt = now
-
-	// Set retry time if retries available
-	if payment.CanRetry() {
-		retryTime := now.Add(time.Hour * 24) // Retry in 24 hours
-		payment.NextRetryAt = &retryTime
-	}
+func (s *PaymentService) FailPayment(ctx context.Context, paymentID uuid.UUID, reason string, processorResponse string) (*PaymentResult, error) {
+	return s.transitionPayment(ctx, paymentID, models.PaymentStatusFailed, "fail",
+		func(txs *PaymentService, payment *models.Payment, transition *models.PaymentStatusTransition, result *PaymentResult) error {
+			now := transition.TransitionAt
+			payment.FailedAt = &now
+			payment.StatusReason = &reason
+			payment.ProcessorResponse = &processorResponse
+
+			// Set retry time if retries available
+			if payment.CanRetry() {
+				retryTime := now.Add(time.Hour * 24) // Retry in 24 hours
+				payment.NextRetryAt = &retryTime
+			}
 
-	transition := models.PaymentStatusTransition{
-		ID:           uuid.New(),
-		PaymentID:    payment.ID,
-		FromStatus:   previousStatus,
-		ToStatus:     models.PaymentStatusFailed,
-		Reason:       &reason,
-		TransitionAt: now,
-		TriggeredBy:  strPtr("processor"),
-	}
+			transition.Reason = &reason
+			transition.TriggeredBy = strPtr("processor")
 
-	return &PaymentResult{
-		Payment:      payment,
-		Transitions:  []models.PaymentStatusTransition{transition},
-		ErrorCode:    "PAYMENT_FAILED",
-		ErrorMessage: reason,
-	}, nil
+			result.ErrorCode = "PAYMENT_FAILED"
+			result.ErrorMessage = reason
+			return nil
+		})
 }
 
 // ReturnPayment marks a cleared payment as returned (ACH return, chargeback)
-func (s *PaymentService) ReturnPayment(payment *models.Payment, card *models.CreditCard, returnCode models.ACHReturnCode) (*PaymentResult, error) {
-	if !payment.CanTransitionTo(models.PaymentStatusReturned) {
-		return nil, fmt.Errorf("cannot return payment in status %s", payment.Status)
-	}
-
-	now := time.Now()
-	previousStatus := payment.Status
+func (s *PaymentService) ReturnPayment(ctx context.Context, paymentID uuid.UUID, returnCode models.ACHReturnCode) (*PaymentResult, error) {
 	codeStr := string(returnCode)
 	desc := models.ACHReturnCodeDescriptions[returnCode]
 
-	payment.PreviousStatus = &previousStatus
-	payment.Status = models.PaymentStatusReturned
-	payment.ReturnedAt = &now
-	payment.ReturnReasonCode = &codeStr
-	payment.ReturnReasonDesc = &desc
-	payment.UpdatedAt = now
-
-	transition := models.PaymentStatusTransition{
-		ID:           uuid.New(),
-		PaymentID:    payment.ID,
-		FromStatus:   previousStatus,
-		ToStatus:     models.PaymentStatusReturned,
-		Reason:       &desc,
-		TransitionAt: now,
-		TriggeredBy:  strPtr("processor"),
-		Metadata: map[string]interface{}{
-			"return_code":     codeStr,
-			"description":     desc,
-			"is_hard_failure": returnCode.IsHardFailure(),
-		},
-	}
+	return s.transitionPayment(ctx, paymentID, models.PaymentStatusReturned, "return",
+		func(txs *PaymentService, payment *models.Payment, transition *models.PaymentStatusTransition, result *PaymentResult) error {
+			now := transition.TransitionAt
+			payment.ReturnedAt = &now
+			payment.ReturnReasonCode = &codeStr
+			payment.ReturnReasonDesc = &desc
+
+			transition.Reason = &desc
+			transition.TriggeredBy = strPtr("processor")
+			transition.Metadata = map[string]interface{}{
+				"return_code":     codeStr,
+				"description":     desc,
+				"is_hard_failure": returnCode.IsHardFailure(),
+			}
 
-	// Create reversal ledger entry
-	var ledgerEntry *models.StatementLedgerEntry
-	if s.ledgerService != nil {
-		entry := &models.StatementLedgerEntry{
-			ID:          uuid.New(),
-			TenantID:    payment.TenantID,
-			EntryType:   models.EntryTypeAdjustment,
-			EntryDate:   now,
-			PostingDate: now,
-			Amount:      payment.AppliedAmount.Neg(), // Negative to reverse
-			Description: fmt.Sprintf("Payment Returned - %s: %s", codeStr, desc),
-			ReferenceID: &payment.PaymentNumber,
-			CreatedAt:   now,
-		}
-		ledgerEntry = entry
-	}
+			entry, err := txs.createReversalEntry(ctx, payment, transition.TransitionAt,
+				fmt.Sprintf("Payment Returned - %s: %s", codeStr, desc))
+			if err != nil {
+				return err
+			}
 
-	return &PaymentResult{
-		Payment:      payment,
-		Transitions:  []models.PaymentStatusTransition{transition},
-		LedgerEntry:  ledgerEntry,
-		ErrorCode:    codeStr,
-		ErrorMessage: desc,
-	}, nil
+			result.LedgerEntry = entry
+			result.ErrorCode = codeStr
+			result.ErrorMessage = desc
+			return nil
+		})
 }
 
 // CancelPayment cancels a pending or processing payment
-func (s *PaymentService) CancelPayment(payment *models.Payment, reason string, cancelledBy string) (*PaymentResult, error) {
-	if !payment.CanTransitionTo(models.PaymentStatusCancelled) {
-		return nil, fmt.Errorf("cannot cancel payment in status %s", payment.Status)
-	}
+func (s *PaymentService) CancelPayment(ctx context.Context, paymentID uuid.UUID, reason string, cancelledBy string) (*PaymentResult, error) {
+	return s.transitionPayment(ctx, paymentID, models.PaymentStatusCancelled, "cancel",
+		func(txs *PaymentService, payment *models.Payment, transition *models.PaymentStatusTransition, result *PaymentResult) error {
+			now := transition.TransitionAt
+			payment.CancelledAt = &now
+			payment.StatusReason = &reason
+			payment.UpdatedBy = &cancelledBy
+
+			transition.Reason = &reason
+			transition.TriggeredBy = &cancelledBy
+			return nil
+		})
+}
 
-	now := time.Now()
-	previousStatus := payment.Status
+// ReversePayment reverses a cleared payment (full reversal)
+func (s *PaymentService) ReversePayment(ctx context.Context, paymentID uuid.UUID, reason string, reversedBy string) (*PaymentResult, error) {
+	return s.transitionPayment(ctx, paymentID, models.PaymentStatusReversed, "reverse",
+		func(txs *PaymentService, payment *models.Payment, transition *models.PaymentStatusTransition, result *PaymentResult) error {
+			now := transition.TransitionAt
+			payment.ReversedAt = &now
+			payment.StatusReason = &reason
+			payment.UpdatedBy = &reversedBy
+
+			transition.Reason = &reason
+			transition.TriggeredBy = &reversedBy
+
+			entry, err := txs.createReversalEntry(ctx, payment, transition.TransitionAt,
+				fmt.Sprintf("Payment Reversal - %s: %s", payment.PaymentNumber, reason))
+			if err != nil {
+				return err
+			}
 
-	payment.PreviousStatus = &previousStatus
-	payment.Status = models.PaymentStatusCancelled
-	payment.CancelledAt = &now
-	payment.StatusReason = &reason
-	payment.UpdatedAt = now
-	payment.UpdatedBy = &cancelledBy
+			result.LedgerEntry = entry
+			return nil
+		})
+}
 
-	transition := models.PaymentStatusTransition{
-		ID:           uuid.New(),
-		PaymentID:    payment.ID,
-		FromStatus:   previousStatus,
-		ToStatus:     models.PaymentStatusCancelled,
-		Reason:       &reason,
-		TransitionAt: now,
-		TriggeredBy:  &cancelledBy,
-	}
+// RetryPayment attempts to retry a failed payment
+func (s *PaymentService) RetryPayment(ctx context.Context, paymentID uuid.UUID) (*PaymentResult, error) {
+	return s.transitionPayment(ctx, paymentID, models.PaymentStatusPending, "retry",
+		func(txs *PaymentService, payment *models.Payment, transition *models.PaymentStatusTransition, result *PaymentResult) error {
+			// The status has already moved to pending, so only the attempt budget is left to check
+			if payment.AttemptCount >= payment.MaxRetries {
+				return fmt.Errorf("payment cannot be retried: status=%s, attempts=%d, max=%d",
+					transition.FromStatus, payment.AttemptCount, payment.MaxRetries)
+			}
 
-	return &PaymentResult{
-		Payment:     payment,
-		Transitions: []models.PaymentStatusTransition{transition},
-	}, nil
+			payment.NextRetryAt = nil
+
+			transition.Reason = strPtr("Retry attempt")
+			transition.TriggeredBy = strPtr("system")
+			transition.Metadata = map[string]interface{}{
+				"attempt_number": payment.AttemptCount + 1,
+			}
+			return nil
+		})
 }
 
-// ReversePayment reverses a cleared payment (full reversal)
-func (s *PaymentService) ReversePayment(payment *models.Payment, reason string, reversedBy string) (*PaymentResult, error) {
-	if !payment.CanTransitionTo(models.PaymentStatusReversed) {
-		return nil, fmt.Errorf("cannot reverse payment in status %s", payment.Status)
-	}
+// transitionPayment loads a payment, moves it to status and persists the payment,
+// its transition record and anything apply writes in a single transaction
+func (s *PaymentService) transitionPayment(
+	ctx context.Context,
+	paymentID uuid.UUID,
+	status models.PaymentStatus,
+	verb string,
+	apply func(txs *PaymentService, payment *models.Payment, transition *models.PaymentStatusTransition, result *PaymentResult) error,
+) (*PaymentResult, error) {
+	result := &PaymentResult{}
+
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		payment, err := txs.getPayment(ctx, paymentID)
+		if err != nil {
+			return err
+		}
 
-	now := time.Now()
-	previousStatus := payment.Status
+		if !payment.CanTransitionTo(status) {
+			return fmt.Errorf("cannot %s payment in status %s", verb, payment.Status)
+		}
 
-	payment.PreviousStatus = &previousStatus
-	payment.Status = models.PaymentStatusReversed
-	payment.ReversedAt = &now
-	payment.StatusReason = &reason
-	payment.UpdatedAt = now
-	payment.UpdatedBy = &reversedBy
+		now := time.Now()
+		previousStatus := payment.Status
 
-	transition := models.PaymentStatusTransition{
-		ID:           uuid.New(),
-		PaymentID:    payment.ID,
-		FromStatus:   previousStatus,
-		ToStatus:     models.PaymentStatusReversed,
-		Reason:       &reason,
-		TransitionAt: now,
-		TriggeredBy:  &reversedBy,
-	}
+		payment.PreviousStatus = &previousStatus
+		payment.Status = status
+		payment.UpdatedAt = now
 
-	// Create reversal ledger entry
-	var ledgerEntry *models.StatementLedgerEntry
-	if s.ledgerService != nil {
-		entry := &models.StatementLedgerEntry{
-			ID:          uuid.New(),
-			TenantID:    payment.TenantID,
-			EntryType:   models.EntryTypeAdjustment,
-			EntryDate:   now,
-			PostingDate: now,
-			Amount:      payment.AppliedAmount.Neg(), // Negative to reverse
-			Description: fmt.Sprintf("Payment Reversal - %s: %s", payment.PaymentNumber, reason),
-			ReferenceID: &payment.PaymentNumber,
-			CreatedAt:   now,
+		transition := models.PaymentStatusTransition{
+			ID:           uuid.New(),
+			PaymentID:    payment.ID,
+			FromStatus:   previousStatus,
+			ToStatus:     status,
+			TransitionAt: now,
 		}
-		ledgerEntry = entry
-	}
 
-	return &PaymentResult{
-		Payment:     payment,
-		Transitions: []models.PaymentStatusTransition{transition},
-		LedgerEntry: ledgerEntry,
-	}, nil
-}
+		if err := apply(txs, payment, &transition, result); err != nil {
+			return err
+		}
 
-// RetryPayment attempts to retry a failed payment
-func (s *PaymentService) RetryPayment(payment *models.Payment) (*PaymentResult, error) {
-	if !payment.CanRetry() {
-		return nil, fmt.Errorf("payment cannot be retried: status=%s, attempts=%d, max=%d",
-			payment.Status, payment.AttemptCount, payment.MaxRetries)
-	}
+		if err := tx.Payments().Update(ctx, payment); err != nil {
+			return fmt.Errorf("failed to update payment: %w", err)
+		}
 
-	if !payment.CanTransitionTo(models.PaymentStatusPending) {
-		return nil, fmt.Errorf("cannot retry payment in status %s", payment.Status)
-	}
+		if err := tx.Payments().CreateTransition(ctx, &transition); err != nil {
+			return fmt.Errorf("failed to record payment transition: %w", err)
+		}
 
-	now := time.Now()
-	previousStatus := payment.Status
+		result.Payment = payment
+		result.Transitions = []models.PaymentStatusTransition{transition}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-	payment.PreviousStatus = &previousStatus
-	payment.Status = models.PaymentStatusPending
-	payment.UpdatedAt = now
-	payment.NextRetryAt = nil
+	return result, nil
+}
 
-	transition := models.PaymentStatusTransition{
-		ID:           uuid.New(),
-		PaymentID:    payment.ID,
-		FromStatus:   previousStatus,
-		ToStatus:     models.PaymentStatusPending,
-		Reason:       strPtr("Retry attempt"),
-		TransitionAt: now,
-		TriggeredBy:  strPtr("system"),
+// createReversalEntry posts an adjustment that adds a payment back to the balance
+// and takes back the credit it freed up. The adjustment carries the payment's
+// allocation, so each balance segment gets back exactly what the payment paid.
+func (s *PaymentService) createReversalEntry(
+	ctx context.Context,
+	payment *models.Payment,
+	now time.Time,
+	description string,
+) (*models.StatementLedgerEntry, error) {
+	card, err := s.creditCardService.lockCard(ctx, payment.CreditCardID)
+	if err != nil {
+		return nil, err
+	}
+	if err := card.CanPost(); err != nil {
+		return nil, err
+	}
+
+	var allocation interface{}
+	if payment.StatementEntryID != nil {
+		paymentEntry, err := s.store.StatementEntries().GetByID(ctx, *payment.StatementEntryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payment entry: %w", err)
+		}
+		allocation = paymentEntry.Metadata[models.AllocationMetadataKey]
+	}
+
+	entry := &models.StatementLedgerEntry{
+		ID:          uuid.New(),
+		TenantID:    payment.TenantID,
+		EntryType:   models.EntryTypeAdjustment,
+		EntryDate:   now,
+		PostingDate: now,
+		Amount:      payment.AppliedAmount, // Positive to add back
+		Description: description,
+		ReferenceID: &payment.PaymentNumber,
+		Status:      models.EntryStatusPending,
 		Metadata: map[string]interface{}{
-			"attempt_number": payment.AttemptCount + 1,
+			"payment_id": payment.ID.String(),
 		},
+		CreatedAt: now,
+	}
+	if allocation != nil {
+		entry.Metadata[models.AllocationMetadataKey] = allocation
+	}
+
+	if err := s.ledgerService.CreateEntry(ctx, entry); err != nil {
+		return nil, fmt.Errorf("failed to create reversal entry: %w", err)
 	}
 
-	return &PaymentResult{
-		Payment:     payment,
-		Transitions: []models.PaymentStatusTransition{transition},
-	}, nil
+	err = s.creditCardService.updateCard(ctx, payment.CreditCardID, func(card *models.CreditCard) {
+		card.AvailableCredit = card.AvailableCredit.Sub(payment.AppliedAmount)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update available credit: %w", err)
+	}
+
+	return entry, nil
 }
 
 // AssessFailedPaymentFee assesses a failed payment fee when applicable
@@ -408,10 +455,6 @@ func (s *PaymentService) AssessFailedPaymentFee(ctx context.Context, payment *mo
 		return nil, errors.New("can only assess fee for failed or returned payments")
 	}
 
-	if s.feeService == nil {
-		return nil, errors.New("fee service not configured")
-	}
-
 	req := FailedPaymentFeeRequest{
 		CreditCard:    card,
 		PaymentAmount: payment.Amount,
@@ -428,81 +471,39 @@ func (s *PaymentService) AssessFailedPaymentFee(ctx context.Context, payment *mo
 	return s.feeService.AssessFailedPaymentFee(ctx, req)
 }
 
-// GetPaymentHistory returns the status transition history for a payment
-func (s *PaymentService) GetPaymentHistory(payment *models.Payment) []models.PaymentStatusTransition {
-	// In a real implementation, this would query from database
-	// For now, we return based on payment timestamps
-	var history []models.PaymentStatusTransition
-
-	// Initial pending
-	if !payment.InitiatedAt.IsZero() {
-		history = append(history, models.PaymentStatusTransition{
-			PaymentID:    payment.ID,
-			ToStatus:     models.PaymentStatusPending,
-			TransitionAt: payment.InitiatedAt,
-		})
-	}
-
-	// Processing
-	if payment.ProcessingAt != nil {
-		history = append(history, models.PaymentStatusTransition{
-			PaymentID:    payment.ID,
-			FromStatus:   models.PaymentStatusPending,
-			ToStatus:     models.PaymentStatusProcessing,
-			TransitionAt: *payment.ProcessingAt,
-		})
-	}
-
-	// Cleared
-	if payment.ClearedAt != nil {
-		history = append(history, models.PaymentStatusTransition{
-			PaymentID:    payment.ID,
-			FromStatus:   models.PaymentStatusProcessing,
-			ToStatus:     models.PaymentStatusCleared,
-			TransitionAt: *payment.ClearedAt,
-		})
-	}
+// GetPayment retrieves a payment by ID
+func (s *PaymentService) GetPayment(ctx context.Context, paymentID uuid.UUID) (*models.Payment, error) {
+	return s.getPayment(ctx, paymentID)
+}
 
-	// Failed
-	if payment.FailedAt != nil {
-		history = append(history, models.PaymentStatusTransition{
-			PaymentID:    payment.ID,
-			FromStatus:   models.PaymentStatusProcessing,
-			ToStatus:     models.PaymentStatusFailed,
-			TransitionAt: *payment.FailedAt,
-		})
+// GetPaymentByNumber retrieves a payment by its payment number
+func (s *PaymentService) GetPaymentByNumber(ctx context.Context, paymentNumber string) (*models.Payment, error) {
+	payment, err := s.store.Payments().GetByNumber(ctx, paymentNumber)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("payment not found: %s", paymentNumber)
 	}
+	return payment, err
+}
 
-	// Returned
-	if payment.ReturnedAt != nil {
-		history = append(history, models.PaymentStatusTransition{
-			PaymentID:    payment.ID,
-			FromStatus:   models.PaymentStatusCleared,
-			ToStatus:     models.PaymentStatusReturned,
-			TransitionAt: *payment.ReturnedAt,
-		})
-	}
+// GetPaymentsByCard retrieves all payments for a credit card, oldest first
+func (s *PaymentService) GetPaymentsByCard(ctx context.Context, creditCardID uuid.UUID) ([]*models.Payment, error) {
+	return s.store.Payments().List(ctx, repository.PaymentFilter{
+		CreditCardID: &creditCardID,
+	})
+}
 
-	// Cancelled
-	if payment.CancelledAt != nil {
-		history = append(history, models.PaymentStatusTransition{
-			PaymentID:    payment.ID,
-			ToStatus:     models.PaymentStatusCancelled,
-			TransitionAt: *payment.CancelledAt,
-		})
-	}
+// GetPaymentHistory returns the status transition history for a payment
+func (s *PaymentService) GetPaymentHistory(ctx context.Context, paymentID uuid.UUID) ([]*models.PaymentStatusTransition, error) {
+	return s.store.Payments().ListTransitions(ctx, paymentID)
+}
 
-	// Reversed
-	if payment.ReversedAt != nil {
-		history = append(history, models.PaymentStatusTransition{
-			PaymentID:    payment.ID,
-			FromStatus:   models.PaymentStatusCleared,
-			ToStatus:     models.PaymentStatusReversed,
-			TransitionAt: *payment.ReversedAt,
-		})
+// getPayment loads a payment, reporting a missing one by ID
+func (s *PaymentService) getPayment(ctx context.Context, paymentID uuid.UUID) (*models.Payment, error) {
+	payment, err := s.store.Payments().GetByID(ctx, paymentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, fmt.Errorf("payment not found: %s", paymentID)
 	}
-
-	return history
+	return payment, err
 }
 
 // CalculatePaymentSummary calculates payment summary for a card and period
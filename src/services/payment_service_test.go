@@ -0,0 +1,415 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+// failingEntryStore wraps a store so that creating a statement entry fails
+type failingEntryStore struct {
+	repository.Store
+}
+
+type failingEntries struct {
+	repository.StatementEntryRepository
+}
+
+func (s failingEntryStore) StatementEntries() repository.StatementEntryRepository {
+	return failingEntries{s.Store.StatementEntries()}
+}
+
+func (s failingEntryStore) WithinTx(ctx context.Context, fn func(tx repository.Store) error) error {
+	return s.Store.WithinTx(ctx, func(tx repository.Store) error {
+		return fn(failingEntryStore{tx})
+	})
+}
+
+func (failingEntries) Create(context.Context, *models.StatementLedgerEntry) error {
+	return errors.New("forced failure")
+}
+
+func TestPaymentService_Lifecycle(t *testing.T) {
+	tests := []struct {
+		name          string
+		settle        func(s *PaymentService, ctx context.Context, p *models.Payment) (*PaymentResult, error)
+		wantStatus    models.PaymentStatus
+		wantEntries   int
+		wantBalance   decimal.Decimal
+		wantAvailable decimal.Decimal
+		wantHistory   []models.PaymentStatus
+	}{
+		{
+			name: "Cleared payment posts a credit",
+			settle: func(s *PaymentService, ctx context.Context, p *models.Payment) (*PaymentResult, error) {
+				return s.ClearPayment(ctx, p.ID, "CONF-1")
+			},
+			wantStatus:    models.PaymentStatusCleared,
+			wantEntries:   1,
+			wantBalance:   decimal.NewFromInt(-100),
+			wantAvailable: decimal.NewFromInt(4100),
+			wantHistory: []models.PaymentStatus{
+				models.PaymentStatusPending, models.PaymentStatusProcessing, models.PaymentStatusCleared,
+			},
+		},
+		{
+			name: "Failed payment posts nothing",
+			settle: func(s *PaymentService, ctx context.Context, p *models.Payment) (*PaymentResult, error) {
+				return s.FailPayment(ctx, p.ID, "NSF", "R01")
+			},
+			wantStatus:    models.PaymentStatusFailed,
+			wantEntries:   0,
+			wantBalance:   decimal.Zero,
+			wantAvailable: decimal.NewFromInt(4000),
+			wantHistory: []models.PaymentStatus{
+				models.PaymentStatusPending, models.PaymentStatusProcessing, models.PaymentStatusFailed,
+			},
+		},
+		{
+			name: "Returned payment adds the amount back",
+			settle: func(s *PaymentService, ctx context.Context, p *models.Payment) (*PaymentResult, error) {
+				if _, err := s.ClearPayment(ctx, p.ID, "CONF-1"); err != nil {
+					return nil, err
+				}
+				return s.ReturnPayment(ctx, p.ID, models.ACHReturnR01)
+			},
+			wantStatus:    models.PaymentStatusReturned,
+			wantEntries:   2,
+			wantBalance:   decimal.Zero,
+			wantAvailable: decimal.NewFromInt(4000),
+			wantHistory: []models.PaymentStatus{
+				models.PaymentStatusPending, models.PaymentStatusProcessing,
+				models.PaymentStatusCleared, models.PaymentStatusReturned,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			service := NewPaymentServiceWithStore(store)
+
+			card := testCard()
+			card.AvailableCredit = decimal.NewFromInt(4000)
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+
+			initiated, err := service.InitiatePayment(ctx, InitiatePaymentRequest{
+				TenantID:      card.TenantID,
+				CreditCardID:  card.ID,
+				Amount:        decimal.NewFromInt(100),
+				PaymentType:   models.PaymentTypeRegular,
+				PaymentMethod: models.PaymentMethodACH,
+				CreatedBy:     "user",
+			})
+			if err != nil {
+				t.Fatalf("failed to initiate payment: %v", err)
+			}
+			payment := initiated.Payment
+
+			if _, err := service.ProcessPayment(ctx, payment.ID, "REF-1"); err != nil {
+				t.Fatalf("failed to process payment: %v", err)
+			}
+			if _, err := tt.settle(service, ctx, payment); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			stored, err := service.GetPaymentByNumber(ctx, payment.PaymentNumber)
+			if err != nil {
+				t.Fatalf("failed to load payment: %v", err)
+			}
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+
+			history, err := service.GetPaymentHistory(ctx, payment.ID)
+			if err != nil {
+				t.Fatalf("failed to load history: %v", err)
+			}
+			if len(history) != len(tt.wantHistory) {
+				t.Fatalf("history has %d transitions, want %d", len(history), len(tt.wantHistory))
+			}
+			for i, transition := range history {
+				if transition.ToStatus != tt.wantHistory[i] {
+					t.Errorf("transition %d to = %s, want %s", i, transition.ToStatus, tt.wantHistory[i])
+				}
+				if i > 0 && transition.FromStatus != tt.wantHistory[i-1] {
+					t.Errorf("transition %d from = %s, want %s", i, transition.FromStatus, tt.wantHistory[i-1])
+				}
+			}
+
+			entries, err := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+			if err != nil {
+				t.Fatalf("failed to list entries: %v", err)
+			}
+			if len(entries) != tt.wantEntries {
+				t.Errorf("ledger entries = %d, want %d", len(entries), tt.wantEntries)
+			}
+			if balance := sumSignedAmounts(entries); !balance.Equal(tt.wantBalance) {
+				t.Errorf("ledger balance = %s, want %s", balance, tt.wantBalance)
+			}
+
+			updated, err := store.CreditCards().GetByID(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to load card: %v", err)
+			}
+			if !updated.AvailableCredit.Equal(tt.wantAvailable) {
+				t.Errorf("available credit = %s, want %s", updated.AvailableCredit, tt.wantAvailable)
+			}
+
+			payments, err := service.GetPaymentsByCard(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to list payments: %v", err)
+			}
+			if len(payments) != 1 || payments[0].ID != payment.ID {
+				t.Errorf("unexpected payments for card: %+v", payments)
+			}
+		})
+	}
+}
+
+func TestPaymentService_ClearPaymentRollsBack(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	service := NewPaymentServiceWithStore(store)
+	initiated, err := service.InitiatePayment(ctx, InitiatePaymentRequest{
+		TenantID:      card.TenantID,
+		CreditCardID:  card.ID,
+		Amount:        decimal.NewFromInt(100),
+		PaymentType:   models.PaymentTypeRegular,
+		PaymentMethod: models.PaymentMethodACH,
+		CreatedBy:     "user",
+	})
+	if err != nil {
+		t.Fatalf("failed to initiate payment: %v", err)
+	}
+	paymentID := initiated.Payment.ID
+
+	if _, err := service.ProcessPayment(ctx, paymentID, "REF-1"); err != nil {
+		t.Fatalf("failed to process payment: %v", err)
+	}
+
+	failing := NewPaymentServiceWithStore(failingEntryStore{store})
+	if _, err := failing.ClearPayment(ctx, paymentID, "CONF-1"); err == nil {
+		t.Fatal("expected clear to fail")
+	}
+
+	// Neither the status change nor its transition survives the failed ledger write
+	stored, err := service.GetPayment(ctx, paymentID)
+	if err != nil {
+		t.Fatalf("failed to load payment: %v", err)
+	}
+	if stored.Status != models.PaymentStatusProcessing {
+		t.Errorf("status = %s, want processing", stored.Status)
+	}
+
+	history, err := service.GetPaymentHistory(ctx, paymentID)
+	if err != nil {
+		t.Fatalf("failed to load history: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("history has %d transitions, want 2", len(history))
+	}
+
+	if _, err := service.ClearPayment(ctx, paymentID, "CONF-1"); err != nil {
+		t.Errorf("retrying clear failed: %v", err)
+	}
+}
+
+func TestPaymentAllocation(t *testing.T) {
+	tests := []struct {
+		name string
+		// pay posts a payment of amount and returns its ledger entry
+		pay func(ctx context.Context, store repository.Store, card *models.CreditCard, amount decimal.Decimal) (*models.StatementLedgerEntry, error)
+		// undo returns or reverses the payment and returns the reversal entry
+		undo         func(ctx context.Context, store repository.Store, card *models.CreditCard, payment *models.StatementLedgerEntry) (*models.StatementLedgerEntry, error)
+		wantPurchase decimal.Decimal
+	}{
+		{
+			name: "Recorded payment returned",
+			pay: func(ctx context.Context, store repository.Store, card *models.CreditCard, amount decimal.Decimal) (*models.StatementLedgerEntry, error) {
+				result, err := NewCreditCardServiceWithStore(store).RecordPayment(ctx, CCPaymentRequest{
+					CreditCard:    card,
+					Amount:        amount,
+					PaymentDate:   time.Now(),
+					PostingDate:   time.Now(),
+					PaymentMethod: "ACH",
+					ReferenceID:   "PMT-1",
+				})
+				if err != nil {
+					return nil, err
+				}
+				return result.PaymentEntry, nil
+			},
+			undo: func(ctx context.Context, store repository.Store, card *models.CreditCard, payment *models.StatementLedgerEntry) (*models.StatementLedgerEntry, error) {
+				result, err := NewCreditCardServiceWithStore(store).RecordFailedPayment(ctx, FailedPaymentRequest{
+					CreditCard:      card,
+					OriginalPayment: payment,
+					FailureReason:   "NSF",
+					FailureDate:     time.Now(),
+				})
+				if err != nil {
+					return nil, err
+				}
+				return result.ReversalEntry, nil
+			},
+			// 1000 - 160 still paid, plus the failed payment fee
+			wantPurchase: decimal.NewFromInt(875),
+		},
+		{
+			name: "Cleared payment reversed",
+			pay: func(ctx context.Context, store repository.Store, card *models.CreditCard, amount decimal.Decimal) (*models.StatementLedgerEntry, error) {
+				service := NewPaymentServiceWithStore(store)
+				initiated, err := service.InitiatePayment(ctx, InitiatePaymentRequest{
+					TenantID:      card.TenantID,
+					CreditCardID:  card.ID,
+					Amount:        amount,
+					PaymentType:   models.PaymentTypeRegular,
+					PaymentMethod: models.PaymentMethodACH,
+					CreatedBy:     "user",
+				})
+				if err != nil {
+					return nil, err
+				}
+				if _, err := service.ProcessPayment(ctx, initiated.Payment.ID, "REF-1"); err != nil {
+					return nil, err
+				}
+				result, err := service.ClearPayment(ctx, initiated.Payment.ID, "CONF-1")
+				if err != nil {
+					return nil, err
+				}
+				return result.LedgerEntry, nil
+			},
+			undo: func(ctx context.Context, store repository.Store, card *models.CreditCard, payment *models.StatementLedgerEntry) (*models.StatementLedgerEntry, error) {
+				id, err := uuid.Parse(payment.Metadata["payment_id"].(string))
+				if err != nil {
+					return nil, err
+				}
+				result, err := NewPaymentServiceWithStore(store).ReversePayment(ctx, id, "Disputed", "ops")
+				if err != nil {
+					return nil, err
+				}
+				return result.LedgerEntry, nil
+			},
+			wantPurchase: decimal.NewFromInt(840),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			card.AvailableCredit = decimal.NewFromInt(3500)
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+
+			// 1000 of purchases at 19.99% and 500 of cash advances at 24.99%,
+			// on a statement with a 40 minimum payment
+			statementEnd := time.Now().AddDate(0, 0, -10)
+			for _, entry := range []*models.StatementLedgerEntry{
+				{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(1000)},
+				{EntryType: models.EntryTypeCashAdvance, Amount: decimal.NewFromInt(500)},
+			} {
+				entry.TenantID = card.TenantID
+				entry.EntryDate = statementEnd.AddDate(0, 0, -5)
+				entry.PostingDate = entry.EntryDate
+				entry.Status = models.EntryStatusCleared
+				if err := store.StatementEntries().Create(ctx, entry); err != nil {
+					t.Fatalf("failed to create entry: %v", err)
+				}
+			}
+			if err := store.BillingCycles().Create(ctx, &models.BillingCycle{
+				CreditCardID:   card.ID,
+				TenantID:       card.TenantID,
+				CycleNumber:    1,
+				CycleEndDate:   statementEnd,
+				NewBalance:     decimal.NewFromInt(1500),
+				MinimumPayment: decimal.NewFromInt(40),
+				Status:         models.BillingCycleStatusClosed,
+			}); err != nil {
+				t.Fatalf("failed to create cycle: %v", err)
+			}
+
+			// The first 40 covers the minimum on the lowest APR; the rest goes
+			// to the highest APR, and what is left of it back to purchases
+			wantFirst := models.SegmentAllocation{
+				models.SegmentPurchase:    decimal.NewFromInt(40),
+				models.SegmentCashAdvance: decimal.NewFromInt(260),
+			}
+			wantSecond := models.SegmentAllocation{
+				models.SegmentPurchase:    decimal.NewFromInt(160),
+				models.SegmentCashAdvance: decimal.NewFromInt(240),
+			}
+
+			first, err := tt.pay(ctx, store, card, decimal.NewFromInt(300))
+			if err != nil {
+				t.Fatalf("failed to post first payment: %v", err)
+			}
+			assertAllocation(t, first, wantFirst)
+
+			// The minimum is already met, so all of the second payment is excess
+			second, err := tt.pay(ctx, store, card, decimal.NewFromInt(400))
+			if err != nil {
+				t.Fatalf("failed to post second payment: %v", err)
+			}
+			assertAllocation(t, second, wantSecond)
+
+			reversal, err := tt.undo(ctx, store, card, first)
+			if err != nil {
+				t.Fatalf("failed to undo payment: %v", err)
+			}
+			assertAllocation(t, reversal, wantFirst)
+
+			entries, err := store.StatementEntries().List(ctx, repository.StatementEntryFilter{TenantID: &card.TenantID})
+			if err != nil {
+				t.Fatalf("failed to list entries: %v", err)
+			}
+			ledger := newSegmentLedger(card)
+			for _, entry := range entries {
+				ledger.post(entry)
+			}
+			want := models.SegmentBalances{
+				models.SegmentPurchase:    tt.wantPurchase,
+				models.SegmentCashAdvance: decimal.NewFromInt(260),
+			}
+			for segment, balance := range want {
+				if !ledger.balances[segment].Equal(balance) {
+					t.Errorf("%s balance = %s, want %s", segment, ledger.balances[segment], balance)
+				}
+			}
+		})
+	}
+}
+
+func assertAllocation(t *testing.T, entry *models.StatementLedgerEntry, want models.SegmentAllocation) {
+	t.Helper()
+	got, ok := models.SegmentAllocationFromMetadata(entry.Metadata)
+	if !ok {
+		t.Fatalf("entry %s has no allocation: %+v", entry.Description, entry.Metadata)
+	}
+	if len(got) != len(want) {
+		t.Errorf("allocation = %v, want %v", got, want)
+	}
+	for segment, amount := range want {
+		if !got[segment].Equal(amount) {
+			t.Errorf("%s allocation = %s, want %s", segment, got[segment], amount)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/shopspring/decimal"
+)
+
+// PayoffRequest describes a payoff calculation
+type PayoffRequest struct {
+	CreditCardID uuid.UUID       // Used by CalculateCardPayoff
+	Balance      decimal.Decimal // CalculateCardPayoff uses the card's cleared balance when zero
+	AsOf         time.Time       // Payments fall on this day of each following month
+
+	Strategy       models.PayoffStrategy
+	MonthlyPayment decimal.Decimal // Fixed payment strategy
+	TargetDate     time.Time       // Target date strategy; the last payment is on or before it
+
+	// AverageDailyBalanceMethod (the default) or DailyBalanceMethod
+	Method InterestCalculationMethod
+}
+
+// CalculateCardPayoff calculates a payoff schedule for a stored card,
+// starting from its cleared balance unless the request gives one
+func (s *InterestService) CalculateCardPayoff(ctx context.Context, req PayoffRequest) (*models.PayoffSchedule, error) {
+	card, err := s.store.CreditCards().GetByID(ctx, req.CreditCardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit card: %w", err)
+	}
+	if req.AsOf.IsZero() {
+		req.AsOf = time.Now()
+	}
+
+	if req.Balance.IsZero() {
+		asOf := req.AsOf
+		entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+			TenantID: &card.TenantID,
+			Statuses: []models.EntryStatus{models.EntryStatusCleared},
+			PostedTo: &asOf,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list statement entries: %w", err)
+		}
+		req.Balance = sumSignedAmounts(entries)
+	}
+
+	return s.CalculatePayoff(card, req)
+}
+
+// CalculatePayoff returns the month-by-month amortization schedule for
+// repaying a balance with no further charges. Unlike ProjectPayoff, which
+// follows the statement disclosure rules, months are calendar months: each
+// accrues interest for its actual days at the card's effective APR then,
+// using the request's interest calculation method.
+func (s *InterestService) CalculatePayoff(card *models.CreditCard, req PayoffRequest) (*models.PayoffSchedule, error) {
+	if !req.Balance.IsPositive() {
+		return nil, errors.New("payoff balance must be positive")
+	}
+	if req.AsOf.IsZero() {
+		req.AsOf = time.Now()
+	}
+	switch req.Method {
+	case "":
+		req.Method = AverageDailyBalanceMethod
+	case AverageDailyBalanceMethod, DailyBalanceMethod:
+	default:
+		return nil, fmt.Errorf("unsupported payoff interest method: %s", req.Method)
+	}
+
+	switch req.Strategy {
+	case models.PayoffStrategyFixedPayment:
+		if !req.MonthlyPayment.IsPositive() {
+			return nil, errors.New("monthly payment must be positive")
+		}
+		payment := req.MonthlyPayment.Round(2)
+		return s.amortize(card, req, func(decimal.Decimal) decimal.Decimal { return payment }), nil
+
+	case models.PayoffStrategyMinimumOnly:
+		return s.amortize(card, req, func(owed decimal.Decimal) decimal.Decimal {
+			return card.CalculateMinimumPayment(owed).Round(2)
+		}), nil
+
+	case models.PayoffStrategyTargetDate:
+		months := 0
+		for !payoffPaymentDate(req.AsOf, months+1).After(req.TargetDate) {
+			months++
+		}
+		if months == 0 {
+			return nil, errors.New("target date is less than a month away")
+		}
+		return s.amortizeByMonths(card, req, months), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported payoff strategy: %s", req.Strategy)
+	}
+}
+
+// amortizeByMonths finds the smallest level payment, to the cent, that
+// repays the balance within the given number of months
+func (s *InterestService) amortizeByMonths(card *models.CreditCard, req PayoffRequest, months int) *models.PayoffSchedule {
+	paysOff := func(payment decimal.Decimal) *models.PayoffSchedule {
+		schedule := s.amortize(card, req, func(decimal.Decimal) decimal.Decimal { return payment })
+		if schedule.NeverPaysOff || schedule.Months > months {
+			return nil
+		}
+		return schedule
+	}
+
+	// Paying the balance and the first month's interest always repays it
+	first := s.payoffMonthInterest(card, req, req.Balance, req.AsOf, payoffPaymentDate(req.AsOf, 1))
+	low, high := int64(0), req.Balance.Add(first).Shift(2).Ceil().IntPart()
+	best := paysOff(decimal.New(high, -2))
+	for low+1 < high {
+		mid := (low + high) / 2
+		if schedule := paysOff(decimal.New(mid, -2)); schedule != nil {
+			high, best = mid, schedule
+		} else {
+			low = mid
+		}
+	}
+	return best
+}
+
+// amortize builds the schedule for the payment paymentFor returns each
+// month, given the balance owed after that month's interest
+func (s *InterestService) amortize(
+	card *models.CreditCard,
+	req PayoffRequest,
+	paymentFor func(owed decimal.Decimal) decimal.Decimal,
+) *models.PayoffSchedule {
+	schedule := &models.PayoffSchedule{
+		Strategy: req.Strategy,
+		Balance:  req.Balance,
+		AsOf:     req.AsOf,
+	}
+
+	owed := req.Balance
+	periodStart := req.AsOf
+	for month := 1; owed.IsPositive(); month++ {
+		if month > models.PayoffMaxMonths {
+			schedule.NeverPaysOff = true
+			break
+		}
+
+		paymentDate := payoffPaymentDate(req.AsOf, month)
+		interest := s.payoffMonthInterest(card, req, owed, periodStart, paymentDate)
+		due := owed.Add(interest)
+		payment := decimal.Min(paymentFor(due), due)
+		if month == 1 {
+			schedule.MonthlyPayment = payment
+		}
+
+		row := models.PayoffScheduleMonth{
+			Month:          month,
+			PaymentDate:    paymentDate,
+			APR:            card.GetEffectiveAPR(periodStart),
+			OpeningBalance: owed,
+			Interest:       interest,
+			Payment:        payment,
+			Principal:      payment.Sub(interest),
+			ClosingBalance: due.Sub(payment),
+		}
+		schedule.Schedule = append(schedule.Schedule, row)
+		schedule.TotalInterest = schedule.TotalInterest.Add(interest)
+		schedule.TotalPaid = schedule.TotalPaid.Add(payment)
+
+		if row.ClosingBalance.IsPositive() && !row.Principal.IsPositive() {
+			// The balance never falls
+			schedule.NeverPaysOff = true
+			break
+		}
+		owed = row.ClosingBalance
+		periodStart = paymentDate
+	}
+
+	if schedule.NeverPaysOff {
+		return schedule
+	}
+	schedule.Months = len(schedule.Schedule)
+	payoffDate := schedule.Schedule[schedule.Months-1].PaymentDate
+	schedule.PayoffDate = &payoffDate
+	return schedule
+}
+
+// payoffMonthInterest is the interest on an unchanged balance from the start
+// of a month to its payment date
+func (s *InterestService) payoffMonthInterest(
+	card *models.CreditCard,
+	req PayoffRequest,
+	owed decimal.Decimal,
+	start, end time.Time,
+) decimal.Decimal {
+	dpr := card.GetDailyPeriodicRate(card.GetEffectiveAPR(start))
+	days := int(models.DateOf(end).Sub(models.DateOf(start)).Hours() / 24)
+
+	if req.Method == DailyBalanceMethod {
+		balances := make([]models.DailyBalanceRecord, days)
+		for i := range balances {
+			balances[i] = models.DailyBalanceRecord{Date: start.AddDate(0, 0, i), Balance: owed}
+		}
+		return s.calculateDailyBalanceInterest(balances, dpr)
+	}
+	return s.calculateADBInterest(owed, dpr, days)
+}
+
+// payoffPaymentDate returns the payment date the given number of months
+// after asOf, on the same day of the month or the month's last day
+func payoffPaymentDate(asOf time.Time, months int) time.Time {
+	date := asOf.AddDate(0, months, 0)
+	if date.Day() != asOf.Day() {
+		// Overflowed into the next month, e.g. January 31 plus one month
+		date = date.AddDate(0, 0, -date.Day())
+	}
+	return date
+}
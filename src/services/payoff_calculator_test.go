@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestInterestService_CalculatePayoff(t *testing.T) {
+	service := &InterestService{}
+	asOf := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		apr          decimal.Decimal
+		req          PayoffRequest
+		wantErr      bool
+		wantNever    bool
+		wantPayment  decimal.Decimal
+		wantMonths   int
+		wantInterest decimal.Decimal
+		wantPayoff   time.Time
+	}{
+		{
+			name: "Fixed payment",
+			apr:  decimal.NewFromFloat(18.25), // DPR 0.0005
+			req: PayoffRequest{
+				Balance:        decimal.NewFromInt(1000),
+				Strategy:       models.PayoffStrategyFixedPayment,
+				MonthlyPayment: decimal.NewFromInt(500),
+			},
+			// Feb 28: 1000 × 0.0005 × 28 = 14.00, leaving 514.00
+			// Mar 31: 514 × 0.0005 × 31 = 7.97, leaving 21.97
+			// Apr 30: 21.97 × 0.0005 × 30 = 0.33, paying 22.30
+			wantPayment:  decimal.NewFromInt(500),
+			wantMonths:   3,
+			wantInterest: decimal.NewFromFloat(22.30),
+			wantPayoff:   time.Date(2026, time.April, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Fixed payment, daily balance method",
+			apr:  decimal.NewFromFloat(18.25),
+			req: PayoffRequest{
+				Balance:        decimal.NewFromInt(1000),
+				Strategy:       models.PayoffStrategyFixedPayment,
+				MonthlyPayment: decimal.NewFromInt(500),
+				Method:         DailyBalanceMethod,
+			},
+			wantPayment:  decimal.NewFromInt(500),
+			wantMonths:   3,
+			wantInterest: decimal.NewFromFloat(22.30),
+			wantPayoff:   time.Date(2026, time.April, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Target date",
+			apr:  decimal.Zero,
+			req: PayoffRequest{
+				Balance:    decimal.NewFromInt(1000),
+				Strategy:   models.PayoffStrategyTargetDate,
+				TargetDate: time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC),
+			},
+			// 11 payments: 1000 / 11 = 90.91, with a smaller last payment
+			wantPayment:  decimal.NewFromFloat(90.91),
+			wantMonths:   11,
+			wantInterest: decimal.Zero,
+			wantPayoff:   time.Date(2026, time.December, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "Minimum payment below the interest",
+			apr:  decimal.NewFromFloat(29.99),
+			req: PayoffRequest{
+				Balance:  decimal.NewFromInt(2000),
+				Strategy: models.PayoffStrategyMinimumOnly,
+			},
+			wantNever: true,
+		},
+		{
+			name: "Target date too soon",
+			apr:  decimal.NewFromFloat(18.25),
+			req: PayoffRequest{
+				Balance:    decimal.NewFromInt(1000),
+				Strategy:   models.PayoffStrategyTargetDate,
+				TargetDate: asOf.AddDate(0, 0, 20),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Fixed payment missing",
+			apr:  decimal.NewFromFloat(18.25),
+			req: PayoffRequest{
+				Balance:  decimal.NewFromInt(1000),
+				Strategy: models.PayoffStrategyFixedPayment,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := models.CreditCardDefaults()
+			card.PurchaseAPR = tt.apr
+			card.MinimumPaymentPercent = decimal.NewFromInt(1)
+			card.MinimumPaymentAmount = decimal.Zero
+			tt.req.AsOf = asOf
+
+			schedule, err := service.CalculatePayoff(&card, tt.req)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if schedule.NeverPaysOff != tt.wantNever {
+				t.Fatalf("NeverPaysOff = %v, want %v", schedule.NeverPaysOff, tt.wantNever)
+			}
+			if tt.wantNever {
+				if schedule.Months != 0 || schedule.PayoffDate != nil {
+					t.Errorf("never paying off, got %d months ending %v", schedule.Months, schedule.PayoffDate)
+				}
+				return
+			}
+
+			if !schedule.MonthlyPayment.Equal(tt.wantPayment) {
+				t.Errorf("MonthlyPayment = %s, want %s", schedule.MonthlyPayment, tt.wantPayment)
+			}
+			if schedule.Months != tt.wantMonths || len(schedule.Schedule) != tt.wantMonths {
+				t.Errorf("Months = %d with %d rows, want %d", schedule.Months, len(schedule.Schedule), tt.wantMonths)
+			}
+			if !schedule.TotalInterest.Equal(tt.wantInterest) {
+				t.Errorf("TotalInterest = %s, want %s", schedule.TotalInterest, tt.wantInterest)
+			}
+			if !schedule.TotalPaid.Equal(tt.req.Balance.Add(tt.wantInterest)) {
+				t.Errorf("TotalPaid = %s, want balance plus interest", schedule.TotalPaid)
+			}
+			if schedule.PayoffDate == nil || !schedule.PayoffDate.Equal(tt.wantPayoff) {
+				t.Errorf("PayoffDate = %v, want %v", schedule.PayoffDate, tt.wantPayoff)
+			}
+
+			// Each month picks up where the last one left off
+			owed := tt.req.Balance
+			for _, month := range schedule.Schedule {
+				if !month.OpeningBalance.Equal(owed) {
+					t.Errorf("month %d opens at %s, want %s", month.Month, month.OpeningBalance, owed)
+				}
+				if !month.ClosingBalance.Equal(owed.Add(month.Interest).Sub(month.Payment)) {
+					t.Errorf("month %d closes at %s", month.Month, month.ClosingBalance)
+				}
+				owed = month.ClosingBalance
+			}
+			if !owed.IsZero() {
+				t.Errorf("final balance = %s, want 0", owed)
+			}
+		})
+	}
+}
+
+func TestInterestService_CalculatePayoffTargetDateIsSmallestPayment(t *testing.T) {
+	service := &InterestService{}
+	card := models.CreditCardDefaults()
+	asOf := time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)
+
+	schedule, err := service.CalculatePayoff(&card, PayoffRequest{
+		Balance:    decimal.NewFromInt(3000),
+		AsOf:       asOf,
+		Strategy:   models.PayoffStrategyTargetDate,
+		TargetDate: asOf.AddDate(2, 0, 0),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if schedule.Months != 24 {
+		t.Errorf("Months = %d, want 24", schedule.Months)
+	}
+
+	// A cent less misses the target date
+	lower, err := service.CalculatePayoff(&card, PayoffRequest{
+		Balance:        decimal.NewFromInt(3000),
+		AsOf:           asOf,
+		Strategy:       models.PayoffStrategyFixedPayment,
+		MonthlyPayment: schedule.MonthlyPayment.Sub(decimal.New(1, -2)),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lower.Months <= 24 {
+		t.Errorf("paying %s takes %d months, want more than 24", lower.MonthlyPayment, lower.Months)
+	}
+}
+
+func TestInterestService_CalculateCardPayoff(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	asOf := time.Date(2026, time.May, 31, 0, 0, 0, 0, time.UTC)
+
+	card := testCard()
+	card.PurchaseAPR = decimal.Zero
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	// Only cleared entries up to the as-of date count towards the balance
+	entries := []*models.StatementLedgerEntry{
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(1300), PostingDate: asOf.AddDate(0, 0, -20), Status: models.EntryStatusCleared},
+		{EntryType: models.EntryTypePayment, Amount: decimal.NewFromInt(100), PostingDate: asOf.AddDate(0, 0, -10), Status: models.EntryStatusCleared},
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(50), PostingDate: asOf.AddDate(0, 0, -5), Status: models.EntryStatusPending},
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(70), PostingDate: asOf.AddDate(0, 0, 2), Status: models.EntryStatusCleared},
+	}
+	for _, entry := range entries {
+		entry.TenantID = card.TenantID
+		entry.EntryDate = entry.PostingDate
+		if err := store.StatementEntries().Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create entry: %v", err)
+		}
+	}
+
+	schedule, err := NewInterestServiceWithStore(store).CalculateCardPayoff(ctx, PayoffRequest{
+		CreditCardID:   card.ID,
+		AsOf:           asOf,
+		Strategy:       models.PayoffStrategyFixedPayment,
+		MonthlyPayment: decimal.NewFromInt(100),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !schedule.Balance.Equal(decimal.NewFromInt(1200)) {
+		t.Errorf("Balance = %s, want 1200", schedule.Balance)
+	}
+	if schedule.Months != 12 {
+		t.Errorf("Months = %d, want 12", schedule.Months)
+	}
+}
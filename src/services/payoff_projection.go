@@ -0,0 +1,121 @@
+package services
+
+import (
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/shopspring/decimal"
+)
+
+// ProjectPayoff estimates how long repaying a statement balance takes, and
+// what it costs, paying only the minimum each month and paying it off in 36
+// months. Each month accrues interest on the whole remaining balance at the
+// card's effective APR then, so an introductory rate ends on schedule. No
+// further charges or fees are assumed. Returns nil when there is no balance
+// to repay.
+func (s *InterestService) ProjectPayoff(
+	card *models.CreditCard,
+	balance decimal.Decimal,
+	asOf time.Time,
+) *models.PayoffProjection {
+	if !balance.IsPositive() {
+		return nil
+	}
+
+	projection := &models.PayoffProjection{
+		Balance: balance,
+		APR:     card.GetEffectiveAPR(asOf),
+		MinimumOnly: s.simulatePayoff(card, balance, asOf, func(owed decimal.Decimal) decimal.Decimal {
+			return card.CalculateMinimumPayment(owed).Round(2)
+		}),
+	}
+	if projection.MinimumOnly.NeverPaysOff || projection.MinimumOnly.Months <= models.PayoffThirtySixMonths {
+		return projection
+	}
+
+	payment := s.amortizedPayment(card, balance, asOf, models.PayoffThirtySixMonths)
+	thirtySix := s.simulatePayoff(card, balance, asOf, func(decimal.Decimal) decimal.Decimal {
+		return payment
+	})
+	projection.ThirtySixMonth = &thirtySix
+	return projection
+}
+
+// simulatePayoff pays the balance down month by month. The first payment is
+// due on the current statement; interest on what is left then accrues until
+// the next one.
+func (s *InterestService) simulatePayoff(
+	card *models.CreditCard,
+	balance decimal.Decimal,
+	asOf time.Time,
+	paymentFor func(owed decimal.Decimal) decimal.Decimal,
+) models.PayoffScenario {
+	scenario := models.PayoffScenario{MonthlyPayment: decimal.Min(paymentFor(balance), balance)}
+
+	owed := balance
+	for owed.IsPositive() {
+		if scenario.Months == models.PayoffMaxMonths {
+			scenario.NeverPaysOff = true
+			break
+		}
+
+		payment := decimal.Min(paymentFor(owed), owed)
+		if !payment.IsPositive() {
+			scenario.NeverPaysOff = true
+			break
+		}
+		owed = owed.Sub(payment)
+		scenario.Months++
+		scenario.TotalCost = scenario.TotalCost.Add(payment)
+
+		interest := s.monthlyInterest(card, owed, asOf, scenario.Months)
+		if owed.IsPositive() && interest.GreaterThanOrEqual(payment) {
+			// The balance never falls
+			scenario.NeverPaysOff = true
+			break
+		}
+		owed = owed.Add(interest)
+		scenario.TotalInterest = scenario.TotalInterest.Add(interest)
+	}
+
+	if scenario.NeverPaysOff {
+		scenario.Months = 0
+		scenario.TotalInterest = decimal.Zero
+		scenario.TotalCost = decimal.Zero
+	}
+	return scenario
+}
+
+// monthlyInterest is the interest charged on the statement months after
+// asOf. Every month is a twelfth of a 365-day year, so the level 36-month
+// payment repays the balance exactly.
+func (s *InterestService) monthlyInterest(card *models.CreditCard, owed decimal.Decimal, asOf time.Time, months int) decimal.Decimal {
+	dpr := card.GetDailyPeriodicRate(card.GetEffectiveAPR(asOf.AddDate(0, months-1, 0)))
+	return owed.Mul(dpr).Mul(decimal.NewFromInt(365)).Div(decimal.NewFromInt(12)).Round(2)
+}
+
+// amortizedPayment returns the level payment, rounded up to the cent, that
+// repays the balance in the given number of months at the APR as of asOf
+func (s *InterestService) amortizedPayment(card *models.CreditCard, balance decimal.Decimal, asOf time.Time, months int) decimal.Decimal {
+	n := decimal.NewFromInt(int64(months))
+	rate := card.GetEffectiveAPR(asOf).Div(decimal.NewFromInt(1200))
+	if !rate.IsPositive() {
+		return roundUpCents(balance.Div(n))
+	}
+
+	// balance × r / (1 - (1 + r)^-n)
+	growth := decimal.NewFromInt(1)
+	for i := 0; i < months; i++ {
+		growth = growth.Mul(rate.Add(decimal.NewFromInt(1)))
+	}
+	payment := balance.Mul(rate).Mul(growth).Div(growth.Sub(decimal.NewFromInt(1)))
+	return roundUpCents(payment)
+}
+
+func roundUpCents(amount decimal.Decimal) decimal.Decimal {
+	rounded := amount.Round(2)
+	if rounded.LessThan(amount) {
+		rounded = rounded.Add(decimal.New(1, -2))
+	}
+	return rounded
+}
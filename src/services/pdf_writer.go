@@ -0,0 +1,174 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// pdfWriter draws text, lines and boxes onto US Letter pages and encodes
+// them as a PDF 1.4 file. It uses the standard Helvetica fonts, which every
+// reader provides, so nothing is embedded. The output has no timestamps or
+// random IDs; the same drawing always produces the same bytes.
+type pdfWriter struct {
+	pages   []*bytes.Buffer
+	current int // Page that drawing goes onto
+}
+
+const (
+	pdfPageWidth  = 612.0
+	pdfPageHeight = 792.0
+)
+
+// pdfFont names a font resource on every page
+type pdfFont string
+
+const (
+	pdfRegular pdfFont = "F1" // Helvetica
+	pdfBold    pdfFont = "F2" // Helvetica-Bold
+)
+
+// newPage starts a page; later drawing goes onto it
+func (w *pdfWriter) newPage() {
+	w.pages = append(w.pages, &bytes.Buffer{})
+	w.current = len(w.pages) - 1
+}
+
+// eachPage calls draw with each page in turn made current, for headers and
+// footers that need the page count
+func (w *pdfWriter) eachPage(draw func(page, pages int)) {
+	last := w.current
+	for i := range w.pages {
+		w.current = i
+		draw(i+1, len(w.pages))
+	}
+	w.current = last
+}
+
+func (w *pdfWriter) page() *bytes.Buffer {
+	if len(w.pages) == 0 {
+		w.newPage()
+	}
+	return w.pages[w.current]
+}
+
+// text draws s with its baseline starting at x, y (from the bottom left)
+func (w *pdfWriter) text(x, y float64, font pdfFont, size float64, s string) {
+	fmt.Fprintf(w.page(), "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfEscape(s))
+}
+
+// textRight draws s so that it ends at x
+func (w *pdfWriter) textRight(x, y float64, font pdfFont, size float64, s string) {
+	w.text(x-pdfTextWidth(s, size), y, font, size, s)
+}
+
+// line draws a line from x1, y1 to x2, y2
+func (w *pdfWriter) line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(w.page(), "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, y1, x2, y2)
+}
+
+// rect outlines a box with its bottom left corner at x, y
+func (w *pdfWriter) rect(x, y, width, height float64) {
+	fmt.Fprintf(w.page(), "1.00 w %.2f %.2f %.2f %.2f re S\n", x, y, width, height)
+}
+
+// bytes encodes the pages as a PDF file
+func (w *pdfWriter) bytes(title string) []byte {
+	if len(w.pages) == 0 {
+		w.newPage()
+	}
+
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1-5 are fixed; each page is then a page and a content stream
+	const firstPage = 6
+	kids := make([]string, len(w.pages))
+	for i := range w.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+
+	out.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(w.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	object(fmt.Sprintf("<< /Title (%s) /Producer (ez-ledger) >>", pdfEscape(title)))
+	for i, content := range w.pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, firstPage+2*i+1))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R /Info 5 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return out.Bytes()
+}
+
+// pdfEscape escapes a string literal. Characters outside ASCII are not in
+// the fonts' encoding and are replaced with '?'.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 32 || r > 126:
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// pdfTextWidth returns the width of s in Helvetica at size. The bold face is
+// measured with the same widths, which is close enough for aligning amounts.
+func pdfTextWidth(s string, size float64) float64 {
+	units := 0
+	for _, r := range s {
+		if r >= 32 && r <= 126 {
+			units += helveticaWidths[r-32]
+		} else {
+			units += helveticaWidths['?'-32]
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// pdfFit shortens s with an ellipsis until it is no wider than width
+func pdfFit(s string, size, width float64) string {
+	if pdfTextWidth(s, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pdfTextWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// helveticaWidths are the Helvetica advance widths of ASCII 32-126, in
+// thousandths of the font size
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0-9
+	278, 278, 584, 584, 584, 556, 1015, // : - @
+	667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A-M
+	722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N-Z
+	278, 278, 278, 469, 556, 333, // [ - `
+	556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a-m
+	556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n-z
+	334, 260, 334, 584, // { - ~
+}
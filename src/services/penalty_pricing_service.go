@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+)
+
+// PenaltyPricingService applies the penalty APR when a card hits a penalty
+// trigger and restores the prior APRs once the cardholder has cured it
+type PenaltyPricingService struct {
+	store             repository.Store
+	creditCardService *CreditCardService
+}
+
+// NewPenaltyPricingService creates a new penalty pricing service
+func NewPenaltyPricingService(db Querier) *PenaltyPricingService {
+	return NewPenaltyPricingServiceWithStore(postgres.NewStore(db))
+}
+
+// NewPenaltyPricingServiceWithStore creates a penalty pricing service backed by store
+func NewPenaltyPricingServiceWithStore(store repository.Store) *PenaltyPricingService {
+	return &PenaltyPricingService{
+		store:             store,
+		creditCardService: NewCreditCardServiceWithStore(store),
+	}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *PenaltyPricingService) withStore(store repository.Store) *PenaltyPricingService {
+	return NewPenaltyPricingServiceWithStore(store)
+}
+
+// PenaltyPricingConfig sets what puts a card on its penalty APR and what
+// takes it off again
+type PenaltyPricingConfig struct {
+	LateDays        int  // A minimum payment this many days past due triggers the penalty APR; zero disables
+	ReturnedPayment bool // A returned payment triggers the penalty APR, repricing existing balances too
+	CurePayments    int  // Consecutive on-time minimum payments that restore the prior APRs; zero never cures
+}
+
+// DefaultPenaltyPricingConfig returns the Regulation Z penalty rules: 60 days
+// late before existing balances can be repriced, and a cure after six
+// consecutive on-time minimum payments. The penalty APR reprices existing
+// balances, which a returned payment does not allow, so it is not a trigger.
+func DefaultPenaltyPricingConfig() PenaltyPricingConfig {
+	return PenaltyPricingConfig{
+		LateDays:        60,
+		ReturnedPayment: false,
+		CurePayments:    6,
+	}
+}
+
+// penaltyAPRTypes are the rates the penalty APR replaces
+var penaltyAPRTypes = []models.APRType{models.APRTypePurchase, models.APRTypeCashAdvance}
+
+// ReviewPenaltyPricing reviews every open card as of asOf and returns the
+// penalty events recorded. Each card is reviewed in its own transaction, and
+// a card that fails does not stop the others: the events recorded are
+// returned along with an error naming each card that failed.
+func (s *PenaltyPricingService) ReviewPenaltyPricing(
+	ctx context.Context,
+	asOf time.Time,
+	config PenaltyPricingConfig,
+) ([]*models.PenaltyEvent, error) {
+	cards, err := s.store.CreditCards().List(ctx, repository.CreditCardFilter{
+		Statuses: []models.CreditCardStatus{
+			models.CreditCardStatusActive,
+			models.CreditCardStatusFrozen,
+			models.CreditCardStatusDelinquent,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list credit cards: %w", err)
+	}
+
+	var (
+		events   []*models.PenaltyEvent
+		failures []error
+	)
+	for _, card := range cards {
+		event, err := s.ReviewCardPenaltyPricing(ctx, card.ID, asOf, config)
+		if err != nil {
+			failures = append(failures, fmt.Errorf("failed to review penalty pricing for card %s: %w", card.ID, err))
+			continue
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, errors.Join(failures...)
+}
+
+// ReviewCardPenaltyPricing puts a card on its penalty APR if it has hit a
+// trigger since its last penalty event, or takes it off once enough
+// consecutive minimum payments have been made on time. It returns the event
+// recorded, or nil when nothing changed.
+//
+// The penalty APR replaces the purchase and cash advance APRs from 45 days
+// after the review, with a change-in-terms notice for each. The cure counts
+// payments due after the penalty APR took effect and restores the prior APRs
+// from the review date.
+func (s *PenaltyPricingService) ReviewCardPenaltyPricing(
+	ctx context.Context,
+	cardID uuid.UUID,
+	asOf time.Time,
+	config PenaltyPricingConfig,
+) (*models.PenaltyEvent, error) {
+	var event *models.PenaltyEvent
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		card, err := tx.CreditCards().GetForUpdate(ctx, cardID)
+		if err != nil {
+			return fmt.Errorf("failed to get credit card: %w", err)
+		}
+		if card.IsChargedOff() {
+			return nil
+		}
+
+		history, err := tx.PenaltyEvents().List(ctx, cardID)
+		if err != nil {
+			return fmt.Errorf("failed to list penalty events: %w", err)
+		}
+		var last *models.PenaltyEvent
+		if len(history) > 0 {
+			last = history[len(history)-1]
+		}
+
+		if last != nil && last.IsApplied() {
+			event, err = txs.cure(ctx, card, last, asOf, config)
+		} else {
+			event, err = txs.trigger(ctx, card, last, asOf, config)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// ListPenaltyEvents returns a card's penalty events, oldest first
+func (s *PenaltyPricingService) ListPenaltyEvents(ctx context.Context, cardID uuid.UUID) ([]*models.PenaltyEvent, error) {
+	events, err := s.store.PenaltyEvents().List(ctx, cardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list penalty events: %w", err)
+	}
+	return events, nil
+}
+
+// trigger applies the penalty APR for the earliest trigger after the card's
+// last penalty event, if there is one by asOf
+func (s *PenaltyPricingService) trigger(
+	ctx context.Context,
+	card *models.CreditCard,
+	last *models.PenaltyEvent,
+	asOf time.Time,
+	config PenaltyPricingConfig,
+) (*models.PenaltyEvent, error) {
+	var found *models.PenaltyEvent
+	consider := func(candidate *models.PenaltyEvent) {
+		if candidate.OccurredAt.After(asOf) || (last != nil && !candidate.OccurredAt.After(last.OccurredAt)) {
+			return
+		}
+		if found == nil || candidate.OccurredAt.Before(found.OccurredAt) {
+			found = candidate
+		}
+	}
+
+	if config.LateDays > 0 {
+		unpaid := false
+		cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{
+			CreditCardID: &card.ID,
+			Statuses: []models.BillingCycleStatus{
+				models.BillingCycleStatusClosed,
+				models.BillingCycleStatusPastDue,
+				models.BillingCycleStatusDelinquent,
+			},
+			MinimumPaymentMet: &unpaid,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list billing cycles: %w", err)
+		}
+		for _, cycle := range cycles {
+			if !cycle.MinimumPayment.IsPositive() {
+				continue
+			}
+			cycleID := cycle.ID
+			consider(&models.PenaltyEvent{
+				Trigger:        models.PenaltyTriggerLatePayment,
+				BillingCycleID: &cycleID,
+				DaysLate:       cycle.DaysOverdue(asOf),
+				OccurredAt:     models.DateOf(cycle.DueDate).AddDate(0, 0, config.LateDays),
+			})
+		}
+	}
+
+	if config.ReturnedPayment {
+		payments, err := s.store.Payments().List(ctx, repository.PaymentFilter{
+			CreditCardID: &card.ID,
+			Statuses:     []models.PaymentStatus{models.PaymentStatusReturned},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list payments: %w", err)
+		}
+		for _, payment := range payments {
+			if payment.ReturnedAt == nil {
+				continue
+			}
+			paymentID := payment.ID
+			consider(&models.PenaltyEvent{
+				Trigger:    models.PenaltyTriggerReturnedPayment,
+				PaymentID:  &paymentID,
+				OccurredAt: *payment.ReturnedAt,
+			})
+		}
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	// Penalty increases need the same advance notice as any other
+	found.EffectiveDate = models.DateOf(asOf).AddDate(0, 0, models.ChangeInTermsNoticeDays)
+	found.EventType = models.PenaltyEventApplied
+	found.PenaltyAPR = card.APROn(models.APRTypePenalty, found.EffectiveDate)
+	found.PriorPurchaseAPR = card.APROn(models.APRTypePurchase, found.EffectiveDate)
+	found.PriorCashAdvanceAPR = card.APROn(models.APRTypeCashAdvance, found.EffectiveDate)
+
+	for _, aprType := range penaltyAPRTypes {
+		if !found.PenaltyAPR.GreaterThan(card.APROn(aprType, found.EffectiveDate)) {
+			continue
+		}
+		_, err := s.creditCardService.ScheduleAPRChange(ctx, APRChangeRequest{
+			CreditCardID:  card.ID,
+			APRType:       aprType,
+			APR:           found.PenaltyAPR,
+			EffectiveDate: found.EffectiveDate,
+			NoticeDate:    asOf,
+			Reason:        fmt.Sprintf("Penalty APR: %s", found.Trigger),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to schedule penalty APR: %w", err)
+		}
+	}
+
+	if err := s.createEvent(ctx, card, found); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// cure restores the APRs the penalty replaced once the minimum payments due
+// since it took effect have been made on time CurePayments times in a row
+func (s *PenaltyPricingService) cure(
+	ctx context.Context,
+	card *models.CreditCard,
+	applied *models.PenaltyEvent,
+	asOf time.Time,
+	config PenaltyPricingConfig,
+) (*models.PenaltyEvent, error) {
+	if config.CurePayments <= 0 {
+		return nil, nil
+	}
+
+	cycles, err := s.store.BillingCycles().List(ctx, repository.BillingCycleFilter{CreditCardID: &card.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billing cycles: %w", err)
+	}
+
+	onTime := 0
+	for _, cycle := range cycles {
+		// Count from the first payment due after the penalty APR took effect
+		if !cycle.DueDate.After(applied.EffectiveDate) || cycle.DueDate.After(asOf) ||
+			!cycle.MinimumPayment.IsPositive() {
+			continue
+		}
+		if cycle.MinimumPaidOnTime() {
+			onTime++
+		} else {
+			onTime = 0
+		}
+	}
+	if onTime < config.CurePayments {
+		return nil, nil
+	}
+
+	cured := &models.PenaltyEvent{
+		EventType:           models.PenaltyEventCured,
+		OnTimePayments:      onTime,
+		PenaltyAPR:          applied.PenaltyAPR,
+		PriorPurchaseAPR:    applied.PriorPurchaseAPR,
+		PriorCashAdvanceAPR: applied.PriorCashAdvanceAPR,
+		OccurredAt:          asOf,
+		EffectiveDate:       models.DateOf(asOf),
+	}
+
+	for _, aprType := range penaltyAPRTypes {
+		restored := applied.PriorPurchaseAPR
+		if aprType == models.APRTypeCashAdvance {
+			restored = applied.PriorCashAdvanceAPR
+		}
+		if !card.APROn(aprType, cured.EffectiveDate).GreaterThan(restored) {
+			continue
+		}
+		_, err := s.creditCardService.ScheduleAPRChange(ctx, APRChangeRequest{
+			CreditCardID:  card.ID,
+			APRType:       aprType,
+			APR:           restored,
+			EffectiveDate: cured.EffectiveDate,
+			NoticeDate:    asOf,
+			Reason:        fmt.Sprintf("Penalty APR cured after %d on-time payments", onTime),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore APR: %w", err)
+		}
+	}
+
+	if err := s.createEvent(ctx, card, cured); err != nil {
+		return nil, err
+	}
+	return cured, nil
+}
+
+// createEvent stores a penalty event for the card
+func (s *PenaltyPricingService) createEvent(ctx context.Context, card *models.CreditCard, event *models.PenaltyEvent) error {
+	event.ID = uuid.New()
+	event.TenantID = card.TenantID
+	event.CreditCardID = card.ID
+	event.CreatedAt = time.Now()
+
+	if err := s.store.PenaltyEvents().Create(ctx, event); err != nil {
+		return fmt.Errorf("failed to create penalty event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func penaltyDate(month time.Month, day int) time.Time {
+	return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// createDueCycle stores a closed cycle with a $35 minimum due on dueDate,
+// paid on paidOn or unpaid when paidOn is nil
+func createDueCycle(t *testing.T, store *memory.Store, card *models.CreditCard, number int, dueDate time.Time, paidOn *time.Time) {
+	t.Helper()
+	cycle := &models.BillingCycle{
+		ID:             uuid.New(),
+		CreditCardID:   card.ID,
+		TenantID:       card.TenantID,
+		CycleNumber:    number,
+		CycleStartDate: dueDate.AddDate(0, -1, -25),
+		CycleEndDate:   dueDate.AddDate(0, 0, -25),
+		DueDate:        dueDate,
+		NewBalance:     decimal.NewFromInt(1000),
+		MinimumPayment: decimal.NewFromInt(35),
+		Status:         models.BillingCycleStatusClosed,
+	}
+	if paidOn != nil {
+		cycle.PaymentsMade = cycle.MinimumPayment
+		cycle.LastPaymentDate = paidOn
+		cycle.LastPaymentAmount = cycle.MinimumPayment
+		cycle.MinimumPaymentMet = true
+		cycle.Status = models.BillingCycleStatusPaid
+	}
+	if err := store.BillingCycles().Create(context.Background(), cycle); err != nil {
+		t.Fatalf("failed to create billing cycle: %v", err)
+	}
+}
+
+// createReturnedPayment stores a payment returned on March 3
+func createReturnedPayment(t *testing.T, store *memory.Store, card *models.CreditCard) {
+	t.Helper()
+	returnedAt := penaltyDate(time.March, 3)
+	err := store.Payments().Create(context.Background(), &models.Payment{
+		ID:            uuid.New(),
+		TenantID:      card.TenantID,
+		CreditCardID:  card.ID,
+		PaymentNumber: "PAY-RETURNED",
+		Amount:        decimal.NewFromInt(200),
+		Status:        models.PaymentStatusReturned,
+		ReturnedAt:    &returnedAt,
+		InitiatedAt:   returnedAt.AddDate(0, 0, -3),
+	})
+	if err != nil {
+		t.Fatalf("failed to create payment: %v", err)
+	}
+}
+
+func TestPenaltyPricingService_ReviewCardPenaltyPricingTriggers(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(t *testing.T, store *memory.Store, card *models.CreditCard)
+		asOf        time.Time
+		config      PenaltyPricingConfig
+		wantTrigger models.PenaltyTrigger // Empty when no penalty is applied
+	}{
+		{
+			name: "Minimum payment 59 days late",
+			setup: func(t *testing.T, store *memory.Store, card *models.CreditCard) {
+				createDueCycle(t, store, card, 1, penaltyDate(time.January, 25), nil)
+			},
+			asOf:   penaltyDate(time.March, 25),
+			config: DefaultPenaltyPricingConfig(),
+		},
+		{
+			name: "Minimum payment 60 days late",
+			setup: func(t *testing.T, store *memory.Store, card *models.CreditCard) {
+				createDueCycle(t, store, card, 1, penaltyDate(time.January, 25), nil)
+			},
+			asOf:        penaltyDate(time.March, 26),
+			config:      DefaultPenaltyPricingConfig(),
+			wantTrigger: models.PenaltyTriggerLatePayment,
+		},
+		{
+			name: "Late payment trigger disabled",
+			setup: func(t *testing.T, store *memory.Store, card *models.CreditCard) {
+				createDueCycle(t, store, card, 1, penaltyDate(time.January, 25), nil)
+			},
+			asOf:   penaltyDate(time.March, 26),
+			config: PenaltyPricingConfig{ReturnedPayment: true, CurePayments: 6},
+		},
+		{
+			name:   "Returned payment under the default rules",
+			setup:  createReturnedPayment,
+			asOf:   penaltyDate(time.March, 26),
+			config: DefaultPenaltyPricingConfig(),
+		},
+		{
+			name:        "Returned payment trigger enabled",
+			setup:       createReturnedPayment,
+			asOf:        penaltyDate(time.March, 26),
+			config:      PenaltyPricingConfig{ReturnedPayment: true, CurePayments: 6},
+			wantTrigger: models.PenaltyTriggerReturnedPayment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+			tt.setup(t, store, card)
+			service := NewPenaltyPricingServiceWithStore(store)
+
+			event, err := service.ReviewCardPenaltyPricing(ctx, card.ID, tt.asOf, tt.config)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantTrigger == "" {
+				if event != nil {
+					t.Fatalf("unexpected penalty event: %+v", event)
+				}
+				return
+			}
+			if event == nil || event.Trigger != tt.wantTrigger || !event.IsApplied() {
+				t.Fatalf("event = %+v, want %s applied", event, tt.wantTrigger)
+			}
+
+			// The penalty APR takes effect after 45 days' notice
+			effective := tt.asOf.AddDate(0, 0, models.ChangeInTermsNoticeDays)
+			if !event.EffectiveDate.Equal(effective) {
+				t.Errorf("effective date = %s, want %s", event.EffectiveDate, effective)
+			}
+			stored, err := store.CreditCards().GetByID(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to load card: %v", err)
+			}
+			for _, aprType := range []models.APRType{models.APRTypePurchase, models.APRTypeCashAdvance} {
+				if got := stored.APROn(aprType, effective.AddDate(0, 0, -1)); got.Equal(card.PenaltyAPR) {
+					t.Errorf("%s APR before the effective date = %s, want the prior rate", aprType, got)
+				}
+				if got := stored.APROn(aprType, effective); !got.Equal(card.PenaltyAPR) {
+					t.Errorf("%s APR = %s, want penalty APR %s", aprType, got, card.PenaltyAPR)
+				}
+			}
+			notices, err := NewCreditCardServiceWithStore(store).ListChangeInTermsNotices(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to list notices: %v", err)
+			}
+			if len(notices) != 2 {
+				t.Errorf("notices = %d, want one each for purchases and cash advances", len(notices))
+			}
+
+			// The same trigger does not apply the penalty twice
+			again, err := service.ReviewCardPenaltyPricing(ctx, card.ID, tt.asOf.AddDate(0, 0, 1), tt.config)
+			if err != nil || again != nil {
+				t.Errorf("second review = %+v, %v; want nothing", again, err)
+			}
+		})
+	}
+}
+
+func TestPenaltyPricingService_ReviewPenaltyPricingReportsFailedCards(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	cards := []*models.CreditCard{testCard(), testCard(), testCard()}
+	for _, card := range cards {
+		if err := store.CreditCards().Create(ctx, card); err != nil {
+			t.Fatalf("failed to create card: %v", err)
+		}
+		createDueCycle(t, store, card, 1, penaltyDate(time.January, 25), nil)
+	}
+	failing := cards[1]
+
+	service := NewPenaltyPricingServiceWithStore(failingCardStore{store, failing.ID})
+	events, err := service.ReviewPenaltyPricing(ctx, penaltyDate(time.March, 26), DefaultPenaltyPricingConfig())
+	if err == nil || !strings.Contains(err.Error(), failing.ID.String()) {
+		t.Errorf("error = %v, want one naming card %s", err, failing.ID)
+	}
+
+	// Every other card was still reviewed and committed
+	if len(events) != len(cards)-1 {
+		t.Fatalf("events = %d, want %d", len(events), len(cards)-1)
+	}
+	for _, card := range cards {
+		history, err := store.PenaltyEvents().List(ctx, card.ID)
+		if err != nil {
+			t.Fatalf("failed to list penalty events: %v", err)
+		}
+		if applied := len(history) == 1; applied == (card.ID == failing.ID) {
+			t.Errorf("card %s has %d penalty events", card.ID, len(history))
+		}
+	}
+}
+
+func TestPenaltyPricingService_ReturnedPaymentKeepsExistingBalanceAPR(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	posted := penaltyDate(time.January, 5)
+	if _, err := NewCreditCardServiceWithStore(store).RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:      card,
+		Amount:          decimal.NewFromInt(1000),
+		Description:     "Furniture",
+		MerchantName:    "Test Merchant",
+		TransactionDate: posted,
+		PostingDate:     posted,
+	}); err != nil {
+		t.Fatalf("failed to record purchase: %v", err)
+	}
+	createReturnedPayment(t, store, card)
+
+	asOf := penaltyDate(time.March, 26)
+	event, err := NewPenaltyPricingServiceWithStore(store).ReviewCardPenaltyPricing(ctx, card.ID, asOf, DefaultPenaltyPricingConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if event != nil {
+		t.Fatalf("returned payment applied the penalty APR: %+v", event)
+	}
+
+	// Well past any notice period, the balance still accrues at its contract rates
+	stored, err := loadAPRSchedule(ctx, store, card)
+	if err != nil {
+		t.Fatalf("failed to load APR history: %v", err)
+	}
+	later := asOf.AddDate(0, 3, 0)
+	if got := stored.SegmentAPR(models.SegmentPurchase, later); !got.Equal(card.PurchaseAPR) {
+		t.Errorf("purchase APR = %s, want %s", got, card.PurchaseAPR)
+	}
+	if got := stored.SegmentAPR(models.SegmentCashAdvance, later); !got.Equal(card.CashAdvanceAPR) {
+		t.Errorf("cash advance APR = %s, want %s", got, card.CashAdvanceAPR)
+	}
+}
+
+func TestPenaltyPricingService_ReviewCardPenaltyPricingCures(t *testing.T) {
+	tests := []struct {
+		name     string
+		lateDue  int // Index of the one cycle paid late, or -1
+		wantCure bool
+	}{
+		{name: "Six on-time payments", lateDue: -1, wantCure: true},
+		{name: "Fifth payment late", lateDue: 4, wantCure: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store := memory.NewStore()
+			card := testCard()
+			if err := store.CreditCards().Create(ctx, card); err != nil {
+				t.Fatalf("failed to create card: %v", err)
+			}
+			createDueCycle(t, store, card, 1, penaltyDate(time.January, 25), nil)
+			service := NewPenaltyPricingServiceWithStore(store)
+
+			// Penalty APR from May 10
+			applied, err := service.ReviewCardPenaltyPricing(ctx, card.ID, penaltyDate(time.March, 26), DefaultPenaltyPricingConfig())
+			if err != nil || applied == nil {
+				t.Fatalf("failed to apply penalty APR: %+v, %v", applied, err)
+			}
+
+			// Payments due June through November
+			for i := 0; i < 6; i++ {
+				dueDate := penaltyDate(time.June+time.Month(i), 1)
+				paidOn := dueDate.AddDate(0, 0, -2)
+				if i == tt.lateDue {
+					paidOn = dueDate.AddDate(0, 0, 3)
+				}
+				createDueCycle(t, store, card, i+2, dueDate, &paidOn)
+			}
+
+			asOf := penaltyDate(time.November, 2)
+			cured, err := service.ReviewCardPenaltyPricing(ctx, card.ID, asOf, DefaultPenaltyPricingConfig())
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tt.wantCure {
+				if cured != nil {
+					t.Fatalf("unexpected cure: %+v", cured)
+				}
+				return
+			}
+			if cured == nil || cured.EventType != models.PenaltyEventCured || cured.OnTimePayments != 6 {
+				t.Fatalf("event = %+v, want a cure after 6 payments", cured)
+			}
+
+			stored, err := store.CreditCards().GetByID(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to load card: %v", err)
+			}
+			if got := stored.APROn(models.APRTypePurchase, asOf); !got.Equal(applied.PriorPurchaseAPR) {
+				t.Errorf("purchase APR = %s, want %s restored", got, applied.PriorPurchaseAPR)
+			}
+			if got := stored.APROn(models.APRTypeCashAdvance, asOf); !got.Equal(applied.PriorCashAdvanceAPR) {
+				t.Errorf("cash advance APR = %s, want %s restored", got, applied.PriorCashAdvanceAPR)
+			}
+			if got := stored.APROn(models.APRTypePurchase, penaltyDate(time.July, 1)); !got.Equal(card.PenaltyAPR) {
+				t.Errorf("purchase APR during the penalty = %s, want %s", got, card.PenaltyAPR)
+			}
+
+			events, err := service.ListPenaltyEvents(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("failed to list penalty events: %v", err)
+			}
+			if len(events) != 2 || !events[0].IsApplied() || events[1].IsApplied() {
+				t.Errorf("events = %+v, want applied then cured", events)
+			}
+		})
+	}
+}
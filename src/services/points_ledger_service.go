@@ -2,133 +2,81 @@ package services
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 )
 
 // PointsLedgerService handles points ledger operations
 type PointsLedgerService struct {
-	db *sql.DB
+	store repository.Store
 }
 
 // NewPointsLedgerService creates a new points ledger service
-func NewPointsLedgerService(db *sql.DB) *PointsLedgerService {
-	return &PointsLedgerService{db: db}
+func NewPointsLedgerService(db Querier) *PointsLedgerService {
+	return NewPointsLedgerServiceWithStore(postgres.NewStore(db))
+}
+
+// NewPointsLedgerServiceWithStore creates a points ledger service backed by store
+func NewPointsLedgerServiceWithStore(store repository.Store) *PointsLedgerService {
+	return &PointsLedgerService{store: store}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *PointsLedgerService) withStore(store repository.Store) *PointsLedgerService {
+	return NewPointsLedgerServiceWithStore(store)
 }
 
 // CreateEntry creates a new points ledger entry
 func (s *PointsLedgerService) CreateEntry(ctx context.Context, entry *models.PointsLedgerEntry) error {
-	query := `
-		INSERT INTO points_ledger_entries (
-			id, tenant_id, statement_entry_id, entry_type, entry_date,
-			points, description, external_platform, external_reference_id,
-			transaction_amount, points_rate, metadata, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-	`
-
-	if entry.ID == uuid.Nil {
-		entry.ID = uuid.New()
-	}
-
-	_, err := s.db.ExecContext(ctx, query,
-		entry.ID,
-		entry.TenantID,
-		entry.StatementEntryID,
-		entry.EntryType,
-		entry.EntryDate,
-		entry.Points,
-		entry.Description,
-		entry.ExternalPlatform,
-		entry.ExternalReferenceID,
-		entry.TransactionAmount,
-		entry.PointsRate,
-		entry.Metadata,
-		entry.CreatedBy,
-	)
-
-	return err
+	return s.store.PointsEntries().Create(ctx, entry)
 }
 
 // GetBalance calculates the current points balance for a tenant
 func (s *PointsLedgerService) GetBalance(ctx context.Context, tenantID uuid.UUID) (*models.PointsBalance, error) {
-	query := `SELECT * FROM points_balances WHERE tenant_id = $1`
-
-	var balance models.PointsBalance
-	err := s.db.QueryRowContext(ctx, query, tenantID).Scan(
-		&balance.TenantID,
-		&balance.EarnedPoints,
-		&balance.RedeemedPoints,
-		&balance.AvailablePoints,
-		&balance.TotalEntries,
-		&balance.LastActivityDate,
-	)
-
-	if err == sql.ErrNoRows {
-		// No entries yet, return zero balance
-		return &models.PointsBalance{
-			TenantID:        tenantID,
-			EarnedPoints:    0,
-			RedeemedPoints:  0,
-			AvailablePoints: 0,
-			TotalEntries:    0,
-		}, nil
-	}
-
+	entries, err := s.store.PointsEntries().List(ctx, repository.PointsEntryFilter{
+		TenantID: &tenantID,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &balance, nil
-}
-
-// GetEntriesByTenant retrieves all points entries for a tenant
-func (s *PointsLedgerService) GetEntriesByTenant(ctx context.Context, tenantID uuid.UUID, limit int) ([]*models.PointsLedgerEntry, error) {
-	query := `
-		SELECT id, tenant_id, statement_entry_id, entry_type, entry_date,
-		       points, description, external_platform, external_reference_id,
-		       transaction_amount, points_rate, metadata, created_at, created_by
-		FROM points_ledger_entries
-		WHERE tenant_id = $1
-		ORDER BY entry_date DESC, created_at DESC
-		LIMIT $2
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, tenantID, limit)
-	if err != nil {
-		return nil, err
+	// No entries yet gives a zero balance
+	balance := &models.PointsBalance{
+		TenantID:     tenantID,
+		TotalEntries: len(entries),
 	}
-	defer rows.Close()
-
-	var entries []*models.PointsLedgerEntry
-	for rows.Next() {
-		entry := &models.PointsLedgerEntry{}
-		err := rows.Scan(
-			&entry.ID,
-			&entry.TenantID,
-			&entry.StatementEntryID,
-			&entry.EntryType,
-			&entry.EntryDate,
-			&entry.Points,
-			&entry.Description,
-			&entry.ExternalPlatform,
-			&entry.ExternalReferenceID,
-			&entry.TransactionAmount,
-			&entry.PointsRate,
-			&entry.Metadata,
-			&entry.CreatedAt,
-			&entry.CreatedBy,
-		)
-		if err != nil {
-			return nil, err
+
+	for _, entry := range entries {
+		switch entry.EntryType {
+		case models.PointsEarnedTransaction, models.PointsEarnedRefund:
+			balance.EarnedPoints += entry.Points
+		case models.PointsRedeemedSpent:
+			balance.RedeemedPoints += absInt(entry.Points)
+		case models.PointsRedeemedCancelled, models.PointsRedeemedRefunded:
+			// Cancelled and refunded redemptions give the points back
+			balance.RedeemedPoints -= absInt(entry.Points)
+		}
+
+		balance.AvailablePoints += entry.Points
+		if entry.EntryDate.After(balance.LastActivityDate) {
+			balance.LastActivityDate = entry.EntryDate
 		}
-		entries = append(entries, entry)
 	}
 
-	return entries, rows.Err()
+	return balance, nil
+}
+
+// GetEntriesByTenant retrieves the most recent points entries for a tenant
+func (s *PointsLedgerService) GetEntriesByTenant(ctx context.Context, tenantID uuid.UUID, limit int) ([]*models.PointsLedgerEntry, error) {
+	return s.store.PointsEntries().List(ctx, repository.PointsEntryFilter{
+		TenantID: &tenantID,
+		Limit:    limit,
+	})
 }
 
 // ValidateRedemption checks if tenant has enough points for redemption
@@ -155,11 +103,6 @@ func (s *PointsLedgerService) RecordRedemption(
 	externalRefID string,
 	description string,
 ) (*models.PointsLedgerEntry, error) {
-	// Validate redemption
-	if err := s.ValidateRedemption(ctx, tenantID, pointsSpent); err != nil {
-		return nil, err
-	}
-
 	// Create redemption entry (negative points)
 	entry := &models.PointsLedgerEntry{
 		TenantID:            tenantID,
@@ -171,9 +114,27 @@ func (s *PointsLedgerService) RecordRedemption(
 		ExternalReferenceID: &externalRefID,
 	}
 
-	if err := s.CreateEntry(ctx, entry); err != nil {
+	// Validate and record in one unit of work so the balance check holds
+	err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		if err := txs.ValidateRedemption(ctx, tenantID, pointsSpent); err != nil {
+			return err
+		}
+
+		return txs.CreateEntry(ctx, entry)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return entry, nil
 }
+
+// absInt returns the absolute value of n
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/livefire2015/ez-ledger/src/migrate"
+)
+
+// openPostgres connects to the database named by DATABASE_URL and migrates
+// it to the current schema. Tests that depend on Postgres row locks or
+// transaction semantics use it, and are skipped when DATABASE_URL is unset.
+func openPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	url := os.Getenv("DATABASE_URL")
+	if url == "" {
+		t.Skip("DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	// Stay well under the server's connection limit when tests post in parallel
+	db.SetMaxOpenConns(20)
+
+	migrator, err := migrate.New(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// ProjectionService rebuilds the mutable projections kept on credit cards
+// and billing cycles from the immutable ledgers
+type ProjectionService struct {
+	store repository.Store
+}
+
+// NewProjectionService creates a new projection service
+func NewProjectionService(db Querier) *ProjectionService {
+	return NewProjectionServiceWithStore(postgres.NewStore(db))
+}
+
+// NewProjectionServiceWithStore creates a projection service backed by store
+func NewProjectionServiceWithStore(store repository.Store) *ProjectionService {
+	return &ProjectionService{store: store}
+}
+
+// RebuildRequest selects the cards to replay
+type RebuildRequest struct {
+	CreditCardID *uuid.UUID // Nil replays every card
+	Repair       bool       // Write the rebuilt values over any that differ
+}
+
+// ProjectionDifference is a stored value that does not match the ledger
+type ProjectionDifference struct {
+	CreditCardID   uuid.UUID       `json:"credit_card_id"`
+	BillingCycleID *uuid.UUID      `json:"billing_cycle_id,omitempty"`
+	Field          string          `json:"field"`
+	Stored         decimal.Decimal `json:"stored"`
+	Rebuilt        decimal.Decimal `json:"rebuilt"`
+}
+
+// String describes the difference for logs and reports
+func (d ProjectionDifference) String() string {
+	target := "card " + d.CreditCardID.String()
+	if d.BillingCycleID != nil {
+		target = "billing cycle " + d.BillingCycleID.String()
+	}
+	return fmt.Sprintf("%s %s: stored %s, ledger %s", target, d.Field, d.Stored, d.Rebuilt)
+}
+
+// CardRebuild is the replay of one card
+type CardRebuild struct {
+	CreditCardID    uuid.UUID               `json:"credit_card_id"`
+	TenantID        uuid.UUID               `json:"tenant_id"`
+	AvailableCredit decimal.Decimal         `json:"available_credit"`
+	Cashback        *models.CashbackBalance `json:"cashback"`
+	Differences     []ProjectionDifference  `json:"differences"`
+	Repaired        bool                    `json:"repaired"`
+}
+
+// RebuildReport is the result of a replay
+type RebuildReport struct {
+	Cards       []*CardRebuild `json:"cards"`
+	Differences int            `json:"differences"`
+	Repaired    int            `json:"repaired"`
+}
+
+// Rebuild replays statement and cashback ledger entries for each selected
+// card and reports every projection that differs from the ledger. With
+// Repair set, each card's differences are written back in one transaction
+// while the card is locked.
+//
+// Statement entries belong to a tenant rather than a card, so a tenant with
+// more than one card cannot be replayed.
+func (s *ProjectionService) Rebuild(ctx context.Context, req RebuildRequest) (*RebuildReport, error) {
+	var cardIDs []uuid.UUID
+	if req.CreditCardID != nil {
+		cardIDs = []uuid.UUID{*req.CreditCardID}
+	} else {
+		cards, err := s.store.CreditCards().List(ctx, repository.CreditCardFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list credit cards: %w", err)
+		}
+		for _, card := range cards {
+			cardIDs = append(cardIDs, card.ID)
+		}
+	}
+
+	report := &RebuildReport{}
+	for _, cardID := range cardIDs {
+		var rebuilt *CardRebuild
+		err := s.store.WithinTx(ctx, func(tx repository.Store) error {
+			var err error
+			rebuilt, err = rebuildCard(ctx, tx, cardID, req.Repair)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to rebuild card %s: %w", cardID, err)
+		}
+
+		report.Cards = append(report.Cards, rebuilt)
+		report.Differences += len(rebuilt.Differences)
+		if rebuilt.Repaired {
+			report.Repaired++
+		}
+	}
+
+	return report, nil
+}
+
+// rebuildCard replays one card inside tx
+func rebuildCard(ctx context.Context, tx repository.Store, cardID uuid.UUID, repair bool) (*CardRebuild, error) {
+	card, err := tx.CreditCards().GetForUpdate(ctx, cardID)
+	if err == repository.ErrNotFound {
+		return nil, fmt.Errorf("credit card not found: %s", cardID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tenantCards, err := tx.CreditCards().List(ctx, repository.CreditCardFilter{TenantID: &card.TenantID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenant cards: %w", err)
+	}
+	if len(tenantCards) > 1 {
+		return nil, fmt.Errorf("tenant %s has %d cards; its statement entries cannot be attributed to one card",
+			card.TenantID, len(tenantCards))
+	}
+
+	entries, err := tx.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID: &card.TenantID,
+		Statuses: []models.EntryStatus{models.EntryStatusPending, models.EntryStatusCleared},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement entries: %w", err)
+	}
+
+	cashbackEntries, err := tx.CashbackEntries().List(ctx, repository.CashbackEntryFilter{CreditCardID: &card.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cashback entries: %w", err)
+	}
+
+	cycles, err := tx.BillingCycles().List(ctx, repository.BillingCycleFilter{CreditCardID: &card.ID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list billing cycles: %w", err)
+	}
+
+	authorizations, err := tx.Authorizations().List(ctx, repository.AuthorizationFilter{
+		CreditCardID: &card.ID,
+		Statuses:     []models.AuthorizationStatus{models.AuthorizationStatusActive},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list authorizations: %w", err)
+	}
+
+	// Open holds are not in the ledger but still use up credit
+	availableCredit := replayAvailableCredit(card.CreditLimit, entries)
+	for _, authorization := range authorizations {
+		availableCredit = availableCredit.Sub(authorization.HeldAmount)
+	}
+	// Charge-off entries credit the ledger, but the written-off line stays closed
+	if card.IsChargedOff() {
+		availableCredit = decimal.Zero
+	}
+
+	result := &CardRebuild{
+		CreditCardID:    card.ID,
+		TenantID:        card.TenantID,
+		AvailableCredit: availableCredit,
+		Cashback:        replayCashbackBalance(card, cashbackEntries),
+	}
+
+	if !card.AvailableCredit.Equal(result.AvailableCredit) {
+		result.Differences = append(result.Differences, ProjectionDifference{
+			CreditCardID: card.ID,
+			Field:        "available_credit",
+			Stored:       card.AvailableCredit,
+			Rebuilt:      result.AvailableCredit,
+		})
+		card.AvailableCredit = result.AvailableCredit
+	}
+	cardChanged := len(result.Differences) > 0
+
+	cycleDifferences, changedCycles := replayBillingCycles(card, cycles, entries)
+	result.Differences = append(result.Differences, cycleDifferences...)
+
+	if !repair || len(result.Differences) == 0 {
+		return result, nil
+	}
+
+	now := time.Now()
+	if cardChanged {
+		card.UpdatedAt = now
+		if err := tx.CreditCards().Update(ctx, card); err != nil {
+			return nil, fmt.Errorf("failed to repair credit card: %w", err)
+		}
+	}
+	for _, cycle := range changedCycles {
+		cycle.UpdatedAt = now
+		if err := tx.BillingCycles().Update(ctx, cycle); err != nil {
+			return nil, fmt.Errorf("failed to repair billing cycle %d: %w", cycle.CycleNumber, err)
+		}
+	}
+	result.Repaired = true
+
+	return result, nil
+}
+
+// replayAvailableCredit applies entries in the order they were recorded.
+// Charges, fees and interest use up credit; credits give it back but never
+// raise available credit above the limit.
+func replayAvailableCredit(creditLimit decimal.Decimal, entries []*models.StatementLedgerEntry) decimal.Decimal {
+	ordered := append([]*models.StatementLedgerEntry(nil), entries...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].CreatedAt.Before(ordered[j].CreatedAt)
+	})
+
+	available := creditLimit
+	for _, entry := range ordered {
+		available = available.Sub(entry.GetSignedAmount())
+		if available.GreaterThan(creditLimit) {
+			available = creditLimit
+		}
+	}
+	return available
+}
+
+// replayCashbackBalance totals a card's cashback ledger
+func replayCashbackBalance(card *models.CreditCard, entries []*models.CashbackLedgerEntry) *models.CashbackBalance {
+	balance := &models.CashbackBalance{TenantID: card.TenantID, CreditCardID: card.ID}
+	for _, entry := range entries {
+		switch entry.EntryType {
+		case models.CashbackEarned:
+			balance.EarnedTotal = balance.EarnedTotal.Add(entry.Amount)
+		case models.CashbackRedeemed:
+			balance.RedeemedTotal = balance.RedeemedTotal.Add(entry.Amount.Abs())
+		case models.CashbackExpired:
+			balance.ExpiredTotal = balance.ExpiredTotal.Add(entry.Amount.Abs())
+		}
+		balance.AvailableBalance = balance.AvailableBalance.Add(entry.GetSignedAmount())
+		balance.TotalEntries++
+		if entry.EntryDate.After(balance.LastActivityDate) {
+			balance.LastActivityDate = entry.EntryDate
+		}
+	}
+	return balance
+}
+
+// replayBillingCycles rebuilds the amounts of every generated cycle, oldest
+// first. Returns the differences and the cycles, updated to the rebuilt
+// values, that changed.
+//
+// Each entry counts toward one cycle: the cycle it was charged on (interest
+// carries its cycle's ID), otherwise the first cycle whose dates cover its
+// posting date.
+func replayBillingCycles(
+	card *models.CreditCard,
+	cycles []*models.BillingCycle,
+	entries []*models.StatementLedgerEntry,
+) ([]ProjectionDifference, []*models.BillingCycle) {
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i].CycleNumber < cycles[j].CycleNumber })
+
+	var generated []*models.BillingCycle
+	index := make(map[uuid.UUID]int)
+	for _, cycle := range cycles {
+		if cycle.Status == models.BillingCycleStatusOpen {
+			continue
+		}
+		index[cycle.ID] = len(generated)
+		generated = append(generated, cycle)
+	}
+
+	activity := make([][]*models.StatementLedgerEntry, len(generated))
+	for _, entry := range entries {
+		if entry.StatementID != nil {
+			if i, ok := index[*entry.StatementID]; ok {
+				activity[i] = append(activity[i], entry)
+				continue
+			}
+		}
+		for i, cycle := range generated {
+			if !entry.PostingDate.Before(cycle.CycleStartDate) && !entry.PostingDate.After(cycle.CycleEndDate) {
+				activity[i] = append(activity[i], entry)
+				break
+			}
+		}
+	}
+
+	var differences []ProjectionDifference
+	var changed []*models.BillingCycle
+	previousBalance := decimal.Zero
+	for i, stored := range generated {
+		rebuilt := &models.BillingCycle{PreviousBalance: previousBalance}
+		for _, entry := range activity[i] {
+			switch {
+			case entry.EntryType == models.EntryTypeTransaction:
+				rebuilt.PurchasesAmount = rebuilt.PurchasesAmount.Add(entry.Amount)
+			case entry.EntryType == models.EntryTypeCashAdvance:
+				rebuilt.CashAdvancesAmount = rebuilt.CashAdvancesAmount.Add(entry.Amount)
+			case entry.EntryType == models.EntryTypeBalanceTransfer:
+				rebuilt.BalanceTransfersAmount = rebuilt.BalanceTransfersAmount.Add(entry.Amount)
+			case entry.EntryType == models.EntryTypeRefund:
+				rebuilt.RefundsAmount = rebuilt.RefundsAmount.Add(entry.Amount)
+			case entry.EntryType == models.EntryTypePayment:
+				rebuilt.PaymentsReceived = rebuilt.PaymentsReceived.Add(entry.Amount)
+			case entry.EntryType == models.EntryTypeFeeInterest:
+				rebuilt.InterestAmount = rebuilt.InterestAmount.Add(entry.Amount)
+			case strings.HasPrefix(string(entry.EntryType), "fee_"):
+				rebuilt.FeesAmount = rebuilt.FeesAmount.Add(entry.Amount)
+			case entry.EntryType == models.EntryTypeAdjustment:
+				rebuilt.AdjustmentsAmount = rebuilt.AdjustmentsAmount.Add(entry.Amount)
+			case entry.EntryType == models.EntryTypeCredit, entry.EntryType == models.EntryTypeChargeOff:
+				rebuilt.AdjustmentsAmount = rebuilt.AdjustmentsAmount.Sub(entry.Amount)
+			case entry.EntryType == models.EntryTypeCashbackEarned:
+				rebuilt.CashbackEarned = rebuilt.CashbackEarned.Add(entry.Amount)
+			case entry.EntryType == models.EntryTypeCashbackRedeemed:
+				rebuilt.CashbackRedeemed = rebuilt.CashbackRedeemed.Add(entry.Amount)
+			}
+		}
+		rebuilt.NewBalance = rebuilt.CalculateNewBalance()
+		rebuilt.MinimumPayment = card.CalculateMinimumPayment(rebuilt.NewBalance)
+
+		fields := []struct {
+			name    string
+			stored  *decimal.Decimal
+			rebuilt decimal.Decimal
+		}{
+			{"previous_balance", &stored.PreviousBalance, rebuilt.PreviousBalance},
+			{"payments_received", &stored.PaymentsReceived, rebuilt.PaymentsReceived},
+			{"purchases_amount", &stored.PurchasesAmount, rebuilt.PurchasesAmount},
+			{"cash_advances_amount", &stored.CashAdvancesAmount, rebuilt.CashAdvancesAmount},
+			{"balance_transfers_amount", &stored.BalanceTransfersAmount, rebuilt.BalanceTransfersAmount},
+			{"refunds_amount", &stored.RefundsAmount, rebuilt.RefundsAmount},
+			{"fees_amount", &stored.FeesAmount, rebuilt.FeesAmount},
+			{"interest_amount", &stored.InterestAmount, rebuilt.InterestAmount},
+			{"adjustments_amount", &stored.AdjustmentsAmount, rebuilt.AdjustmentsAmount},
+			{"cashback_earned", &stored.CashbackEarned, rebuilt.CashbackEarned},
+			{"cashback_redeemed", &stored.CashbackRedeemed, rebuilt.CashbackRedeemed},
+			{"new_balance", &stored.NewBalance, rebuilt.NewBalance},
+			{"minimum_payment", &stored.MinimumPayment, rebuilt.MinimumPayment},
+		}
+
+		cycleChanged := false
+		for _, field := range fields {
+			if field.stored.Equal(field.rebuilt) {
+				continue
+			}
+			cycleID := stored.ID
+			differences = append(differences, ProjectionDifference{
+				CreditCardID:   card.ID,
+				BillingCycleID: &cycleID,
+				Field:          field.name,
+				Stored:         *field.stored,
+				Rebuilt:        field.rebuilt,
+			})
+			*field.stored = field.rebuilt
+			cycleChanged = true
+		}
+		if cycleChanged {
+			changed = append(changed, stored)
+		}
+
+		// Payments made toward a statement are not in the ledger, so carry the stored total
+		previousBalance = rebuilt.NewBalance.Sub(stored.PaymentsMade)
+	}
+
+	return differences, changed
+}
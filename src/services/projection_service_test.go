@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestProjectionService_Rebuild(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	cycleStart := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	cycleEnd := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	day := cycleStart.AddDate(0, 0, 9)
+
+	card := testCard()
+	card.CreatedAt = cycleStart
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	cards := NewCreditCardServiceWithStore(store)
+	if _, err := cards.RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:       card,
+		Amount:           decimal.NewFromInt(1000),
+		Description:      "Dinner",
+		MerchantName:     "Test Merchant",
+		MerchantCategory: "5812",
+		TransactionDate:  day,
+		PostingDate:      day,
+	}); err != nil {
+		t.Fatalf("failed to record purchase: %v", err)
+	}
+	if _, err := cards.RecordPayment(ctx, CCPaymentRequest{
+		CreditCard:  card,
+		Amount:      decimal.NewFromInt(200),
+		PaymentDate: day,
+		PostingDate: day,
+	}); err != nil {
+		t.Fatalf("failed to record payment: %v", err)
+	}
+	statement, err := NewBillingServiceWithStore(store).GenerateStatement(ctx, GenerateStatementRequest{
+		CreditCard: card,
+		CycleEnd:   cycleEnd,
+	})
+	if err != nil {
+		t.Fatalf("failed to generate statement: %v", err)
+	}
+
+	service := NewProjectionServiceWithStore(store)
+	rebuild := func(repair bool) *RebuildReport {
+		t.Helper()
+		report, err := service.Rebuild(ctx, RebuildRequest{CreditCardID: &card.ID, Repair: repair})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return report
+	}
+
+	report := rebuild(false)
+	if report.Differences != 0 {
+		t.Fatalf("fresh projections differ: %v", report.Cards[0].Differences)
+	}
+	rebuilt := report.Cards[0]
+	if !rebuilt.AvailableCredit.Equal(decimal.NewFromInt(4200)) {
+		t.Errorf("available credit = %s, want 4200", rebuilt.AvailableCredit)
+	}
+	if !rebuilt.Cashback.AvailableBalance.Equal(decimal.NewFromInt(15)) {
+		t.Errorf("cashback = %s, want 15", rebuilt.Cashback.AvailableBalance)
+	}
+
+	// Drift both projections away from the ledger
+	stored, _ := store.CreditCards().GetByID(ctx, card.ID)
+	stored.AvailableCredit = decimal.NewFromInt(4500)
+	if err := store.CreditCards().Update(ctx, stored); err != nil {
+		t.Fatalf("failed to update card: %v", err)
+	}
+	cycle, _ := store.BillingCycles().GetByID(ctx, statement.BillingCycle.ID)
+	cycle.PurchasesAmount = decimal.NewFromInt(900)
+	if err := store.BillingCycles().Update(ctx, cycle); err != nil {
+		t.Fatalf("failed to update cycle: %v", err)
+	}
+
+	report = rebuild(false)
+	if report.Differences != 2 || report.Repaired != 0 {
+		t.Fatalf("differences = %v, want available_credit and purchases_amount", report.Cards[0].Differences)
+	}
+	for _, diff := range report.Cards[0].Differences {
+		switch diff.Field {
+		case "available_credit":
+			if !diff.Stored.Equal(decimal.NewFromInt(4500)) || !diff.Rebuilt.Equal(decimal.NewFromInt(4200)) {
+				t.Errorf("unexpected difference: %s", diff)
+			}
+		case "purchases_amount":
+			if diff.BillingCycleID == nil || !diff.Rebuilt.Equal(decimal.NewFromInt(1000)) {
+				t.Errorf("unexpected difference: %s", diff)
+			}
+		default:
+			t.Errorf("unexpected difference: %s", diff)
+		}
+	}
+	if stored, _ := store.CreditCards().GetByID(ctx, card.ID); !stored.AvailableCredit.Equal(decimal.NewFromInt(4500)) {
+		t.Error("report-only rebuild changed the card")
+	}
+
+	if report := rebuild(true); report.Repaired != 1 {
+		t.Fatalf("repaired = %d, want 1", report.Repaired)
+	}
+	if report := rebuild(false); report.Differences != 0 {
+		t.Errorf("differences after repair: %v", report.Cards[0].Differences)
+	}
+}
+
+func TestProjectionService_RebuildRejectsSharedTenant(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+
+	card := testCard()
+	second := testCard()
+	second.TenantID = card.TenantID
+	for _, c := range []*models.CreditCard{card, second} {
+		if err := store.CreditCards().Create(ctx, c); err != nil {
+			t.Fatalf("failed to create card: %v", err)
+		}
+	}
+
+	if _, err := NewProjectionServiceWithStore(store).Rebuild(ctx, RebuildRequest{}); err == nil {
+		t.Fatal("expected error for a tenant with two cards")
+	}
+}
+
+func TestProjectionService_RebuildKeepsChargedOffCardClosed(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	posted := penaltyDate(time.January, 5)
+	if _, err := NewCreditCardServiceWithStore(store).RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:      card,
+		Amount:          decimal.NewFromInt(1000),
+		Description:     "Furniture",
+		MerchantName:    "Test Merchant",
+		TransactionDate: posted,
+		PostingDate:     posted,
+	}); err != nil {
+		t.Fatalf("failed to record purchase: %v", err)
+	}
+	dueDate := penaltyDate(time.January, 25)
+	createDueCycle(t, store, card, 1, dueDate, nil)
+	if _, err := NewDelinquencyServiceWithStore(store).AgeCard(ctx, card.ID, dueDate.AddDate(0, 0, models.ChargeOffDays)); err != nil {
+		t.Fatalf("failed to charge off card: %v", err)
+	}
+
+	report, err := NewProjectionServiceWithStore(store).Rebuild(ctx, RebuildRequest{CreditCardID: &card.ID, Repair: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rebuilt := report.Cards[0]; !rebuilt.AvailableCredit.IsZero() {
+		t.Errorf("rebuilt available credit = %s, want 0", rebuilt.AvailableCredit)
+	}
+	for _, difference := range report.Cards[0].Differences {
+		if difference.Field == "available_credit" {
+			t.Errorf("unexpected difference: %s", difference)
+		}
+	}
+
+	stored, err := store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	if !stored.AvailableCredit.IsZero() {
+		t.Errorf("stored available credit = %s, want 0", stored.AvailableCredit)
+	}
+}
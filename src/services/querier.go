@@ -0,0 +1,12 @@
+package services
+
+import (
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+)
+
+// Querier is the statement surface shared by *sql.DB and *sql.Tx.
+// The NewXService constructors wrap a Querier in a Postgres store, so a
+// service constructed with a transaction performs all of its reads and
+// writes - including those of the services it delegates to - inside that
+// transaction. Use the NewXServiceWithStore constructors for other stores.
+type Querier = postgres.Querier
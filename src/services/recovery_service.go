@@ -0,0 +1,469 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// RecoveryService records money collected on charged-off balances and
+// reports on it. Recoveries live in their own collections ledger: the
+// cardholder's statement balance was written off at charge-off and stays at
+// zero.
+type RecoveryService struct {
+	store             repository.Store
+	creditCardService *CreditCardService
+	glService         *GeneralLedgerService
+}
+
+// NewRecoveryService creates a new recovery service
+func NewRecoveryService(db Querier) *RecoveryService {
+	return NewRecoveryServiceWithStore(postgres.NewStore(db))
+}
+
+// NewRecoveryServiceWithStore creates a recovery service backed by store
+func NewRecoveryServiceWithStore(store repository.Store) *RecoveryService {
+	return &RecoveryService{
+		store:             store,
+		creditCardService: NewCreditCardServiceWithStore(store),
+		glService:         NewGeneralLedgerServiceWithStore(store),
+	}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *RecoveryService) withStore(store repository.Store) *RecoveryService {
+	return NewRecoveryServiceWithStore(store)
+}
+
+// RecoveryRequest contains parameters for recording a recovery
+type RecoveryRequest struct {
+	CreditCard     *models.CreditCard
+	Amount         decimal.Decimal // Gross amount collected from the cardholder
+	Agency         string          // Empty when collected in house
+	CommissionRate decimal.Decimal // Agency commission percentage, e.g. 25.00 = 25%
+	RecoveredOn    time.Time
+	ReferenceID    string // Agency remittance reference
+	IdempotencyKey string // Retries with the same key replay the original result
+}
+
+// RecoveryResult contains the result of recording a recovery
+type RecoveryResult struct {
+	Recovery  *models.Recovery
+	ChargeOff *models.ChargeOff // Remaining balances after the recovery
+	Journal   *models.JournalEntry
+}
+
+// RecordRecovery records money collected on a charged-off card. The gross
+// amount pays down the remaining charged-off principal, then interest, then
+// fees; an agency's commission is split out and only the net amount is
+// booked as cash. Returns models.ErrNotChargedOff for a card that has not
+// been charged off.
+func (s *RecoveryService) RecordRecovery(ctx context.Context, req RecoveryRequest) (*RecoveryResult, error) {
+	if !req.Amount.IsPositive() {
+		return nil, errors.New("recovery amount must be positive")
+	}
+	if req.CommissionRate.IsNegative() || req.CommissionRate.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, errors.New("commission rate must be between 0 and 100")
+	}
+	if req.Agency == "" && !req.CommissionRate.IsZero() {
+		return nil, errors.New("in-house recoveries carry no commission")
+	}
+
+	result := &RecoveryResult{}
+
+	err := idempotently(ctx, s.store, req.CreditCard.TenantID, req.IdempotencyKey, operationRecordRecovery, result, func(tx repository.Store) error {
+		txs := s.withStore(tx)
+
+		card, err := txs.creditCardService.lockCard(ctx, req.CreditCard.ID)
+		if err != nil {
+			return err
+		}
+		if !card.IsChargedOff() {
+			return models.ErrNotChargedOff
+		}
+
+		chargeOff, err := tx.Recoveries().GetChargeOff(ctx, card.ID)
+		if errors.Is(err, repository.ErrNotFound) {
+			return models.ErrNotChargedOff
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get charge-off: %w", err)
+		}
+
+		now := time.Now()
+		recovery := &models.Recovery{
+			ID:             uuid.New(),
+			TenantID:       card.TenantID,
+			CreditCardID:   card.ID,
+			ChargeOffID:    chargeOff.ID,
+			Agency:         req.Agency,
+			Amount:         req.Amount,
+			CommissionRate: req.CommissionRate,
+			RecoveredOn:    req.RecoveredOn,
+			CreatedAt:      now,
+		}
+		if req.ReferenceID != "" {
+			recovery.ReferenceID = &req.ReferenceID
+		}
+		recovery.SplitCommission()
+
+		if err := chargeOff.ApplyRecovery(recovery); err != nil {
+			return err
+		}
+		chargeOff.UpdatedAt = now
+
+		if err := tx.Recoveries().UpdateChargeOff(ctx, chargeOff); err != nil {
+			return fmt.Errorf("failed to update charge-off: %w", err)
+		}
+		if err := tx.Recoveries().Create(ctx, recovery); err != nil {
+			return fmt.Errorf("failed to create recovery: %w", err)
+		}
+
+		journal, err := txs.glService.PostRecovery(ctx, recovery)
+		if err != nil {
+			return err
+		}
+
+		result.Recovery = recovery
+		result.ChargeOff = chargeOff
+		result.Journal = journal
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// GetChargeOff returns a card's charged-off balance and what is still owed
+func (s *RecoveryService) GetChargeOff(ctx context.Context, cardID uuid.UUID) (*models.ChargeOff, error) {
+	chargeOff, err := s.store.Recoveries().GetChargeOff(ctx, cardID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, models.ErrNotChargedOff
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get charge-off: %w", err)
+	}
+	return chargeOff, nil
+}
+
+// ListRecoveries returns a card's recoveries, oldest first
+func (s *RecoveryService) ListRecoveries(ctx context.Context, cardID uuid.UUID) ([]*models.Recovery, error) {
+	recoveries, err := s.store.Recoveries().List(ctx, repository.RecoveryFilter{CreditCardID: &cardID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recoveries: %w", err)
+	}
+	return recoveries, nil
+}
+
+// RecoveryPeriod is the length of the periods a recovery report groups by
+type RecoveryPeriod string
+
+const (
+	RecoveryPeriodMonth   RecoveryPeriod = "month"
+	RecoveryPeriodQuarter RecoveryPeriod = "quarter"
+	RecoveryPeriodYear    RecoveryPeriod = "year"
+)
+
+// start returns the first day of the period containing t
+func (p RecoveryPeriod) start(t time.Time) time.Time {
+	year, month, _ := t.Date()
+	switch p {
+	case RecoveryPeriodQuarter:
+		month = time.Month((int(month)-1)/3*3 + 1)
+	case RecoveryPeriodYear:
+		month = time.January
+	}
+	return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+}
+
+// next returns the first day of the period after the one starting at start
+func (p RecoveryPeriod) next(start time.Time) time.Time {
+	switch p {
+	case RecoveryPeriodQuarter:
+		return start.AddDate(0, 3, 0)
+	case RecoveryPeriodYear:
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// RecoveryReportRequest selects the recoveries to report
+type RecoveryReportRequest struct {
+	TenantID *uuid.UUID // Nil reports every tenant
+	From     time.Time  // Inclusive
+	To       time.Time  // Inclusive
+	Period   RecoveryPeriod
+}
+
+// RecoveryReportLine totals the recoveries of one agency in a period
+type RecoveryReportLine struct {
+	Agency          string          `json:"agency"` // Empty for in-house collections
+	RecoveryCount   int             `json:"recovery_count"`
+	Amount          decimal.Decimal `json:"amount"`
+	Commission      decimal.Decimal `json:"commission"`
+	NetAmount       decimal.Decimal `json:"net_amount"`
+	PrincipalAmount decimal.Decimal `json:"principal_amount"`
+	InterestAmount  decimal.Decimal `json:"interest_amount"`
+	FeesAmount      decimal.Decimal `json:"fees_amount"`
+}
+
+func newRecoveryReportLine(agency string) RecoveryReportLine {
+	return RecoveryReportLine{
+		Agency:          agency,
+		Amount:          decimal.Zero,
+		Commission:      decimal.Zero,
+		NetAmount:       decimal.Zero,
+		PrincipalAmount: decimal.Zero,
+		InterestAmount:  decimal.Zero,
+		FeesAmount:      decimal.Zero,
+	}
+}
+
+// add counts a recovery in the line
+func (l *RecoveryReportLine) add(recovery *models.Recovery) {
+	l.RecoveryCount++
+	l.Amount = l.Amount.Add(recovery.Amount)
+	l.Commission = l.Commission.Add(recovery.Commission)
+	l.NetAmount = l.NetAmount.Add(recovery.NetAmount)
+	l.PrincipalAmount = l.PrincipalAmount.Add(recovery.PrincipalAmount)
+	l.InterestAmount = l.InterestAmount.Add(recovery.InterestAmount)
+	l.FeesAmount = l.FeesAmount.Add(recovery.FeesAmount)
+}
+
+// RecoveryReportPeriod is the charge-offs and recoveries of one period
+type RecoveryReportPeriod struct {
+	Start          time.Time            `json:"start"`
+	End            time.Time            `json:"end"` // Inclusive
+	ChargeOffCount int                  `json:"charge_off_count"`
+	ChargedOff     decimal.Decimal      `json:"charged_off"`
+	Agencies       []RecoveryReportLine `json:"agencies"` // Sorted by agency, in house first
+	Total          RecoveryReportLine   `json:"total"`
+}
+
+// NetChargeOff returns the balances charged off in the period less the
+// gross amount recovered in it
+func (p RecoveryReportPeriod) NetChargeOff() decimal.Decimal {
+	return p.ChargedOff.Sub(p.Total.Amount)
+}
+
+// RecoveryReport is charge-off and recovery activity grouped by period and
+// agency
+type RecoveryReport struct {
+	TenantID       *uuid.UUID             `json:"tenant_id,omitempty"`
+	From           time.Time              `json:"from"`
+	To             time.Time              `json:"to"`
+	Period         RecoveryPeriod         `json:"period"`
+	Periods        []RecoveryReportPeriod `json:"periods"`
+	ChargeOffCount int                    `json:"charge_off_count"`
+	ChargedOff     decimal.Decimal        `json:"charged_off"`
+	Total          RecoveryReportLine     `json:"total"`
+	GeneratedAt    time.Time              `json:"generated_at"`
+}
+
+// NetChargeOff returns the balances charged off in the range less the gross
+// amount recovered in it
+func (r *RecoveryReport) NetChargeOff() decimal.Decimal {
+	return r.ChargedOff.Sub(r.Total.Amount)
+}
+
+// RecoveryReport totals the charge-offs and recoveries dated in the range
+// by period, and each period's recoveries by agency. Every period in the
+// range is reported, including those with no activity.
+func (s *RecoveryService) RecoveryReport(ctx context.Context, req RecoveryReportRequest) (*RecoveryReport, error) {
+	if req.To.Before(req.From) {
+		return nil, errors.New("report range ends before it starts")
+	}
+	period := req.Period
+	if period == "" {
+		period = RecoveryPeriodMonth
+	}
+	switch period {
+	case RecoveryPeriodMonth, RecoveryPeriodQuarter, RecoveryPeriodYear:
+	default:
+		return nil, fmt.Errorf("unsupported report period: %s", period)
+	}
+
+	chargeOffs, err := s.store.Recoveries().ListChargeOffs(ctx, repository.ChargeOffFilter{
+		TenantID: req.TenantID,
+		From:     &req.From,
+		To:       &req.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list charge-offs: %w", err)
+	}
+
+	recoveries, err := s.store.Recoveries().List(ctx, repository.RecoveryFilter{
+		TenantID: req.TenantID,
+		From:     &req.From,
+		To:       &req.To,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recoveries: %w", err)
+	}
+
+	report := &RecoveryReport{
+		TenantID:    req.TenantID,
+		From:        req.From,
+		To:          req.To,
+		Period:      period,
+		ChargedOff:  decimal.Zero,
+		Total:       newRecoveryReportLine(""),
+		GeneratedAt: time.Now(),
+	}
+
+	for start := period.start(req.From); !start.After(req.To); start = period.next(start) {
+		p := RecoveryReportPeriod{
+			Start:      start,
+			End:        period.next(start).AddDate(0, 0, -1),
+			ChargedOff: decimal.Zero,
+			Total:      newRecoveryReportLine(""),
+		}
+		if p.Start.Before(req.From) {
+			p.Start = req.From
+		}
+		if p.End.After(req.To) {
+			p.End = req.To
+		}
+		report.Periods = append(report.Periods, p)
+	}
+
+	// Both lists are sorted by date, so each walks the periods once
+	i := 0
+	for _, chargeOff := range chargeOffs {
+		for i < len(report.Periods)-1 && models.DateOf(chargeOff.ChargedOffOn).After(report.Periods[i].End) {
+			i++
+		}
+		report.Periods[i].ChargeOffCount++
+		report.Periods[i].ChargedOff = report.Periods[i].ChargedOff.Add(chargeOff.Amount())
+		report.ChargeOffCount++
+		report.ChargedOff = report.ChargedOff.Add(chargeOff.Amount())
+	}
+
+	agencies := make([]map[string]*RecoveryReportLine, len(report.Periods))
+	i = 0
+	for _, recovery := range recoveries {
+		for i < len(report.Periods)-1 && models.DateOf(recovery.RecoveredOn).After(report.Periods[i].End) {
+			i++
+		}
+		if agencies[i] == nil {
+			agencies[i] = make(map[string]*RecoveryReportLine)
+		}
+		line, ok := agencies[i][recovery.Agency]
+		if !ok {
+			added := newRecoveryReportLine(recovery.Agency)
+			line = &added
+			agencies[i][recovery.Agency] = line
+		}
+		line.add(recovery)
+		report.Periods[i].Total.add(recovery)
+		report.Total.add(recovery)
+	}
+
+	for i, lines := range agencies {
+		for _, line := range lines {
+			report.Periods[i].Agencies = append(report.Periods[i].Agencies, *line)
+		}
+		sort.Slice(report.Periods[i].Agencies, func(a, b int) bool {
+			return report.Periods[i].Agencies[a].Agency < report.Periods[i].Agencies[b].Agency
+		})
+	}
+
+	return report, nil
+}
+
+// WriteCSV writes one row per period and agency, a subtotal row per period
+// carrying its charge-offs, and a totals row
+func (r *RecoveryReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	row := func(start, end, agency string, line RecoveryReportLine, chargedOff, netChargeOff string) []string {
+		return []string{
+			start, end, agency,
+			strconv.Itoa(line.RecoveryCount),
+			line.Amount.StringFixed(2),
+			line.Commission.StringFixed(2),
+			line.NetAmount.StringFixed(2),
+			line.PrincipalAmount.StringFixed(2),
+			line.InterestAmount.StringFixed(2),
+			line.FeesAmount.StringFixed(2),
+			chargedOff,
+			netChargeOff,
+		}
+	}
+
+	rows := [][]string{{
+		"period_start", "period_end", "agency", "recovery_count",
+		"amount", "commission", "net_amount", "principal", "interest", "fees",
+		"charged_off", "net_charge_off",
+	}}
+	for _, p := range r.Periods {
+		start, end := p.Start.Format("2006-01-02"), p.End.Format("2006-01-02")
+		for _, line := range p.Agencies {
+			agency := line.Agency
+			if agency == "" {
+				agency = "in-house"
+			}
+			rows = append(rows, row(start, end, agency, line, "", ""))
+		}
+		rows = append(rows, row(start, end, "SUBTOTAL", p.Total,
+			p.ChargedOff.StringFixed(2), p.NetChargeOff().StringFixed(2)))
+	}
+	rows = append(rows, row(r.From.Format("2006-01-02"), r.To.Format("2006-01-02"), "TOTAL", r.Total,
+		r.ChargedOff.StringFixed(2), r.NetChargeOff().StringFixed(2)))
+
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// splitChargeOff splits a charged-off total into principal, interest and
+// fees. Entries are replayed in order: interest and fees accrue as they are
+// charged and come off again when waived, and payments settle interest, then
+// fees, before principal. Whatever is left of the total is principal.
+func splitChargeOff(
+	entries []*models.StatementLedgerEntry,
+	total decimal.Decimal,
+) (principal, interest, fees decimal.Decimal) {
+	interest, fees = decimal.Zero, decimal.Zero
+	for _, entry := range entries {
+		entryType := entry.EntryType
+		if entryType == models.EntryTypeAdjustment || entryType == models.EntryTypeCredit {
+			if feeType, ok := entry.Metadata["original_fee_type"].(string); ok {
+				entryType = models.StatementEntryType(feeType)
+			}
+		}
+
+		switch {
+		case entryType == models.EntryTypeFeeInterest:
+			interest = interest.Add(entry.GetSignedAmount())
+		case strings.HasPrefix(string(entryType), "fee_"):
+			fees = fees.Add(entry.GetSignedAmount())
+		case entryType == models.EntryTypePayment, entryType == models.EntryTypeCashbackRedeemed:
+			paid := entry.GetSignedAmount().Neg()
+			toInterest := decimal.Max(decimal.Min(paid, interest), decimal.Zero)
+			interest = interest.Sub(toInterest)
+			paid = paid.Sub(toInterest)
+			fees = fees.Sub(decimal.Max(decimal.Min(paid, fees), decimal.Zero))
+		}
+	}
+
+	interest = decimal.Min(decimal.Max(interest, decimal.Zero), total).Round(2)
+	fees = decimal.Min(decimal.Max(fees, decimal.Zero), total.Sub(interest)).Round(2)
+	return total.Sub(interest).Sub(fees), interest, fees
+}
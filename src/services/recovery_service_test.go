@@ -0,0 +1,335 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+// chargedOffCard stores a card carrying $800 of purchases, $30 of interest
+// and a $40 late fee, less a $20 payment, and ages it to charge-off on
+// July 24, 2026
+func chargedOffCard(t *testing.T) (*memory.Store, *models.CreditCard) {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	posted := penaltyDate(time.January, 5)
+	entries := []*models.StatementLedgerEntry{
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(800)},
+		{EntryType: models.EntryTypeFeeInterest, Amount: decimal.NewFromInt(30)},
+		{EntryType: models.EntryTypeFeeLate, Amount: decimal.NewFromInt(40)},
+		{EntryType: models.EntryTypePayment, Amount: decimal.NewFromInt(20)},
+	}
+	for i, entry := range entries {
+		entry.TenantID = card.TenantID
+		entry.EntryDate = posted.AddDate(0, 0, i)
+		entry.PostingDate = posted.AddDate(0, 0, i)
+		entry.Status = models.EntryStatusCleared
+		if err := store.StatementEntries().Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create entry: %v", err)
+		}
+	}
+	dueDate := penaltyDate(time.January, 25)
+	createDueCycle(t, store, card, 1, dueDate, nil)
+
+	_, err := NewDelinquencyServiceWithStore(store).AgeCard(ctx, card.ID, dueDate.AddDate(0, 0, models.ChargeOffDays))
+	if err != nil {
+		t.Fatalf("failed to charge off card: %v", err)
+	}
+	card, err = store.CreditCards().GetByID(ctx, card.ID)
+	if err != nil {
+		t.Fatalf("failed to get card: %v", err)
+	}
+	return store, card
+}
+
+func TestRecoveryService_ChargeOffSplit(t *testing.T) {
+	store, card := chargedOffCard(t)
+
+	chargeOff, err := NewRecoveryServiceWithStore(store).GetChargeOff(context.Background(), card.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The payment settled $20 of the interest first
+	want := map[string][2]decimal.Decimal{
+		"principal": {chargeOff.Principal, decimal.NewFromInt(800)},
+		"interest":  {chargeOff.Interest, decimal.NewFromInt(10)},
+		"fees":      {chargeOff.Fees, decimal.NewFromInt(40)},
+	}
+	for name, got := range want {
+		if !got[0].Equal(got[1]) {
+			t.Errorf("charged-off %s = %s, want %s", name, got[0], got[1])
+		}
+	}
+	if !chargeOff.Remaining().Equal(chargeOff.Amount()) || !chargeOff.Amount().Equal(decimal.NewFromInt(850)) {
+		t.Errorf("charge-off = %s with %s remaining, want 850 all remaining", chargeOff.Amount(), chargeOff.Remaining())
+	}
+	if !chargeOff.ChargedOffOn.Equal(penaltyDate(time.July, 24)) {
+		t.Errorf("charged off on %s, want 2026-07-24", chargeOff.ChargedOffOn.Format("2006-01-02"))
+	}
+}
+
+func TestRecoveryService_RecordRecovery(t *testing.T) {
+	tests := []struct {
+		name           string
+		amount         int64
+		agency         string
+		commissionRate int64
+		wantErr        error
+
+		// Expected split
+		principal  int64
+		interest   int64
+		fees       int64
+		commission int64
+	}{
+		{
+			name:      "in house recovery pays down principal",
+			amount:    300,
+			principal: 300,
+		},
+		{
+			name:           "agency recovery splits out commission",
+			amount:         820,
+			agency:         "Acme Collections",
+			commissionRate: 25,
+			principal:      800,
+			interest:       10,
+			fees:           10,
+			commission:     205,
+		},
+		{
+			name:    "more than the charged-off balance",
+			amount:  851,
+			agency:  "Acme Collections",
+			wantErr: models.ErrRecoveryExceedsBalance,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			store, card := chargedOffCard(t)
+			service := NewRecoveryServiceWithStore(store)
+
+			result, err := service.RecordRecovery(ctx, RecoveryRequest{
+				CreditCard:     card,
+				Amount:         decimal.NewFromInt(tt.amount),
+				Agency:         tt.agency,
+				CommissionRate: decimal.NewFromInt(tt.commissionRate),
+				RecoveredOn:    penaltyDate(time.August, 10),
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				recoveries, err := service.ListRecoveries(ctx, card.ID)
+				if err != nil || len(recoveries) != 0 {
+					t.Errorf("recoveries after rejected recovery = %d, %v; want none", len(recoveries), err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			recovery := result.Recovery
+			if !recovery.PrincipalAmount.Equal(decimal.NewFromInt(tt.principal)) ||
+				!recovery.InterestAmount.Equal(decimal.NewFromInt(tt.interest)) ||
+				!recovery.FeesAmount.Equal(decimal.NewFromInt(tt.fees)) {
+				t.Errorf("split = %s/%s/%s, want %d/%d/%d", recovery.PrincipalAmount,
+					recovery.InterestAmount, recovery.FeesAmount, tt.principal, tt.interest, tt.fees)
+			}
+			if !recovery.Commission.Equal(decimal.NewFromInt(tt.commission)) ||
+				!recovery.NetAmount.Equal(decimal.NewFromInt(tt.amount-tt.commission)) {
+				t.Errorf("commission = %s, net = %s; want %d, %d", recovery.Commission,
+					recovery.NetAmount, tt.commission, tt.amount-tt.commission)
+			}
+
+			chargeOff, err := service.GetChargeOff(ctx, card.ID)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			remaining := decimal.NewFromInt(850 - tt.amount)
+			if !chargeOff.Remaining().Equal(remaining) || !chargeOff.Recovered.Equal(decimal.NewFromInt(tt.amount)) {
+				t.Errorf("remaining = %s, recovered = %s; want %s, %d",
+					chargeOff.Remaining(), chargeOff.Recovered, remaining, tt.amount)
+			}
+
+			// The cardholder's statement balance stays written off
+			balance, err := NewStatementLedgerServiceWithStore(store).GetBalance(ctx, card.TenantID)
+			if err != nil {
+				t.Fatalf("failed to get balance: %v", err)
+			}
+			if !balance.CurrentBalance.IsZero() {
+				t.Errorf("balance after recovery = %s, want 0", balance.CurrentBalance)
+			}
+		})
+	}
+}
+
+func TestRecoveryService_RejectsOpenCards(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	card := testCard()
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	_, err := NewRecoveryServiceWithStore(store).RecordRecovery(ctx, RecoveryRequest{
+		CreditCard:  card,
+		Amount:      decimal.NewFromInt(100),
+		RecoveredOn: penaltyDate(time.August, 10),
+	})
+	if !errors.Is(err, models.ErrNotChargedOff) {
+		t.Errorf("recovery on open card error = %v, want %v", err, models.ErrNotChargedOff)
+	}
+}
+
+func TestRecoveryService_RejectsPaymentsAfterChargeOff(t *testing.T) {
+	store, card := chargedOffCard(t)
+
+	_, err := NewCreditCardServiceWithStore(store).RecordPayment(context.Background(), CCPaymentRequest{
+		CreditCard:  card,
+		Amount:      decimal.NewFromInt(100),
+		PaymentDate: penaltyDate(time.August, 10),
+		PostingDate: penaltyDate(time.August, 10),
+	})
+	if !errors.Is(err, models.ErrCardChargedOff) {
+		t.Errorf("payment on charged-off card error = %v, want %v", err, models.ErrCardChargedOff)
+	}
+}
+
+func TestRecoveryService_GeneralLedger(t *testing.T) {
+	ctx := context.Background()
+	store, card := chargedOffCard(t)
+	service := NewRecoveryServiceWithStore(store)
+
+	req := RecoveryRequest{
+		CreditCard:     card,
+		Amount:         decimal.NewFromInt(200),
+		Agency:         "Acme Collections",
+		CommissionRate: decimal.NewFromInt(30),
+		RecoveredOn:    penaltyDate(time.August, 10),
+		ReferenceID:    "REMIT-0810",
+		IdempotencyKey: "remit-0810",
+	}
+	first, err := service.RecordRecovery(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(first.Journal.Lines) != 3 {
+		t.Fatalf("journal has %d lines, want 3", len(first.Journal.Lines))
+	}
+
+	// A retried remittance is recorded once
+	replayed, err := service.RecordRecovery(ctx, req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replayed.Recovery.ID != first.Recovery.ID {
+		t.Errorf("replayed recovery %s, want %s", replayed.Recovery.ID, first.Recovery.ID)
+	}
+
+	trial, err := NewGeneralLedgerServiceWithStore(store).GetTrialBalance(ctx, &card.TenantID, penaltyDate(time.December, 31))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !trial.IsBalanced() {
+		t.Errorf("trial balance nets to %s, want 0", trial.Net())
+	}
+	want := map[models.GLAccountCode]decimal.Decimal{
+		models.GLAccountCashClearing:      decimal.NewFromInt(140),
+		models.GLAccountCollectionExpense: decimal.NewFromInt(60),
+		models.GLAccountRecoveries:        decimal.NewFromInt(200),
+	}
+	for _, line := range trial.Lines {
+		if amount, ok := want[line.Account.Code]; ok && !line.Balance().Equal(amount) {
+			t.Errorf("%s balance = %s, want %s", line.Account.Name, line.Balance(), amount)
+		}
+	}
+}
+
+func TestRecoveryService_RecoveryReport(t *testing.T) {
+	ctx := context.Background()
+	store, card := chargedOffCard(t)
+	service := NewRecoveryServiceWithStore(store)
+
+	recoveries := []RecoveryRequest{
+		{Agency: "Acme Collections", CommissionRate: decimal.NewFromInt(25), Amount: decimal.NewFromInt(100), RecoveredOn: penaltyDate(time.August, 10)},
+		{Amount: decimal.NewFromInt(50), RecoveredOn: penaltyDate(time.September, 5)},
+		{Agency: "Acme Collections", CommissionRate: decimal.NewFromInt(25), Amount: decimal.NewFromInt(100), RecoveredOn: penaltyDate(time.October, 3)},
+	}
+	for _, req := range recoveries {
+		req.CreditCard = card
+		if _, err := service.RecordRecovery(ctx, req); err != nil {
+			t.Fatalf("failed to record recovery: %v", err)
+		}
+	}
+
+	report, err := service.RecoveryReport(ctx, RecoveryReportRequest{
+		TenantID: &card.TenantID,
+		From:     penaltyDate(time.July, 1),
+		To:       penaltyDate(time.December, 31),
+		Period:   RecoveryPeriodQuarter,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(report.Periods) != 2 {
+		t.Fatalf("got %d periods, want 2", len(report.Periods))
+	}
+	third, fourth := report.Periods[0], report.Periods[1]
+	if !third.End.Equal(penaltyDate(time.September, 30)) || !fourth.Start.Equal(penaltyDate(time.October, 1)) {
+		t.Errorf("periods = %s-%s, %s-%s", third.Start.Format("2006-01-02"), third.End.Format("2006-01-02"),
+			fourth.Start.Format("2006-01-02"), fourth.End.Format("2006-01-02"))
+	}
+	if third.ChargeOffCount != 1 || !third.ChargedOff.Equal(decimal.NewFromInt(850)) {
+		t.Errorf("Q3 charge-offs = %d totaling %s, want 1 totaling 850", third.ChargeOffCount, third.ChargedOff)
+	}
+	if len(third.Agencies) != 2 || third.Agencies[0].Agency != "" || third.Agencies[1].Agency != "Acme Collections" {
+		t.Fatalf("Q3 agencies = %+v, want in house then Acme Collections", third.Agencies)
+	}
+	if !third.Agencies[1].Commission.Equal(decimal.NewFromInt(25)) || !third.NetChargeOff().Equal(decimal.NewFromInt(700)) {
+		t.Errorf("Q3 agency commission = %s, net charge-off = %s; want 25, 700",
+			third.Agencies[1].Commission, third.NetChargeOff())
+	}
+	if fourth.ChargeOffCount != 0 || !fourth.Total.Amount.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("Q4 = %d charge-offs and %s recovered, want 0 and 100", fourth.ChargeOffCount, fourth.Total.Amount)
+	}
+	if report.Total.RecoveryCount != 3 || !report.NetChargeOff().Equal(decimal.NewFromInt(600)) {
+		t.Errorf("report total = %d recoveries, net charge-off %s; want 3, 600",
+			report.Total.RecoveryCount, report.NetChargeOff())
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteCSV(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read CSV: %v", err)
+	}
+	// Header, two Q3 agencies and subtotal, one Q4 agency and subtotal, total
+	if len(rows) != 7 {
+		t.Fatalf("got %d CSV rows, want 7", len(rows))
+	}
+	total := rows[len(rows)-1]
+	if total[2] != "TOTAL" || total[4] != "250.00" || total[10] != "850.00" || total[11] != "600.00" {
+		t.Errorf("total row = %v", total)
+	}
+}
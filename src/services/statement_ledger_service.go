@@ -2,160 +2,96 @@ package services
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
 	"github.com/shopspring/decimal"
 )
 
 // StatementLedgerService handles statement ledger operations
 type StatementLedgerService struct {
-	db *sql.DB
+	store repository.Store
 }
 
 // NewStatementLedgerService creates a new statement ledger service
-func NewStatementLedgerService(db *sql.DB) *StatementLedgerService {
-	return &StatementLedgerService{db: db}
+func NewStatementLedgerService(db Querier) *StatementLedgerService {
+	return NewStatementLedgerServiceWithStore(postgres.NewStore(db))
+}
+
+// NewStatementLedgerServiceWithStore creates a statement ledger service backed by store
+func NewStatementLedgerServiceWithStore(store repository.Store) *StatementLedgerService {
+	return &StatementLedgerService{store: store}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *StatementLedgerService) withStore(store repository.Store) *StatementLedgerService {
+	return NewStatementLedgerServiceWithStore(store)
 }
 
 // CreateEntry creates a new statement ledger entry
 // This is the core function for recording all financial activities
 func (s *StatementLedgerService) CreateEntry(ctx context.Context, entry *models.StatementLedgerEntry) error {
-	query := `
-		INSERT INTO statement_ledger_entries (
-			id, tenant_id, statement_id, entry_type, entry_date, posting_date,
-			amount, description, reference_id, metadata, status, created_by
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-	`
-
-	if entry.ID == uuid.Nil {
-		entry.ID = uuid.New()
-	}
-
-	_, err := s.db.ExecContext(ctx, query,
-		entry.ID,
-		entry.TenantID,
-		entry.StatementID,
-		entry.EntryType,
-		entry.EntryDate,
-		entry.PostingDate,
-		entry.Amount,
-		entry.Description,
-		entry.ReferenceID,
-		entry.Metadata,
-		entry.Status,
-		entry.CreatedBy,
-	)
+	return s.store.WithinTx(ctx, func(tx repository.Store) error {
+		if err := tx.StatementEntries().Create(ctx, entry); err != nil {
+			return err
+		}
 
-	return err
+		// Every statement entry has a balanced posting in the general ledger
+		_, err := NewGeneralLedgerServiceWithStore(tx).PostStatementEntry(ctx, entry)
+		return err
+	})
 }
 
 // ClearEntry marks an entry as cleared (processed)
 func (s *StatementLedgerService) ClearEntry(ctx context.Context, entryID uuid.UUID) error {
-	query := `
-		UPDATE statement_ledger_entries
-		SET status = $1, cleared_at = $2
-		WHERE id = $3 AND status = $4
-	`
-
-	now := time.Now()
-	result, err := s.db.ExecContext(ctx, query,
-		models.EntryStatusCleared,
-		now,
-		entryID,
+	err := s.store.StatementEntries().UpdateStatus(ctx, entryID,
 		models.EntryStatusPending,
+		models.EntryStatusCleared,
+		time.Now(),
 	)
 
-	if err != nil {
-		return err
-	}
-
-	rows, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rows == 0 {
+	if errors.Is(err, repository.ErrNotFound) {
 		return fmt.Errorf("entry not found or already cleared: %s", entryID)
 	}
 
-	return nil
+	return err
 }
 
 // GetBalance calculates the current balance for a tenant
 func (s *StatementLedgerService) GetBalance(ctx context.Context, tenantID uuid.UUID) (*models.StatementBalance, error) {
-	query := `SELECT * FROM statement_balances WHERE tenant_id = $1`
-
-	var balance models.StatementBalance
-	err := s.db.QueryRowContext(ctx, query, tenantID).Scan(
-		&balance.TenantID,
-		&balance.CurrentBalance,
-		&balance.TotalEntries,
-		&balance.LastActivityDate,
-	)
-
-	if err == sql.ErrNoRows {
-		// No entries yet, return zero balance
-		return &models.StatementBalance{
-			TenantID:       tenantID,
-			CurrentBalance: decimal.Zero,
-			TotalEntries:   0,
-		}, nil
-	}
-
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID: &tenantID,
+		Statuses: []models.EntryStatus{models.EntryStatusCleared},
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &balance, nil
-}
-
-// GetEntriesByStatement retrieves all entries for a statement
-func (s *StatementLedgerService) GetEntriesByStatement(ctx context.Context, statementID uuid.UUID) ([]*models.StatementLedgerEntry, error) {
-	query := `
-		SELECT id, tenant_id, statement_id, entry_type, entry_date, posting_date,
-		       amount, description, reference_id, metadata, status, cleared_at,
-		       created_at, created_by
-		FROM statement_ledger_entries
-		WHERE statement_id = $1
-		ORDER BY posting_date, entry_date
-	`
-
-	rows, err := s.db.QueryContext(ctx, query, statementID)
-	if err != nil {
-		return nil, err
+	// No entries yet gives a zero balance
+	balance := &models.StatementBalance{
+		TenantID:       tenantID,
+		CurrentBalance: sumSignedAmounts(entries),
+		TotalEntries:   len(entries),
 	}
-	defer rows.Close()
-
-	var entries []*models.StatementLedgerEntry
-	for rows.Next() {
-		entry := &models.StatementLedgerEntry{}
-		err := rows.Scan(
-			&entry.ID,
-			&entry.TenantID,
-			&entry.StatementID,
-			&entry.EntryType,
-			&entry.EntryDate,
-			&entry.PostingDate,
-			&entry.Amount,
-			&entry.Description,
-			&entry.ReferenceID,
-			&entry.Metadata,
-			&entry.Status,
-			&entry.ClearedAt,
-			&entry.CreatedAt,
-			&entry.CreatedBy,
-		)
-		if err != nil {
-			return nil, err
+	for _, entry := range entries {
+		if entry.EntryDate.After(balance.LastActivityDate) {
+			balance.LastActivityDate = entry.EntryDate
 		}
-		entries = append(entries, entry)
 	}
 
-	return entries, rows.Err()
+	return balance, nil
+}
+
+// GetEntriesByStatement retrieves all entries for a statement
+func (s *StatementLedgerService) GetEntriesByStatement(ctx context.Context, statementID uuid.UUID) ([]*models.StatementLedgerEntry, error) {
+	return s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		StatementID: &statementID,
+	})
 }
 
 // CalculateStatementBalance calculates the statement balance for a billing period
@@ -165,30 +101,24 @@ func (s *StatementLedgerService) CalculateStatementBalance(
 	startDate, endDate time.Time,
 	openingBalance decimal.Decimal,
 ) (decimal.Decimal, error) {
-	query := `
-		SELECT COALESCE(SUM(
-			CASE
-				WHEN entry_type IN ('transaction', 'fee_late', 'fee_failed', 'fee_international', 'returned_reward')
-					THEN amount
-				WHEN entry_type IN ('payment', 'refund', 'reward', 'credit')
-					THEN -amount
-				WHEN entry_type = 'adjustment'
-					THEN amount
-				ELSE 0
-			END
-		), 0) as period_total
-		FROM statement_ledger_entries
-		WHERE tenant_id = $1
-		  AND posting_date >= $2
-		  AND posting_date <= $3
-		  AND status = 'cleared'
-	`
-
-	var periodTotal decimal.Decimal
-	err := s.db.QueryRowContext(ctx, query, tenantID, startDate, endDate).Scan(&periodTotal)
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:   &tenantID,
+		Statuses:   []models.EntryStatus{models.EntryStatusCleared},
+		PostedFrom: &startDate,
+		PostedTo:   &endDate,
+	})
 	if err != nil {
 		return decimal.Zero, err
 	}
 
-	return openingBalance.Add(periodTotal), nil
+	return openingBalance.Add(sumSignedAmounts(entries)), nil
+}
+
+// sumSignedAmounts totals entries as debits (positive) and credits (negative)
+func sumSignedAmounts(entries []*models.StatementLedgerEntry) decimal.Decimal {
+	total := decimal.Zero
+	for _, entry := range entries {
+		total = total.Add(entry.GetSignedAmount())
+	}
+	return total
 }
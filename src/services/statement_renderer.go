@@ -0,0 +1,293 @@
+package services
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+//go:embed templates/statement.html
+var statementTemplates embed.FS
+
+var statementHTML = template.Must(template.New("statement.html").Funcs(template.FuncMap{
+	"money":    formatMoney,
+	"percent":  formatPercent,
+	"date":     formatStatementDate,
+	"category": cashbackCategoryLabel,
+}).ParseFS(statementTemplates, "templates/statement.html"))
+
+// RenderStatementHTML renders a statement as a standalone HTML page
+func RenderStatementHTML(doc *StatementDocument) ([]byte, error) {
+	var out bytes.Buffer
+	if err := statementHTML.Execute(&out, doc); err != nil {
+		return nil, fmt.Errorf("failed to render statement: %w", err)
+	}
+	return out.Bytes(), nil
+}
+
+// Statement PDF layout, in points from the bottom left of a Letter page
+const (
+	statementMargin   = 54.0
+	statementRight    = pdfPageWidth - statementMargin
+	statementTop      = pdfPageHeight - statementMargin
+	statementBottom   = 72.0 // Leaves room for the page footer
+	statementFontSize = 9.0
+	statementLeading  = 13.0
+)
+
+// RenderStatementPDF renders a statement as a PDF, continuing onto further
+// pages as the transaction list requires
+func RenderStatementPDF(doc *StatementDocument) []byte {
+	l := &statementLayout{w: &pdfWriter{}}
+	l.w.newPage()
+	l.y = statementTop
+
+	c := doc.Cycle
+	l.w.text(statementMargin, l.y-16, pdfBold, 16, "Credit Card Statement")
+	l.w.textRight(statementRight, l.y-16, pdfRegular, 10, "Account ending in "+doc.AccountEnding)
+	l.y -= 34
+	l.w.text(statementMargin, l.y, pdfRegular, 10, doc.CardholderName)
+	l.w.textRight(statementRight, l.y, pdfRegular, 10,
+		formatStatementDate(c.CycleStartDate)+" to "+formatStatementDate(c.CycleEndDate))
+	l.y -= 10
+
+	l.paymentDueBox(doc)
+
+	l.heading("Account Summary")
+	for _, line := range doc.Summary() {
+		if line.Total {
+			l.rule(0.5)
+			l.row(line.Label, formatMoney(line.Amount), pdfBold)
+			continue
+		}
+		l.row(line.Label, formatMoney(line.Amount), pdfRegular)
+	}
+	l.gap()
+	l.row("Credit Limit", formatMoney(doc.CreditLimit), pdfRegular)
+	l.row("Available Credit", formatMoney(doc.AvailableCredit), pdfRegular)
+	l.row("Statement Closing Date", formatStatementDate(c.CycleEndDate), pdfRegular)
+	l.row("Days in Billing Cycle", strconv.Itoa(c.DaysInCycle), pdfRegular)
+
+	l.heading("Transactions")
+	l.subheading("Payments and Other Credits")
+	l.lines(doc.Credits)
+	l.subheading("Purchases and Other Charges")
+	l.lines(doc.Charges)
+
+	l.heading("Fees")
+	l.lines(doc.Fees)
+	l.rule(0.5)
+	l.row("Total Fees for This Period", formatMoney(doc.FeesTotal()), pdfBold)
+
+	l.heading("Interest Charged")
+	l.lines(doc.Interest)
+	l.rule(0.5)
+	l.row("Total Interest for This Period", formatMoney(doc.InterestTotal()), pdfBold)
+
+	year := strconv.Itoa(doc.Year)
+	l.heading(year + " Totals Year-to-Date")
+	l.row("Total Fees Charged in "+year, formatMoney(doc.FeesYTD), pdfRegular)
+	l.row("Total Interest Charged in "+year, formatMoney(doc.InterestYTD), pdfRegular)
+
+	l.heading("Interest Charge Calculation")
+	l.columns(pdfBold, "Type of Balance", "APR", "Balance Subject to Interest Rate", "Interest Charge")
+	for _, line := range doc.InterestCalculation() {
+		l.columns(pdfRegular, line.Label, formatPercent(line.APR),
+			formatMoney(line.Balance), formatMoney(line.Interest))
+	}
+
+	if cashback := doc.Cashback; cashback != nil {
+		l.heading("Cashback Summary")
+		l.row("Cashback Earned This Period", formatMoney(cashback.CashbackEarned), pdfRegular)
+		l.row("Cashback Redeemed This Period", formatMoney(cashback.CashbackRedeemed), pdfRegular)
+		l.row("Cashback Available", formatMoney(cashback.EndingBalance), pdfRegular)
+		if len(cashback.CategoryBreakdown) > 0 {
+			l.gap()
+			l.columns(pdfBold, "Category", "", "Spent", "Earned")
+			for _, category := range cashback.CategoryBreakdown {
+				l.columns(pdfRegular, cashbackCategoryLabel(category), "",
+					formatMoney(category.TotalSpent), formatMoney(category.CashbackEarned))
+			}
+		}
+	}
+
+	l.w.eachPage(func(page, pages int) {
+		l.w.line(statementMargin, statementBottom-18, statementRight, statementBottom-18, 0.5)
+		l.w.text(statementMargin, statementBottom-30, pdfRegular, 8,
+			doc.CardholderName+" - Account ending in "+doc.AccountEnding)
+		l.w.textRight(statementRight, statementBottom-30, pdfRegular, 8, fmt.Sprintf("Page %d of %d", page, pages))
+	})
+
+	return l.w.bytes("Statement ending " + formatStatementDate(c.CycleEndDate))
+}
+
+// statementLayout places statement sections down the page, starting a new
+// page when the next row would run into the footer
+type statementLayout struct {
+	w *pdfWriter
+	y float64 // Top of the next row
+}
+
+func (l *statementLayout) ensure(height float64) {
+	if l.y-height < statementBottom {
+		l.w.newPage()
+		l.y = statementTop
+	}
+}
+
+func (l *statementLayout) gap() {
+	l.y -= statementLeading / 2
+}
+
+func (l *statementLayout) heading(title string) {
+	// Keep a heading with at least its first two rows
+	l.ensure(24 + 2*statementLeading)
+	l.y -= 24
+	l.w.text(statementMargin, l.y, pdfBold, 11, title)
+	l.y -= 4
+	l.w.line(statementMargin, l.y, statementRight, l.y, 1)
+	l.y -= 2
+}
+
+func (l *statementLayout) subheading(title string) {
+	l.ensure(2 * statementLeading)
+	l.y -= statementLeading + 2
+	l.w.text(statementMargin, l.y, pdfBold, statementFontSize, title)
+}
+
+func (l *statementLayout) rule(width float64) {
+	l.y -= 3
+	l.w.line(statementMargin, l.y, statementRight, l.y, width)
+}
+
+// row prints a label with a right-aligned value
+func (l *statementLayout) row(label, value string, font pdfFont) {
+	l.ensure(statementLeading)
+	l.y -= statementLeading
+	l.w.text(statementMargin, l.y, font, statementFontSize, label)
+	l.w.textRight(statementRight, l.y, font, statementFontSize, value)
+}
+
+// lines prints dated ledger lines, or "None" when there are none
+func (l *statementLayout) lines(lines []StatementLine) {
+	if len(lines) == 0 {
+		l.row("None", "", pdfRegular)
+		return
+	}
+	const descriptionX = statementMargin + 70
+	for _, line := range lines {
+		l.ensure(statementLeading)
+		l.y -= statementLeading
+		l.w.text(statementMargin, l.y, pdfRegular, statementFontSize, line.Date.Format("01/02"))
+		l.w.text(descriptionX, l.y, pdfRegular, statementFontSize,
+			pdfFit(line.Description, statementFontSize, statementRight-descriptionX-90))
+		l.w.textRight(statementRight, l.y, pdfRegular, statementFontSize, formatMoney(line.Amount))
+	}
+}
+
+// columns prints a label followed by three right-aligned columns
+func (l *statementLayout) columns(font pdfFont, label string, values ...string) {
+	l.ensure(statementLeading)
+	l.y -= statementLeading
+	l.w.text(statementMargin, l.y, font, statementFontSize, label)
+	for i, value := range values {
+		right := statementRight - float64(len(values)-1-i)*140
+		l.w.textRight(right, l.y, font, statementFontSize, value)
+	}
+}
+
+// paymentDueBox prints the new balance, minimum payment and due date with
+// the late payment and minimum payment warnings in a box
+func (l *statementLayout) paymentDueBox(doc *StatementDocument) {
+	const padding = 10.0
+	width := statementRight - statementMargin - 2*padding
+	late := wrapText("Late Payment Warning: "+doc.LatePaymentWarning(), statementFontSize, width)
+	minimum := wrapText("Minimum Payment Warning: "+doc.MinimumPaymentWarning(), statementFontSize, width)
+
+	payoff := doc.PayoffRows()
+
+	height := 2*padding + 3*statementLeading + 8 + float64(len(late)+len(minimum))*12 + 6
+	if len(payoff) > 0 {
+		height += float64(2+len(payoff)) * 12
+	}
+	l.ensure(height + 14)
+	l.y -= 14
+	l.w.rect(statementMargin, l.y-height, statementRight-statementMargin, height)
+
+	y := l.y - padding
+	for _, row := range [][2]string{
+		{"New Balance", formatMoney(doc.Cycle.NewBalance)},
+		{"Minimum Payment Due", formatMoney(doc.Cycle.MinimumPayment)},
+		{"Payment Due Date", formatStatementDate(doc.Cycle.DueDate)},
+	} {
+		y -= statementLeading
+		l.w.text(statementMargin+padding, y, pdfBold, 10, row[0])
+		l.w.textRight(statementRight-padding, y, pdfBold, 10, row[1])
+	}
+	y -= 8
+	for i, paragraph := range [][]string{late, minimum} {
+		if i > 0 {
+			y -= 6
+		}
+		for _, text := range paragraph {
+			y -= 12
+			l.w.text(statementMargin+padding, y, pdfRegular, statementFontSize, text)
+		}
+	}
+
+	// The payoff table: payment, time to pay off and total cost
+	if len(payoff) > 0 {
+		left, middle, right := statementMargin+padding, statementMargin+padding+170, statementRight-padding
+		y -= 6
+		for _, header := range [][3]string{
+			{"If you make no additional", "You will pay off the balance", "And you will end up paying"},
+			{"charges and each month you pay...", "on this statement in about...", "an estimated total of..."},
+		} {
+			y -= 12
+			l.w.text(left, y, pdfBold, statementFontSize, header[0])
+			l.w.text(middle, y, pdfBold, statementFontSize, header[1])
+			l.w.textRight(right, y, pdfBold, statementFontSize, header[2])
+		}
+		for _, row := range payoff {
+			y -= 12
+			l.w.text(left, y, pdfRegular, statementFontSize, row.Payment)
+			l.w.text(middle, y, pdfRegular, statementFontSize, row.Years)
+			l.w.textRight(right, y, pdfRegular, statementFontSize, row.TotalCost)
+		}
+	}
+	l.y -= height
+}
+
+// wrapText breaks text into lines no wider than width
+func wrapText(text string, size, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && pdfTextWidth(candidate, size) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// cashbackCategoryLabel names a cashback category, falling back to its code
+func cashbackCategoryLabel(category models.CategoryCashbackSummary) string {
+	if category.CategoryName != "" {
+		return category.CategoryName
+	}
+	return category.CategoryCode
+}
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// StatementService assembles the cardholder statement for a billing cycle
+type StatementService struct {
+	store           repository.Store
+	cashbackService *CashbackService
+}
+
+// NewStatementService creates a new statement service
+func NewStatementService(db Querier) *StatementService {
+	return NewStatementServiceWithStore(postgres.NewStore(db))
+}
+
+// NewStatementServiceWithStore creates a statement service backed by store
+func NewStatementServiceWithStore(store repository.Store) *StatementService {
+	return &StatementService{
+		store:           store,
+		cashbackService: NewCashbackServiceWithStore(store),
+	}
+}
+
+// withStore returns a copy of the service bound to store
+func (s *StatementService) withStore(store repository.Store) *StatementService {
+	return NewStatementServiceWithStore(store)
+}
+
+// StatementDocument is everything printed on a cardholder statement. It is
+// built from the ledger once and then rendered as HTML or PDF.
+type StatementDocument struct {
+	CardholderName  string
+	AccountEnding   string // Last four digits of the card number
+	Cycle           *models.BillingCycle
+	CreditLimit     decimal.Decimal
+	AvailableCredit decimal.Decimal
+	LatePaymentFee  decimal.Decimal
+	PenaltyAPR      decimal.Decimal
+
+	Credits  []StatementLine // Payments, refunds and other credits
+	Charges  []StatementLine // Purchases, cash advances and balance transfers
+	Fees     []StatementLine
+	Interest []StatementLine
+
+	// Calendar year to date, through the end of the cycle
+	Year        int
+	FeesYTD     decimal.Decimal
+	InterestYTD decimal.Decimal
+
+	Cashback *models.CashbackStatement // Nil when the card does not earn cashback
+}
+
+// StatementLine is one ledger entry as printed on a statement
+type StatementLine struct {
+	Date        time.Time
+	Description string
+	Amount      decimal.Decimal // Negative for credits
+}
+
+// StatementSummaryLine is one row of the account summary
+type StatementSummaryLine struct {
+	Label  string
+	Amount decimal.Decimal
+	Total  bool // The closing new balance
+}
+
+// BuildDocument assembles the statement for a billing cycle from the card,
+// the cycle's ledger entries, year-to-date fee and interest totals and the
+// cashback earned in the cycle
+func (s *StatementService) BuildDocument(
+	ctx context.Context,
+	card *models.CreditCard,
+	cycle *models.BillingCycle,
+) (*StatementDocument, error) {
+	doc := &StatementDocument{
+		CardholderName:  card.CardholderName,
+		AccountEnding:   lastFour(card.CardNumber),
+		Cycle:           cycle,
+		CreditLimit:     card.CreditLimit,
+		AvailableCredit: card.AvailableCredit,
+		LatePaymentFee:  card.LatePaymentFee,
+		PenaltyAPR:      card.APROn(models.APRTypePenalty, cycle.CycleEndDate),
+		Year:            cycle.CycleEndDate.Year(),
+	}
+
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:   &cycle.TenantID,
+		Statuses:   []models.EntryStatus{models.EntryStatusPending, models.EntryStatusCleared},
+		PostedFrom: &cycle.CycleStartDate,
+		PostedTo:   &cycle.CycleEndDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement entries: %w", err)
+	}
+
+	for _, entry := range entries {
+		line := StatementLine{
+			Date:        entry.PostingDate,
+			Description: entry.Description,
+			Amount:      entry.GetSignedAmount(),
+		}
+		if line.Description == "" {
+			line.Description = entryTypeLabel(entry.EntryType)
+		}
+
+		switch {
+		case entry.EntryType == models.EntryTypeFeeInterest:
+			// Interest posts at the end of the cycle that charged it, which is
+			// also the first day of the next one
+			if entry.StatementID != nil && *entry.StatementID == cycle.ID {
+				doc.Interest = append(doc.Interest, line)
+			}
+		case strings.HasPrefix(string(entry.EntryType), "fee_"):
+			doc.Fees = append(doc.Fees, line)
+		case entry.EntryType == models.EntryTypeCashbackEarned:
+			// Shown in the cashback summary instead
+		case line.Amount.IsNegative():
+			doc.Credits = append(doc.Credits, line)
+		default:
+			doc.Charges = append(doc.Charges, line)
+		}
+	}
+
+	yearStart := time.Date(doc.Year, time.January, 1, 0, 0, 0, 0, cycle.CycleEndDate.Location())
+	yearEntries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:   &cycle.TenantID,
+		Statuses:   []models.EntryStatus{models.EntryStatusPending, models.EntryStatusCleared},
+		PostedFrom: &yearStart,
+		PostedTo:   &cycle.CycleEndDate,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list year to date entries: %w", err)
+	}
+	for _, entry := range yearEntries {
+		switch {
+		case entry.EntryType == models.EntryTypeFeeInterest:
+			doc.InterestYTD = doc.InterestYTD.Add(entry.Amount)
+		case strings.HasPrefix(string(entry.EntryType), "fee_"):
+			doc.FeesYTD = doc.FeesYTD.Add(entry.Amount)
+		}
+	}
+
+	if card.CashbackEnabled {
+		cashback, err := s.cashbackService.GetCashbackStatement(ctx, card.ID, cycle.CycleStartDate, cycle.CycleEndDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get cashback statement: %w", err)
+		}
+		cashback.BillingCycleID = cycle.ID
+		doc.Cashback = cashback
+	}
+
+	return doc, nil
+}
+
+// Summary returns the account summary, from the previous balance to the new
+// balance. Adjustments and cashback redemptions are only listed when the
+// cycle has them.
+func (d *StatementDocument) Summary() []StatementSummaryLine {
+	c := d.Cycle
+	lines := []StatementSummaryLine{
+		{Label: "Previous Balance", Amount: c.PreviousBalance},
+		{Label: "Payments", Amount: c.PaymentsReceived.Neg()},
+		{Label: "Other Credits", Amount: c.RefundsAmount.Neg()},
+		{Label: "Purchases", Amount: c.PurchasesAmount},
+		{Label: "Balance Transfers", Amount: c.BalanceTransfersAmount},
+		{Label: "Cash Advances", Amount: c.CashAdvancesAmount},
+		{Label: "Fees Charged", Amount: c.FeesAmount},
+		{Label: "Interest Charged", Amount: c.InterestAmount},
+	}
+	if !c.AdjustmentsAmount.IsZero() {
+		lines = append(lines, StatementSummaryLine{Label: "Adjustments", Amount: c.AdjustmentsAmount})
+	}
+	if !c.CashbackRedeemed.IsZero() {
+		lines = append(lines, StatementSummaryLine{Label: "Cashback Redeemed", Amount: c.CashbackRedeemed.Neg()})
+	}
+	return append(lines, StatementSummaryLine{Label: "New Balance", Amount: c.NewBalance, Total: true})
+}
+
+// FeesTotal returns the fees charged in the cycle
+func (d *StatementDocument) FeesTotal() decimal.Decimal {
+	return sumStatementLines(d.Fees)
+}
+
+// InterestTotal returns the interest charged in the cycle
+func (d *StatementDocument) InterestTotal() decimal.Decimal {
+	return sumStatementLines(d.Interest)
+}
+
+// StatementInterestLine is one row of the interest charge calculation
+type StatementInterestLine struct {
+	Label    string
+	APR      decimal.Decimal
+	Balance  decimal.Decimal // Balance subject to the rate
+	Interest decimal.Decimal
+}
+
+// InterestCalculation returns the interest charge calculation: one row per
+// balance segment, or per rate when a segment's APR changed during the
+// cycle. Cycles without a segment breakdown are shown as purchases.
+func (d *StatementDocument) InterestCalculation() []StatementInterestLine {
+	c := d.Cycle
+	if len(c.Segments) == 0 {
+		return []StatementInterestLine{{
+			Label:    models.SegmentPurchase.Label(),
+			APR:      c.APRApplied,
+			Balance:  c.AverageDailyBalance,
+			Interest: c.InterestAmount,
+		}}
+	}
+
+	var lines []StatementInterestLine
+	for _, segment := range c.Segments {
+		if len(segment.Rates) == 0 {
+			lines = append(lines, StatementInterestLine{
+				Label:    segment.Segment.Label(),
+				APR:      segment.APR,
+				Balance:  segment.AverageDailyBalance,
+				Interest: segment.InterestCharge,
+			})
+			continue
+		}
+		for _, rate := range segment.Rates {
+			lines = append(lines, StatementInterestLine{
+				Label:    segment.Segment.Label() + " from " + rate.From.Format("Jan 2"),
+				APR:      rate.APR,
+				Balance:  rate.AverageDailyBalance,
+				Interest: rate.InterestCharge,
+			})
+		}
+	}
+	return lines
+}
+
+// LatePaymentWarning returns the late payment warning printed with the
+// payment due date
+func (d *StatementDocument) LatePaymentWarning() string {
+	return fmt.Sprintf("If we do not receive your minimum payment by the date listed above, "+
+		"you may have to pay a late fee of up to %s and your APRs may be increased up to the Penalty APR of %s.",
+		formatMoney(d.LatePaymentFee), formatPercent(d.PenaltyAPR))
+}
+
+// MinimumPaymentWarning returns the minimum payment warning printed with the
+// payment due date. When the minimum payment does not cover the interest,
+// the warning says the balance will never be repaid instead.
+func (d *StatementDocument) MinimumPaymentWarning() string {
+	if payoff := d.Cycle.Payoff; payoff != nil && payoff.MinimumOnly.NeverPaysOff {
+		return "Even if you make no more charges using this card, if you make only the minimum payment " +
+			"each month we estimate you will never be able to pay off the balance shown on this statement " +
+			"because your payment will be less than the interest charged each month."
+	}
+	warning := "If you make only the minimum payment each period, you will pay more in interest " +
+		"and it will take you longer to pay off your balance."
+	if len(d.PayoffRows()) > 0 {
+		warning += " For example:"
+	}
+	return warning
+}
+
+// StatementPayoffRow is one row of the minimum payment warning table
+type StatementPayoffRow struct {
+	Payment   string // What is paid each month
+	Years     string // Time to pay off the balance
+	TotalCost string // Estimated total paid, with any savings
+}
+
+// PayoffRows returns the minimum payment warning table, with dollar amounts
+// rounded to the nearest dollar. It is empty when there is no projection or
+// the minimum payment never repays the balance.
+func (d *StatementDocument) PayoffRows() []StatementPayoffRow {
+	payoff := d.Cycle.Payoff
+	if payoff == nil || payoff.MinimumOnly.NeverPaysOff {
+		return nil
+	}
+
+	rows := []StatementPayoffRow{{
+		Payment:   "Only the minimum payment",
+		Years:     payoff.MinimumOnly.PayoffYears(),
+		TotalCost: formatWholeDollars(payoff.MinimumOnly.TotalCost),
+	}}
+	if plan := payoff.ThirtySixMonth; plan != nil {
+		rows = append(rows, StatementPayoffRow{
+			Payment: formatWholeDollars(plan.MonthlyPayment),
+			Years:   plan.PayoffYears(),
+			TotalCost: fmt.Sprintf("%s (Savings = %s)",
+				formatWholeDollars(plan.TotalCost), formatWholeDollars(payoff.Savings())),
+		})
+	}
+	return rows
+}
+
+func sumStatementLines(lines []StatementLine) decimal.Decimal {
+	total := decimal.Zero
+	for _, line := range lines {
+		total = total.Add(line.Amount)
+	}
+	return total
+}
+
+// entryTypeLabel describes an entry that has no description of its own
+func entryTypeLabel(entryType models.StatementEntryType) string {
+	label := strings.ReplaceAll(strings.TrimPrefix(string(entryType), "fee_"), "_", " ")
+	if label == "" {
+		return ""
+	}
+	if strings.HasPrefix(string(entryType), "fee_") {
+		label += " fee"
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// lastFour returns the last four characters of a card number
+func lastFour(cardNumber string) string {
+	if len(cardNumber) <= 4 {
+		return cardNumber
+	}
+	return cardNumber[len(cardNumber)-4:]
+}
+
+// formatMoney formats an amount in dollars with thousands separators
+func formatMoney(amount decimal.Decimal) string {
+	sign := ""
+	if amount.IsNegative() {
+		sign = "-"
+	}
+	digits := amount.Abs().StringFixed(2)
+	whole, cents := digits[:len(digits)-3], digits[len(digits)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + "$" + whole + cents
+}
+
+// formatWholeDollars formats an amount rounded to the nearest dollar
+func formatWholeDollars(amount decimal.Decimal) string {
+	money := formatMoney(amount.Round(0))
+	return strings.TrimSuffix(money, ".00")
+}
+
+// formatPercent formats a rate held as a percentage, such as an APR
+func formatPercent(rate decimal.Decimal) string {
+	return rate.StringFixed(2) + "%"
+}
+
+// formatStatementDate formats a date as printed on statements
+func formatStatementDate(date time.Time) string {
+	return date.Format("Jan 2, 2006")
+}
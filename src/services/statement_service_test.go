@@ -0,0 +1,220 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// statementFixture generates a February statement for a card that carried a
+// January balance, paid part of it and made new purchases
+func statementFixture(t *testing.T) (repository.Store, *models.CreditCard, *StatementGenerationResult) {
+	t.Helper()
+	ctx := context.Background()
+	store := memory.NewStore()
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2026, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	card := testCard()
+	card.CardholderName = "Jane Q. Cardholder"
+	card.CardNumber = "************4242"
+	card.CreatedAt = day(time.January, 1)
+	lastStatement := day(time.January, 31)
+	card.LastStatementDate = &lastStatement
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	cardService := NewCreditCardServiceWithStore(store)
+	ledgerService := NewStatementLedgerServiceWithStore(store)
+	purchase := func(amount decimal.Decimal, merchant, description, category string, on time.Time) {
+		recorded, err := cardService.RecordTransaction(ctx, CCTransactionRequest{
+			CreditCard:       card,
+			Amount:           amount,
+			Description:      description,
+			MerchantName:     merchant,
+			MerchantCategory: category,
+			TransactionDate:  on,
+			PostingDate:      on,
+		})
+		if err != nil {
+			t.Fatalf("failed to record purchase: %v", err)
+		}
+		if err := ledgerService.ClearEntry(ctx, recorded.TransactionEntry.ID); err != nil {
+			t.Fatalf("failed to clear purchase: %v", err)
+		}
+	}
+	entry := func(entryType models.StatementEntryType, amount int64, description string, on time.Time) {
+		err := store.StatementEntries().Create(ctx, &models.StatementLedgerEntry{
+			TenantID:    card.TenantID,
+			EntryType:   entryType,
+			EntryDate:   on,
+			PostingDate: on,
+			Amount:      decimal.NewFromInt(amount),
+			Description: description,
+			Status:      models.EntryStatusCleared,
+		})
+		if err != nil {
+			t.Fatalf("failed to create entry: %v", err)
+		}
+	}
+
+	// January, billed on the previous statement and not paid in full
+	purchase(decimal.NewFromInt(500), "Hardware Store", "Shelving", "5200", day(time.January, 20))
+	entry(models.EntryTypeFeeAnnual, 95, "Annual membership fee", day(time.January, 15))
+	err := store.BillingCycles().Create(ctx, &models.BillingCycle{
+		CreditCardID:   card.ID,
+		TenantID:       card.TenantID,
+		CycleNumber:    1,
+		CycleStartDate: day(time.January, 1),
+		CycleEndDate:   lastStatement,
+		NewBalance:     decimal.NewFromInt(595),
+		Status:         models.BillingCycleStatusClosed,
+	})
+	if err != nil {
+		t.Fatalf("failed to create previous cycle: %v", err)
+	}
+
+	// February
+	entry(models.EntryTypePayment, 200, "Payment - Thank You", day(time.February, 10))
+	purchase(decimal.NewFromFloat(120.50), "Corner Grocery", "Groceries", "5411", day(time.February, 12))
+	purchase(decimal.NewFromInt(1000), "Airline", "Tickets (round trip)", "4511", day(time.February, 18))
+	entry(models.EntryTypeRefund, 20, "Refund - Corner Grocery", day(time.February, 20))
+	entry(models.EntryTypeFeeLate, 35, "", day(time.February, 26))
+
+	result, err := NewBillingServiceWithStore(store).GenerateStatement(ctx, GenerateStatementRequest{
+		CreditCard: card,
+		CycleEnd:   day(time.February, 28),
+	})
+	if err != nil {
+		t.Fatalf("failed to generate statement: %v", err)
+	}
+	return store, card, result
+}
+
+func TestStatementService_BuildDocument(t *testing.T) {
+	store, card, result := statementFixture(t)
+	cycle := result.BillingCycle
+	doc, err := NewStatementServiceWithStore(store).BuildDocument(context.Background(), card, cycle)
+	if err != nil {
+		t.Fatalf("failed to build document: %v", err)
+	}
+
+	if len(doc.Credits) != 2 || len(doc.Charges) != 2 {
+		t.Errorf("credits = %d, charges = %d, want 2 and 2", len(doc.Credits), len(doc.Charges))
+	}
+	if len(doc.Fees) != 1 || doc.Fees[0].Description != "Late fee" {
+		t.Errorf("fees = %+v, want the late fee", doc.Fees)
+	}
+	if !doc.InterestTotal().Equal(cycle.InterestAmount) || !cycle.InterestAmount.IsPositive() {
+		t.Errorf("interest lines total %s, want %s", doc.InterestTotal(), cycle.InterestAmount)
+	}
+
+	// January's annual fee counts toward the year but not the cycle
+	if !doc.FeesYTD.Equal(decimal.NewFromInt(130)) {
+		t.Errorf("fees YTD = %s, want 130", doc.FeesYTD)
+	}
+	if !doc.InterestYTD.Equal(cycle.InterestAmount) {
+		t.Errorf("interest YTD = %s, want %s", doc.InterestYTD, cycle.InterestAmount)
+	}
+
+	// The summary adds up to the new balance
+	total := decimal.Zero
+	for _, line := range doc.Summary() {
+		if !line.Total {
+			total = total.Add(line.Amount)
+		}
+	}
+	if !total.Equal(cycle.NewBalance) {
+		t.Errorf("summary adds to %s, want new balance %s", total, cycle.NewBalance)
+	}
+}
+
+func TestRenderStatement_Golden(t *testing.T) {
+	_, _, result := statementFixture(t)
+
+	tests := []struct {
+		golden string
+		got    []byte
+	}{
+		{"statement.html", result.StatementHTML},
+		{"statement.pdf", result.StatementPDF},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.golden)
+			if *updateGolden {
+				if err := os.WriteFile(path, tt.got, 0o644); err != nil {
+					t.Fatalf("failed to update golden file: %v", err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(tt.got, want) {
+				t.Errorf("%s differs from the golden file; run go test -update and review the diff", tt.golden)
+			}
+		})
+	}
+}
+
+func TestRenderStatementPDF_CrossReferences(t *testing.T) {
+	// Enough transactions to run onto a second page
+	doc := &StatementDocument{
+		CardholderName: "Jane Q. Cardholder",
+		AccountEnding:  "4242",
+		Cycle:          &models.BillingCycle{CycleEndDate: time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)},
+		Year:           2026,
+	}
+	for i := 0; i < 80; i++ {
+		doc.Charges = append(doc.Charges, StatementLine{
+			Date:        doc.Cycle.CycleEndDate,
+			Description: fmt.Sprintf("Purchase %d (\\ test)", i),
+			Amount:      decimal.NewFromInt(int64(i)),
+		})
+	}
+	pdf := RenderStatementPDF(doc)
+
+	if count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(pdf); count == nil || string(count[1]) == "1" {
+		t.Errorf("expected more than one page, got %q", count)
+	}
+
+	// Every object the cross-reference table lists starts at its offset
+	startxref := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(pdf)
+	if startxref == nil {
+		t.Fatalf("missing startxref trailer")
+	}
+	xref, _ := strconv.Atoi(string(startxref[1]))
+	offsets := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	if len(offsets) == 0 {
+		t.Fatalf("empty cross-reference table")
+	}
+	for i, match := range offsets {
+		offset, _ := strconv.Atoi(string(match[1]))
+		want := fmt.Sprintf("%d 0 obj", i+1)
+		if !bytes.HasPrefix(pdf[offset:], []byte(want)) {
+			t.Errorf("object %d is not at offset %d", i+1, offset)
+		}
+	}
+
+	if !bytes.Contains(pdf, []byte(`(Purchase 0 \(\\ test\))`)) {
+		t.Errorf("description was not escaped")
+	}
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository"
+	"github.com/livefire2015/ez-ledger/src/repository/postgres"
+	"github.com/shopspring/decimal"
+)
+
+// TransactionExportFormat names a cardholder activity download format
+type TransactionExportFormat string
+
+const (
+	TransactionExportOFX  TransactionExportFormat = "ofx"
+	TransactionExportQFX  TransactionExportFormat = "qfx" // OFX with Intuit's bank ID, for Quicken
+	TransactionExportCSV  TransactionExportFormat = "csv"
+	TransactionExportJSON TransactionExportFormat = "json"
+)
+
+// TransactionExportService exports a card's statement ledger activity for
+// cardholders and support
+type TransactionExportService struct {
+	store         repository.Store
+	ledgerService *StatementLedgerService
+}
+
+// NewTransactionExportService creates a new transaction export service
+func NewTransactionExportService(db Querier) *TransactionExportService {
+	return NewTransactionExportServiceWithStore(postgres.NewStore(db))
+}
+
+// NewTransactionExportServiceWithStore creates a transaction export service backed by store
+func NewTransactionExportServiceWithStore(store repository.Store) *TransactionExportService {
+	return &TransactionExportService{
+		store:         store,
+		ledgerService: NewStatementLedgerServiceWithStore(store),
+	}
+}
+
+// TransactionExportRequest selects the activity to export: a billing cycle,
+// or a date range when BillingCycleID is nil
+type TransactionExportRequest struct {
+	CreditCardID   uuid.UUID
+	BillingCycleID *uuid.UUID
+	From           time.Time // Inclusive
+	To             time.Time // Inclusive
+	IncludePending bool      // Pending entries are left out unless set
+}
+
+// ExportedTransaction is one statement ledger entry in an export
+type ExportedTransaction struct {
+	ID               uuid.UUID                 `json:"id"`
+	Type             models.StatementEntryType `json:"type"`
+	Status           models.EntryStatus        `json:"status"`
+	TransactionDate  time.Time                 `json:"transaction_date"`
+	PostingDate      time.Time                 `json:"posting_date"`
+	Amount           decimal.Decimal           `json:"amount"` // Positive for charges, negative for credits
+	Description      string                    `json:"description"`
+	MerchantName     string                    `json:"merchant_name,omitempty"`
+	MerchantCategory string                    `json:"merchant_category,omitempty"`
+	ReferenceID      string                    `json:"reference_id,omitempty"`
+}
+
+// TransactionExport is a card's activity over a date range
+type TransactionExport struct {
+	CreditCardID    uuid.UUID             `json:"credit_card_id"`
+	CardNumber      string                `json:"card_number"` // Masked, as stored on the card
+	BillingCycleID  *uuid.UUID            `json:"billing_cycle_id,omitempty"`
+	From            time.Time             `json:"from"`
+	To              time.Time             `json:"to"`
+	Transactions    []ExportedTransaction `json:"transactions"`
+	Balance         decimal.Decimal       `json:"balance"` // Cleared balance owed at the end of the range
+	AvailableCredit decimal.Decimal       `json:"available_credit"`
+	GeneratedAt     time.Time             `json:"generated_at"`
+}
+
+// Export lists the card's statement ledger entries posted in the billing
+// cycle or date range. Cashback earned is tracked in the cashback ledger
+// and left out.
+func (s *TransactionExportService) Export(ctx context.Context, req TransactionExportRequest) (*TransactionExport, error) {
+	card, err := s.store.CreditCards().GetByID(ctx, req.CreditCardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credit card: %w", err)
+	}
+
+	from, to := req.From, req.To
+	if req.BillingCycleID != nil {
+		cycle, err := s.store.BillingCycles().GetByID(ctx, *req.BillingCycleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get billing cycle: %w", err)
+		}
+		if cycle.CreditCardID != card.ID {
+			return nil, fmt.Errorf("billing cycle %s is not for card %s", cycle.ID, card.ID)
+		}
+		from, to = cycle.CycleStartDate, cycle.CycleEndDate
+	}
+	if to.Before(from) {
+		return nil, errors.New("export range ends before it starts")
+	}
+
+	statuses := []models.EntryStatus{models.EntryStatusCleared}
+	if req.IncludePending {
+		statuses = append(statuses, models.EntryStatusPending)
+	}
+	entries, err := s.store.StatementEntries().List(ctx, repository.StatementEntryFilter{
+		TenantID:   &card.TenantID,
+		Statuses:   statuses,
+		PostedFrom: &from,
+		PostedTo:   &to,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list statement entries: %w", err)
+	}
+
+	balance, err := s.ledgerService.CalculateStatementBalance(ctx, card.TenantID, time.Time{}, to, decimal.Zero)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate statement balance: %w", err)
+	}
+
+	export := &TransactionExport{
+		CreditCardID:    card.ID,
+		CardNumber:      card.CardNumber,
+		BillingCycleID:  req.BillingCycleID,
+		From:            from,
+		To:              to,
+		Transactions:    make([]ExportedTransaction, 0, len(entries)),
+		Balance:         balance,
+		AvailableCredit: card.AvailableCredit,
+		GeneratedAt:     time.Now(),
+	}
+	for _, entry := range entries {
+		if entry.EntryType == models.EntryTypeCashbackEarned {
+			continue
+		}
+
+		transaction := ExportedTransaction{
+			ID:              entry.ID,
+			Type:            entry.EntryType,
+			Status:          entry.Status,
+			TransactionDate: entry.EntryDate,
+			PostingDate:     entry.PostingDate,
+			Amount:          entry.GetSignedAmount(),
+			Description:     entry.Description,
+		}
+		if transaction.Description == "" {
+			transaction.Description = entryTypeLabel(entry.EntryType)
+		}
+		transaction.MerchantName, _ = entry.Metadata["merchant_name"].(string)
+		transaction.MerchantCategory, _ = entry.Metadata["merchant_category"].(string)
+		if entry.ReferenceID != nil {
+			transaction.ReferenceID = *entry.ReferenceID
+		}
+		export.Transactions = append(export.Transactions, transaction)
+	}
+
+	return export, nil
+}
+
+// Write writes the export in the given format. The QFX format needs the
+// institution's Intuit bank ID; the other formats ignore institution.
+func (e *TransactionExport) Write(w io.Writer, format TransactionExportFormat, institution OFXInstitution) error {
+	switch format {
+	case TransactionExportOFX:
+		institution.IntuitBankID = ""
+		return e.WriteOFX(w, institution)
+	case TransactionExportQFX:
+		if institution.IntuitBankID == "" {
+			return errors.New("QFX export needs an Intuit bank ID")
+		}
+		return e.WriteOFX(w, institution)
+	case TransactionExportCSV:
+		return e.WriteCSV(w)
+	case TransactionExportJSON:
+		return e.WriteJSON(w)
+	default:
+		return fmt.Errorf("unsupported export format: %s", format)
+	}
+}
+
+// transactionCSVColumns are the CSV export's header row
+var transactionCSVColumns = []string{
+	"transaction_date", "posting_date", "description", "merchant_name", "merchant_category",
+	"type", "status", "amount", "reference_id", "id",
+}
+
+// WriteCSV writes one row per transaction with a header row
+func (e *TransactionExport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	rows := [][]string{transactionCSVColumns}
+	for _, t := range e.Transactions {
+		rows = append(rows, []string{
+			t.TransactionDate.Format("2006-01-02"),
+			t.PostingDate.Format("2006-01-02"),
+			t.Description,
+			t.MerchantName,
+			t.MerchantCategory,
+			string(t.Type),
+			string(t.Status),
+			t.Amount.StringFixed(2),
+			t.ReferenceID,
+			t.ID.String(),
+		})
+	}
+
+	if err := cw.WriteAll(rows); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
+
+// WriteJSON writes the export as an indented JSON document
+func (e *TransactionExport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(e); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// OFXInstitution identifies the card issuer in OFX and QFX downloads
+type OFXInstitution struct {
+	Organization string // FI/ORG
+	ID           string // FI/FID
+	IntuitBankID string // INTU.BID; set for QFX
+}
+
+// WriteOFX writes the export as an OFX 2.2 credit card statement response.
+// OFX amounts are from the cardholder's side: charges are negative and
+// payments positive, and the ledger balance owed is negative.
+func (e *TransactionExport) WriteOFX(w io.Writer, institution OFXInstitution) error {
+	serverTime := ofxDateTime(e.GeneratedAt)
+	status := ofxStatus{Code: 0, Severity: "INFO"}
+
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:     status,
+			ServerTime: serverTime,
+			Language:   "ENG",
+			IntuitBID:  institution.IntuitBankID,
+		},
+		CreditCard: ofxCreditCardMessages{
+			Response: ofxStatementTransaction{
+				TransactionUID: "0",
+				Status:         status,
+				Statement: ofxStatement{
+					Currency: "USD",
+					Account:  ofxAccount{ID: e.ofxAccountID()},
+					Transactions: ofxTransactionList{
+						Start: ofxDate(e.From),
+						End:   ofxDate(e.To),
+					},
+					LedgerBalance:    ofxBalance{Amount: e.Balance.Neg().StringFixed(2), AsOf: ofxDate(e.To)},
+					AvailableBalance: ofxBalance{Amount: e.AvailableCredit.StringFixed(2), AsOf: serverTime},
+				},
+			},
+		},
+	}
+	if institution.Organization != "" || institution.ID != "" {
+		doc.SignOn.Institution = &ofxInstitution{Organization: institution.Organization, ID: institution.ID}
+	}
+	for _, t := range e.Transactions {
+		name := t.MerchantName
+		if name == "" {
+			name = t.Description
+		}
+		transaction := ofxTransaction{
+			Type:      ofxTransactionType(t),
+			Posted:    ofxDate(t.PostingDate),
+			User:      ofxDate(t.TransactionDate),
+			Amount:    t.Amount.Neg().StringFixed(2),
+			ID:        t.ID.String(),
+			Reference: t.ReferenceID,
+			Name:      truncateRunes(name, 32),
+			Category:  t.MerchantCategory,
+		}
+		if t.Description != name {
+			transaction.Memo = truncateRunes(t.Description, 255)
+		}
+		doc.CreditCard.Response.Statement.Transactions.Items = append(doc.CreditCard.Response.Statement.Transactions.Items, transaction)
+	}
+
+	header := xml.Header + `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return fmt.Errorf("failed to write OFX: %w", err)
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write OFX: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write OFX: %w", err)
+	}
+	return nil
+}
+
+// OFX 2.2 credit card statement download, reduced to the aggregates we send
+type ofxDocument struct {
+	XMLName    xml.Name              `xml:"OFX"`
+	SignOn     ofxSignOn             `xml:"SIGNONMSGSRSV1>SONRS"`
+	CreditCard ofxCreditCardMessages `xml:"CREDITCARDMSGSRSV1"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxInstitution struct {
+	Organization string `xml:"ORG,omitempty"`
+	ID           string `xml:"FID,omitempty"`
+}
+
+type ofxSignOn struct {
+	Status      ofxStatus       `xml:"STATUS"`
+	ServerTime  string          `xml:"DTSERVER"`
+	Language    string          `xml:"LANGUAGE"`
+	Institution *ofxInstitution `xml:"FI,omitempty"`
+	IntuitBID   string          `xml:"INTU.BID,omitempty"`
+}
+
+type ofxCreditCardMessages struct {
+	Response ofxStatementTransaction `xml:"CCSTMTTRNRS"`
+}
+
+type ofxStatementTransaction struct {
+	TransactionUID string       `xml:"TRNUID"`
+	Status         ofxStatus    `xml:"STATUS"`
+	Statement      ofxStatement `xml:"CCSTMTRS"`
+}
+
+type ofxStatement struct {
+	Currency         string             `xml:"CURDEF"`
+	Account          ofxAccount         `xml:"CCACCTFROM"`
+	Transactions     ofxTransactionList `xml:"BANKTRANLIST"`
+	LedgerBalance    ofxBalance         `xml:"LEDGERBAL"`
+	AvailableBalance ofxBalance         `xml:"AVAILBAL"`
+}
+
+type ofxAccount struct {
+	ID string `xml:"ACCTID"`
+}
+
+type ofxTransactionList struct {
+	Start string           `xml:"DTSTART"`
+	End   string           `xml:"DTEND"`
+	Items []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	Type      string `xml:"TRNTYPE"`
+	Posted    string `xml:"DTPOSTED"`
+	User      string `xml:"DTUSER"`
+	Amount    string `xml:"TRNAMT"`
+	ID        string `xml:"FITID"`
+	Reference string `xml:"REFNUM,omitempty"`
+	Category  string `xml:"SIC,omitempty"`
+	Name      string `xml:"NAME"`
+	Memo      string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// ofxAccountID returns the card number, or the card ID cut to the 22
+// characters OFX allows when the card has no number
+func (e *TransactionExport) ofxAccountID() string {
+	if e.CardNumber != "" {
+		return e.CardNumber
+	}
+	return truncateRunes(strings.ReplaceAll(e.CreditCardID.String(), "-", ""), 22)
+}
+
+// ofxTransactionType maps an entry type to an OFX TRNTYPE
+func ofxTransactionType(t ExportedTransaction) string {
+	switch {
+	case t.Type == models.EntryTypePayment:
+		return "PAYMENT"
+	case t.Type == models.EntryTypeFeeInterest:
+		return "INT"
+	case strings.HasPrefix(string(t.Type), "fee_"):
+		return "FEE"
+	case t.Type == models.EntryTypeCashAdvance:
+		return "CASH"
+	case t.Amount.IsNegative():
+		return "CREDIT"
+	default:
+		return "DEBIT"
+	}
+}
+
+func ofxDate(t time.Time) string {
+	return t.UTC().Format("20060102")
+}
+
+func ofxDateTime(t time.Time) string {
+	return t.UTC().Format("20060102150405")
+}
+
+// truncateRunes shortens s to at most n characters
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
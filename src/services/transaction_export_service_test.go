@@ -0,0 +1,186 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/livefire2015/ez-ledger/src/repository/memory"
+	"github.com/shopspring/decimal"
+)
+
+func TestTransactionExportService_Export(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	day := func(d int) time.Time { return time.Date(2026, time.May, d, 0, 0, 0, 0, time.UTC) }
+
+	card := testCard()
+	card.CardNumber = "************4242"
+	if err := store.CreditCards().Create(ctx, card); err != nil {
+		t.Fatalf("failed to create card: %v", err)
+	}
+
+	recorded, err := NewCreditCardServiceWithStore(store).RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:       card,
+		Amount:           decimal.NewFromFloat(42.50),
+		Description:      "Dinner",
+		MerchantName:     "Bistro & Bar",
+		MerchantCategory: "5812",
+		TransactionDate:  day(3),
+		PostingDate:      day(4),
+		ReferenceID:      "REF-DINNER",
+	})
+	if err != nil {
+		t.Fatalf("failed to record purchase: %v", err)
+	}
+	if err := NewStatementLedgerServiceWithStore(store).ClearEntry(ctx, recorded.TransactionEntry.ID); err != nil {
+		t.Fatalf("failed to clear purchase: %v", err)
+	}
+
+	entries := []*models.StatementLedgerEntry{
+		{EntryType: models.EntryTypePayment, Amount: decimal.NewFromInt(30), PostingDate: day(10), Description: "Payment received"},
+		{EntryType: models.EntryTypeFeeLate, Amount: decimal.NewFromInt(35), PostingDate: day(12)},
+		// Pending and out of range entries are only exported when asked for
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(15), PostingDate: day(20), Status: models.EntryStatusPending},
+		{EntryType: models.EntryTypeTransaction, Amount: decimal.NewFromInt(70), PostingDate: day(31).AddDate(0, 0, 3)},
+	}
+	for _, entry := range entries {
+		entry.TenantID = card.TenantID
+		entry.EntryDate = entry.PostingDate
+		if entry.Status == "" {
+			entry.Status = models.EntryStatusCleared
+		}
+		if err := store.StatementEntries().Create(ctx, entry); err != nil {
+			t.Fatalf("failed to create entry: %v", err)
+		}
+	}
+
+	cycle := &models.BillingCycle{
+		CreditCardID:   card.ID,
+		TenantID:       card.TenantID,
+		CycleNumber:    1,
+		CycleStartDate: day(1),
+		CycleEndDate:   day(31),
+		Status:         models.BillingCycleStatusOpen,
+	}
+	if err := store.BillingCycles().Create(ctx, cycle); err != nil {
+		t.Fatalf("failed to create cycle: %v", err)
+	}
+
+	service := NewTransactionExportServiceWithStore(store)
+
+	withPending, err := service.Export(ctx, TransactionExportRequest{
+		CreditCardID:   card.ID,
+		From:           day(1),
+		To:             day(31),
+		IncludePending: true,
+	})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if len(withPending.Transactions) != 4 {
+		t.Errorf("range export with pending has %d transactions, want 4", len(withPending.Transactions))
+	}
+
+	export, err := service.Export(ctx, TransactionExportRequest{CreditCardID: card.ID, BillingCycleID: &cycle.ID})
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if len(export.Transactions) != 3 {
+		t.Fatalf("cycle export has %d transactions, want 3", len(export.Transactions))
+	}
+	dinner := export.Transactions[0]
+	if dinner.MerchantName != "Bistro & Bar" || dinner.MerchantCategory != "5812" || dinner.ReferenceID != "REF-DINNER" {
+		t.Errorf("merchant details not exported: %+v", dinner)
+	}
+	// 42.50 purchase + 35 fee - 30 payment
+	if !export.Balance.Equal(decimal.NewFromFloat(47.50)) {
+		t.Errorf("balance = %s, want 47.50", export.Balance)
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := export.Write(&out, TransactionExportCSV, OFXInstitution{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		rows, err := csv.NewReader(&out).ReadAll()
+		if err != nil {
+			t.Fatalf("invalid CSV: %v", err)
+		}
+		if len(rows) != 4 || rows[1][7] != "42.50" || rows[2][2] != "Payment received" || rows[3][2] != "Late fee" {
+			t.Errorf("unexpected rows: %v", rows)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := export.Write(&out, TransactionExportJSON, OFXInstitution{}); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		var decoded TransactionExport
+		if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+			t.Fatalf("invalid JSON: %v", err)
+		}
+		if len(decoded.Transactions) != 3 || !decoded.Transactions[1].Amount.Equal(decimal.NewFromInt(-30)) {
+			t.Errorf("unexpected transactions: %+v", decoded.Transactions)
+		}
+	})
+
+	institution := OFXInstitution{Organization: "EZ Bank", ID: "1234", IntuitBankID: "56789"}
+	for _, tt := range []struct {
+		format   TransactionExportFormat
+		wantBank string
+	}{
+		{TransactionExportOFX, ""},
+		{TransactionExportQFX, "56789"},
+	} {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var out bytes.Buffer
+			if err := export.Write(&out, tt.format, institution); err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if !strings.Contains(out.String(), `<?OFX OFXHEADER="200" VERSION="220"`) {
+				t.Errorf("missing OFX header")
+			}
+
+			var doc ofxDocument
+			if err := xml.Unmarshal(out.Bytes(), &doc); err != nil {
+				t.Fatalf("invalid OFX: %v", err)
+			}
+			if doc.SignOn.IntuitBID != tt.wantBank {
+				t.Errorf("INTU.BID = %q, want %q", doc.SignOn.IntuitBID, tt.wantBank)
+			}
+			statement := doc.CreditCard.Response.Statement
+			if statement.Account.ID != "************4242" || statement.LedgerBalance.Amount != "-47.50" {
+				t.Errorf("account %q balance %q", statement.Account.ID, statement.LedgerBalance.Amount)
+			}
+
+			// Charges are negative and payments positive from the cardholder's side
+			want := []struct{ kind, amount, name string }{
+				{"DEBIT", "-42.50", "Bistro & Bar"},
+				{"PAYMENT", "30.00", "Payment received"},
+				{"FEE", "-35.00", "Late fee"},
+			}
+			items := statement.Transactions.Items
+			if len(items) != len(want) {
+				t.Fatalf("%d transactions, want %d", len(items), len(want))
+			}
+			for i, w := range want {
+				if items[i].Type != w.kind || items[i].Amount != w.amount || items[i].Name != w.name {
+					t.Errorf("transaction %d = %s %s %s, want %s %s %s",
+						i, items[i].Type, items[i].Amount, items[i].Name, w.kind, w.amount, w.name)
+				}
+			}
+		})
+	}
+
+	if err := export.Write(&bytes.Buffer{}, TransactionExportQFX, OFXInstitution{}); err == nil {
+		t.Errorf("QFX without an Intuit bank ID should fail")
+	}
+}
@@ -0,0 +1,400 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/shopspring/decimal"
+)
+
+// fakeStore is a minimal database/sql backend that records which tables were
+// written and whether those writes were committed, rolled back or autocommitted.
+// Like Postgres, a failed statement aborts the transaction until it is rolled
+// back to a savepoint.
+type fakeStore struct {
+	mu         sync.Mutex
+	failOn     string
+	committed  map[string]int
+	autocommit int
+	rows       map[string]fakeRow
+
+	// Inserts into duplicateOn fail with a unique violation, as if another
+	// transaction had just committed the rows in concurrent
+	duplicateOn string
+	concurrent  map[string]fakeRow
+}
+
+type fakeRow struct {
+	columns []string
+	values  []driver.Value
+}
+
+var writeTable = regexp.MustCompile(`(INSERT INTO|UPDATE)\s+(\w+)`)
+
+var errTxAborted = errors.New("pq: current transaction is aborted, commands ignored until end of transaction block")
+
+func newFakeDB(t *testing.T, store *fakeStore) *sql.DB {
+	t.Helper()
+	if store.committed == nil {
+		store.committed = make(map[string]int)
+	}
+	db := sql.OpenDB(fakeConnector{store: store})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func (s *fakeStore) totalCommitted() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := 0
+	for _, n := range s.committed {
+		total += n
+	}
+	return total
+}
+
+type fakeConnector struct{ store *fakeStore }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{store: c.store}, nil
+}
+
+func (c fakeConnector) Driver() driver.Driver { return fakeDriver{} }
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) { return nil, errors.New("use fakeConnector") }
+
+type fakeConn struct {
+	store      *fakeStore
+	inTx       bool
+	aborted    bool
+	pending    []string
+	savepoints []int // Length of pending at each open savepoint
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("not supported") }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.inTx = true
+	c.aborted = false
+	c.pending = nil
+	c.savepoints = nil
+	return fakeTx{conn: c}, nil
+}
+
+// CheckNamedValue accepts every argument as-is, including metadata maps
+func (c *fakeConn) CheckNamedValue(*driver.NamedValue) error { return nil }
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	switch {
+	case strings.HasPrefix(query, "SAVEPOINT"):
+		c.savepoints = append(c.savepoints, len(c.pending))
+		return driver.RowsAffected(0), nil
+	case strings.HasPrefix(query, "ROLLBACK TO SAVEPOINT"):
+		c.pending = c.pending[:c.savepoints[len(c.savepoints)-1]]
+		c.aborted = false
+		return driver.RowsAffected(0), nil
+	case c.aborted:
+		return nil, errTxAborted
+	case strings.HasPrefix(query, "RELEASE SAVEPOINT"):
+		c.savepoints = c.savepoints[:len(c.savepoints)-1]
+		return driver.RowsAffected(0), nil
+	}
+
+	m := writeTable.FindStringSubmatch(query)
+	if m == nil {
+		return driver.RowsAffected(0), nil
+	}
+	table := m[2]
+	if table == c.store.failOn {
+		c.aborted = c.inTx
+		return nil, errors.New("forced failure writing " + table)
+	}
+	if table == c.store.duplicateOn {
+		c.aborted = c.inTx
+		for key, row := range c.store.concurrent {
+			c.store.rows[key] = row
+		}
+		return nil, &pq.Error{Code: "23505", Message: "duplicate key value violates unique constraint"}
+	}
+
+	if c.inTx {
+		c.pending = append(c.pending, table)
+	} else {
+		c.store.mu.Lock()
+		c.store.committed[table]++
+		c.store.autocommit++
+		c.store.mu.Unlock()
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	if c.aborted {
+		return nil, errTxAborted
+	}
+	for key, row := range c.store.rows {
+		if strings.Contains(query, key) {
+			return &fakeRows{columns: row.columns, values: [][]driver.Value{row.values}}, nil
+		}
+	}
+	return &fakeRows{columns: []string{"none"}}, nil
+}
+
+type fakeTx struct{ conn *fakeConn }
+
+func (tx fakeTx) Commit() error {
+	if tx.conn.aborted {
+		tx.conn.inTx = false
+		tx.conn.pending = nil
+		return errTxAborted
+	}
+	tx.conn.store.mu.Lock()
+	for _, table := range tx.conn.pending {
+		tx.conn.store.committed[table]++
+	}
+	tx.conn.store.mu.Unlock()
+	tx.conn.inTx = false
+	tx.conn.pending = nil
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.conn.inTx = false
+	tx.conn.pending = nil
+	return nil
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func testCard() *models.CreditCard {
+	card := models.CreditCardDefaults()
+	card.ID = uuid.New()
+	card.TenantID = uuid.New()
+	card.Status = models.CreditCardStatusActive
+	card.CreditLimit = decimal.NewFromInt(5000)
+	card.AvailableCredit = decimal.NewFromInt(5000)
+	card.CashbackEnabled = true
+	card.CashbackRedemptionMin = decimal.NewFromInt(1)
+	return &card
+}
+
+func cashbackRows(card *models.CreditCard, available string) map[string]fakeRow {
+	now := time.Now()
+	return map[string]fakeRow{
+		"FROM credit_cards WHERE id": {
+			columns: strings.Fields(`
+				id tenant_id cardholder_name credit_limit available_credit
+				purchase_apr cash_advance_apr penalty_apr introductory_apr
+				introductory_end_date annual_fee late_payment_fee failed_payment_fee
+				international_fee_rate cash_advance_fee cash_advance_fee_rate over_limit_fee
+				balance_transfer_apr balance_transfer_end_date
+				balance_transfer_fee balance_transfer_fee_rate
+				billing_cycle_type billing_cycle_day payment_due_days grace_period_days
+				minimum_payment_percent minimum_payment_amount
+				cashback_enabled cashback_rate cashback_redemption_min
+				status last_statement_date next_statement_date
+				last_payment_date last_payment_amount consecutive_late_count
+				days_past_due delinquency_bucket charged_off_at
+				created_at updated_at closed_at`),
+			values: []driver.Value{
+				card.ID.String(), card.TenantID.String(), card.CardholderName,
+				card.CreditLimit.String(), card.AvailableCredit.String(),
+				card.PurchaseAPR.String(), card.CashAdvanceAPR.String(),
+				card.PenaltyAPR.String(), card.IntroductoryAPR.String(),
+				nil, card.AnnualFee.String(), card.LatePaymentFee.String(), card.FailedPaymentFee.String(),
+				card.InternationalFeeRate.String(), card.CashAdvanceFee.String(),
+				card.CashAdvanceFeeRate.String(), card.OverLimitFee.String(),
+				card.BalanceTransferAPR.String(), nil,
+				card.BalanceTransferFee.String(), card.BalanceTransferFeeRate.String(),
+				string(card.BillingCycleType), int64(card.BillingCycleDay),
+				int64(card.PaymentDueDays), int64(card.GracePeriodDays),
+				card.MinimumPaymentPercent.String(), card.MinimumPaymentAmount.String(),
+				card.CashbackEnabled, "1.5", card.CashbackRedemptionMin.String(),
+				string(card.Status), nil, nil,
+				nil, "0", int64(0),
+				int64(0), string(models.DelinquencyBucketCurrent), nil,
+				now, now, nil,
+			},
+		},
+		"FROM cashback_ledger_entries": {
+			columns: []string{"id", "tenant_id", "credit_card_id", "statement_entry_id", "entry_type",
+				"entry_date", "amount", "description", "reference_id", "transaction_amount",
+				"cashback_rate", "category_bonus", "metadata", "created_at", "created_by",
+				"sequence", "prev_hash", "hash"},
+			values: []driver.Value{uuid.New().String(), card.TenantID.String(), card.ID.String(), nil,
+				string(models.CashbackEarned), now, available, "Earned", nil, nil,
+				nil, nil, nil, now, nil,
+				int64(1), "", ""},
+		},
+	}
+}
+
+func TestCreditCardService_RecordTransactionAtomicity(t *testing.T) {
+	tests := []struct {
+		name    string
+		failOn  string
+		wantErr bool
+	}{
+		{name: "Cashback insert fails", failOn: "cashback_ledger_entries", wantErr: true},
+		{name: "Statement entry insert fails", failOn: "statement_ledger_entries", wantErr: true},
+		{name: "Available credit update fails", failOn: "credit_cards", wantErr: true},
+		{name: "All writes succeed", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := testCard()
+			store := &fakeStore{failOn: tt.failOn, rows: cashbackRows(card, "0")}
+			service := NewCreditCardService(newFakeDB(t, store))
+
+			_, err := service.RecordTransaction(context.Background(), CCTransactionRequest{
+				CreditCard:      card,
+				Amount:          decimal.NewFromInt(100),
+				Description:     "Test purchase",
+				MerchantName:    "Test Merchant",
+				TransactionDate: time.Now(),
+				PostingDate:     time.Now(),
+				ReferenceID:     "TXN-1",
+				IsInternational: true,
+				CountryCode:     "FR",
+				CurrencyCode:    "EUR",
+				ExchangeRate:    decimal.NewFromFloat(1.1),
+			})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error, got nil")
+				}
+				if got := store.totalCommitted(); got != 0 {
+					t.Errorf("expected no committed rows after failure, got %d (%v)", got, store.committed)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				// transaction + international fee, cashback, available credit
+				if store.committed["statement_ledger_entries"] != 2 ||
+					store.committed["cashback_ledger_entries"] != 1 ||
+					store.committed["credit_cards"] != 1 {
+					t.Errorf("unexpected committed rows: %v", store.committed)
+				}
+			}
+
+			if store.autocommit != 0 {
+				t.Errorf("expected every write inside a transaction, got %d autocommitted", store.autocommit)
+			}
+		})
+	}
+}
+
+func TestCashbackService_RedeemCashbackAtomicity(t *testing.T) {
+	card := testCard()
+	store := &fakeStore{failOn: "statement_ledger_entries", rows: cashbackRows(card, "50")}
+	service := NewCashbackService(newFakeDB(t, store))
+
+	_, _, err := service.RedeemCashback(context.Background(), RedeemCashbackRequest{
+		TenantID:       card.TenantID,
+		CreditCard:     card,
+		Amount:         decimal.NewFromInt(25),
+		RedemptionDate: time.Now(),
+		RedeemAs:       "statement_credit",
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if got := store.totalCommitted(); got != 0 {
+		t.Errorf("expected cashback redemption to be rolled back, got %v", store.committed)
+	}
+}
+
+func TestLedgerReconciliationService_RecordTransactionAtomicity(t *testing.T) {
+	store := &fakeStore{failOn: "points_ledger_entries"}
+	service := NewLedgerReconciliationService(newFakeDB(t, store), models.PointsEarningRule{
+		PointsPerDollar: decimal.NewFromInt(1),
+	})
+
+	_, _, err := service.RecordTransaction(context.Background(), TransactionRequest{
+		TenantID:        uuid.New(),
+		Amount:          decimal.NewFromInt(100),
+		Description:     "Test purchase",
+		ReferenceID:     "TXN-1",
+		TransactionDate: time.Now(),
+		PostingDate:     time.Now(),
+		EarnPoints:      true,
+	})
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+	if got := store.totalCommitted(); got != 0 {
+		t.Errorf("expected statement entry to be rolled back, got %v", store.committed)
+	}
+}
+
+func TestServiceJoinsCallerTransaction(t *testing.T) {
+	card := testCard()
+	store := &fakeStore{rows: cashbackRows(card, "0")}
+	db := newFakeDB(t, store)
+	ctx := context.Background()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %v", err)
+	}
+
+	service := NewCreditCardService(tx)
+	if _, err := service.RecordTransaction(ctx, CCTransactionRequest{
+		CreditCard:      card,
+		Amount:          decimal.NewFromInt(100),
+		Description:     "Test purchase",
+		MerchantName:    "Test Merchant",
+		TransactionDate: time.Now(),
+		PostingDate:     time.Now(),
+		ReferenceID:     "TXN-1",
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The service must not commit a transaction it does not own
+	if got := store.totalCommitted(); got != 0 {
+		t.Fatalf("expected nothing committed before caller commits, got %v", store.committed)
+	}
+
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("failed to roll back: %v", err)
+	}
+	if got := store.totalCommitted(); got != 0 {
+		t.Errorf("expected caller rollback to discard writes, got %v", store.committed)
+	}
+}
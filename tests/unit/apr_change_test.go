@@ -0,0 +1,79 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/shopspring/decimal"
+)
+
+func TestCreditCardAPROn(t *testing.T) {
+	march := time.Date(2026, time.March, 16, 0, 0, 0, 0, time.UTC)
+	card := &models.CreditCard{
+		PurchaseAPR:    decimal.NewFromFloat(19.99),
+		CashAdvanceAPR: decimal.NewFromFloat(24.99),
+		PenaltyAPR:     decimal.NewFromFloat(29.99),
+		APRSchedule: []models.APRChange{
+			{APRType: models.APRTypePurchase, PreviousAPR: decimal.NewFromFloat(17.99), APR: decimal.NewFromFloat(19.99), EffectiveDate: march},
+			{APRType: models.APRTypePurchase, PreviousAPR: decimal.NewFromFloat(19.99), APR: decimal.NewFromFloat(22.99), EffectiveDate: march.AddDate(0, 2, 0)},
+			{APRType: models.APRTypePurchase, PreviousAPR: decimal.NewFromFloat(22.99), APR: decimal.NewFromFloat(21.99), EffectiveDate: march.AddDate(0, 2, 0)},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		aprType  models.APRType
+		on       time.Time
+		expected decimal.Decimal
+	}{
+		{name: "before the first change", aprType: models.APRTypePurchase, on: march.AddDate(0, 0, -1), expected: decimal.NewFromFloat(17.99)},
+		{name: "on the effective date", aprType: models.APRTypePurchase, on: march.Add(15 * time.Hour), expected: decimal.NewFromFloat(19.99)},
+		{name: "later change on the same date wins", aprType: models.APRTypePurchase, on: march.AddDate(1, 0, 0), expected: decimal.NewFromFloat(21.99)},
+		{name: "no history uses the card rate", aprType: models.APRTypeCashAdvance, on: march, expected: decimal.NewFromFloat(24.99)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := card.APROn(tt.aprType, tt.on); !got.Equal(tt.expected) {
+				t.Errorf("APROn() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+
+	// Delinquency alone does not reprice the card
+	card.Status = models.CreditCardStatusDelinquent
+	if got := card.GetEffectiveAPR(march); !got.Equal(decimal.NewFromFloat(19.99)) {
+		t.Errorf("GetEffectiveAPR() while delinquent = %s, want 19.99", got)
+	}
+	if got := card.SegmentAPR(models.SegmentPurchase, march); !got.Equal(decimal.NewFromFloat(19.99)) {
+		t.Errorf("SegmentAPR() while delinquent = %s, want 19.99", got)
+	}
+
+	// The penalty APR applies once it is scheduled
+	card.APRSchedule = append(card.APRSchedule, models.APRChange{
+		APRType: models.APRTypePurchase, PreviousAPR: decimal.NewFromFloat(21.99), APR: card.PenaltyAPR,
+		EffectiveDate: march.AddDate(1, 0, 0),
+	})
+	if got := card.GetEffectiveAPR(march.AddDate(1, 0, 0)); !got.Equal(decimal.NewFromFloat(29.99)) {
+		t.Errorf("GetEffectiveAPR() after repricing = %s, want 29.99", got)
+	}
+}
+
+func TestNewChangeInTermsNotice(t *testing.T) {
+	change := &models.APRChange{
+		APRType:       models.APRTypeCashAdvance,
+		PreviousAPR:   decimal.NewFromFloat(24.99),
+		APR:           decimal.NewFromFloat(27.5),
+		EffectiveDate: time.Date(2026, time.May, 1, 0, 0, 0, 0, time.UTC),
+	}
+	notice := models.NewChangeInTermsNotice(change, time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC))
+
+	expected := "Beginning May 1, 2026, the APR for cash advances will increase from 24.99% to 27.50%."
+	if notice.Summary != expected {
+		t.Errorf("Summary = %q, want %q", notice.Summary, expected)
+	}
+	if !notice.CurrentAPR.Equal(change.PreviousAPR) || !notice.NewAPR.Equal(change.APR) {
+		t.Errorf("notice rates = %s to %s, want %s to %s", notice.CurrentAPR, notice.NewAPR, change.PreviousAPR, change.APR)
+	}
+}
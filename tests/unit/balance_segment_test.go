@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/shopspring/decimal"
+)
+
+func TestAllocatePayment(t *testing.T) {
+	aprs := map[models.BalanceSegment]decimal.Decimal{
+		models.SegmentPurchase:        decimal.NewFromFloat(19.99),
+		models.SegmentPromo:           decimal.Zero,
+		models.SegmentBalanceTransfer: decimal.NewFromFloat(3.99),
+		models.SegmentCashAdvance:     decimal.NewFromFloat(24.99),
+	}
+	balances := models.SegmentBalances{
+		models.SegmentPurchase:        decimal.NewFromInt(1000),
+		models.SegmentPromo:           decimal.NewFromInt(300),
+		models.SegmentBalanceTransfer: decimal.NewFromInt(2000),
+		models.SegmentCashAdvance:     decimal.NewFromInt(200),
+	}
+
+	tests := []struct {
+		name       string
+		amount     decimal.Decimal
+		minimumDue decimal.Decimal
+		want       models.SegmentAllocation
+	}{
+		{
+			name:       "Minimum payment goes to the lowest APR",
+			amount:     decimal.NewFromInt(50),
+			minimumDue: decimal.NewFromInt(75),
+			want: models.SegmentAllocation{
+				models.SegmentPromo: decimal.NewFromInt(50),
+			},
+		},
+		{
+			name:       "Excess goes to the highest APR first",
+			amount:     decimal.NewFromInt(575),
+			minimumDue: decimal.NewFromInt(75),
+			want: models.SegmentAllocation{
+				models.SegmentPromo:       decimal.NewFromInt(75),
+				models.SegmentCashAdvance: decimal.NewFromInt(200),
+				models.SegmentPurchase:    decimal.NewFromInt(300),
+			},
+		},
+		{
+			name:       "Overpayment becomes a purchase credit",
+			amount:     decimal.NewFromInt(3600),
+			minimumDue: decimal.Zero,
+			want: models.SegmentAllocation{
+				models.SegmentCashAdvance:     decimal.NewFromInt(200),
+				models.SegmentPurchase:        decimal.NewFromInt(1100),
+				models.SegmentBalanceTransfer: decimal.NewFromInt(2000),
+				models.SegmentPromo:           decimal.NewFromInt(300),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := models.AllocatePayment(balances, aprs, tt.amount, tt.minimumDue)
+			if len(got) != len(tt.want) {
+				t.Errorf("allocation = %v, want %v", got, tt.want)
+			}
+			for segment, amount := range tt.want {
+				if !got[segment].Equal(amount) {
+					t.Errorf("%s = %s, want %s", segment, got[segment], amount)
+				}
+			}
+			if !got.Total().Equal(tt.amount) {
+				t.Errorf("allocated %s of %s", got.Total(), tt.amount)
+			}
+
+			// The allocation survives a round trip through entry metadata
+			decoded, ok := models.SegmentAllocationFromMetadata(map[string]interface{}{
+				models.AllocationMetadataKey: got.Metadata(),
+			})
+			if !ok || !decoded.Total().Equal(tt.amount) {
+				t.Errorf("decoded allocation = %v", decoded)
+			}
+		})
+	}
+
+	if !balances[models.SegmentPurchase].Equal(decimal.NewFromInt(1000)) {
+		t.Error("allocating a payment changed the balances it was given")
+	}
+}
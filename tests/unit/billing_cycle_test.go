@@ -217,6 +217,41 @@ func TestBillingCycleIsPaidInFull(t *testing.T) {
 	}
 }
 
+func TestBillingCycleMinimumPaidOnTime(t *testing.T) {
+	dueDate := time.Date(2026, 3, 25, 0, 0, 0, 0, time.UTC)
+	onDueDate := dueDate.Add(18 * time.Hour)
+	dayAfter := dueDate.AddDate(0, 0, 1)
+
+	tests := []struct {
+		name      string
+		met       bool
+		paidOn    *time.Time
+		status    models.BillingCycleStatus
+		expected  bool
+	}{
+		{"not met", false, &onDueDate, models.BillingCycleStatusClosed, false},
+		{"paid on the due date", true, &onDueDate, models.BillingCycleStatusPaid, true},
+		{"paid the day after", true, &dayAfter, models.BillingCycleStatusPaid, false},
+		{"past due", true, &onDueDate, models.BillingCycleStatusPastDue, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cycle := &models.BillingCycle{
+				DueDate:           dueDate,
+				MinimumPaymentMet: tt.met,
+				LastPaymentDate:   tt.paidOn,
+				Status:            tt.status,
+			}
+
+			result := cycle.MinimumPaidOnTime()
+			if result != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, result)
+			}
+		})
+	}
+}
+
 func TestCalculateAverageDailyBalance(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -356,3 +391,13 @@ func TestBillingCycleBuilder(t *testing.T) {
 		t.Errorf("Expected 30 days in cycle, got %d", cycle.DaysInCycle)
 	}
 }
+
+func TestDateOf(t *testing.T) {
+	// Late evening in New York is already the next day in UTC
+	newYork := time.FixedZone("EST", -5*60*60)
+	got := models.DateOf(time.Date(2024, 1, 15, 22, 30, 0, 0, newYork))
+	want := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("DateOf = %v, want %v", got, want)
+	}
+}
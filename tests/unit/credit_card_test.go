@@ -147,7 +147,7 @@ func TestCreditCardCanTransact(t *testing.T) {
 		{"active card", models.CreditCardStatusActive, nil},
 		{"frozen card", models.CreditCardStatusFrozen, models.ErrCardFrozen},
 		{"closed card", models.CreditCardStatusClosed, models.ErrCardClosed},
-		{"delinquent card", models.CreditCardStatusDelinquent, nil}, // Can still transact, just at penalty APR
+		{"delinquent card", models.CreditCardStatusDelinquent, nil}, // Can still transact
 	}
 
 	for _, tt := range tests {
@@ -210,11 +210,11 @@ func TestCreditCardGetEffectiveAPR(t *testing.T) {
 			expectedAPR: decimal.NewFromFloat(19.99),
 		},
 		{
-			name:        "delinquent - penalty APR",
+			name:        "delinquent, not repriced - purchase APR",
 			status:      models.CreditCardStatusDelinquent,
 			purchaseAPR: decimal.NewFromFloat(19.99),
 			penaltyAPR:  decimal.NewFromFloat(29.99),
-			expectedAPR: decimal.NewFromFloat(29.99),
+			expectedAPR: decimal.NewFromFloat(19.99),
 		},
 		{
 			name:         "active intro - intro APR",
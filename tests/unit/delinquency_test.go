@@ -0,0 +1,46 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+func TestDelinquencyBucketFor(t *testing.T) {
+	tests := []struct {
+		days     int
+		expected models.DelinquencyBucket
+	}{
+		{days: 0, expected: models.DelinquencyBucketCurrent},
+		{days: 1, expected: models.DelinquencyBucket1To29},
+		{days: 29, expected: models.DelinquencyBucket1To29},
+		{days: 30, expected: models.DelinquencyBucket30To59},
+		{days: 59, expected: models.DelinquencyBucket30To59},
+		{days: 60, expected: models.DelinquencyBucket60To89},
+		{days: 90, expected: models.DelinquencyBucket90To119},
+		{days: 120, expected: models.DelinquencyBucket120To179},
+		{days: 179, expected: models.DelinquencyBucket120To179},
+		{days: 180, expected: models.DelinquencyBucket180Plus},
+		{days: 400, expected: models.DelinquencyBucket180Plus},
+	}
+
+	for _, tt := range tests {
+		if got := models.DelinquencyBucketFor(tt.days); got != tt.expected {
+			t.Errorf("DelinquencyBucketFor(%d) = %s, want %s", tt.days, got, tt.expected)
+		}
+	}
+}
+
+func TestCreditCardIsChargedOff(t *testing.T) {
+	card := &models.CreditCard{Status: models.CreditCardStatusFrozen}
+	if card.IsChargedOff() {
+		t.Error("frozen card reported as charged off")
+	}
+
+	chargedOffAt := time.Date(2026, time.July, 24, 0, 0, 0, 0, time.UTC)
+	card.ChargedOffAt = &chargedOffAt
+	if !card.IsChargedOff() {
+		t.Error("card with a charge-off date not reported as charged off")
+	}
+}
@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+)
+
+func TestDisputeStatusTransitions(t *testing.T) {
+	tests := []struct {
+		name        string
+		fromStatus  models.DisputeStatus
+		toStatus    models.DisputeStatus
+		shouldAllow bool
+	}{
+		{"open to provisional credit", models.DisputeStatusOpen, models.DisputeStatusProvisionalCredit, true},
+		{"open to chargeback", models.DisputeStatusOpen, models.DisputeStatusChargeback, true},
+		{"open to resolved", models.DisputeStatusOpen, models.DisputeStatusResolvedMerchant, true},
+		{"provisional credit to chargeback", models.DisputeStatusProvisionalCredit, models.DisputeStatusChargeback, true},
+		{"provisional credit twice", models.DisputeStatusProvisionalCredit, models.DisputeStatusProvisionalCredit, false},
+		{"chargeback to provisional credit", models.DisputeStatusChargeback, models.DisputeStatusProvisionalCredit, false},
+		{"chargeback to resolved", models.DisputeStatusChargeback, models.DisputeStatusResolvedCardholder, true},
+		{"resolved for cardholder to any", models.DisputeStatusResolvedCardholder, models.DisputeStatusOpen, false},
+		{"resolved for merchant to any", models.DisputeStatusResolvedMerchant, models.DisputeStatusResolvedCardholder, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispute := &models.Dispute{Status: tt.fromStatus}
+			result := dispute.CanTransitionTo(tt.toStatus)
+			if result != tt.shouldAllow {
+				t.Errorf("Expected CanTransitionTo(%s) = %v, got %v", tt.toStatus, tt.shouldAllow, result)
+			}
+		})
+	}
+}
+
+func TestDisputeRegZTimeline(t *testing.T) {
+	card := models.CreditCardDefaults() // Monthly cycles starting on the 1st
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	statement := date(time.March, 1)
+
+	tests := []struct {
+		name              string
+		statementDate     *time.Time
+		notice            time.Time
+		wantCovered       bool
+		wantAcknowledgeBy time.Time
+		wantResolveBy     time.Time
+	}{
+		{
+			name:              "Two complete cycles after the notice",
+			statementDate:     &statement,
+			notice:            date(time.March, 10),
+			wantCovered:       true,
+			wantAcknowledgeBy: date(time.April, 9),
+			wantResolveBy:     date(time.May, 31),
+		},
+		{
+			name:              "Notice on the first day of a cycle",
+			statementDate:     &statement,
+			notice:            date(time.April, 1),
+			wantCovered:       true,
+			wantAcknowledgeBy: date(time.May, 1),
+			wantResolveBy:     date(time.May, 31),
+		},
+		{
+			name:              "Notice after the 60 day window",
+			statementDate:     &statement,
+			notice:            date(time.May, 1),
+			wantCovered:       false,
+			wantAcknowledgeBy: date(time.May, 31),
+			wantResolveBy:     date(time.June, 30),
+		},
+		{
+			name:              "Charge not yet billed",
+			notice:            date(time.March, 10),
+			wantCovered:       true,
+			wantAcknowledgeBy: date(time.April, 9),
+			wantResolveBy:     date(time.May, 31),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispute := &models.Dispute{
+				Status:           models.DisputeStatusOpen,
+				StatementDate:    tt.statementDate,
+				NoticeReceivedAt: tt.notice.Add(15 * time.Hour),
+			}
+			dispute.SetRegZTimeline(&card)
+
+			if dispute.RegZCovered != tt.wantCovered {
+				t.Errorf("covered = %v, want %v", dispute.RegZCovered, tt.wantCovered)
+			}
+			if !dispute.AcknowledgeBy.Equal(tt.wantAcknowledgeBy) {
+				t.Errorf("acknowledge by = %s, want %s", dispute.AcknowledgeBy, tt.wantAcknowledgeBy)
+			}
+			if !dispute.ResolveBy.Equal(tt.wantResolveBy) {
+				t.Errorf("resolve by = %s, want %s", dispute.ResolveBy, tt.wantResolveBy)
+			}
+
+			overdue := dispute.ResolveBy.AddDate(0, 0, 1)
+			if dispute.IsResolutionOverdue(overdue) != tt.wantCovered {
+				t.Errorf("resolution overdue = %v, want %v", !tt.wantCovered, tt.wantCovered)
+			}
+		})
+	}
+}
+
+func TestDisputeInterestSuspendedOn(t *testing.T) {
+	notice := time.Date(2026, time.March, 10, 15, 0, 0, 0, time.UTC)
+	credited := time.Date(2026, time.March, 20, 9, 0, 0, 0, time.UTC)
+	dispute := &models.Dispute{NoticeReceivedAt: notice, ProvisionalCreditAt: &credited}
+
+	tests := []struct {
+		day  int
+		want bool
+	}{
+		{9, false},
+		{10, true},
+		{19, true},
+		{20, false}, // The provisional credit now carries the amount
+	}
+
+	for _, tt := range tests {
+		day := time.Date(2026, time.March, tt.day, 0, 0, 0, 0, time.UTC)
+		if got := dispute.InterestSuspendedOn(day); got != tt.want {
+			t.Errorf("InterestSuspendedOn(March %d) = %v, want %v", tt.day, got, tt.want)
+		}
+	}
+}
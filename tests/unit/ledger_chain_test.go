@@ -0,0 +1,107 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/shopspring/decimal"
+)
+
+// TestStatementEntryHashSurvivesStorage checks that an entry read back from
+// Postgres hashes the same as the entry that was sealed before the insert
+func TestStatementEntryHashSurvivesStorage(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	entryDate := time.Date(2026, time.January, 10, 18, 30, 0, 123456000, newYork)
+
+	written := &models.StatementLedgerEntry{
+		ID:          uuid.New(),
+		TenantID:    uuid.New(),
+		EntryType:   models.EntryTypeTransaction,
+		EntryDate:   entryDate,
+		PostingDate: entryDate,
+		Amount:      decimal.NewFromInt(125),
+		Description: "Dinner",
+		Metadata: map[string]interface{}{
+			"merchant_name": "Test Merchant",
+			"installments":  3,
+			"exchange_rate": decimal.RequireFromString("1.10"),
+		},
+	}
+	written.Seal(7, "abc123")
+
+	// Simulate the round trip: JSONB metadata, DECIMAL(15,2), DATE and TIMESTAMPTZ in UTC
+	read := *written
+	raw, _ := json.Marshal(written.Metadata)
+	read.Metadata = nil
+	if err := json.Unmarshal(raw, &read.Metadata); err != nil {
+		t.Fatalf("failed to decode metadata: %v", err)
+	}
+	read.Amount = decimal.RequireFromString("125.00")
+	read.EntryDate = entryDate.UTC()
+	read.PostingDate = time.Date(2026, time.January, 10, 0, 0, 0, 0, time.UTC)
+	read.Status = models.EntryStatusCleared
+	clearedAt := time.Now()
+	read.ClearedAt = &clearedAt
+
+	if got := read.ComputeHash(); got != written.Hash {
+		t.Errorf("hash after round trip = %s, want %s", got, written.Hash)
+	}
+
+	read.Description = "Lunch"
+	if read.ComputeHash() == written.Hash {
+		t.Error("changing the description did not change the hash")
+	}
+}
+
+func TestLedgerEntrySeal(t *testing.T) {
+	cashback := &models.CashbackLedgerEntry{
+		ID:           uuid.New(),
+		TenantID:     uuid.New(),
+		CreditCardID: uuid.New(),
+		EntryType:    models.CashbackEarned,
+		EntryDate:    time.Now(),
+		Amount:       decimal.NewFromFloat(1.5),
+		Description:  "Cashback earned",
+	}
+	cashback.Seal(1, "")
+
+	points := &models.PointsLedgerEntry{
+		ID:          uuid.New(),
+		TenantID:    cashback.TenantID,
+		EntryType:   models.PointsEarnedTransaction,
+		EntryDate:   time.Now(),
+		Points:      150,
+		Description: "Points earned",
+	}
+	points.Seal(2, cashback.Hash)
+
+	tests := []struct {
+		name string
+		link models.ChainLink
+		hash string
+	}{
+		{name: "Cashback entry", link: cashback.ChainLink, hash: cashback.ComputeHash()},
+		{name: "Points entry", link: points.ChainLink, hash: points.ComputeHash()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.link.IsSealed() || len(tt.link.Hash) != 64 {
+				t.Fatalf("hash = %q, want a SHA-256 hex digest", tt.link.Hash)
+			}
+			if tt.link.Hash != tt.hash {
+				t.Errorf("sealed hash %s does not match recomputed %s", tt.link.Hash, tt.hash)
+			}
+		})
+	}
+
+	if points.PrevHash != cashback.Hash {
+		t.Error("sealing did not record the previous hash")
+	}
+}
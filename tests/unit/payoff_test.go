@@ -0,0 +1,44 @@
+package unit
+
+import (
+	"testing"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/shopspring/decimal"
+)
+
+func TestPayoffScenarioPayoffYears(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario models.PayoffScenario
+		expected string
+	}{
+		{name: "under six months", scenario: models.PayoffScenario{Months: 5}, expected: "less than 1 year"},
+		{name: "rounds up to one year", scenario: models.PayoffScenario{Months: 6}, expected: "1 year"},
+		{name: "three years", scenario: models.PayoffScenario{Months: 36}, expected: "3 years"},
+		{name: "rounds to nearest year", scenario: models.PayoffScenario{Months: 173}, expected: "14 years"},
+		{name: "never", scenario: models.PayoffScenario{NeverPaysOff: true}, expected: "never"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.scenario.PayoffYears(); got != tt.expected {
+				t.Errorf("PayoffYears() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPayoffProjectionSavings(t *testing.T) {
+	projection := &models.PayoffProjection{
+		MinimumOnly: models.PayoffScenario{TotalCost: decimal.NewFromInt(4194)},
+	}
+	if !projection.Savings().IsZero() {
+		t.Errorf("Savings() without a 36-month plan = %s, want 0", projection.Savings())
+	}
+
+	projection.ThirtySixMonth = &models.PayoffScenario{TotalCost: decimal.NewFromInt(2021)}
+	if !projection.Savings().Equal(decimal.NewFromInt(2173)) {
+		t.Errorf("Savings() = %s, want 2173", projection.Savings())
+	}
+}
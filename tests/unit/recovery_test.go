@@ -0,0 +1,82 @@
+package unit
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/livefire2015/ez-ledger/src/models"
+	"github.com/shopspring/decimal"
+)
+
+func TestChargeOffApplyRecovery(t *testing.T) {
+	chargeOff := &models.ChargeOff{
+		Principal:          decimal.NewFromInt(500),
+		Interest:           decimal.NewFromInt(60),
+		Fees:               decimal.NewFromInt(40),
+		PrincipalRemaining: decimal.NewFromInt(500),
+		InterestRemaining:  decimal.NewFromInt(60),
+		FeesRemaining:      decimal.NewFromInt(40),
+		Recovered:          decimal.Zero,
+	}
+
+	tests := []struct {
+		name                      string
+		amount                    int64
+		principal, interest, fees int64
+		wantErr                   error
+	}{
+		{name: "principal first", amount: 450, principal: 450},
+		{name: "then interest", amount: 80, principal: 50, interest: 30},
+		{name: "then fees", amount: 50, interest: 30, fees: 20},
+		{name: "more than remains", amount: 21, wantErr: models.ErrRecoveryExceedsBalance},
+		{name: "the rest", amount: 20, fees: 20},
+	}
+
+	for _, tt := range tests {
+		recovery := &models.Recovery{Amount: decimal.NewFromInt(tt.amount)}
+		err := chargeOff.ApplyRecovery(recovery)
+		if !errors.Is(err, tt.wantErr) {
+			t.Fatalf("%s: error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if !recovery.PrincipalAmount.Equal(decimal.NewFromInt(tt.principal)) ||
+			!recovery.InterestAmount.Equal(decimal.NewFromInt(tt.interest)) ||
+			!recovery.FeesAmount.Equal(decimal.NewFromInt(tt.fees)) {
+			t.Errorf("%s: split = %s/%s/%s, want %d/%d/%d", tt.name, recovery.PrincipalAmount,
+				recovery.InterestAmount, recovery.FeesAmount, tt.principal, tt.interest, tt.fees)
+		}
+	}
+
+	if !chargeOff.Remaining().IsZero() || !chargeOff.Recovered.Equal(chargeOff.Amount()) {
+		t.Errorf("remaining = %s, recovered = %s; want 0, %s",
+			chargeOff.Remaining(), chargeOff.Recovered, chargeOff.Amount())
+	}
+}
+
+func TestRecoverySplitCommission(t *testing.T) {
+	tests := []struct {
+		amount     string
+		rate       string
+		commission string
+		net        string
+	}{
+		{amount: "100.00", rate: "0", commission: "0", net: "100.00"},
+		{amount: "100.00", rate: "25", commission: "25.00", net: "75.00"},
+		{amount: "33.33", rate: "33.5", commission: "11.17", net: "22.16"},
+	}
+
+	for _, tt := range tests {
+		recovery := &models.Recovery{
+			Amount:         decimal.RequireFromString(tt.amount),
+			CommissionRate: decimal.RequireFromString(tt.rate),
+		}
+		recovery.SplitCommission()
+		if !recovery.Commission.Equal(decimal.RequireFromString(tt.commission)) ||
+			!recovery.NetAmount.Equal(decimal.RequireFromString(tt.net)) {
+			t.Errorf("%s at %s%%: commission = %s, net = %s; want %s, %s", tt.amount, tt.rate,
+				recovery.Commission, recovery.NetAmount, tt.commission, tt.net)
+		}
+	}
+}